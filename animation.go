@@ -12,6 +12,9 @@ const (
 	TrackTypePosition = "Pos"
 	TrackTypeScale    = "Sca"
 	TrackTypeRotation = "Rot"
+	TrackTypeVisible  = "Vis"  // Animates the visibility of a Node; its keyframes are bools.
+	TrackTypeColor    = "Col"  // Animates a Material's Color; only used in the MaterialChannels of an Animation.
+	TrackTypeProperty = "Prop" // Animates a numeric property of a Material; only used in the MaterialChannels of an Animation.
 )
 
 const (
@@ -32,6 +35,18 @@ func (data *Data) AsQuaternion() Quaternion {
 	return data.contents.(Quaternion)
 }
 
+func (data *Data) AsBool() bool {
+	return data.contents.(bool)
+}
+
+func (data *Data) AsColor() Color {
+	return data.contents.(Color)
+}
+
+func (data *Data) AsFloat() float32 {
+	return data.contents.(float32)
+}
+
 // Keyframe represents a single keyframe in an animation for an AnimationTrack.
 type Keyframe struct {
 	Time float32
@@ -45,9 +60,11 @@ func newKeyframe(time float32, data Data) *Keyframe {
 	}
 }
 
-// AnimationTrack represents a collection of keyframes that drive an animation type (position, scale or rotation) for a node in an animation.
+// AnimationTrack represents a collection of keyframes that drive an animation type (position, scale or rotation) for a node in an animation,
+// or the color or a property of a Material.
 type AnimationTrack struct {
 	Type          string
+	Property      string // The name of the Material property the track animates, if it's a TrackTypeProperty track.
 	Keyframes     []*Keyframe
 	Interpolation int
 }
@@ -163,6 +180,85 @@ func (track *AnimationTrack) ValueAsQuaternion(time float32) (Quaternion, bool)
 
 }
 
+// keyframesAround returns the keyframes on either side of the given time, along with how far the time is between them (from 0 to 1).
+// If the time is outside of the track, or the track uses constant interpolation, both keyframes are the same.
+func (track *AnimationTrack) keyframesAround(time float32) (*Keyframe, *Keyframe, float32) {
+
+	if first := track.Keyframes[0]; time <= first.Time {
+		return first, first, 0
+	} else if last := track.Keyframes[len(track.Keyframes)-1]; time >= last.Time {
+		return last, last, 0
+	}
+
+	var first *Keyframe
+	var last *Keyframe
+
+	for _, k := range track.Keyframes {
+
+		if k.Time < time {
+			first = k
+		} else {
+			last = k
+			break
+		}
+
+	}
+
+	if time == last.Time {
+		return last, last, 0
+	} else if track.Interpolation == InterpolationConstant {
+		return first, first, 0
+	}
+
+	return first, last, (time - first.Time) / (last.Time - first.Time)
+
+}
+
+// ValueAsBool returns the boolean associated with this AnimationTrack using the given time in seconds, and a boolean indicating if
+// the value exists (i.e. if this track has visibility animation data). Boolean values aren't interpolated; the value of the keyframe
+// at or before the given time is returned.
+func (track *AnimationTrack) ValueAsBool(time float32) (bool, bool) {
+
+	if len(track.Keyframes) == 0 {
+		return false, false
+	}
+
+	first, _, _ := track.keyframesAround(time)
+
+	return first.Data.AsBool(), true
+
+}
+
+// ValueAsColor returns the Color associated with this AnimationTrack using the given time in seconds,
+// and a boolean indicating if the Color exists (i.e. if this track has color animation data).
+func (track *AnimationTrack) ValueAsColor(time float32) (Color, bool) {
+
+	if len(track.Keyframes) == 0 {
+		return Color{}, false
+	}
+
+	first, last, t := track.keyframesAround(time)
+
+	return first.Data.AsColor().Lerp(last.Data.AsColor(), t), true
+
+}
+
+// ValueAsFloat returns the numeric value associated with this AnimationTrack using the given time in seconds,
+// and a boolean indicating if the value exists (i.e. if this track has property animation data).
+func (track *AnimationTrack) ValueAsFloat(time float32) (float32, bool) {
+
+	if len(track.Keyframes) == 0 {
+		return 0, false
+	}
+
+	first, last, t := track.keyframesAround(time)
+
+	fv := first.Data.AsFloat()
+
+	return fv + (last.Data.AsFloat()-fv)*t, true
+
+}
+
 func newAnimationTrack(trackType string) *AnimationTrack {
 	return &AnimationTrack{
 		Type:      trackType,
@@ -170,10 +266,28 @@ func newAnimationTrack(trackType string) *AnimationTrack {
 	}
 }
 
+// AnimationDelta is an offset applied on top of an AnimationChannel's animated transform, similar to delta transforms in Blender.
+// This allows multiple objects to share an Animation while moving from different positions, rotations, or scales.
+type AnimationDelta struct {
+	Position Vector3    // Added to the animated position.
+	Rotation Quaternion // Applied on top of the animated rotation.
+	Scale    Vector3    // Multiplied with the animated scale.
+}
+
+// NewAnimationDelta creates a new AnimationDelta that doesn't offset the animated transform at all.
+func NewAnimationDelta() *AnimationDelta {
+	return &AnimationDelta{
+		Rotation: NewQuaternion(0, 0, 0, 1),
+		Scale:    Vector3{1, 1, 1},
+	}
+}
+
 // AnimationChannel represents a set of tracks (one for position, scale, and rotation) for the various nodes contained within the Animation.
 type AnimationChannel struct {
-	Name                string
-	Tracks              map[string]*AnimationTrack
+	Name   string
+	Tracks map[string]*AnimationTrack
+	// Delta, if set, offsets the animated transform of the channel (i.e. from the delta transforms of an object in Blender).
+	Delta               *AnimationDelta
 	startingPositionSet bool
 	startingPosition    Vector3
 }
@@ -191,6 +305,15 @@ func (channel *AnimationChannel) AddTrack(trackType string) *AnimationTrack {
 	return newTrack
 }
 
+// AddPropertyTrack adds a TrackTypeProperty track to the channel that animates the Material property of the given name.
+// The track's keyframes should be float32 values.
+func (channel *AnimationChannel) AddPropertyTrack(propertyName string) *AnimationTrack {
+	newTrack := newAnimationTrack(TrackTypeProperty)
+	newTrack.Property = propertyName
+	channel.Tracks[TrackTypeProperty+":"+propertyName] = newTrack
+	return newTrack
+}
+
 // Marker represents a tag as placed in an Animation in a 3D modeler.
 type Marker struct {
 	Time float32 // Time of the marker in seconds in the Animation.
@@ -202,10 +325,14 @@ type Animation struct {
 	library *Library
 	Name    string
 	// A Channel represents a set of tracks (one for position, scale, and rotation) for the various nodes contained within the Animation.
-	Channels   map[string]*AnimationChannel
-	Length     float32    // Length of the animation in seconds
-	Markers    []Marker   // Markers as specified in the Animation from the modeler
-	properties Properties // Animation properties
+	Channels map[string]*AnimationChannel
+	// MaterialChannels are channels that animate Materials (rather than Nodes), keyed by the name of the Material they animate.
+	// Their tracks are TrackTypeColor tracks (with Color keyframes) to animate the Material's Color (including its alpha), and TrackTypeProperty tracks
+	// (with float32 keyframes) to animate numeric Material properties (like "emission", which is exported from the Emission Strength of shader nodes in Blender).
+	MaterialChannels map[string]*AnimationChannel
+	Length           float32    // Length of the animation in seconds
+	Markers          []Marker   // Markers as specified in the Animation from the modeler
+	properties       Properties // Animation properties
 
 	// RelativeMotion indicates if an animation's motion happens relative to the object's starting
 	// position.
@@ -220,10 +347,11 @@ type Animation struct {
 // NewAnimation creates a new Animation of the name specified.
 func NewAnimation(name string) *Animation {
 	return &Animation{
-		Name:       name,
-		Channels:   map[string]*AnimationChannel{},
-		Markers:    []Marker{},
-		properties: NewProperties(),
+		Name:             name,
+		Channels:         map[string]*AnimationChannel{},
+		MaterialChannels: map[string]*AnimationChannel{},
+		Markers:          []Marker{},
+		properties:       NewProperties(),
 	}
 }
 
@@ -233,6 +361,13 @@ func (animation *Animation) AddChannel(name string) *AnimationChannel {
 	return newChannel
 }
 
+// AddMaterialChannel adds a channel to the Animation that animates the Materials of the given name.
+func (animation *Animation) AddMaterialChannel(materialName string) *AnimationChannel {
+	newChannel := NewAnimationChannel(materialName)
+	animation.MaterialChannels[materialName] = newChannel
+	return newChannel
+}
+
 // Library returns the Library from which this Animation was loaded. If it was created in code, this function would return nil.
 func (animation *Animation) Library() *Library {
 	return animation.library
@@ -271,13 +406,35 @@ type AnimationValues struct {
 	Rotation              Quaternion
 	RotationExists        bool
 	RotationInterpolation int
+	Visible               bool
+	VisibleExists         bool
 	channel               *AnimationChannel
 }
 
+// AnimationLODLevel represents a level of detail for animation updates. Past a certain distance from the AnimationPlayer's
+// LODReference Node, the AnimationPlayer only updates at the specified rate, rather than every time AnimationPlayer.Update() is called.
+type AnimationLODLevel struct {
+	Distance   float32 // The distance from the LODReference at which this level takes effect.
+	UpdateRate float32 // How many times per second the AnimationPlayer updates at this level. 0 means every time AnimationPlayer.Update() is called.
+}
+
+// DefaultAnimationLODLevels returns a set of sensible default AnimationLODLevels - full rate close by, 15 updates a second
+// past 20 units of distance, and 5 updates a second past 50 units of distance.
+func DefaultAnimationLODLevels() []AnimationLODLevel {
+	return []AnimationLODLevel{
+		{Distance: 20, UpdateRate: 15},
+		{Distance: 50, UpdateRate: 5},
+	}
+}
+
 // AnimationPlayer is an object that allows you to play back an animation on a Node.
 type AnimationPlayer struct {
-	RootNode               INode
+	RootNode INode
+	// DefaultAnimation is the Animation assigned to the RootNode in the 3D modeler (i.e. the active action of the object in Blender), if any.
+	// See AnimationPlayer.PlayDefault().
+	DefaultAnimation       *Animation
 	ChannelsToNodes        map[*AnimationChannel]INode
+	ChannelsToMaterials    map[*AnimationChannel][]*Material // The Materials animated by the MaterialChannels of the current Animation
 	ChannelsUpdated        bool
 	Animation              *Animation
 	Playhead               float32 // Playhead of the animation. Setting this to 0 restarts the animation.
@@ -302,6 +459,60 @@ type AnimationPlayer struct {
 	// The default for PlayLastFrame is false.
 	PlayLastFrame bool
 
+	// Constraints is a slice of constraints (like IK solvers) that are applied, in order, after the AnimationPlayer updates
+	// its animated Nodes with each call to AnimationPlayer.Update(). Constraints are applied even if the player isn't playing
+	// an animation. Note that Constraints aren't copied when an AnimationPlayer is cloned, as they refer to specific Nodes.
+	// Bone constraints imported from Blender (Copy Rotation, Copy Location, Damped Track, Limit Rotation, and IK) are added to
+	// Constraints the first time the player updates, and are recreated for clones.
+	Constraints []IConstraint
+
+	importedConstraints         []*importedConstraint
+	importedConstraintsResolved bool
+
+	// Layers is a slice of AnimationLayers, which play additively, in order, on top of the AnimationPlayer's base Animation.
+	// Use AnimationPlayer.AddLayer() to easily add a layer. Layers are applied before Constraints.
+	Layers        []*AnimationLayer
+	layersApplied map[INode]layerApplication
+
+	// OffscreenCulling, when enabled, makes the AnimationPlayer skip posing the Nodes it animates when none of the skinned Models
+	// under its RootNode were rendered by any Camera since the previous call to AnimationPlayer.Update() (or in the latest frame, if
+	// the game updates more often than it renders). This way, off-screen characters don't pay for posing their bones (or skinning
+	// their vertices). The playhead still advances, markers and finish
+	// callbacks still trigger, and the RootNode (as well as the RootMotionBone, if set) is still animated, so gameplay that relies on
+	// them keeps working. Note that the skeleton will be a frame behind when the Model comes back into view. Defaults to false.
+	OffscreenCulling bool
+
+	// LODReference is the Node (usually the Camera) that the AnimationPlayer's RootNode's distance is measured from to
+	// determine which of the LODLevels applies. If LODReference is nil (the default), the AnimationPlayer updates fully every time
+	// AnimationPlayer.Update() is called.
+	LODReference INode
+	// LODLevels are the levels of detail that control how often the AnimationPlayer updates depending on its distance to the LODReference.
+	// The furthest level that the RootNode is past is used. When updating at a reduced rate, the time between updates is accumulated and
+	// the animated Nodes simply hold their pose in-between updates (there's no interpolation), which is usually fine for distant crowds.
+	// See DefaultAnimationLODLevels() for some sensible defaults.
+	LODLevels       []AnimationLODLevel
+	lodTime         float32
+	culled          bool
+	cullingModels   []*Model
+	cullingSearched bool
+	cullingChanges  uint64 // The RootNode's tree changes (see Node.treeChanges) when the skinned Models under it were last searched for
+	lastUpdateFrame uint64 // The rendered frame (see renderFrame) when the AnimationPlayer was last updated
+
+	// RootMotionBone is the name of the bone (or Node) to extract root motion from. If set, the animated translation of this bone
+	// is stripped from it (so it stays in place), and is instead made available through RootMotionDelta() and RootMotionWorldDelta()
+	// each time the AnimationPlayer updates. You can then move your character by this delta, so that animations that move forward
+	// don't slide or need in-place duplicates. Defaults to an empty string (no root motion extraction).
+	RootMotionBone string
+	// RootMotionAxes indicates which axes of the root bone's translation to extract; for example, {1, 0, 1} would extract horizontal
+	// movement, but leave vertical movement (like bouncing) in the animation. Defaults to {1, 1, 1}.
+	RootMotionAxes Vector3
+
+	rootMotionNode    INode
+	rootMotionDelta   Vector3
+	rootMotionPrev    Vector3
+	rootMotionPrevSet bool
+	rootMotionLooped  bool
+
 	startingPosition Vector3
 	startingScale    Vector3
 	startingRotation Matrix4
@@ -317,6 +528,7 @@ func NewAnimationPlayer(node INode) *AnimationPlayer {
 		currentProperties:      map[INode]AnimationValues{},
 		prevAnimatedProperties: map[INode]AnimationValues{},
 		PlayLastFrame:          false,
+		RootMotionAxes:         Vector3{1, 1, 1},
 	}
 }
 
@@ -328,8 +540,14 @@ func (ap *AnimationPlayer) Clone() *AnimationPlayer {
 	for channel, node := range ap.ChannelsToNodes {
 		newAP.ChannelsToNodes[channel] = node
 	}
+	newAP.ChannelsToMaterials = map[*AnimationChannel][]*Material{}
+	for channel, materials := range ap.ChannelsToMaterials {
+		newAP.ChannelsToMaterials[channel] = append([]*Material{}, materials...)
+	}
 	newAP.ChannelsUpdated = ap.ChannelsUpdated
 
+	newAP.DefaultAnimation = ap.DefaultAnimation
+	newAP.importedConstraints = ap.importedConstraints
 	newAP.Animation = ap.Animation
 	newAP.Playhead = ap.Playhead
 	newAP.PlaySpeed = ap.PlaySpeed
@@ -337,6 +555,14 @@ func (ap *AnimationPlayer) Clone() *AnimationPlayer {
 	newAP.OnFinish = ap.OnFinish
 	newAP.Playing = ap.Playing
 	newAP.PlayLastFrame = ap.PlayLastFrame
+	newAP.RootMotionBone = ap.RootMotionBone
+	newAP.RootMotionAxes = ap.RootMotionAxes
+	newAP.OffscreenCulling = ap.OffscreenCulling
+	newAP.LODReference = ap.LODReference
+	newAP.LODLevels = append([]AnimationLODLevel{}, ap.LODLevels...)
+	for _, layer := range ap.Layers {
+		newAP.Layers = append(newAP.Layers, layer.Clone())
+	}
 	return newAP
 }
 
@@ -344,6 +570,7 @@ func (ap *AnimationPlayer) Clone() *AnimationPlayer {
 func (ap *AnimationPlayer) SetRoot(node INode) {
 	ap.RootNode = node
 	ap.ChannelsUpdated = false
+	ap.cullingSearched = false
 }
 
 // Play plays the specified animation back, resetting the playhead if the specified animation is not currently
@@ -374,6 +601,7 @@ func (ap *AnimationPlayer) Play(animation *Animation) {
 	}
 
 	ap.ChannelsUpdated = false
+	ap.rootMotionPrevSet = false
 
 	if ap.BlendTime > 0 {
 		ap.prevAnimatedProperties = map[INode]AnimationValues{}
@@ -396,6 +624,13 @@ func (ap *AnimationPlayer) PlayByName(animationName string) error {
 	return nil
 }
 
+// PlayDefault plays the AnimationPlayer's DefaultAnimation, if it has one. To play back a hierarchy of objects that were each animated separately
+// in Blender (i.e. a parent swinging while its child spins), call PlayDefault() on each Node's AnimationPlayer in the tree (i.e. through
+// Node.SearchTree().ForEach()), and update each of them.
+func (ap *AnimationPlayer) PlayDefault() {
+	ap.Play(ap.DefaultAnimation)
+}
+
 // Stop stops the AnimationPlayer's playback. Note that this is fundamentally the same as calling ap.Playing = false (for now).
 func (ap *AnimationPlayer) Stop() {
 	ap.Playing = false
@@ -452,6 +687,8 @@ func (ap *AnimationPlayer) assignChannels() {
 
 			}
 
+			ap.assignMaterialChannels()
+
 		}
 
 		ap.ChannelsUpdated = true
@@ -460,25 +697,118 @@ func (ap *AnimationPlayer) assignChannels() {
 
 }
 
+// assignMaterialChannels assigns the Materials used by the Models in the player's root node's tree to the Animation's MaterialChannels by name.
+// If no Model in the tree uses a Material of a channel's name, the Material of that name in the Animation's Library is animated instead.
+func (ap *AnimationPlayer) assignMaterialChannels() {
+
+	ap.ChannelsToMaterials = map[*AnimationChannel][]*Material{}
+
+	if len(ap.Animation.MaterialChannels) == 0 {
+		return
+	}
+
+	for _, node := range append([]INode{ap.RootNode}, ap.RootNode.SearchTree().INodes()...) {
+
+		model, ok := node.(*Model)
+
+		if !ok || model.Mesh == nil {
+			continue
+		}
+
+		for _, mp := range model.Mesh.MeshParts {
+
+			if mp.Material == nil {
+				continue
+			}
+
+			if channel, exists := ap.Animation.MaterialChannels[mp.Material.Name]; exists {
+
+				added := false
+				for _, mat := range ap.ChannelsToMaterials[channel] {
+					if mat == mp.Material {
+						added = true
+						break
+					}
+				}
+
+				if !added {
+					ap.ChannelsToMaterials[channel] = append(ap.ChannelsToMaterials[channel], mp.Material)
+				}
+
+			}
+
+		}
+
+	}
+
+	if lib := ap.Animation.Library(); lib != nil {
+		for name, channel := range ap.Animation.MaterialChannels {
+			if _, exists := ap.ChannelsToMaterials[channel]; !exists {
+				if mat, exists := lib.Materials[name]; exists {
+					ap.ChannelsToMaterials[channel] = []*Material{mat}
+				}
+			}
+		}
+	}
+
+}
+
+// updateMaterials applies the values of the Animation's MaterialChannels to their Materials. Material animations are applied directly,
+// without blending between animations.
+func (ap *AnimationPlayer) updateMaterials() {
+
+	for channel, materials := range ap.ChannelsToMaterials {
+
+		for _, track := range channel.Tracks {
+
+			switch track.Type {
+
+			case TrackTypeColor:
+				if color, exists := track.ValueAsColor(ap.Playhead); exists {
+					for _, mat := range materials {
+						mat.Color = color
+					}
+				}
+
+			case TrackTypeProperty:
+				if value, exists := track.ValueAsFloat(ap.Playhead); exists {
+					for _, mat := range materials {
+						mat.Properties().Add(track.Property).Set(value)
+					}
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
 func (ap *AnimationPlayer) updateValues(dt float32) {
 
 	if ap.Animation != nil {
 
 		ap.assignChannels()
 
+		ap.updateMaterials()
+
 		for _, channel := range ap.Animation.Channels {
 
 			node := ap.ChannelsToNodes[channel]
 
 			if node == nil {
 				log.Println("Error: Cannot find matching node for channel " + channel.Name + " for root " + ap.RootNode.Name())
-			} else {
+			} else if !ap.culled || ap.animatedWhileCulled(node) {
 
 				n := ap.AnimatedProperties[node]
 
 				if track, exists := channel.Tracks[TrackTypePosition]; exists {
 					// node.SetLocalPositionVecVec(track.ValueAsVector(ap.Playhead))
 					if vec, exists := track.ValueAsVector(ap.Playhead); exists {
+						if channel.Delta != nil {
+							vec = vec.Add(channel.Delta.Position)
+						}
 						n.Position = vec
 						n.PositionExists = true
 						n.PositionInterpolation = track.Interpolation
@@ -488,6 +818,9 @@ func (ap *AnimationPlayer) updateValues(dt float32) {
 						if !channel.startingPositionSet {
 							channel.startingPositionSet = true
 							channel.startingPosition, _ = track.ValueAsVector(-math.MaxFloat32)
+							if channel.Delta != nil {
+								channel.startingPosition = channel.startingPosition.Add(channel.Delta.Position)
+							}
 						}
 
 					}
@@ -496,6 +829,9 @@ func (ap *AnimationPlayer) updateValues(dt float32) {
 				if track, exists := channel.Tracks[TrackTypeScale]; exists {
 					// node.SetLocalScaleVec(track.ValueAsVector(ap.Playhead))
 					if vec, exists := track.ValueAsVector(ap.Playhead); exists {
+						if channel.Delta != nil {
+							vec = vec.Mult(channel.Delta.Scale)
+						}
 						n.Scale = vec
 						n.ScaleExists = true
 						n.ScaleInterpolation = track.Interpolation
@@ -505,12 +841,22 @@ func (ap *AnimationPlayer) updateValues(dt float32) {
 				if track, exists := channel.Tracks[TrackTypeRotation]; exists {
 					if quat, exists := track.ValueAsQuaternion(ap.Playhead); exists {
 						// node.SetLocalRotation(NewMatrix4RotateFromQuaternion(quat))
+						if channel.Delta != nil {
+							quat = channel.Delta.Rotation.Mult(quat)
+						}
 						n.Rotation = quat
 						n.RotationExists = true
 						n.RotationInterpolation = track.Interpolation
 					}
 				}
 
+				if track, exists := channel.Tracks[TrackTypeVisible]; exists {
+					if visible, exists := track.ValueAsBool(ap.Playhead); exists {
+						n.Visible = visible
+						n.VisibleExists = true
+					}
+				}
+
 				ap.AnimatedProperties[node] = n
 
 			}
@@ -553,6 +899,7 @@ func (ap *AnimationPlayer) updateValues(dt float32) {
 			}
 
 			ap.justLooped = true
+			ap.rootMotionLooped = true
 
 			if ap.OnFinish != nil {
 				ap.OnFinish(ap.Animation)
@@ -610,17 +957,35 @@ func (ap *AnimationPlayer) Update(dt float32) {
 
 	ap.finished = false
 	ap.touchedMarkers = ap.touchedMarkers[:0]
+	ap.rootMotionDelta = Vector3{}
+
+	// Hold the current pose until enough time has passed to update at the current level of detail's rate.
+	if rate := ap.lodUpdateRate(); rate > 0 {
+		ap.lodTime += dt
+		if ap.lodTime < 1/rate {
+			return
+		}
+		dt = ap.lodTime
+		ap.lodTime = 0
+	} else if ap.lodTime > 0 {
+		dt += ap.lodTime
+		ap.lodTime = 0
+	}
+
+	ap.culled = ap.OffscreenCulling && !ap.renderedSinceLastUpdate()
+	ap.lastUpdateFrame = renderFrame.Load()
 
 	if !ap.Playing && !ap.blendStart.IsZero() {
 		ap.blendStart = time.Time{}
 		ap.prevAnimatedProperties = map[INode]AnimationValues{}
 	}
 
-	if ap.Animation == nil || !ap.Playing {
-		return
+	if ap.Animation != nil && ap.Playing {
+		ap.forceUpdate(dt)
 	}
 
-	ap.forceUpdate(dt)
+	ap.updateLayers(dt)
+	ap.applyConstraints()
 
 }
 
@@ -628,7 +993,98 @@ func (ap *AnimationPlayer) Update(dt float32) {
 // also performs an update of the animated nodes.
 func (ap *AnimationPlayer) SetPlayhead(time float32) {
 	ap.Playhead = time
+	// Jumping around in the animation shouldn't count as root motion.
+	ap.rootMotionPrevSet = false
+	ap.rootMotionDelta = Vector3{}
 	ap.forceUpdate(0)
+	ap.updateLayers(0)
+	ap.applyConstraints()
+}
+
+// lodUpdateRate returns the update rate of the AnimationPlayer's current level of detail, or 0 if it should update fully.
+func (ap *AnimationPlayer) lodUpdateRate() float32 {
+
+	if ap.LODReference == nil || ap.RootNode == nil || len(ap.LODLevels) == 0 {
+		return 0
+	}
+
+	dist := ap.RootNode.DistanceTo(ap.LODReference)
+	rate := float32(0)
+	furthest := float32(-1)
+
+	for _, level := range ap.LODLevels {
+		if dist >= level.Distance && level.Distance > furthest {
+			rate = level.UpdateRate
+			furthest = level.Distance
+		}
+	}
+
+	return rate
+
+}
+
+// renderedSinceLastUpdate returns if any of the skinned Models underneath the AnimationPlayer's RootNode were rendered since
+// the AnimationPlayer was last updated, or in the latest frame. If there are no skinned Models to check, it returns true.
+func (ap *AnimationPlayer) renderedSinceLastUpdate() bool {
+
+	if !ap.cullingSearched || ap.cullingChanges != ap.RootNode.getNode().treeChanges {
+
+		ap.cullingModels = ap.cullingModels[:0]
+
+		ap.RootNode.SearchTree().ByType(NodeTypeModel).ForEach(func(node INode) bool {
+			if model := node.(*Model); model.skinned {
+				ap.cullingModels = append(ap.cullingModels, model)
+			}
+			return true
+		})
+
+		ap.cullingSearched = true
+		ap.cullingChanges = ap.RootNode.getNode().treeChanges
+
+	}
+
+	if len(ap.cullingModels) == 0 {
+		return true
+	}
+
+	for _, model := range ap.cullingModels {
+		if model.renderedFrame > ap.lastUpdateFrame || model.renderedFrame == renderFrame.Load() {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// animatedWhileCulled returns if the given Node should still be animated while the AnimationPlayer is culled.
+func (ap *AnimationPlayer) animatedWhileCulled(node INode) bool {
+	return node == ap.RootNode || (ap.RootMotionBone != "" && node.Name() == ap.RootMotionBone)
+}
+
+func (ap *AnimationPlayer) applyConstraints() {
+
+	if !ap.importedConstraintsResolved && ap.RootNode != nil {
+
+		ap.importedConstraintsResolved = true
+
+		var sceneRoot INode
+		if root := ap.RootNode.Root(); root != nil {
+			sceneRoot = root
+		}
+
+		for _, ic := range ap.importedConstraints {
+			if constraint := ic.resolve(ap.RootNode, sceneRoot); constraint != nil {
+				ap.Constraints = append(ap.Constraints, constraint)
+			}
+		}
+
+	}
+
+	for _, constraint := range ap.Constraints {
+		constraint.Apply()
+	}
+
 }
 
 func (ap *AnimationPlayer) forceUpdate(dt float32) {
@@ -637,6 +1093,10 @@ func (ap *AnimationPlayer) forceUpdate(dt float32) {
 
 	for node, props := range ap.AnimatedProperties {
 
+		if ap.culled && !ap.animatedWhileCulled(node) {
+			continue
+		}
+
 		_, prevExists := ap.prevAnimatedProperties[node]
 
 		var targetPosition Vector3
@@ -742,6 +1202,10 @@ func (ap *AnimationPlayer) forceUpdate(dt float32) {
 
 		}
 
+		if posSet && ap.RootMotionBone != "" && props.PositionExists && node.Name() == ap.RootMotionBone {
+			targetPosition = ap.extractRootMotion(node, props, targetPosition)
+		}
+
 		if posSet {
 			if ap.Animation.RelativeMotion {
 				node.SetLocalPositionVec(ap.startingPosition.Add(targetPosition.Sub(props.channel.startingPosition)))
@@ -766,8 +1230,80 @@ func (ap *AnimationPlayer) forceUpdate(dt float32) {
 			}
 		}
 
+		// Visibility is never blended; it simply switches.
+		if props.VisibleExists && node.Visible() != props.Visible {
+			node.SetVisible(props.Visible, false)
+		}
+
+	}
+
+}
+
+// extractRootMotion records the movement of the root motion bone since the last update, and returns the provided
+// target position with that movement stripped out.
+func (ap *AnimationPlayer) extractRootMotion(node INode, props AnimationValues, targetPosition Vector3) Vector3 {
+
+	track := props.channel.Tracks[TrackTypePosition]
+	start, _ := track.ValueAsVector(-math.MaxFloat32)
+	end, _ := track.ValueAsVector(math.MaxFloat32)
+
+	// props.Position already has the channel's delta applied, so the ends of the track need it as well.
+	if props.channel.Delta != nil {
+		start = start.Add(props.channel.Delta.Position)
+		end = end.Add(props.channel.Delta.Position)
+	}
+
+	current := props.Position
+
+	if ap.rootMotionPrevSet && ap.rootMotionNode == node {
+
+		var delta Vector3
+
+		// If the animation looped, the bone jumped back to the start (or end), so we add up the motion on either side of the loop.
+		if ap.rootMotionLooped {
+			if ap.PlaySpeed >= 0 {
+				delta = end.Sub(ap.rootMotionPrev).Add(current.Sub(start))
+			} else {
+				delta = start.Sub(ap.rootMotionPrev).Add(current.Sub(end))
+			}
+		} else {
+			delta = current.Sub(ap.rootMotionPrev)
+		}
+
+		ap.rootMotionDelta = ap.rootMotionDelta.Add(delta.Mult(ap.RootMotionAxes))
+
 	}
 
+	ap.rootMotionNode = node
+	ap.rootMotionPrev = current
+	ap.rootMotionPrevSet = true
+	ap.rootMotionLooped = false
+
+	// Keep the bone at its starting position on the extracted axes.
+	return targetPosition.Sub(targetPosition.Sub(start).Mult(ap.RootMotionAxes))
+
+}
+
+// RootMotionDelta returns the movement of the root motion bone (as set by AnimationPlayer.RootMotionBone) since the last
+// AnimationPlayer.Update() call. The delta is in the local space of the bone's parent (usually the armature).
+func (ap *AnimationPlayer) RootMotionDelta() Vector3 {
+	return ap.rootMotionDelta
+}
+
+// RootMotionWorldDelta returns the movement of the root motion bone (as set by AnimationPlayer.RootMotionBone) since the last
+// AnimationPlayer.Update() call in world space, taking into account the rotation and scale of the bone's parent. This can be
+// added to your character's world position directly.
+func (ap *AnimationPlayer) RootMotionWorldDelta() Vector3 {
+
+	if ap.rootMotionNode == nil || ap.rootMotionNode.Parent() == nil {
+		return ap.rootMotionDelta
+	}
+
+	transformNoLoc := ap.rootMotionNode.Parent().Transform()
+	transformNoLoc.SetRow(3, Vector4{0, 0, 0, 1})
+
+	return transformNoLoc.MultVec(ap.rootMotionDelta)
+
 }
 
 // Finished returns whether the AnimationPlayer is finished playing its current animation.
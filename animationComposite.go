@@ -0,0 +1,336 @@
+package tetra3d
+
+import (
+	"sort"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// StripBlendMode indicates how a CompositeStrip's animation combines with the tracks underneath it.
+type StripBlendMode int
+
+const (
+	StripBlendReplace StripBlendMode = iota // The strip's values replace the values underneath it (mixed by the strip's influence).
+	StripBlendAdd                           // The strip's position and scale are added to the values underneath it, while rotations are combined.
+	StripBlendCombine                       // The strip's position is added, while its rotation and scale are multiplied with the values underneath it.
+)
+
+// CompositeStrip is a clip of an Animation placed on a CompositeTrack, similar to a strip in Blender's NLA editor.
+type CompositeStrip struct {
+	Animation *Animation // The Animation the strip plays.
+	Start     float32    // When the strip starts on the composite animation's timeline, in seconds.
+
+	AnimationStart float32 // The start of the range of the Animation that the strip plays, in seconds.
+	AnimationEnd   float32 // The end of the range of the Animation that the strip plays, in seconds. 0 or less means the end of the Animation.
+
+	Scale  float32 // How much slower the strip plays its Animation; 2 means it plays at half speed. Defaults to 1.
+	Repeat float32 // How many times the strip repeats its Animation. Defaults to 1.
+
+	BlendMode StripBlendMode // How the strip combines with the tracks underneath it. Defaults to StripBlendReplace.
+	Influence float32        // How strongly the strip affects the result, ranging from 0 to 1. Defaults to 1.
+	BlendIn   float32        // How long in seconds the strip takes to fade in from its start.
+	BlendOut  float32        // How long in seconds the strip takes to fade out before its end.
+
+	// Hold indicates if the strip holds its first and last poses outside of its range (until another strip on the track starts),
+	// rather than having no effect.
+	Hold bool
+}
+
+// NewCompositeStrip creates a new CompositeStrip that plays the entirety of the given Animation, starting at the time provided in seconds.
+func NewCompositeStrip(animation *Animation, start float32) *CompositeStrip {
+	return &CompositeStrip{
+		Animation: animation,
+		Start:     start,
+		Scale:     1,
+		Repeat:    1,
+		Influence: 1,
+	}
+}
+
+func (strip *CompositeStrip) animationEnd() float32 {
+	if strip.AnimationEnd <= 0 {
+		return strip.Animation.Length
+	}
+	return strip.AnimationEnd
+}
+
+// End returns when the strip ends on the composite animation's timeline, in seconds.
+func (strip *CompositeStrip) End() float32 {
+	return strip.Start + (strip.animationEnd()-strip.AnimationStart)*strip.scale()*strip.repeat()
+}
+
+func (strip *CompositeStrip) scale() float32 {
+	if strip.Scale <= 0 {
+		return 1
+	}
+	return strip.Scale
+}
+
+func (strip *CompositeStrip) repeat() float32 {
+	if strip.Repeat <= 0 {
+		return 1
+	}
+	return strip.Repeat
+}
+
+// localTime returns the time in the strip's Animation for the given time on the composite timeline.
+func (strip *CompositeStrip) localTime(time float32) float32 {
+
+	length := strip.animationEnd() - strip.AnimationStart
+
+	if time <= strip.Start || length <= 0 {
+		return strip.AnimationStart
+	}
+
+	if time >= strip.End() {
+		return strip.animationEnd()
+	}
+
+	return strip.AnimationStart + math32.Mod((time-strip.Start)/strip.scale(), length)
+
+}
+
+// weight returns the influence of the strip at the given time on the composite timeline, taking blending in and out into account.
+func (strip *CompositeStrip) weight(time float32) float32 {
+
+	weight := strip.Influence
+
+	if strip.BlendIn > 0 && time < strip.Start+strip.BlendIn {
+		weight *= math32.Clamp((time-strip.Start)/strip.BlendIn, 0, 1)
+	}
+
+	if end := strip.End(); strip.BlendOut > 0 && time > end-strip.BlendOut {
+		weight *= math32.Clamp((end-time)/strip.BlendOut, 0, 1)
+	}
+
+	return weight
+
+}
+
+// CompositeTrack is a track of CompositeStrips, similar to a track in Blender's NLA editor. Strips on a track shouldn't overlap.
+type CompositeTrack struct {
+	Name   string
+	Strips []*CompositeStrip
+	Muted  bool // Whether the track is muted (in which case it has no effect).
+}
+
+// NewCompositeTrack creates a new CompositeTrack with the given name and strips.
+func NewCompositeTrack(name string, strips ...*CompositeStrip) *CompositeTrack {
+	return &CompositeTrack{
+		Name:   name,
+		Strips: append([]*CompositeStrip{}, strips...),
+	}
+}
+
+// activeStrip returns the strip on the track that affects the given time, and the time to evaluate it at, or nil if no strip does.
+func (track *CompositeTrack) activeStrip(time float32) (*CompositeStrip, float32) {
+
+	var held *CompositeStrip
+	heldTime := float32(0)
+
+	for i, strip := range track.Strips {
+
+		if strip.Animation == nil {
+			continue
+		}
+
+		if time >= strip.Start && time < strip.End() {
+			return strip, time
+		}
+
+		if !strip.Hold {
+			continue
+		}
+
+		if time >= strip.End() {
+			held = strip
+			heldTime = strip.End()
+		} else if i == 0 && held == nil {
+			return strip, strip.Start
+		}
+
+	}
+
+	if held != nil {
+		return held, heldTime
+	}
+
+	return nil, 0
+
+}
+
+// NewCompositeAnimation creates a new Animation by layering the strips of the given tracks on top of each other, similar to how Blender's
+// NLA editor combines actions. The tracks are evaluated from first (the bottom) to last (the top), with each track's strips blending on
+// top of the result of the tracks before it. The result is baked into keyframes sampled at the given rate (in samples per second; 0 or less
+// means 60), so it can be played back through an AnimationPlayer like any other Animation. The composite animation also has the strips' markers.
+func NewCompositeAnimation(name string, sampleRate float32, tracks ...*CompositeTrack) *Animation {
+
+	if sampleRate <= 0 {
+		sampleRate = 60
+	}
+
+	anim := NewAnimation(name)
+
+	// Gather the channels animated by the strips, along with which of their tracks exist.
+	channelTracks := map[string]map[string]bool{}
+	channelNames := []string{}
+
+	for _, track := range tracks {
+
+		if track.Muted {
+			continue
+		}
+
+		for _, strip := range track.Strips {
+
+			if strip.Animation == nil {
+				continue
+			}
+
+			if end := strip.End(); end > anim.Length {
+				anim.Length = end
+			}
+
+			if anim.library == nil {
+				anim.library = strip.Animation.library
+			}
+
+			for _, marker := range strip.Animation.Markers {
+				if marker.Time >= strip.AnimationStart && marker.Time <= strip.animationEnd() {
+					anim.Markers = append(anim.Markers, Marker{
+						Name: marker.Name,
+						Time: strip.Start + (marker.Time-strip.AnimationStart)*strip.scale(),
+					})
+				}
+			}
+
+			for channelName, channel := range strip.Animation.Channels {
+
+				if _, exists := channelTracks[channelName]; !exists {
+					channelTracks[channelName] = map[string]bool{}
+					channelNames = append(channelNames, channelName)
+				}
+
+				for trackType := range channel.Tracks {
+					channelTracks[channelName][trackType] = true
+				}
+
+			}
+
+		}
+
+	}
+
+	sort.Slice(anim.Markers, func(i, j int) bool { return anim.Markers[i].Time < anim.Markers[j].Time })
+
+	sampleCount := int(math32.Ceil(anim.Length*sampleRate)) + 1
+
+	for _, channelName := range channelNames {
+
+		existing := channelTracks[channelName]
+
+		channel := anim.AddChannel(channelName)
+
+		var posTrack, scaleTrack, rotTrack *AnimationTrack
+
+		if existing[TrackTypePosition] {
+			posTrack = channel.AddTrack(TrackTypePosition)
+		}
+		if existing[TrackTypeScale] {
+			scaleTrack = channel.AddTrack(TrackTypeScale)
+		}
+		if existing[TrackTypeRotation] {
+			rotTrack = channel.AddTrack(TrackTypeRotation)
+		}
+
+		for i := 0; i < sampleCount; i++ {
+
+			time := math32.Min(float32(i)/sampleRate, anim.Length)
+
+			pos, scale, rot := compositeChannelValues(channelName, time, tracks)
+
+			if posTrack != nil {
+				posTrack.AddKeyframe(time, pos)
+			}
+			if scaleTrack != nil {
+				scaleTrack.AddKeyframe(time, scale)
+			}
+			if rotTrack != nil {
+				rotTrack.AddKeyframe(time, rot)
+			}
+
+		}
+
+	}
+
+	return anim
+
+}
+
+// compositeChannelValues returns the position, scale, and rotation of the channel of the given name at the time provided, after
+// blending the tracks' strips together. Values that no strip animates are left at their defaults.
+func compositeChannelValues(channelName string, time float32, tracks []*CompositeTrack) (Vector3, Vector3, Quaternion) {
+
+	identity := NewQuaternion(0, 0, 0, 1)
+
+	pos := Vector3{}
+	scale := Vector3{1, 1, 1}
+	rot := identity
+
+	for _, track := range tracks {
+
+		if track.Muted {
+			continue
+		}
+
+		strip, stripTime := track.activeStrip(time)
+		if strip == nil {
+			continue
+		}
+
+		channel, exists := strip.Animation.Channels[channelName]
+		if !exists {
+			continue
+		}
+
+		weight := strip.weight(stripTime)
+		localTime := strip.localTime(stripTime)
+
+		if t, exists := channel.Tracks[TrackTypePosition]; exists {
+			if value, exists := t.ValueAsVector(localTime); exists {
+				if strip.BlendMode == StripBlendReplace {
+					pos = pos.Lerp(value, weight)
+				} else {
+					pos = pos.Add(value.Scale(weight))
+				}
+			}
+		}
+
+		if t, exists := channel.Tracks[TrackTypeScale]; exists {
+			if value, exists := t.ValueAsVector(localTime); exists {
+				switch strip.BlendMode {
+				case StripBlendReplace:
+					scale = scale.Lerp(value, weight)
+				case StripBlendAdd:
+					scale = scale.Add(value.Scale(weight))
+				case StripBlendCombine:
+					scale = scale.Mult(Vector3{1, 1, 1}.Lerp(value, weight))
+				}
+			}
+		}
+
+		if t, exists := channel.Tracks[TrackTypeRotation]; exists {
+			if value, exists := t.ValueAsQuaternion(localTime); exists {
+				if strip.BlendMode == StripBlendReplace {
+					rot = rot.Lerp(value, weight).Normalized()
+				} else {
+					rot = rot.Mult(identity.Lerp(value, weight).Normalized())
+				}
+			}
+		}
+
+	}
+
+	return pos, scale, rot
+
+}
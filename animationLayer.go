@@ -0,0 +1,230 @@
+package tetra3d
+
+import "math"
+
+// AnimationLayer represents an Animation that plays additively on top of an AnimationPlayer's base Animation (for example,
+// a breathing or recoil animation playing on top of a walk cycle). Rather than replacing the base animation's values,
+// a layer adds the difference between its current pose and its reference pose (the pose at ReferenceTime) to them,
+// scaled by the layer's Weight.
+type AnimationLayer struct {
+	Animation     *Animation
+	Weight        float32    // How strongly the layer affects the animated Nodes, ranging from 0 (not at all) to 1 (fully). Defaults to 1.
+	Playhead      float32    // Playhead of the layer's animation, in seconds.
+	PlaySpeed     float32    // Playback speed in percentage - defaults to 1 (100%).
+	Playing       bool       // Whether the layer's animation is advancing or not. A paused layer still applies its current pose.
+	FinishMode    FinishMode // What to do when the layer finishes playback. Defaults to looping.
+	ReferenceTime float32    // The time in the Animation of the reference (or "rest") pose that the layer's motion is relative to. Defaults to 0.
+
+	channelsToNodes map[*AnimationChannel]INode
+	assignedRoot    INode
+}
+
+// NewAnimationLayer creates a new AnimationLayer for the given Animation with the weight provided.
+func NewAnimationLayer(animation *Animation, weight float32) *AnimationLayer {
+	return &AnimationLayer{
+		Animation:  animation,
+		Weight:     weight,
+		PlaySpeed:  1,
+		Playing:    true,
+		FinishMode: FinishModeLoop,
+	}
+}
+
+// Clone returns a clone of the AnimationLayer.
+func (layer *AnimationLayer) Clone() *AnimationLayer {
+	newLayer := NewAnimationLayer(layer.Animation, layer.Weight)
+	newLayer.Playhead = layer.Playhead
+	newLayer.PlaySpeed = layer.PlaySpeed
+	newLayer.Playing = layer.Playing
+	newLayer.FinishMode = layer.FinishMode
+	newLayer.ReferenceTime = layer.ReferenceTime
+	return newLayer
+}
+
+func (layer *AnimationLayer) assignChannels(root INode) {
+
+	if layer.assignedRoot == root && layer.channelsToNodes != nil {
+		return
+	}
+
+	layer.channelsToNodes = map[*AnimationChannel]INode{}
+	layer.assignedRoot = root
+
+	if layer.Animation == nil || root == nil {
+		return
+	}
+
+	childrenRecursive := root.SearchTree().INodes()
+
+	for _, channel := range layer.Animation.Channels {
+
+		if root.Name() == channel.Name {
+			layer.channelsToNodes[channel] = root
+			continue
+		}
+
+		for _, n := range childrenRecursive {
+			if n.Name() == channel.Name {
+				layer.channelsToNodes[channel] = n
+				break
+			}
+		}
+
+	}
+
+}
+
+func (layer *AnimationLayer) advance(dt float32) {
+
+	if !layer.Playing || layer.Animation == nil || layer.Animation.Length <= 0 {
+		return
+	}
+
+	length := layer.Animation.Length
+
+	layer.Playhead += dt * layer.PlaySpeed
+
+	if layer.Playhead >= length || layer.Playhead < 0 {
+
+		switch layer.FinishMode {
+
+		case FinishModeLoop:
+			layer.Playhead = float32(math.Mod(float64(layer.Playhead), float64(length)))
+			if layer.Playhead < 0 {
+				layer.Playhead += length
+			}
+
+		case FinishModePingPong:
+			if layer.Playhead >= length {
+				layer.Playhead = length - (layer.Playhead - length)
+			} else {
+				layer.Playhead *= -1
+			}
+			layer.PlaySpeed *= -1
+
+		case FinishModeStop:
+			if layer.Playhead >= length {
+				layer.Playhead = length
+			} else {
+				layer.Playhead = 0
+			}
+			layer.Playing = false
+
+		}
+
+	}
+
+}
+
+// layerApplication records what offsets layers applied to a Node, and what the Node's resulting local transform was, so that the
+// offsets can be undone if nothing else (i.e. the base animation) resets the Node's transform before the layers are applied again.
+type layerApplication struct {
+	position, resultPosition Vector3
+	scale, resultScale       Vector3
+	rotation, resultRotation Matrix4
+}
+
+// AddLayer adds an additive AnimationLayer playing the given Animation at the specified weight to the AnimationPlayer and returns it.
+func (ap *AnimationPlayer) AddLayer(animation *Animation, weight float32) *AnimationLayer {
+	layer := NewAnimationLayer(animation, weight)
+	ap.Layers = append(ap.Layers, layer)
+	return layer
+}
+
+// RemoveLayer removes the given AnimationLayer from the AnimationPlayer.
+func (ap *AnimationPlayer) RemoveLayer(layer *AnimationLayer) {
+	for i, l := range ap.Layers {
+		if l == layer {
+			ap.Layers = append(ap.Layers[:i], ap.Layers[i+1:]...)
+			return
+		}
+	}
+}
+
+// updateLayers advances the AnimationPlayer's layers by the given delta time and applies them on top of the current
+// transforms of the animated Nodes.
+func (ap *AnimationPlayer) updateLayers(dt float32) {
+
+	// Undo the previous frame's layer offsets for any Nodes that weren't reset by the base animation since then,
+	// so they don't accumulate.
+	for node, applied := range ap.layersApplied {
+		if node.LocalPosition().Equals(applied.resultPosition) {
+			node.SetLocalPositionVec(node.LocalPosition().Sub(applied.position))
+		}
+		if node.LocalScale().Equals(applied.resultScale) {
+			node.SetLocalScaleVec(node.LocalScale().Sub(applied.scale))
+		}
+		if node.LocalRotation().Equals(applied.resultRotation) {
+			node.SetLocalRotation(applied.rotation.Transposed().Mult(node.LocalRotation()))
+		}
+		delete(ap.layersApplied, node)
+	}
+
+	if len(ap.Layers) == 0 {
+		return
+	}
+
+	if ap.layersApplied == nil {
+		ap.layersApplied = map[INode]layerApplication{}
+	}
+
+	for _, layer := range ap.Layers {
+
+		layer.advance(dt)
+
+		if layer.Animation == nil || layer.Weight == 0 {
+			continue
+		}
+
+		layer.assignChannels(ap.RootNode)
+
+		for channel, node := range layer.channelsToNodes {
+
+			applied, exists := ap.layersApplied[node]
+			if !exists {
+				applied.rotation = NewMatrix4()
+			}
+
+			if track, exists := channel.Tracks[TrackTypePosition]; exists {
+				value, ok := track.ValueAsVector(layer.Playhead)
+				ref, refOk := track.ValueAsVector(layer.ReferenceTime)
+				if ok && refOk {
+					offset := value.Sub(ref).Scale(layer.Weight)
+					node.SetLocalPositionVec(node.LocalPosition().Add(offset))
+					applied.position = applied.position.Add(offset)
+				}
+			}
+
+			if track, exists := channel.Tracks[TrackTypeScale]; exists {
+				value, ok := track.ValueAsVector(layer.Playhead)
+				ref, refOk := track.ValueAsVector(layer.ReferenceTime)
+				if ok && refOk {
+					offset := value.Sub(ref).Scale(layer.Weight)
+					node.SetLocalScaleVec(node.LocalScale().Add(offset))
+					applied.scale = applied.scale.Add(offset)
+				}
+			}
+
+			if track, exists := channel.Tracks[TrackTypeRotation]; exists {
+				value, ok := track.ValueAsQuaternion(layer.Playhead)
+				ref, refOk := track.ValueAsQuaternion(layer.ReferenceTime)
+				if ok && refOk {
+					// The difference between the reference rotation and the current one, applied in the Node's local space.
+					offset := value.ToMatrix4().Mult(ref.ToMatrix4().Transposed())
+					offset = NewMatrix4().Lerp(offset, layer.Weight)
+					node.SetLocalRotation(offset.Mult(node.LocalRotation()))
+					applied.rotation = offset.Mult(applied.rotation)
+				}
+			}
+
+			applied.resultPosition = node.LocalPosition()
+			applied.resultScale = node.LocalScale()
+			applied.resultRotation = node.LocalRotation()
+
+			ap.layersApplied[node] = applied
+
+		}
+
+	}
+
+}
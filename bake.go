@@ -0,0 +1,777 @@
+package tetra3d
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// BakeJob represents a bake of lighting or ambient occlusion into a Model's vertex colors that runs on a background goroutine, so
+// that long bakes don't freeze the game. BakeJobs are started through Model.BakeLightingAsync(), Model.BakeLightingGIAsync(), or Model.BakeAOAsync().
+// While baking, the results are written to a separate buffer; they're only copied into the Model's Mesh once the bake is finished
+// and BakeJob.Update() or BakeJob.Wait() is called, so the Model can keep rendering normally in the meantime.
+// Note that the Mesh, the Model, and the other objects influencing the bake (the Lights or other Models) shouldn't be modified or
+// moved while a bake is running.
+type BakeJob struct {
+	Model         *Model
+	TargetChannel int // The vertex color channel the bake results will be copied into.
+
+	// OnProgress is called from BakeJob.Update() whenever the progress of the bake changes, with progress ranging from 0 to 1.
+	// This is useful for displaying progress bars on loading screens.
+	OnProgress func(progress float32)
+
+	// OnFinish is called from BakeJob.Update() or BakeJob.Wait() once the bake has finished and its results have been applied to the Model.
+	OnFinish func()
+
+	result       VertexColorChannel
+	progress     *bakeProgress
+	finished     chan struct{}
+	applied      bool
+	lastProgress float32
+}
+
+// bakeProgress tracks the progress of a bake in units of work, which can be safely read from other goroutines.
+type bakeProgress struct {
+	completed atomic.Int64
+	total     int64
+}
+
+func (progress *bakeProgress) advance(units int) {
+	if progress != nil {
+		progress.completed.Add(int64(units))
+	}
+}
+
+func newBakeJob(model *Model, targetChannel int, total int) *BakeJob {
+
+	// Copy the channel so the bake can mix with the existing colors without writing to the Mesh while it's being rendered.
+	renderLock.Lock()
+	model.Mesh.ensureEnoughVertexColorChannels(targetChannel)
+	result := append(VertexColorChannel{}, model.Mesh.VertexColors[targetChannel]...)
+	renderLock.Unlock()
+
+	return &BakeJob{
+		Model:         model,
+		TargetChannel: targetChannel,
+		result:        result,
+		progress:      &bakeProgress{total: int64(total)},
+		finished:      make(chan struct{}),
+	}
+
+}
+
+// Progress returns the progress of the bake, ranging from 0 to 1. Progress can be called from any goroutine.
+func (job *BakeJob) Progress() float32 {
+	if job.progress.total <= 0 {
+		return 1
+	}
+	return math32.Clamp(float32(job.progress.completed.Load())/float32(job.progress.total), 0, 1)
+}
+
+// Done returns if the bake has finished and its results have been applied to the Model.
+func (job *BakeJob) Done() bool {
+	return job.applied
+}
+
+// Update checks on the bake's progress, calling OnProgress if it changed; once the bake is finished, Update applies the bake's results
+// to the Model's Mesh and calls OnFinish. Update should be called from the game's goroutine (i.e. in your game's Update() function)
+// every frame until it returns true, indicating that the bake has finished and been applied.
+func (job *BakeJob) Update() bool {
+
+	if job.applied {
+		return true
+	}
+
+	select {
+	case <-job.finished:
+		job.apply()
+		return true
+	default:
+	}
+
+	job.reportProgress()
+
+	return false
+
+}
+
+// Wait blocks until the bake has finished, and then applies its results to the Model's Mesh (calling OnFinish).
+func (job *BakeJob) Wait() {
+
+	if job.applied {
+		return
+	}
+
+	<-job.finished
+	job.apply()
+
+}
+
+func (job *BakeJob) reportProgress() {
+
+	if progress := job.Progress(); progress != job.lastProgress {
+		job.lastProgress = progress
+		if job.OnProgress != nil {
+			job.OnProgress(progress)
+		}
+	}
+
+}
+
+func (job *BakeJob) apply() {
+
+	renderLock.Lock()
+	copy(job.Model.Mesh.VertexColors[job.TargetChannel], job.result)
+	renderLock.Unlock()
+
+	job.applied = true
+
+	job.reportProgress()
+
+	if job.OnFinish != nil {
+		job.OnFinish()
+	}
+
+}
+
+// BakeLightingAsync is the asynchronous version of Model.BakeLighting(); it bakes the colors for the provided lights into the Model's Mesh's vertex
+// colors on a background goroutine, returning a BakeJob to track the bake's progress. The results are applied to the Mesh once the bake
+// finishes and BakeJob.Update() (or BakeJob.Wait()) is called. If the Model has no Mesh or the target channel is below 0, BakeLightingAsync returns nil.
+func (model *Model) BakeLightingAsync(targetChannel int, lights ...ILight) *BakeJob {
+
+	if model.Mesh == nil || targetChannel < 0 {
+		return nil
+	}
+
+	total := 0
+	for _, mp := range model.Mesh.MeshParts {
+		total += mp.VertexIndexEnd - mp.VertexIndexStart
+	}
+
+	job := newBakeJob(model, targetChannel, total)
+
+	allLights := model.bakeLights(lights)
+
+	go func() {
+		model.bakeLighting(allLights, job.result, job.progress)
+		close(job.finished)
+	}()
+
+	return job
+
+}
+
+// BakeAOAsync is the asynchronous version of Model.BakeAO(); it bakes the ambient occlusion for the Model to its vertex colors on a background
+// goroutine, returning a BakeJob to track the bake's progress. The results are applied to the Mesh once the bake finishes and BakeJob.Update()
+// (or BakeJob.Wait()) is called. If nil is passed instead of bake options, a default AOBakeOptions struct will be created and used.
+// If the Model has no Mesh or the target channel is below 0, BakeAOAsync returns nil.
+func (model *Model) BakeAOAsync(bakeOptions *AOBakeOptions) *BakeJob {
+
+	if bakeOptions == nil {
+		bakeOptions = NewDefaultAOBakeOptions()
+	}
+
+	if model.Mesh == nil || bakeOptions.TargetChannel < 0 {
+		return nil
+	}
+
+	transform := model.Transform()
+	others := model.aoBakeOthers(bakeOptions)
+
+	job := newBakeJob(model, bakeOptions.TargetChannel, len(model.Mesh.Triangles)*(1+len(others)))
+
+	go func() {
+		model.bakeAO(bakeOptions, transform, others, job.result, job.progress)
+		close(job.finished)
+	}()
+
+	return job
+
+}
+
+// bakeLights returns the lights that should be baked into the Model, including the Model's Scene's ambient light.
+func (model *Model) bakeLights(lights []ILight) []ILight {
+
+	allLights := append([]ILight{}, lights...)
+
+	if scene := model.Scene(); scene != nil && scene.World != nil {
+		allLights = append(allLights, scene.World.AmbientLight)
+	}
+
+	return allLights
+
+}
+
+// bakeLighting bakes the given lights into the target colors provided. As Lights hold working state used while lighting
+// (which is also used while rendering), the render lock is held while lighting each MeshPart.
+func (model *Model) bakeLighting(lights []ILight, target VertexColorChannel, progress *bakeProgress) {
+
+	for _, mp := range model.Mesh.MeshParts {
+
+		if mp.Material != nil && mp.Material.Shadeless {
+
+			mp.ForEachVertexIndex(func(vertIndex int) {
+				target[vertIndex].R = 1
+				target[vertIndex].G = 1
+				target[vertIndex].B = 1
+			}, false)
+
+		} else {
+
+			mp.ForEachVertexIndex(func(vertIndex int) {
+				target[vertIndex].R = 0
+				target[vertIndex].G = 0
+				target[vertIndex].B = 0
+			}, false)
+
+			renderLock.Lock()
+
+			for _, light := range lights {
+
+				if light.IsOn() {
+					light.beginRender()
+					light.beginModel(model)
+					light.Light(mp, model, target, false)
+				}
+
+			}
+
+			renderLock.Unlock()
+
+		}
+
+		progress.advance(mp.VertexIndexEnd - mp.VertexIndexStart)
+
+	}
+
+}
+
+// aoBakeOther is another Model that influences the ambient occlusion baked into a Model, along with its transform at the time of baking.
+type aoBakeOther struct {
+	model     *Model
+	transform Matrix4
+}
+
+// aoBakeOthers returns the other Models (from bakeOptions.OtherModels) close enough to the Model to influence its ambient occlusion.
+func (model *Model) aoBakeOthers(bakeOptions *AOBakeOptions) []aoBakeOther {
+
+	others := []aoBakeOther{}
+
+	if bakeOptions.OtherModels.IsZero() {
+		return others
+	}
+
+	bakeOptions.OtherModels.ForEach(func(node INode) bool {
+
+		other := node.(*Model)
+
+		rad := model.frustumCullingSphere.WorldRadius()
+		if or := other.frustumCullingSphere.WorldRadius(); or > rad {
+			rad = or
+		}
+		if model == other || model.WorldPosition().DistanceSquared(other.WorldPosition()) > rad*rad {
+			return true
+		}
+
+		others = append(others, aoBakeOther{model: other, transform: other.Transform()})
+
+		return true
+
+	})
+
+	return others
+
+}
+
+// bakeAO bakes ambient occlusion into the target colors provided, using the other Models given for inter-object occlusion.
+func (model *Model) bakeAO(bakeOptions *AOBakeOptions, transform Matrix4, others []aoBakeOther, target VertexColorChannel, progress *bakeProgress) {
+
+	// Same model AO first
+
+	for _, tri := range model.Mesh.Triangles {
+
+		progress.advance(1)
+
+		if len(bakeOptions.TargetMeshParts) > 0 {
+			include := false
+			for _, m := range bakeOptions.TargetMeshParts {
+				if m == tri.MeshPart {
+					include = true
+					break
+				}
+			}
+			if !include {
+				continue
+			}
+		}
+
+		ao := [3]float32{0, 0, 0}
+
+		verts := tri.VertexIndices
+
+		for _, other := range model.Mesh.Triangles {
+
+			if tri == other {
+				continue
+			}
+
+			span := math32.Max(tri.MaxSpan, other.MaxSpan) * 0.66
+
+			if tri.Center.DistanceSquared(other.Center) > span*span {
+				continue
+			}
+
+			angle := tri.Normal.Angle(other.Normal)
+			if angle < bakeOptions.OcclusionAngle {
+				continue
+			}
+
+			if sharedA, sharedB, sharedC, count := tri.SharesVertexPositions(other); count > 0 {
+
+				if sharedA >= 0 {
+					ao[0] = 1
+				}
+				if sharedB >= 0 {
+					ao[1] = 1
+				}
+				if sharedC >= 0 {
+					ao[2] = 1
+				}
+
+			}
+
+			if ao[0] == 1 && ao[1] == 1 && ao[2] == 1 {
+				break
+			}
+
+		}
+
+		for i := 0; i < 3; i++ {
+			p := math32.Clamp(ao[i], 0, 1)
+			target[verts[i]].R += (bakeOptions.OcclusionColor.R - target[verts[i]].R) * p
+			target[verts[i]].G += (bakeOptions.OcclusionColor.G - target[verts[i]].G) * p
+			target[verts[i]].B += (bakeOptions.OcclusionColor.B - target[verts[i]].B) * p
+			target[verts[i]].A += (bakeOptions.OcclusionColor.A - target[verts[i]].A) * p
+		}
+
+	}
+
+	// Inter-object AO next; this is kinda slow and janky, but it does work OK, I think
+
+	if len(others) == 0 {
+		return
+	}
+
+	distanceSquared := bakeOptions.InterModelDistance * bakeOptions.InterModelDistance
+
+	for _, o := range others {
+
+		other := o.model
+		otherTransform := o.transform
+
+		for _, tri := range model.Mesh.Triangles {
+
+			progress.advance(1)
+
+			ao := [3]float32{0, 0, 0}
+
+			verts := tri.VertexIndices
+
+			transformedTriVerts := [3]Vector3{
+				transform.MultVec(model.Mesh.VertexPositions[verts[0]]),
+				transform.MultVec(model.Mesh.VertexPositions[verts[1]]),
+				transform.MultVec(model.Mesh.VertexPositions[verts[2]]),
+			}
+
+			for _, otherTri := range other.Mesh.Triangles {
+
+				otherVerts := otherTri.VertexIndices
+
+				span := tri.MaxSpan
+				if otherTri.MaxSpan > span {
+					span = otherTri.MaxSpan
+				}
+
+				span *= 0.66
+
+				if transform.MultVec(tri.Center).DistanceSquared(otherTransform.MultVec(otherTri.Center)) > span {
+					continue
+				}
+
+				transformedOtherVerts := [3]Vector3{
+					otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[0]]),
+					otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[1]]),
+					otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[2]]),
+				}
+
+				for i := 0; i < 3; i++ {
+					for j := 0; j < 3; j++ {
+						if transformedTriVerts[i].DistanceSquared(transformedOtherVerts[j]) <= distanceSquared {
+							ao[i] = 1
+							break
+						}
+					}
+				}
+
+			}
+
+			for i := 0; i < 3; i++ {
+				target[verts[i]] = target[verts[i]].Mix(bakeOptions.OcclusionColor, ao[i])
+			}
+
+		}
+
+	}
+
+}
+
+// BakeLightingGIAsync is the asynchronous version of Model.BakeLightingGI(); it bakes the provided lights (along with bounced lighting) into
+// the Model's Mesh's vertex colors on a background goroutine, returning a BakeJob to track the bake's progress. The results are applied to the
+// Mesh once the bake finishes and BakeJob.Update() (or BakeJob.Wait()) is called. If nil is passed instead of bake options, a default
+// GIBakeOptions struct will be created and used. If the Model has no Mesh or the target channel is below 0, BakeLightingGIAsync returns nil.
+func (model *Model) BakeLightingGIAsync(bakeOptions *GIBakeOptions, lights ...ILight) *BakeJob {
+
+	if bakeOptions == nil {
+		bakeOptions = NewDefaultGIBakeOptions()
+	}
+
+	if model.Mesh == nil || bakeOptions.TargetChannel < 0 {
+		return nil
+	}
+
+	bake := model.newGIBake(bakeOptions)
+
+	job := newBakeJob(model, bakeOptions.TargetChannel, bake.work())
+
+	allLights := model.bakeLights(lights)
+
+	go func() {
+		bake.bake(allLights, job.result, job.progress)
+		close(job.finished)
+	}()
+
+	return job
+
+}
+
+// giBakeTriangle is a triangle of a Model taking part in a global illumination bake, in world space.
+type giBakeTriangle struct {
+	indices      []int
+	v0           Vector3
+	edge1, edge2 Vector3
+	albedo       Color // The color of the triangle's surface, which tints the light bouncing off of it.
+}
+
+// giBakeModel is a Model taking part in a global illumination bake, with its vertices and triangles transformed into world space
+// at the time the bake was started.
+type giBakeModel struct {
+	model     *Model
+	positions []Vector3
+	normals   []Vector3
+	triangles []giBakeTriangle
+	center    Vector3
+	radius    float32
+	light     VertexColorChannel // The light falling on each vertex; this starts out as the direct lighting, and has bounces added to it.
+}
+
+// giBake is a bake of lighting with bounces for a Model, using the other Models given as bounce surfaces.
+type giBake struct {
+	options *GIBakeOptions
+	models  []*giBakeModel // The Model being baked, followed by the other Models.
+}
+
+func newGIBakeModel(model *Model) *giBakeModel {
+
+	transform := model.Transform()
+	_, _, rotation := transform.Decompose()
+
+	mesh := model.Mesh
+
+	bakeModel := &giBakeModel{
+		model:     model,
+		positions: make([]Vector3, len(mesh.VertexPositions)),
+		normals:   make([]Vector3, len(mesh.VertexPositions)),
+		triangles: make([]giBakeTriangle, 0, len(mesh.Triangles)),
+	}
+
+	min := Vector3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+	max := Vector3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+
+	for i, pos := range mesh.VertexPositions {
+		pos = transform.MultVec(pos)
+		bakeModel.positions[i] = pos
+		if i < len(mesh.VertexNormals) {
+			bakeModel.normals[i] = rotation.MultVec(mesh.VertexNormals[i]).Unit()
+		}
+		min = Vector3{math32.Min(min.X, pos.X), math32.Min(min.Y, pos.Y), math32.Min(min.Z, pos.Z)}
+		max = Vector3{math32.Max(max.X, pos.X), math32.Max(max.Y, pos.Y), math32.Max(max.Z, pos.Z)}
+	}
+
+	if len(mesh.VertexPositions) > 0 {
+		bakeModel.center = min.Add(max).Scale(0.5)
+		bakeModel.radius = max.Sub(bakeModel.center).Magnitude()
+	}
+
+	for _, tri := range mesh.Triangles {
+
+		albedo := model.Color
+		if tri.MeshPart != nil && tri.MeshPart.Material != nil {
+			albedo = albedo.Multiply(tri.MeshPart.Material.Color)
+		}
+
+		v0 := bakeModel.positions[tri.VertexIndices[0]]
+
+		bakeModel.triangles = append(bakeModel.triangles, giBakeTriangle{
+			indices: tri.VertexIndices,
+			v0:      v0,
+			edge1:   bakeModel.positions[tri.VertexIndices[1]].Sub(v0),
+			edge2:   bakeModel.positions[tri.VertexIndices[2]].Sub(v0),
+			albedo:  albedo,
+		})
+
+	}
+
+	return bakeModel
+
+}
+
+// newGIBake creates a global illumination bake for the Model, gathering the other Models (from bakeOptions.OtherModels) that light can bounce off of.
+func (model *Model) newGIBake(bakeOptions *GIBakeOptions) *giBake {
+
+	bake := &giBake{
+		options: bakeOptions,
+		models:  []*giBakeModel{newGIBakeModel(model)},
+	}
+
+	if !bakeOptions.OtherModels.IsZero() {
+
+		bakeOptions.OtherModels.ForEach(func(node INode) bool {
+			if other, ok := node.(*Model); ok && other != model && other.Mesh != nil {
+				otherModel := newGIBakeModel(other)
+				otherModel.light = make(VertexColorChannel, len(other.Mesh.VertexPositions))
+				bake.models = append(bake.models, otherModel)
+			}
+			return true
+		})
+
+	}
+
+	return bake
+
+}
+
+// work returns the units of work the bake will take, for tracking progress.
+func (bake *giBake) work() int {
+
+	all := 0
+	for _, m := range bake.models {
+		all += len(m.positions)
+	}
+
+	total := all
+
+	for b := 0; b < bake.options.Bounces; b++ {
+		if b < bake.options.Bounces-1 {
+			total += all
+		} else {
+			total += len(bake.models[0].positions)
+		}
+	}
+
+	return total
+
+}
+
+// bake bakes the lights given, along with their bounced light, into the target colors provided.
+func (bake *giBake) bake(lights []ILight, target VertexColorChannel, progress *bakeProgress) {
+
+	bake.models[0].light = target
+
+	// Direct lighting first
+
+	for _, m := range bake.models {
+		m.model.bakeLighting(lights, m.light, progress)
+	}
+
+	direct := make([]VertexColorChannel, len(bake.models))
+	for i, m := range bake.models {
+		direct[i] = append(VertexColorChannel{}, m.light...)
+	}
+
+	// Each bounce gathers the light reflected off of nearby surfaces from the previous bounce (or the direct lighting, for the first bounce).
+	// Only the last bounce is skipped for the other Models, as nothing gathers light from them afterwards.
+
+	for b := 0; b < bake.options.Bounces; b++ {
+
+		receivers := bake.models
+		if b == bake.options.Bounces-1 {
+			receivers = bake.models[:1]
+		}
+
+		gathered := make([]VertexColorChannel, len(receivers))
+
+		for i, m := range receivers {
+			gathered[i] = bake.gatherModel(m, direct[i], progress)
+		}
+
+		// The new light is only applied once all receivers have gathered, so that every receiver gathers light from the same bounce
+		for i, m := range receivers {
+			for v := range m.light {
+				m.light[v].R = gathered[i][v].R
+				m.light[v].G = gathered[i][v].G
+				m.light[v].B = gathered[i][v].B
+			}
+		}
+
+	}
+
+}
+
+// gatherModel returns the given direct lighting with the light bounced onto each of the Model's vertices added to it.
+func (bake *giBake) gatherModel(m *giBakeModel, direct VertexColorChannel, progress *bakeProgress) VertexColorChannel {
+
+	result := append(VertexColorChannel{}, direct...)
+
+	for _, mp := range m.model.Mesh.MeshParts {
+
+		if mp.Material == nil || !mp.Material.Shadeless {
+
+			mp.ForEachVertexIndex(func(vertIndex int) {
+				bounce := bake.gather(m.positions[vertIndex], m.normals[vertIndex], vertIndex)
+				result[vertIndex].R += bounce.R
+				result[vertIndex].G += bounce.G
+				result[vertIndex].B += bounce.B
+			}, false)
+
+		}
+
+		progress.advance(mp.VertexIndexEnd - mp.VertexIndexStart)
+
+	}
+
+	return result
+
+}
+
+// gather returns the light bounced onto a point with the given world position and normal by casting rays across the hemisphere around the normal.
+func (bake *giBake) gather(position, normal Vector3, seed int) Color {
+
+	if normal.IsZero() || bake.options.Samples <= 0 {
+		return Color{}
+	}
+
+	helper := WorldUp
+	if math32.Abs(normal.Y) > 0.99 {
+		helper = WorldRight
+	}
+
+	tangent := helper.Cross(normal).Unit()
+	bitangent := normal.Cross(tangent)
+
+	origin := position.Add(normal.Scale(0.001))
+
+	total := Color{}
+
+	// The ray directions are cosine-weighted across the hemisphere in a golden angle spiral, so the result is noise-free and doesn't have to
+	// be weighted by the angle. Each vertex rotates the spiral slightly to break up banding between neighboring vertices.
+	offset := float32(seed) * 2.39996
+
+	for s := 0; s < bake.options.Samples; s++ {
+
+		u := (float32(s) + 0.5) / float32(bake.options.Samples)
+		r := math32.Sqrt(u)
+		phi := float32(s)*2.39996 + offset
+
+		dir := tangent.Scale(r * math32.Cos(phi)).Add(bitangent.Scale(r * math32.Sin(phi))).Add(normal.Scale(math32.Sqrt(1 - u)))
+
+		if color, ok := bake.trace(origin, dir); ok {
+			total.R += color.R
+			total.G += color.G
+			total.B += color.B
+		}
+
+	}
+
+	return total.MultiplyScalarRGB(bake.options.Strength / float32(bake.options.Samples))
+
+}
+
+// trace casts a ray from the given origin in the given (normalized) direction, returning the light reflected off of the closest front-facing
+// surface struck within the bake's Distance.
+func (bake *giBake) trace(origin, dir Vector3) (Color, bool) {
+
+	closest := bake.options.Distance
+	var hitModel *giBakeModel
+	var hitTri *giBakeTriangle
+	var hitU, hitV float32
+	frontFacing := false
+
+	for _, m := range bake.models {
+
+		// Skip Models that the ray can't reach
+		t := math32.Clamp(m.center.Sub(origin).Dot(dir), 0, closest)
+		if origin.Add(dir.Scale(t)).DistanceSquared(m.center) > m.radius*m.radius {
+			continue
+		}
+
+		for i := range m.triangles {
+
+			tri := &m.triangles[i]
+
+			// Möller–Trumbore ray-triangle intersection
+			p := dir.Cross(tri.edge2)
+			det := tri.edge1.Dot(p)
+			if math32.Abs(det) < 1e-8 {
+				continue
+			}
+
+			inv := 1 / det
+			s := origin.Sub(tri.v0)
+
+			u := s.Dot(p) * inv
+			if u < 0 || u > 1 {
+				continue
+			}
+
+			q := s.Cross(tri.edge1)
+			v := dir.Dot(q) * inv
+			if v < 0 || u+v > 1 {
+				continue
+			}
+
+			dist := tri.edge2.Dot(q) * inv
+			if dist <= 1e-4 || dist >= closest {
+				continue
+			}
+
+			closest = dist
+			hitModel = m
+			hitTri = tri
+			hitU = u
+			hitV = v
+			frontFacing = det > 0
+
+		}
+
+	}
+
+	// Backfaces still block the ray, but don't reflect any light
+	if hitTri == nil || !frontFacing {
+		return Color{}, false
+	}
+
+	light := hitModel.light
+	c0 := light[hitTri.indices[0]]
+	c1 := light[hitTri.indices[1]]
+	c2 := light[hitTri.indices[2]]
+	w := 1 - hitU - hitV
+
+	return NewColor(
+		(c0.R*w+c1.R*hitU+c2.R*hitV)*hitTri.albedo.R,
+		(c0.G*w+c1.G*hitU+c2.G*hitV)*hitTri.albedo.G,
+		(c0.B*w+c1.B*hitU+c2.B*hitV)*hitTri.albedo.B,
+		1,
+	), true
+
+}
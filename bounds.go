@@ -12,7 +12,24 @@ type Intersection struct {
 	ContactPoint  Vector3   // The contact point for the intersection on the second, collided object in world space (i.e. the point of collision on the triangle in a Sphere>Triangles test).
 	MTV           Vector3   // MTV represents the minimum translation vector to remove the calling object from the intersecting object.
 	Triangle      *Triangle // Triangle represents the triangle that was intersected in intersection tests that involve triangle meshes; if no triangle mesh was tested against, then this will be nil.
-	Normal        Vector3
+	Normal        Vector3   // Normal is the world normal of the collided object's surface at the contact point; for triangles, this is the triangle's face normal.
+}
+
+// ContactNormal returns the direction the calling object is pushed in to resolve the intersection (the direction of the MTV). This usually
+// matches the intersection's Normal, but differs when the calling object touches an edge or corner of a triangle rather than its face
+// (see Intersection.IsEdgeContact()). If the MTV is zero, the Normal is returned instead.
+func (intersection *Intersection) ContactNormal() Vector3 {
+	if intersection.MTV.IsZero() {
+		return intersection.Normal
+	}
+	return intersection.MTV.Unit()
+}
+
+// IsEdgeContact returns if the intersection is against an edge or corner of the collided object rather than against its face; that is, if
+// the calling object is being pushed out in a different direction than the collided surface's Normal. This is useful for ignoring bumps
+// when sliding across the seams between triangles in a mesh, for example.
+func (intersection *Intersection) IsEdgeContact() bool {
+	return intersection.ContactNormal().Dot(intersection.Normal) < 0.999
 }
 
 // Slope returns the slope of the intersection's normal, in radians. This ranges from 0 (straight up) to pi (straight down).
@@ -35,6 +52,63 @@ func (intersection *Intersection) SlideAgainstNormal(movementVec Vector3) Vector
 
 }
 
+// SlopeMTV is an MTV decomposed into ground and wall components for walking characters; see Intersection.ResolveSlope() and Collision.ResolveSlope().
+type SlopeMTV struct {
+	// Ground is the push out of walkable surfaces (surfaces with a slope no steeper than the maximum slope). It points purely along the up
+	// vector, so walking uphill lifts the character onto the slope rather than pushing them back down it.
+	Ground Vector3
+	// Wall is the push out of surfaces too steep to walk on. For walls and steep slopes, it's perpendicular to the up vector, so steep slopes
+	// stop the character like walls rather than lifting them up; for ceilings (surfaces facing against the up vector), it's left as-is.
+	Wall Vector3
+}
+
+// MTV returns the total MTV to resolve the intersection(s), which is the sum of the Ground and Wall components.
+func (slope SlopeMTV) MTV() Vector3 {
+	return slope.Ground.Add(slope.Wall)
+}
+
+// OnGround returns if the SlopeMTV has a ground component; that is, if the character is standing on a walkable surface.
+func (slope SlopeMTV) OnGround() bool {
+	return !slope.Ground.IsZero()
+}
+
+// ResolveSlope decomposes the intersection's MTV into ground and wall components, given the up vector (usually WorldUp) and the maximum
+// walkable slope in radians. If the intersection's ContactNormal() is no more than maxSlope away from up, the surface is walkable and the MTV
+// is turned into a push straight along up; otherwise, the surface is treated as a wall, and the MTV is turned into a push perpendicular to up.
+func (intersection *Intersection) ResolveSlope(up Vector3, maxSlope float32) SlopeMTV {
+
+	depth := intersection.MTV.Magnitude()
+
+	if depth == 0 {
+		return SlopeMTV{}
+	}
+
+	up = up.Unit()
+	normal := intersection.MTV.Scale(1 / depth)
+	upDot := normal.Dot(up)
+
+	if up.Angle(normal) <= maxSlope && upDot > 0 {
+		// Push along up far enough to move the same distance out along the normal
+		return SlopeMTV{Ground: up.Scale(depth / upDot)}
+	}
+
+	// Ceilings stay as they are, pushing the character down
+	if upDot < 0 {
+		return SlopeMTV{Wall: intersection.MTV}
+	}
+
+	flat := normal.Sub(up.Scale(upDot))
+	flatLength := flat.Magnitude()
+
+	if flatLength < 0.0001 {
+		return SlopeMTV{Wall: intersection.MTV}
+	}
+
+	// Push perpendicular to up far enough to move the same distance out along the normal
+	return SlopeMTV{Wall: flat.Scale(depth / (flatLength * flatLength))}
+
+}
+
 // Collision represents the result of a collision test. A Collision test may result in multiple intersections, and
 // so an Collision holds each of these individual intersections in its Intersections slice.
 // The intersections are sorted in order of distance from the starting point of the intersection (the center of the
@@ -86,6 +160,40 @@ func (col *Collision) AverageMTV() Vector3 {
 	return mtv
 }
 
+// ResolveSlope decomposes the Collision's MTVs into ground and wall components, given the up vector (usually WorldUp) and the maximum walkable
+// slope in radians; see Intersection.ResolveSlope() for more information. Like AverageMTV(), the wall components of all Intersections are
+// added together for the direction, using the greatest one's magnitude for the distance; the ground component is the greatest of all
+// Intersections' ground components.
+func (col *Collision) ResolveSlope(up Vector3, maxSlope float32) SlopeMTV {
+
+	result := SlopeMTV{}
+	greatestWall := float32(0)
+
+	for _, inter := range col.Intersections {
+
+		if inter.MTV.IsInf() || inter.MTV.IsNaN() {
+			continue
+		}
+
+		slope := inter.ResolveSlope(up, maxSlope)
+
+		if slope.Ground.MagnitudeSquared() > result.Ground.MagnitudeSquared() {
+			result.Ground = slope.Ground
+		}
+
+		if mag := slope.Wall.Magnitude(); mag > 0 {
+			greatestWall = math32.Max(greatestWall, mag)
+			result.Wall = result.Wall.Add(slope.Wall)
+		}
+
+	}
+
+	result.Wall = result.Wall.Unit().Scale(greatestWall)
+
+	return result
+
+}
+
 // AverageNormal returns the average normal vector from all Intersections contained within the Collision.
 func (col *Collision) AverageNormal() Vector3 {
 	normal := col.Intersections[0].Normal
@@ -127,6 +235,27 @@ func (result *Collision) AverageSlope() float32 {
 	return slope / slopeCount
 }
 
+// ContactPoints returns the world contact points of all Intersections contained within the Collision, in the same order as the Intersections.
+func (col *Collision) ContactPoints() []Vector3 {
+	points := make([]Vector3, 0, len(col.Intersections))
+	for _, inter := range col.Intersections {
+		points = append(points, inter.ContactPoint)
+	}
+	return points
+}
+
+// Triangles returns the triangles intersected in the Collision (one for each Intersection that has a Triangle, so a triangle may be present
+// more than once). If the collided object wasn't a triangle mesh, the returned slice is empty.
+func (col *Collision) Triangles() []*Triangle {
+	triangles := []*Triangle{}
+	for _, inter := range col.Intersections {
+		if inter.Triangle != nil {
+			triangles = append(triangles, inter.Triangle)
+		}
+	}
+	return triangles
+}
+
 // AverageContactPoint returns the average world contact point out of the contact points of all Intersections
 // contained within the Collision.
 func (result *Collision) AverageContactPoint() Vector3 {
@@ -161,6 +290,17 @@ type CollisionTestSettings struct {
 	// This being the case, you may need to store and re-sort the collisions to fit your needs.
 	// index is the index of the collision out of the total number of collisions, which is the count.
 	OnCollision func(col *Collision, index, count int) bool
+
+	// Continuous enables continuous collision detection for the calling object. Rather than only being tested at its current position,
+	// the calling object is swept from ContinuousFrom to its current position, so that fast-moving objects (like bullets or falling characters)
+	// can't pass through thin objects (like BoundingTriangles floors) between tests at low frame rates. If the swept object strikes an object
+	// that it doesn't collide with at its current position, the resulting Collision's MTV moves it back out along the struck surface's normal.
+	// Continuous collision detection only works for convex calling objects (spheres, capsules, AABBs, OBBs, and convex hulls); it's
+	// ignored for BoundingTriangles.
+	Continuous bool
+	// ContinuousFrom is the world position the calling object is swept from when Continuous is true - usually, this would be the object's
+	// position before it moved this frame.
+	ContinuousFrom Vector3
 }
 
 // IBoundingObject represents a Node type that can be tested for collision. The exposed functions are essentially just
@@ -432,8 +572,9 @@ func btAABBTriangles(box *BoundingAABB, triangles *BoundingTriangles) *Collision
 
 		for _, axis := range axes {
 
+			// Edges parallel to one of the box's axes produce no separating axis, so we skip them
 			if axis.IsZero() {
-				return nil
+				continue
 			}
 
 			axis = axis.Unit()
@@ -471,7 +612,7 @@ func btAABBTriangles(box *BoundingAABB, triangles *BoundingTriangles) *Collision
 				ContactPoint:  closestPointOnTri(Vector3{0, 0, 0}, v0, v1, v2).Add(boxPos),
 				MTV:           mtv,
 				Triangle:      tri,
-				Normal:        axes[12],
+				Normal:        axes[12].Unit(),
 			})
 		}
 
@@ -498,6 +639,12 @@ func btTrianglesTriangles(trianglesA, trianglesB *BoundingTriangles) *Collision
 	transformA := trianglesA.Transform()
 	transformB := trianglesB.Transform()
 
+	// Normals are only rotated and scaled, not moved
+	transformNoLocA := transformA.Clone()
+	transformNoLocA.SetRow(3, Vector4{0, 0, 0, 1})
+	transformNoLocB := transformB.Clone()
+	transformNoLocB.SetRow(3, Vector4{0, 0, 0, 1})
+
 	transformedA := [][]Vector3{}
 	transformedB := [][]Vector3{}
 
@@ -519,7 +666,7 @@ func btTrianglesTriangles(trianglesA, trianglesB *BoundingTriangles) *Collision
 					v1.Sub(v0).Unit(),
 					v2.Sub(v1).Unit(),
 					v0.Sub(v2).Unit(),
-					transformA.MultVec(tri.Normal),
+					transformNoLocA.MultVec(tri.Normal).Unit(),
 				},
 			)
 
@@ -547,7 +694,7 @@ func btTrianglesTriangles(trianglesA, trianglesB *BoundingTriangles) *Collision
 					v1.Sub(v0).Unit(),
 					v2.Sub(v1).Unit(),
 					v0.Sub(v2).Unit(),
-					transformB.MultVec(tri.Normal),
+					transformNoLocB.MultVec(tri.Normal).Unit(),
 				},
 			)
 
@@ -573,8 +720,8 @@ func btTrianglesTriangles(trianglesA, trianglesB *BoundingTriangles) *Collision
 				a[5].Cross(b[4]),
 				a[5].Cross(b[5]),
 
-				transformA.MultVec(a[6]),
-				transformB.MultVec(b[6]),
+				a[6],
+				b[6],
 			}
 
 			var overlapAxis Vector3
@@ -582,8 +729,9 @@ func btTrianglesTriangles(trianglesA, trianglesB *BoundingTriangles) *Collision
 
 			for _, axis := range axes {
 
+				// Parallel edges produce no separating axis, so we skip them
 				if axis.IsZero() {
-					return nil
+					continue
 				}
 
 				axis = axis.Unit()
@@ -607,14 +755,14 @@ func btTrianglesTriangles(trianglesA, trianglesB *BoundingTriangles) *Collision
 
 			if !overlapAxis.IsZero() {
 				mtv := overlapAxis.Scale(smallestOverlap)
+				aCenter := a[0].Add(a[1]).Add(a[2]).Scale(1.0 / 3.0)
 				result.add(
 					&Intersection{
-						StartingPoint: transformA.MultVec(bTris[bTriIndex].Center),
-						// ContactPoint: b[0].Add(b[1]).Add(b[2]).Scale(1.0 / 3.0),
-						ContactPoint: trianglesB.WorldPosition().Add(mtv),
-						MTV:          mtv,
-						Triangle:     bTris[bTriIndex],
-						Normal:       b[6],
+						StartingPoint: aCenter,
+						ContactPoint:  closestPointOnTri(aCenter, b[0], b[1], b[2]),
+						MTV:           mtv,
+						Triangle:      bTris[bTriIndex],
+						Normal:        b[6],
 					},
 				)
 			}
@@ -818,6 +966,11 @@ func commonCollisionTest(node INode, settings CollisionTestSettings) bool {
 
 	internalCollisionList = internalCollisionList[:0]
 
+	var sweep *collisionSweep
+	if settings.Continuous {
+		sweep = newCollisionSweep(node.(IBoundingObject), settings.ContinuousFrom)
+	}
+
 	settings.TestAgainst.ForEach(func(checking INode) bool {
 
 		bounds, ok := checking.(IBoundingObject)
@@ -828,6 +981,10 @@ func commonCollisionTest(node INode, settings CollisionTestSettings) bool {
 
 		if collision := node.(IBoundingObject).Collision(bounds); collision != nil {
 			internalCollisionList = append(internalCollisionList, collision)
+		} else if sweep != nil {
+			if collision := sweep.collision(bounds); collision != nil {
+				internalCollisionList = append(internalCollisionList, collision)
+			}
 		}
 
 		return true
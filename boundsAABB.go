@@ -228,6 +228,15 @@ func (box *BoundingAABB) Collision(other IBoundingObject) *Collision {
 	case *BoundingTriangles:
 		return btAABBTriangles(box, otherBounds)
 
+	case *BoundingOBB:
+		return btBoxBox(aabbOrientedBox(box), otherBounds.orientedBox(), otherBounds)
+
+	case *BoundingConvexHull:
+		return btConvexHull(aabbOrientedBox(box), otherBounds)
+
+	case *BoundingHeightfield:
+		return btConvexHeightfield(aabbOrientedBox(box), otherBounds)
+
 	case *BoundingCapsule:
 		intersection := btCapsuleAABB(otherBounds, box)
 		if intersection != nil {
@@ -78,6 +78,15 @@ func (capsule *BoundingCapsule) Collision(other IBoundingObject) *Collision {
 	case *BoundingTriangles:
 		return btCapsuleTriangles(capsule, otherBounds)
 
+	case *BoundingOBB:
+		return btCapsuleOBB(capsule, otherBounds)
+
+	case *BoundingConvexHull:
+		return btConvexHull(capsule.shape(), otherBounds)
+
+	case *BoundingHeightfield:
+		return btConvexHeightfield(capsule.shape(), otherBounds)
+
 	}
 
 	panic("Unimplemented bounds type")
@@ -0,0 +1,507 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// BoundingConvexHull represents the convex hull of a set of points (usually the vertices of a Mesh); this is the smallest convex shape
+// that encloses all of the points, like a sheet of plastic shrink-wrapped around them. BoundingConvexHulls fit irregular objects like
+// rocks or vehicles much more tightly than capsules or boxes, while being much faster to test against than BoundingTriangles, as they're
+// tested as a single solid shape rather than triangle by triangle.
+// Like the other Bounding* Nodes, the primary purpose of a BoundingConvexHull is to perform intersection testing between itself and
+// other BoundingObject Nodes.
+type BoundingConvexHull struct {
+	*Node
+	Vertices []Vector3 // The vertices of the convex hull in local space; set these using BoundingConvexHull.SetPoints().
+	Faces    [][3]int  // The triangular faces of the convex hull as indices into Vertices, wound counter-clockwise when seen from outside.
+
+	worldVertices []Vector3
+}
+
+// NewBoundingConvexHull returns a new BoundingConvexHull Node, using the convex hull of the vertices of the given Mesh.
+func NewBoundingConvexHull(name string, mesh *Mesh) *BoundingConvexHull {
+	hull := &BoundingConvexHull{
+		Node: NewNode(name),
+	}
+	hull.owner = hull
+	hull.Node.onTransformUpdate = hull.updateWorldVertices
+	hull.SetPoints(mesh.VertexPositions...)
+	return hull
+}
+
+// Clone returns a new BoundingConvexHull.
+func (hull *BoundingConvexHull) Clone() INode {
+	clone := &BoundingConvexHull{
+		// The hull's vertices and faces are replaced rather than modified, so they can be shared
+		Vertices: hull.Vertices,
+		Faces:    hull.Faces,
+	}
+	clone.Node = hull.Node.clone(clone).(*Node)
+	clone.owner = clone
+	clone.Node.onTransformUpdate = clone.updateWorldVertices
+	clone.updateWorldVertices()
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+	return clone
+}
+
+// SetPoints sets the BoundingConvexHull to be the convex hull of the given points in local space, generating the hull using
+// the quickhull algorithm. If the points are all on a line or plane, the BoundingConvexHull's Vertices are set to the points
+// as given, with no Faces.
+func (hull *BoundingConvexHull) SetPoints(points ...Vector3) {
+	hull.Vertices, hull.Faces = quickhull(points)
+	hull.updateWorldVertices()
+}
+
+// updateWorldVertices updates the world positions of the BoundingConvexHull's vertices.
+// This is be called automatically internally as necessary after the node's transform is updated.
+func (hull *BoundingConvexHull) updateWorldVertices() {
+
+	transform := hull.Node.Transform()
+
+	if cap(hull.worldVertices) < len(hull.Vertices) {
+		hull.worldVertices = make([]Vector3, len(hull.Vertices))
+	}
+
+	hull.worldVertices = hull.worldVertices[:len(hull.Vertices)]
+
+	for i, v := range hull.Vertices {
+		hull.worldVertices[i] = transform.MultVec(v)
+	}
+
+}
+
+// WorldVertices returns the world positions of the BoundingConvexHull's vertices.
+// The returned slice is shared with the BoundingConvexHull, and so shouldn't be modified.
+func (hull *BoundingConvexHull) WorldVertices() []Vector3 {
+	hull.Transform() // Make sure the world vertices are up to date
+	return hull.worldVertices
+}
+
+// shape returns the BoundingConvexHull as a convexShape in world space.
+func (hull *BoundingConvexHull) shape() pointsShape {
+	return pointsShape(hull.WorldVertices())
+}
+
+// PointInside returns whether the given point is inside of the BoundingConvexHull or not.
+func (hull *BoundingConvexHull) PointInside(point Vector3) bool {
+
+	if len(hull.Faces) == 0 {
+		return false
+	}
+
+	vertices := hull.WorldVertices()
+
+	for _, face := range hull.Faces {
+		v0 := vertices[face[0]]
+		normal := vertices[face[1]].Sub(v0).Cross(vertices[face[2]].Sub(v0))
+		if normal.Dot(point.Sub(v0)) > 0 {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+// Colliding returns true if the BoundingConvexHull collides with another IBoundingObject.
+func (hull *BoundingConvexHull) Colliding(other IBoundingObject) bool {
+	return hull.Collision(other) != nil
+}
+
+// Collision returns the Collision between the BoundingConvexHull and the other IBoundingObject. If
+// there is no intersection, the function returns nil.
+func (hull *BoundingConvexHull) Collision(other IBoundingObject) *Collision {
+
+	if other == hull || other == nil || len(hull.Vertices) == 0 {
+		return nil
+	}
+
+	switch otherBounds := other.(type) {
+
+	case *BoundingConvexHull:
+		return btConvexHull(hull.shape(), otherBounds)
+
+	case *BoundingSphere:
+		return btConvexShapes(hull.shape(), otherBounds.shape(), otherBounds)
+
+	case *BoundingCapsule:
+		return btConvexShapes(hull.shape(), otherBounds.shape(), otherBounds)
+
+	case *BoundingAABB:
+		return btConvexShapes(hull.shape(), aabbOrientedBox(otherBounds), otherBounds)
+
+	case *BoundingOBB:
+		return btConvexShapes(hull.shape(), otherBounds.orientedBox(), otherBounds)
+
+	case *BoundingTriangles:
+		return btConvexHullTriangles(hull, otherBounds)
+
+	case *BoundingHeightfield:
+		return btConvexHeightfield(hull.shape(), otherBounds)
+
+	}
+
+	panic("Unimplemented bounds type")
+
+}
+
+// CollisionTest performs a collision test using the provided collision test settings structure.
+// Collisions reported will be sorted in distance from closest to furthest.
+// The function will return if a collision was found with the convex hull at the settings specified.
+func (hull *BoundingConvexHull) CollisionTest(settings CollisionTestSettings) bool {
+	return commonCollisionTest(hull, settings)
+}
+
+// Type returns the NodeType for this object.
+func (hull *BoundingConvexHull) Type() NodeType {
+	return NodeTypeBoundingConvexHull
+}
+
+// btConvexHull tests the given convex shape against the given BoundingConvexHull for intersection.
+func btConvexHull(shape convexShape, hull *BoundingConvexHull) *Collision {
+	if len(hull.Vertices) == 0 {
+		return nil
+	}
+	return btConvexShapes(shape, hull.shape(), hull)
+}
+
+func btConvexHullTriangles(hull *BoundingConvexHull, triangles *BoundingTriangles) *Collision {
+
+	// If we're not intersecting the triangle's bounding AABB, we couldn't possibly be colliding with any of the triangles, so we're good
+	if !hull.Colliding(triangles.BoundingAABB) {
+		return nil
+	}
+
+	shape := hull.shape()
+
+	transform := triangles.Transform()
+	transformNoLoc := transform.Clone()
+	transformNoLoc.SetRow(3, Vector4{0, 0, 0, 1})
+
+	result := newCollision(triangles)
+
+	tris := triangles.Broadphase.TrianglesFromBoundingObject(hull)
+
+	for triID := range tris {
+
+		tri := triangles.Mesh.Triangles[triID]
+
+		triShape := pointsShape{
+			transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[0]]),
+			transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[1]]),
+			transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[2]]),
+		}
+
+		if col := btConvexShapes(shape, triShape, triangles); col != nil {
+			inter := col.Intersections[0]
+			inter.Triangle = tri
+			inter.Normal = transformNoLoc.MultVec(tri.Normal).Unit()
+			result.add(inter)
+		}
+
+	}
+
+	if len(result.Intersections) == 0 {
+		return nil
+	}
+
+	result.sortResults()
+
+	return result
+
+}
+
+// quickhull returns the vertices and triangular faces of the convex hull of the given points using the quickhull algorithm.
+// If the points don't form a 3D volume (i.e. they're all on a plane), the points are returned as-is, with no faces.
+func quickhull(points []Vector3) ([]Vector3, [][3]int) {
+
+	flat := func() ([]Vector3, [][3]int) {
+		return append([]Vector3{}, points...), nil
+	}
+
+	if len(points) < 4 {
+		return flat()
+	}
+
+	// The extreme points along each axis are used to find the initial tetrahedron
+	extremes := [6]int{}
+	for i, p := range points {
+		if p.X < points[extremes[0]].X {
+			extremes[0] = i
+		}
+		if p.X > points[extremes[1]].X {
+			extremes[1] = i
+		}
+		if p.Y < points[extremes[2]].Y {
+			extremes[2] = i
+		}
+		if p.Y > points[extremes[3]].Y {
+			extremes[3] = i
+		}
+		if p.Z < points[extremes[4]].Z {
+			extremes[4] = i
+		}
+		if p.Z > points[extremes[5]].Z {
+			extremes[5] = i
+		}
+	}
+
+	span := math32.Max(points[extremes[1]].X-points[extremes[0]].X, math32.Max(points[extremes[3]].Y-points[extremes[2]].Y, points[extremes[5]].Z-points[extremes[4]].Z))
+	epsilon := span * 0.00001
+
+	// The first two points are the two extreme points farthest apart
+	i0, i1 := extremes[0], extremes[1]
+	for i := 0; i < 6; i++ {
+		for j := i + 1; j < 6; j++ {
+			if points[extremes[i]].DistanceSquared(points[extremes[j]]) > points[i0].DistanceSquared(points[i1]) {
+				i0, i1 = extremes[i], extremes[j]
+			}
+		}
+	}
+
+	if points[i0].Distance(points[i1]) <= epsilon {
+		return flat()
+	}
+
+	// The third is the point farthest from the line between them
+	i2 := -1
+	farthest := epsilon
+	for i, p := range points {
+		if d := p.Distance(ClosestPointOnLine(points[i0], points[i1], p)); d > farthest {
+			i2 = i
+			farthest = d
+		}
+	}
+
+	if i2 < 0 {
+		return flat()
+	}
+
+	// And the fourth is the point farthest from the plane of the first three
+	i3 := -1
+	farthest = epsilon
+	planeNormal := points[i1].Sub(points[i0]).Cross(points[i2].Sub(points[i0])).Unit()
+	for i, p := range points {
+		if d := math32.Abs(planeNormal.Dot(p.Sub(points[i0]))); d > farthest {
+			i3 = i
+			farthest = d
+		}
+	}
+
+	if i3 < 0 {
+		return flat()
+	}
+
+	faces := []*hullFace{}
+
+	center := points[i0].Add(points[i1]).Add(points[i2]).Add(points[i3]).Scale(0.25)
+
+	for _, f := range [][3]int{{i0, i1, i2}, {i0, i1, i3}, {i0, i2, i3}, {i1, i2, i3}} {
+		face := newHullFace(points, f[0], f[1], f[2])
+		// Faces are wound to face away from the center of the tetrahedron (and so the hull)
+		if face.distance(center) > 0 {
+			face = newHullFace(points, f[0], f[2], f[1])
+		}
+		faces = append(faces, face)
+	}
+
+	for i := range points {
+		if i != i0 && i != i1 && i != i2 && i != i3 {
+			assignHullPoint(points, faces, i, epsilon)
+		}
+	}
+
+	for {
+
+		// Any face that has points outside of it is expanded towards the farthest one
+		var face *hullFace
+		for _, f := range faces {
+			if len(f.outside) > 0 {
+				face = f
+				break
+			}
+		}
+
+		if face == nil {
+			break
+		}
+
+		eyeIndex := face.outside[0]
+		for _, i := range face.outside[1:] {
+			if face.distance(points[i]) > face.distance(points[eyeIndex]) {
+				eyeIndex = i
+			}
+		}
+
+		eye := points[eyeIndex]
+
+		// The faces visible from the point are replaced with faces connecting the edges around them (the horizon) to the point
+		edges := map[[2]int]bool{}
+		orphans := []int{}
+		remaining := faces[:0]
+
+		for _, f := range faces {
+
+			if f.distance(eye) > epsilon {
+				for e := 0; e < 3; e++ {
+					edges[[2]int{f.indices[e], f.indices[(e+1)%3]}] = true
+				}
+				orphans = append(orphans, f.outside...)
+			} else {
+				remaining = append(remaining, f)
+			}
+
+		}
+
+		faces = remaining
+		newFaces := []*hullFace{}
+
+		for edge := range edges {
+			if !edges[[2]int{edge[1], edge[0]}] {
+				newFaces = append(newFaces, newHullFace(points, edge[0], edge[1], eyeIndex))
+			}
+		}
+
+		for _, i := range orphans {
+			if i != eyeIndex {
+				assignHullPoint(points, newFaces, i, epsilon)
+			}
+		}
+
+		faces = append(faces, newFaces...)
+
+	}
+
+	// Only the points that make up the hull's faces are kept
+	vertices := []Vector3{}
+	vertexIndices := map[int]int{}
+	outFaces := make([][3]int, 0, len(faces))
+
+	for _, f := range faces {
+
+		var outFace [3]int
+
+		for i, index := range f.indices {
+
+			newIndex, exists := vertexIndices[index]
+			if !exists {
+				newIndex = len(vertices)
+				vertexIndices[index] = newIndex
+				vertices = append(vertices, points[index])
+			}
+
+			outFace[i] = newIndex
+
+		}
+
+		outFaces = append(outFaces, outFace)
+
+	}
+
+	return vertices, outFaces
+
+}
+
+// hullFace is a triangular face of a convex hull being generated by quickhull().
+type hullFace struct {
+	indices [3]int
+	normal  Vector3
+	offset  float32
+	outside []int // The indices of the points outside of the face that haven't been added to the hull
+}
+
+func newHullFace(points []Vector3, a, b, c int) *hullFace {
+	normal := points[b].Sub(points[a]).Cross(points[c].Sub(points[a])).Unit()
+	return &hullFace{
+		indices: [3]int{a, b, c},
+		normal:  normal,
+		offset:  normal.Dot(points[a]),
+	}
+}
+
+// distance returns the signed distance of the given point from the face's plane; positive distances are outside of the face.
+func (face *hullFace) distance(point Vector3) float32 {
+	return face.normal.Dot(point) - face.offset
+}
+
+// assignHullPoint adds the given point to the outside set of the face that it's farthest outside of, if it's outside of any.
+func assignHullPoint(points []Vector3, faces []*hullFace, index int, epsilon float32) {
+
+	var best *hullFace
+	bestDistance := epsilon
+
+	for _, f := range faces {
+		if d := f.distance(points[index]); d > bestDistance {
+			best = f
+			bestDistance = d
+		}
+	}
+
+	if best != nil {
+		best.outside = append(best.outside, index)
+	}
+
+}
+
+func boundingConvexHullRayTest(from, to Vector3, test *BoundingConvexHull) (RayHit, bool) {
+
+	if len(test.Faces) == 0 {
+		return RayHit{}, false
+	}
+
+	vertices := test.WorldVertices()
+
+	rayLine := to.Sub(from)
+
+	// The ray is clipped against each face of the hull in terms of how far along the ray it is (from 0 at the start to 1 at the end)
+	tmin := float32(-math.MaxFloat32)
+	tmax := float32(math.MaxFloat32)
+
+	var normal Vector3
+
+	for _, face := range test.Faces {
+
+		v0 := vertices[face[0]]
+		faceNormal := vertices[face[1]].Sub(v0).Cross(vertices[face[2]].Sub(v0)).Unit()
+
+		dist := faceNormal.Dot(from.Sub(v0))
+		dir := faceNormal.Dot(rayLine)
+
+		if math32.Abs(dir) < 1e-8 {
+			if dist > 0 {
+				return RayHit{}, false
+			}
+			continue
+		}
+
+		t := -dist / dir
+
+		if dir < 0 {
+			// The ray enters the hull through this face
+			if t > tmin {
+				tmin = t
+				normal = faceNormal
+			}
+		} else {
+			tmax = math32.Min(tmax, t)
+		}
+
+	}
+
+	// Rays starting inside of the hull don't hit it, like with the other bounding objects
+	if tmin < 0 || tmin > tmax || tmin > 1 {
+		return RayHit{}, false
+	}
+
+	return RayHit{
+		Object:   test,
+		Position: from.Add(rayLine.Scale(tmin)),
+		Normal:   normal,
+		from:     from,
+	}, true
+
+}
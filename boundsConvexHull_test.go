@@ -0,0 +1,64 @@
+package tetra3d
+
+import "testing"
+
+func TestQuickhullCube(t *testing.T) {
+
+	corners := []Vector3{
+		{-1, -1, -1}, {1, -1, -1}, {-1, 1, -1}, {1, 1, -1},
+		{-1, -1, 1}, {1, -1, 1}, {-1, 1, 1}, {1, 1, 1},
+	}
+
+	tests := []struct {
+		name   string
+		points []Vector3
+	}{
+		{"corners", corners},
+		{"corners and center", append([]Vector3{{0, 0, 0}}, corners...)},
+		{"corners and inner points", append(append([]Vector3{}, corners...), Vector3{0.5, 0.5, 0.5}, Vector3{-0.25, 0.9, 0}, Vector3{0, 0, -0.99})},
+		{"corners and face centers", append(append([]Vector3{}, corners...), Vector3{1, 0, 0}, Vector3{0, -1, 0}, Vector3{0, 0, 1})},
+	}
+
+	for _, test := range tests {
+
+		vertices, faces := quickhull(test.points)
+
+		// Points on the hull's faces (i.e. the centers of the cube's faces) may be kept as vertices, so only the corners are required
+		for _, corner := range corners {
+			found := false
+			for _, v := range vertices {
+				if v.Equals(corner) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("%s: corner %s is missing from the hull", test.name, corner)
+			}
+		}
+
+		for _, v := range vertices {
+			if v.X > -1 && v.X < 1 && v.Y > -1 && v.Y < 1 && v.Z > -1 && v.Z < 1 {
+				t.Errorf("%s: point %s inside of the cube is part of the hull", test.name, v)
+			}
+		}
+
+		if len(faces) < 12 {
+			t.Errorf("%s: hull has %d faces; expected at least 12", test.name, len(faces))
+		}
+
+		// Every face should face outwards, so no vertex of the hull is in front of any face
+		for _, face := range faces {
+			a, b, c := vertices[face[0]], vertices[face[1]], vertices[face[2]]
+			normal := b.Sub(a).Cross(c.Sub(a)).Unit()
+			for _, v := range vertices {
+				if v.Sub(a).Dot(normal) > 0.001 {
+					t.Errorf("%s: vertex %s is in front of face %v", test.name, v, face)
+					break
+				}
+			}
+		}
+
+	}
+
+}
@@ -0,0 +1,577 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// BoundingHeightfield represents a landscape-like surface generated from a grid of height samples (a heightmap), with everything beneath
+// the surface being solid. Unlike BoundingTriangles, a BoundingHeightfield doesn't store any triangles or broadphase; the few triangles
+// needed for a collision, shape cast, or ray test are generated on the fly from the height samples under the object being tested, so a
+// BoundingHeightfield uses far less memory and is much faster to test against than BoundingTriangles for large landscapes.
+// Like a Terrain, the BoundingHeightfield is centered on its origin, with each height sample spaced apart on the X and Z axes according
+// to its scale; its surface is split into triangles the same way a Terrain's is, so that the two line up exactly (see Terrain.Heightfield).
+// Like the other Bounding* Nodes, the primary purpose of a BoundingHeightfield is to perform intersection testing between itself and
+// other BoundingObject Nodes. Note that BoundingHeightfields don't collide with other BoundingHeightfields.
+type BoundingHeightfield struct {
+	*Node
+
+	heights   [][]float32
+	scale     Vector3
+	minHeight float32
+	maxHeight float32
+
+	shapeBuffer pointsShape // The points of the triangle or prism currently being tested, reused to avoid allocating a shape for each one
+}
+
+// NewBoundingHeightfield creates a new BoundingHeightfield with the given name from the given height samples, organized by rows along the
+// Z axis, and then columns along the X axis (so heights[z][x]). Each row should be the same length; there should be at least two rows and
+// columns. scale controls the spacing between the height samples on the X and Z axes in local units, while scale.Y is how high a height of
+// 1 is. The height samples aren't copied, so they can be shared with a Terrain; see BoundingHeightfield.UpdateBounds() if they change.
+func NewBoundingHeightfield(name string, heights [][]float32, scale Vector3) *BoundingHeightfield {
+
+	if len(heights) < 2 || len(heights[0]) < 2 {
+		panic("Error: NewBoundingHeightfield() requires at least 2x2 height samples.")
+	}
+
+	for _, row := range heights {
+		if len(row) != len(heights[0]) {
+			panic("Error: NewBoundingHeightfield() requires each row of height samples to be the same length.")
+		}
+	}
+
+	hf := &BoundingHeightfield{
+		Node:    NewNode(name),
+		heights: heights,
+		scale:   scale,
+	}
+	hf.owner = hf
+	hf.UpdateBounds()
+
+	return hf
+
+}
+
+// Clone returns a new BoundingHeightfield with the same values set as the original. The clone shares its height samples with the original.
+func (hf *BoundingHeightfield) Clone() INode {
+	clone := &BoundingHeightfield{
+		heights:   hf.heights,
+		scale:     hf.scale,
+		minHeight: hf.minHeight,
+		maxHeight: hf.maxHeight,
+	}
+	clone.Node = hf.Node.clone(clone).(*Node)
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+	return clone
+}
+
+// UpdateBounds updates the range of heights that the BoundingHeightfield spans; this should be called after modifying its height samples
+// (i.e. when deforming terrain), as objects above the previous highest point of the BoundingHeightfield otherwise aren't tested against it.
+func (hf *BoundingHeightfield) UpdateBounds() {
+
+	hf.minHeight = hf.heights[0][0]
+	hf.maxHeight = hf.heights[0][0]
+
+	for _, row := range hf.heights {
+		for _, h := range row {
+			hf.minHeight = math32.Min(hf.minHeight, h)
+			hf.maxHeight = math32.Max(hf.maxHeight, h)
+		}
+	}
+
+}
+
+// Heights returns the height samples of the BoundingHeightfield, organized by rows along the Z axis, and then columns along the X axis.
+func (hf *BoundingHeightfield) Heights() [][]float32 {
+	return hf.heights
+}
+
+// Scale returns the scale of the BoundingHeightfield's height samples; X and Z are the spacing between the samples, and Y is how high a height of 1 is.
+func (hf *BoundingHeightfield) Scale() Vector3 {
+	return hf.scale
+}
+
+// Width returns the width of the BoundingHeightfield on the X axis in local units.
+func (hf *BoundingHeightfield) Width() float32 {
+	countX, _ := hf.sampleCounts()
+	return float32(countX-1) * hf.scale.X
+}
+
+// Depth returns the depth of the BoundingHeightfield on the Z axis in local units.
+func (hf *BoundingHeightfield) Depth() float32 {
+	_, countZ := hf.sampleCounts()
+	return float32(countZ-1) * hf.scale.Z
+}
+
+// sampleCounts returns the number of height samples along the X and Z axes.
+func (hf *BoundingHeightfield) sampleCounts() (int, int) {
+	return len(hf.heights[0]), len(hf.heights)
+}
+
+// samplePosition returns the local position of the height sample at the given indices.
+func (hf *BoundingHeightfield) samplePosition(x, z int) Vector3 {
+	return Vector3{
+		float32(x)*hf.scale.X - hf.Width()/2,
+		hf.heights[z][x] * hf.scale.Y,
+		float32(z)*hf.scale.Z - hf.Depth()/2,
+	}
+}
+
+// cellTriangles returns the local positions of the vertices of the two triangles making up the cell (quad) with the given indices; this
+// matches how a Terrain's chunks are triangulated. Each triangle's vertices are wound so that (v1 - v0).Cross(v2 - v0) faces upwards.
+func (hf *BoundingHeightfield) cellTriangles(x, z int) [2][3]Vector3 {
+	p00 := hf.samplePosition(x, z)
+	p10 := hf.samplePosition(x+1, z)
+	p01 := hf.samplePosition(x, z+1)
+	p11 := hf.samplePosition(x+1, z+1)
+	return [2][3]Vector3{{p00, p01, p10}, {p10, p01, p11}}
+}
+
+// localHeight returns the local height of the BoundingHeightfield's surface at the given local X and Z position, along with a boolean
+// indicating if the position is within the BoundingHeightfield's bounds. The height is interpolated across the triangles of the surface.
+func (hf *BoundingHeightfield) localHeight(x, z float32) (float32, bool) {
+
+	countX, countZ := hf.sampleCounts()
+
+	fx := (x + hf.Width()/2) / hf.scale.X
+	fz := (z + hf.Depth()/2) / hf.scale.Z
+
+	if fx < 0 || fz < 0 || fx > float32(countX-1) || fz > float32(countZ-1) {
+		return 0, false
+	}
+
+	cellX := math32.Min(int(fx), countX-2)
+	cellZ := math32.Min(int(fz), countZ-2)
+
+	fx -= float32(cellX)
+	fz -= float32(cellZ)
+
+	h00 := hf.heights[cellZ][cellX]
+	h10 := hf.heights[cellZ][cellX+1]
+	h01 := hf.heights[cellZ+1][cellX]
+	h11 := hf.heights[cellZ+1][cellX+1]
+
+	var height float32
+
+	// Each quad is split along the diagonal from its +X, -Z corner to its -X, +Z corner.
+	if fx+fz < 1 {
+		height = h00 + (h10-h00)*fx + (h01-h00)*fz
+	} else {
+		height = h11 + (h01-h11)*(1-fx) + (h10-h11)*(1-fz)
+	}
+
+	return height * hf.scale.Y, true
+
+}
+
+// HeightAt returns the world height (Y position) of the BoundingHeightfield's surface directly above or below the given world position,
+// along with a boolean indicating if the position is within the BoundingHeightfield's bounds. This is faster than casting a ray against it.
+// Note that this is only exact if the BoundingHeightfield isn't rotated on its X or Z axes.
+func (hf *BoundingHeightfield) HeightAt(position Vector3) (float32, bool) {
+
+	transform := hf.Transform()
+	local := transform.Inverted().MultVec(position)
+
+	height, ok := hf.localHeight(local.X, local.Z)
+	if !ok {
+		return 0, false
+	}
+
+	return transform.MultVec(Vector3{local.X, height, local.Z}).Y, true
+
+}
+
+// PointInside returns whether the given point is inside of the BoundingHeightfield or not (i.e. beneath its surface, within its bounds).
+func (hf *BoundingHeightfield) PointInside(point Vector3) bool {
+	local := hf.Transform().Inverted().MultVec(point)
+	height, ok := hf.localHeight(local.X, local.Z)
+	return ok && local.Y <= height
+}
+
+// localDimensions returns the local bounds of the BoundingHeightfield.
+func (hf *BoundingHeightfield) localDimensions() Dimensions {
+	low, high := hf.minHeight*hf.scale.Y, hf.maxHeight*hf.scale.Y
+	if low > high {
+		low, high = high, low
+	}
+	return Dimensions{
+		Vector3{-hf.Width() / 2, low, -hf.Depth() / 2},
+		Vector3{hf.Width() / 2, high, hf.Depth() / 2},
+	}
+}
+
+// worldDimensions returns the world bounds of the BoundingHeightfield.
+func (hf *BoundingHeightfield) worldDimensions() Dimensions {
+	return transformDimensions(hf.localDimensions(), hf.Transform())
+}
+
+// transformDimensions returns the Dimensions enclosing the given Dimensions after being transformed by the given Matrix4.
+func transformDimensions(dims Dimensions, transform Matrix4) Dimensions {
+
+	out := NewEmptyDimensions()
+
+	for i := 0; i < 8; i++ {
+
+		corner := dims.Min
+		if i&1 > 0 {
+			corner.X = dims.Max.X
+		}
+		if i&2 > 0 {
+			corner.Y = dims.Max.Y
+		}
+		if i&4 > 0 {
+			corner.Z = dims.Max.Z
+		}
+
+		corner = transform.MultVec(corner)
+
+		out.Min = Vector3{math32.Min(out.Min.X, corner.X), math32.Min(out.Min.Y, corner.Y), math32.Min(out.Min.Z, corner.Z)}
+		out.Max = Vector3{math32.Max(out.Max.X, corner.X), math32.Max(out.Max.Y, corner.Y), math32.Max(out.Max.Z, corner.Z)}
+
+	}
+
+	return out
+
+}
+
+// forEachTriangle calls the given function with each of the BoundingHeightfield's triangles that lies within the given world-space
+// Dimensions, as a convex shape in world space along with the triangle's world normal. If solid is true, each triangle is extended
+// downwards into a prism reaching below both the BoundingHeightfield and the Dimensions, so that objects beneath the surface collide with
+// it as well. The shape passed to the function is reused, so it shouldn't be held onto. The function returns whether to keep iterating.
+func (hf *BoundingHeightfield) forEachTriangle(dims Dimensions, solid bool, forEach func(shape pointsShape, normal Vector3) bool) {
+
+	transform := hf.Transform()
+	local := transformDimensions(dims, transform.Inverted())
+	bounds := hf.localDimensions()
+
+	if local.Min.Y > bounds.Max.Y || (!solid && local.Max.Y < bounds.Min.Y) {
+		return
+	}
+
+	countX, countZ := hf.sampleCounts()
+
+	minX := math32.Max(int(math32.Floor((local.Min.X-bounds.Min.X)/hf.scale.X)), 0)
+	maxX := math32.Min(int(math32.Floor((local.Max.X-bounds.Min.X)/hf.scale.X)), countX-2)
+	minZ := math32.Max(int(math32.Floor((local.Min.Z-bounds.Min.Z)/hf.scale.Z)), 0)
+	maxZ := math32.Min(int(math32.Floor((local.Max.Z-bounds.Min.Z)/hf.scale.Z)), countZ-2)
+
+	// The prisms reach a bit further down than anything they could be tested against.
+	bottom := math32.Min(bounds.Min.Y, local.Min.Y) - 1
+
+	for z := minZ; z <= maxZ; z++ {
+
+		for x := minX; x <= maxX; x++ {
+
+			for _, tri := range hf.cellTriangles(x, z) {
+
+				top := math32.Max(math32.Max(tri[0].Y, tri[1].Y), tri[2].Y)
+				low := math32.Min(math32.Min(tri[0].Y, tri[1].Y), tri[2].Y)
+
+				// Skip triangles that the Dimensions lie entirely above (or, for non-solid tests, entirely below).
+				if local.Min.Y > top || (!solid && local.Max.Y < low) {
+					continue
+				}
+
+				hf.shapeBuffer = hf.shapeBuffer[:0]
+
+				for _, v := range tri {
+					hf.shapeBuffer = append(hf.shapeBuffer, transform.MultVec(v))
+				}
+
+				if solid {
+					for _, v := range tri {
+						hf.shapeBuffer = append(hf.shapeBuffer, transform.MultVec(Vector3{v.X, bottom, v.Z}))
+					}
+				}
+
+				normal := hf.shapeBuffer[1].Sub(hf.shapeBuffer[0]).Cross(hf.shapeBuffer[2].Sub(hf.shapeBuffer[0])).Unit()
+
+				if !forEach(hf.shapeBuffer, normal) {
+					return
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
+// Colliding returns true if the BoundingHeightfield is intersecting the other BoundingObject.
+func (hf *BoundingHeightfield) Colliding(other IBoundingObject) bool {
+	return hf.Collision(other) != nil
+}
+
+// Collision returns a Collision if the BoundingHeightfield is intersecting another BoundingObject. If
+// no intersection is reported, Collision returns nil.
+func (hf *BoundingHeightfield) Collision(other IBoundingObject) *Collision {
+
+	if other == hf || other == nil {
+		return nil
+	}
+
+	switch otherBounds := other.(type) {
+
+	case *BoundingHeightfield:
+		return nil
+
+	case *BoundingSphere, *BoundingCapsule, *BoundingAABB, *BoundingOBB, *BoundingConvexHull, *BoundingTriangles:
+		intersection := otherBounds.Collision(hf)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				inter.Normal = inter.Normal.Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
+	}
+
+	panic("Unimplemented bounds type")
+
+}
+
+// CollisionTest performs a collision test using the provided collision test settings structure.
+// Collisions reported will be sorted in distance from closest to furthest.
+// The function will return if a collision was found with the BoundingHeightfield at the settings specified.
+func (hf *BoundingHeightfield) CollisionTest(settings CollisionTestSettings) bool {
+	return commonCollisionTest(hf, settings)
+}
+
+// Type returns the NodeType for this object.
+func (hf *BoundingHeightfield) Type() NodeType {
+	return NodeTypeBoundingHeightfield
+}
+
+// btConvexHeightfield tests the given convex shape against the given BoundingHeightfield for intersection.
+func btConvexHeightfield(shape convexShape, hf *BoundingHeightfield) *Collision {
+
+	var result *Collision
+
+	up := hf.WorldRotation().MultVec(WorldUp)
+
+	hf.forEachTriangle(shapeDimensions(shape), true, func(prism pointsShape, normal Vector3) bool {
+
+		col := btConvexShapes(shape, prism, hf)
+
+		if col == nil {
+			return true
+		}
+
+		inter := col.Intersections[0]
+
+		// If the shape is pushed out of the side or bottom of a prism (i.e. because it's deep beneath the surface), then it's pushed up out of the
+		// surface instead, as the sides of the prisms beneath the surface are shared with their neighbors and so aren't really there.
+		if inter.MTV.Dot(up) <= inter.MTV.Magnitude()*0.001 {
+			depth := normal.Dot(prism[0]) - normal.Dot(shape.support(normal.Invert()))
+			inter.MTV = normal.Scale(depth)
+			inter.ContactPoint = shape.support(normal.Invert()).Add(inter.MTV)
+		}
+
+		inter.Normal = normal
+
+		if result == nil {
+			result = newCollision(hf)
+		}
+
+		result.add(inter)
+
+		return true
+
+	})
+
+	return result
+
+}
+
+func btTrianglesHeightfield(triangles *BoundingTriangles, hf *BoundingHeightfield) *Collision {
+
+	// If the triangles' bounding AABB isn't intersecting the BoundingHeightfield, none of the triangles could be either
+	if btConvexHeightfield(aabbOrientedBox(triangles.BoundingAABB), hf) == nil {
+		return nil
+	}
+
+	transform := triangles.Transform()
+
+	var result *Collision
+
+	for _, tri := range triangles.Mesh.Triangles {
+
+		triShape := pointsShape{
+			transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[0]]),
+			transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[1]]),
+			transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[2]]),
+		}
+
+		if col := btConvexHeightfield(triShape, hf); col != nil {
+			if result == nil {
+				result = newCollision(hf)
+			}
+			for _, inter := range col.Intersections {
+				inter.Triangle = tri
+				result.add(inter)
+			}
+		}
+
+	}
+
+	return result
+
+}
+
+func boundingHeightfieldRayTest(from, to Vector3, test *BoundingHeightfield, doublesided bool) (RayHit, bool) {
+
+	transform := test.Transform()
+	inverted := transform.Inverted()
+
+	localFrom := inverted.MultVec(from)
+	rayLine := inverted.MultVec(to).Sub(localFrom)
+
+	bounds := test.localDimensions()
+
+	// The ray is first clipped against the BoundingHeightfield's bounds in terms of how far along the ray it is (from 0 at the start to 1 at the end)
+	tmin := float32(0)
+	tmax := float32(1)
+
+	start := [3]float32{localFrom.X, localFrom.Y, localFrom.Z}
+	dir := [3]float32{rayLine.X, rayLine.Y, rayLine.Z}
+	min := [3]float32{bounds.Min.X, bounds.Min.Y, bounds.Min.Z}
+	max := [3]float32{bounds.Max.X, bounds.Max.Y, bounds.Max.Z}
+
+	for i := range start {
+
+		if math32.Abs(dir[i]) < 1e-8 {
+			if start[i] < min[i] || start[i] > max[i] {
+				return RayHit{}, false
+			}
+			continue
+		}
+
+		t1 := (min[i] - start[i]) / dir[i]
+		t2 := (max[i] - start[i]) / dir[i]
+
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+
+		tmin = math32.Max(tmin, t1)
+		tmax = math32.Min(tmax, t2)
+
+	}
+
+	if tmin > tmax {
+		return RayHit{}, false
+	}
+
+	// Then, the cells the ray passes over are walked through in order (using a 2D DDA), so the first cell with a struck triangle has the closest hit.
+	countX, countZ := test.sampleCounts()
+
+	entry := localFrom.Add(rayLine.Scale(tmin))
+	cellX := math32.Clamp(int(math32.Floor((entry.X-bounds.Min.X)/test.scale.X)), 0, countX-2)
+	cellZ := math32.Clamp(int(math32.Floor((entry.Z-bounds.Min.Z)/test.scale.Z)), 0, countZ-2)
+
+	stepX, nextX, deltaX := heightfieldRayStep(rayLine.X, localFrom.X-bounds.Min.X, cellX, test.scale.X)
+	stepZ, nextZ, deltaZ := heightfieldRayStep(rayLine.Z, localFrom.Z-bounds.Min.Z, cellZ, test.scale.Z)
+
+	for cellX >= 0 && cellZ >= 0 && cellX < countX-1 && cellZ < countZ-1 {
+
+		closest := float32(math.MaxFloat32)
+		var hitTri [3]Vector3
+
+		for _, tri := range test.cellTriangles(cellX, cellZ) {
+			if t, ok := rayTriangle(localFrom, rayLine, tri, doublesided); ok && t >= tmin && t <= tmax && t < closest {
+				closest = t
+				hitTri = tri
+			}
+		}
+
+		if closest <= tmax {
+
+			v0 := transform.MultVec(hitTri[0])
+			normal := transform.MultVec(hitTri[1]).Sub(v0).Cross(transform.MultVec(hitTri[2]).Sub(v0)).Unit()
+
+			return RayHit{
+				Object:   test,
+				Position: transform.MultVec(localFrom.Add(rayLine.Scale(closest))),
+				Normal:   normal,
+				from:     from,
+			}, true
+
+		}
+
+		if math32.Min(nextX, nextZ) > tmax {
+			break
+		}
+
+		if nextX < nextZ {
+			cellX += stepX
+			nextX += deltaX
+		} else {
+			cellZ += stepZ
+			nextZ += deltaZ
+		}
+
+	}
+
+	return RayHit{}, false
+
+}
+
+// heightfieldRayStep returns the direction to step through cells on an axis for a ray moving along it by the given amount, how far along
+// the ray (from 0 to 1) it crosses into the next cell from the given one, and how far along the ray it takes to cross each cell afterwards.
+// offset is the ray's starting position on the axis relative to the start of the first cell.
+func heightfieldRayStep(dir, offset float32, cell int, cellSize float32) (int, float32, float32) {
+
+	if math32.Abs(dir) < 1e-8 {
+		return 0, math.MaxFloat32, math.MaxFloat32
+	}
+
+	if dir > 0 {
+		return 1, (float32(cell+1)*cellSize - offset) / dir, cellSize / dir
+	}
+
+	return -1, (float32(cell)*cellSize - offset) / dir, -cellSize / dir
+
+}
+
+// rayTriangle returns how far along the given ray (from 0 at its start to 1 at its end) it strikes the given triangle, using the
+// Möller-Trumbore algorithm. Unless doublesided is true, triangles are only struck from the side their normal faces.
+func rayTriangle(from, rayLine Vector3, tri [3]Vector3, doublesided bool) (float32, bool) {
+
+	edge1 := tri[1].Sub(tri[0])
+	edge2 := tri[2].Sub(tri[0])
+
+	if !doublesided && rayLine.Dot(edge1.Cross(edge2)) >= 0 {
+		return 0, false
+	}
+
+	p := rayLine.Cross(edge2)
+	det := edge1.Dot(p)
+
+	if math32.Abs(det) < 1e-12 {
+		return 0, false
+	}
+
+	invDet := 1 / det
+	s := from.Sub(tri[0])
+
+	u := s.Dot(p) * invDet
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+
+	q := s.Cross(edge1)
+
+	v := rayLine.Dot(q) * invDet
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+
+	return edge2.Dot(q) * invDet, true
+
+}
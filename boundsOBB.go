@@ -0,0 +1,441 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// BoundingOBB represents a 3D OBB (Oriented Bounding Box), a 3D box of varying width, height, and depth that, unlike a BoundingAABB,
+// rotates with its Node. This allows it to tightly fit rotated objects (like crates) without needing to use BoundingTriangles.
+// Like the other Bounding* Nodes, the primary purpose of a BoundingOBB is to perform intersection testing between itself and other
+// BoundingObject Nodes.
+type BoundingOBB struct {
+	*Node
+	Size Vector3 // Size is the size of the OBB on its local X, Y, and Z axes, prior to scaling.
+}
+
+// NewBoundingOBB returns a new BoundingOBB Node with the given width, height, and depth.
+func NewBoundingOBB(name string, width, height, depth float32) *BoundingOBB {
+	min := float32(0.0001)
+	obb := &BoundingOBB{
+		Node: NewNode(name),
+		Size: Vector3{math32.Max(width, min), math32.Max(height, min), math32.Max(depth, min)},
+	}
+	obb.owner = obb
+	return obb
+}
+
+// Clone returns a new BoundingOBB.
+func (obb *BoundingOBB) Clone() INode {
+	clone := NewBoundingOBB(obb.name, obb.Size.X, obb.Size.Y, obb.Size.Z)
+	clone.Node = obb.Node.clone(clone).(*Node)
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+	return clone
+}
+
+// HalfExtents returns half of the size of the BoundingOBB on its local axes in world units, after taking into account its scale.
+func (obb *BoundingOBB) HalfExtents() Vector3 {
+	scale := obb.WorldScale()
+	return Vector3{
+		math32.Abs(obb.Size.X*scale.X) / 2,
+		math32.Abs(obb.Size.Y*scale.Y) / 2,
+		math32.Abs(obb.Size.Z*scale.Z) / 2,
+	}
+}
+
+// Corners returns the world positions of the eight corners of the BoundingOBB.
+func (obb *BoundingOBB) Corners() []Vector3 {
+
+	box := obb.orientedBox()
+
+	corners := make([]Vector3, 0, 8)
+
+	for _, c := range [][3]float32{
+		{1, 1, 1},
+		{1, -1, 1},
+		{-1, -1, 1},
+		{-1, 1, 1},
+		{1, 1, -1},
+		{1, -1, -1},
+		{-1, -1, -1},
+		{-1, 1, -1},
+	} {
+		corner := box.center
+		for i := range box.axes {
+			corner = corner.Add(box.axes[i].Scale(box.half[i] * c[i]))
+		}
+		corners = append(corners, corner)
+	}
+
+	return corners
+
+}
+
+// ClosestPoint returns the closest point, to the point given, on the inside or surface of the BoundingOBB in world space.
+func (obb *BoundingOBB) ClosestPoint(point Vector3) Vector3 {
+	return obb.orientedBox().closestPoint(point)
+}
+
+// PointInside returns whether the given point is inside of the BoundingOBB or not.
+func (obb *BoundingOBB) PointInside(point Vector3) bool {
+	return obb.orientedBox().pointInside(point, 0.01)
+}
+
+// Colliding returns true if the BoundingOBB collides with another IBoundingObject.
+func (obb *BoundingOBB) Colliding(other IBoundingObject) bool {
+	return obb.Collision(other) != nil
+}
+
+// Collision returns the Collision between the BoundingOBB and the other IBoundingObject. If
+// there is no intersection, the function returns nil.
+func (obb *BoundingOBB) Collision(other IBoundingObject) *Collision {
+
+	if other == obb || other == nil {
+		return nil
+	}
+
+	switch otherBounds := other.(type) {
+
+	case *BoundingOBB:
+		return btBoxBox(obb.orientedBox(), otherBounds.orientedBox(), otherBounds)
+
+	case *BoundingAABB:
+		return btBoxBox(obb.orientedBox(), aabbOrientedBox(otherBounds), otherBounds)
+
+	case *BoundingSphere:
+		intersection := btSphereOBB(otherBounds, obb)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				inter.Normal = inter.Normal.Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
+	case *BoundingCapsule:
+		intersection := btCapsuleOBB(otherBounds, obb)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				inter.Normal = inter.Normal.Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
+	case *BoundingTriangles:
+		return btOBBTriangles(obb, otherBounds)
+
+	case *BoundingConvexHull:
+		return btConvexHull(obb.orientedBox(), otherBounds)
+
+	case *BoundingHeightfield:
+		return btConvexHeightfield(obb.orientedBox(), otherBounds)
+
+	}
+
+	panic("Unimplemented bounds type")
+
+}
+
+// CollisionTest performs a collision test using the provided collision test settings structure.
+// Collisions reported will be sorted in distance from closest to furthest.
+// The function will return if a collision was found with the OBB at the settings specified.
+func (obb *BoundingOBB) CollisionTest(settings CollisionTestSettings) bool {
+	return commonCollisionTest(obb, settings)
+}
+
+// Type returns the NodeType for this object.
+func (obb *BoundingOBB) Type() NodeType {
+	return NodeTypeBoundingOBB
+}
+
+// orientedBox returns the BoundingOBB as an orientedBox in world space.
+func (obb *BoundingOBB) orientedBox() orientedBox {
+	rotation := obb.WorldRotation()
+	half := obb.HalfExtents()
+	return orientedBox{
+		center: obb.WorldPosition(),
+		axes:   [3]Vector3{rotation.Right(), rotation.Up(), rotation.Forward()},
+		half:   [3]float32{half.X, half.Y, half.Z},
+	}
+}
+
+// orientedBox is a box in world space, used to test BoundingOBBs (and BoundingAABBs, when tested against BoundingOBBs) for intersection.
+type orientedBox struct {
+	center Vector3
+	axes   [3]Vector3 // The box's local X, Y, and Z axes as unit vectors
+	half   [3]float32 // Half of the box's size along each axis
+}
+
+func aabbOrientedBox(aabb *BoundingAABB) orientedBox {
+	half := aabb.Dimensions.Size().Scale(0.5)
+	return orientedBox{
+		center: aabb.WorldPosition(),
+		axes:   [3]Vector3{WorldRight, WorldUp, WorldBackward},
+		half:   [3]float32{half.X, half.Y, half.Z},
+	}
+}
+
+func (box orientedBox) project(axis Vector3) projection {
+	c := axis.Dot(box.center)
+	r := box.half[0]*math32.Abs(box.axes[0].Dot(axis)) +
+		box.half[1]*math32.Abs(box.axes[1].Dot(axis)) +
+		box.half[2]*math32.Abs(box.axes[2].Dot(axis))
+	return projection{Min: c - r, Max: c + r}
+}
+
+func (box orientedBox) closestPoint(point Vector3) Vector3 {
+	delta := point.Sub(box.center)
+	out := box.center
+	for i, axis := range box.axes {
+		d := math32.Clamp(delta.Dot(axis), -box.half[i], box.half[i])
+		out = out.Add(axis.Scale(d))
+	}
+	return out
+}
+
+func (box orientedBox) pointInside(point Vector3, margin float32) bool {
+	delta := point.Sub(box.center)
+	for i, axis := range box.axes {
+		if math32.Abs(delta.Dot(axis)) > box.half[i]+margin {
+			return false
+		}
+	}
+	return true
+}
+
+// faceNormal returns the normal of the face of the box closest to the given point (i.e. the face of the box a point on its surface is on).
+func (box orientedBox) faceNormal(point Vector3) (normal Vector3, faceDistance float32) {
+
+	delta := point.Sub(box.center)
+	faceDistance = float32(math.MaxFloat32)
+
+	for i, axis := range box.axes {
+
+		d := delta.Dot(axis)
+
+		if dist := box.half[i] - math32.Abs(d); dist < faceDistance {
+			faceDistance = dist
+			if d < 0 {
+				normal = axis.Invert()
+			} else {
+				normal = axis
+			}
+		}
+
+	}
+
+	return normal, faceDistance
+
+}
+
+// support returns the point of the box farthest along the given direction.
+func (box orientedBox) support(dir Vector3) Vector3 {
+	out := box.center
+	for i, axis := range box.axes {
+		if axis.Dot(dir) < 0 {
+			out = out.Sub(axis.Scale(box.half[i]))
+		} else {
+			out = out.Add(axis.Scale(box.half[i]))
+		}
+	}
+	return out
+}
+
+// satMTV performs a separating axis test between two convex shapes using the given axes, where project returns the projections of
+// both shapes onto an axis. If the shapes intersect, satMTV returns the minimum translation vector to move the first shape out of the second.
+func satMTV(axes []Vector3, project func(axis Vector3) (projection, projection)) (Vector3, bool) {
+
+	mtv := Vector3{}
+	smallestOverlap := float32(math.MaxFloat32)
+
+	for _, axis := range axes {
+
+		// Cross products of parallel edges don't form axes
+		if axis.MagnitudeSquared() < 1e-8 {
+			continue
+		}
+
+		axis = axis.Unit()
+
+		a, b := project(axis)
+
+		if !a.IsOverlapping(b) {
+			return Vector3{}, false
+		}
+
+		// The first shape can be pushed out either way along the axis
+		if d := a.Max - b.Min; d < smallestOverlap {
+			smallestOverlap = d
+			mtv = axis.Scale(-d)
+		}
+
+		if d := b.Max - a.Min; d < smallestOverlap {
+			smallestOverlap = d
+			mtv = axis.Scale(d)
+		}
+
+	}
+
+	return mtv, true
+
+}
+
+func btBoxBox(boxA, boxB orientedBox, other IBoundingObject) *Collision {
+
+	axes := make([]Vector3, 0, 15)
+	axes = append(axes, boxA.axes[:]...)
+	axes = append(axes, boxB.axes[:]...)
+	for _, a := range boxA.axes {
+		for _, b := range boxB.axes {
+			axes = append(axes, a.Cross(b))
+		}
+	}
+
+	mtv, ok := satMTV(axes, func(axis Vector3) (projection, projection) {
+		return boxA.project(axis), boxB.project(axis)
+	})
+
+	if !ok {
+		return nil
+	}
+
+	normal := mtv.Unit()
+
+	return newCollision(other).add(
+		&Intersection{
+			StartingPoint: boxA.center,
+			// The deepest point of A into B
+			ContactPoint: boxB.closestPoint(boxA.support(normal.Invert())),
+			MTV:          mtv,
+			Normal:       normal,
+		},
+	)
+
+}
+
+func btSphereOBB(sphere *BoundingSphere, obb *BoundingOBB) *Collision {
+
+	box := obb.orientedBox()
+
+	spherePos := sphere.WorldPosition()
+	sphereRadius := sphere.WorldRadius()
+
+	closest := box.closestPoint(spherePos)
+	delta := spherePos.Sub(closest)
+	distance := delta.Magnitude()
+
+	if distance > sphereRadius {
+		return nil
+	}
+
+	normal, faceDistance := box.faceNormal(closest)
+
+	var mtv Vector3
+
+	if box.pointInside(spherePos, 0) {
+		// The sphere's center is inside of the box, so it's pushed out of the closest face
+		mtv = normal.Scale(faceDistance + sphereRadius)
+		closest = spherePos.Add(normal.Scale(faceDistance))
+	} else {
+		mtv = delta.Unit().Scale(sphereRadius - distance)
+	}
+
+	return newCollision(obb).add(
+		&Intersection{
+			StartingPoint: spherePos,
+			ContactPoint:  closest,
+			MTV:           mtv,
+			Normal:        normal,
+		},
+	)
+
+}
+
+func btCapsuleOBB(capsule *BoundingCapsule, obb *BoundingOBB) *Collision {
+
+	box := obb.orientedBox()
+	bottom := capsule.lineBottom()
+	top := capsule.lineTop()
+
+	// Going back and forth between the closest points on the capsule's line and the box gets close enough to the true closest point
+	point := ClosestPointOnLine(bottom, top, box.center)
+	for i := 0; i < 2; i++ {
+		point = ClosestPointOnLine(bottom, top, box.closestPoint(point))
+	}
+
+	capsule.internalSphere.SetLocalScaleVec(capsule.LocalScale())
+	capsule.internalSphere.SetLocalPositionVec(point)
+	capsule.internalSphere.Radius = capsule.Radius
+
+	return btSphereOBB(capsule.internalSphere, obb)
+
+}
+
+func btOBBTriangles(obb *BoundingOBB, triangles *BoundingTriangles) *Collision {
+
+	// If we're not intersecting the triangle's bounding AABB, we couldn't possibly be colliding with any of the triangles, so we're good
+	if !obb.Colliding(triangles.BoundingAABB) {
+		return nil
+	}
+
+	box := obb.orientedBox()
+
+	transform := triangles.Transform()
+	transformNoLoc := transform.Clone()
+	transformNoLoc.SetRow(3, Vector4{0, 0, 0, 1})
+
+	result := newCollision(triangles)
+
+	tris := triangles.Broadphase.TrianglesFromBoundingObject(obb)
+
+	axes := make([]Vector3, 0, 13)
+
+	for triID := range tris {
+
+		tri := triangles.Mesh.Triangles[triID]
+
+		v0 := transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[0]])
+		v1 := transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[1]])
+		v2 := transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[2]])
+
+		normal := transformNoLoc.MultVec(tri.Normal).Unit()
+
+		axes = append(axes[:0], box.axes[:]...)
+		axes = append(axes, normal)
+		for _, edge := range []Vector3{v1.Sub(v0), v2.Sub(v1), v0.Sub(v2)} {
+			for _, axis := range box.axes {
+				axes = append(axes, axis.Cross(edge))
+			}
+		}
+
+		mtv, ok := satMTV(axes, func(axis Vector3) (projection, projection) {
+			return box.project(axis), project(axis, v0, v1, v2)
+		})
+
+		if ok {
+			result.add(&Intersection{
+				StartingPoint: box.center,
+				ContactPoint:  closestPointOnTri(box.center, v0, v1, v2),
+				MTV:           mtv,
+				Triangle:      tri,
+				Normal:        normal,
+			})
+		}
+
+	}
+
+	if len(result.Intersections) == 0 {
+		return nil
+	}
+
+	result.sortResults()
+
+	return result
+
+}
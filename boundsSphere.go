@@ -68,6 +68,15 @@ func (sphere *BoundingSphere) Collision(other IBoundingObject) *Collision {
 	case *BoundingCapsule:
 		return btSphereCapsule(sphere, otherBounds)
 
+	case *BoundingOBB:
+		return btSphereOBB(sphere, otherBounds)
+
+	case *BoundingConvexHull:
+		return btConvexHull(sphere.shape(), otherBounds)
+
+	case *BoundingHeightfield:
+		return btConvexHeightfield(sphere.shape(), otherBounds)
+
 	}
 
 	panic("Unimplemented bounds type")
@@ -121,6 +121,28 @@ func (bt *BoundingTriangles) Collision(other IBoundingObject) *Collision {
 	case *BoundingTriangles:
 		return btTrianglesTriangles(bt, otherBounds)
 
+	case *BoundingConvexHull:
+		intersection := otherBounds.Collision(bt)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				inter.Normal = inter.Normal.Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
+	case *BoundingOBB:
+		intersection := otherBounds.Collision(bt)
+		if intersection != nil {
+			for _, inter := range intersection.Intersections {
+				inter.MTV = inter.MTV.Invert()
+				inter.Normal = inter.Normal.Invert()
+			}
+			intersection.BoundingObject = otherBounds
+		}
+		return intersection
+
 	case *BoundingCapsule:
 		intersection := otherBounds.Collision(bt)
 		if intersection != nil {
@@ -132,6 +154,9 @@ func (bt *BoundingTriangles) Collision(other IBoundingObject) *Collision {
 		}
 		return intersection
 
+	case *BoundingHeightfield:
+		return btTrianglesHeightfield(bt, otherBounds)
+
 	}
 
 	panic("Unimplemented bounds type")
@@ -0,0 +1,233 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// BuoyancyVolume is a Node representing a box-shaped body of water (or any other liquid). Objects inside of it are pushed up towards
+// its surface (counteracting gravity), slowed down by drag, and carried along by its current; this allows boats to bob on the water
+// and characters to swim. The volume's surface is the top of its box, optionally displaced by a wave function.
+//
+// RigidBodies found in the volume's TestAgainst have the volume's forces applied to them automatically by BuoyancyVolume.Update(), which
+// should be called once per frame (this also animates the volume's waves). Other bodies (i.e. the player's character) should call
+// BuoyancyVolume.Apply() with their position and velocity each frame to have the volume's forces applied.
+type BuoyancyVolume struct {
+	*Node
+	On   bool    // Whether the BuoyancyVolume affects bodies or not. Defaults to true.
+	Size Vector3 // The local size of the volume's box. The box is centered on the volume, and its top is the surface at rest.
+
+	// Buoyancy is how strongly bodies are pushed upwards when fully submerged, as a multiple of gravity. Values above 1 make bodies float,
+	// values below 1 make them sink slowly, and 1 makes them hover in place. Defaults to 1.5.
+	Buoyancy float32
+	Drag     float32 // How much of a submerged body's velocity (relative to the Current) is lost per second. Defaults to 2.
+	Current  Vector3 // The velocity of the liquid in world units per second; submerged bodies are dragged along with it.
+	// AngularDrag is how much of a submerged RigidBody's angular velocity is lost per second. Defaults to 1.
+	AngularDrag float32
+
+	// TestAgainst is used to specify a selection of RigidBodies that Update() applies the volume's forces to - this can be either a NodeFilter
+	// or a NodeCollection (a slice of Nodes). Nodes other than RigidBodies are ignored. If nil, Update() doesn't affect any RigidBodies.
+	TestAgainst NodeIterator
+
+	// WaveFunction returns the height of the volume's surface above (or below) its rest height at the given world X and Z coordinates,
+	// at the given time in seconds. If nil, the surface is flat.
+	WaveFunction func(x, z, time float32) float32
+	Time         float32 // The time passed to the WaveFunction; this is advanced by Update().
+}
+
+// NewBuoyancyVolume creates a new BuoyancyVolume with the given name and local size.
+func NewBuoyancyVolume(name string, size Vector3) *BuoyancyVolume {
+	volume := &BuoyancyVolume{
+		Node:     NewNode(name),
+		On:       true,
+		Size:     size,
+		Buoyancy: 1.5,
+		Drag:     2,
+
+		AngularDrag: 1,
+	}
+	volume.owner = volume
+	return volume
+}
+
+// Clone creates a clone of the BuoyancyVolume and its children.
+func (volume *BuoyancyVolume) Clone() INode {
+
+	clone := NewBuoyancyVolume(volume.name, volume.Size)
+	clone.On = volume.On
+	clone.Buoyancy = volume.Buoyancy
+	clone.Drag = volume.Drag
+	clone.Current = volume.Current
+	clone.AngularDrag = volume.AngularDrag
+	clone.TestAgainst = volume.TestAgainst
+	clone.WaveFunction = volume.WaveFunction
+	clone.Time = volume.Time
+
+	clone.Node = volume.Node.clone(clone).(*Node)
+
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+
+	return clone
+
+}
+
+// Update advances the BuoyancyVolume's Time by the given delta time in seconds, animating its waves, and applies the volume's forces to
+// the RigidBodies in TestAgainst (see BuoyancyVolume.ApplyToRigidBody()).
+func (volume *BuoyancyVolume) Update(dt float32) {
+
+	volume.Time += dt
+
+	if volume.TestAgainst == nil {
+		return
+	}
+
+	volume.TestAgainst.ForEach(func(node INode) bool {
+		if body, ok := node.(*RigidBody); ok {
+			volume.ApplyToRigidBody(body, dt)
+		}
+		return true
+	})
+
+}
+
+// SurfaceHeight returns the world Y position of the BuoyancyVolume's surface at the given world X and Z coordinates, including any waves.
+func (volume *BuoyancyVolume) SurfaceHeight(x, z float32) float32 {
+
+	height := volume.WorldPosition().Y + volume.Size.Y/2*volume.WorldScale().Y
+
+	if volume.WaveFunction != nil {
+		height += volume.WaveFunction(x, z, volume.Time)
+	}
+
+	return height
+
+}
+
+// SurfaceNormal returns the normal of the BuoyancyVolume's surface at the given world X and Z coordinates, which can be used to tilt
+// floating objects (like boats) to follow the waves. For flat surfaces, this is WorldUp.
+func (volume *BuoyancyVolume) SurfaceNormal(x, z float32) Vector3 {
+
+	if volume.WaveFunction == nil {
+		return WorldUp
+	}
+
+	const step = 0.05
+
+	dx := volume.WaveFunction(x+step, z, volume.Time) - volume.WaveFunction(x-step, z, volume.Time)
+	dz := volume.WaveFunction(x, z+step, volume.Time) - volume.WaveFunction(x, z-step, volume.Time)
+
+	return Vector3{-dx, step * 2, -dz}.Unit()
+
+}
+
+// Contains returns if the given world position is within the BuoyancyVolume's horizontal extents and between its bottom and its surface.
+func (volume *BuoyancyVolume) Contains(position Vector3) bool {
+	return volume.withinBounds(position) && position.Y <= volume.SurfaceHeight(position.X, position.Z)
+}
+
+// Depth returns how far below the BuoyancyVolume's surface the given world position is. If the position is outside of the volume's
+// horizontal extents or below its bottom, 0 is returned; if the position is above the surface, the returned value is negative.
+func (volume *BuoyancyVolume) Depth(position Vector3) float32 {
+
+	if !volume.withinBounds(position) {
+		return 0
+	}
+
+	return volume.SurfaceHeight(position.X, position.Z) - position.Y
+
+}
+
+// withinBounds returns if the given world position is within the horizontal extents of the volume's box and above its bottom.
+func (volume *BuoyancyVolume) withinBounds(position Vector3) bool {
+
+	local := volume.Transform().Inverted().MultVec(position)
+
+	return math32.Abs(local.X) <= volume.Size.X/2 && math32.Abs(local.Z) <= volume.Size.Z/2 && local.Y >= -volume.Size.Y/2
+
+}
+
+// Submerged returns how much of a body of the given radius centered on the given world position is below the BuoyancyVolume's surface,
+// ranging from 0 (not at all) to 1 (entirely). A radius of 0 treats the body as a point.
+func (volume *BuoyancyVolume) Submerged(position Vector3, radius float32) float32 {
+
+	if !volume.On || !volume.withinBounds(position) {
+		return 0
+	}
+
+	depth := volume.SurfaceHeight(position.X, position.Z) - position.Y
+
+	if radius <= 0 {
+		if depth >= 0 {
+			return 1
+		}
+		return 0
+	}
+
+	return math32.Clamp((depth+radius)/(radius*2), 0, 1)
+
+}
+
+// Acceleration returns the acceleration (in world units per second squared) the BuoyancyVolume applies to a body of the given radius at
+// the given world position, moving at the given velocity (in world units per second), under the given gravity. This consists of the
+// buoyant force pushing the body upwards against gravity, and the drag pulling the body's velocity towards the volume's Current.
+func (volume *BuoyancyVolume) Acceleration(position, velocity Vector3, radius float32, gravity Vector3) Vector3 {
+
+	submerged := volume.Submerged(position, radius)
+
+	if submerged == 0 {
+		return Vector3{}
+	}
+
+	buoyancy := gravity.Scale(-volume.Buoyancy * submerged)
+	drag := volume.Current.Sub(velocity).Scale(volume.Drag * submerged)
+
+	return buoyancy.Add(drag)
+
+}
+
+// Apply applies the BuoyancyVolume's forces over the given delta time in seconds to a body of the given radius at the given world position,
+// moving at the given velocity (in world units per second) under the given gravity, and returns the body's new velocity.
+// Gravity itself isn't applied, so the body should still apply gravity to itself as usual.
+func (volume *BuoyancyVolume) Apply(position, velocity Vector3, radius float32, gravity Vector3, dt float32) Vector3 {
+
+	submerged := volume.Submerged(position, radius)
+
+	if submerged == 0 {
+		return velocity
+	}
+
+	velocity = velocity.Add(gravity.Scale(-volume.Buoyancy * submerged * dt))
+
+	// Drag is clamped so that it can't push the body past the Current's velocity, regardless of how long the delta time is.
+	drag := math32.Min(volume.Drag*submerged*dt, 1)
+
+	return velocity.Lerp(volume.Current, drag)
+
+}
+
+// ApplyToRigidBody applies the BuoyancyVolume's forces over the given delta time in seconds to the given RigidBody, changing its velocity
+// and slowing its rotation by the volume's AngularDrag. The RigidBody is treated as a sphere enclosing its shape when determining how
+// submerged it is. RigidBodies that are turned off aren't affected.
+func (volume *BuoyancyVolume) ApplyToRigidBody(body *RigidBody, dt float32) {
+
+	if !body.On || dt <= 0 {
+		return
+	}
+
+	position := body.WorldPosition()
+	radius := body.radius()
+
+	submerged := volume.Submerged(position, radius)
+
+	if submerged == 0 {
+		return
+	}
+
+	body.Velocity = volume.Apply(position, body.Velocity, radius, body.Gravity, dt)
+	body.AngularVelocity = body.AngularVelocity.Scale(1 - math32.Min(volume.AngularDrag*submerged*dt, 1))
+
+}
+
+// Type returns the NodeType for this object.
+func (volume *BuoyancyVolume) Type() NodeType {
+	return NodeTypeBuoyancyVolume
+}
@@ -4,6 +4,15 @@ package tetra3d
 type Callbacks struct {
 	OnReparent func(node, oldParent, newParent INode) // A callback to be called whenever a Node is reparented.
 	OnClone    func(newNode INode)                    // A callback to be called whenever a Node is cloned (including when its owning Scene is cloned).
-	// TODO?: Maybe add OnSceneTreeChange(), where a function is called for each child in a tree when an ancestor is reparented?
-	// I'm hesitant to add this without running into the use-case myself, though.
+	// A callback to be called whenever a Node enters a Scene's tree, either by being added to a Node in the tree, by being part of the tree
+	// of a Node that was, or by being part of a Scene as it's cloned (i.e. when instantiating a level from a Library with Scene.Clone()).
+	// This is useful for registering game objects with managers (i.e. lists of enemies) reliably.
+	OnEnterTree func(node INode, scene *Scene)
+	// A callback to be called whenever a Node leaves a Scene's tree, either by being removed from it (i.e. with Node.Unparent()), by being
+	// part of the tree of a Node that was, or by being moved to another Scene's tree (in which case OnEnterTree is called afterwards).
+	OnExitTree func(node INode, scene *Scene)
+	// A callback to be called whenever the Sector a Node is in changes. Note that a Node's Sector is evaluated lazily - it's checked
+	// when Node.Sector() is called, which Cameras do automatically for SectorTypeObject Nodes when rendering with sectors (in which case
+	// it's called once the Camera has gathered the Nodes to render).
+	OnSectorChange func(node INode, oldSector, newSector *Sector)
 }
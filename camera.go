@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"image"
+	"math"
 	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -32,6 +34,10 @@ type DebugInfo struct {
 	ActiveLightCount     int // Total active number of lights
 }
 
+// renderFrame counts the frames rendered so far, so that work done while rendering (i.e. sampling a CurveDeform's Path) can be shared across
+// all of the Models and Cameras rendering a frame. It advances when a Camera is cleared for a second time, as that means a new frame has begun.
+var renderFrame atomic.Uint64
+
 type AccumulationColorMode int
 
 const (
@@ -51,6 +57,10 @@ type Camera struct {
 	SectorRenderDepth                  int  // How far out the Camera renders other sectors. Defaults to 1 (so the current sector and its immediate neighbors).
 	PerspectiveCorrectedTextureMapping bool // If the Camera should render textures with perspective corrected texture mapping. Defaults to false.
 	currentSector                      *Sector
+
+	renderedModels     Set[*Model] // Models rendered since the Camera was last cleared that have visibility callbacks
+	prevRenderedModels Set[*Model] // Models rendered in the previous frame that have visibility callbacks
+	clearedFrame       uint64      // The render frame the Camera was last cleared in
 	// How many lights (sorted by distance) should be used to render each object, maximum. If it's greater than 0,
 	// then only that many lights will be considered. If less than or equal to 0 (the default), then all available lights will be used.
 	MaxLightCount int
@@ -59,6 +69,8 @@ type Camera struct {
 	resultDepthTexture  *ebiten.Image // DepthTexture holds the depth results of rendering any models, if Camera.RenderDepth is on.
 	resultNormalTexture *ebiten.Image // NormalTexture holds a texture indicating the normal render
 	depthIntermediate   *ebiten.Image
+	opaqueColorTexture  *ebiten.Image // A copy of the color texture before rendering transparent objects; see Material.SampleOpaqueColor.
+	depthPixels         []byte        // A buffer for reading back the depth texture; see Camera.DepthData().
 
 	resultAccumulatedColorTexture *ebiten.Image // ResultAccumulatedColorTexture holds the previous frame's render result of rendering any models.
 	accumulatedBackBuffer         *ebiten.Image
@@ -76,6 +88,9 @@ type Camera struct {
 	fieldOfView float32 // Vertical field of view in degrees for a perspective projection camera
 	orthoScale  float32 // Scale of the view for an orthographic projection camera in units horizontally
 
+	clipPlane   Plane // The world-space plane that geometry behind is discarded; see Camera.SetClipPlane().
+	clipPlaneOn bool
+
 	// When set to a value > 0, it will snap all rendered models' vertices to a grid of the provided size (so VertexSnapping of 0.1 will snap all rendered positions to 0.1 intervals).
 	// Note that snapping vertices is not free and does take some time to execute (so if you're up against a performance limit, turning off vertex snapping might make a bit of a difference).
 	// Defaults to 0 (off).
@@ -101,6 +116,10 @@ type Camera struct {
 
 	debugTextTexture *ebiten.Image
 
+	immediateModel     *Model         // The Model used to render triangles drawn through Camera.DrawTriangles3D()
+	dynamicRenderModel *Model         // The Model used to render objects drawn through Camera.DynamicRender()
+	buffers            *renderBuffers // The scratch buffers the Camera is currently rendering with
+
 	// DepthMargin is a margin in percentage on both the near and far plane to leave some
 	// distance remaining in the depth buffer for triangle comparison.
 	// This ensures that objects that are, for example, close to or far from the camera
@@ -123,6 +142,9 @@ func NewCamera(w, h int) *Camera {
 
 		SectorRendering:   false,
 		SectorRenderDepth: 1,
+
+		renderedModels:     newSet[*Model](),
+		prevRenderedModels: newSet[*Model](),
 	}
 
 	cam.owner = cam
@@ -132,6 +154,9 @@ func NewCamera(w, h int) *Camera {
 
 		//kage:unit pixels
 
+		var DepthTest int
+		var ClipPlane int
+
 		func encodeDepth(depth float) vec4 {
 			r := floor(depth * 255) / 255
 			g := floor(fract(depth * 255) * 255) / 255
@@ -147,11 +172,28 @@ func NewCamera(w, h int) *Camera {
 			return dstPos.xy - imageDstOrigin() + imageSrc0Origin()
 		}
 
-		func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+		// depthTestPasses returns if a fragment at the given depth passes the depth test against the existing depth (see Material.DepthTest).
+		func depthTestPasses(existingDepth vec4, depth float) bool {
+			if DepthTest == 1 {
+				return true
+			} else if DepthTest == 2 {
+				return false
+			} else if DepthTest == 3 {
+				return existingDepth.a > 0 && decodeDepth(existingDepth) < depth
+			}
+			return existingDepth.a == 0 || decodeDepth(existingDepth) > depth
+		}
+
+		func Fragment(dstPos vec4, srcPos vec2, color, custom vec4) vec4 {
+
+			// The distance to the clip plane (divided by W) is stored in the second custom vertex value.
+			if ClipPlane > 0 && custom.y < 0 {
+				discard()
+			}
 
 			existingDepth := imageSrc0UnsafeAt(dstPosToSrcPos(dstPos.xy))
 
-			if existingDepth.a == 0 || decodeDepth(existingDepth) > color.r {
+			if depthTestPasses(existingDepth, color.r) {
 				return encodeDepth(color.r)
 			}
 
@@ -175,6 +217,8 @@ func NewCamera(w, h int) *Camera {
 		package main
 
 		var PerspectiveCorrection int
+		var DepthTest int
+		var ClipPlane int
 
 		func encodeDepth(depth float) vec4 {
 			r := floor(depth * 255) / 255
@@ -191,8 +235,24 @@ func NewCamera(w, h int) *Camera {
 			return dstPos.xy - imageDstOrigin() + imageSrc0Origin()
 		}
 
+		// depthTestPasses returns if a fragment at the given depth passes the depth test against the existing depth (see Material.DepthTest).
+		func depthTestPasses(existingDepth vec4, depth float) bool {
+			if DepthTest == 1 {
+				return true
+			} else if DepthTest == 2 {
+				return false
+			} else if DepthTest == 3 {
+				return existingDepth.a > 0 && decodeDepth(existingDepth) < depth
+			}
+			return existingDepth.a == 0 || decodeDepth(existingDepth) > depth
+		}
+
 		func Fragment(dstPos vec4, srcPos vec2, vc, custom vec4) vec4 {
 
+			if ClipPlane > 0 && custom.y < 0 {
+				discard()
+			}
+
 			color := vc
 			srcSize := imageSrc1Size()
 
@@ -222,7 +282,7 @@ func NewCamera(w, h int) *Camera {
 
 			depthValue := imageSrc0UnsafeAt(dstPosToSrcPos(dstPos.xy))
 
-			if depthValue.a == 0 || decodeDepth(depthValue) > color.r {
+			if depthTestPasses(depthValue, color.r) {
 				return vec4(encodeDepth(color.r).rgb, tex.a)
 			}
 
@@ -313,6 +373,8 @@ func (camera *Camera) Clone() INode {
 
 	clone.Node = camera.Node.clone(clone).(*Node)
 	clone.MaxLightCount = camera.MaxLightCount
+	clone.clipPlane = camera.clipPlane
+	clone.clipPlaneOn = camera.clipPlaneOn
 
 	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
 		clone.Callbacks().OnClone(clone)
@@ -339,6 +401,10 @@ func (camera *Camera) Resize(w, h int) {
 		camera.accumulatedBackBuffer.Dispose()
 		camera.resultDepthTexture.Dispose()
 		camera.depthIntermediate.Dispose()
+		if camera.opaqueColorTexture != nil {
+			camera.opaqueColorTexture.Dispose()
+			camera.opaqueColorTexture = nil
+		}
 	}
 
 	bounds := image.Rect(0, 0, w, h)
@@ -359,6 +425,10 @@ func (camera *Camera) Resize(w, h int) {
 // Size returns the width and height of the camera's backing color texture. All of the Camera's textures are the same size, so these
 // same size values can also be used for the depth texture, the accumulation buffer, etc.
 func (camera *Camera) Size() (w, h int) {
+	// A Camera created with a size of 0x0 has no textures until it's resized
+	if camera.resultColorTexture == nil {
+		return 0, 0
+	}
 	size := camera.resultColorTexture.Bounds().Size()
 	return size.X, size.Y
 }
@@ -422,6 +492,26 @@ func (camera *Camera) Perspective() bool {
 	return camera.perspective
 }
 
+// SetClipPlane sets a world-space plane for the Camera to clip geometry against while rendering; any geometry behind the plane
+// (on the opposite side of the way its normal faces) is discarded. This is useful for rendering planar reflections and portals
+// (where objects between the virtual camera and the mirror or portal surface shouldn't be seen), or cutaway views of buildings.
+// Geometry is clipped per-pixel in the depth pass, so clipping is only exact if the Camera renders depth (Camera.RenderDepth);
+// otherwise, only triangles that are entirely behind the plane are discarded.
+func (camera *Camera) SetClipPlane(plane Plane) {
+	camera.clipPlane = plane
+	camera.clipPlaneOn = true
+}
+
+// ClearClipPlane removes the Camera's clip plane, so geometry is no longer clipped against it.
+func (camera *Camera) ClearClipPlane() {
+	camera.clipPlaneOn = false
+}
+
+// ClipPlane returns the Camera's clip plane, and whether it's set (see Camera.SetClipPlane()).
+func (camera *Camera) ClipPlane() (Plane, bool) {
+	return camera.clipPlane, camera.clipPlaneOn
+}
+
 // SetFieldOfView sets the vertical field of the view of the camera in degrees.
 func (camera *Camera) SetFieldOfView(fovY float32) {
 	if camera.fieldOfView == fovY {
@@ -714,6 +804,10 @@ func (camera *Camera) SphereInFrustum(sphere *BoundingSphere) bool {
 // ModelInFrustum returns if a model is onscreen when viewed through a Camera.
 func (camera *Camera) ModelInFrustum(model *Model) bool {
 	model.Transform() // Make sure to update the transform of the Model as necessary.
+	// Curve-deformed Models have their bounding spheres positioned along their Paths, so those have to be sampled before culling.
+	if model.curveDeform != nil {
+		model.curveDeform.update(model)
+	}
 	return camera.SphereInFrustum(model.frustumCullingSphere)
 }
 
@@ -748,6 +842,11 @@ func (camera *Camera) ClearWithColor(clear Color) {
 
 	rgba := clear.ToNRGBA64()
 
+	if camera.clearedFrame == renderFrame.Load() {
+		renderFrame.Add(1)
+	}
+	camera.clearedFrame = renderFrame.Load()
+
 	if camera.AccumulationColorMode != AccumulationColorModeNone {
 		camera.accumulatedBackBuffer.Clear()
 		camera.accumulatedBackBuffer.DrawImage(camera.resultAccumulatedColorTexture, nil)
@@ -770,6 +869,16 @@ func (camera *Camera) ClearWithColor(clear Color) {
 		camera.resultDepthTexture.Clear()
 	}
 
+	// Any Models rendered last frame that weren't rendered this frame are no longer visible to this Camera.
+	for model := range camera.prevRenderedModels {
+		if !camera.renderedModels.Contains(model) && model.OnBecameInvisible != nil {
+			model.OnBecameInvisible(model, camera)
+		}
+	}
+
+	camera.prevRenderedModels, camera.renderedModels = camera.renderedModels, camera.prevRenderedModels
+	camera.renderedModels.Clear()
+
 	if camera.RenderNormals {
 		camera.resultNormalTexture.Clear()
 	}
@@ -806,26 +915,40 @@ func (camera *Camera) RenderScene(scene *Scene) {
 	camera.RenderNodes(scene, scene.Root)
 }
 
-var meshes []*Model
-var lights []ILight
-
 // RenderNodes renders all nodes starting with the provided rootNode using the Scene's properties (fog, for example). Note that if Camera.RenderDepth
 // is false, scenes rendered one after another in multiple RenderScene() calls will be rendered on top of each other in the Camera's texture buffers.
 // Note that each MeshPart of a Model has a maximum renderable triangle count of 21845.
 func (camera *Camera) RenderNodes(scene *Scene, rootNode INode) {
 
-	meshes = meshes[:0]
-	lights = lights[:0]
+	buffers := camera.scratch()
+
+	camera.gatherRenderNodes(buffers, rootNode)
+
+	// Callbacks (i.e. OnSectorChange) are called after the render lock is released, so that they can use it themselves
+	buffers.runCallbacks()
+
+	camera.Render(scene, buffers.lights, buffers.models...)
+
+}
+
+// gatherRenderNodes gathers the Models and Lights to render from the given root Node into the given buffers, taking sectors into account.
+func (camera *Camera) gatherRenderNodes(buffers *renderBuffers, rootNode INode) {
+
+	renderLock.Lock()
+	defer renderLock.Unlock()
+
+	buffers.models = buffers.models[:0]
+	buffers.lights = buffers.lights[:0]
+	buffers.renderingSectors = buffers.renderingSectors[:0]
 
 	if model, isModel := rootNode.(*Model); isModel {
-		meshes = append(meshes, model)
+		buffers.models = append(buffers.models, model)
 	}
 
 	if camera.SectorRendering {
 
 		// Gather sectors
 		sectors := rootNode.SearchTree().bySectors()
-		sectorModels := sectors.Models()
 
 		var insideSector *Sector
 
@@ -861,22 +984,29 @@ func (camera *Camera) RenderNodes(scene *Scene, rootNode INode) {
 				r.rendering = true
 			}
 
+			sectors.ForEach(func(node INode) bool {
+				if sector := node.(*Model).sector; sector.rendering {
+					buffers.renderingSectors = append(buffers.renderingSectors, sector)
+				}
+				return true
+			})
+
 			rootNode.SearchTree().ByType(NodeTypeModel).ForEach(func(node INode) bool {
 				model := node.(*Model)
 
 				if model.sector != nil && model.sector.rendering {
 					if model.sector.rendering {
-						meshes = append(meshes, model)
+						buffers.models = append(buffers.models, model)
 					}
 				} else if model.DynamicBatchOwner == nil {
 
 					// If something is dynamically batching, then we don't want to deal with sectors, because the batched objects belong to sectors.
 					if model.DynamicBatcher() {
-						meshes = append(meshes, model)
-					} else if model.SectorType() == SectorTypeStandalone || (model.SectorType() == SectorTypeObject && model.isInVisibleSector(sectorModels)) {
-						meshes = append(meshes, model)
+						buffers.models = append(buffers.models, model)
+					} else if model.SectorType() == SectorTypeStandalone || (model.SectorType() == SectorTypeObject && model.isInVisibleSector(buffers)) {
+						buffers.models = append(buffers.models, model)
 					} else if s := model.sectorHierarchy(); s != nil && s.rendering {
-						meshes = append(meshes, model)
+						buffers.models = append(buffers.models, model)
 					}
 
 				}
@@ -886,10 +1016,10 @@ func (camera *Camera) RenderNodes(scene *Scene, rootNode INode) {
 
 			rootNode.SearchTree().ByType(NodeTypeLight).ForEach(func(node INode) bool {
 				light := node.(ILight)
-				if light.SectorType() == SectorTypeStandalone || (light.SectorType() == SectorTypeObject && light.isInVisibleSector(sectorModels)) {
-					lights = append(lights, light)
+				if light.SectorType() == SectorTypeStandalone || (light.SectorType() == SectorTypeObject && light.isInVisibleSector(buffers)) {
+					buffers.lights = append(buffers.lights, light)
 				} else if s := light.sectorHierarchy(); s != nil && s.rendering {
-					lights = append(lights, light)
+					buffers.lights = append(buffers.lights, light)
 				}
 				return true
 			})
@@ -902,9 +1032,9 @@ func (camera *Camera) RenderNodes(scene *Scene, rootNode INode) {
 
 			// Avoid allocating new model / lights slices
 			if m, ok := node.(*Model); ok && m.DynamicBatchOwner == nil {
-				meshes = append(meshes, m)
+				buffers.models = append(buffers.models, m)
 			} else if l, ok := node.(ILight); ok {
-				lights = append(lights, l)
+				buffers.lights = append(buffers.lights, l)
 			}
 
 			return true
@@ -912,8 +1042,6 @@ func (camera *Camera) RenderNodes(scene *Scene, rootNode INode) {
 
 	}
 
-	camera.Render(scene, lights, meshes...)
-
 }
 
 // RenderImageSequence runs a render function for each frame in an image sequence.
@@ -960,18 +1088,81 @@ var bayerMatrix = []float32{
 	16.0 / 17.0, 8.0 / 17.0, 14.0 / 17.0, 6.0 / 17.0,
 }
 
-var sceneLights []ILight
+// scratch returns the buffers the Camera is currently rendering with, grabbing a set from the pool if it doesn't already have one.
+func (camera *Camera) scratch() *renderBuffers {
+	if camera.buffers == nil {
+		camera.buffers = renderBufferPool.Get().(*renderBuffers)
+	}
+	return camera.buffers
+}
+
+// releaseScratch returns the Camera's render buffers to the pool, so that other Cameras can use them.
+func (camera *Camera) releaseScratch() {
+	if camera.buffers != nil {
+		renderBufferPool.Put(camera.buffers)
+		camera.buffers = nil
+	}
+}
+
+// markRendered marks the Model as having been rendered by the Camera this frame, queueing a call to its OnBecameVisible
+// callback (made once the render lock is released) if it wasn't rendered by the Camera in the previous frame.
+func (camera *Camera) markRendered(model *Model) {
+
+	if (model.OnBecameVisible == nil && model.OnBecameInvisible == nil) || camera.renderedModels.Contains(model) {
+		return
+	}
+
+	camera.renderedModels.Add(model)
+
+	if !camera.prevRenderedModels.Contains(model) && model.OnBecameVisible != nil {
+		onBecameVisible := model.OnBecameVisible
+		buffers := camera.scratch()
+		buffers.callbacks = append(buffers.callbacks, func() { onBecameVisible(model, camera) })
+	}
+
+}
+
+// ModelRendered returns if the Model provided was rendered by the Camera since it was last cleared. Note that this only
+// works for Models that have an OnBecameVisible or OnBecameInvisible callback set.
+func (camera *Camera) ModelRendered(model *Model) bool {
+	return camera.renderedModels.Contains(model)
+}
 
 // Render renders all of the models passed using the provided Scene's properties (fog, for example) and lights provided. Note that if Camera.RenderDepth
 // is false, scenes rendered one after another in multiple Render() calls will be rendered on top of each other in the Camera's texture buffers.
 // Also, the function will automatically include the Scene's world ambient light, if there is a world.
+// Different Cameras can render from multiple goroutines at the same time, even when rendering Models that share Meshes; each Camera renders
+// using its own buffers, and only the processing of shared Meshes and Lights is serialized.
 func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 
+	buffers := camera.scratch()
+	defer camera.releaseScratch()
+
+	// The render lock is released if rendering panics while it's held, so other Cameras don't wait for it forever
+	locked := false
+	lockRender := func() {
+		renderLock.Lock()
+		locked = true
+	}
+	unlockRender := func() {
+		locked = false
+		renderLock.Unlock()
+	}
+	defer func() {
+		if locked {
+			renderLock.Unlock()
+		}
+	}()
+
+	lockRender()
+
 	scene.HandleAutobatch()
 
 	frametimeStart := time.Now()
 
-	sceneLights = sceneLights[:0]
+	buffers.sceneLights = buffers.sceneLights[:0]
+	buffers.windScene = nil
+	buffers.windZones = buffers.windZones[:0]
 
 	if scene.World != nil {
 
@@ -979,7 +1170,7 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 		if scene.World.LightingOn && scene.World.AmbientLight.IsOn() {
 			camera.DebugInfo.ActiveLightCount++
 			scene.World.AmbientLight.beginRender()
-			sceneLights = append(sceneLights, scene.World.AmbientLight)
+			buffers.sceneLights = append(buffers.sceneLights, scene.World.AmbientLight)
 		}
 
 	}
@@ -989,11 +1180,11 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 		if (scene.World == nil || scene.World.LightingOn) && light.IsOn() {
 			camera.DebugInfo.ActiveLightCount++
 			light.beginRender()
-			sceneLights = append(sceneLights, light)
+			buffers.sceneLights = append(buffers.sceneLights, light)
 		}
 	}
 
-	originalSceneLights := sceneLights
+	originalSceneLights := buffers.sceneLights
 
 	// if scene.World == nil || scene.World.LightingOn {
 
@@ -1001,7 +1192,7 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 	// 		if light, isLight := l.(ILight); isLight {
 	// 			camera.DebugInfo.LightCount++
 	// 			if light.IsOn() {
-	// 				sceneLights = append(sceneLights, light)
+	// 				buffers.sceneLights = append(buffers.sceneLights, light)
 	// 				light.beginRender()
 	// 				camera.DebugInfo.ActiveLightCount++
 	// 			}
@@ -1009,7 +1200,7 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 	// 	}
 
 	// 	if scene.World != nil && scene.World.AmbientLight != nil && scene.World.AmbientLight.IsOn() {
-	// 		sceneLights = append(sceneLights, scene.World.AmbientLight)
+	// 		buffers.sceneLights = append(buffers.sceneLights, scene.World.AmbientLight)
 	// 		scene.World.AmbientLight.beginRender()
 	// 		camera.DebugInfo.LightCount++
 	// 		camera.DebugInfo.ActiveLightCount++
@@ -1053,14 +1244,14 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 
 		if !model.DynamicBatcher() {
 
-			if model.FrustumCulling {
+			if model.FrustumCulling && !camera.ModelInFrustum(model) {
+				continue
+			}
 
-				if !camera.ModelInFrustum(model) {
-					continue
-				}
-				model.refreshVertexVisibility()
+			model.refreshVertexVisibility()
 
-			}
+			model.renderedFrame = renderFrame.Load()
+			camera.markRendered(model)
 
 			if model.Mesh != nil {
 
@@ -1105,6 +1296,10 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 						continue
 					}
 
+					child.renderedFrame = renderFrame.Load()
+					camera.markRendered(child)
+					child.refreshVertexVisibility()
+
 					if !transparent {
 
 						for _, mp := range child.Mesh.MeshParts {
@@ -1147,12 +1342,15 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 
 	}
 
+	unlockRender()
+
 	camWidth := camera.resultColorTexture.Bounds().Dx()
 	camHeight := camera.resultColorTexture.Bounds().Dy()
 
+	// render processes the vertices of the given renderPair into the Camera's buffers; the render lock must be held while calling it.
 	render := func(rp renderPair) {
 
-		// startingVertexListIndex := vertexListIndex
+		// startingVertexListIndex := buffers.vertexListIndex
 
 		model := rp.Model
 
@@ -1173,15 +1371,18 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 			}
 		}
 
+		// Per-pixel lit MeshParts are lit in the color shader, rather than through their vertex colors.
+		pixelLit := lighting && camera.pixelLit(scene, model, meshPart)
+
 		camera.DebugInfo.TotalTris += meshPart.TriangleCount()
 
 		if model.DynamicBatchOwner != nil {
 			camera.DebugInfo.BatchedParts++
 		}
 
-		globalSortingTriangleBucket.sortMode = TriangleSortModeBackToFront
+		buffers.sortingTriangles.sortMode = TriangleSortModeBackToFront
 		if meshPart.Material != nil {
-			globalSortingTriangleBucket.sortMode = meshPart.Material.TriangleSortMode
+			buffers.sortingTriangles.sortMode = meshPart.Material.TriangleSortMode
 		}
 
 		model.ProcessVertices(vpMatrix, camera, meshPart, true)
@@ -1204,20 +1405,20 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 			t := time.Now()
 
 			if model.LightGroup != nil && model.LightGroup.Active {
-				sceneLights = model.LightGroup.Lights
-				for _, l := range sceneLights {
+				buffers.sceneLights = model.LightGroup.Lights
+				for _, l := range buffers.sceneLights {
 					l.beginRender() // Call this because it's relatively cheap and necessary if a light doesn't exist in the Scene
 				}
 			} else if camera.MaxLightCount > 0 {
-				sort.SliceStable(sceneLights, func(i, j int) bool {
+				sort.SliceStable(buffers.sceneLights, func(i, j int) bool {
 					// We sort ambient lights as being closest to the camera, naturally
-					_, iOK := sceneLights[i].(*AmbientLight)
-					return iOK || camera.DistanceSquaredTo(sceneLights[i]) < camera.DistanceSquaredTo(sceneLights[j])
+					_, iOK := buffers.sceneLights[i].(*AmbientLight)
+					return iOK || camera.DistanceSquaredTo(buffers.sceneLights[i]) < camera.DistanceSquaredTo(buffers.sceneLights[j])
 				})
-				sceneLights = sceneLights[:math32.Min(camera.MaxLightCount, len(sceneLights))]
+				buffers.sceneLights = buffers.sceneLights[:math32.Min(camera.MaxLightCount, len(buffers.sceneLights))]
 			}
 
-			for _, light := range sceneLights {
+			for _, light := range buffers.sceneLights {
 				light.beginModel(model)
 			}
 
@@ -1238,7 +1439,12 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 			mpColor = mpColor.MultiplyRGBA(meshPart.Material.Color.ToFloat32s())
 		}
 
-		if lighting && !globalSortingTriangleBucket.IsEmpty() {
+		var pixelNormalRotation Matrix4
+		if pixelLit && !model.deformed() {
+			pixelNormalRotation = model.WorldRotation()
+		}
+
+		if lighting && !pixelLit && !buffers.sortingTriangles.IsEmpty() {
 
 			t := time.Now()
 
@@ -1246,7 +1452,7 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 				mesh.vertexLights[vertIndex] = Color{0, 0, 0, 1}
 			}, true)
 
-			for _, light := range sceneLights {
+			for _, light := range buffers.sceneLights {
 
 				// Skip calculating lighting for objects that are too far away from light sources.
 				if point, ok := light.(*PointLight); ok && point.Range > 0 {
@@ -1269,18 +1475,20 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 
 		}
 
-		sceneLights = originalSceneLights
+		buffers.sceneLights = originalSceneLights
 
 		if camera.MaxLightCount > 0 {
-			sceneLights = sceneLights[:math32.Min(camera.MaxLightCount, len(sceneLights))]
+			buffers.sceneLights = buffers.sceneLights[:math32.Min(camera.MaxLightCount, len(buffers.sceneLights))]
 		}
 
 		halfCamWidth, halfCamHeight := float32(camWidth)/2, float32(camHeight)/2
 
-		// TODO: Implement PS1-style automatic tesselation
+		// Large triangles can be tessellated ahead of time using Mesh.Tessellate() (or GLTFLoadOptions.TessellationEdgeLength)
+		// to improve vertex lighting and reduce affine texture warping.
 
 		meshPartVertexIndexStart := meshPart.VertexIndexStart
 
+		clipPlaneOn := camera.clipPlaneOn && len(mesh.vertexClipDistances) >= meshPart.VertexIndexEnd // The distances aren't set if no triangles were processed
 		customDepthOffsetOn := mat != nil && mat.CustomDepthOffsetOn
 		customDepthFunctionSet := mat != nil && mat.CustomDepthFunction != nil
 		vertexClipFunctionOn := model != nil && model.VertexClipFunction != nil
@@ -1315,10 +1523,10 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 
 			// CLIP SCREEN END
 
-			colorVertexList[vertexListIndex].DstX = float32(mesh.vertexTransforms[vertIndex].X)
-			colorVertexList[vertexListIndex].DstY = float32(mesh.vertexTransforms[vertIndex].Y)
-			depthVertexList[vertexListIndex].DstX = float32(mesh.vertexTransforms[vertIndex].X)
-			depthVertexList[vertexListIndex].DstY = float32(mesh.vertexTransforms[vertIndex].Y)
+			buffers.colorVertexList[buffers.vertexListIndex].DstX = float32(mesh.vertexTransforms[vertIndex].X)
+			buffers.colorVertexList[buffers.vertexListIndex].DstY = float32(mesh.vertexTransforms[vertIndex].Y)
+			buffers.depthVertexList[buffers.vertexListIndex].DstX = float32(mesh.vertexTransforms[vertIndex].X)
+			buffers.depthVertexList[buffers.vertexListIndex].DstY = float32(mesh.vertexTransforms[vertIndex].Y)
 
 			var uvU, uvV float32
 
@@ -1333,51 +1541,75 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 				uvV = float32((1 - mesh.VertexUVs[vertIndex].Y) * srcH)
 			}
 
-			colorVertexList[vertexListIndex].SrcX = uvU
-			colorVertexList[vertexListIndex].SrcY = uvV
+			buffers.colorVertexList[buffers.vertexListIndex].SrcX = uvU
+			buffers.colorVertexList[buffers.vertexListIndex].SrcY = uvV
 
 			if camera.PerspectiveCorrectedTextureMapping {
 				d := 1.0 / float32(w)
-				colorVertexList[vertexListIndex].Custom0 = d // Set the perspective divide here
-				depthVertexList[vertexListIndex].Custom0 = d
-				// normalVertexList[vertexListIndex].Custom0 = d
+				buffers.colorVertexList[buffers.vertexListIndex].Custom0 = d // Set the perspective divide here
+				buffers.depthVertexList[buffers.vertexListIndex].Custom0 = d
+				// buffers.normalVertexList[buffers.vertexListIndex].Custom0 = d
 			}
 
-			depthVertexList[vertexListIndex].SrcX = uvU
-			depthVertexList[vertexListIndex].SrcY = uvV
+			// The distance to the clip plane is divided by W so that it interpolates linearly across the screen; only its sign matters.
+			if clipPlaneOn {
+				buffers.depthVertexList[buffers.vertexListIndex].Custom1 = mesh.vertexClipDistances[vertIndex] / w
+			}
+
+			// For per-pixel lighting, the world normal is passed in the remaining custom values; it's multiplied by the perspective divide
+			// so that it can be interpolated with perspective correction.
+			if pixelLit {
+
+				var normal Vector3
+				if model.deformed() {
+					normal = mesh.vertexSkinnedNormals[vertIndex]
+				} else {
+					normal = pixelNormalRotation.MultVec(mesh.VertexNormals[vertIndex])
+				}
+
+				d := 1.0 / float32(w)
+				buffers.colorVertexList[buffers.vertexListIndex].Custom0 = d
+				buffers.colorVertexList[buffers.vertexListIndex].Custom1 = normal.X * d
+				buffers.colorVertexList[buffers.vertexListIndex].Custom2 = normal.Y * d
+				buffers.colorVertexList[buffers.vertexListIndex].Custom3 = normal.Z * d
+
+			}
+
+			buffers.depthVertexList[buffers.vertexListIndex].SrcX = uvU
+			buffers.depthVertexList[buffers.vertexListIndex].SrcY = uvV
 
 			if camera.RenderNormals {
 
-				normalVertexList[vertexListIndex].DstX = float32(mesh.vertexTransforms[vertIndex].X)
-				normalVertexList[vertexListIndex].DstY = float32(mesh.vertexTransforms[vertIndex].Y)
+				buffers.normalVertexList[buffers.vertexListIndex].DstX = float32(mesh.vertexTransforms[vertIndex].X)
+				buffers.normalVertexList[buffers.vertexListIndex].DstY = float32(mesh.vertexTransforms[vertIndex].Y)
 
-				normalVertexList[vertexListIndex].SrcX = uvU
-				normalVertexList[vertexListIndex].SrcY = uvV
+				buffers.normalVertexList[buffers.vertexListIndex].SrcX = uvU
+				buffers.normalVertexList[buffers.vertexListIndex].SrcY = uvV
 
-				normalVertexList[vertexListIndex].ColorR = float32(mesh.vertexTransformedNormals[vertIndex].X*0.5 + 0.5)
-				normalVertexList[vertexListIndex].ColorG = float32(mesh.vertexTransformedNormals[vertIndex].Y*0.5 + 0.5)
-				normalVertexList[vertexListIndex].ColorB = float32(mesh.vertexTransformedNormals[vertIndex].Z*0.5 + 0.5)
+				buffers.normalVertexList[buffers.vertexListIndex].ColorR = float32(mesh.vertexTransformedNormals[vertIndex].X*0.5 + 0.5)
+				buffers.normalVertexList[buffers.vertexListIndex].ColorG = float32(mesh.vertexTransformedNormals[vertIndex].Y*0.5 + 0.5)
+				buffers.normalVertexList[buffers.vertexListIndex].ColorB = float32(mesh.vertexTransformedNormals[vertIndex].Z*0.5 + 0.5)
 
 			}
 
 			// Vertex colors
 
 			if activeChannel := mesh.VertexActiveColorChannel; activeChannel >= 0 {
-				colorVertexList[vertexListIndex].ColorR = mesh.VertexColors[activeChannel][vertIndex].R * mpColor.R
-				colorVertexList[vertexListIndex].ColorG = mesh.VertexColors[activeChannel][vertIndex].G * mpColor.G
-				colorVertexList[vertexListIndex].ColorB = mesh.VertexColors[activeChannel][vertIndex].B * mpColor.B
-				colorVertexList[vertexListIndex].ColorA = mesh.VertexColors[activeChannel][vertIndex].A * mpColor.A
+				buffers.colorVertexList[buffers.vertexListIndex].ColorR = mesh.VertexColors[activeChannel][vertIndex].R * mpColor.R
+				buffers.colorVertexList[buffers.vertexListIndex].ColorG = mesh.VertexColors[activeChannel][vertIndex].G * mpColor.G
+				buffers.colorVertexList[buffers.vertexListIndex].ColorB = mesh.VertexColors[activeChannel][vertIndex].B * mpColor.B
+				buffers.colorVertexList[buffers.vertexListIndex].ColorA = mesh.VertexColors[activeChannel][vertIndex].A * mpColor.A
 			} else {
-				colorVertexList[vertexListIndex].ColorR = mpColor.R
-				colorVertexList[vertexListIndex].ColorG = mpColor.G
-				colorVertexList[vertexListIndex].ColorB = mpColor.B
-				colorVertexList[vertexListIndex].ColorA = mpColor.A
+				buffers.colorVertexList[buffers.vertexListIndex].ColorR = mpColor.R
+				buffers.colorVertexList[buffers.vertexListIndex].ColorG = mpColor.G
+				buffers.colorVertexList[buffers.vertexListIndex].ColorB = mpColor.B
+				buffers.colorVertexList[buffers.vertexListIndex].ColorA = mpColor.A
 			}
 
-			if lighting {
-				colorVertexList[vertexListIndex].ColorR *= mesh.vertexLights[vertIndex].R
-				colorVertexList[vertexListIndex].ColorG *= mesh.vertexLights[vertIndex].G
-				colorVertexList[vertexListIndex].ColorB *= mesh.vertexLights[vertIndex].B
+			if lighting && !pixelLit {
+				buffers.colorVertexList[buffers.vertexListIndex].ColorR *= mesh.vertexLights[vertIndex].R
+				buffers.colorVertexList[buffers.vertexListIndex].ColorG *= mesh.vertexLights[vertIndex].G
+				buffers.colorVertexList[buffers.vertexListIndex].ColorB *= mesh.vertexLights[vertIndex].B
 			}
 
 			if camera.RenderDepth {
@@ -1411,10 +1643,10 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 					depth = 1
 				}
 
-				depthVertexList[vertexListIndex].ColorR = float32(depth)
-				depthVertexList[vertexListIndex].ColorG = float32(depth)
-				depthVertexList[vertexListIndex].ColorB = float32(depth)
-				depthVertexList[vertexListIndex].ColorA = 1
+				buffers.depthVertexList[buffers.vertexListIndex].ColorR = float32(depth)
+				buffers.depthVertexList[buffers.vertexListIndex].ColorG = float32(depth)
+				buffers.depthVertexList[buffers.vertexListIndex].ColorB = float32(depth)
+				buffers.depthVertexList[buffers.vertexListIndex].ColorA = 1
 
 			} else if scene.World != nil && scene.World.FogOn {
 
@@ -1439,48 +1671,48 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 				depth = float32(scene.World.FogRange[0] + ((scene.World.FogRange[1]-scene.World.FogRange[0])*1 - float32(depth)))
 
 				if scene.World.FogMode == FogAdd {
-					colorVertexList[vertexListIndex].ColorR += scene.World.FogColor.R * float32(depth)
-					colorVertexList[vertexListIndex].ColorG += scene.World.FogColor.G * float32(depth)
-					colorVertexList[vertexListIndex].ColorB += scene.World.FogColor.B * float32(depth)
+					buffers.colorVertexList[buffers.vertexListIndex].ColorR += scene.World.FogColor.R * float32(depth)
+					buffers.colorVertexList[buffers.vertexListIndex].ColorG += scene.World.FogColor.G * float32(depth)
+					buffers.colorVertexList[buffers.vertexListIndex].ColorB += scene.World.FogColor.B * float32(depth)
 				} else if scene.World.FogMode == FogSub {
-					colorVertexList[vertexListIndex].ColorR *= scene.World.FogColor.R * float32(depth)
-					colorVertexList[vertexListIndex].ColorG *= scene.World.FogColor.G * float32(depth)
-					colorVertexList[vertexListIndex].ColorB *= scene.World.FogColor.B * float32(depth)
+					buffers.colorVertexList[buffers.vertexListIndex].ColorR *= scene.World.FogColor.R * float32(depth)
+					buffers.colorVertexList[buffers.vertexListIndex].ColorG *= scene.World.FogColor.G * float32(depth)
+					buffers.colorVertexList[buffers.vertexListIndex].ColorB *= scene.World.FogColor.B * float32(depth)
 				}
 
 			}
 
-			vertexListIndex++
+			buffers.vertexListIndex++
 
 		}
 
-		if vertexListIndex == 0 {
+		if buffers.vertexListIndex == 0 {
 			return
 		}
 
-		globalSortingTriangleBucket.ForEach(func(triIndex, triID int, vertexIndices []int) {
+		buffers.sortingTriangles.ForEach(func(triIndex, triID int, vertexIndices []int) {
 
 			for _, index := range vertexIndices {
-				indexList[indexListIndex] = uint16(index - meshPartVertexIndexStart + indexListStart)
-				indexListIndex++
+				buffers.indexList[buffers.indexListIndex] = uint16(index - meshPartVertexIndexStart + buffers.indexListStart)
+				buffers.indexListIndex++
 			}
 
 		})
 
-		indexListStart = vertexListIndex
+		buffers.indexListStart = buffers.vertexListIndex
 
-		// for i := 0; i < vertexListIndex; i++ {
-		// 	indexList[i] = uint16(i)
+		// for i := 0; i < buffers.vertexListIndex; i++ {
+		// 	buffers.indexList[i] = uint16(i)
 		// }
 
 	}
 
 	flush := func(rp renderPair) {
 
-		if vertexListIndex == 0 || indexListIndex == 0 {
-			vertexListIndex = 0
-			indexListIndex = 0
-			indexListStart = 0
+		if buffers.vertexListIndex == 0 || buffers.indexListIndex == 0 {
+			buffers.vertexListIndex = 0
+			buffers.indexListIndex = 0
+			buffers.indexListStart = 0
 			return
 		}
 
@@ -1516,6 +1748,8 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 			// camera.ColorTexture visibly, but does not obscure any objects behind them by writing to the depth texture, thereby
 			// allowing them to be seen through the transparent materials.
 			// Transparent objects are rendered in a second pass, in far-to-close ordering.
+			// (Materials can override whether they write to the DepthTexture, and how they're tested against it, using Material.DepthWrite
+			// and Material.DepthTest.)
 
 			// 3) For alpha clip objects, we first render the triangles to an intermediate texture (camera.AlphaClipIntermediate). We use the camera.ClipAlphaRenderShader to
 			// render the triangles with their vertex colors, and use the texture's alpha channel for clipping. We then draw the intermediate render
@@ -1525,9 +1759,23 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 			// See: https://github.com/hajimehoshi/ebiten/issues/1870
 
 			transparencyMode := TransparencyModeOpaque
+			depthTest := DepthTestLess
+			writeDepth := !model.isTransparent(meshPart)
 
 			if mat != nil {
 				transparencyMode = mat.TransparencyMode
+				depthTest = mat.DepthTest
+				switch mat.DepthWrite {
+				case DepthWriteOn:
+					writeDepth = true
+				case DepthWriteOff:
+					writeDepth = false
+				}
+			}
+
+			clipPlane := 0
+			if camera.clipPlaneOn {
+				clipPlane = 1
 			}
 
 			camera.depthIntermediate.Clear()
@@ -1538,19 +1786,25 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 					Images: [4]*ebiten.Image{camera.resultDepthTexture, img},
 					Uniforms: map[string]any{
 						"PerspectiveCorrection": perspectiveCorrection,
+						"DepthTest":             depthTest,
+						"ClipPlane":             clipPlane,
 					},
 				}
-				camera.depthIntermediate.DrawTrianglesShader(depthVertexList[:vertexListIndex], indexList[:indexListIndex], camera.clipAlphaShader, shaderOpt)
+				camera.depthIntermediate.DrawTrianglesShader(buffers.depthVertexList[:buffers.vertexListIndex], buffers.indexList[:buffers.indexListIndex], camera.clipAlphaShader, shaderOpt)
 
 			} else {
 				shaderOpt := &ebiten.DrawTrianglesShaderOptions{
 					Images: [4]*ebiten.Image{camera.resultDepthTexture},
+					Uniforms: map[string]any{
+						"DepthTest": depthTest,
+						"ClipPlane": clipPlane,
+					},
 				}
 
-				camera.depthIntermediate.DrawTrianglesShader(depthVertexList[:vertexListIndex], indexList[:indexListIndex], camera.depthShader, shaderOpt)
+				camera.depthIntermediate.DrawTrianglesShader(buffers.depthVertexList[:buffers.vertexListIndex], buffers.indexList[:buffers.indexListIndex], camera.depthShader, shaderOpt)
 			}
 
-			if !model.isTransparent(meshPart) {
+			if writeDepth {
 				camera.resultDepthTexture.DrawImage(camera.depthIntermediate, nil)
 			}
 
@@ -1616,6 +1870,37 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 
 		colorPassShaderOptions.Images[0] = img
 		colorPassShaderOptions.Images[1] = camera.depthIntermediate
+		colorPassShaderOptions.Images[2] = camera.resultDepthTexture
+
+		// Opaque materials have already been written to the depth texture, so only transparent ones can fade against it.
+		softParticleDistance := float32(0)
+		if mat != nil && mat.SoftParticleDistance > 0 && model.isTransparent(meshPart) {
+			softParticleDistance = camera.WorldUnitToViewRangePercentage(mat.SoftParticleDistance)
+		}
+		colorPassShaderOptions.Uniforms["SoftParticleDistance"] = softParticleDistance
+
+		// The size of the depth range in world units, so custom shaders can convert differences in depth to world units
+		colorPassShaderOptions.Uniforms["DepthRange"] = (camera.far - camera.near) * (1 + camera.DepthMargin*2)
+
+		pixelLit := camera.pixelLit(scene, model, meshPart)
+
+		if pixelLit {
+
+			lights := buffers.sceneLights
+			if model.LightGroup != nil && model.LightGroup.Active {
+				lights = model.LightGroup.Lights
+			}
+
+			depthOffset := float32(0)
+			if mat.CustomDepthOffsetOn {
+				depthOffset = camera.WorldUnitToViewRangePercentage(mat.CustomDepthOffsetValue)
+			}
+
+			lockRender()
+			camera.setPixelLightUniforms(colorPassShaderOptions.Uniforms, model, meshPart, lights, vpMatrix, depthOffset)
+			unlockRender()
+
+		}
 
 		fogless := float32(0)
 		if mat != nil && mat.Fogless {
@@ -1625,8 +1910,12 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 		if camera.RenderNormals {
 			colorPassShaderOptions.Images[0] = defaultImg
 			colorPassShaderOptions.Uniforms["Fogless"] = 1 // No fog in a normal render
-			camera.resultNormalTexture.DrawTrianglesShader(normalVertexList[:vertexListIndex], indexList[:indexListIndex], camera.colorShader, colorPassShaderOptions)
-			// camera.resultNormalTexture.DrawTrianglesShader(colorVertexList[:vertexListIndex], indexList[:indexListIndex], camera.colorShader, colorPassShaderOptions)
+			colorPassShaderOptions.Uniforms["PixelLighting"] = 0
+			camera.resultNormalTexture.DrawTrianglesShader(buffers.normalVertexList[:buffers.vertexListIndex], buffers.indexList[:buffers.indexListIndex], camera.colorShader, colorPassShaderOptions)
+			if pixelLit {
+				colorPassShaderOptions.Uniforms["PixelLighting"] = 1
+			}
+			// camera.resultNormalTexture.DrawTrianglesShader(buffers.colorVertexList[:buffers.vertexListIndex], buffers.indexList[:buffers.indexListIndex], camera.colorShader, colorPassShaderOptions)
 		} else {
 			colorPassShaderOptions.Uniforms["Fogless"] = fogless
 		}
@@ -1656,9 +1945,12 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 						colorPassShaderOptions.Images[3] = mat.FragmentShaderOptions.Images[3]
 					}
 				}
-				camera.resultColorTexture.DrawTrianglesShader(colorVertexList[:vertexListIndex], indexList[:indexListIndex], mat.fragmentShader, colorPassShaderOptions)
+				if mat.SampleOpaqueColor && camera.opaqueColorTexture != nil {
+					colorPassShaderOptions.Images[3] = camera.opaqueColorTexture
+				}
+				camera.resultColorTexture.DrawTrianglesShader(buffers.colorVertexList[:buffers.vertexListIndex], buffers.indexList[:buffers.indexListIndex], mat.fragmentShader, colorPassShaderOptions)
 			} else {
-				camera.resultColorTexture.DrawTrianglesShader(colorVertexList[:vertexListIndex], indexList[:indexListIndex], camera.colorShader, colorPassShaderOptions)
+				camera.resultColorTexture.DrawTrianglesShader(buffers.colorVertexList[:buffers.vertexListIndex], buffers.indexList[:buffers.indexListIndex], camera.colorShader, colorPassShaderOptions)
 			}
 
 			// camera.resultColorTexture.DrawRectShader(w, h, camera.colorShader, rectShaderOptions)
@@ -1667,19 +1959,19 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 
 			if hasFragShader {
 				// TODO: Review usage of FragmentShaderOptions here.
-				camera.resultColorTexture.DrawTrianglesShader(colorVertexList[:vertexListIndex], indexList[:indexListIndex], mat.fragmentShader, mat.FragmentShaderOptions)
+				camera.resultColorTexture.DrawTrianglesShader(buffers.colorVertexList[:buffers.vertexListIndex], buffers.indexList[:buffers.indexListIndex], mat.fragmentShader, mat.FragmentShaderOptions)
 			} else {
-				camera.resultColorTexture.DrawTriangles(colorVertexList[:vertexListIndex], indexList[:indexListIndex], img, colorPassOptions)
+				camera.resultColorTexture.DrawTriangles(buffers.colorVertexList[:buffers.vertexListIndex], buffers.indexList[:buffers.indexListIndex], img, colorPassOptions)
 			}
 
 		}
 
-		camera.DebugInfo.DrawnTris += indexListIndex / 3
+		camera.DebugInfo.DrawnTris += buffers.indexListIndex / 3
 		camera.DebugInfo.DrawnParts++
 
-		vertexListIndex = 0
-		indexListIndex = 0
-		indexListStart = 0
+		buffers.vertexListIndex = 0
+		buffers.indexListIndex = 0
+		buffers.indexListStart = 0
 
 	}
 
@@ -1691,7 +1983,18 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 		solids, transparents,
 	}
 
-	for _, pass := range renderPasses {
+	for passIndex, pass := range renderPasses {
+
+		// A shader can't sample the image it's rendering to, so Materials that sample the opaque objects rendered behind them need
+		// a copy of the color texture as it is before the transparent pass.
+		if passIndex == 1 && camera.RenderDepth {
+			for _, pair := range pass {
+				if pair.MeshPart.Material != nil && pair.MeshPart.Material.SampleOpaqueColor {
+					camera.copyOpaqueColor()
+					break
+				}
+			}
+		}
 
 		for _, pair := range pass {
 
@@ -1707,6 +2010,8 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 
 			if pair.Model.DynamicBatcher() {
 
+				lockRender()
+
 				modelSlice := pair.Model.DynamicBatchModels[pair.MeshPart]
 
 				sort.Slice(modelSlice, func(i, j int) bool {
@@ -1731,10 +2036,14 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 					}
 				}
 
+				unlockRender()
+
 				flush(pair)
 
 			} else {
+				lockRender()
 				render(pair)
+				unlockRender()
 				flush(pair)
 			}
 
@@ -1744,6 +2053,9 @@ func (camera *Camera) Render(scene *Scene, lights []ILight, models ...*Model) {
 
 	camera.DebugInfo.currentFrameTime += time.Since(frametimeStart)
 
+	// Callbacks (i.e. OnBecameVisible) are called after the render lock is released, so that they can use it themselves
+	buffers.runCallbacks()
+
 }
 
 // packFloat packs two numbers into a single float32 with a given precision. 128 is a good number.
@@ -1788,6 +2100,9 @@ func (camera *Camera) RenderSprite3D(screen *ebiten.Image, renderSettings ...Dra
 
 	camViewProj := camera.ViewMatrix().Mult(camera.Projection())
 
+	verts := [4]ebiten.Vertex{}
+	copy(verts[:], spriteRender3DVerts)
+
 	for _, rs := range renderSettings {
 
 		px := camera.WorldToScreenPixels(rs.WorldPosition)
@@ -1810,21 +2125,21 @@ func (camera *Camera) RenderSprite3D(screen *ebiten.Image, renderSettings ...Dra
 		halfImageW := imageW / 2
 		halfImageH := imageH / 2
 
-		spriteRender3DVerts[0].DstX = float32(px.X) - halfImageW
-		spriteRender3DVerts[0].DstY = float32(px.Y) - halfImageH
+		verts[0].DstX = float32(px.X) - halfImageW
+		verts[0].DstY = float32(px.Y) - halfImageH
 
-		spriteRender3DVerts[1].DstX = float32(px.X) + halfImageW
-		spriteRender3DVerts[1].DstY = float32(px.Y) - halfImageH
-		spriteRender3DVerts[1].SrcX = imageW
+		verts[1].DstX = float32(px.X) + halfImageW
+		verts[1].DstY = float32(px.Y) - halfImageH
+		verts[1].SrcX = imageW
 
-		spriteRender3DVerts[2].DstX = float32(px.X) + halfImageW
-		spriteRender3DVerts[2].DstY = float32(px.Y) + halfImageH
-		spriteRender3DVerts[2].SrcX = imageW
-		spriteRender3DVerts[2].SrcY = imageH
+		verts[2].DstX = float32(px.X) + halfImageW
+		verts[2].DstY = float32(px.Y) + halfImageH
+		verts[2].SrcX = imageW
+		verts[2].SrcY = imageH
 
-		spriteRender3DVerts[3].DstX = float32(px.X) - halfImageW
-		spriteRender3DVerts[3].DstY = float32(px.Y) + halfImageH
-		spriteRender3DVerts[3].SrcY = imageH
+		verts[3].DstX = float32(px.X) - halfImageW
+		verts[3].DstY = float32(px.Y) + halfImageH
+		verts[3].SrcY = imageH
 
 		shaderOptions := &ebiten.DrawTrianglesShaderOptions{}
 		shaderOptions.Images[0] = rs.Image
@@ -1832,7 +2147,7 @@ func (camera *Camera) RenderSprite3D(screen *ebiten.Image, renderSettings ...Dra
 		shaderOptions.Uniforms = map[string]any{
 			"SpriteDepth": depth,
 		}
-		screen.DrawTrianglesShader(spriteRender3DVerts, spriteRender3DIndices, camera.sprite3DShader, shaderOptions)
+		screen.DrawTrianglesShader(verts[:], spriteRender3DIndices, camera.sprite3DShader, shaderOptions)
 
 	}
 
@@ -1851,9 +2166,6 @@ type DynamicRenderSettings struct {
 	Color Color // The color to render the Model.
 }
 
-var dynamicRenderMesh = NewCubeMesh()
-var dynamicRenderOwner = NewModel("dynamic batch render cubes", dynamicRenderMesh)
-
 // DynamicRender quickly and easily renders an object with the desired setup.
 // The Camera must be present in a Scene to perform this function.
 func (camera *Camera) DynamicRender(settings ...DynamicRenderSettings) error {
@@ -1867,6 +2179,12 @@ func (camera *Camera) DynamicRender(settings ...DynamicRenderSettings) error {
 			return errors.New("no render settings to render")
 		}
 
+		if camera.dynamicRenderModel == nil {
+			camera.dynamicRenderModel = NewModel("dynamic batch render cubes", NewCubeMesh())
+		}
+
+		dynamicRenderOwner := camera.dynamicRenderModel
+
 		dynamicRenderOwner.Mesh.MeshParts[0].Material = settings[0].Model.Mesh.MeshParts[0].Material
 
 		dynamicRenderOwner.FrustumCulling = false
@@ -1908,6 +2226,138 @@ func (camera *Camera) DynamicRender(settings ...DynamicRenderSettings) error {
 
 }
 
+// DrawTriangles3DSettings controls how triangles drawn through Camera.DrawTriangles3D() are rendered.
+type DrawTriangles3DSettings struct {
+	Material  *Material // The Material to render the triangles with. If nil, the triangles are rendered untextured.
+	Transform Matrix4   // The transform to render the triangles with. If zero, the vertex positions are treated as being in world space.
+
+	Normals []Vector3 // Optional normals for each vertex, used for lighting. If nil, each vertex uses the normal of (the last) triangle it's a part of.
+	UVs     []Vector2 // Optional UV values for each vertex.
+	Colors  []Color   // Optional colors for each vertex.
+
+	Lights []ILight // The lights to light the triangles with. If nil, all lights in the Camera's Scene are used.
+}
+
+// DrawTriangles3D renders the provided triangles immediately through the same projection, lighting, depth, and sorting pipeline that
+// Models go through when rendered. vertices is a slice of vertex positions, and indices is a slice of indices into that slice, with each three
+// indices forming a triangle. The optional Normals, UVs, and Colors slices in the settings must be either nil or have one entry for each vertex.
+// This is useful for drawing fully procedural geometry that changes every frame (like debug shapes or custom effects) without having to
+// create and maintain Meshes and Models; the Camera reuses its internal buffers from call to call, so drawing doesn't allocate memory
+// unless the number of vertices or triangles drawn grows.
+// As with Camera.DynamicRender(), the Camera must be present in a Scene to perform this function.
+func (camera *Camera) DrawTriangles3D(vertices []Vector3, indices []int, settings DrawTriangles3DSettings) error {
+
+	scene := camera.Scene()
+
+	if scene == nil {
+		return errors.New("camera is not in a scene; cannot render triangles")
+	}
+
+	if len(indices) == 0 {
+		return nil
+	}
+
+	if len(indices)%3 > 0 {
+		return errors.New("number of indices given to Camera.DrawTriangles3D() is not a multiple of 3")
+	}
+
+	triCount := len(indices) / 3
+
+	if triCount >= MaxTriangleCount {
+		return fmt.Errorf("too many triangles given to Camera.DrawTriangles3D(); the maximum renderable in one call is %d", MaxTriangleCount-1)
+	}
+
+	for _, index := range indices {
+		if index < 0 || index >= len(vertices) {
+			return fmt.Errorf("index given to Camera.DrawTriangles3D() is out of range of the vertices given: %d", index)
+		}
+	}
+
+	if (settings.Normals != nil && len(settings.Normals) != len(vertices)) ||
+		(settings.UVs != nil && len(settings.UVs) != len(vertices)) ||
+		(settings.Colors != nil && len(settings.Colors) != len(vertices)) {
+		return errors.New("normals, UVs, and colors given to Camera.DrawTriangles3D() must be nil or have one entry for each vertex")
+	}
+
+	if camera.immediateModel == nil {
+		mesh := NewMesh("immediate triangles")
+		mesh.AddMeshPart(nil)
+		camera.immediateModel = NewModel("immediate triangles", mesh)
+		camera.immediateModel.FrustumCulling = false
+	}
+
+	model := camera.immediateModel
+	mesh := model.Mesh
+	part := mesh.MeshParts[0]
+
+	mesh.setVertexCount(len(vertices))
+
+	copy(mesh.VertexPositions, vertices)
+
+	for i := range mesh.visibleVertices {
+		mesh.visibleVertices[i] = false
+	}
+
+	if settings.UVs != nil {
+		copy(mesh.VertexUVs, settings.UVs)
+	} else {
+		for i := range mesh.VertexUVs {
+			mesh.VertexUVs[i] = Vector2{}
+		}
+	}
+
+	if settings.Colors != nil {
+		mesh.ensureEnoughVertexColorChannels(0)
+		copy(mesh.VertexColors[0], settings.Colors)
+		mesh.VertexActiveColorChannel = 0
+	} else {
+		mesh.VertexActiveColorChannel = -1
+	}
+
+	// The Mesh's Triangles slice acts as a pool; only the Triangles from 0 to the MeshPart's TriangleEnd are rendered.
+	for len(mesh.Triangles) < triCount {
+		mesh.Triangles = append(mesh.Triangles, NewTriangle(part, 0, 0, 0))
+	}
+
+	for t := 0; t < triCount; t++ {
+		tri := mesh.Triangles[t]
+		copy(tri.VertexIndices, indices[t*3:t*3+3])
+		tri.RecalculateCenter()
+		tri.RecalculateNormal()
+		if settings.Normals == nil {
+			tri.ResetVertexNormals()
+		}
+	}
+
+	if settings.Normals != nil {
+		copy(mesh.VertexNormals, settings.Normals)
+	}
+
+	part.Material = settings.Material
+	part.VertexIndexStart = 0
+	part.VertexIndexEnd = len(vertices)
+	part.TriangleStart = 0
+	part.TriangleEnd = triCount - 1
+
+	mesh.UpdateBounds()
+
+	if settings.Transform.IsZero() {
+		model.SetWorldTransform(NewMatrix4())
+	} else {
+		model.SetWorldTransform(settings.Transform)
+	}
+
+	lights := settings.Lights
+	if lights == nil {
+		lights = scene.Root.SearchTree().ILights()
+	}
+
+	camera.Render(scene, lights, model)
+
+	return nil
+
+}
+
 // DrawDebugRenderInfo draws render debug information (like number of drawn objects, number of drawn triangles, frame time, etc)
 // at the top-left of the provided screen *ebiten.Image, using the textScale and color provided.
 // Note that the frame-time mentioned here is purely the time that Tetra3D spends sending render commands to the command queue.
@@ -1960,6 +2410,12 @@ func (camera *Camera) DrawDebugRenderInfo(screen *ebiten.Image, textScale float3
 // image provided.
 func (camera *Camera) DrawDebugWireframe(screen *ebiten.Image, rootNode INode, color Color) {
 
+	sortingTriangles := camera.scratch().sortingTriangles
+	defer camera.releaseScratch()
+
+	renderLock.Lock()
+	defer renderLock.Unlock()
+
 	vpMatrix := camera.ViewMatrix().Mult(camera.Projection())
 
 	allModels := append([]INode{rootNode}, rootNode.SearchTree().INodes()...)
@@ -1992,14 +2448,14 @@ func (camera *Camera) DrawDebugWireframe(screen *ebiten.Image, rootNode INode, c
 
 			for _, meshPart := range model.Mesh.MeshParts {
 
-				globalSortingTriangleBucket.sortMode = TriangleSortModeBackToFront
+				sortingTriangles.sortMode = TriangleSortModeBackToFront
 				if meshPart.Material != nil {
-					globalSortingTriangleBucket.sortMode = meshPart.Material.TriangleSortMode
+					sortingTriangles.sortMode = meshPart.Material.TriangleSortMode
 				}
 
 				model.ProcessVertices(vpMatrix, camera, meshPart, false)
 
-				globalSortingTriangleBucket.ForEach(func(triIndex, triID int, vertexIndices []int) {
+				sortingTriangles.ForEach(func(triIndex, triID int, vertexIndices []int) {
 
 					v0 := camera.clipToScreen(model.Mesh.vertexTransforms[vertexIndices[0]], vertexIndices[0], model, float32(camWidth), float32(camHeight), halfCamWidth, halfCamHeight, true)
 					v1 := camera.clipToScreen(model.Mesh.vertexTransforms[vertexIndices[1]], vertexIndices[1], model, float32(camWidth), float32(camHeight), halfCamWidth, halfCamHeight, true)
@@ -2056,6 +2512,12 @@ func (camera *Camera) DrawDebugWireframe(screen *ebiten.Image, rootNode INode, c
 // image provided.
 func (camera *Camera) DrawDebugDrawOrder(screen *ebiten.Image, rootNode INode, textScale float32, color Color) {
 
+	sortingTriangles := camera.scratch().sortingTriangles
+	defer camera.releaseScratch()
+
+	renderLock.Lock()
+	defer renderLock.Unlock()
+
 	vpMatrix := camera.ViewMatrix().Mult(camera.Projection())
 
 	allModels := append([]INode{rootNode}, rootNode.SearchTree().INodes()...)
@@ -2075,14 +2537,14 @@ func (camera *Camera) DrawDebugDrawOrder(screen *ebiten.Image, rootNode INode, t
 
 			for _, meshPart := range model.Mesh.MeshParts {
 
-				globalSortingTriangleBucket.sortMode = TriangleSortModeBackToFront
+				sortingTriangles.sortMode = TriangleSortModeBackToFront
 				if meshPart.Material != nil {
-					globalSortingTriangleBucket.sortMode = meshPart.Material.TriangleSortMode
+					sortingTriangles.sortMode = meshPart.Material.TriangleSortMode
 				}
 
 				model.ProcessVertices(vpMatrix, camera, meshPart, false)
 
-				globalSortingTriangleBucket.ForEach(func(triIndex, triID int, vertexIndices []int) {
+				sortingTriangles.ForEach(func(triIndex, triID int, vertexIndices []int) {
 
 					screenPos := camera.WorldToScreenPixels(model.Transform().MultVec(model.Mesh.Triangles[triID].Center))
 
@@ -2246,6 +2708,123 @@ func (camera *Camera) DepthTexture() *ebiten.Image {
 	return camera.resultDepthTexture
 }
 
+// DepthAt returns the depth in world units (that is, the distance from the Camera along its forward axis) of whatever was rendered
+// at the given pixel in the Camera's depth texture by any previous Render() or RenderNodes() calls. The boolean returned is false if
+// nothing was rendered at the pixel, the pixel is outside of the Camera's bounds, or Camera.RenderDepth is set to false.
+// As the depth is read back from the GPU, DepthAt should be called while the game is running (i.e. in your game's Update() or Draw() function).
+// Note that the depth is decoded from the depth texture's color channels, so it's precise to roughly 1/16581375th of the Camera's view range.
+func (camera *Camera) DepthAt(x, y int) (float32, bool) {
+
+	if !camera.RenderDepth || !image.Pt(x, y).In(camera.resultDepthTexture.Bounds()) {
+		return 0, false
+	}
+
+	r, g, b, a := camera.resultDepthTexture.At(x, y).RGBA()
+
+	if a == 0 {
+		return 0, false
+	}
+
+	return camera.decodeDepth(float32(r)/float32(a), float32(g)/float32(a), float32(b)/float32(a)), true
+
+}
+
+// DepthData reads back the depth in world units (that is, the distance from the Camera along its forward axis) of each pixel in the Camera's
+// depth texture from any previous Render() or RenderNodes() calls, storing them in the target slice row by row and returning it.
+// If the target slice is nil or too small, a new slice is allocated; pass the previously returned slice to avoid allocating each call.
+// Pixels where nothing was rendered are set to math.MaxFloat32. If Camera.RenderDepth is set to false, DepthData returns nil.
+// As with DepthAt(), DepthData should be called while the game is running.
+func (camera *Camera) DepthData(target []float32) []float32 {
+
+	if !camera.RenderDepth {
+		return nil
+	}
+
+	w, h := camera.Size()
+
+	if cap(target) < w*h {
+		target = make([]float32, w*h)
+	}
+	target = target[:w*h]
+
+	if len(camera.depthPixels) != w*h*4 {
+		camera.depthPixels = make([]byte, w*h*4)
+	}
+
+	camera.resultDepthTexture.ReadPixels(camera.depthPixels)
+
+	for i := range target {
+
+		p := camera.depthPixels[i*4 : i*4+4]
+
+		if p[3] == 0 {
+			target[i] = math.MaxFloat32
+			continue
+		}
+
+		a := float32(p[3])
+		target[i] = camera.decodeDepth(float32(p[0])/a, float32(p[1])/a, float32(p[2])/a)
+
+	}
+
+	return target
+
+}
+
+// WorldPositionAt returns the world position of whatever was rendered at the given pixel in the Camera's depth texture by any previous
+// Render() or RenderNodes() calls, reconstructed from its depth (see Camera.DepthAt()). This can be useful as a cheap fallback for
+// picking or hit detection against rendered geometry. The boolean returned is false if nothing was rendered at the pixel.
+func (camera *Camera) WorldPositionAt(x, y int) (Vector3, bool) {
+
+	depth, ok := camera.DepthAt(x, y)
+	if !ok {
+		return Vector3{}, false
+	}
+
+	forward := camera.WorldRotation().MultVec(Vector3{0, 0, -1})
+
+	if !camera.perspective {
+		// Orthographic rays are parallel, so the pixel's position is offset from the Camera's position on its view plane
+		w, h := camera.Size()
+		right := camera.WorldRotation().MultVec(Vector3{1, 0, 0})
+		up := camera.WorldRotation().MultVec(Vector3{0, 1, 0})
+		sx := (float32(x)/float32(w))*2 - 1
+		sy := 1 - (float32(y)/float32(h))*2
+		halfWidth := camera.orthoScale / 2
+		origin := camera.WorldPosition().Add(right.Scale(sx * halfWidth)).Add(up.Scale(sy * halfWidth * float32(h) / float32(w)))
+		return origin.Add(forward.Scale(depth)), true
+	}
+
+	dir := camera.ScreenToWorldPixels(x, y, 1).Sub(camera.WorldPosition())
+
+	// The depth is measured along the Camera's forward axis, rather than along the ray through the pixel
+	along := dir.Dot(forward)
+	if along <= 0 {
+		return Vector3{}, false
+	}
+
+	return camera.WorldPosition().Add(dir.Scale(depth / along)), true
+
+}
+
+// decodeDepth decodes the depth in world units from the (non-premultiplied) color channels of a pixel in the depth texture.
+func (camera *Camera) decodeDepth(r, g, b float32) float32 {
+
+	// The depth texture stores the projected depth, remapped to range from 0 to 1 (with a margin on either side; see Camera.DepthMargin)
+	encoded := r + (g / 255) + (b / 65025)
+
+	depthMargin := (camera.far - camera.near) * camera.DepthMargin
+	projected := encoded*(camera.far-camera.near+(depthMargin*2)) - depthMargin
+
+	// Undo the projection matrix's depth transformation
+	if camera.perspective {
+		return (projected + 1) * (camera.far - camera.near) / (camera.far + camera.near)
+	}
+
+	return projected * (camera.far - camera.near) / 2
+
+}
+
 // NormalTexture returns the camera's final result normal texture from any previous Render() or RenderNodes() calls. If Camera.RenderNormals is set to false,
 // the function will return nil instead.
 func (camera *Camera) NormalTexture() *ebiten.Image {
@@ -2255,6 +2834,25 @@ func (camera *Camera) NormalTexture() *ebiten.Image {
 	return camera.resultNormalTexture
 }
 
+// OpaqueColorTexture returns a copy of the camera's color texture as it was just before rendering transparent objects in the last
+// Render() or RenderNodes() call. This is only updated if a transparent Material with SampleOpaqueColor set to true was rendered;
+// otherwise, the function returns nil.
+func (camera *Camera) OpaqueColorTexture() *ebiten.Image {
+	return camera.opaqueColorTexture
+}
+
+// copyOpaqueColor copies the color texture to the opaque color texture, creating it if necessary.
+func (camera *Camera) copyOpaqueColor() {
+
+	if camera.opaqueColorTexture == nil {
+		camera.opaqueColorTexture = ebiten.NewImageWithOptions(camera.resultColorTexture.Bounds(), &ebiten.NewImageOptions{Unmanaged: true})
+	}
+
+	camera.opaqueColorTexture.Clear()
+	camera.opaqueColorTexture.DrawImage(camera.resultColorTexture, nil)
+
+}
+
 // AccumulationColorTexture returns the camera's final result accumulation color texture from previous renders. If the Camera's AccumulateColorMode
 // property is set to AccumulateColorModeNone, the function will return nil instead.
 func (camera *Camera) AccumulationColorTexture() *ebiten.Image {
@@ -2270,6 +2868,12 @@ type DrawDebugBoundsColoredSettings struct {
 	RenderAABBs bool  // Whether BoundingAABBs should be rendered or not
 	AABBColor   Color // The color used to render BoundingAABBs
 
+	RenderOBBs bool  // Whether BoundingOBBs should be rendered or not
+	OBBColor   Color // The color used to render BoundingOBBs
+
+	RenderConvexHulls bool  // Whether BoundingConvexHulls should be rendered or not
+	ConvexHullColor   Color // The color used to render BoundingConvexHulls
+
 	RenderSpheres bool  // Whether BoundingSpheres should be rendered or not
 	SphereColor   Color // The color used to render BoundingSpheres
 
@@ -2388,6 +2992,47 @@ func (camera *Camera) DrawDebugBoundsColored(screen *ebiten.Image, rootNode INod
 
 				}
 
+			case *BoundingOBB:
+
+				if options.RenderOBBs {
+
+					corners := bounds.Corners()
+
+					// The first four corners are the front face, and the last four are the back face
+					for i := 0; i < 4; i++ {
+
+						for _, edge := range [][2]Vector3{
+							{corners[i], corners[(i+1)%4]},
+							{corners[i+4], corners[(i+1)%4+4]},
+							{corners[i], corners[i+4]},
+						} {
+							start := camera.WorldToScreenPixels(edge[0])
+							end := camera.WorldToScreenPixels(edge[1])
+							vector.StrokeLine(screen, start.X, start.Y, end.X, end.Y, 1, options.OBBColor.ToNRGBA64(), false)
+						}
+
+					}
+
+				}
+
+			case *BoundingConvexHull:
+
+				if options.RenderConvexHulls {
+
+					vertices := bounds.WorldVertices()
+
+					for _, face := range bounds.Faces {
+
+						for i := range face {
+							start := camera.WorldToScreenPixels(vertices[face[i]])
+							end := camera.WorldToScreenPixels(vertices[face[(i+1)%3]])
+							vector.StrokeLine(screen, start.X, start.Y, end.X, end.Y, 1, options.ConvexHullColor.ToNRGBA64(), false)
+						}
+
+					}
+
+				}
+
 			case *BoundingTriangles:
 
 				if options.RenderBroadphases {
@@ -2473,6 +3118,8 @@ func (camera *Camera) DrawDebugBoundsColored(screen *ebiten.Image, rootNode INod
 func DefaultDrawDebugBoundsSettings() DrawDebugBoundsColoredSettings {
 	return DrawDebugBoundsColoredSettings{
 		RenderAABBs:         true,
+		RenderOBBs:          true,
+		RenderConvexHulls:   true,
 		RenderSpheres:       true,
 		RenderCapsules:      true,
 		RenderTriangles:     true,
@@ -2480,6 +3127,8 @@ func DefaultDrawDebugBoundsSettings() DrawDebugBoundsColoredSettings {
 		RenderBroadphases:   false,
 
 		AABBColor:          NewColor(0, 0.25, 1, 1),
+		OBBColor:           NewColor(0, 0.75, 1, 1),
+		ConvexHullColor:    NewColor(1, 0.75, 0, 1),
 		SphereColor:        NewColor(0.5, 0.25, 1.0, 1),
 		CapsuleColor:       NewColor(0.25, 1, 0, 1),
 		TrianglesColor:     NewColor(0, 0, 0, 0.5),
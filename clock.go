@@ -0,0 +1,189 @@
+package tetra3d
+
+// Updater is anything that's advanced over time by calling its Update() function with the time passed in seconds, like
+// AnimationPlayers, ParticleSystems, TweenManagers, Crowds, Ragdolls, and Ropes.
+type Updater interface {
+	Update(dt float32)
+}
+
+// Clock updates a collection of Updaters (i.e. the animated systems of a Scene) with a common delta time, and allows you to pause, slow
+// down, and single-step them independently of Ebitengine's ticks, so visual bugs can be inspected frame by frame in-game.
+// Every Clock also follows the pause state, time scale, and steps of GlobalClock, so pausing GlobalClock pauses everything.
+type Clock struct {
+	TimeScale float32 // How quickly time passes for the Clock's Updaters; 1 is normal speed, 0.5 is half-speed, and so on. Defaults to 1.
+	StepDelta float32 // The delta time in seconds that the Clock's Updaters advance by for each step while paused. Defaults to 1/60.
+
+	updaters   []Updater
+	paused     bool
+	steps      int // The number of steps requested through Clock.Step().
+	stepsTaken int // The number of the Clock's own steps taken.
+	// The number of GlobalClock's steps taken; each Clock tracks these separately so one global step advances every Clock once.
+	globalStepsTaken int
+	started          bool
+	delta            float32
+	time             float32
+}
+
+// GlobalClock is the Clock that controls time for all Clocks (including each Scene's Clock) - pausing or stepping it pauses or steps
+// all of them, while its TimeScale multiplies theirs. Updaters can also be registered to GlobalClock directly; in that case, GlobalClock
+// must be updated each tick through GlobalClock.Update(), like any other Clock.
+var GlobalClock = NewClock()
+
+// NewClock creates a new Clock.
+func NewClock() *Clock {
+	return &Clock{
+		TimeScale: 1,
+		StepDelta: 1.0 / 60.0,
+		updaters:  []Updater{},
+	}
+}
+
+// Register adds the given Updaters to the Clock, so they're updated whenever the Clock is. An Updater that's already registered
+// isn't added again.
+func (clock *Clock) Register(updaters ...Updater) {
+
+	for _, updater := range updaters {
+
+		registered := false
+
+		for _, existing := range clock.updaters {
+			if existing == updater {
+				registered = true
+				break
+			}
+		}
+
+		if !registered {
+			clock.updaters = append(clock.updaters, updater)
+		}
+
+	}
+
+}
+
+// Unregister removes the given Updaters from the Clock.
+func (clock *Clock) Unregister(updaters ...Updater) {
+
+	for _, updater := range updaters {
+		for i, existing := range clock.updaters {
+			if existing == updater {
+				clock.updaters[i] = nil
+				clock.updaters = append(clock.updaters[:i], clock.updaters[i+1:]...)
+				break
+			}
+		}
+	}
+
+}
+
+// Updaters returns the Updaters registered to the Clock.
+func (clock *Clock) Updaters() []Updater {
+	return append([]Updater{}, clock.updaters...)
+}
+
+// Update advances the Clock by the given delta time (in seconds, usually 1 / ebiten.TPS()), scaled by the Clock's and GlobalClock's
+// TimeScales, updating all of the Clock's registered Updaters. If either the Clock or GlobalClock is paused, the Updaters are only
+// updated (by StepDelta) once per requested step. Update should be called once per game tick.
+func (clock *Clock) Update(dt float32) {
+
+	dt, advance := clock.advance(dt)
+
+	clock.delta = dt
+
+	if !advance {
+		return
+	}
+
+	clock.time += dt
+
+	// Updaters can be registered or unregistered while updating, so the length is checked on each iteration
+	for i := 0; i < len(clock.updaters); i++ {
+		clock.updaters[i].Update(dt)
+	}
+
+}
+
+// advance returns the delta time the Clock advances by for the given (unscaled) delta time, and if it advances at all.
+func (clock *Clock) advance(dt float32) (float32, bool) {
+
+	global := GlobalClock
+	if clock == global {
+		global = nil
+	}
+
+	// Global steps requested before the Clock was first updated don't apply to it.
+	if !clock.started {
+		clock.started = true
+		if global != nil {
+			clock.globalStepsTaken = global.steps
+		}
+	}
+
+	if clock.paused || (global != nil && global.paused) {
+
+		if clock.stepsTaken < clock.steps {
+			clock.stepsTaken++
+			return clock.StepDelta, true
+		}
+
+		if global != nil && clock.globalStepsTaken < global.steps {
+			clock.globalStepsTaken++
+			return clock.StepDelta, true
+		}
+
+		return 0, false
+
+	}
+
+	// Steps that haven't been taken by the time the Clock is resumed are dropped.
+	clock.stepsTaken = clock.steps
+	if global != nil {
+		clock.globalStepsTaken = global.steps
+	}
+
+	scale := clock.TimeScale
+	if global != nil {
+		scale *= global.TimeScale
+	}
+
+	return dt * scale, true
+
+}
+
+// Pause pauses the Clock, so its Updaters stop being updated (other than when stepping).
+func (clock *Clock) Pause() {
+	clock.paused = true
+}
+
+// Resume resumes the Clock after being paused.
+func (clock *Clock) Resume() {
+	clock.paused = false
+}
+
+// SetPaused sets whether the Clock is paused or not.
+func (clock *Clock) SetPaused(paused bool) {
+	clock.paused = paused
+}
+
+// Paused returns if the Clock is paused. Note that a Clock that isn't paused still doesn't advance while GlobalClock is paused.
+func (clock *Clock) Paused() bool {
+	return clock.paused
+}
+
+// Step pauses the Clock and queues the given number of steps, advancing its Updaters by StepDelta once on each of the following
+// calls to Clock.Update() until they've all been taken. Stepping GlobalClock steps every Clock.
+func (clock *Clock) Step(steps int) {
+	clock.paused = true
+	clock.steps += max(steps, 0)
+}
+
+// Delta returns the delta time in seconds that the Clock advanced by on its last update (0 if it didn't advance at all). This is useful for
+// manually updating systems that aren't registered to the Clock (i.e. your own physics) in step with those that are.
+func (clock *Clock) Delta() float32 {
+	return clock.delta
+}
+
+// Time returns the total time in seconds that the Clock has advanced by.
+func (clock *Clock) Time() float32 {
+	return clock.time
+}
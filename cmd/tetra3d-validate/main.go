@@ -0,0 +1,93 @@
+// tetra3d-validate checks GLTF / GLB files for tetra3d-specific issues, like meshes that are too large to render, solid objects
+// without bounds, unsupported material features, and non-uniformly scaled bones, so they can be caught before runtime.
+//
+// Usage:
+//
+//	tetra3d-validate [flags] path/to/file.glb [path/to/other.glb ...]
+//
+// tetra3d-validate exits with a status of 1 if any errors were found (or with -strict, if any warnings were found).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/solarlune/tetra3d"
+)
+
+func main() {
+
+	solid := flag.String("solid", "solid", "A comma-separated list of game properties that mark objects as solid.")
+	strict := flag.Bool("strict", false, "Exit with an error status if any warnings are found, not just errors.")
+	quiet := flag.Bool("quiet", false, "Only report errors, not warnings.")
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: tetra3d-validate [flags] path/to/file.glb [path/to/other.glb ...]")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	options := tetra3d.DefaultGLTFValidationOptions()
+	options.SolidProperties = nil
+
+	for _, prop := range strings.Split(*solid, ",") {
+		if prop = strings.TrimSpace(prop); prop != "" {
+			options.SolidProperties = append(options.SolidProperties, prop)
+		}
+	}
+
+	failed := false
+
+	for _, filename := range flag.Args() {
+
+		dir, file := filepath.Split(filename)
+		if dir == "" {
+			dir = "."
+		}
+
+		issues, err := tetra3d.ValidateGLTFFileSystem(os.DirFS(dir), file, options)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, filename+": error loading file:", err)
+			failed = true
+			continue
+		}
+
+		errorCount, warningCount := 0, 0
+
+		for _, issue := range issues {
+
+			if issue.Severity == tetra3d.ValidationSeverityError {
+				errorCount++
+			} else {
+				warningCount++
+				if *quiet {
+					continue
+				}
+			}
+
+			fmt.Println(filename + ": " + issue.String())
+
+		}
+
+		fmt.Printf("%s: %d error(s), %d warning(s)\n", filename, errorCount, warningCount)
+
+		if errorCount > 0 || (*strict && warningCount > 0) {
+			failed = true
+		}
+
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+
+}
@@ -0,0 +1,626 @@
+// tetra3d-viewer is a simple viewer for GLTF / GLB files, allowing you to fly around the scenes in the file, inspect the scene
+// hierarchy, toggle materials and lighting, and view debug information to help triage problems with assets.
+//
+// Usage:
+//
+//	tetra3d-viewer [flags] path/to/file.glb
+//
+// Run tetra3d-viewer -h for the available flags; once running, press F1 to view the controls.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/solarlune/tetra3d"
+	"github.com/solarlune/tetra3d/colors"
+	"github.com/solarlune/tetra3d/math32"
+)
+
+const controlsText = `WASD: Move, Space / Ctrl: Up / Down, Shift: Move fast
+Right Click: Lock / Unlock mouse look, F: Focus on selected node
+[ / ]: Previous / next scene, R: Reload file
+Tab: Toggle hierarchy - Up / Down: Select node - V: Toggle node visibility
+L: Lighting - G: Fog - T: Textures - U: Unlit - B: Backface culling
+F1: Help - F2: Depth - F3: Wireframe - F4: Fullscreen - F5: Centers
+F6: Perspective correction - F7: Normals - F8: Bounds - F9: Frustums
+F10: Render info
+ESC: Quit`
+
+// hierarchyEntry is a node in the flattened scene hierarchy, along with its depth in the tree.
+type hierarchyEntry struct {
+	Node  tetra3d.INode
+	Depth int
+}
+
+// materialState is the original state of a Material, so that toggling material settings off can restore it.
+type materialState struct {
+	UseTexture      bool
+	Shadeless       bool
+	BackfaceCulling bool
+}
+
+type Viewer struct {
+	Width, Height int
+
+	Filename   string
+	LoadOpts   *tetra3d.GLTFLoadOptions
+	Library    *tetra3d.Library
+	SceneIndex int
+	Scene      *tetra3d.Scene
+	Camera     *tetra3d.Camera
+
+	CameraTilt, CameraRotate           float32
+	CameraTiltSpeed, CameraRotateSpeed float32
+	PrevMousePosition                  tetra3d.Vector3
+	MouseLocked                        bool
+
+	Hierarchy     []hierarchyEntry
+	Selected      int
+	ShowHierarchy bool
+
+	ShowHelp       bool
+	ShowRenderInfo bool
+	ShowDepth      bool
+	ShowWireframe  bool
+	ShowCenters    bool
+	ShowNormals    bool
+	ShowBounds     bool
+	ShowFrustums   bool
+
+	TexturesOn        bool
+	Unlit             bool
+	BackfaceCullingOn bool
+	originalMaterials map[*tetra3d.Material]materialState
+}
+
+func NewViewer(filename string, width, height int, loadOpts *tetra3d.GLTFLoadOptions) (*Viewer, error) {
+
+	viewer := &Viewer{
+		Width:             width,
+		Height:            height,
+		Filename:          filename,
+		LoadOpts:          loadOpts,
+		ShowHelp:          true,
+		ShowRenderInfo:    true,
+		ShowHierarchy:     true,
+		TexturesOn:        true,
+		BackfaceCullingOn: true,
+	}
+
+	if err := viewer.Load(); err != nil {
+		return nil, err
+	}
+
+	return viewer, nil
+
+}
+
+// Load (re)loads the viewer's file, opening the scene at the current scene index.
+func (viewer *Viewer) Load() error {
+
+	dir, file := filepath.Split(viewer.Filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	library, err := tetra3d.LoadGLTFFileSystem(os.DirFS(dir), file, viewer.LoadOpts)
+	if err != nil {
+		return err
+	}
+
+	if len(library.Scenes) == 0 {
+		return errors.New("file " + viewer.Filename + " contains no scenes")
+	}
+
+	viewer.Library = library
+	viewer.originalMaterials = map[*tetra3d.Material]materialState{}
+
+	for _, mat := range library.Materials {
+		viewer.originalMaterials[mat] = materialState{
+			UseTexture:      mat.UseTexture,
+			Shadeless:       mat.Shadeless,
+			BackfaceCulling: mat.BackfaceCulling,
+		}
+	}
+
+	viewer.applyMaterialToggles()
+
+	if viewer.SceneIndex >= len(library.Scenes) {
+		viewer.SceneIndex = 0
+	}
+
+	viewer.OpenScene(viewer.SceneIndex)
+
+	return nil
+
+}
+
+// OpenScene opens the scene at the given index in the loaded file, keeping the camera where it was.
+func (viewer *Viewer) OpenScene(index int) {
+
+	scenes := viewer.Library.Scenes
+
+	index = (index + len(scenes)) % len(scenes)
+	viewer.SceneIndex = index
+	viewer.Scene = scenes[index].Clone()
+
+	if viewer.Camera == nil {
+
+		viewer.Camera = tetra3d.NewCamera(viewer.Width, viewer.Height)
+		viewer.Camera.SetFieldOfView(60)
+		viewer.Camera.SetFar(1000)
+
+		// Start by looking at the scene from a distance based on the size of its contents
+		radius := float32(5)
+		for _, model := range viewer.Scene.Root.SearchTree().Models() {
+			if model.Mesh != nil {
+				radius = math32.Max(radius, model.WorldPosition().Magnitude()+model.Mesh.Dimensions.MaxSpan())
+			}
+		}
+		viewer.Camera.SetLocalPosition(0, radius*0.25, radius)
+
+	}
+
+	viewer.Scene.Root.AddChildren(viewer.Camera)
+
+	viewer.Selected = 0
+	viewer.refreshHierarchy()
+
+}
+
+// refreshHierarchy flattens the scene's hierarchy into a list for the hierarchy inspector.
+func (viewer *Viewer) refreshHierarchy() {
+
+	viewer.Hierarchy = viewer.Hierarchy[:0]
+
+	var add func(node tetra3d.INode, depth int)
+	add = func(node tetra3d.INode, depth int) {
+		if node == viewer.Camera {
+			return
+		}
+		viewer.Hierarchy = append(viewer.Hierarchy, hierarchyEntry{Node: node, Depth: depth})
+		for _, child := range node.Children() {
+			add(child, depth+1)
+		}
+	}
+
+	add(viewer.Scene.Root, 0)
+
+	if viewer.Selected >= len(viewer.Hierarchy) {
+		viewer.Selected = len(viewer.Hierarchy) - 1
+	}
+
+}
+
+// SelectedNode returns the node selected in the hierarchy inspector.
+func (viewer *Viewer) SelectedNode() tetra3d.INode {
+	if viewer.Selected < 0 || viewer.Selected >= len(viewer.Hierarchy) {
+		return nil
+	}
+	return viewer.Hierarchy[viewer.Selected].Node
+}
+
+// applyMaterialToggles applies the texture, unlit, and backface culling toggles to the loaded file's Materials.
+func (viewer *Viewer) applyMaterialToggles() {
+	for mat, original := range viewer.originalMaterials {
+		mat.UseTexture = original.UseTexture && viewer.TexturesOn
+		mat.Shadeless = original.Shadeless || viewer.Unlit
+		mat.BackfaceCulling = original.BackfaceCulling && viewer.BackfaceCullingOn
+	}
+}
+
+func (viewer *Viewer) Update() error {
+
+	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		return ebiten.Termination
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		if err := viewer.Load(); err != nil {
+			fmt.Println("error reloading file:", err)
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketLeft) {
+		viewer.OpenScene(viewer.SceneIndex - 1)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketRight) {
+		viewer.OpenScene(viewer.SceneIndex + 1)
+	}
+
+	viewer.updateToggles()
+	viewer.updateHierarchy()
+	viewer.updateCamera()
+
+	return nil
+
+}
+
+func (viewer *Viewer) updateToggles() {
+
+	toggles := map[ebiten.Key]*bool{
+		ebiten.KeyF1:  &viewer.ShowHelp,
+		ebiten.KeyF2:  &viewer.ShowDepth,
+		ebiten.KeyF3:  &viewer.ShowWireframe,
+		ebiten.KeyF5:  &viewer.ShowCenters,
+		ebiten.KeyF6:  &viewer.Camera.PerspectiveCorrectedTextureMapping,
+		ebiten.KeyF7:  &viewer.ShowNormals,
+		ebiten.KeyF8:  &viewer.ShowBounds,
+		ebiten.KeyF9:  &viewer.ShowFrustums,
+		ebiten.KeyF10: &viewer.ShowRenderInfo,
+		ebiten.KeyTab: &viewer.ShowHierarchy,
+		ebiten.KeyL:   &viewer.Scene.World.LightingOn,
+		ebiten.KeyG:   &viewer.Scene.World.FogOn,
+	}
+
+	for key, value := range toggles {
+		if inpututil.IsKeyJustPressed(key) {
+			*value = !*value
+		}
+	}
+
+	materialToggles := map[ebiten.Key]*bool{
+		ebiten.KeyT: &viewer.TexturesOn,
+		ebiten.KeyU: &viewer.Unlit,
+		ebiten.KeyB: &viewer.BackfaceCullingOn,
+	}
+
+	for key, value := range materialToggles {
+		if inpututil.IsKeyJustPressed(key) {
+			*value = !*value
+			viewer.applyMaterialToggles()
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyF4) {
+		ebiten.SetFullscreen(!ebiten.IsFullscreen())
+	}
+
+}
+
+func (viewer *Viewer) updateHierarchy() {
+
+	// The hierarchy is refreshed every frame in case nodes were added or removed (i.e. by particle systems)
+	viewer.refreshHierarchy()
+
+	if !viewer.ShowHierarchy {
+		return
+	}
+
+	if repeatingKeyPressed(ebiten.KeyUp) && viewer.Selected > 0 {
+		viewer.Selected--
+	}
+
+	if repeatingKeyPressed(ebiten.KeyDown) && viewer.Selected < len(viewer.Hierarchy)-1 {
+		viewer.Selected++
+	}
+
+	selected := viewer.SelectedNode()
+
+	if selected == nil {
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyV) {
+		selected.SetVisible(!selected.Visible(), false)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+
+		distance := float32(5)
+		if model, ok := selected.(*tetra3d.Model); ok && model.Mesh != nil {
+			distance = math32.Max(model.Mesh.Dimensions.MaxSpan()*model.WorldScale().Magnitude(), 1) * 1.5
+		}
+
+		// Move the camera back from the node along the direction the camera is currently facing
+		backward := viewer.Camera.LocalRotation().Forward()
+		viewer.Camera.SetLocalPositionVec(selected.WorldPosition().Add(backward.Scale(distance)))
+
+	}
+
+}
+
+// repeatingKeyPressed returns true when the key is first pressed, and then repeatedly while it's held down.
+func repeatingKeyPressed(key ebiten.Key) bool {
+	duration := inpututil.KeyPressDuration(key)
+	return duration == 1 || (duration >= 20 && duration%4 == 0)
+}
+
+func (viewer *Viewer) updateCamera() {
+
+	moveSpd := float32(0.075)
+
+	if ebiten.IsKeyPressed(ebiten.KeyShift) {
+		moveSpd *= 4
+	}
+
+	// The camera looks down -Z, so its forward vector is inverted
+	forward := viewer.Camera.LocalRotation().Forward().Invert()
+	right := viewer.Camera.LocalRotation().Right()
+
+	pos := viewer.Camera.LocalPosition()
+
+	if ebiten.IsKeyPressed(ebiten.KeyW) {
+		pos = pos.Add(forward.Scale(moveSpd))
+	}
+
+	if ebiten.IsKeyPressed(ebiten.KeyS) {
+		pos = pos.Add(forward.Scale(-moveSpd))
+	}
+
+	if ebiten.IsKeyPressed(ebiten.KeyD) {
+		pos = pos.Add(right.Scale(moveSpd))
+	}
+
+	if ebiten.IsKeyPressed(ebiten.KeyA) {
+		pos = pos.Add(right.Scale(-moveSpd))
+	}
+
+	if ebiten.IsKeyPressed(ebiten.KeySpace) {
+		pos.Y += moveSpd
+	}
+
+	if ebiten.IsKeyPressed(ebiten.KeyControl) {
+		pos.Y -= moveSpd
+	}
+
+	viewer.Camera.SetLocalPositionVec(pos)
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		viewer.MouseLocked = !viewer.MouseLocked
+		if viewer.MouseLocked {
+			ebiten.SetCursorMode(ebiten.CursorModeCaptured)
+		} else {
+			ebiten.SetCursorMode(ebiten.CursorModeVisible)
+		}
+	}
+
+	mx, my := ebiten.CursorPosition()
+	mv := tetra3d.NewVector3(float32(mx), float32(my), 0)
+
+	if viewer.MouseLocked {
+
+		w, h := viewer.Camera.Size()
+
+		diff := mv.Sub(viewer.PrevMousePosition)
+		diff.X /= float32(w)
+		diff.Y /= float32(h)
+
+		accel := float32(3)
+		friction := float32(0.6)
+
+		viewer.CameraTiltSpeed *= friction
+		viewer.CameraRotateSpeed *= friction
+
+		viewer.CameraTiltSpeed -= diff.Y * accel
+		viewer.CameraRotateSpeed -= diff.X * accel
+
+		viewer.CameraTilt += viewer.CameraTiltSpeed
+		viewer.CameraRotate += viewer.CameraRotateSpeed
+
+		viewer.CameraTilt = math32.Clamp(viewer.CameraTilt, -math.Pi/2+0.1, math.Pi/2-0.1)
+
+		rotate := tetra3d.NewMatrix4Rotate(0, 1, 0, viewer.CameraRotate).Rotated(1, 0, 0, viewer.CameraTilt)
+		viewer.Camera.SetLocalRotation(rotate)
+
+	}
+
+	viewer.PrevMousePosition = mv
+
+}
+
+func (viewer *Viewer) Draw(screen *ebiten.Image) {
+
+	screen.Fill(viewer.Scene.World.ClearColor.ToRGBA64())
+
+	camera := viewer.Camera
+
+	camera.Clear()
+	camera.RenderScene(viewer.Scene)
+
+	screen.DrawImage(camera.ColorTexture(), nil)
+
+	root := viewer.Scene.Root
+
+	if viewer.ShowDepth {
+		screen.DrawImage(camera.DepthTexture(), nil)
+	}
+
+	if viewer.ShowWireframe {
+		camera.DrawDebugWireframe(screen, root, colors.White())
+	}
+
+	if viewer.ShowNormals {
+		camera.DrawDebugNormals(screen, root, 0.25, colors.SkyBlue())
+	}
+
+	if viewer.ShowCenters {
+		camera.DrawDebugCenters(screen, root, colors.SkyBlue())
+	}
+
+	if viewer.ShowBounds {
+		camera.DrawDebugBoundsColored(screen, root, tetra3d.DefaultDrawDebugBoundsSettings())
+	}
+
+	if viewer.ShowFrustums {
+		camera.DrawDebugFrustums(screen, root, colors.Yellow())
+	}
+
+	selected := viewer.SelectedNode()
+
+	if viewer.ShowHierarchy && selected != nil && selected != root {
+		camera.DrawDebugWireframe(screen, selected, colors.Orange())
+		camera.DrawDebugCenters(screen, selected, colors.Orange())
+	}
+
+	y := float32(0)
+
+	if viewer.ShowRenderInfo {
+		camera.DrawDebugRenderInfo(screen, 1, colors.White())
+		y = 130
+	}
+
+	status := fmt.Sprintf("%s - Scene %d / %d: %s\nLighting: %s, Fog: %s, Textures: %s, Unlit: %s, Backface culling: %s",
+		filepath.Base(viewer.Filename), viewer.SceneIndex+1, len(viewer.Library.Scenes), viewer.Scene.Name,
+		onOff(viewer.Scene.World.LightingOn), onOff(viewer.Scene.World.FogOn), onOff(viewer.TexturesOn), onOff(viewer.Unlit), onOff(viewer.BackfaceCullingOn))
+
+	camera.DrawDebugText(screen, status, 0, y, 1, colors.LightGray())
+
+	if viewer.ShowHelp {
+		camera.DrawDebugText(screen, controlsText, 0, y+40, 1, colors.LightGray())
+	}
+
+	if viewer.ShowHierarchy {
+		camera.DrawDebugText(screen, viewer.hierarchyText(), float32(viewer.Width)-320, 0, 1, colors.White())
+	}
+
+}
+
+// hierarchyText returns the text for the hierarchy inspector, showing the nodes surrounding the selected node and the details of
+// the selected node.
+func (viewer *Viewer) hierarchyText() string {
+
+	builder := strings.Builder{}
+
+	builder.WriteString("Hierarchy:\n")
+
+	visibleLines := 20
+	start := viewer.Selected - visibleLines/2
+	if start > len(viewer.Hierarchy)-visibleLines {
+		start = len(viewer.Hierarchy) - visibleLines
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	for i := start; i < len(viewer.Hierarchy) && i < start+visibleLines; i++ {
+
+		entry := viewer.Hierarchy[i]
+
+		cursor := "  "
+		if i == viewer.Selected {
+			cursor = "> "
+		}
+
+		hidden := ""
+		if !entry.Node.Visible() {
+			hidden = " (hidden)"
+		}
+
+		builder.WriteString(cursor + strings.Repeat("  ", entry.Depth) + entry.Node.Name() + hidden + "\n")
+
+	}
+
+	selected := viewer.SelectedNode()
+
+	if selected == nil {
+		return builder.String()
+	}
+
+	pos := selected.WorldPosition()
+	builder.WriteString(fmt.Sprintf("\n%s (%s)\nPosition: %.2f, %.2f, %.2f\n", selected.Name(), selected.Type(), pos.X, pos.Y, pos.Z))
+
+	if model, ok := selected.(*tetra3d.Model); ok && model.Mesh != nil {
+
+		mesh := model.Mesh
+		builder.WriteString(fmt.Sprintf("Mesh: %s\nVertices: %d, Triangles: %d\n", mesh.Name, len(mesh.VertexPositions), len(mesh.Triangles)))
+
+		for _, part := range mesh.MeshParts {
+			matName := "nil"
+			if part.Material != nil {
+				matName = part.Material.Name
+			}
+			builder.WriteString(fmt.Sprintf("  Material: %s (%d tris)\n", matName, part.TriangleCount()))
+		}
+
+	}
+
+	if props := selected.Properties(); len(props) > 0 {
+		builder.WriteString("Properties:\n")
+		for name, prop := range props {
+			builder.WriteString(fmt.Sprintf("  %s: %v\n", name, prop.Value))
+		}
+	}
+
+	return builder.String()
+
+}
+
+func onOff(value bool) string {
+	if value {
+		return "On"
+	}
+	return "Off"
+}
+
+func (viewer *Viewer) Layout(w, h int) (int, int) {
+	return viewer.Width, viewer.Height
+}
+
+func main() {
+
+	width := flag.Int("width", 1280, "The width of the viewer's render resolution.")
+	height := flag.Int("height", 720, "The height of the viewer's render resolution.")
+	sceneName := flag.String("scene", "", "The name of the scene to open; defaults to the scene that was open when the file was exported.")
+	groups := flag.Bool("groups", false, "Load collections as Group nodes.")
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: tetra3d-viewer [flags] path/to/file.glb")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	loadOpts := tetra3d.DefaultGLTFLoadOptions()
+	loadOpts.CameraWidth = *width
+	loadOpts.CameraHeight = *height
+	loadOpts.LoadCollectionsAsGroups = *groups
+
+	viewer, err := NewViewer(flag.Arg(0), *width, *height, loadOpts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error loading file:", err)
+		os.Exit(1)
+	}
+
+	sceneIndex := -1
+
+	for i, scene := range viewer.Library.Scenes {
+		if (*sceneName != "" && scene.Name == *sceneName) || (*sceneName == "" && scene == viewer.Library.ExportedScene) {
+			sceneIndex = i
+			break
+		}
+	}
+
+	if sceneIndex < 0 && *sceneName != "" {
+		fmt.Fprintln(os.Stderr, "error: scene "+*sceneName+" not found")
+		os.Exit(1)
+	}
+
+	if sceneIndex > 0 {
+		viewer.OpenScene(sceneIndex)
+	}
+
+	ebiten.SetWindowTitle("Tetra3D Viewer - " + filepath.Base(viewer.Filename))
+	ebiten.SetWindowSize(*width, *height)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+
+	if err := ebiten.RunGame(viewer); err != nil && !errors.Is(err, ebiten.Termination) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+}
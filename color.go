@@ -204,6 +204,32 @@ func (color Color) ConvertTosRGB() Color {
 
 }
 
+// ConvertToLinear() converts the color's R, G, and B components from the sRGB color space back to linear values; this is the inverse
+// of Color.ConvertTosRGB(). This is used to convert colors from how they appear on the screen back to their values in GLTF.
+func (color Color) ConvertToLinear() Color {
+
+	if color.R <= 0.04045 {
+		color.R /= 12.92
+	} else {
+		color.R = math32.Pow((color.R+0.055)/1.055, 2.4)
+	}
+
+	if color.G <= 0.04045 {
+		color.G /= 12.92
+	} else {
+		color.G = math32.Pow((color.G+0.055)/1.055, 2.4)
+	}
+
+	if color.B <= 0.04045 {
+		color.B /= 12.92
+	} else {
+		color.B = math32.Pow((color.B+0.055)/1.055, 2.4)
+	}
+
+	return color
+
+}
+
 // Lerp linearly interpolates the color from the starting color to the target by the percentage given.
 func (c Color) Lerp(other Color, percentage float32) Color {
 
@@ -0,0 +1,198 @@
+package tetra3d
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// CompositorClearWorld clears the Camera's color texture using the clear color of its Scene's World before rendering (see Camera.Clear()).
+	CompositorClearWorld = iota
+
+	// CompositorClearTransparent clears the Camera's color texture to transparent black before rendering, so that the entries
+	// composited before it show through wherever it didn't render anything (i.e. for viewmodels or 3D UI).
+	CompositorClearTransparent
+
+	// CompositorClearNone doesn't clear the Camera's color texture before rendering, so the Camera renders on top of its previous results.
+	CompositorClearNone
+)
+
+const (
+	// CompositorDepthClear clears the Camera's depth texture before rendering, so its objects render on top of everything composited before it
+	// (i.e. for a first-person viewmodel that shouldn't clip into walls).
+	CompositorDepthClear = iota
+
+	// CompositorDepthPreserve copies the depth texture of the previous entry into the Camera's depth texture before rendering, so its objects are
+	// hidden behind the objects rendered by the previous entry. This only lines up properly if both Cameras share the same position, rotation,
+	// projection, size, and near and far planes, and requires Camera.RenderDepth to be on for both Cameras.
+	CompositorDepthPreserve
+)
+
+// CompositorEntry is a single Camera rendered by a Compositor.
+type CompositorEntry struct {
+	Camera *Camera // The Camera to render.
+	// The Scene to render. If nil, the Scene the Camera is in is rendered; if the Camera isn't in a Scene either, the entry is skipped.
+	Scene *Scene
+	// The root of the Nodes to render from the Scene. If nil, the Scene's root is used (and so the whole Scene is rendered).
+	Root INode
+
+	ClearMode int // How the Camera is cleared before rendering. Defaults to CompositorClearWorld for the first entry, and CompositorClearTransparent for the rest.
+	DepthMode int // How the Camera's depth texture is set up before rendering. Defaults to CompositorDepthClear.
+
+	// DrawOptions are the options used to draw the Camera's color texture onto the Compositor's output texture. The Camera's color
+	// texture is scaled to fit the output texture before DrawOptions.GeoM is applied. Defaults to an empty set of DrawImageOptions.
+	DrawOptions *ebiten.DrawImageOptions
+
+	Active bool // Whether the entry is rendered and composited. Defaults to true.
+}
+
+// Compositor renders an ordered list of Cameras (i.e. the game world, a first-person viewmodel, and 3D UI elements) and composites
+// their color textures on top of each other, in order, into a single output texture.
+type Compositor struct {
+	Entries []*CompositorEntry
+	output  *ebiten.Image
+}
+
+// NewCompositor creates a new Compositor with an output texture of the given size.
+func NewCompositor(width, height int) *Compositor {
+	comp := &Compositor{}
+	comp.Resize(width, height)
+	return comp
+}
+
+// Resize resizes the Compositor's output texture to the given size. Note that this doesn't resize the Cameras of its entries.
+func (comp *Compositor) Resize(width, height int) {
+
+	if comp.output != nil {
+		if size := comp.output.Bounds().Size(); size.X == width && size.Y == height {
+			return
+		}
+		comp.output.Dispose()
+	}
+
+	comp.output = ebiten.NewImage(width, height)
+
+}
+
+// AddCamera adds an entry for the given Camera to render the given Scene (which can be nil to render the Scene the Camera is in)
+// to the end of the Compositor's entries, and returns the entry for customization.
+func (comp *Compositor) AddCamera(camera *Camera, scene *Scene) *CompositorEntry {
+
+	entry := &CompositorEntry{
+		Camera:      camera,
+		Scene:       scene,
+		ClearMode:   CompositorClearTransparent,
+		DepthMode:   CompositorDepthClear,
+		DrawOptions: &ebiten.DrawImageOptions{},
+		Active:      true,
+	}
+
+	if len(comp.Entries) == 0 {
+		entry.ClearMode = CompositorClearWorld
+	}
+
+	comp.Entries = append(comp.Entries, entry)
+
+	return entry
+
+}
+
+// RemoveCamera removes any entries rendering the given Camera from the Compositor.
+func (comp *Compositor) RemoveCamera(camera *Camera) {
+
+	entries := comp.Entries[:0]
+
+	for _, entry := range comp.Entries {
+		if entry.Camera != camera {
+			entries = append(entries, entry)
+		}
+	}
+
+	for i := len(entries); i < len(comp.Entries); i++ {
+		comp.Entries[i] = nil
+	}
+
+	comp.Entries = entries
+
+}
+
+// Render clears and renders the Camera of each active entry in order, and then composites their color textures on top of each
+// other into the Compositor's output texture, which is returned.
+func (comp *Compositor) Render() *ebiten.Image {
+
+	comp.output.Clear()
+
+	outputSize := comp.output.Bounds().Size()
+
+	var prev *Camera
+
+	for _, entry := range comp.Entries {
+
+		if !entry.Active || entry.Camera == nil {
+			continue
+		}
+
+		camera := entry.Camera
+
+		scene := entry.Scene
+		if scene == nil {
+			scene = camera.Scene()
+		}
+
+		if scene == nil {
+			continue
+		}
+
+		switch entry.ClearMode {
+		case CompositorClearWorld:
+			camera.Clear()
+		case CompositorClearTransparent:
+			camera.ClearWithColor(NewColor(0, 0, 0, 0))
+		}
+
+		if camera.RenderDepth {
+
+			if entry.ClearMode == CompositorClearNone || entry.DepthMode == CompositorDepthPreserve {
+				camera.resultDepthTexture.Clear()
+			}
+
+			if entry.DepthMode == CompositorDepthPreserve && prev != nil && prev.RenderDepth {
+				camera.resultDepthTexture.DrawImage(prev.resultDepthTexture, nil)
+			}
+
+		}
+
+		root := entry.Root
+		if root == nil {
+			root = scene.Root
+		}
+
+		camera.RenderNodes(scene, root)
+
+		opt := &ebiten.DrawImageOptions{}
+
+		if entry.DrawOptions != nil {
+			*opt = *entry.DrawOptions
+			opt.GeoM.Reset()
+		}
+
+		w, h := camera.Size()
+		opt.GeoM.Scale(float64(outputSize.X)/float64(w), float64(outputSize.Y)/float64(h))
+
+		if entry.DrawOptions != nil {
+			opt.GeoM.Concat(entry.DrawOptions.GeoM)
+		}
+
+		comp.output.DrawImage(camera.ColorTexture(), opt)
+
+		prev = camera
+
+	}
+
+	return comp.output
+
+}
+
+// Texture returns the Compositor's output texture, holding the results of the last Render() call.
+func (comp *Compositor) Texture() *ebiten.Image {
+	return comp.output
+}
@@ -0,0 +1,395 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// IConstraint represents an object that alters Nodes' transforms after animation has been applied (like an IK solver).
+// Constraints can be added to an AnimationPlayer's Constraints slice to be applied automatically after each
+// AnimationPlayer.Update() call, or you can call Apply() yourself whenever it suits your game.
+type IConstraint interface {
+	// Apply applies the constraint to the Nodes it affects.
+	Apply()
+}
+
+// rotateTowards rotates the given Node in world space so that the currentDir vector (a direction in world space,
+// usually from the Node to one of its children) points along the targetDir vector instead. influence controls how much
+// of the rotation is applied, ranging from 0 (none) to 1 (all).
+func rotateTowards(node INode, currentDir, targetDir Vector3, influence float32) {
+
+	currentDir = currentDir.Unit()
+	targetDir = targetDir.Unit()
+
+	if currentDir.IsZero() || targetDir.IsZero() || influence <= 0 {
+		return
+	}
+
+	angle := currentDir.Angle(targetDir)
+
+	if angle < 0.0001 {
+		return
+	}
+
+	axis := currentDir.Cross(targetDir)
+
+	// The vectors point in opposite directions, so any axis perpendicular to the current direction will do
+	if axis.IsZero() {
+		axis = currentDir.Cross(WorldUp)
+		if axis.IsZero() {
+			axis = currentDir.Cross(WorldRight)
+		}
+	}
+
+	angle *= math32.Clamp(influence, 0, 1)
+
+	// The axis is in world space, so we bring it into the parent's space and rotate the Node locally from there.
+	if parent := node.Parent(); parent != nil {
+		axis = parent.WorldRotation().Transposed().MultVec(axis)
+	}
+
+	node.SetLocalRotation(node.LocalRotation().Mult(NewMatrix4Rotate(axis.X, axis.Y, axis.Z, angle)))
+
+}
+
+// setWorldRotation sets the Node's rotation in world space by way of setting its local rotation, taking into account its parent's rotation.
+func setWorldRotation(node INode, rotation Matrix4) {
+	if parent := node.Parent(); parent != nil {
+		rotation = rotation.Mult(parent.WorldRotation().Transposed())
+	}
+	node.SetLocalRotation(rotation)
+}
+
+// LookAtConstraint is a constraint that rotates a Node so that one of its local axes points towards a target.
+// This is useful for turret heads, eyes, or characters' heads tracking something of interest.
+type LookAtConstraint struct {
+	Node INode // The Node to rotate.
+
+	TargetNode     INode   // If set, the Node looks towards this Node's world position; otherwise, TargetPosition is used.
+	TargetPosition Vector3 // The world position to look towards if TargetNode is nil.
+
+	// Axis is the local axis of the Node that should point towards the target. Defaults to WorldForward (-Z), which is
+	// the direction Cameras and Lights face. Note that bones exported from Blender generally point along +Y (WorldUp) instead.
+	Axis Vector3
+
+	Influence float32 // How strongly the constraint affects the Node, ranging from 0 to 1. Defaults to 1.
+	Enabled   bool    // Whether the constraint is enabled or not. Defaults to true.
+}
+
+// NewLookAtConstraint creates a new LookAtConstraint that rotates the node provided to look at the target Node
+// (which can be nil if you want to set the target position manually through LookAtConstraint.TargetPosition).
+func NewLookAtConstraint(node, target INode) *LookAtConstraint {
+	return &LookAtConstraint{
+		Node:       node,
+		TargetNode: target,
+		Axis:       WorldForward,
+		Influence:  1,
+		Enabled:    true,
+	}
+}
+
+// Target returns the world position that the constraint is looking towards.
+func (c *LookAtConstraint) Target() Vector3 {
+	if c.TargetNode != nil {
+		return c.TargetNode.WorldPosition()
+	}
+	return c.TargetPosition
+}
+
+// Apply applies the constraint to its Node.
+func (c *LookAtConstraint) Apply() {
+
+	if !c.Enabled || c.Node == nil {
+		return
+	}
+
+	currentDir := c.Node.WorldRotation().MultVec(c.Axis)
+	rotateTowards(c.Node, currentDir, c.Target().Sub(c.Node.WorldPosition()), c.Influence)
+
+}
+
+// CopyRotationConstraint is a constraint that makes a Node copy the rotation of another Node.
+type CopyRotationConstraint struct {
+	Node      INode   // The Node to rotate.
+	Source    INode   // The Node to copy the rotation from.
+	Local     bool    // If the constraint should copy the Source's local rotation, rather than its world rotation. Defaults to false.
+	Influence float32 // How strongly the constraint affects the Node, ranging from 0 to 1. Defaults to 1.
+	Enabled   bool    // Whether the constraint is enabled or not. Defaults to true.
+}
+
+// NewCopyRotationConstraint creates a new CopyRotationConstraint that makes the node provided copy the source Node's rotation
+// with the given influence.
+func NewCopyRotationConstraint(node, source INode, influence float32) *CopyRotationConstraint {
+	return &CopyRotationConstraint{
+		Node:      node,
+		Source:    source,
+		Influence: influence,
+		Enabled:   true,
+	}
+}
+
+// Apply applies the constraint to its Node.
+func (c *CopyRotationConstraint) Apply() {
+
+	if !c.Enabled || c.Node == nil || c.Source == nil || c.Influence <= 0 {
+		return
+	}
+
+	influence := math32.Clamp(c.Influence, 0, 1)
+
+	if c.Local {
+		c.Node.SetLocalRotation(c.Node.LocalRotation().Lerp(c.Source.LocalRotation(), influence))
+	} else {
+		setWorldRotation(c.Node, c.Node.WorldRotation().Lerp(c.Source.WorldRotation(), influence))
+	}
+
+}
+
+// RotationLimitConstraint is a constraint that limits how far a Node can rotate away from a rest (or reference) local rotation.
+// It can either limit the rotation to a cone (i.e. a neck that can't turn more than 60 degrees in any direction), or to a hinge
+// rotating around a single axis (i.e. a knee or elbow).
+type RotationLimitConstraint struct {
+	Node INode   // The Node to limit.
+	Rest Matrix4 // The local rotation the limits are relative to. Defaults to the Node's local rotation when the constraint is created.
+
+	// MaxAngle is the maximum angle in radians that the Node can rotate away from its rest rotation in any direction.
+	// This is only used if HingeAxis is zero.
+	MaxAngle float32
+
+	// HingeAxis, if set to a non-zero vector, limits the Node to only rotating around this local axis, ranging from
+	// MinHingeAngle to MaxHingeAngle (in radians) away from its rest rotation.
+	HingeAxis     Vector3
+	MinHingeAngle float32
+	MaxHingeAngle float32
+
+	Enabled bool // Whether the constraint is enabled or not. Defaults to true.
+}
+
+// NewRotationLimitConstraint creates a new RotationLimitConstraint that limits the Node provided to rotating up to maxAngle radians
+// away from its current local rotation in any direction.
+func NewRotationLimitConstraint(node INode, maxAngle float32) *RotationLimitConstraint {
+	return &RotationLimitConstraint{
+		Node:     node,
+		Rest:     node.LocalRotation(),
+		MaxAngle: maxAngle,
+		Enabled:  true,
+	}
+}
+
+// NewHingeLimitConstraint creates a new RotationLimitConstraint that limits the Node provided to only rotating around the given
+// local axis, from minAngle to maxAngle radians away from its current local rotation.
+func NewHingeLimitConstraint(node INode, axis Vector3, minAngle, maxAngle float32) *RotationLimitConstraint {
+	return &RotationLimitConstraint{
+		Node:          node,
+		Rest:          node.LocalRotation(),
+		HingeAxis:     axis,
+		MinHingeAngle: minAngle,
+		MaxHingeAngle: maxAngle,
+		Enabled:       true,
+	}
+}
+
+// Apply applies the constraint to its Node.
+func (c *RotationLimitConstraint) Apply() {
+
+	if !c.Enabled || c.Node == nil {
+		return
+	}
+
+	// The rotation the Node has relative to its rest rotation
+	diff := c.Node.LocalRotation().Mult(c.Rest.Transposed()).ToQuaternion().Normalized()
+
+	if !c.HingeAxis.IsZero() {
+
+		// Decompose the rotation into twist around the hinge axis (and throw away the rest)
+		axis := c.HingeAxis.Unit()
+		angle := 2 * math32.Atan2(Vector3{diff.X, diff.Y, diff.Z}.Dot(axis), diff.W)
+		if angle > math32.Pi {
+			angle -= math32.Pi * 2
+		} else if angle < -math32.Pi {
+			angle += math32.Pi * 2
+		}
+		angle = math32.Clamp(angle, c.MinHingeAngle, c.MaxHingeAngle)
+		c.Node.SetLocalRotation(NewMatrix4Rotate(axis.X, axis.Y, axis.Z, angle).Mult(c.Rest))
+
+	} else {
+
+		axis, angle := diff.ToAxisAngle()
+		if angle > math32.Pi {
+			angle -= math32.Pi * 2
+		}
+		if math32.Abs(angle) > c.MaxAngle {
+			angle = math32.Copysign(c.MaxAngle, angle)
+			c.Node.SetLocalRotation(NewMatrix4Rotate(axis.X, axis.Y, axis.Z, angle).Mult(c.Rest))
+		}
+
+	}
+
+}
+
+// CopyLocationConstraint is a constraint that makes a Node copy the position of another Node.
+type CopyLocationConstraint struct {
+	Node      INode   // The Node to move.
+	Source    INode   // The Node to copy the position from.
+	Local     bool    // If the constraint should copy the Source's local position, rather than its world position. Defaults to false.
+	Influence float32 // How strongly the constraint affects the Node, ranging from 0 to 1. Defaults to 1.
+	Enabled   bool    // Whether the constraint is enabled or not. Defaults to true.
+}
+
+// NewCopyLocationConstraint creates a new CopyLocationConstraint that makes the node provided copy the source Node's position
+// with the given influence.
+func NewCopyLocationConstraint(node, source INode, influence float32) *CopyLocationConstraint {
+	return &CopyLocationConstraint{
+		Node:      node,
+		Source:    source,
+		Influence: influence,
+		Enabled:   true,
+	}
+}
+
+// Apply applies the constraint to its Node.
+func (c *CopyLocationConstraint) Apply() {
+
+	if !c.Enabled || c.Node == nil || c.Source == nil || c.Influence <= 0 {
+		return
+	}
+
+	influence := math32.Clamp(c.Influence, 0, 1)
+
+	if c.Local {
+		c.Node.SetLocalPositionVec(c.Node.LocalPosition().Lerp(c.Source.LocalPosition(), influence))
+	} else {
+		c.Node.SetWorldPositionVec(c.Node.WorldPosition().Lerp(c.Source.WorldPosition(), influence))
+	}
+
+}
+
+// importedConstraint is a bone constraint imported from Blender; it's turned into an IConstraint once the bones
+// and target it refers to can be found in the AnimationPlayer's tree.
+type importedConstraint struct {
+	Bone       string
+	Type       string
+	Target     string
+	Influence  float32
+	Local      bool
+	Limits     [3]importedRotationLimit
+	Axis       Vector3
+	ChainCount int
+}
+
+type importedRotationLimit struct {
+	Enabled  bool
+	Min, Max float32
+}
+
+// resolve creates the IConstraint for the imported constraint, using the given root to find the bone it affects, and the scene root
+// to find its target. If the bone or target can't be found, resolve returns nil.
+func (ic *importedConstraint) resolve(root, sceneRoot INode) IConstraint {
+
+	find := func(name string) INode {
+		if name == "" {
+			return nil
+		}
+		if root.Name() == name {
+			return root
+		}
+		if found := root.SearchTree().ByName(name).First(); found != nil {
+			return found
+		}
+		if sceneRoot != nil {
+			return sceneRoot.SearchTree().ByName(name).First()
+		}
+		return nil
+	}
+
+	bone := find(ic.Bone)
+	if bone == nil {
+		return nil
+	}
+
+	target := find(ic.Target)
+
+	switch ic.Type {
+
+	case "COPY_ROTATION":
+		if target == nil {
+			return nil
+		}
+		c := NewCopyRotationConstraint(bone, target, ic.Influence)
+		c.Local = ic.Local
+		return c
+
+	case "COPY_LOCATION":
+		if target == nil {
+			return nil
+		}
+		c := NewCopyLocationConstraint(bone, target, ic.Influence)
+		c.Local = ic.Local
+		return c
+
+	case "DAMPED_TRACK":
+		if target == nil {
+			return nil
+		}
+		c := NewLookAtConstraint(bone, target)
+		c.Axis = ic.Axis
+		c.Influence = ic.Influence
+		return c
+
+	case "LIMIT_ROTATION":
+
+		// Bones' limits are in the bones' local space.
+		axes := [3]Vector3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+		limited := []int{}
+		for i, limit := range ic.Limits {
+			if limit.Enabled {
+				limited = append(limited, i)
+			}
+		}
+
+		if len(limited) == 0 {
+			return nil
+		}
+
+		// A single limited axis is a hinge; otherwise, the limits are approximated with a cone using the widest limit.
+		if len(limited) == 1 {
+			limit := ic.Limits[limited[0]]
+			return NewHingeLimitConstraint(bone, axes[limited[0]], limit.Min, limit.Max)
+		}
+
+		maxAngle := float32(0)
+		for _, i := range limited {
+			maxAngle = math32.Max(maxAngle, math32.Max(math32.Abs(ic.Limits[i].Min), math32.Abs(ic.Limits[i].Max)))
+		}
+		return NewRotationLimitConstraint(bone, maxAngle)
+
+	case "IK":
+
+		if target == nil {
+			return nil
+		}
+
+		// The chain includes the constrained bone and ChainCount - 1 of its parents (or all of them if ChainCount is 0).
+		bones := []INode{bone}
+		for parent := bone.Parent(); parent != nil && parent.IsBone() && (ic.ChainCount <= 0 || len(bones) < ic.ChainCount); parent = parent.Parent() {
+			bones = append([]INode{parent}, bones...)
+		}
+
+		// Blender's IK reaches with the tail of the constrained bone, so the bone's first child (if it has one) is used as the tip.
+		for _, child := range bone.Children() {
+			if child.IsBone() {
+				bones = append(bones, child)
+				break
+			}
+		}
+
+		if len(bones) < 2 {
+			return nil
+		}
+
+		c := NewIKChain(target, bones...)
+		c.Influence = ic.Influence
+		return c
+
+	}
+
+	return nil
+
+}
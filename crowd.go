@@ -0,0 +1,374 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// PathAgent is an agent that follows a path (using a PathStepper) at a limited speed, while steering around the other agents in
+// its Crowd. PathAgents move the Node they're created with; the Node's world position is used as the agent's position.
+type PathAgent struct {
+	Node INode // The Node that the agent moves.
+
+	Radius          float32 // The radius of the agent; agents steer to keep at least their combined radii away from each other.
+	MaxSpeed        float32 // The maximum speed of the agent in units per second.
+	MaxAcceleration float32 // How quickly the agent can change its velocity, in units per second squared. Defaults to 20.
+
+	// ArriveDistance is how close the agent has to get to a point on its path to consider it reached and move on to the next one.
+	// Defaults to 0.1.
+	ArriveDistance float32
+
+	Velocity Vector3 // The current velocity of the agent.
+
+	// OnTraverse is called when the agent is about to cross a segment of its path that isn't GridConnectionTypeWalk (i.e. a jump,
+	// ladder, or teleport GridConnection on a GridPath, or an off-mesh link on a NavMeshPath), which is useful for triggering
+	// animations or moving the Node yourself.
+	// If OnTraverse returns true, the agent continues across the segment as usual (and OnTraverse won't be called again for it);
+	// if it returns false, the agent holds still and OnTraverse is called again on the next Update, so the traversal can play out
+	// over several frames (i.e. by teleporting the Node to the other side or waiting for a jump to finish).
+	OnTraverse func(agent *PathAgent, segment PathSegment) bool
+
+	stepper   *PathStepper
+	segments  []PathSegment
+	arrived   bool
+	traversed int // The index of the last point reached by a segment OnTraverse allowed the agent to cross
+}
+
+// NewPathAgent creates a new PathAgent that moves the given Node, with the radius and maximum speed provided.
+func NewPathAgent(node INode, radius, maxSpeed float32) *PathAgent {
+	return &PathAgent{
+		Node:            node,
+		Radius:          radius,
+		MaxSpeed:        maxSpeed,
+		MaxAcceleration: 20,
+		ArriveDistance:  0.1,
+		arrived:         true,
+		traversed:       -1,
+	}
+}
+
+// SetPath sets the path for the PathAgent to follow, starting from its beginning. Passing nil stops the agent.
+func (agent *PathAgent) SetPath(path IPath) {
+
+	if path == nil || len(path.Points()) == 0 {
+		agent.stepper = nil
+		agent.segments = nil
+		agent.arrived = true
+		return
+	}
+
+	agent.stepper = NewPathStepper(path)
+	agent.segments = path.Segments()
+	agent.arrived = false
+	agent.traversed = -1
+
+}
+
+// PathStepper returns the PathStepper the agent uses to step through its path. If the agent has no path, this returns nil.
+func (agent *PathAgent) PathStepper() *PathStepper {
+	return agent.stepper
+}
+
+// Arrived returns if the agent has reached the end of its path (or has no path to follow).
+func (agent *PathAgent) Arrived() bool {
+	return agent.arrived
+}
+
+// Stop stops the agent, clearing its path.
+func (agent *PathAgent) Stop() {
+	agent.SetPath(nil)
+}
+
+// desiredVelocity returns the velocity the agent would like to have to follow its path, ignoring other agents.
+func (agent *PathAgent) desiredVelocity() Vector3 {
+
+	if agent.arrived || agent.stepper == nil {
+		return Vector3{}
+	}
+
+	pos := agent.Node.WorldPosition()
+
+	for {
+
+		diff := agent.stepper.CurrentWorldPosition().Sub(pos)
+		dist := diff.Magnitude()
+
+		if dist > agent.ArriveDistance {
+
+			if !agent.canTraverse() {
+				return Vector3{}
+			}
+
+			// Slow down when approaching the end of the path
+			speed := agent.MaxSpeed
+			if agent.stepper.AtEnd() {
+				speed = math32.Min(speed, dist*4)
+			}
+
+			return diff.Unit().Scale(speed)
+
+		}
+
+		if agent.stepper.AtEnd() {
+			agent.arrived = true
+			return Vector3{}
+		}
+
+		agent.stepper.Next()
+
+	}
+
+}
+
+// canTraverse returns if the agent can head towards the current point on its path, calling OnTraverse if it's reached by
+// crossing a special segment (i.e. a jump or ladder).
+func (agent *PathAgent) canTraverse() bool {
+
+	index := agent.stepper.Index
+
+	// The segment leading to each point is the one before it, as the first point has no segment leading to it
+	if agent.OnTraverse == nil || index <= 0 || index > len(agent.segments) || index == agent.traversed {
+		return true
+	}
+
+	segment := agent.segments[index-1]
+
+	if segment.Type == GridConnectionTypeWalk {
+		return true
+	}
+
+	if agent.OnTraverse(agent, segment) {
+		agent.traversed = index
+		return true
+	}
+
+	return false
+
+}
+
+// Crowd is a collection of PathAgents that follow their paths while steering around each other (using separation forces along with
+// predictive avoidance of agents that are about to collide), so that agents don't clip through one another when pathing to the same place.
+// Avoidance is performed on the horizontal (X and Z) plane.
+type Crowd struct {
+	Agents []*PathAgent // The agents in the Crowd.
+
+	// AvoidanceTime is how far ahead in seconds agents look for upcoming collisions with other agents to steer around. Defaults to 1.
+	AvoidanceTime float32
+
+	// AvoidanceStrength is how strongly agents steer away from each other, ranging from 0 (not at all) upwards. Defaults to 1.
+	AvoidanceStrength float32
+}
+
+// NewCrowd creates a new Crowd with the given agents.
+func NewCrowd(agents ...*PathAgent) *Crowd {
+	return &Crowd{
+		Agents:            append([]*PathAgent{}, agents...),
+		AvoidanceTime:     1,
+		AvoidanceStrength: 1,
+	}
+}
+
+// Add adds the given agents to the Crowd.
+func (crowd *Crowd) Add(agents ...*PathAgent) {
+	crowd.Agents = append(crowd.Agents, agents...)
+}
+
+// Remove removes the given agent from the Crowd.
+func (crowd *Crowd) Remove(agent *PathAgent) {
+	for i, a := range crowd.Agents {
+		if a == agent {
+			crowd.Agents = append(crowd.Agents[:i], crowd.Agents[i+1:]...)
+			return
+		}
+	}
+}
+
+// Update moves all agents in the Crowd along their paths by the given delta time (in seconds), steering them around each other.
+func (crowd *Crowd) Update(dt float32) {
+
+	if dt <= 0 {
+		return
+	}
+
+	velocities := make([]Vector3, len(crowd.Agents))
+
+	for i, agent := range crowd.Agents {
+
+		desired := agent.desiredVelocity()
+
+		avoidance := crowd.avoidance(agent)
+
+		target := desired.Add(avoidance.Scale(agent.MaxSpeed * crowd.AvoidanceStrength)).ClampMagnitude(agent.MaxSpeed)
+
+		// Agents that have arrived stay put unless they're being pushed out of the way.
+		if agent.arrived && avoidance.IsZero() {
+			target = Vector3{}
+		}
+
+		velocities[i] = agent.Velocity.MoveTowards(target, agent.MaxAcceleration*dt)
+
+	}
+
+	for i, agent := range crowd.Agents {
+		agent.Velocity = velocities[i]
+		if !agent.Velocity.IsZero() {
+			moveWorld(agent.Node, agent.Velocity.Scale(dt))
+		}
+	}
+
+	crowd.separate()
+
+	// Agents still heading towards the end of their path, but blocked by agents that have already arrived there, are considered arrived as well.
+	for _, agent := range crowd.Agents {
+
+		if agent.arrived || agent.stepper == nil || !agent.stepper.AtEnd() {
+			continue
+		}
+
+		pos := agent.Node.WorldPosition()
+		goal := agent.stepper.CurrentWorldPosition()
+		goalDist := pos.DistanceSquared(goal)
+
+		for _, other := range crowd.Agents {
+
+			if other == agent || !other.arrived {
+				continue
+			}
+
+			otherPos := other.Node.WorldPosition()
+			touching := agent.Radius + other.Radius + agent.ArriveDistance
+
+			if horizontalDistanceSquared(pos, otherPos) <= touching*touching && otherPos.DistanceSquared(goal) < goalDist {
+				agent.arrived = true
+				break
+			}
+
+		}
+
+	}
+
+}
+
+// avoidance returns the steering direction the agent should take to avoid the other agents in the Crowd.
+func (crowd *Crowd) avoidance(agent *PathAgent) Vector3 {
+
+	steer := Vector3{}
+
+	pos := agent.Node.WorldPosition()
+	pos.Y = 0
+
+	vel := agent.Velocity
+	vel.Y = 0
+
+	for _, other := range crowd.Agents {
+
+		if other == agent {
+			continue
+		}
+
+		otherPos := other.Node.WorldPosition()
+		otherPos.Y = 0
+
+		otherVel := other.Velocity
+		otherVel.Y = 0
+
+		minDist := agent.Radius + other.Radius
+		diff := pos.Sub(otherPos)
+		dist := diff.Magnitude()
+
+		// Separation: push away from agents we're already overlapping
+		if dist < minDist {
+			if dist < 0.0001 {
+				// Agents are right on top of each other, so push them apart in opposite directions
+				diff = Vector3{1, 0, 0}
+				if crowd.index(agent) < crowd.index(other) {
+					diff = diff.Invert()
+				}
+				dist = 0
+			}
+			steer = steer.Add(diff.Unit().Scale((minDist - dist) / minDist))
+			continue
+		}
+
+		// Predictive avoidance: steer away from where the agents will be at their closest approach if they're going to collide
+		relVel := vel.Sub(otherVel)
+		speedSquared := relVel.MagnitudeSquared()
+
+		if speedSquared < 0.0001 {
+			continue
+		}
+
+		t := -diff.Dot(relVel) / speedSquared
+
+		if t <= 0 || t > crowd.AvoidanceTime {
+			continue
+		}
+
+		closest := diff.Add(relVel.Scale(t))
+		closestDist := closest.Magnitude()
+
+		if closestDist >= minDist {
+			continue
+		}
+
+		if closestDist < 0.0001 {
+			// Head-on; sidestep to the right of our velocity
+			closest = vel.Cross(WorldUp)
+			if closest.IsZero() {
+				closest = diff
+			}
+		}
+
+		steer = steer.Add(closest.Unit().Scale((1 - t/crowd.AvoidanceTime) * (minDist - closestDist) / minDist))
+
+	}
+
+	return steer
+
+}
+
+// separate pushes apart any agents that are still overlapping after moving.
+func (crowd *Crowd) separate() {
+
+	for i, agent := range crowd.Agents {
+
+		for _, other := range crowd.Agents[i+1:] {
+
+			pos := agent.Node.WorldPosition()
+			otherPos := other.Node.WorldPosition()
+
+			diff := pos.Sub(otherPos)
+			diff.Y = 0
+			dist := diff.Magnitude()
+			minDist := agent.Radius + other.Radius
+
+			if dist >= minDist || dist < 0.0001 {
+				continue
+			}
+
+			push := diff.Unit().Scale((minDist - dist) / 2)
+			moveWorld(agent.Node, push)
+			moveWorld(other.Node, push.Invert())
+
+		}
+
+	}
+
+}
+
+func (crowd *Crowd) index(agent *PathAgent) int {
+	for i, a := range crowd.Agents {
+		if a == agent {
+			return i
+		}
+	}
+	return -1
+}
+
+// moveWorld moves the Node by the given vector in world space.
+func moveWorld(node INode, vec Vector3) {
+	node.SetWorldPositionVec(node.WorldPosition().Add(vec))
+}
+
+func horizontalDistanceSquared(a, b Vector3) float32 {
+	dx := a.X - b.X
+	dz := a.Z - b.Z
+	return dx*dx + dz*dz
+}
@@ -0,0 +1,96 @@
+package tetra3d
+
+import (
+	"sort"
+
+	"github.com/tanema/gween/ease"
+)
+
+// CurvePoint indicates an individual value point in a Curve.
+type CurvePoint struct {
+	Value      float32
+	Percentage float32
+}
+
+// Curve represents a range of values that a value of 0 to 1 can interpolate between (i.e. for how something changes over time).
+// It's the single-value counterpart of ColorCurve.
+type Curve struct {
+	Points         []CurvePoint
+	EasingFunction ease.TweenFunc
+}
+
+// NewCurve creates a new Curve composed of the values given, evenly spaced throughout the curve.
+// If no values are given, the Curve is still valid - it's just empty.
+func NewCurve(values ...float32) Curve {
+	curve := Curve{
+		Points:         []CurvePoint{},
+		EasingFunction: ease.Linear,
+	}
+
+	if len(values) == 1 {
+		curve.Add(values[0], 0)
+	} else if len(values) > 1 {
+		for i, value := range values {
+			curve.Add(value, float32(i)/float32(len(values)-1))
+		}
+	}
+
+	return curve
+}
+
+// Clone creates a duplicate Curve.
+func (curve Curve) Clone() Curve {
+	newCurve := NewCurve()
+	newCurve.Points = append(newCurve.Points, curve.Points...)
+	newCurve.EasingFunction = curve.EasingFunction
+	return newCurve
+}
+
+// Add adds a point to the Curve with the value and percentage provided (from 0-1).
+func (curve *Curve) Add(value float32, percentage float32) {
+
+	if percentage > 1 {
+		percentage = 1
+	} else if percentage < 0 {
+		percentage = 0
+	}
+
+	curve.Points = append(curve.Points, CurvePoint{
+		Value:      value,
+		Percentage: percentage,
+	})
+
+	sort.Slice(curve.Points, func(i, j int) bool { return curve.Points[i].Percentage < curve.Points[j].Percentage })
+}
+
+// Value returns the value for the given percentage in the Curve. For example, if you have a curve composed of
+// the values 0 at 0 and 1 at 1, then calling Curve.Value(0.5) would return 0.5.
+// If the curve doesn't have any points, Value will return 0.
+func (curve Curve) Value(perc float32) float32 {
+
+	var v float32
+
+	for i := 0; i < len(curve.Points); i++ {
+
+		v = curve.Points[i].Value
+
+		if i >= len(curve.Points)-1 || curve.Points[i].Percentage >= perc {
+			break
+		}
+
+		if curve.Points[i].Percentage <= perc && curve.Points[i+1].Percentage >= perc {
+			easing := curve.EasingFunction
+			if easing == nil {
+				easing = ease.Linear
+			}
+			pp := perc - curve.Points[i].Percentage
+			t := easing(pp, 0, 1, curve.Points[i+1].Percentage-curve.Points[i].Percentage)
+			v = curve.Points[i].Value + (curve.Points[i+1].Value-curve.Points[i].Value)*t
+			break
+		}
+
+	}
+
+	return v
+
+}
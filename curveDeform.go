@@ -0,0 +1,235 @@
+package tetra3d
+
+import (
+	"math"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// curveDeformSamples is the number of frames sampled along the section of a Path that a curve-deformed Model covers.
+const curveDeformSamples = 64
+
+// CurveDeform bends a Model's Mesh along a Path as it renders, similar to Blender's Curve modifier. This is useful for snakes, ropes, tentacles,
+// or trains bending along rails. A CurveDeform is set on a Model through Model.SetCurveDeform().
+//
+// The Mesh's vertices are laid along the Path according to how far along the Axis they are, with distance 0 being the Path's start;
+// the rest of each vertex's position (its offset away from the Axis) is rotated to follow the Path as it turns. The Model's world
+// scale still applies to the Mesh, but its position and rotation are replaced by the Path's (so move the Mesh along the Path by
+// changing the Offset instead).
+// Past the ends of an open Path, the Mesh continues on in a straight line; for closed Paths, it loops around.
+//
+// Like armature skinning, curve deformation is done on the CPU, and so is more expensive than rendering a Mesh normally.
+type CurveDeform struct {
+	Path   *Path   // The Path the Model bends along.
+	Axis   Vector3 // The local axis of the Mesh that follows the Path (i.e. {0, 0, -1} for a Mesh facing -Z).
+	Up     Vector3 // The upward direction of both the Mesh and Path, used to orient the Mesh around the Path. Defaults to WorldUp (+Y).
+	Offset float32 // How far along the Path the Mesh is positioned.
+
+	// If Stretch is true, the Mesh is stretched or squashed along the Axis to cover the length of the Path exactly (starting from Offset).
+	Stretch bool
+
+	sampled       bool   // Whether the Path has been sampled in sampledFrame
+	sampledFrame  uint64 // The render frame the Path was last sampled in
+	frameStart    float32
+	frameStep     float32
+	positions     [curveDeformSamples]Vector3
+	forwards      [curveDeformSamples]Vector3
+	rights        [curveDeformSamples]Vector3
+	ups           [curveDeformSamples]Vector3
+	meshForward   Vector3
+	meshRight     Vector3
+	meshUp        Vector3
+	meshScale     Vector3
+	distanceStart float32
+	distanceScale float32
+}
+
+// SetCurveDeform sets the Model to bend its Mesh along the given Path, with the provided local axis of the Mesh following the Path
+// (i.e. {0, 0, -1} for a Mesh facing -Z). If the Model is also skinned, the curve deformation is used instead of the armature.
+// The CurveDeform is returned so that it can be customized further (i.e. to move the Mesh along
+// the Path by changing its Offset). Passing a nil Path clears any existing curve deformation.
+func (model *Model) SetCurveDeform(path *Path, axis Vector3) *CurveDeform {
+
+	if path == nil {
+		model.ClearCurveDeform()
+		return nil
+	}
+
+	model.curveDeform = &CurveDeform{
+		Path: path,
+		Axis: axis,
+		Up:   WorldUp,
+	}
+
+	return model.curveDeform
+
+}
+
+// CurveDeform returns the CurveDeform bending the Model along a Path, or nil if the Model isn't curve-deformed.
+func (model *Model) CurveDeform() *CurveDeform {
+	return model.curveDeform
+}
+
+// ClearCurveDeform stops the Model from bending along a Path, returning it to being rendered normally.
+func (model *Model) ClearCurveDeform() {
+	model.curveDeform = nil
+	model.onTransformUpdate()
+}
+
+// Clone returns a clone of the CurveDeform, following the same Path.
+func (cd *CurveDeform) Clone() *CurveDeform {
+	return &CurveDeform{
+		Path:    cd.Path,
+		Axis:    cd.Axis,
+		Up:      cd.Up,
+		Offset:  cd.Offset,
+		Stretch: cd.Stretch,
+	}
+}
+
+// update samples the frames of the section of the Path that the Model's Mesh covers, and updates the Model's bounding sphere for frustum
+// culling to surround that section. The Path is only sampled once each frame, however many MeshParts and Cameras render the Model.
+func (cd *CurveDeform) update(model *Model) {
+
+	frame := renderFrame.Load()
+	if cd.sampled && cd.sampledFrame == frame {
+		return
+	}
+	cd.sampled = true
+	cd.sampledFrame = frame
+
+	cd.meshForward = cd.Axis.Unit()
+	if cd.meshForward.IsZero() {
+		cd.meshForward = WorldBackward
+	}
+
+	cd.meshRight, cd.meshUp = curveDeformBasis(cd.meshForward, cd.Up)
+	cd.meshScale = model.WorldScale()
+
+	// Find the range the Mesh covers along the axis.
+	dim := model.Mesh.Dimensions
+	minAlong, maxAlong := float32(0), float32(0)
+	for i, axis := range [3]float32{cd.meshForward.X, cd.meshForward.Y, cd.meshForward.Z} {
+		scale := [3]float32{cd.meshScale.X, cd.meshScale.Y, cd.meshScale.Z}[i]
+		a := [3]float32{dim.Min.X, dim.Min.Y, dim.Min.Z}[i] * scale * axis
+		b := [3]float32{dim.Max.X, dim.Max.Y, dim.Max.Z}[i] * scale * axis
+		minAlong += math32.Min(a, b)
+		maxAlong += math32.Max(a, b)
+	}
+
+	length := cd.Path.Length()
+
+	cd.distanceScale = 1
+	cd.distanceStart = cd.Offset
+	if cd.Stretch && maxAlong > minAlong {
+		cd.distanceScale = (length - cd.Offset) / (maxAlong - minAlong)
+		cd.distanceStart = cd.Offset - minAlong*cd.distanceScale
+	}
+
+	cd.frameStart = cd.distanceStart + minAlong*cd.distanceScale
+	cd.frameStep = (maxAlong - minAlong) * cd.distanceScale / (curveDeformSamples - 1)
+
+	boundsMin := Vector3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+	boundsMax := boundsMin.Invert()
+
+	for i := 0; i < curveDeformSamples; i++ {
+
+		distance := cd.frameStart + cd.frameStep*float32(i)
+
+		var pos, forward Vector3
+
+		if cd.Path.Closed || (distance >= 0 && distance <= length) {
+			pos = cd.Path.PositionAtDistance(distance)
+			forward = cd.Path.TangentAtDistance(distance)
+		} else {
+			// Continue on in a straight line past the ends of open Paths.
+			end := math32.Clamp(distance, 0, length)
+			forward = cd.Path.TangentAtDistance(end)
+			pos = cd.Path.PositionAtDistance(end).Add(forward.Scale(distance - end))
+		}
+
+		if forward.IsZero() {
+			forward = cd.meshForward
+		}
+
+		cd.positions[i] = pos
+		cd.forwards[i] = forward
+		cd.rights[i], cd.ups[i] = curveDeformBasis(forward, cd.Up)
+
+		boundsMin = Vector3{math32.Min(boundsMin.X, pos.X), math32.Min(boundsMin.Y, pos.Y), math32.Min(boundsMin.Z, pos.Z)}
+		boundsMax = Vector3{math32.Max(boundsMax.X, pos.X), math32.Max(boundsMax.Y, pos.Y), math32.Max(boundsMax.Z, pos.Z)}
+
+	}
+
+	if model.updateFrustumSphere {
+		dim.Min = dim.Min.Mult(cd.meshScale)
+		dim.Max = dim.Max.Mult(cd.meshScale)
+		model.frustumCullingSphere.SetLocalPositionVec(boundsMin.Add(boundsMax).Scale(0.5))
+		model.frustumCullingSphere.Radius = boundsMax.Sub(boundsMin).Magnitude()/2 + dim.MaxSpan()/2
+	}
+
+}
+
+// deformVertex returns the world position and normal of the given vertex of the Model's Mesh after bending it along the Path.
+func (cd *CurveDeform) deformVertex(model *Model, vertID int) (Vector3, Vector3) {
+
+	vert := model.Mesh.VertexPositions[vertID].Mult(cd.meshScale)
+	normal := model.Mesh.VertexNormals[vertID]
+
+	along := vert.Dot(cd.meshForward)
+	right := vert.Dot(cd.meshRight)
+	up := vert.Dot(cd.meshUp)
+
+	index := float32(0)
+	if cd.frameStep > 0 {
+		index = math32.Clamp((cd.distanceStart+along*cd.distanceScale-cd.frameStart)/cd.frameStep, 0, curveDeformSamples-1)
+	}
+
+	i := int(index)
+	if i >= curveDeformSamples-1 {
+		i = curveDeformSamples - 2
+	}
+	perc := index - float32(i)
+
+	pos := cd.positions[i].Lerp(cd.positions[i+1], perc)
+	pathForward := cd.forwards[i].Lerp(cd.forwards[i+1], perc).Unit()
+	pathRight := cd.rights[i].Lerp(cd.rights[i+1], perc).Unit()
+	pathUp := cd.ups[i].Lerp(cd.ups[i+1], perc).Unit()
+
+	vertOut := pos.Add(pathRight.Scale(right)).Add(pathUp.Scale(up))
+
+	normalOut := pathForward.Scale(normal.Dot(cd.meshForward)).
+		Add(pathRight.Scale(normal.Dot(cd.meshRight))).
+		Add(pathUp.Scale(normal.Dot(cd.meshUp)))
+
+	return vertOut, normalOut
+
+}
+
+// curveDeformBasis returns the right and up vectors of a frame facing forward, oriented according to the up vector given
+// (in the same manner as NewLookAtMatrix()).
+func curveDeformBasis(forward, up Vector3) (Vector3, Vector3) {
+
+	up = up.Unit()
+
+	if up.IsZero() {
+		up = WorldUp
+	}
+
+	right := up.Cross(forward)
+
+	// If forward and up are parallel, swap up out with another direction
+	if right.IsZero() {
+		if !up.Equals(WorldRight) && !up.Equals(WorldLeft) {
+			up = WorldRight
+		} else {
+			up = WorldBackward
+		}
+		right = up.Cross(forward)
+	}
+
+	right = right.Unit()
+
+	return right, forward.Cross(right)
+
+}
@@ -0,0 +1,92 @@
+package tetra3d
+
+import (
+	_ "embed"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+//go:embed shaders/distanceField.kage
+var distanceFieldShaderSrc []byte
+
+//go:embed shaders/distanceFieldIcon.kage
+var distanceFieldIconShaderSrc []byte
+
+var distanceFieldShader *ebiten.Shader
+
+// maxDistanceFieldSpread is the furthest a distance field can extend from the edges of its shapes, in pixels.
+const maxDistanceFieldSpread = 16
+
+func clampDistanceFieldSpread(spread int) float32 {
+	if spread < 1 {
+		return 1
+	}
+	if spread > maxDistanceFieldSpread {
+		return maxDistanceFieldSpread
+	}
+	return float32(spread)
+}
+
+// drawDistanceField draws a signed distance field generated from the alpha of the src image to the dst image.
+func drawDistanceField(dst, src *ebiten.Image, spread int) {
+
+	if distanceFieldShader == nil {
+		shader, err := ebiten.NewShader(distanceFieldShaderSrc)
+		if err != nil {
+			panic(err)
+		}
+		distanceFieldShader = shader
+	}
+
+	dst.DrawRectShader(src.Bounds().Dx(), src.Bounds().Dy(), distanceFieldShader, &ebiten.DrawRectShaderOptions{
+		Images: [4]*ebiten.Image{src},
+		Uniforms: map[string]any{
+			"Spread": clampDistanceFieldSpread(spread),
+		},
+		Blend: ebiten.BlendCopy,
+	})
+
+}
+
+// GenerateDistanceField generates a signed distance field texture out of the alpha of the given image (i.e. an icon or a glyph),
+// returning it as a new image of the same size. Each pixel of the distance field stores the distance to the nearest edge of the shapes
+// in the source image; 0.5 is right on the edge, with values towards 1 being inside of the shapes and values towards 0 being outside of them.
+// spread is how far (in pixels) the distance field extends from the edges, ranging from 1 to 16. Rendering a distance field (i.e. with a
+// Material created through NewDistanceFieldMaterial()) keeps the edges of the shapes crisp when they're magnified, rather than
+// blurry or pixelated.
+// As the distance field is generated on the GPU, GenerateDistanceField should be called once the game is running.
+func GenerateDistanceField(src *ebiten.Image, spread int) *ebiten.Image {
+	dst := ebiten.NewImage(src.Bounds().Dx(), src.Bounds().Dy())
+	drawDistanceField(dst, src, spread)
+	return dst
+}
+
+// NewDistanceFieldMaterial creates a new transparent Material that renders the shapes in the given image (i.e. an icon or label)
+// using a signed distance field generated from the image's alpha (see GenerateDistanceField()), so the shapes stay crisp when
+// scaled up in 3D without needing enormous textures. The shapes are drawn using the Material's Color (and the Model's Color),
+// while spread is how far (in pixels) the distance field extends from the edges of the shapes, ranging from 1 to 16.
+func NewDistanceFieldMaterial(name string, src *ebiten.Image, spread int) *Material {
+
+	mat := NewMaterial(name)
+	mat.Texture = GenerateDistanceField(src, spread)
+	mat.TransparencyMode = TransparencyModeTransparent
+
+	shader, err := ExtendBase3DShader(string(distanceFieldIconShaderSrc))
+	if err != nil {
+		panic(err)
+	}
+
+	mat.SetShader(shader)
+
+	mat.FragmentShaderOptions = &ebiten.DrawTrianglesShaderOptions{
+		Images: [4]*ebiten.Image{
+			mat.Texture,
+		},
+		Uniforms: map[string]any{
+			"Spread": clampDistanceFieldSpread(spread),
+		},
+	}
+
+	return mat
+
+}
@@ -0,0 +1,187 @@
+package tetra3d
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// gltfDracoExtension is the name of the GLTF extension used to compress mesh data with Draco.
+const gltfDracoExtension = "KHR_draco_mesh_compression"
+
+// DracoDecoder is a function that decodes a mesh primitive compressed using the KHR_draco_mesh_compression GLTF extension.
+// data is the Draco-compressed data, and attributes maps the GLTF names of the primitive's vertex attributes (i.e. "POSITION",
+// "NORMAL", "TEXCOORD_0") to the unique IDs of those attributes in the Draco data. tetra3d doesn't include a Draco decoder itself,
+// so this allows you to plug in whichever decoder you prefer (i.e. a Go port or bindings to Google's Draco library).
+type DracoDecoder func(data []byte, attributes map[string]int) (*DracoDecodedMesh, error)
+
+// DracoDecodedMesh is the result of decoding a Draco-compressed mesh primitive using a DracoDecoder.
+type DracoDecodedMesh struct {
+	Indices []uint32 // The triangle indices of the primitive.
+
+	// Attributes are the decoded vertex attributes of the primitive, keyed by GLTF attribute name (i.e. "POSITION").
+	// The components of each vertex are laid out one after another (i.e. X, Y, Z, X, Y, Z, ... for positions). Normalized
+	// integer attributes (like vertex colors, usually) should be converted to floats ranging from 0 to 1, as in the GLTF file.
+	Attributes map[string][]float32
+}
+
+// gltfDracoPrimitive is the data of the KHR_draco_mesh_compression extension on a GLTF primitive.
+type gltfDracoPrimitive struct {
+	BufferView int            `json:"bufferView"`
+	Attributes map[string]int `json:"attributes"`
+}
+
+// decodeDracoPrimitives decodes the Draco-compressed primitives in the GLTF document using the decoder provided, pointing their
+// accessors at new, uncompressed buffers so they can be read as usual. If decoder is nil, compressed primitives that have
+// uncompressed fallback data are left as-is; otherwise, an error is returned.
+func decodeDracoPrimitives(doc *gltf.Document, decoder DracoDecoder) error {
+
+	for _, mesh := range doc.Meshes {
+
+		for _, prim := range mesh.Primitives {
+
+			extData, exists := prim.Extensions[gltfDracoExtension]
+
+			if !exists {
+				continue
+			}
+
+			if decoder == nil {
+				if pos, exists := prim.Attributes[gltf.POSITION]; exists && doc.Accessors[pos].BufferView != nil {
+					continue
+				}
+				return fmt.Errorf("mesh [%s] is compressed using %s; set GLTFLoadOptions.DracoDecoder to load it", mesh.Name, gltfDracoExtension)
+			}
+
+			// Unknown extensions are left as raw JSON by the GLTF decoder, which marshals back to itself
+			raw, err := json.Marshal(extData)
+			if err != nil {
+				return err
+			}
+
+			ext := gltfDracoPrimitive{}
+			if err := json.Unmarshal(raw, &ext); err != nil {
+				return err
+			}
+
+			if ext.BufferView < 0 || ext.BufferView >= len(doc.BufferViews) {
+				return fmt.Errorf("mesh [%s] has Draco-compressed data in a buffer view that doesn't exist: %d", mesh.Name, ext.BufferView)
+			}
+
+			compressed, err := modeler.ReadBufferView(doc, doc.BufferViews[ext.BufferView])
+			if err != nil {
+				return err
+			}
+
+			decoded, err := decoder(compressed, ext.Attributes)
+			if err != nil {
+				return fmt.Errorf("mesh [%s] failed to decode Draco-compressed data: %w", mesh.Name, err)
+			}
+
+			buffer := &gltf.Buffer{}
+			doc.Buffers = append(doc.Buffers, buffer)
+
+			// Each accessor keeps its type and count, but now points to a view of the decoded data in the new buffer
+			writeAccessor := func(accessor *gltf.Accessor, values []float64) {
+
+				for len(buffer.Data)%4 != 0 {
+					buffer.Data = append(buffer.Data, 0)
+				}
+
+				view := &gltf.BufferView{
+					Buffer:     len(doc.Buffers) - 1,
+					ByteOffset: len(buffer.Data),
+				}
+
+				for _, v := range values {
+					buffer.Data = encodeGLTFComponent(buffer.Data, accessor.ComponentType, accessor.Normalized, v)
+				}
+
+				view.ByteLength = len(buffer.Data) - view.ByteOffset
+				buffer.ByteLength = len(buffer.Data)
+
+				doc.BufferViews = append(doc.BufferViews, view)
+				accessor.BufferView = gltf.Index(len(doc.BufferViews) - 1)
+				accessor.ByteOffset = 0
+				accessor.Sparse = nil
+
+			}
+
+			for name, accessorIndex := range prim.Attributes {
+
+				if _, compressed := ext.Attributes[name]; !compressed {
+					continue
+				}
+
+				accessor := doc.Accessors[accessorIndex]
+				values := decoded.Attributes[name]
+				valueCount := accessor.Count * accessor.Type.Components()
+
+				if len(values) < valueCount {
+					return fmt.Errorf("mesh [%s] decoded %d values for its Draco-compressed attribute %s; %d were expected", mesh.Name, len(values), name, valueCount)
+				}
+
+				converted := make([]float64, valueCount)
+				for i := range converted {
+					converted[i] = float64(values[i])
+				}
+
+				writeAccessor(accessor, converted)
+
+			}
+
+			if prim.Indices != nil {
+
+				accessor := doc.Accessors[*prim.Indices]
+
+				if len(decoded.Indices) < accessor.Count {
+					return fmt.Errorf("mesh [%s] decoded %d indices from its Draco-compressed data; %d were expected", mesh.Name, len(decoded.Indices), accessor.Count)
+				}
+
+				converted := make([]float64, accessor.Count)
+				for i := range converted {
+					converted[i] = float64(decoded.Indices[i])
+				}
+
+				writeAccessor(accessor, converted)
+
+			}
+
+		}
+
+	}
+
+	return nil
+
+}
+
+// encodeGLTFComponent appends a single component value to the data in the GLTF component type given, scaling it if normalized.
+func encodeGLTFComponent(data []byte, componentType gltf.ComponentType, normalized bool, value float64) []byte {
+
+	scale := func(max float64) float64 {
+		if normalized {
+			return math.Round(value * max)
+		}
+		return value
+	}
+
+	switch componentType {
+	case gltf.ComponentByte:
+		return append(data, byte(int8(scale(math.MaxInt8))))
+	case gltf.ComponentUbyte:
+		return append(data, uint8(scale(math.MaxUint8)))
+	case gltf.ComponentShort:
+		return binary.LittleEndian.AppendUint16(data, uint16(int16(scale(math.MaxInt16))))
+	case gltf.ComponentUshort:
+		return binary.LittleEndian.AppendUint16(data, uint16(scale(math.MaxUint16)))
+	case gltf.ComponentUint:
+		return binary.LittleEndian.AppendUint32(data, uint32(value))
+	default:
+		return binary.LittleEndian.AppendUint32(data, math.Float32bits(float32(value)))
+	}
+
+}
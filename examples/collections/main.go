@@ -21,24 +21,20 @@ type Game struct {
 //go:embed assets/*.gltf
 var assets embed.FS
 
-func loadAsset(assetName string) *tetra3d.Library {
+// The libraries loaded so far, so that each one is only loaded once, even if multiple libraries link objects from it.
+var libraryCache = tetra3d.NewLibraryCache(loadAsset)
 
-	loadOptions := tetra3d.DefaultGLTFLoadOptions()
+// loadAsset loads the library exported from the given blend file. If a dependent library is found, it's loaded through the cache
+// given to loadAsset as well, so each dependent library's only loaded the first time it's needed.
+func loadAsset(blendPath string, cache *tetra3d.LibraryCache) (*tetra3d.Library, error) {
 
-	// If a dependent library is found, then try to load it using the loadAsset function as well; in truth, this should
-	// store the result in a map / dictionary and then return that if possible, rather than loading the asset again, for efficiency.
-	loadOptions.DependentLibraryResolver = func(blendPath string) *tetra3d.Library {
-		path := "assets/" + strings.Split(blendPath, ".blend")[0] + ".gltf"
-		return loadAsset(path)
-	}
+	loadOptions := tetra3d.DefaultGLTFLoadOptions()
+	loadOptions.DependentLibraryCache = cache
 
-	library, err := tetra3d.LoadGLTFFileSystem(assets, assetName, loadOptions)
+	path := "assets/" + strings.Split(blendPath, ".blend")[0] + ".gltf"
 
-	if err != nil {
-		panic(err)
-	}
+	return tetra3d.LoadGLTFFileSystem(assets, path, loadOptions)
 
-	return library
 }
 
 func NewGame() *Game {
@@ -51,7 +47,11 @@ func NewGame() *Game {
 
 func (g *Game) Init() {
 
-	library := loadAsset("assets/collections.gltf")
+	library, err := libraryCache.Load("collections.blend")
+
+	if err != nil {
+		panic(err)
+	}
 
 	g.Scene = library.ExportedScene.Clone()
 	g.Camera = examples.NewBasicFreeCam(g.Scene)
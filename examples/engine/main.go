@@ -82,6 +82,9 @@ func (g *Game) Init() {
 
 	}
 
+	// The Player is registered as a Prefab, so we can easily spawn more of them later.
+	library.RegisterPrefab("Player", library.Scenes[0].Root.Get("Player"))
+
 	g.Library = library
 
 	// We clone the scene, and that's it!
@@ -97,9 +100,9 @@ func (g *Game) Init() {
 func (g *Game) Update() error {
 
 	if inpututil.IsKeyJustPressed(ebiten.Key1) {
-		// Cloning a Node is as simple as getting it (from any Scene), Cloning it, and adding it under the hierarchy of your target Scene.
-		player := g.Library.Scenes[0].Root.Get("Player")
-		g.Scene.Root.AddChildren(player.Clone())
+		// Instantiating a Prefab clones its prototype Node and adds it under the hierarchy of your target Scene; you could also just
+		// get the Node (from any Scene), clone it, and add it yourself.
+		g.Library.PrefabByName("Player").Instantiate(g.Scene, tetra3d.PrefabOverrides{})
 	}
 
 	for i := len(g.GameObjects) - 1; i >= 0; i-- {
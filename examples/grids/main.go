@@ -144,9 +144,9 @@ Grids are networks of vertices,
 connected by their edges. Navigators can
 navigate from point to point on Grids.
 
-Currently, navigation is calculated using
-number of hops, rather than overall
-distance to navigate.`
+Navigation is calculated using the
+overall distance to travel, plus the
+cost of each connection.`
 		g.Camera.DrawDebugText(screen, txt, 0, 220, 1, colors.White())
 
 	}
@@ -0,0 +1,760 @@
+package tetra3d
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/ext/lightspunctual"
+	"github.com/qmuntal/gltf/modeler"
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// GLTFExportOptions represents options for exporting Libraries, Scenes, and Meshes to GLTF files.
+type GLTFExportOptions struct {
+	// Binary indicates if the file should be written as a binary .glb file (true), or as a .gltf JSON file with its buffers embedded
+	// as base64 data (false). Defaults to true.
+	Binary bool
+	// EmbedTextures indicates if the textures of exported Materials should be embedded in the file as PNG images. If false, textures are
+	// referenced using their Materials' TexturePath values instead. Note that if EmbedTextures is true, Materials without a Texture
+	// (i.e. Materials loaded without loading their external textures) are exported without one. As textures are read back from the GPU,
+	// they can only be embedded once the game has started running. Defaults to true.
+	EmbedTextures bool
+}
+
+// DefaultGLTFExportOptions creates an instance of GLTFExportOptions with some sensible defaults.
+func DefaultGLTFExportOptions() *GLTFExportOptions {
+	return &GLTFExportOptions{
+		Binary:        true,
+		EmbedTextures: true,
+	}
+}
+
+// ExportGLTF writes the Library's Scenes (including their node hierarchies and the Meshes, Materials, Cameras, and lights within them),
+// as well as any of the Library's Meshes and Materials not used in its Scenes, to the given writer as a GLTF file. This allows
+// procedurally generated or modified content to be saved and inspected in a 3D modeler (like Blender), or loaded back in later using
+// LoadGLTFData(). If options is nil, DefaultGLTFExportOptions() is used.
+// Nodes are exported with their local transforms and custom Properties (if they are simple values). Only point and directional lights
+// are exported as lights; other nodes that can't be represented in GLTF (like Paths, Grids, or other light types) are exported as empty nodes.
+// The Library's Animations are exported as well, though only their position, scale, rotation, and visibility tracks for channels that
+// match the name of an exported node are included. Note that armatures and vertex skinning aren't exported; skinned Meshes are exported
+// in their rest poses.
+func (library *Library) ExportGLTF(writer io.Writer, options *GLTFExportOptions) error {
+
+	exporter := newGLTFExporter(options)
+
+	for _, scene := range library.Scenes {
+
+		index, err := exporter.addScene(scene.Name, scene.Root.Children())
+		if err != nil {
+			return err
+		}
+
+		if scene == library.ExportedScene {
+			exporter.doc.Scene = gltf.Index(index)
+		}
+
+	}
+
+	meshNames := make([]string, 0, len(library.Meshes))
+	for name := range library.Meshes {
+		meshNames = append(meshNames, name)
+	}
+	sort.Strings(meshNames)
+
+	for _, name := range meshNames {
+		if _, err := exporter.addMesh(library.Meshes[name]); err != nil {
+			return err
+		}
+	}
+
+	materialNames := make([]string, 0, len(library.Materials))
+	for name := range library.Materials {
+		materialNames = append(materialNames, name)
+	}
+	sort.Strings(materialNames)
+
+	for _, name := range materialNames {
+		if _, err := exporter.addMaterial(library.Materials[name]); err != nil {
+			return err
+		}
+	}
+
+	animationNames := make([]string, 0, len(library.Animations))
+	for name := range library.Animations {
+		animationNames = append(animationNames, name)
+	}
+	sort.Strings(animationNames)
+
+	for _, name := range animationNames {
+		exporter.addAnimation(library.Animations[name])
+	}
+
+	return exporter.write(writer)
+
+}
+
+// ExportGLTF writes the Scene's node hierarchy (including the Meshes, Materials, Cameras, and lights within it) to the given writer as
+// a GLTF file. If options is nil, DefaultGLTFExportOptions() is used. See Library.ExportGLTF() for more information.
+func (scene *Scene) ExportGLTF(writer io.Writer, options *GLTFExportOptions) error {
+
+	exporter := newGLTFExporter(options)
+
+	if _, err := exporter.addScene(scene.Name, scene.Root.Children()); err != nil {
+		return err
+	}
+
+	return exporter.write(writer)
+
+}
+
+// ExportGLTF writes the Mesh (and the Materials it uses) to the given writer as a GLTF file containing a single scene, with a single
+// object using the Mesh. If options is nil, DefaultGLTFExportOptions() is used. See Library.ExportGLTF() for more information.
+func (mesh *Mesh) ExportGLTF(writer io.Writer, options *GLTFExportOptions) error {
+
+	exporter := newGLTFExporter(options)
+
+	index, err := exporter.addMesh(mesh)
+	if err != nil {
+		return err
+	}
+
+	exporter.doc.Nodes = append(exporter.doc.Nodes, &gltf.Node{Name: mesh.Name, Mesh: gltf.Index(index)})
+	exporter.doc.Scenes = append(exporter.doc.Scenes, &gltf.Scene{Name: mesh.Name, Nodes: []int{0}})
+	exporter.doc.Scene = gltf.Index(0)
+
+	return exporter.write(writer)
+
+}
+
+// gltfExporter builds a GLTF document out of Tetra3D objects, exporting each Mesh, Material, and texture only once.
+type gltfExporter struct {
+	doc        *gltf.Document
+	options    *GLTFExportOptions
+	meshes     map[*Mesh]int
+	materials  map[*Material]int
+	images     map[*ebiten.Image]int
+	imagePaths map[string]int
+	nodeNames  map[string]int // The index of the first node exported with each name, for targeting animations
+	sampler    *int           // The sampler for textures using nearest-neighbor filtering
+	lights     lightspunctual.Lights
+}
+
+func newGLTFExporter(options *GLTFExportOptions) *gltfExporter {
+
+	if options == nil {
+		options = DefaultGLTFExportOptions()
+	}
+
+	doc := gltf.NewDocument()
+	doc.Asset.Generator = "Tetra3D"
+	doc.Scenes = nil
+	doc.Scene = nil
+
+	return &gltfExporter{
+		doc:        doc,
+		options:    options,
+		meshes:     map[*Mesh]int{},
+		materials:  map[*Material]int{},
+		images:     map[*ebiten.Image]int{},
+		imagePaths: map[string]int{},
+		nodeNames:  map[string]int{},
+	}
+
+}
+
+// addScene adds a scene containing the given nodes (and their children) to the document, returning its index.
+func (e *gltfExporter) addScene(name string, nodes []INode) (int, error) {
+
+	gltfScene := &gltf.Scene{Name: name}
+
+	for _, node := range nodes {
+		index, err := e.addNode(node)
+		if err != nil {
+			return 0, err
+		}
+		gltfScene.Nodes = append(gltfScene.Nodes, index)
+	}
+
+	e.doc.Scenes = append(e.doc.Scenes, gltfScene)
+
+	if e.doc.Scene == nil {
+		e.doc.Scene = gltf.Index(0)
+	}
+
+	return len(e.doc.Scenes) - 1, nil
+
+}
+
+// addNode adds the node and its children to the document, returning the node's index. Children are added before their parents,
+// as LoadGLTFData() expects.
+func (e *gltfExporter) addNode(node INode) (int, error) {
+
+	gltfNode := &gltf.Node{Name: node.Name()}
+
+	for _, child := range node.Children() {
+		index, err := e.addNode(child)
+		if err != nil {
+			return 0, err
+		}
+		gltfNode.Children = append(gltfNode.Children, index)
+	}
+
+	pos := node.LocalPosition()
+	scale := node.LocalScale()
+	rot := node.LocalRotation().ToQuaternion()
+
+	gltfNode.Translation = [3]float64{float64(pos.X), float64(pos.Y), float64(pos.Z)}
+	gltfNode.Scale = [3]float64{float64(scale.X), float64(scale.Y), float64(scale.Z)}
+	gltfNode.Rotation = [4]float64{float64(rot.X), float64(rot.Y), float64(rot.Z), float64(rot.W)}
+
+	switch n := node.(type) {
+
+	case *Model:
+		if n.Mesh != nil {
+			index, err := e.addMesh(n.Mesh)
+			if err != nil {
+				return 0, err
+			}
+			gltfNode.Mesh = gltf.Index(index)
+		}
+
+	case *Camera:
+		gltfNode.Camera = gltf.Index(e.addCamera(n))
+
+	case *PointLight:
+		color := n.Color()
+		light := &lightspunctual.Light{
+			Type:      lightspunctual.TypePoint,
+			Name:      n.Name(),
+			Color:     &[3]float64{float64(color.R), float64(color.G), float64(color.B)},
+			Intensity: gltf.Float(float64(n.Energy() * 80)), // Point lights are loaded from wattage energy
+		}
+		if n.Range > 0 {
+			light.Range = gltf.Float(float64(n.Range))
+		}
+		gltfNode.Extensions = e.addLight(light)
+
+	case *DirectionalLight:
+		color := n.Color()
+		gltfNode.Extensions = e.addLight(&lightspunctual.Light{
+			Type:      lightspunctual.TypeDirectional,
+			Name:      n.Name(),
+			Color:     &[3]float64{float64(color.R), float64(color.G), float64(color.B)},
+			Intensity: gltf.Float(float64(n.Energy())),
+		})
+
+	}
+
+	extras := exportProperties(node.Properties())
+
+	if !node.Visible() {
+		extras["t3dVisible__"] = 0
+	}
+
+	if len(extras) > 0 {
+		gltfNode.Extras = extras
+	}
+
+	e.doc.Nodes = append(e.doc.Nodes, gltfNode)
+
+	index := len(e.doc.Nodes) - 1
+
+	if _, exists := e.nodeNames[node.Name()]; !exists {
+		e.nodeNames[node.Name()] = index
+	}
+
+	return index, nil
+
+}
+
+// addCamera adds the Camera's projection settings to the document, returning the index of the GLTF camera.
+func (e *gltfExporter) addCamera(camera *Camera) int {
+
+	gltfCamera := &gltf.Camera{Name: camera.Name()}
+
+	if camera.Perspective() {
+		gltfCamera.Perspective = &gltf.Perspective{
+			AspectRatio: gltf.Float(float64(camera.AspectRatio())),
+			Yfov:        float64(math32.ToRadians(camera.FieldOfView())),
+			Znear:       float64(camera.Near()),
+			Zfar:        gltf.Float(float64(camera.Far())),
+		}
+	} else {
+		xmag := float64(camera.OrthoScale() / 2)
+		gltfCamera.Orthographic = &gltf.Orthographic{
+			Xmag:  xmag,
+			Ymag:  xmag / float64(camera.AspectRatio()),
+			Znear: float64(camera.Near()),
+			Zfar:  float64(camera.Far()),
+		}
+	}
+
+	e.doc.Cameras = append(e.doc.Cameras, gltfCamera)
+
+	return len(e.doc.Cameras) - 1
+
+}
+
+// addLight adds the light to the document, returning the extensions for a node to use it.
+func (e *gltfExporter) addLight(light *lightspunctual.Light) gltf.Extensions {
+
+	if len(e.lights) == 0 {
+		e.doc.ExtensionsUsed = append(e.doc.ExtensionsUsed, lightspunctual.ExtensionName)
+		e.doc.Extensions = gltf.Extensions{}
+	}
+
+	e.lights = append(e.lights, light)
+	e.doc.Extensions[lightspunctual.ExtensionName] = map[string]any{"lights": e.lights}
+
+	return gltf.Extensions{lightspunctual.ExtensionName: map[string]any{"light": len(e.lights) - 1}}
+
+}
+
+// addMesh adds the Mesh (and the Materials used by its MeshParts) to the document, returning its index. Each MeshPart is exported
+// as a separate primitive.
+func (e *gltfExporter) addMesh(mesh *Mesh) (int, error) {
+
+	if index, exists := e.meshes[mesh]; exists {
+		return index, nil
+	}
+
+	gltfMesh := &gltf.Mesh{Name: mesh.Name}
+
+	extras := exportProperties(mesh.Properties())
+
+	if mesh.Unique != MeshUniqueFalse {
+		extras["t3dUniqueMesh__"] = 1
+		if mesh.Unique == MeshUniqueMeshAndMaterials {
+			extras["t3dUniqueMaterials__"] = 1
+		}
+	}
+
+	channelCount := len(mesh.VertexColors)
+
+	if channelCount > 0 {
+
+		channelNames := make([]string, channelCount)
+		for name, index := range mesh.VertexColorChannelNames {
+			if index < channelCount {
+				channelNames[index] = name
+			}
+		}
+
+		for index, name := range channelNames {
+			if name == "" {
+				channelNames[index] = "Color" + strconv.Itoa(index)
+			}
+		}
+
+		extras["t3dVertexColorNames__"] = channelNames
+		extras["t3dActiveVertexColorIndex__"] = mesh.VertexActiveColorChannel
+
+	}
+
+	if len(extras) > 0 {
+		gltfMesh.Extras = extras
+	}
+
+	for p, data := range mesh.partData() {
+
+		if len(data.indices) == 0 {
+			continue
+		}
+
+		positions := make([][3]float32, len(data.verts))
+		normals := make([][3]float32, len(data.verts))
+		uvs := make([][2]float32, len(data.verts))
+		colors := make([][][4]uint16, channelCount)
+
+		for c := range colors {
+			colors[c] = make([][4]uint16, len(data.verts))
+		}
+
+		for i, v := range data.verts {
+
+			positions[i] = [3]float32{v.X, v.Y, v.Z}
+			normals[i] = [3]float32{v.NormalX, v.NormalY, v.NormalZ}
+			uvs[i] = [2]float32{v.U, 1 - v.V} // UV values are flipped vertically when loading
+
+			for c := range colors {
+				if c < len(v.Colors) {
+					// Vertex colors are converted to sRGB when loading; they're written as integers, as floating-point colors are converted when read
+					color := v.Colors[c].ConvertToLinear().ToNRGBA64()
+					colors[c][i] = [4]uint16{color.R, color.G, color.B, color.A}
+				}
+			}
+
+		}
+
+		attributes := []modeler.PrimitiveAttribute{
+			{Name: gltf.POSITION, Data: positions},
+			{Name: gltf.NORMAL, Data: normals},
+			{Name: gltf.TEXCOORD_0, Data: uvs},
+		}
+
+		for c := range colors {
+			attributes = append(attributes, modeler.PrimitiveAttribute{Name: "COLOR_" + strconv.Itoa(c), Data: colors[c]})
+		}
+
+		attributeMap, err := modeler.WritePrimitiveAttributes(e.doc, attributes...)
+		if err != nil {
+			return 0, err
+		}
+
+		for c := range colors {
+			e.doc.Accessors[attributeMap["COLOR_"+strconv.Itoa(c)]].Normalized = true
+		}
+
+		// Indices are written as 16-bit integers where they fit, and as 32-bit integers for larger MeshParts (i.e. merged levels)
+		var indices any
+		if len(data.verts) > math.MaxUint16 {
+			indices32 := make([]uint32, len(data.indices))
+			for i, index := range data.indices {
+				indices32[i] = uint32(index)
+			}
+			indices = indices32
+		} else {
+			indices16 := make([]uint16, len(data.indices))
+			for i, index := range data.indices {
+				indices16[i] = uint16(index)
+			}
+			indices = indices16
+		}
+
+		primitive := &gltf.Primitive{
+			Attributes: attributeMap,
+			Indices:    gltf.Index(modeler.WriteIndices(e.doc, indices)),
+		}
+
+		if mat := mesh.MeshParts[p].Material; mat != nil {
+			index, err := e.addMaterial(mat)
+			if err != nil {
+				return 0, err
+			}
+			primitive.Material = gltf.Index(index)
+		}
+
+		gltfMesh.Primitives = append(gltfMesh.Primitives, primitive)
+
+	}
+
+	e.doc.Meshes = append(e.doc.Meshes, gltfMesh)
+	e.meshes[mesh] = len(e.doc.Meshes) - 1
+
+	return e.meshes[mesh], nil
+
+}
+
+// addMaterial adds the Material (and its texture) to the document, returning its index.
+func (e *gltfExporter) addMaterial(mat *Material) (int, error) {
+
+	if index, exists := e.materials[mat]; exists {
+		return index, nil
+	}
+
+	color := [4]float64{float64(mat.Color.R), float64(mat.Color.G), float64(mat.Color.B), float64(mat.Color.A)}
+
+	gltfMat := &gltf.Material{
+		Name:        mat.Name,
+		DoubleSided: !mat.BackfaceCulling,
+		PBRMetallicRoughness: &gltf.PBRMetallicRoughness{
+			BaseColorFactor: &color,
+			MetallicFactor:  gltf.Float(0),
+			RoughnessFactor: gltf.Float(1),
+		},
+	}
+
+	switch mat.TransparencyMode {
+	case TransparencyModeOpaque:
+		gltfMat.AlphaMode = gltf.AlphaOpaque
+	case TransparencyModeAlphaClip:
+		gltfMat.AlphaMode = gltf.AlphaMask
+	case TransparencyModeTransparent:
+		gltfMat.AlphaMode = gltf.AlphaBlend
+	default:
+		if mat.Color.A < 1 {
+			gltfMat.AlphaMode = gltf.AlphaBlend
+		}
+	}
+
+	texture, err := e.addTexture(mat)
+	if err != nil {
+		return 0, err
+	}
+
+	if texture >= 0 {
+		gltfMat.PBRMetallicRoughness.BaseColorTexture = &gltf.TextureInfo{Index: texture}
+	}
+
+	extras := exportProperties(mat.Properties())
+
+	extras["t3dMaterialColor__"] = color
+	extras["t3dMaterialShadeless__"] = exportBool(mat.Shadeless)
+	extras["t3dMaterialFogless__"] = exportBool(mat.Fogless)
+	extras["t3dBillboardMode__"] = mat.BillboardMode
+	extras["t3dCustomDepthOn__"] = exportBool(mat.CustomDepthOffsetOn)
+	extras["t3dCustomDepthValue__"] = mat.CustomDepthOffsetValue
+	extras["t3dSoftParticleDistance__"] = mat.SoftParticleDistance
+	extras["t3dPerPixelLighting__"] = exportBool(mat.PerPixelLighting)
+	extras["t3dMaterialLightingMode__"] = mat.LightingMode
+	extras["t3dTransparencyMode__"] = mat.TransparencyMode
+	extras["t3dDepthWrite__"] = mat.DepthWrite
+	extras["t3dDepthTest__"] = mat.DepthTest
+	extras["t3dVisible__"] = exportBool(mat.Visible)
+
+	switch mat.Blend {
+	case ebiten.BlendSourceOver:
+		extras["t3dBlendMode__"] = 0
+	case ebiten.BlendLighter:
+		extras["t3dBlendMode__"] = 1
+	case ebiten.BlendDestinationOut:
+		extras["t3dBlendMode__"] = 3
+	}
+
+	gltfMat.Extras = extras
+
+	e.doc.Materials = append(e.doc.Materials, gltfMat)
+	e.materials[mat] = len(e.doc.Materials) - 1
+
+	return e.materials[mat], nil
+
+}
+
+// addTexture adds the Material's texture to the document (either as an embedded PNG image or as a reference to its TexturePath),
+// returning the index of the GLTF texture, or -1 if the Material has no texture to export.
+func (e *gltfExporter) addTexture(mat *Material) (int, error) {
+
+	imageIndex := -1
+
+	if e.options.EmbedTextures && mat.Texture != nil {
+
+		if index, exists := e.images[mat.Texture]; exists {
+			imageIndex = index
+		} else {
+
+			size := mat.Texture.Bounds().Size()
+			img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+			mat.Texture.ReadPixels(img.Pix)
+
+			buffer := &bytes.Buffer{}
+
+			if err := png.Encode(buffer, img); err != nil {
+				return -1, err
+			}
+
+			index, err := modeler.WriteImage(e.doc, mat.Name, "image/png", buffer)
+			if err != nil {
+				return -1, err
+			}
+
+			e.images[mat.Texture] = index
+			imageIndex = index
+
+		}
+
+	} else if !e.options.EmbedTextures && mat.TexturePath != "" {
+
+		if index, exists := e.imagePaths[mat.TexturePath]; exists {
+			imageIndex = index
+		} else {
+			e.doc.Images = append(e.doc.Images, &gltf.Image{URI: mat.TexturePath})
+			imageIndex = len(e.doc.Images) - 1
+			e.imagePaths[mat.TexturePath] = imageIndex
+		}
+
+	}
+
+	if imageIndex < 0 {
+		return -1, nil
+	}
+
+	texture := &gltf.Texture{Source: gltf.Index(imageIndex)}
+
+	if mat.TextureFilterMode == ebiten.FilterNearest {
+
+		if e.sampler == nil {
+			e.doc.Samplers = append(e.doc.Samplers, &gltf.Sampler{MagFilter: gltf.MagNearest, MinFilter: gltf.MinNearest})
+			e.sampler = gltf.Index(len(e.doc.Samplers) - 1)
+		}
+
+		texture.Sampler = e.sampler
+
+	}
+
+	e.doc.Textures = append(e.doc.Textures, texture)
+
+	return len(e.doc.Textures) - 1, nil
+
+}
+
+// addAnimation adds the transform tracks of the Animation's channels that target exported nodes (by name) to the document. Visibility
+// tracks are exported as node extras, as LoadGLTFData() expects.
+func (e *gltfExporter) addAnimation(animation *Animation) {
+
+	gltfAnim := &gltf.Animation{Name: animation.Name}
+
+	channelNames := make([]string, 0, len(animation.Channels))
+	for name := range animation.Channels {
+		channelNames = append(channelNames, name)
+	}
+	sort.Strings(channelNames)
+
+	paths := []struct {
+		trackType string
+		path      gltf.TRSProperty
+	}{
+		{TrackTypePosition, gltf.TRSTranslation},
+		{TrackTypeScale, gltf.TRSScale},
+		{TrackTypeRotation, gltf.TRSRotation},
+	}
+
+	for _, name := range channelNames {
+
+		nodeIndex, exists := e.nodeNames[name]
+		if !exists {
+			continue
+		}
+
+		channel := animation.Channels[name]
+
+		for _, p := range paths {
+
+			track := channel.Tracks[p.trackType]
+			if track == nil || len(track.Keyframes) == 0 {
+				continue
+			}
+
+			times := make([]float32, len(track.Keyframes))
+			for i, key := range track.Keyframes {
+				times[i] = key.Time
+			}
+
+			var output any
+
+			if p.trackType == TrackTypeRotation {
+				values := make([][4]float32, len(track.Keyframes))
+				for i, key := range track.Keyframes {
+					q := key.Data.AsQuaternion()
+					values[i] = [4]float32{q.X, q.Y, q.Z, q.W}
+				}
+				output = values
+			} else {
+				values := make([][3]float32, len(track.Keyframes))
+				for i, key := range track.Keyframes {
+					v := key.Data.AsVector()
+					values[i] = [3]float32{v.X, v.Y, v.Z}
+				}
+				output = values
+			}
+
+			input := modeler.WriteAccessor(e.doc, gltf.TargetNone, times)
+			e.doc.Accessors[input].Min = []float64{float64(times[0])}
+			e.doc.Accessors[input].Max = []float64{float64(times[len(times)-1])}
+
+			// Cubic interpolation would need tangents for each keyframe, so it's exported as linear
+			interpolation := gltf.InterpolationLinear
+			if track.Interpolation == InterpolationConstant {
+				interpolation = gltf.InterpolationStep
+			}
+
+			gltfAnim.Samplers = append(gltfAnim.Samplers, &gltf.AnimationSampler{
+				Input:         input,
+				Output:        modeler.WriteAccessor(e.doc, gltf.TargetNone, output),
+				Interpolation: interpolation,
+			})
+
+			gltfAnim.Channels = append(gltfAnim.Channels, &gltf.AnimationChannel{
+				Sampler: len(gltfAnim.Samplers) - 1,
+				Target:  gltf.AnimationChannelTarget{Node: gltf.Index(nodeIndex), Path: p.path},
+			})
+
+		}
+
+		if track := channel.Tracks[TrackTypeVisible]; track != nil && len(track.Keyframes) > 0 {
+
+			keys := make([][2]float32, len(track.Keyframes))
+			for i, key := range track.Keyframes {
+				keys[i] = [2]float32{key.Time, float32(exportBool(key.Data.AsBool()))}
+			}
+
+			gltfNode := e.doc.Nodes[nodeIndex]
+
+			extras, ok := gltfNode.Extras.(map[string]any)
+			if !ok {
+				extras = map[string]any{}
+				gltfNode.Extras = extras
+			}
+
+			visibilityKeys, ok := extras["t3dVisibilityKeys__"].(map[string]any)
+			if !ok {
+				visibilityKeys = map[string]any{}
+				extras["t3dVisibilityKeys__"] = visibilityKeys
+			}
+
+			visibilityKeys[animation.Name] = keys
+
+		}
+
+	}
+
+	if len(gltfAnim.Channels) > 0 {
+		e.doc.Animations = append(e.doc.Animations, gltfAnim)
+	}
+
+}
+
+// write encodes the document to the writer.
+func (e *gltfExporter) write(writer io.Writer) error {
+
+	// Whether textures were embedded determines how LoadGLTFData() treats the document's images
+	if len(e.doc.Scenes) > 0 {
+		e.doc.Scenes[0].Extras = map[string]any{"t3dPackTextures__": e.options.EmbedTextures}
+	}
+
+	if !e.options.Binary && len(e.doc.Buffers) > 0 {
+		e.doc.Buffers[0].EmbeddedResource()
+	}
+
+	encoder := gltf.NewEncoder(writer)
+	encoder.AsBinary = e.options.Binary
+
+	return encoder.Encode(e.doc)
+
+}
+
+// exportProperties returns the Properties with simple values (booleans, numbers, strings, Colors, and Vector3s) as a map to be exported as GLTF extras.
+func exportProperties(props Properties) map[string]any {
+
+	extras := map[string]any{}
+
+	for name, prop := range props {
+
+		switch value := prop.Value.(type) {
+		case bool, int, float32, float64, string:
+			extras[name] = value
+		case Color:
+			extras[name] = [4]float32{value.R, value.G, value.B, value.A}
+		case Vector3:
+			extras[name] = [3]float32{value.X, value.Y, value.Z}
+		}
+
+	}
+
+	return extras
+
+}
+
+// exportBool returns the boolean as a number, as the Tetra3D Blender add-on exports boolean settings.
+func exportBool(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
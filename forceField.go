@@ -0,0 +1,155 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// ForceFieldMode indicates how a ForceField pushes the particles within its range.
+type ForceFieldMode int
+
+const (
+	ForceFieldModeAttractor ForceFieldMode = iota // Particles are pulled towards the ForceField (or pushed away, if its Strength is negative).
+	ForceFieldModeVortex                          // Particles are swirled around the ForceField's local Y axis.
+	ForceFieldModeWind                            // Particles are blown along the ForceField's local -Z axis (the direction it faces, like a Camera).
+)
+
+// ForceField is a Node that pushes the particles of ParticleSystems within its range around, acting as an attractor (or repulsor),
+// a vortex, or wind, depending on its Mode. This allows particle movement to be built out of reusable pieces placed in the scene,
+// rather than encoded entirely in MovementFunction closures. A ForceField affects a ParticleSystem once it's been added to the
+// system's settings' ForceFields slice (i.e. through ParticleSystem.AddForceFields()); it can be added to as many systems as desired.
+type ForceField struct {
+	*Node
+	On       bool           // Whether the ForceField affects particles or not. Defaults to true.
+	Mode     ForceFieldMode // How the ForceField pushes particles. Defaults to ForceFieldModeAttractor.
+	Strength float32        // How strongly the ForceField pushes particles per frame (like ParticleSystemSettings.VelocityAdd). Defaults to 0.01.
+
+	// Radius is how far from the ForceField particles have to be to be affected by it. 0 or less means particles are affected
+	// regardless of distance. Defaults to 0.
+	Radius float32
+
+	// Falloff is how quickly the ForceField's strength weakens towards the edge of its Radius; 0 means there's no falloff, 1 means
+	// the strength fades linearly, 2 means it fades quadratically, and so on. Defaults to 1. Ignored if the Radius is 0.
+	Falloff float32
+
+	// Turbulence is how strongly the ForceField randomly jostles particles (in addition to its Strength) as they move through it.
+	// Defaults to 0.
+	Turbulence float32
+	// TurbulenceScale is the size of the swirls in the ForceField's turbulence in world units; larger values make for broader,
+	// smoother gusts. Defaults to 1.
+	TurbulenceScale float32
+	// TurbulenceSpeed is how quickly the ForceField's turbulence changes over time. Defaults to 1.
+	TurbulenceSpeed float32
+}
+
+// NewForceField creates a new ForceField with the given name and mode.
+func NewForceField(name string, mode ForceFieldMode) *ForceField {
+	field := &ForceField{
+		Node:            NewNode(name),
+		On:              true,
+		Mode:            mode,
+		Strength:        0.01,
+		Falloff:         1,
+		TurbulenceScale: 1,
+		TurbulenceSpeed: 1,
+	}
+	field.owner = field
+	return field
+}
+
+// Clone creates a clone of the ForceField and its children.
+func (field *ForceField) Clone() INode {
+
+	clone := NewForceField(field.name, field.Mode)
+	clone.On = field.On
+	clone.Strength = field.Strength
+	clone.Radius = field.Radius
+	clone.Falloff = field.Falloff
+	clone.Turbulence = field.Turbulence
+	clone.TurbulenceScale = field.TurbulenceScale
+	clone.TurbulenceSpeed = field.TurbulenceSpeed
+
+	clone.Node = field.Node.clone(clone).(*Node)
+
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+
+	return clone
+
+}
+
+// ForceAt returns the force (the change in velocity per frame) the ForceField applies to a particle at the given world position.
+// time is used to animate the ForceField's turbulence, and is usually how long the particle has been alive, in seconds.
+func (field *ForceField) ForceAt(position Vector3, time float32) Vector3 {
+
+	if !field.On {
+		return Vector3{}
+	}
+
+	center := field.WorldPosition()
+	diff := position.Sub(center)
+	dist := diff.Magnitude()
+
+	strength := float32(1)
+
+	if field.Radius > 0 {
+
+		if dist > field.Radius {
+			return Vector3{}
+		}
+
+		if field.Falloff > 0 {
+			strength = math32.Pow(1-dist/field.Radius, field.Falloff)
+		}
+
+	}
+
+	var force Vector3
+
+	switch field.Mode {
+
+	case ForceFieldModeAttractor:
+		if dist > 0 {
+			force = diff.Scale(-1 / dist)
+		}
+
+	case ForceFieldModeVortex:
+		force = field.WorldRotation().Up().Cross(diff).Unit()
+
+	case ForceFieldModeWind:
+		force = field.WorldRotation().Forward().Invert()
+
+	}
+
+	force = force.Scale(field.Strength)
+
+	if field.Turbulence != 0 {
+		force = force.Add(field.turbulence(position, time).Scale(field.Turbulence))
+	}
+
+	return force.Scale(strength)
+
+}
+
+// turbulence returns a smoothly varying pseudo-random vector (with each component ranging from -1 to 1) for the given position and time.
+func (field *ForceField) turbulence(position Vector3, time float32) Vector3 {
+
+	scale := field.TurbulenceScale
+	if scale <= 0 {
+		scale = 1
+	}
+
+	p := position.Divide(scale)
+	t := time * field.TurbulenceSpeed
+
+	// Layered sine waves at unrelated frequencies are cheap, and look random enough for jostling particles around
+	return Vector3{
+		(math32.Sin(p.Y*1.7+t*1.3) + math32.Sin(p.Z*2.3-t*0.7+1.1)) / 2,
+		(math32.Sin(p.Z*1.9+t*1.1+2.3) + math32.Sin(p.X*2.1-t*0.9)) / 2,
+		(math32.Sin(p.X*1.3+t*1.7+4.1) + math32.Sin(p.Y*2.7-t*1.2+0.5)) / 2,
+	}
+
+}
+
+// Type returns the NodeType for this object.
+func (field *ForceField) Type() NodeType {
+	return NodeTypeForceField
+}
@@ -0,0 +1,649 @@
+package tetra3d
+
+import "math"
+
+// convexShape is a convex shape in world space that can be tested for intersection against other convexShapes using GJK and EPA.
+type convexShape interface {
+	// support returns the point of the shape farthest along the given direction.
+	support(dir Vector3) Vector3
+	// centroid returns a point inside of the shape.
+	centroid() Vector3
+}
+
+// shapeDimensions returns the world-space Dimensions enclosing the given convex shape.
+func shapeDimensions(shape convexShape) Dimensions {
+	return Dimensions{
+		Vector3{shape.support(WorldLeft).X, shape.support(WorldDown).Y, shape.support(WorldForward).Z},
+		Vector3{shape.support(WorldRight).X, shape.support(WorldUp).Y, shape.support(WorldBackward).Z},
+	}
+}
+
+func (box orientedBox) centroid() Vector3 {
+	return box.center
+}
+
+// shape returns the BoundingSphere as a convexShape in world space.
+func (sphere *BoundingSphere) shape() sphereShape {
+	return sphereShape{sphere.WorldPosition(), sphere.WorldRadius()}
+}
+
+// shape returns the BoundingCapsule as a convexShape in world space.
+func (capsule *BoundingCapsule) shape() capsuleShape {
+	return capsuleShape{capsule.lineBottom(), capsule.lineTop(), capsule.WorldRadius()}
+}
+
+// sphereShape is a BoundingSphere as a convexShape.
+type sphereShape struct {
+	position Vector3
+	radius   float32
+}
+
+func (sphere sphereShape) support(dir Vector3) Vector3 {
+	return sphere.position.Add(dir.Unit().Scale(sphere.radius))
+}
+
+func (sphere sphereShape) centroid() Vector3 {
+	return sphere.position
+}
+
+// capsuleShape is a BoundingCapsule as a convexShape; start and end are the ends of the capsule's central line.
+type capsuleShape struct {
+	start, end Vector3
+	radius     float32
+}
+
+func (capsule capsuleShape) support(dir Vector3) Vector3 {
+	point := capsule.start
+	if capsule.end.Dot(dir) > capsule.start.Dot(dir) {
+		point = capsule.end
+	}
+	return point.Add(dir.Unit().Scale(capsule.radius))
+}
+
+func (capsule capsuleShape) centroid() Vector3 {
+	return capsule.start.Add(capsule.end).Scale(0.5)
+}
+
+// pointsShape is the convex hull of a set of points (i.e. the vertices of a BoundingConvexHull, or of a triangle) as a convexShape.
+type pointsShape []Vector3
+
+func (points pointsShape) support(dir Vector3) Vector3 {
+	best := points[0]
+	bestDot := best.Dot(dir)
+	for _, p := range points[1:] {
+		if d := p.Dot(dir); d > bestDot {
+			best = p
+			bestDot = d
+		}
+	}
+	return best
+}
+
+func (points pointsShape) centroid() Vector3 {
+	c := Vector3{}
+	for _, p := range points {
+		c = c.Add(p)
+	}
+	return c.Divide(float32(len(points)))
+}
+
+// offsetShape is a convexShape moved by an offset (i.e. a shape at a previous position).
+type offsetShape struct {
+	shape  convexShape
+	offset Vector3
+}
+
+func (o offsetShape) support(dir Vector3) Vector3 {
+	return o.shape.support(dir).Add(o.offset)
+}
+
+func (o offsetShape) centroid() Vector3 {
+	return o.shape.centroid().Add(o.offset)
+}
+
+// minkowskiSupport returns the point of the Minkowski difference of shapes a and b (a - b) farthest along the given direction.
+func minkowskiSupport(a, b convexShape, dir Vector3) Vector3 {
+	return a.support(dir).Sub(b.support(dir.Invert()))
+}
+
+const (
+	gjkMaxIterations = 64
+	epaMaxIterations = 64
+	epaTolerance     = 0.0001
+)
+
+// gjk returns if the given convex shapes intersect using the Gilbert-Johnson-Keerthi algorithm, along with the tetrahedron of the Minkowski
+// difference of the shapes enclosing the origin if so, which can be passed to epa() to get the shapes' penetration.
+func gjk(a, b convexShape) ([]Vector3, bool) {
+
+	dir := b.centroid().Sub(a.centroid())
+	if dir.MagnitudeSquared() < 1e-12 {
+		dir = WorldRight
+	}
+
+	// The simplex's newest point is always first
+	simplex := make([]Vector3, 0, 4)
+	simplex = append(simplex, minkowskiSupport(a, b, dir))
+	dir = simplex[0].Invert()
+
+	for i := 0; i < gjkMaxIterations; i++ {
+
+		if dir.MagnitudeSquared() < 1e-12 {
+			// The origin lies exactly on the simplex, so the shapes are only touching
+			return nil, false
+		}
+
+		point := minkowskiSupport(a, b, dir)
+
+		if point.Dot(dir) <= 0 {
+			return nil, false
+		}
+
+		simplex = append(simplex, Vector3{})
+		copy(simplex[1:], simplex)
+		simplex[0] = point
+
+		var contains bool
+		simplex, dir, contains = gjkNextSimplex(simplex)
+
+		if contains {
+			return simplex, true
+		}
+
+	}
+
+	return nil, false
+
+}
+
+// gjkNextSimplex reduces the given simplex to the part of it closest to the origin, returning it along with the next direction to search in,
+// and if the simplex encloses the origin.
+func gjkNextSimplex(simplex []Vector3) ([]Vector3, Vector3, bool) {
+
+	switch len(simplex) {
+
+	case 2:
+		return gjkLine(simplex)
+
+	case 3:
+		return gjkTriangle(simplex)
+
+	default:
+
+		a, b, c, d := simplex[0], simplex[1], simplex[2], simplex[3]
+		ab := b.Sub(a)
+		ac := c.Sub(a)
+		ad := d.Sub(a)
+		ao := a.Invert()
+
+		if abc := ab.Cross(ac); abc.Dot(ao) > 0 {
+			return gjkTriangle(append(simplex[:0], a, b, c))
+		}
+
+		if acd := ac.Cross(ad); acd.Dot(ao) > 0 {
+			return gjkTriangle(append(simplex[:0], a, c, d))
+		}
+
+		if adb := ad.Cross(ab); adb.Dot(ao) > 0 {
+			return gjkTriangle(append(simplex[:0], a, d, b))
+		}
+
+		return simplex, Vector3{}, true
+
+	}
+
+}
+
+func gjkLine(simplex []Vector3) ([]Vector3, Vector3, bool) {
+
+	a, b := simplex[0], simplex[1]
+	ab := b.Sub(a)
+	ao := a.Invert()
+
+	if ab.Dot(ao) > 0 {
+		dir := ab.Cross(ao).Cross(ab)
+		// The origin lies on the line (i.e. for shapes overlapping along an axis), so the search continues perpendicular to it
+		if dir.MagnitudeSquared() < 1e-12 {
+			if dir = ab.Cross(WorldUp); dir.MagnitudeSquared() < 1e-12 {
+				dir = ab.Cross(WorldRight)
+			}
+		}
+		return simplex, dir, false
+	}
+
+	return append(simplex[:0], a), ao, false
+
+}
+
+func gjkTriangle(simplex []Vector3) ([]Vector3, Vector3, bool) {
+
+	a, b, c := simplex[0], simplex[1], simplex[2]
+	ab := b.Sub(a)
+	ac := c.Sub(a)
+	ao := a.Invert()
+	abc := ab.Cross(ac)
+
+	if abc.Cross(ac).Dot(ao) > 0 {
+
+		if ac.Dot(ao) > 0 {
+			return append(simplex[:0], a, c), ac.Cross(ao).Cross(ac), false
+		}
+
+		return gjkLine(append(simplex[:0], a, b))
+
+	}
+
+	if ab.Cross(abc).Dot(ao) > 0 {
+		return gjkLine(append(simplex[:0], a, b))
+	}
+
+	if abc.Dot(ao) > 0 {
+		return simplex, abc, false
+	}
+
+	// The origin is below the triangle, so it's wound the other way to face it
+	return append(simplex[:0], a, c, b), abc.Invert(), false
+
+}
+
+// epa returns the direction and depth of the penetration of the given intersecting convex shapes using the Expanding Polytope Algorithm,
+// starting from the tetrahedron returned by gjk(). The direction points from shape a towards shape b, so a is separated from b by moving
+// it by the inverse of the direction, scaled by the depth.
+func epa(a, b convexShape, simplex []Vector3) (Vector3, float32, bool) {
+
+	polytope := append([]Vector3{}, simplex...)
+
+	faces := []int{
+		0, 1, 2,
+		0, 3, 1,
+		0, 2, 3,
+		1, 3, 2,
+	}
+
+	normals, distances, minFace := epaFaceNormals(polytope, faces)
+
+	if minFace < 0 {
+		return Vector3{}, 0, false
+	}
+
+	for i := 0; i < epaMaxIterations; i++ {
+
+		normal := normals[minFace]
+		distance := distances[minFace]
+
+		point := minkowskiSupport(a, b, normal)
+
+		// The polytope can't be expanded further in the direction of the closest face, so it's the edge of the Minkowski difference
+		if point.Dot(normal)-distance < epaTolerance {
+			break
+		}
+
+		// Faces that can see the new point are removed, and the hole left behind is patched with faces connecting its edges to the point
+		edges := [][2]int{}
+
+		for f := 0; f < len(normals); f++ {
+
+			if normals[f].Dot(point.Sub(polytope[faces[f*3]])) > 0 {
+
+				for e := 0; e < 3; e++ {
+					edges = epaAddUniqueEdge(edges, faces[f*3+e], faces[f*3+(e+1)%3])
+				}
+
+				last := len(normals) - 1
+				copy(faces[f*3:f*3+3], faces[last*3:last*3+3])
+				faces = faces[:last*3]
+				normals[f] = normals[last]
+				normals = normals[:last]
+				distances[f] = distances[last]
+				distances = distances[:last]
+				f--
+
+			}
+
+		}
+
+		if len(edges) == 0 {
+			break
+		}
+
+		polytope = append(polytope, point)
+		newIndex := len(polytope) - 1
+
+		newFaces := make([]int, 0, len(edges)*3)
+		for _, edge := range edges {
+			newFaces = append(newFaces, edge[0], edge[1], newIndex)
+		}
+
+		newNormals, newDistances, _ := epaFaceNormals(polytope, newFaces)
+
+		faces = append(faces, newFaces...)
+		normals = append(normals, newNormals...)
+		distances = append(distances, newDistances...)
+
+		minFace = -1
+		for f := range distances {
+			if !normals[f].IsZero() && (minFace < 0 || distances[f] < distances[minFace]) {
+				minFace = f
+			}
+		}
+
+		if minFace < 0 {
+			return Vector3{}, 0, false
+		}
+
+	}
+
+	return normals[minFace], distances[minFace], true
+
+}
+
+// epaFaceNormals returns the outward-facing normals of the given faces of the polytope, their distances from the origin, and the index of
+// the face closest to the origin (or -1 if there are no valid faces). Faces wound inwards are rewound in place.
+func epaFaceNormals(polytope []Vector3, faces []int) ([]Vector3, []float32, int) {
+
+	normals := make([]Vector3, 0, len(faces)/3)
+	distances := make([]float32, 0, len(faces)/3)
+	minFace := -1
+	minDistance := float32(math.MaxFloat32)
+
+	for i := 0; i < len(faces); i += 3 {
+
+		a := polytope[faces[i]]
+		b := polytope[faces[i+1]]
+		c := polytope[faces[i+2]]
+
+		normal := b.Sub(a).Cross(c.Sub(a)).Unit()
+		distance := normal.Dot(a)
+
+		// Faces are rewound to face away from the origin, so that their edges can be matched up when expanding the polytope
+		if distance < 0 {
+			normal = normal.Invert()
+			distance = -distance
+			faces[i+1], faces[i+2] = faces[i+2], faces[i+1]
+		}
+
+		normals = append(normals, normal)
+		distances = append(distances, distance)
+
+		// Degenerate faces have no normal, and so can't be the closest face
+		if !normal.IsZero() && distance < minDistance {
+			minDistance = distance
+			minFace = len(normals) - 1
+		}
+
+	}
+
+	return normals, distances, minFace
+
+}
+
+// epaAddUniqueEdge adds the edge from a to b to the list of edges, unless its reverse is already in the list (meaning it's shared
+// by two removed faces), in which case the reverse is removed instead.
+func epaAddUniqueEdge(edges [][2]int, a, b int) [][2]int {
+	for i, e := range edges {
+		if e[0] == b && e[1] == a {
+			return append(edges[:i], edges[i+1:]...)
+		}
+	}
+	return append(edges, [2]int{a, b})
+}
+
+// btConvexShapes tests the given convex shapes for intersection using GJK and EPA, returning a Collision against the other IBoundingObject
+// (which shape b represents) if they intersect.
+func btConvexShapes(a, b convexShape, other IBoundingObject) *Collision {
+
+	simplex, intersecting := gjk(a, b)
+
+	if !intersecting {
+		return nil
+	}
+
+	dir, depth, ok := epa(a, b, simplex)
+
+	if !ok || depth <= 0 {
+		return nil
+	}
+
+	mtv := dir.Scale(-depth)
+
+	return newCollision(other).add(
+		&Intersection{
+			StartingPoint: a.centroid(),
+			// The deepest point of a into b, moved out onto b's surface
+			ContactPoint: a.support(dir).Add(mtv),
+			MTV:          mtv,
+			Normal:       dir.Invert(),
+		},
+	)
+
+}
+
+// gjkCast sweeps convex shape a along the given motion vector against convex shape b using the GJK ray cast algorithm (by Gino van den
+// Bergen), returning how far along the motion the shapes first touch (from 0 to 1) and the normal of b's surface at that point. Shapes that
+// are already intersecting before moving aren't considered to be hit.
+func gjkCast(a, b convexShape, motion Vector3) (float32, Vector3, bool) {
+
+	// This casts a ray from the origin along the motion against the Minkowski difference of the shapes (b - a)
+	lambda := float32(0)
+	x := Vector3{}
+	normal := Vector3{}
+
+	v := x.Sub(b.centroid().Sub(a.centroid()))
+
+	simplex := make([]Vector3, 0, 4)
+	points := make([]Vector3, 0, 4)
+
+	for i := 0; i < gjkMaxIterations && v.MagnitudeSquared() > 1e-8; i++ {
+
+		p := minkowskiSupport(b, a, v)
+		w := x.Sub(p)
+
+		if vw := v.Dot(w); vw > 0 {
+
+			vr := v.Dot(motion)
+
+			if vr >= 0 {
+				return 0, Vector3{}, false
+			}
+
+			lambda -= vw / vr
+
+			if lambda > 1 {
+				return 0, Vector3{}, false
+			}
+
+			x = motion.Scale(lambda)
+			normal = v
+
+		}
+
+		// If the support point is already in the simplex, the search has converged
+		converged := false
+		for _, s := range simplex {
+			if s.DistanceSquared(p) < 1e-12 {
+				converged = true
+				break
+			}
+		}
+
+		if converged {
+			break
+		}
+
+		simplex = append(simplex, p)
+
+		points = points[:0]
+		for _, s := range simplex {
+			points = append(points, x.Sub(s))
+		}
+
+		var used []int
+		v, used = closestSimplexPoint(points)
+
+		reduced := simplex[:0]
+		for _, index := range used {
+			reduced = append(reduced, simplex[index])
+		}
+		simplex = reduced
+
+	}
+
+	// The shapes were intersecting from the start
+	if normal.IsZero() {
+		return 0, Vector3{}, false
+	}
+
+	return lambda, normal.Unit(), true
+
+}
+
+// closestSimplexPoint returns the point of the given simplex (of up to 4 points) closest to the origin, along with the indices of the
+// points of the smallest part of the simplex that the closest point lies on.
+func closestSimplexPoint(simplex []Vector3) (Vector3, []int) {
+
+	switch len(simplex) {
+
+	case 1:
+		return simplex[0], []int{0}
+
+	case 2:
+		return closestSegmentPoint(simplex[0], simplex[1])
+
+	case 3:
+		return closestTrianglePoint(simplex[0], simplex[1], simplex[2])
+
+	default:
+
+		closest := Vector3{}
+		closestDistance := float32(math.MaxFloat32)
+		var used []int
+
+		faces := [4][4]int{{0, 1, 2, 3}, {0, 2, 3, 1}, {0, 3, 1, 2}, {1, 3, 2, 0}}
+
+		for _, face := range faces {
+
+			a, b, c, d := simplex[face[0]], simplex[face[1]], simplex[face[2]], simplex[face[3]]
+
+			// The origin can only be closest to faces that it's on the other side of from the remaining point
+			normal := b.Sub(a).Cross(c.Sub(a))
+			signOrigin := normal.Dot(a.Invert())
+			signOther := normal.Dot(d.Sub(a))
+
+			if signOther != 0 && signOrigin*signOther >= 0 {
+				continue
+			}
+
+			point, indices := closestTrianglePoint(a, b, c)
+
+			if dist := point.MagnitudeSquared(); dist < closestDistance {
+				closest = point
+				closestDistance = dist
+				used = used[:0]
+				for _, index := range indices {
+					used = append(used, face[index])
+				}
+			}
+
+		}
+
+		// The origin is inside of the tetrahedron
+		if used == nil {
+			return Vector3{}, []int{0, 1, 2, 3}
+		}
+
+		return closest, used
+
+	}
+
+}
+
+func closestSegmentPoint(a, b Vector3) (Vector3, []int) {
+
+	ab := b.Sub(a)
+	length := ab.Dot(ab)
+
+	if length == 0 {
+		return a, []int{0}
+	}
+
+	t := a.Invert().Dot(ab) / length
+
+	if t <= 0 {
+		return a, []int{0}
+	} else if t >= 1 {
+		return b, []int{1}
+	}
+
+	return a.Add(ab.Scale(t)), []int{0, 1}
+
+}
+
+// closestTrianglePoint returns the point of the given triangle closest to the origin; see Real-Time Collision Detection by Christer Ericson.
+func closestTrianglePoint(a, b, c Vector3) (Vector3, []int) {
+
+	ab := b.Sub(a)
+	ac := c.Sub(a)
+
+	ap := a.Invert()
+	d1 := ab.Dot(ap)
+	d2 := ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a, []int{0}
+	}
+
+	bp := b.Invert()
+	d3 := ab.Dot(bp)
+	d4 := ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b, []int{1}
+	}
+
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return a.Add(ab.Scale(d1 / (d1 - d3))), []int{0, 1}
+	}
+
+	cp := c.Invert()
+	d5 := ab.Dot(cp)
+	d6 := ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c, []int{2}
+	}
+
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return a.Add(ac.Scale(d2 / (d2 - d6))), []int{0, 2}
+	}
+
+	va := d3*d6 - d5*d4
+	if va <= 0 && (d4-d3) >= 0 && (d5-d6) >= 0 {
+		return b.Add(c.Sub(b).Scale((d4 - d3) / ((d4 - d3) + (d5 - d6)))), []int{1, 2}
+	}
+
+	sum := va + vb + vc
+
+	// Degenerate triangles have no area, so the closest point is on one of the edges
+	if sum == 0 {
+
+		points := [3]Vector3{a, b, c}
+		closest, used := closestSegmentPoint(a, b)
+
+		for _, edge := range [][2]int{{0, 2}, {1, 2}} {
+
+			if point, indices := closestSegmentPoint(points[edge[0]], points[edge[1]]); point.MagnitudeSquared() < closest.MagnitudeSquared() {
+				closest = point
+				used = used[:0]
+				for _, index := range indices {
+					used = append(used, edge[index])
+				}
+			}
+
+		}
+
+		return closest, used
+
+	}
+
+	return a.Add(ab.Scale(vb / sum)).Add(ac.Scale(vc / sum)), []int{0, 1, 2}
+
+}
@@ -0,0 +1,67 @@
+package tetra3d
+
+import (
+	"testing"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+func TestGJKEPABoxPenetration(t *testing.T) {
+
+	unitBox := func(center Vector3) orientedBox {
+		return orientedBox{
+			center: center,
+			axes:   [3]Vector3{WorldRight, WorldUp, WorldBackward},
+			half:   [3]float32{1, 1, 1},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		offset     Vector3
+		intersects bool
+		normal     Vector3
+		depth      float32
+	}{
+		{"overlapping on X", Vector3{1.5, 0, 0}, true, Vector3{1, 0, 0}, 0.5},
+		{"overlapping on -X", Vector3{-1.75, 0, 0}, true, Vector3{-1, 0, 0}, 0.25},
+		{"overlapping on Y", Vector3{0.5, 1.8, 0}, true, Vector3{0, 1, 0}, 0.2},
+		{"overlapping on Z", Vector3{0, 0.25, -1.6}, true, Vector3{0, 0, -1}, 0.4},
+		{"separated", Vector3{3, 0, 0}, false, Vector3{}, 0},
+		{"separated diagonally", Vector3{2.1, 2.1, 2.1}, false, Vector3{}, 0},
+	}
+
+	for _, test := range tests {
+
+		a := unitBox(Vector3{})
+		b := unitBox(test.offset)
+
+		simplex, intersects := gjk(a, b)
+
+		if intersects != test.intersects {
+			t.Errorf("%s: gjk() returned an intersection of %t; expected %t", test.name, intersects, test.intersects)
+			continue
+		}
+
+		if !intersects {
+			continue
+		}
+
+		normal, depth, ok := epa(a, b, simplex)
+
+		if !ok {
+			t.Errorf("%s: epa() failed to find the penetration", test.name)
+			continue
+		}
+
+		if math32.Abs(depth-test.depth) > 0.001 {
+			t.Errorf("%s: penetration depth is %f; expected %f", test.name, depth, test.depth)
+		}
+
+		if normal.Sub(test.normal).Magnitude() > 0.001 {
+			t.Errorf("%s: penetration direction is %s; expected %s", test.name, normal, test.normal)
+		}
+
+	}
+
+}
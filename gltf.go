@@ -1,19 +1,17 @@
 package tetra3d
 
 import (
-	"bytes"
 	"encoding/json"
-	"image"
 	"io"
 	"io/fs"
 	"log"
 	"math"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/qmuntal/gltf"
 	"github.com/qmuntal/gltf/ext/lightspunctual"
 	"github.com/qmuntal/gltf/modeler"
@@ -34,12 +32,53 @@ type GLTFLoadOptions struct {
 	// In this example, loading level.gltf would require the dependent library, found in assets.gltf. Loading level.gltf will refer to objects linked from the assets
 	// blend file, known as "../assets.blend".
 	// You could then simply load the assets library first and then code the DependentLibraryResolver function to take the assets library, or code the
-	// function to use the path to load the library on demand. You could then store the loaded result as necessary if multiple levels use this assets Library.
+	// function to use the path to load the library on demand. If multiple levels use this assets Library, you can have the loaded result cached for you
+	// using DependentLibraryCache instead.
 	DependentLibraryResolver func(blendPath string) *Library
-	LoadExternalTextures     bool // Whether any external textures should automatically be loaded if you load a GLTF file using LoadGLTFFile(). Defaults to true.
+	// DependentLibraryCache, if set, is used to resolve dependent Libraries instead of DependentLibraryResolver, loading them through the
+	// LibraryCache's load function and caching them by path, so that each dependent Library is only loaded once, even if it's linked from
+	// multiple Libraries; set the LibraryCache given to the load function as the DependentLibraryCache of the Library it loads (see
+	// LibraryCache). Cyclic dependencies (i.e. a dependent Library linking objects from a Library that depends on it) are also detected,
+	// with the objects linked from the Library that's still being resolved not being instantiated. Defaults to nil.
+	DependentLibraryCache *LibraryCache
+	LoadExternalTextures  bool // Whether any external textures should automatically be loaded if you load a GLTF file using LoadGLTFFile(). Defaults to true.
+	// LoadCollectionsAsGroups controls whether the collections in each scene in Blender should be loaded as Group Nodes, with the top-level
+	// objects in each collection parented to their Group (and child collections becoming child Groups). Collections that are disabled in the
+	// viewport in Blender are loaded as disabled Groups. Objects that are in multiple collections are placed under the first Group that claims them.
+	// Defaults to false, which places all top-level objects directly under the scene root.
+	LoadCollectionsAsGroups bool
+
+	// DeferFinalization controls whether creating the Library's textures (decoding the image data and creating ebiten.Images from it),
+	// its Cameras' render textures, and its Meshes' vertices and triangles is deferred until Library.FinalizeStep() is called, rather than
+	// done while loading. This allows the work to be spread across multiple frames (i.e. behind a loading bar), rather than freezing the
+	// game while a large Library loads. Until the Library is finalized, Materials that use textures have nil Textures, Cameras have a size
+	// of 0x0, and Meshes have no vertices (though their Dimensions are set). Defaults to false.
+	DeferFinalization bool
+
+	// TessellationEdgeLength, if greater than 0, automatically tessellates the loaded Meshes so that none of their triangles' edges are
+	// longer than this length (see Mesh.Tessellate()). This improves the vertex lighting and texturing of large, flat triangles, like
+	// those making up floors and walls, at the cost of rendering more triangles. Defaults to 0.
+	TessellationEdgeLength float32
+
+	// OptimizeVertexOrder controls whether the loaded Meshes have their vertices and triangles reordered for better memory locality while
+	// rendering (see Mesh.OptimizeVertexOrder()). This helps large Meshes, like level geometry, at the cost of a longer load. Defaults to false.
+	OptimizeVertexOrder bool
+
+	// KTX2Decoder is used to decode KTX2 textures referenced using the KHR_texture_basisu GLTF extension (see KTX2Decoder).
+	// If nil, textures fall back to their regular (i.e. PNG) images if they have any. Defaults to nil.
+	KTX2Decoder KTX2Decoder
+
+	// MaxTextureSize, if greater than 0, downscales loaded textures (by halving their size) until neither side is larger than this
+	// many pixels. This is useful for keeping the memory usage of large third-party textures down. Defaults to 0.
+	MaxTextureSize int
+
+	// DracoDecoder is used to decode meshes compressed using the KHR_draco_mesh_compression GLTF extension (see DracoDecoder).
+	// If nil, compressed meshes that don't have uncompressed fallback data fail to load. Defaults to nil.
+	DracoDecoder DracoDecoder
 
 	rootFilename             string
-	externalBufferFileSystem fs.FS // The file system to use for loading external buffers; automatically set if you use LoadGLTFFile().
+	onProgress               func(progress float32) // Called as loading progresses; set when loading asynchronously.
+	externalBufferFileSystem fs.FS                  // The file system to use for loading external buffers; automatically set if you use LoadGLTFFile().
 }
 
 // DefaultGLTFLoadOptions creates an instance of GLTFLoadOptions with some sensible defaults.
@@ -127,11 +166,19 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 	}
 
+	if err := decodeDracoPrimitives(doc, gltfLoadOptions.DracoDecoder); err != nil {
+		return nil, err
+	}
+
+	gltfLoadOptions.reportProgress(0.1)
+
 	library := NewLibrary()
 
-	var images []*ebiten.Image
+	loadOptions := *gltfLoadOptions
+	library.loadOptions = &loadOptions
 
-	exportedTextures := false
+	// Embedded textures are decoded as Materials use them, unless finalization is deferred
+	images := make([]*ebiten.Image, len(doc.Images))
 
 	type Collection struct {
 		Objects []string
@@ -179,15 +226,9 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 			}
 
-			exportFormat := 0 // 0 = GLB, 1 = GLTF Separate
-
-			if format, exists := globalExporterSettings["t3dExportFormat__"]; exists {
-				exportFormat = int(format.(float64))
-			}
-
-			if et, exists := globalExporterSettings["t3dPackTextures__"]; exists {
+			// Whether textures are packed doesn't matter, as each image is loaded from the file if it's embedded, or externally otherwise
+			if _, exists := globalExporterSettings["t3dPackTextures__"]; exists {
 				t3dExport = true
-				exportedTextures = et.(bool) && exportFormat == 0
 			}
 
 			if col, exists := globalExporterSettings["t3dCollections__"]; exists {
@@ -231,7 +272,7 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 					locVec := NewVector3(float32(location[0].(float64)), float32(location[1].(float64)), float32(location[2].(float64)))
 
-					newCam := NewCamera(camWidth, camHeight)
+					newCam := newGLTFCamera(camWidth, camHeight, gltfLoadOptions)
 
 					newCam.SetLocalPositionVec(locVec)
 					newCam.SetLocalRotation(rotationMatrix)
@@ -261,30 +302,16 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 	}
 
-	if exportedTextures {
-		images = make([]*ebiten.Image, len(doc.Images))
-		for i, gltfImage := range doc.Images {
-
-			imageData, err := modeler.ReadBufferView(doc, doc.BufferViews[*gltfImage.BufferView])
-			if err != nil {
-				return nil, err
-			}
-
-			byteReader := bytes.NewReader(imageData)
-
-			img, _, err := image.Decode(byteReader)
-			if err != nil {
-				return nil, err
-			}
-
-			images[i] = ebiten.NewImageFromImage(img)
-
-		}
-
-	}
+	// Materials using each texture, for creating the textures later if finalization is deferred
+	imageUsers := make([][]*Material, len(doc.Images))
+	externalTextureUsers := map[string][]*Material{}
+	externalTexturePaths := []string{}
 
 	externalTextures := map[string]*ebiten.Image{}
 
+	// Material animations are added to the Library's Animations once those have been loaded.
+	materialAnimations := map[*Material][]any{}
+
 	for _, gltfMat := range doc.Materials {
 
 		newMat := NewMaterial(gltfMat.Name)
@@ -295,15 +322,38 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 		if gltfMat.PBRMetallicRoughness != nil {
 
 			if texture := gltfMat.PBRMetallicRoughness.BaseColorTexture; texture != nil {
-				if exportedTextures {
-					newMat.Texture = images[*doc.Textures[texture.Index].Source]
+
+				source := gltfTextureSource(doc, doc.Textures[texture.Index], gltfLoadOptions)
+
+				if source < 0 {
+					log.Println("warning: material [" + gltfMat.Name + "] has a texture without any image that can be loaded (i.e. a KTX2 texture without a fallback image, while GLTFLoadOptions.KTX2Decoder is nil)")
+				} else if gltfImage := doc.Images[source]; gltfImage.BufferView != nil {
+					if gltfLoadOptions.DeferFinalization {
+						imageUsers[source] = append(imageUsers[source], newMat)
+					} else {
+						if images[source] == nil {
+							imageData, err := modeler.ReadBufferView(doc, doc.BufferViews[*gltfImage.BufferView])
+							if err != nil {
+								return nil, err
+							}
+							if images[source], err = decodeGLTFImage(gltfImage, imageData, gltfLoadOptions); err != nil {
+								return nil, err
+							}
+						}
+						newMat.Texture = images[source]
+					}
 				} else {
-					newMat.TexturePath = doc.Images[*doc.Textures[texture.Index].Source].URI
-					if gltfLoadOptions.LoadExternalTextures && gltfLoadOptions.externalBufferFileSystem != nil {
+					newMat.TexturePath = gltfImage.URI
+					if gltfLoadOptions.LoadExternalTextures && gltfLoadOptions.externalBufferFileSystem != nil && gltfLoadOptions.DeferFinalization {
+						if _, ok := externalTextureUsers[newMat.TexturePath]; !ok {
+							externalTexturePaths = append(externalTexturePaths, newMat.TexturePath)
+						}
+						externalTextureUsers[newMat.TexturePath] = append(externalTextureUsers[newMat.TexturePath], newMat)
+					} else if gltfLoadOptions.LoadExternalTextures && gltfLoadOptions.externalBufferFileSystem != nil {
 						if texture, ok := externalTextures[newMat.TexturePath]; ok {
 							newMat.Texture = texture
 						} else {
-							texture, _, err := ebitenutil.NewImageFromFileSystem(gltfLoadOptions.externalBufferFileSystem, baseDir+newMat.TexturePath)
+							texture, err := loadExternalGLTFImage(gltfLoadOptions.externalBufferFileSystem, baseDir+newMat.TexturePath, gltfLoadOptions)
 							if err != nil {
 								log.Println(err)
 							} else {
@@ -329,6 +379,10 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 					newMat.Color.A = float32(color[3].(float64))
 				}
 
+				if anims, exists := dataMap["t3dMaterialAnimations__"]; exists {
+					materialAnimations[newMat] = anims.([]any)
+				}
+
 				if s, exists := dataMap["t3dMaterialShadeless__"]; exists {
 					newMat.Shadeless = s.(float64) > 0.5
 				}
@@ -379,6 +433,12 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 				if s, exists := dataMap["t3dCustomDepthValue__"]; exists {
 					newMat.CustomDepthOffsetValue = float32(s.(float64))
 				}
+				if s, exists := dataMap["t3dSoftParticleDistance__"]; exists {
+					newMat.SoftParticleDistance = float32(s.(float64))
+				}
+				if s, exists := dataMap["t3dPerPixelLighting__"]; exists {
+					newMat.PerPixelLighting = s.(float64) > 0
+				}
 				if s, exists := dataMap["t3dMaterialLightingMode__"]; exists {
 					// newMat.NormalsAlwaysFaceLights = s.(float64) > 0
 					switch int(s.(float64)) {
@@ -395,6 +455,14 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 					newMat.TransparencyMode = int(s.(float64))
 				}
 
+				if s, exists := dataMap["t3dDepthWrite__"]; exists {
+					newMat.DepthWrite = int(s.(float64))
+				}
+
+				if s, exists := dataMap["t3dDepthTest__"]; exists {
+					newMat.DepthTest = int(s.(float64))
+				}
+
 				if s, exists := dataMap["t3dVisible__"]; exists {
 					newMat.Visible = s.(float64) > 0
 				}
@@ -447,7 +515,66 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 	}
 
-	for _, mesh := range doc.Meshes {
+	if gltfLoadOptions.DeferFinalization {
+
+		for i, users := range imageUsers {
+
+			if len(users) == 0 {
+				continue
+			}
+
+			gltfImage := doc.Images[i]
+
+			library.addFinalizeTask(func() error {
+
+				imageData, err := modeler.ReadBufferView(doc, doc.BufferViews[*gltfImage.BufferView])
+				if err != nil {
+					return err
+				}
+
+				texture, err := decodeGLTFImage(gltfImage, imageData, gltfLoadOptions)
+				if err != nil {
+					return err
+				}
+
+				for _, mat := range users {
+					mat.Texture = texture
+				}
+
+				return nil
+
+			})
+
+		}
+
+		for _, texturePath := range externalTexturePaths {
+
+			users := externalTextureUsers[texturePath]
+			fileSystem := gltfLoadOptions.externalBufferFileSystem
+			fullPath := baseDir + texturePath
+
+			library.addFinalizeTask(func() error {
+
+				texture, err := loadExternalGLTFImage(fileSystem, fullPath, gltfLoadOptions)
+				if err != nil {
+					return err
+				}
+
+				for _, mat := range users {
+					mat.Texture = texture
+				}
+
+				return nil
+
+			})
+
+		}
+
+	}
+
+	for meshIndex, mesh := range doc.Meshes {
+
+		gltfLoadOptions.reportProgress(0.1 + 0.5*float32(meshIndex)/float32(len(doc.Meshes)))
 
 		// If t3dGrid__ is set on a mesh, then it can be skipped for loading
 		if mesh.Extras != nil {
@@ -504,6 +631,16 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 		}
 
+		// The vertices and triangle indices of each primitive, to create the Mesh's vertices and MeshParts from
+		type gltfPrimitive struct {
+			verts    []VertexInfo
+			indices  []int
+			material *Material
+		}
+
+		primitives := make([]gltfPrimitive, 0, len(mesh.Primitives))
+		positions := []Vector3{}
+
 		for _, v := range mesh.Primitives {
 
 			posBuffer := [][3]float32{}
@@ -524,6 +661,8 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 					0, 0,
 				)
 
+				positions = append(positions, Vector3{float32(v[0]), float32(v[1]), float32(v[2])})
+
 			}
 
 			if texCoordAccessor, texCoordExists := v.Attributes[gltf.TEXCOORD_0]; texCoordExists {
@@ -536,7 +675,13 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 					return nil, err
 				}
 
+				// UV transforms are baked into the UV values, as they're applied in the GLTF file's UV space (before V is flipped)
+				transform := gltfTextureTransform(doc, v.Material)
+
 				for i, v := range texCoords {
+					if transform != nil {
+						v = applyTextureTransform(transform, v)
+					}
 					vertexData[i].U = float32(v[0])
 					vertexData[i].V = -(float32(v[1]) - 1)
 				}
@@ -639,8 +784,6 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 			}
 
-			newMesh.AddVertices(vertexData...)
-
 			indexBuffer := []uint32{}
 
 			indices, err := modeler.ReadIndices(doc, doc.Accessors[*v.Indices], indexBuffer)
@@ -662,10 +805,38 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 				newIndices[i] = int(j)
 			}
 
-			newMesh.AddMeshPart(mat, newIndices...)
+			primitives = append(primitives, gltfPrimitive{verts: vertexData, indices: newIndices, material: mat})
+
+		}
+
+		buildMesh := func() {
+
+			for _, prim := range primitives {
+				newMesh.AddVertices(prim.verts...)
+				newMesh.AddMeshPart(prim.material, prim.indices...)
+			}
 
 			newMesh.UpdateBounds()
 
+			if gltfLoadOptions.TessellationEdgeLength > 0 {
+				newMesh.Tessellate(gltfLoadOptions.TessellationEdgeLength)
+			}
+
+			if gltfLoadOptions.OptimizeVertexOrder {
+				newMesh.OptimizeVertexOrder()
+			}
+
+		}
+
+		// If finalization is deferred, the Mesh's vertices and triangles are created when the Library is finalized (or when an object
+		// needs them while loading, like BoundingTriangles); its dimensions are set now, so Models using it are culled correctly.
+		if gltfLoadOptions.DeferFinalization {
+			if len(positions) > 0 {
+				newMesh.Dimensions = NewDimensionsFromPoints(positions...)
+			}
+			library.deferMeshBuild(newMesh, buildMesh)
+		} else {
+			buildMesh()
 		}
 
 	}
@@ -822,6 +993,52 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 	}
 
+	for mat, anims := range materialAnimations {
+
+		for _, a := range anims {
+
+			animData := a.(map[string]any)
+
+			name := animData["name"].(string)
+
+			anim, exists := library.Animations[name]
+			if !exists {
+				anim = NewAnimation(name)
+				anim.library = library
+				library.Animations[name] = anim
+			}
+
+			channel := anim.AddMaterialChannel(mat.Name)
+
+			addKeys := func(track *AnimationTrack, keys []any, value func(key []any) any) {
+				for _, k := range keys {
+					key := k.([]any)
+					t := float32(key[0].(float64))
+					track.AddKeyframe(t, value(key))
+					if t > anim.Length {
+						anim.Length = t
+					}
+				}
+			}
+
+			if colorKeys, exists := animData["color"]; exists {
+				addKeys(channel.AddTrack(TrackTypeColor), colorKeys.([]any), func(key []any) any {
+					return NewColor(float32(key[1].(float64)), float32(key[2].(float64)), float32(key[3].(float64)), float32(key[4].(float64)))
+				})
+			}
+
+			if props, exists := animData["properties"]; exists {
+				for propName, propKeys := range props.(map[string]any) {
+					addKeys(channel.AddPropertyTrack(propName), propKeys.([]any), func(key []any) any {
+						return float32(key[1].(float64))
+					})
+				}
+			}
+
+		}
+
+	}
+
 	// skins := []*Skin{}
 
 	// for _, skin := range doc.Skins {
@@ -861,7 +1078,9 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 	}
 
 	// Node / Object creation
-	for _, node := range doc.Nodes {
+	for nodeIndex, node := range doc.Nodes {
+
+		gltfLoadOptions.reportProgress(0.7 + 0.2*float32(nodeIndex)/float32(len(doc.Nodes)))
 
 		var obj INode
 
@@ -874,6 +1093,7 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 		if mesh != nil {
 
 			if mesh.Unique != MeshUniqueFalse {
+				library.buildMesh(mesh)
 				mesh = mesh.Clone()
 			}
 
@@ -888,7 +1108,7 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 			gltfCam := doc.Cameras[*node.Camera]
 
-			newCam := NewCamera(camWidth, camHeight)
+			newCam := newGLTFCamera(camWidth, camHeight, gltfLoadOptions)
 			newCam.name = node.Name
 			newCam.RenderDepth = gltfLoadOptions.CameraDepth
 			newCam.updateProjectionMatrix = true
@@ -970,6 +1190,10 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 				path.Closed = extraMap["t3dPathCyclic__"].(bool)
 			}
 
+			if nodeHasProp(node, "t3dPathBezier__") && extraMap["t3dPathBezier__"].(bool) {
+				path.Interpolation = PathInterpolationBezier
+			}
+
 			obj = path
 
 		} else if node.Extras != nil && nodeHasProp(node, "t3dGridConnections__") {
@@ -1060,6 +1284,172 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 				obj.SetVisible(getOrDefaultBool("t3dVisible__", true), false)
 
+				if d, exists := dataMap["t3dDeltaTransform__"]; exists {
+
+					deltaData := d.(map[string]any)
+					toFloats := func(value any) []float32 {
+						floats := []float32{}
+						for _, v := range value.([]any) {
+							floats = append(floats, float32(v.(float64)))
+						}
+						return floats
+					}
+
+					delta := NewAnimationDelta()
+					loc := toFloats(deltaData["location"])
+					delta.Position = Vector3{loc[0], loc[1], loc[2]}
+					rot := toFloats(deltaData["rotation"])
+					delta.Rotation = NewQuaternion(rot[0], rot[1], rot[2], rot[3])
+					scale := toFloats(deltaData["scale"])
+					delta.Scale = Vector3{scale[0], scale[1], scale[2]}
+
+					for _, anim := range library.Animations {
+						if channel, exists := anim.Channels[obj.Name()]; exists {
+							channel.Delta = delta
+						}
+					}
+
+				}
+
+				// Visibility keyframes are exported per action that the object uses, as the action may not animate anything else.
+				if v, exists := dataMap["t3dVisibilityKeys__"]; exists {
+
+					for animName, keys := range v.(map[string]any) {
+
+						anim, exists := library.Animations[animName]
+						if !exists {
+							anim = NewAnimation(animName)
+							anim.library = library
+							library.Animations[animName] = anim
+						}
+
+						channel, exists := anim.Channels[obj.Name()]
+						if !exists {
+							channel = anim.AddChannel(obj.Name())
+						}
+
+						track := channel.AddTrack(TrackTypeVisible)
+						track.Interpolation = InterpolationConstant
+
+						for _, k := range keys.([]any) {
+							key := k.([]any)
+							t := float32(key[0].(float64))
+							track.AddKeyframe(t, key[1].(float64) > 0.5)
+							if t > anim.Length {
+								anim.Length = t
+							}
+						}
+
+					}
+
+				}
+
+				if actionName, exists := dataMap["t3dCurrentAction__"]; exists {
+					if anim, exists := library.Animations[actionName.(string)]; exists {
+						obj.AnimationPlayer().DefaultAnimation = anim
+					}
+				}
+
+				if constraintData, exists := dataMap["t3dBoneConstraints__"]; exists {
+
+					for _, c := range constraintData.([]any) {
+
+						data := c.(map[string]any)
+
+						ic := &importedConstraint{
+							Bone:      data["bone"].(string),
+							Type:      data["type"].(string),
+							Influence: float32(data["influence"].(float64)),
+						}
+
+						if target, exists := data["target"]; exists {
+							ic.Target = target.(string)
+						}
+
+						if local, exists := data["local"]; exists {
+							ic.Local = local.(float64) > 0.5
+						}
+
+						if limits, exists := data["limits"]; exists {
+							for i, l := range limits.([]any) {
+								limit := l.([]any)
+								ic.Limits[i].Enabled = limit[0].(float64) > 0.5
+								ic.Limits[i].Min = float32(limit[1].(float64))
+								ic.Limits[i].Max = float32(limit[2].(float64))
+							}
+						}
+
+						if axis, exists := data["axis"]; exists {
+							a := axis.([]any)
+							ic.Axis = Vector3{float32(a[0].(float64)), float32(a[1].(float64)), float32(a[2].(float64))}
+						}
+
+						if chainCount, exists := data["chainCount"]; exists {
+							ic.ChainCount = int(chainCount.(float64))
+						}
+
+						obj.AnimationPlayer().importedConstraints = append(obj.AnimationPlayer().importedConstraints, ic)
+
+					}
+
+				}
+
+				// NLA tracks are composed into a single Animation named after the object (i.e. "Door_NLA").
+				if nlaData, exists := dataMap["t3dNLATracks__"]; exists {
+
+					toBool := func(value any) bool {
+						if b, ok := value.(bool); ok {
+							return b
+						}
+						f, _ := value.(float64)
+						return f > 0.5
+					}
+
+					tracks := []*CompositeTrack{}
+
+					for _, t := range nlaData.([]any) {
+
+						trackData := t.(map[string]any)
+						track := NewCompositeTrack(trackData["name"].(string))
+						track.Muted = toBool(trackData["muted"])
+
+						for _, st := range trackData["strips"].([]any) {
+
+							stripData := st.(map[string]any)
+
+							anim, exists := library.Animations[stripData["action"].(string)]
+							if !exists {
+								continue
+							}
+
+							strip := NewCompositeStrip(anim, float32(stripData["start"].(float64)))
+							strip.AnimationStart = float32(stripData["actionStart"].(float64))
+							strip.AnimationEnd = float32(stripData["actionEnd"].(float64))
+							strip.Scale = float32(stripData["scale"].(float64))
+							strip.Repeat = float32(stripData["repeat"].(float64))
+							strip.BlendMode = StripBlendMode(stripData["blendMode"].(float64))
+							strip.Influence = float32(stripData["influence"].(float64))
+							strip.BlendIn = float32(stripData["blendIn"].(float64))
+							strip.BlendOut = float32(stripData["blendOut"].(float64))
+							strip.Hold = toBool(stripData["hold"])
+							track.Strips = append(track.Strips, strip)
+
+						}
+
+						tracks = append(tracks, track)
+
+					}
+
+					composite := NewCompositeAnimation(obj.Name()+"_NLA", 0, tracks...)
+					composite.library = library
+					library.Animations[composite.Name] = composite
+
+					if obj.AnimationPlayer().DefaultAnimation == nil {
+						obj.AnimationPlayer().DefaultAnimation = composite
+					}
+
+				}
+
 				if bt, exists := dataMap["t3dBoundsType__"]; exists {
 
 					boundsType := int(bt.(float64))
@@ -1160,6 +1550,7 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 								gridSize = getOrDefaultFloat("t3dTrianglesCustomBroadphaseGridSize__", 20)
 							}
 
+							library.buildMesh(obj.(*Model).Mesh)
 							triangles := NewBoundingTriangles("BoundingTriangles", obj.(*Model).Mesh, gridSize)
 
 							obj.AddChildren(triangles)
@@ -1272,6 +1663,8 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 			}
 
+			library.buildMesh(model.Mesh)
+
 			for vertIndex, boneIndices := range model.Mesh.VertexBones {
 				model.bones = append(model.bones, []*Node{})
 
@@ -1288,6 +1681,12 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 			objects[i].AddChildren(objects[int(childIndex)])
 		}
 
+		if model, isModel := objects[i].(*Model); isModel {
+			if err := loadGLTFInstances(doc, node, model); err != nil {
+				return nil, err
+			}
+		}
+
 	}
 
 	findNode := func(objName string) INode {
@@ -1338,6 +1737,20 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 	}
 
+	// ParticleSystems are created once all of the objects exist, as they use other objects as their particles.
+	// This is done before instancing collections, so that instanced objects have their own ParticleSystems.
+	for obj, node := range objToNode {
+
+		if node.Extras != nil {
+			if dataMap, isMap := node.Extras.(map[string]any); isMap {
+				if settings, exists := dataMap["t3dParticleSettings__"]; exists {
+					loadParticleSystem(obj, settings.(map[string]any), findNode)
+				}
+			}
+		}
+
+	}
+
 	for obj, node := range objToNode {
 
 		if node.Extras != nil {
@@ -1362,11 +1775,22 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 							clone = findNode(cloneName).Clone()
 						} else {
 							path = convertBlenderPath(path)
-							if gltfLoadOptions.DependentLibraryResolver == nil {
+							if gltfLoadOptions.DependentLibraryResolver == nil && gltfLoadOptions.DependentLibraryCache == nil {
 								log.Printf("Warning: No dependent library resolver defined to resolve dependent library %s for object %s.\n", path, cloneName)
 							} else {
 
-								if library := gltfLoadOptions.DependentLibraryResolver(path); library != nil {
+								var library *Library
+								var err error
+
+								if gltfLoadOptions.DependentLibraryCache != nil {
+									library, err = gltfLoadOptions.DependentLibraryCache.Load(path)
+								} else {
+									library = gltfLoadOptions.DependentLibraryResolver(path)
+								}
+
+								if err != nil {
+									log.Printf("Warning: %s; object %s won't be instantiated.\n", err, cloneName)
+								} else if library != nil {
 									if foundNode := library.NodeByName(cloneName); foundNode != nil {
 										clone = foundNode.Clone()
 									} else {
@@ -1502,7 +1926,9 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 	// Set up scene roots
 
-	for _, s := range doc.Scenes {
+	for sceneIndex, s := range doc.Scenes {
+
+		gltfLoadOptions.reportProgress(0.9 + 0.1*float32(sceneIndex)/float32(len(doc.Scenes)))
 
 		scene := library.AddScene(s.Name)
 
@@ -1511,6 +1937,14 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 			scene.Root.AddChildren(objects[n])
 		}
 
+		disabledGroups := []*Group{}
+
+		if gltfLoadOptions.LoadCollectionsAsGroups && s.Extras != nil {
+			if tree, exists := s.Extras.(map[string]any)["t3dCollectionTree__"]; exists {
+				disabledGroups = loadCollectionGroups(scene, tree.(map[string]any))
+			}
+		}
+
 		if s.Extras != nil {
 			extras := s.Extras.(map[string]any)
 			if wn, exists := extras["t3dCurrentWorld__"]; exists {
@@ -1593,6 +2027,13 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 			scene.View3DCameras = append(scene.View3DCameras, cam.Clone().(*Camera))
 		}
 
+		// Disabling Groups is done last so that everything placed under them (i.e. instanced collection objects) is hidden.
+		for _, group := range disabledGroups {
+			group.SetEnabled(false)
+		}
+
+		assignStableIDs(scene)
+
 	}
 
 	// Cameras exported through GLTF become nodes + a camera child with the correct orientation for some reason???
@@ -1625,10 +2066,155 @@ func LoadGLTFData(data io.Reader, gltfLoadOptions *GLTFLoadOptions) (*Library, e
 
 	library.ExportedScene = library.Scenes[*doc.Scene]
 
+	// Cameras are created without render textures when finalization is deferred, so they're resized once the Library's finalized.
+	if gltfLoadOptions.DeferFinalization {
+
+		library.addFinalizeTask(func() error {
+
+			for _, scene := range library.Scenes {
+
+				for _, node := range scene.Root.SearchTree().INodes() {
+					if cam, isCamera := node.(*Camera); isCamera && cam.resultColorTexture == nil {
+						cam.Resize(camWidth, camHeight)
+					}
+				}
+
+				for _, cam := range scene.View3DCameras {
+					if cam.resultColorTexture == nil {
+						cam.Resize(camWidth, camHeight)
+					}
+				}
+
+			}
+
+			return nil
+
+		})
+
+	}
+
 	return library, nil
 
 }
 
+// newGLTFCamera creates a Camera of the given size for a loading Library; if finalization is deferred, the Camera is created without its
+// render textures, which are created once the Library is finalized.
+func newGLTFCamera(w, h int, options *GLTFLoadOptions) *Camera {
+	if options.DeferFinalization {
+		return NewCamera(0, 0)
+	}
+	return NewCamera(w, h)
+}
+
+// reportProgress reports how far along loading is (from 0 to 1) to the load options' progress callback, if it has one.
+func (options *GLTFLoadOptions) reportProgress(progress float32) {
+	if options.onProgress != nil {
+		options.onProgress(progress)
+	}
+}
+
+// loadCollectionGroups creates Groups for the collection hierarchy exported from Blender for a scene, parenting the scene's top-level
+// objects to the Groups representing their collections. It returns the Groups that should be disabled.
+func loadCollectionGroups(scene *Scene, tree map[string]any) []*Group {
+
+	type collectionData struct {
+		Name    string
+		Objects []string
+		Parent  string
+		Index   int
+		Enabled bool
+	}
+
+	collections := make([]collectionData, 0, len(tree))
+
+	for name, c := range tree {
+
+		data := c.(map[string]any)
+		collection := collectionData{Name: name, Enabled: true}
+
+		if objects, ok := data["objects"].([]any); ok {
+			for _, o := range objects {
+				collection.Objects = append(collection.Objects, o.(string))
+			}
+		}
+
+		if parent, ok := data["parent"].(string); ok {
+			collection.Parent = parent
+		}
+
+		if index, ok := data["index"].(float64); ok {
+			collection.Index = int(index)
+		}
+
+		switch enabled := data["enabled"].(type) {
+		case bool:
+			collection.Enabled = enabled
+		case float64:
+			collection.Enabled = enabled > 0
+		}
+
+		collections = append(collections, collection)
+
+	}
+
+	// JSON objects are unordered, so we sort the collections by depth (so parent Groups are created before their children) and then
+	// by index (so Groups are in the same order as their collections in Blender).
+	depth := func(c collectionData) int {
+		d := 0
+		for parent := c.Parent; parent != ""; d++ {
+			parentData, ok := tree[parent].(map[string]any)
+			if !ok {
+				break
+			}
+			parent, _ = parentData["parent"].(string)
+		}
+		return d
+	}
+
+	sort.SliceStable(collections, func(i, j int) bool {
+		di, dj := depth(collections[i]), depth(collections[j])
+		if di != dj {
+			return di < dj
+		}
+		return collections[i].Index < collections[j].Index
+	})
+
+	topLevel := map[string]INode{}
+	for _, child := range scene.Root.Children() {
+		topLevel[child.Name()] = child
+	}
+
+	groups := map[string]*Group{}
+	disabled := []*Group{}
+
+	for _, collection := range collections {
+
+		group := NewGroup(collection.Name)
+		groups[collection.Name] = group
+
+		if parent, exists := groups[collection.Parent]; exists {
+			parent.AddChildren(group)
+		} else {
+			scene.Root.AddChildren(group)
+		}
+
+		for _, objName := range collection.Objects {
+			if node, exists := topLevel[objName]; exists {
+				group.AddChildren(node)
+				delete(topLevel, objName)
+			}
+		}
+
+		if !collection.Enabled {
+			disabled = append(disabled, group)
+		}
+
+	}
+
+	return disabled
+
+}
+
 func handleGameProperties(p any) (string, any) {
 
 	getOrDefaultInt := func(propMap map[string]any, key string, defaultValue int) int {
@@ -1732,3 +2318,147 @@ func convertBlenderPath(path string) string {
 	path = strings.ReplaceAll(path, "//", "") // Blender relative paths have double-slashes; we don't need them to
 	return path
 }
+
+// loadParticleSystem creates a ParticleSystem for the given object using the particle system settings exported from Blender.
+// If the object isn't a Model, a Model is created as a child of the object to act as the root of the ParticleSystem.
+func loadParticleSystem(obj INode, data map[string]any, findNode func(objName string) INode) {
+
+	toBool := func(value any) bool {
+		if b, ok := value.(bool); ok {
+			return b
+		}
+		f, _ := value.(float64)
+		return f > 0.5
+	}
+
+	toFloats := func(value any) []float32 {
+		floats := []float32{}
+		for _, v := range value.([]any) {
+			floats = append(floats, float32(v.(float64)))
+		}
+		return floats
+	}
+
+	toVector := func(value any) Vector3 {
+		f := toFloats(value)
+		return Vector3{f[0], f[1], f[2]}
+	}
+
+	// Ranges are exported as [min, max] pairs.
+	toVectorRange := func(key string) VectorRange {
+		ran := NewVectorRange()
+		if value, exists := data[key]; exists {
+			pair := value.([]any)
+			ran.Min = toVector(pair[0])
+			ran.Max = toVector(pair[1])
+		}
+		return ran
+	}
+
+	toFloatRange := func(key string, defaultValue float32) FloatRange {
+		ran := NewFloatRange()
+		ran.Set(defaultValue, defaultValue)
+		if value, exists := data[key]; exists {
+			pair := toFloats(value)
+			ran.Set(pair[0], pair[1])
+		}
+		return ran
+	}
+
+	factories := []*Model{}
+
+	if names, exists := data["factories"]; exists {
+		for _, name := range names.([]any) {
+			model, ok := findNode(name.(string)).(*Model)
+			if !ok || model.Mesh == nil {
+				continue
+			}
+			model.Mesh.library.buildMesh(model.Mesh)
+			if len(model.Mesh.MeshParts) > 0 {
+				factories = append(factories, model)
+			}
+		}
+	}
+
+	if len(factories) == 0 {
+		log.Println("Warning: object " + obj.Name() + " has a particle system, but no mesh objects to use as particles; the particle system won't be created")
+		return
+	}
+
+	root, isModel := obj.(*Model)
+	if !isModel {
+		root = NewModel(obj.Name()+"_particles", NewMesh(obj.Name()+"_particles"))
+		root.setLibrary(obj.Library())
+		obj.AddChildren(root)
+	}
+
+	ps := NewParticleSystem(root, factories...)
+	settings := ps.Settings
+
+	settings.SpawnRate = toFloatRange("spawnRate", 1)
+	settings.Lifetime = toFloatRange("lifetime", 1)
+
+	if value, exists := data["spawnCount"]; exists {
+		pair := toFloats(value)
+		settings.SpawnCount.Set(int(pair[0]), int(pair[1]))
+	}
+
+	settings.Velocity = toVectorRange("velocity")
+	settings.VelocityAdd = toVectorRange("velocityAdd")
+	settings.ScaleAdd = toVectorRange("scaleAdd")
+	settings.RotationAdd = toVectorRange("rotationAdd")
+	settings.SpawnOffset = toVectorRange("spawnOffset")
+
+	if _, exists := data["scale"]; exists {
+		settings.Scale = toVectorRange("scale")
+	}
+	settings.Scale.Uniform = toBool(data["scaleUniform"])
+
+	if value, exists := data["friction"]; exists {
+		settings.Friction = float32(value.(float64))
+	}
+
+	settings.LocalPosition = toBool(data["localPosition"])
+
+	if value, exists := data["prewarm"]; exists {
+		settings.Prewarm = float32(value.(float64))
+	}
+
+	settings.OneShot = toBool(data["oneShot"])
+
+	if value, exists := data["emission"]; exists {
+
+		emission := value.(map[string]any)
+
+		shape := NewParticleEmissionShape(ParticleEmissionShapeSphere)
+
+		switch int(emission["shape"].(float64)) {
+		case 2:
+			shape.Type = ParticleEmissionShapeCone
+		case 3:
+			shape.Type = ParticleEmissionShapeBox
+		}
+
+		shape.Radius = float32(emission["radius"].(float64))
+		shape.Surface = toBool(emission["surface"])
+		shape.Angle = float32(emission["angle"].(float64))
+		shape.Size = toVector(emission["size"])
+		speed := toFloats(emission["speed"])
+		shape.Speed.Set(speed[0], speed[1])
+
+		settings.EmissionShape = shape
+
+	}
+
+	if value, exists := data["colors"]; exists {
+		settings.ColorCurve = NewColorCurve()
+		colors := value.([]any)
+		for i, c := range colors {
+			rgba := toFloats(c)
+			settings.ColorCurve.AddRGBA(rgba[0], rgba[1], rgba[2], rgba[3], float32(i)/float32(len(colors)-1))
+		}
+	}
+
+	root.particleSystem = ps
+
+}
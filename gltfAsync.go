@@ -0,0 +1,174 @@
+package tetra3d
+
+import (
+	"io"
+	"io/fs"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// GLTFLoadHandle is a handle to a GLTF file being loaded asynchronously using LoadGLTFDataAsync() or LoadGLTFFileSystemAsync().
+// The file is parsed and its Meshes, Animations, and Scenes are built on another goroutine, while the ebiten.Images of the loaded
+// Library (its textures and Cameras' render textures) are created on the game's goroutine through GLTFLoadHandle.Done().
+type GLTFLoadHandle struct {
+	// FinalizeBudget is how long each call to GLTFLoadHandle.Done() may spend finalizing the loaded Library (creating its textures).
+	// Defaults to 4 milliseconds.
+	FinalizeBudget time.Duration
+
+	mutex        sync.Mutex
+	loadProgress float32
+	loaded       bool
+	library      *Library
+	err          error
+}
+
+// LoadGLTFDataAsync starts loading a .gltf or .glb file from the byte data given on another goroutine, returning a GLTFLoadHandle
+// to monitor its progress with. This keeps the game running (i.e. to draw a loading screen) while loading large files, which is
+// particularly important on WASM, where a long load otherwise freezes the browser tab. See LoadGLTFData() for more information;
+// gltfLoadOptions is copied, and the Library is always loaded with GLTFLoadOptions.DeferFinalization set.
+func LoadGLTFDataAsync(data io.Reader, gltfLoadOptions *GLTFLoadOptions) *GLTFLoadHandle {
+
+	options := DefaultGLTFLoadOptions()
+	if gltfLoadOptions != nil {
+		copied := *gltfLoadOptions
+		options = &copied
+	}
+
+	options.DeferFinalization = true
+
+	handle := &GLTFLoadHandle{
+		FinalizeBudget: time.Millisecond * 4,
+	}
+
+	options.onProgress = func(progress float32) {
+
+		handle.mutex.Lock()
+		handle.loadProgress = progress
+		handle.mutex.Unlock()
+
+		// Yielding allows the game to keep running on platforms where goroutines can't be preempted, like WASM
+		runtime.Gosched()
+
+	}
+
+	go func() {
+
+		library, err := LoadGLTFData(data, options)
+
+		// Meshes don't need to be created on the game's goroutine, so they're created here rather than while finalizing.
+		if library != nil {
+			library.buildMeshes()
+		}
+
+		handle.mutex.Lock()
+		handle.library = library
+		handle.err = err
+		handle.loadProgress = 1
+		handle.loaded = true
+		handle.mutex.Unlock()
+
+	}()
+
+	return handle
+
+}
+
+// LoadGLTFFileSystemAsync starts loading a .gltf or .glb file from the file system given on another goroutine, returning a
+// GLTFLoadHandle to monitor its progress with. See LoadGLTFDataAsync() and LoadGLTFFileSystem() for more information.
+func LoadGLTFFileSystemAsync(fileSystem fs.FS, filename string, gltfLoadOptions *GLTFLoadOptions) *GLTFLoadHandle {
+
+	options := DefaultGLTFLoadOptions()
+	if gltfLoadOptions != nil {
+		copied := *gltfLoadOptions
+		options = &copied
+	}
+
+	options.externalBufferFileSystem = fileSystem
+	options.rootFilename = filename
+
+	file, err := fileSystem.Open(filename)
+
+	if err != nil {
+		return &GLTFLoadHandle{
+			loadProgress: 1,
+			loaded:       true,
+			err:          err,
+		}
+	}
+
+	return LoadGLTFDataAsync(file, options)
+
+}
+
+// Done returns if the GLTFLoadHandle has finished, either by loading and finalizing the Library or by failing to load it.
+// Done should be called once per game tick from the game's goroutine (i.e. in your game's Update() function) until it
+// returns true, as once the Library has been loaded, each call finalizes it for up to FinalizeBudget.
+func (handle *GLTFLoadHandle) Done() bool {
+
+	handle.mutex.Lock()
+	loaded := handle.loaded
+	library := handle.library
+	handle.mutex.Unlock()
+
+	if !loaded || library == nil {
+		return loaded
+	}
+
+	if library.Finalized() {
+		return true
+	}
+
+	finalized, err := library.FinalizeStep(handle.FinalizeBudget)
+
+	// Only the first error is kept; the Library continues to be finalized regardless
+	if err != nil {
+		handle.mutex.Lock()
+		if handle.err == nil {
+			handle.err = err
+		}
+		handle.mutex.Unlock()
+	}
+
+	return finalized
+
+}
+
+// Progress returns how much of the loading work has been done, ranging from 0 to 1. Loading the Library and finalizing it
+// (see GLTFLoadHandle.Done()) each make up half of the progress. This is useful for displaying a loading bar.
+func (handle *GLTFLoadHandle) Progress() float32 {
+
+	handle.mutex.Lock()
+	defer handle.mutex.Unlock()
+
+	if !handle.loaded || handle.library == nil {
+		return handle.loadProgress / 2
+	}
+
+	return 0.5 + handle.library.FinalizeProgress()/2
+
+}
+
+// Library returns the loaded Library once the GLTFLoadHandle is done (see GLTFLoadHandle.Done()). Until then (or if loading
+// failed), Library returns nil.
+func (handle *GLTFLoadHandle) Library() *Library {
+
+	handle.mutex.Lock()
+	defer handle.mutex.Unlock()
+
+	if !handle.loaded || handle.library == nil || !handle.library.Finalized() {
+		return nil
+	}
+
+	return handle.library
+
+}
+
+// Error returns the error that occurred while loading or finalizing the Library, if any. If the Library failed to load, the
+// GLTFLoadHandle is done without a Library. If finalizing fails (i.e. a texture couldn't be decoded), the rest of the Library
+// is still finalized, and only the first error is returned.
+func (handle *GLTFLoadHandle) Error() error {
+	handle.mutex.Lock()
+	defer handle.mutex.Unlock()
+	return handle.err
+}
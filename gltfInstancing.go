@@ -0,0 +1,188 @@
+package tetra3d
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// gltfGPUInstancingExtension is the name of the GLTF extension used to place many instances of a node's mesh (i.e. scattered foliage).
+const gltfGPUInstancingExtension = "EXT_mesh_gpu_instancing"
+
+// gltfGPUInstancing is the data of the EXT_mesh_gpu_instancing extension on a GLTF node.
+type gltfGPUInstancing struct {
+	Attributes map[string]int `json:"attributes"`
+}
+
+// loadGLTFInstances creates a Model for each instance of the GLTF node's mesh described by the node's EXT_mesh_gpu_instancing extension,
+// parenting them to the node's Model and dynamically batching them into it, so they're rendered together (and the node's Model itself
+// isn't rendered, as GLTF specifies). If the instances have more triangles than can be rendered in a single batch, additional batching
+// Models (named after the node's Model, followed by "_batch_" and a number) are created under the node's Model, with the rest of the
+// instances under them. As batched Models are rendered using the Material of the MeshPart they're batched into, each instance of a mesh
+// with multiple MeshParts only holds the first MeshPart, with a child Model for each of the other MeshParts (named after the instance,
+// followed by an underscore and the index of the MeshPart).
+func loadGLTFInstances(doc *gltf.Document, node *gltf.Node, model *Model) error {
+
+	extData, exists := node.Extensions[gltfGPUInstancingExtension]
+
+	if !exists || model.Mesh == nil {
+		return nil
+	}
+
+	model.Mesh.library.buildMesh(model.Mesh)
+
+	if len(model.Mesh.MeshParts) == 0 {
+		return nil
+	}
+
+	// Unknown extensions are left as raw JSON by the GLTF decoder, which marshals back to itself
+	raw, err := json.Marshal(extData)
+	if err != nil {
+		return err
+	}
+
+	ext := gltfGPUInstancing{}
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		return err
+	}
+
+	count := -1
+
+	read := func(attribute string) (any, error) {
+
+		index, exists := ext.Attributes[attribute]
+
+		if !exists {
+			return nil, nil
+		}
+
+		if index < 0 || index >= len(doc.Accessors) {
+			return nil, fmt.Errorf("object [%s] has instances using an accessor that doesn't exist: %d", node.Name, index)
+		}
+
+		accessor := doc.Accessors[index]
+
+		if count < 0 || accessor.Count < count {
+			count = accessor.Count
+		}
+
+		return modeler.ReadAccessor(doc, accessor, nil)
+
+	}
+
+	translationData, err := read("TRANSLATION")
+	if err != nil {
+		return err
+	}
+
+	rotationData, err := read("ROTATION")
+	if err != nil {
+		return err
+	}
+
+	scaleData, err := read("SCALE")
+	if err != nil {
+		return err
+	}
+
+	if count <= 0 {
+		return nil
+	}
+
+	if len(model.Mesh.Triangles) > MaxTriangleCount {
+		return fmt.Errorf("object [%s] has instances of a mesh with too many triangles to be batched (%d, max is %d)", node.Name, len(model.Mesh.Triangles), MaxTriangleCount)
+	}
+
+	partMeshes := gltfInstancePartMeshes(model.Mesh)
+
+	owner := model
+	batches := 0
+
+	for i := 0; i < count; i++ {
+
+		instance := NewModel(fmt.Sprintf("%s_%d", model.Name(), i), partMeshes[0])
+		instance.setLibrary(model.Library())
+
+		partModels := []*Model{instance}
+
+		for p := 1; p < len(partMeshes); p++ {
+			partModel := NewModel(fmt.Sprintf("%s_%d", instance.Name(), p), partMeshes[p])
+			partModel.setLibrary(model.Library())
+			instance.AddChildren(partModel)
+			partModels = append(partModels, partModel)
+		}
+
+		if t, ok := translationData.([][3]float32); ok {
+			instance.SetLocalPosition(t[i][0], t[i][1], t[i][2])
+		}
+
+		if s, ok := scaleData.([][3]float32); ok {
+			instance.SetLocalScale(s[i][0], s[i][1], s[i][2])
+		}
+
+		// Rotations can also be stored as normalized integers
+		switch r := rotationData.(type) {
+		case [][4]float32:
+			instance.SetLocalRotation(NewQuaternion(r[i][0], r[i][1], r[i][2], r[i][3]).ToMatrix4())
+		case [][4]int8:
+			instance.SetLocalRotation(NewQuaternion(float32(r[i][0])/127, float32(r[i][1])/127, float32(r[i][2])/127, float32(r[i][3])/127).Normalized().ToMatrix4())
+		case [][4]int16:
+			instance.SetLocalRotation(NewQuaternion(float32(r[i][0])/32767, float32(r[i][1])/32767, float32(r[i][2])/32767, float32(r[i][3])/32767).Normalized().ToMatrix4())
+		}
+
+		if owner.DynamicBatchTriangleCount()+len(model.Mesh.Triangles) > MaxTriangleCount && owner.DynamicBatcher() {
+			batches++
+			owner = NewModel(fmt.Sprintf("%s_batch_%d", model.Name(), batches), model.Mesh)
+			owner.setLibrary(model.Library())
+			model.AddChildren(owner)
+		}
+
+		owner.AddChildren(instance)
+
+		for p, partModel := range partModels {
+			if err := owner.DynamicBatchAdd(owner.Mesh.MeshParts[p], partModel); err != nil {
+				return err
+			}
+		}
+
+	}
+
+	return nil
+
+}
+
+// gltfInstancePartMeshes returns a Mesh for each of the given Mesh's MeshParts, holding just that MeshPart's vertices and triangles, to
+// batch instances of the Mesh with. If the Mesh only has one MeshPart, the Mesh itself is returned.
+func gltfInstancePartMeshes(mesh *Mesh) []*Mesh {
+
+	if len(mesh.MeshParts) == 1 {
+		return []*Mesh{mesh}
+	}
+
+	meshes := make([]*Mesh, 0, len(mesh.MeshParts))
+
+	for i, data := range mesh.partData() {
+
+		partMesh := NewMesh(fmt.Sprintf("%s_%d", mesh.Name, i))
+		partMesh.library = mesh.library
+		partMesh.VertexActiveColorChannel = mesh.VertexActiveColorChannel
+		for name, index := range mesh.VertexColorChannelNames {
+			partMesh.VertexColorChannelNames[name] = index
+		}
+
+		if len(data.verts) > 0 {
+			partMesh.AddVertices(data.verts...)
+		}
+
+		partMesh.AddMeshPart(mesh.MeshParts[i].Material, data.indices...)
+		partMesh.UpdateBounds()
+
+		meshes = append(meshes, partMesh)
+
+	}
+
+	return meshes
+
+}
@@ -0,0 +1,187 @@
+package tetra3d
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/ext/texturetransform"
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// gltfBasisuExtension is the name of the GLTF extension used to reference KTX2 textures compressed with Basis Universal.
+const gltfBasisuExtension = "KHR_texture_basisu"
+
+// ktx2Identifier is the identifier that all KTX2 files start with.
+var ktx2Identifier = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+// KTX2Decoder is a function that decodes the data of a KTX2 texture (i.e. one compressed using Basis Universal, as referenced by
+// the KHR_texture_basisu GLTF extension) into an image. tetra3d doesn't include a KTX2 or Basis Universal transcoder itself, so
+// this allows you to plug in whichever decoder you prefer. Only the first (largest) mip level of the texture is needed.
+type KTX2Decoder func(data []byte) (image.Image, error)
+
+// gltfBasisuTexture is the data of the KHR_texture_basisu extension on a GLTF texture.
+type gltfBasisuTexture struct {
+	Source int `json:"source"`
+}
+
+// gltfTextureSource returns the index of the image the GLTF texture should be loaded from, preferring KTX2 images referenced through
+// the KHR_texture_basisu extension if a KTX2Decoder is set. If the texture has no image that can be loaded, -1 is returned.
+func gltfTextureSource(doc *gltf.Document, texture *gltf.Texture, options *GLTFLoadOptions) int {
+
+	if extData, exists := texture.Extensions[gltfBasisuExtension]; exists && options.KTX2Decoder != nil {
+
+		ext := gltfBasisuTexture{}
+
+		// Unknown extensions are left as raw JSON by the GLTF decoder, which marshals back to itself
+		if raw, err := json.Marshal(extData); err == nil && json.Unmarshal(raw, &ext) == nil && ext.Source >= 0 && ext.Source < len(doc.Images) {
+			return ext.Source
+		}
+
+	}
+
+	if texture.Source == nil || isKTX2Image(doc.Images[*texture.Source], nil) && options.KTX2Decoder == nil {
+		return -1
+	}
+
+	return *texture.Source
+
+}
+
+// isKTX2Image returns if the GLTF image (or the data loaded for it, if not nil) is a KTX2 texture.
+func isKTX2Image(gltfImage *gltf.Image, data []byte) bool {
+	return gltfImage.MimeType == "image/ktx2" || strings.EqualFold(path.Ext(gltfImage.URI), ".ktx2") || bytes.HasPrefix(data, ktx2Identifier)
+}
+
+// decodeGLTFImage decodes the data of the GLTF image given into a texture, using the KTX2Decoder of the load options for KTX2
+// textures and downscaling the texture according to GLTFLoadOptions.MaxTextureSize.
+func decodeGLTFImage(gltfImage *gltf.Image, data []byte, options *GLTFLoadOptions) (*ebiten.Image, error) {
+
+	var img image.Image
+	var err error
+
+	if isKTX2Image(gltfImage, data) {
+		if options.KTX2Decoder == nil {
+			return nil, errors.New("image [" + gltfImage.Name + gltfImage.URI + "] is a KTX2 texture; set GLTFLoadOptions.KTX2Decoder to load it")
+		}
+		img, err = options.KTX2Decoder(data)
+	} else {
+		img, _, err = image.Decode(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if options.MaxTextureSize > 0 {
+		img = downscaleImage(img, options.MaxTextureSize)
+	}
+
+	return ebiten.NewImageFromImage(img), nil
+
+}
+
+// loadExternalGLTFImage loads the external image file of the given path from the file system as a texture (see decodeGLTFImage()).
+func loadExternalGLTFImage(fileSystem fs.FS, filepath string, options *GLTFLoadOptions) (*ebiten.Image, error) {
+
+	file, err := fileSystem.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeGLTFImage(&gltf.Image{URI: filepath}, data, options)
+
+}
+
+// gltfTextureTransform returns the UV transform (from the KHR_texture_transform extension) of the base color texture of the GLTF
+// material of the given index, or nil if it doesn't have one.
+func gltfTextureTransform(doc *gltf.Document, materialIndex *int) *texturetransform.TextureTranform {
+
+	if materialIndex == nil {
+		return nil
+	}
+
+	pbr := doc.Materials[*materialIndex].PBRMetallicRoughness
+
+	if pbr == nil || pbr.BaseColorTexture == nil {
+		return nil
+	}
+
+	transform, _ := pbr.BaseColorTexture.Extensions[texturetransform.ExtensionName].(*texturetransform.TextureTranform)
+	return transform
+
+}
+
+// applyTextureTransform applies the UV transform to the UV values given (in GLTF's UV space, where V points down), scaling, then rotating,
+// and then offsetting them.
+func applyTextureTransform(transform *texturetransform.TextureTranform, uv [2]float32) [2]float32 {
+
+	scale := transform.ScaleOrDefault()
+	sin, cos := math32.Sincos(float32(transform.Rotation))
+
+	u := uv[0] * float32(scale[0])
+	v := uv[1] * float32(scale[1])
+
+	return [2]float32{
+		cos*u + sin*v + float32(transform.Offset[0]),
+		-sin*u + cos*v + float32(transform.Offset[1]),
+	}
+
+}
+
+// downscaleImage halves the size of the image (averaging each 2x2 block of pixels together) until neither of its sides are
+// larger than maxSize.
+func downscaleImage(img image.Image, maxSize int) image.Image {
+
+	for {
+
+		bounds := img.Bounds()
+		w, h := bounds.Dx(), bounds.Dy()
+
+		if (w <= maxSize && h <= maxSize) || (w <= 1 && h <= 1) {
+			return img
+		}
+
+		newW, newH := max(w/2, 1), max(h/2, 1)
+		scaled := image.NewNRGBA(image.Rect(0, 0, newW, newH))
+
+		for y := 0; y < newH; y++ {
+
+			for x := 0; x < newW; x++ {
+
+				var r, g, b, a uint32
+
+				for _, offset := range [4]image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+					sx := min(x*w/newW+offset.X, w-1)
+					sy := min(y*h/newH+offset.Y, h-1)
+					pr, pg, pb, pa := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a = r+pr, g+pg, b+pb, a+pa
+				}
+
+				// Colors are averaged premultiplied, so transparent pixels don't bleed their color into opaque ones
+				scaled.Set(x, y, color.RGBA64{uint16(r / 4), uint16(g / 4), uint16(b / 4), uint16(a / 4)})
+
+			}
+
+		}
+
+		img = scaled
+
+	}
+
+}
@@ -1,20 +1,38 @@
 package tetra3d
 
 import (
+	"container/heap"
 	"math"
 	"math/rand"
 	"sort"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// GridConnectionType indicates how a GridConnection (or a NavMeshLink) is traversed (i.e. walking, jumping, climbing a ladder,
+// teleporting, etc). You can define your own connection types as well, starting from GridConnectionTypeCustom.
+type GridConnectionType int
+
+const (
+	GridConnectionTypeWalk     GridConnectionType = iota // A regular connection that is walked across; this is the default
+	GridConnectionTypeJump                               // A connection that has to be jumped across, like a gap
+	GridConnectionTypeLadder                             // A connection that has to be climbed, like a ladder
+	GridConnectionTypeTeleport                           // A connection that is instantly traveled across, like a teleporter; the distance between the GridPoints doesn't count towards its cost
+	GridConnectionTypeCustom                             // The first custom connection type; custom types should be GridConnectionTypeCustom, GridConnectionTypeCustom + 1, and so on
 )
 
 // A GridConnection represents a one-way connection from one GridPoint to another.
 type GridConnection struct {
 	To       *GridPoint
 	Passable bool    // Whether the connection should be considered as passable when performing pathfinding.
-	Cost     float32 // The cost of the jump, from one grid point to another. Defaults to 0.
-	length   float32 // the length of the jump from one GridPoint to another.
+	Cost     float32 // The cost of the jump, from one grid point to another, on top of the distance between them. Defaults to 0.
+
+	// Type is how the connection is traversed. Connections that aren't GridConnectionTypeWalk are "off-mesh links"; agents following
+	// a GridPath call PathAgent.OnTraverse before crossing them, and GridPath.Smooth() doesn't smooth across them.
+	// Defaults to GridConnectionTypeWalk.
+	Type GridConnectionType
 }
 
 // Clone the GridConnection.
@@ -23,6 +41,7 @@ func (c *GridConnection) Clone() *GridConnection {
 		To:       c.To,
 		Passable: c.Passable,
 		Cost:     c.Cost,
+		Type:     c.Type,
 	}
 }
 
@@ -35,7 +54,9 @@ type GridPoint struct {
 	Connections       []*GridConnection
 	sortedConnections []*GridConnection
 	prevLink          *GridPoint
+	prevConnection    *GridConnection
 	costSoFar         float32
+	pathClosed        bool
 }
 
 // NewGridPoint creates a new GridPoint.
@@ -96,7 +117,6 @@ func (point *GridPoint) Connect(other *GridPoint) (aToB, bToA *GridConnection) {
 		aToB = &GridConnection{
 			To:       other,
 			Passable: true,
-			length:   point.DistanceTo(other),
 		}
 		point.Connections = append(point.Connections, aToB)
 		point.sortedConnections = append(point.sortedConnections, aToB)
@@ -106,7 +126,6 @@ func (point *GridPoint) Connect(other *GridPoint) (aToB, bToA *GridConnection) {
 		bToA = &GridConnection{
 			To:       point,
 			Passable: true,
-			length:   other.DistanceTo(point),
 		}
 		other.Connections = append(other.Connections, bToA)
 		other.sortedConnections = append(other.sortedConnections, bToA)
@@ -172,9 +191,11 @@ func (point *GridPoint) DisconnectAll() {
 }
 
 // PathTo creates a path going from the GridPoint to the given other GridPoint. The path generated
-// should be the shortest-possible route, taking into account both the cumulative lengths (in units)
-// and costs of individual hops.
+// should be the shortest-possible route, taking into account both the cumulative distances (in units)
+// between GridPoints and costs of individual hops (or whatever the Grid's CostFunction returns, if it's set).
 // If a path is not possible from the starting point to the end point, then PathTo will return nil.
+// The path doesn't include the starting GridPoint itself (unless it's also the goal, in which case the path is just that point).
+// The path goes from GridPoint to GridPoint; use GridPath.Smooth() to skip points that don't need to be visited.
 func (point *GridPoint) PathTo(goal *GridPoint) *GridPath {
 
 	if point.parent == nil || point.parent.Type() != NodeTypeGrid || !point.IsOnSameGrid(goal) {
@@ -183,65 +204,100 @@ func (point *GridPoint) PathTo(goal *GridPoint) *GridPath {
 
 	if point == goal {
 		return &GridPath{
-			GridPoints: []Vector3{point.WorldPosition()},
+			GridPoints:  []Vector3{point.WorldPosition()},
+			Connections: []*GridConnection{nil},
 		}
 	}
 
-	point.parent.(*Grid).ForEachPoint(func(gridPoint *GridPoint) {
+	grid := point.parent.(*Grid)
+
+	goalPos := goal.WorldPosition()
+
+	// Teleport connections cost less than the distance they cover, so the distance to the goal alone could overestimate the cost of paths
+	// using them; the estimate is capped at the distance from the closest teleport destination to the goal, so the shortest path is still found.
+	teleportDistance := float32(math.MaxFloat32)
+
+	grid.ForEachPoint(func(gridPoint *GridPoint) {
 		gridPoint.prevLink = nil
-		gridPoint.costSoFar = 0
+		gridPoint.prevConnection = nil
+		gridPoint.costSoFar = math.MaxFloat32
+		gridPoint.pathClosed = false
+		for _, c := range gridPoint.Connections {
+			if c.Type == GridConnectionTypeTeleport {
+				teleportDistance = math32.Min(teleportDistance, c.To.WorldPosition().Distance(goalPos))
+			}
+		}
 	})
 
-	path := &GridPath{
-		GridPoints: []Vector3{},
+	heuristic := func(gridPoint *GridPoint) float32 {
+		return math32.Min(gridPoint.WorldPosition().Distance(goalPos), teleportDistance)
 	}
 
-	toCheck := []*GridPoint{point}
+	point.costSoFar = 0
 
-	goal.prevLink = nil
-	point.prevLink = nil
+	toCheck := &priorityQueue[*GridPoint]{}
+	heap.Push(toCheck, priorityQueueItem[*GridPoint]{value: point, priority: heuristic(point)})
 
-	var next *GridPoint
+	found := false
 
-	// finishedForLoop:
+	for toCheck.Len() > 0 {
 
-	for {
+		next := heap.Pop(toCheck).(priorityQueueItem[*GridPoint]).value
 
 		if next == goal {
+			found = true
 			break
 		}
 
-		if len(toCheck) == 0 {
-			return nil
+		if next.pathClosed {
+			continue
 		}
 
-		next = toCheck[0]
-		toCheck = toCheck[1:]
+		next.pathClosed = true
 
 		for _, c := range next.Connections {
 
-			if c.Passable {
-				nextCost := next.costSoFar + c.Cost + c.length
-				if c.To != point && (c.To.costSoFar == 0 || c.To.costSoFar > nextCost) {
-					c.To.costSoFar = nextCost
-					c.To.prevLink = next
-					toCheck = append(toCheck, c.To)
-				}
+			// Connections to GridPoints that have since left the Grid are ignored
+			if !c.Passable || c.To.parent != grid || c.To.pathClosed {
+				continue
+			}
+
+			cost := grid.connectionCost(next, c)
+
+			if cost < 0 {
+				continue
+			}
+
+			nextCost := next.costSoFar + cost
+
+			if nextCost < c.To.costSoFar {
+				c.To.costSoFar = nextCost
+				c.To.prevLink = next
+				c.To.prevConnection = c
+				heap.Push(toCheck, priorityQueueItem[*GridPoint]{value: c.To, priority: nextCost + heuristic(c.To)})
 			}
 
 		}
 
-		sort.Slice(toCheck, func(i, j int) bool { return toCheck[i].costSoFar < toCheck[j].costSoFar })
+	}
+
+	if !found {
+		return nil
+	}
 
+	path := &GridPath{
+		GridPoints:  []Vector3{},
+		Connections: []*GridConnection{},
 	}
 
-	for next.prevLink != nil {
+	for next := goal; next.prevLink != nil; next = next.prevLink {
 		path.GridPoints = append(path.GridPoints, next.WorldPosition())
-		next = next.prevLink
+		path.Connections = append(path.Connections, next.prevConnection)
 	}
 
 	for i, j := 0, len(path.GridPoints)-1; i < j; i, j = i+1, j-1 {
 		path.GridPoints[i], path.GridPoints[j] = path.GridPoints[j], path.GridPoints[i]
+		path.Connections[i], path.Connections[j] = path.Connections[j], path.Connections[i]
 	}
 
 	return path
@@ -268,11 +324,25 @@ func (point *GridPoint) Type() NodeType {
 // or simply for connecting points in space (like for a world map in a level-based game, for example).
 type Grid struct {
 	*Node
+
+	// CostFunction, if set, is used to calculate the cost of traveling across a connection from one GridPoint to another when
+	// pathfinding, allowing you to weight connections (i.e. by terrain type). Returning a negative value makes the connection impassable.
+	// If CostFunction is nil, the cost of a connection is the distance between its GridPoints (apart from teleport connections) plus
+	// the connection's Cost, plus the cost for the connection's type in ConnectionTypeCosts.
+	// Note that for GridPoint.PathTo() to find the shortest paths, the cost shouldn't be less than the distance between the GridPoints.
+	CostFunction func(from *GridPoint, connection *GridConnection) float32
+
+	// ConnectionTypeCosts holds additional costs for traversing connections by their type (i.e. making jumping across gaps more costly
+	// than walking). These costs are ignored if CostFunction is set.
+	ConnectionTypeCosts map[GridConnectionType]float32
 }
 
 // NewGrid creates a new Grid.
 func NewGrid(name string) *Grid {
-	g := &Grid{Node: NewNode(name)}
+	g := &Grid{
+		Node:                NewNode(name),
+		ConnectionTypeCosts: map[GridConnectionType]float32{},
+	}
 	g.owner = g
 	return g
 }
@@ -280,7 +350,14 @@ func NewGrid(name string) *Grid {
 // Clone creates a clone of this GridPoint.
 func (grid *Grid) Clone() INode {
 
-	newGrid := &Grid{}
+	newGrid := &Grid{
+		CostFunction:        grid.CostFunction,
+		ConnectionTypeCosts: map[GridConnectionType]float32{},
+	}
+
+	for connectionType, cost := range grid.ConnectionTypeCosts {
+		newGrid.ConnectionTypeCosts[connectionType] = cost
+	}
 	newGrid.Node = grid.Node.clone(newGrid).(*Node)
 
 	for _, child := range newGrid.children {
@@ -308,6 +385,18 @@ func (grid *Grid) Clone() INode {
 	return newGrid
 }
 
+// connectionCost returns the cost of traveling across the given connection from the GridPoint provided.
+func (grid *Grid) connectionCost(from *GridPoint, connection *GridConnection) float32 {
+	if grid.CostFunction != nil {
+		return grid.CostFunction(from, connection)
+	}
+	cost := connection.Cost + grid.ConnectionTypeCosts[connection.Type]
+	if connection.Type != GridConnectionTypeTeleport {
+		cost += from.WorldPosition().Distance(connection.To.WorldPosition())
+	}
+	return cost
+}
+
 // Points returns a slice of the children nodes that constitute this Grid's GridPoints.
 func (grid *Grid) Points() []*GridPoint {
 	points := make([]*GridPoint, 0, len(grid.children))
@@ -563,6 +652,20 @@ func (grid *Grid) Type() NodeType {
 // GridPath implements IPath.
 type GridPath struct {
 	GridPoints []Vector3
+
+	// Connections holds the GridConnection traversed to reach each point in GridPoints from the one before it (or from the starting
+	// GridPoint, for the first point), so it's the same length as GridPoints. Points that are reached by skipping other points after
+	// smoothing the path with GridPath.Smooth() have nil connections as well.
+	Connections []*GridConnection
+}
+
+// Connection returns the GridConnection that is traversed to reach the point at the given index in the path from the point before it.
+// If there's no such connection (i.e. for a point that is reached by skipping others after smoothing), Connection returns nil.
+func (gp *GridPath) Connection(pointIndex int) *GridConnection {
+	if pointIndex < 0 || pointIndex >= len(gp.Connections) {
+		return nil
+	}
+	return gp.Connections[pointIndex]
 }
 
 // Length returns the length of the overall path.
@@ -597,6 +700,79 @@ func (gp *GridPath) HopCount() int {
 	return len(gp.GridPoints) - 1
 }
 
+// Smooth smooths the GridPath by removing points that can be skipped using line-of-sight tests, so agents walking the path
+// go directly from point to point, rather than following each hop of the Grid. See SmoothPath() for more information.
+// Connections that aren't GridConnectionTypeWalk (jumps, ladders, etc) are kept as they are, with the path only being smoothed
+// between them.
+func (gp *GridPath) Smooth(options PathSmoothOptions) {
+
+	if len(gp.Connections) != len(gp.GridPoints) {
+		gp.GridPoints = SmoothPath(gp.GridPoints, options)
+		gp.Connections = nil
+		return
+	}
+
+	points := make([]Vector3, 0, len(gp.GridPoints))
+	connections := make([]*GridConnection, 0, len(gp.GridPoints))
+
+	start := 0
+
+	for i := 1; i <= len(gp.GridPoints); i++ {
+
+		// Smooth each stretch of walkable connections separately, stopping at special connections
+		if i < len(gp.GridPoints) && (gp.Connections[i] == nil || gp.Connections[i].Type == GridConnectionTypeWalk) {
+			continue
+		}
+
+		prev := -1
+
+		for _, index := range smoothPathIndices(gp.GridPoints[start:i], options) {
+
+			index += start
+			points = append(points, gp.GridPoints[index])
+
+			if index == start || index == prev+1 {
+				connections = append(connections, gp.Connections[index])
+			} else {
+				connections = append(connections, nil)
+			}
+
+			prev = index
+
+		}
+
+		start = i
+
+	}
+
+	gp.GridPoints = points
+	gp.Connections = connections
+
+}
+
+// Segments returns the hops of the path from each point to the next, along with how each is traversed (using the type of the
+// GridConnection crossed, or GridConnectionTypeWalk for shortcuts made by smoothing the path).
+func (gp *GridPath) Segments() []PathSegment {
+
+	segments := make([]PathSegment, 0, len(gp.GridPoints))
+
+	for i := 1; i < len(gp.GridPoints); i++ {
+
+		segment := PathSegment{Start: gp.GridPoints[i-1], End: gp.GridPoints[i]}
+
+		if connection := gp.Connection(i); connection != nil {
+			segment.Type = connection.Type
+			segment.Connection = connection
+		}
+
+		segments = append(segments, segment)
+
+	}
+
+	return segments
+
+}
+
 func (gp *GridPath) isClosed() bool {
 	return false
 }
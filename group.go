@@ -0,0 +1,96 @@
+package tetra3d
+
+// Group is a Node that groups other Nodes together under it, allowing you to control them in bulk. When loading a GLTF file exported
+// from Blender with GLTFLoadOptions.LoadCollectionsAsGroups set, each collection in a scene becomes a Group, with the objects
+// in the collection parented to it (and child collections becoming child Groups).
+type Group struct {
+	*Node
+	enabled         bool
+	savedVisibility map[INode]bool // The visibility of the Group's descendants when the Group was disabled, to be restored when re-enabled
+}
+
+// NewGroup creates a new, enabled Group with the given name.
+func NewGroup(name string) *Group {
+	group := &Group{
+		Node:    NewNode(name),
+		enabled: true,
+	}
+	group.owner = group
+	return group
+}
+
+// Clone creates a clone of the Group and its children.
+func (group *Group) Clone() INode {
+
+	clone := NewGroup(group.name)
+	clone.enabled = group.enabled
+
+	clone.Node = group.Node.clone(clone).(*Node)
+
+	// The clone's tree is laid out the same as the original's, so the saved visibility is moved onto the cloned descendants by their order.
+	if group.savedVisibility != nil {
+
+		clone.savedVisibility = map[INode]bool{}
+
+		originals := group.SearchTree().INodes()
+		clones := clone.SearchTree().INodes()
+
+		for i, node := range originals {
+			if visible, saved := group.savedVisibility[node]; saved && i < len(clones) {
+				clone.savedVisibility[clones[i]] = visible
+			}
+		}
+
+	}
+
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+
+	return clone
+
+}
+
+// Enabled returns if the Group is enabled.
+func (group *Group) Enabled() bool {
+	return group.enabled
+}
+
+// SetEnabled enables or disables the Group. Disabling a Group hides all of the Nodes under it (so they won't render); enabling the Group
+// again restores the visibility each Node had when the Group was disabled.
+func (group *Group) SetEnabled(enabled bool) {
+
+	if group.enabled == enabled {
+		return
+	}
+
+	group.enabled = enabled
+
+	if !enabled {
+
+		group.savedVisibility = map[INode]bool{}
+
+		group.SearchTree().ForEach(func(node INode) bool {
+			group.savedVisibility[node] = node.Visible()
+			node.SetVisible(false, false)
+			return true
+		})
+
+	} else {
+
+		group.SearchTree().ForEach(func(node INode) bool {
+			visible, saved := group.savedVisibility[node]
+			node.SetVisible(visible || !saved, false)
+			return true
+		})
+
+		group.savedVisibility = nil
+
+	}
+
+}
+
+// Type returns the NodeType for this object.
+func (group *Group) Type() NodeType {
+	return NodeTypeGroup
+}
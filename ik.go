@@ -0,0 +1,219 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// IKTwoBone is an inverse kinematics solver for two-bone chains, like arms (upper arm > forearm > hand) or legs
+// (thigh > shin > foot). When applied, it rotates the Upper and Lower bones so that the End bone reaches towards the
+// target position, bending in the direction of the Pole Node (if one is set).
+type IKTwoBone struct {
+	Upper INode // The first bone in the chain (i.e. the thigh or upper arm). This bone rotates, but doesn't move.
+	Lower INode // The second bone in the chain (i.e. the shin or forearm); this should be a child of Upper.
+	End   INode // The end of the chain (i.e. the foot or hand); this should be a child of Lower. This is what reaches towards the target.
+
+	TargetNode     INode   // If set, the solver reaches towards this Node's world position; otherwise, TargetPosition is used.
+	TargetPosition Vector3 // The world position to reach towards if TargetNode is nil.
+
+	// Pole is an optional Node that controls which direction the chain bends towards (i.e. the knee or elbow points towards the Pole).
+	// If Pole is nil, the chain bends in whichever direction it's already bending in.
+	Pole INode
+
+	Influence float32 // How strongly the solver affects the chain, ranging from 0 to 1. Defaults to 1.
+	Enabled   bool    // Whether the solver is enabled or not. Defaults to true.
+}
+
+// NewIKTwoBone creates a new IKTwoBone solver for the given bone chain, reaching towards the target Node (which can be nil
+// if you want to set the target position manually through IKTwoBone.TargetPosition).
+func NewIKTwoBone(upper, lower, end, target INode) *IKTwoBone {
+	return &IKTwoBone{
+		Upper:      upper,
+		Lower:      lower,
+		End:        end,
+		TargetNode: target,
+		Influence:  1,
+		Enabled:    true,
+	}
+}
+
+// Target returns the world position that the solver is reaching towards.
+func (ik *IKTwoBone) Target() Vector3 {
+	if ik.TargetNode != nil {
+		return ik.TargetNode.WorldPosition()
+	}
+	return ik.TargetPosition
+}
+
+// Apply applies the IK solver to its bone chain.
+func (ik *IKTwoBone) Apply() {
+
+	if !ik.Enabled || ik.Influence <= 0 || ik.Upper == nil || ik.Lower == nil || ik.End == nil {
+		return
+	}
+
+	a := ik.Upper.WorldPosition()
+	b := ik.Lower.WorldPosition()
+	c := ik.End.WorldPosition()
+	target := ik.Target()
+
+	upperLength := b.Distance(a)
+	lowerLength := c.Distance(b)
+
+	if upperLength == 0 || lowerLength == 0 {
+		return
+	}
+
+	toTarget := target.Sub(a)
+	dir := toTarget.Unit()
+
+	if dir.IsZero() {
+		return
+	}
+
+	// We can't reach further than the chain is long, nor closer than the difference in bone lengths.
+	margin := float32(0.0001)
+	targetDist := math32.Clamp(toTarget.Magnitude(), math32.Abs(upperLength-lowerLength)+margin, upperLength+lowerLength-margin)
+
+	// The bend direction is the pole (or the current middle joint) direction, flattened to be perpendicular to the target direction.
+	bend := b.Sub(a)
+	if ik.Pole != nil {
+		bend = ik.Pole.WorldPosition().Sub(a)
+	}
+	bend = bend.Sub(dir.Scale(bend.Dot(dir))).Unit()
+
+	if bend.IsZero() {
+		bend = dir.Cross(WorldRight)
+		if bend.IsZero() {
+			bend = dir.Cross(WorldBackward)
+		}
+		bend = bend.Unit()
+	}
+
+	// Law of cosines to get the angle at the upper joint
+	cosAngle := math32.Clamp((upperLength*upperLength+targetDist*targetDist-lowerLength*lowerLength)/(2*upperLength*targetDist), -1, 1)
+	sinAngle := math32.Sqrt(1 - cosAngle*cosAngle)
+
+	desiredB := a.Add(dir.Scale(upperLength * cosAngle)).Add(bend.Scale(upperLength * sinAngle))
+	desiredC := a.Add(dir.Scale(targetDist))
+
+	rotateTowards(ik.Upper, b.Sub(a), desiredB.Sub(a), ik.Influence)
+
+	// Rotating the upper bone moves the lower bone and the end, so we re-check their positions.
+	b = ik.Lower.WorldPosition()
+	c = ik.End.WorldPosition()
+
+	rotateTowards(ik.Lower, c.Sub(b), desiredC.Sub(b), ik.Influence)
+
+}
+
+// IKChain is an inverse kinematics solver that uses the FABRIK (Forward And Backward Reaching Inverse Kinematics)
+// algorithm to make a chain of bones of any length reach towards a target. This is good for tails, tentacles, spines, or
+// other longer bone chains.
+type IKChain struct {
+	// Bones is the chain of bones to solve, ordered from the root of the chain to the tip. Each bone should be a descendant
+	// of the previous one. The first bone rotates, but doesn't move; the last bone is what reaches towards the target.
+	Bones []INode
+
+	TargetNode     INode   // If set, the solver reaches towards this Node's world position; otherwise, TargetPosition is used.
+	TargetPosition Vector3 // The world position to reach towards if TargetNode is nil.
+
+	Iterations int     // The maximum number of iterations to perform when solving. Defaults to 10.
+	Tolerance  float32 // How close the tip of the chain needs to be to the target to stop iterating early. Defaults to 0.001.
+	Influence  float32 // How strongly the solver affects the chain, ranging from 0 to 1. Defaults to 1.
+	Enabled    bool    // Whether the solver is enabled or not. Defaults to true.
+
+	positions []Vector3
+	lengths   []float32
+}
+
+// NewIKChain creates a new IKChain solver using the provided target Node (which can be nil if you want to set the
+// target position manually through IKChain.TargetPosition) and bone chain, ordered from root to tip.
+func NewIKChain(target INode, bones ...INode) *IKChain {
+	return &IKChain{
+		Bones:      bones,
+		TargetNode: target,
+		Iterations: 10,
+		Tolerance:  0.001,
+		Influence:  1,
+		Enabled:    true,
+	}
+}
+
+// Target returns the world position that the solver is reaching towards.
+func (ik *IKChain) Target() Vector3 {
+	if ik.TargetNode != nil {
+		return ik.TargetNode.WorldPosition()
+	}
+	return ik.TargetPosition
+}
+
+// Apply applies the IK solver to its bone chain.
+func (ik *IKChain) Apply() {
+
+	if !ik.Enabled || ik.Influence <= 0 || len(ik.Bones) < 2 {
+		return
+	}
+
+	ik.positions = ik.positions[:0]
+	ik.lengths = ik.lengths[:0]
+
+	totalLength := float32(0)
+
+	for i, bone := range ik.Bones {
+		ik.positions = append(ik.positions, bone.WorldPosition())
+		if i > 0 {
+			length := ik.positions[i].Distance(ik.positions[i-1])
+			ik.lengths = append(ik.lengths, length)
+			totalLength += length
+		}
+	}
+
+	if totalLength == 0 {
+		return
+	}
+
+	target := ik.Target()
+	root := ik.positions[0]
+	last := len(ik.positions) - 1
+
+	if root.Distance(target) >= totalLength {
+
+		// The target is out of reach, so we just stretch out towards it.
+		dir := target.Sub(root).Unit()
+		for i := 1; i < len(ik.positions); i++ {
+			ik.positions[i] = ik.positions[i-1].Add(dir.Scale(ik.lengths[i-1]))
+		}
+
+	} else {
+
+		for iter := 0; iter < ik.Iterations; iter++ {
+
+			if ik.positions[last].Distance(target) <= ik.Tolerance {
+				break
+			}
+
+			// Backward pass: place the tip on the target and work back towards the root
+			ik.positions[last] = target
+			for i := last - 1; i >= 0; i-- {
+				dir := ik.positions[i].Sub(ik.positions[i+1]).Unit()
+				ik.positions[i] = ik.positions[i+1].Add(dir.Scale(ik.lengths[i]))
+			}
+
+			// Forward pass: place the root back where it belongs and work out towards the tip
+			ik.positions[0] = root
+			for i := 1; i <= last; i++ {
+				dir := ik.positions[i].Sub(ik.positions[i-1]).Unit()
+				ik.positions[i] = ik.positions[i-1].Add(dir.Scale(ik.lengths[i-1]))
+			}
+
+		}
+
+	}
+
+	// Now rotate each bone to point towards its solved child position; each rotation moves all of the bones
+	// further down the chain, so we have to get their current positions as we go.
+	for i := 0; i < last; i++ {
+		bonePos := ik.Bones[i].WorldPosition()
+		childPos := ik.Bones[i+1].WorldPosition()
+		rotateTowards(ik.Bones[i], childPos.Sub(bonePos), ik.positions[i+1].Sub(bonePos), ik.Influence)
+	}
+
+}
@@ -1,6 +1,15 @@
 package tetra3d
 
+import "time"
+
 // Library represents a collection of Scenes, Meshes, Animations, etc., as loaded from an intermediary file format (.dae or .gltf / .glb).
+//
+// A Library's Meshes and Materials can be shared between any number of Scenes and Cameras, including Cameras rendering from different
+// goroutines or game contexts (i.e. multiple windows); each Camera renders with its own buffers, and
+// the processing of shared resources is serialized internally, so they won't be corrupted.
+// Each Scene (and the Nodes in it) is owned by whoever updates it, though - a Scene shouldn't be modified on one goroutine while it is being
+// rendered on another. Modifying a shared Mesh or Material (i.e. changing its vertices or shader) should likewise only be done
+// when it isn't being rendered.
 type Library struct {
 	Scenes        []*Scene              // A slice of Scenes
 	ExportedScene *Scene                // The scene that was open when the library was exported from the modeler
@@ -8,6 +17,13 @@ type Library struct {
 	Animations    map[string]*Animation // A Map of Animations to their names
 	Materials     map[string]*Material  // A Map of Materials to their names
 	Worlds        map[string]*World     // A Map of Worlds to their names
+	Prefabs       map[string]*Prefab    // A Map of Prefabs to their names; see Library.RegisterPrefab()
+
+	loadOptions       *GLTFLoadOptions // The options the Library was loaded with, for reloading it; see Library.Reload()
+	finalizeTasks     []func() error   // Work left to do to finalize the Library's resources; see Library.FinalizeStep()
+	finalizeTotal     int
+	finalizeTasksDone int
+	meshBuilds        map[*Mesh]func() // Meshes whose vertices and triangles have yet to be created; see Library.buildMesh()
 }
 
 // NewLibrary creates a new Library.
@@ -18,7 +34,138 @@ func NewLibrary() *Library {
 		Animations: map[string]*Animation{},
 		Materials:  map[string]*Material{},
 		Worlds:     map[string]*World{},
+		Prefabs:    map[string]*Prefab{},
+	}
+}
+
+func (lib *Library) addFinalizeTask(task func() error) {
+	lib.finalizeTasks = append(lib.finalizeTasks, task)
+	lib.finalizeTotal++
+}
+
+// deferMeshBuild defers creating the given Mesh's vertices and triangles using the given function until the Library is finalized.
+func (lib *Library) deferMeshBuild(mesh *Mesh, build func()) {
+	if lib.meshBuilds == nil {
+		lib.meshBuilds = map[*Mesh]func(){}
+	}
+	lib.meshBuilds[mesh] = build
+	lib.addFinalizeTask(func() error {
+		lib.buildMesh(mesh)
+		return nil
+	})
+}
+
+// buildMesh creates the given Mesh's vertices and triangles if they were deferred and haven't been created yet. This is used while
+// loading for objects that need a Mesh's data immediately (i.e. BoundingTriangles or skinned Models).
+func (lib *Library) buildMesh(mesh *Mesh) {
+	if lib == nil {
+		return
+	}
+	if build, exists := lib.meshBuilds[mesh]; exists {
+		delete(lib.meshBuilds, mesh)
+		build()
+	}
+}
+
+// buildMeshes creates the vertices and triangles of all of the Library's Meshes that were deferred.
+func (lib *Library) buildMeshes() {
+	for mesh := range lib.meshBuilds {
+		lib.buildMesh(mesh)
+	}
+}
+
+// FinalizeStep performs the work left to finalize the Library's resources (i.e. creating its textures and its Meshes' vertices and
+// triangles when the Library was loaded with GLTFLoadOptions.DeferFinalization set) until the given time budget has been used up, so the work can be spread across frames
+// behind a loading screen. At least one piece of work is done on each call, so calling FinalizeStep repeatedly always finishes eventually.
+// FinalizeStep returns true once the Library is completely finalized, and an error if a piece of work fails (i.e. a texture couldn't
+// be decoded); the failed piece of work is skipped, so FinalizeStep can be called again to continue.
+// FinalizeStep should be called from the game's goroutine (i.e. in your game's Update() function), and the Library's Scenes shouldn't
+// be rendered until it's finalized.
+func (lib *Library) FinalizeStep(budget time.Duration) (bool, error) {
+
+	start := time.Now()
+
+	for len(lib.finalizeTasks) > 0 {
+
+		task := lib.finalizeTasks[0]
+		lib.finalizeTasks[0] = nil
+		lib.finalizeTasks = lib.finalizeTasks[1:]
+		lib.finalizeTasksDone++
+
+		if err := task(); err != nil {
+			return len(lib.finalizeTasks) == 0, err
+		}
+
+		if time.Since(start) >= budget {
+			break
+		}
+
+	}
+
+	return len(lib.finalizeTasks) == 0, nil
+
+}
+
+// Finalize finishes all of the work left to finalize the Library's resources at once. See Library.FinalizeStep() for more information.
+// Finalize returns the first error encountered, if any.
+func (lib *Library) Finalize() error {
+
+	var firstErr error
+
+	for {
+		done, err := lib.FinalizeStep(time.Hour)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if done {
+			return firstErr
+		}
+	}
+
+}
+
+// FinalizeProgress returns how much of the work to finalize the Library's resources has been done, ranging from 0 to 1.
+// This is useful for displaying a loading bar. If the Library has nothing to finalize, this returns 1.
+func (lib *Library) FinalizeProgress() float32 {
+	if lib.finalizeTotal == 0 {
+		return 1
+	}
+	return float32(lib.finalizeTasksDone) / float32(lib.finalizeTotal)
+}
+
+// Finalized returns if the Library has no more work left to finalize its resources.
+func (lib *Library) Finalized() bool {
+	return len(lib.finalizeTasks) == 0
+}
+
+// Dispose frees the Library's resources, disposing of its Materials' textures and custom shaders (as compiled through
+// Material.SetShaderText()), and removing its Scenes, Meshes,
+// Animations, Materials, Worlds, and Prefabs from it so they can be garbage collected. This is useful for unloading a Library (i.e. a
+// streamed piece of a level) that's no longer needed. Nothing loaded from the Library (including clones of its Nodes) should be used
+// or rendered afterwards, as they share its textures.
+func (lib *Library) Dispose() {
+
+	for _, mat := range lib.Materials {
+		if mat.Texture != nil {
+			mat.Texture.Dispose()
+			mat.Texture = nil
+		}
+		// Shaders set through Material.SetShader() may be shared with other Materials, so only those compiled for the Material are disposed
+		if mat.fragmentSrc != nil {
+			mat.DisposeShader()
+		}
 	}
+
+	lib.Scenes = []*Scene{}
+	lib.ExportedScene = nil
+	lib.Meshes = map[string]*Mesh{}
+	lib.Animations = map[string]*Animation{}
+	lib.Materials = map[string]*Material{}
+	lib.Worlds = map[string]*World{}
+	lib.Prefabs = map[string]*Prefab{}
+	lib.finalizeTasks = nil
+	lib.meshBuilds = nil
+
 }
 
 // SceneByName searches all scenes in a Library to find the one with the provided name. If a scene with the given name isn't found,
@@ -39,6 +186,19 @@ func (lib *Library) AddScene(sceneName string) *Scene {
 	return newScene
 }
 
+// RegisterPrefab registers the given Node (and its tree) as a Prefab in the Library under the given name, replacing any Prefab already
+// registered under that name, and returns the Prefab.
+func (lib *Library) RegisterPrefab(name string, prototype INode) *Prefab {
+	prefab := NewPrefab(name, prototype)
+	lib.Prefabs[name] = prefab
+	return prefab
+}
+
+// PrefabByName returns the Prefab registered in the Library under the given name. If there's no such Prefab, PrefabByName will return nil.
+func (lib *Library) PrefabByName(name string) *Prefab {
+	return lib.Prefabs[name]
+}
+
 // NodeByName allows you to find a node by name by searching through each of a Library's scenes. If the Node with the given name isn't found,
 // NodeByName will return nil.
 func (lib *Library) NodeByName(objectName string) INode {
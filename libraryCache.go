@@ -0,0 +1,117 @@
+package tetra3d
+
+import (
+	"fmt"
+	"path"
+	"sync"
+)
+
+// LibraryCache memoizes the dependent Libraries resolved while loading GLTF files (see GLTFLoadOptions.DependentLibraryCache), so that
+// each dependent Library is only loaded once, no matter how many Libraries link objects from it. Libraries are keyed by their cleaned
+// blend file paths, as linked in Blender, and are loaded using the load function given to NewLibraryCache().
+// Each Library is loaded with its own LibraryCache (sharing the cached Libraries) that knows which Libraries are being loaded to resolve
+// it, so cyclic dependencies are detected; the load function should set that LibraryCache as the GLTFLoadOptions.DependentLibraryCache
+// of the Library it loads. Load the root Library (i.e. the level) through LibraryCache.Load() as well, so that links back to it are
+// detected as cyclic, rather than loading it again.
+type LibraryCache struct {
+	shared    *libraryCacheStore
+	resolving []string // The paths of the Libraries being loaded by the call this LibraryCache was given to, for detecting cyclic dependencies
+}
+
+// libraryCacheStore is the state shared by a LibraryCache and the LibraryCaches it gives to its load function.
+type libraryCacheStore struct {
+	mutex     sync.Mutex
+	libraries map[string]*Library
+	load      func(blendPath string, cache *LibraryCache) (*Library, error)
+}
+
+// NewLibraryCache creates a new, empty LibraryCache that loads the Library for a blend file path using the given load function. The load
+// function is given the LibraryCache to load the Library's own dependent Libraries with (see LibraryCache). If it returns an error,
+// the Library isn't cached, and the objects linked from it aren't instantiated.
+func NewLibraryCache(load func(blendPath string, cache *LibraryCache) (*Library, error)) *LibraryCache {
+	return &LibraryCache{
+		shared: &libraryCacheStore{
+			libraries: map[string]*Library{},
+			load:      load,
+		},
+	}
+}
+
+// Load returns the Library cached under the given blend file path, loading it using the LibraryCache's load function if it isn't
+// cached yet. Load returns an error if the Library is already being loaded by this LibraryCache (i.e. it depends on itself, directly
+// or through other Libraries), or if the load function fails.
+func (cache *LibraryCache) Load(blendPath string) (*Library, error) {
+
+	key := path.Clean(blendPath)
+
+	if library, exists := cache.cached(key); exists {
+		return library, nil
+	}
+
+	for _, resolving := range cache.resolving {
+		if resolving == key {
+			return nil, fmt.Errorf("dependent library [%s] is already being resolved; it depends on itself", key)
+		}
+	}
+
+	// The Library is marked as being resolved before it's loaded, so that links back to it from its own dependent Libraries are detected
+	loading := &LibraryCache{
+		shared:    cache.shared,
+		resolving: append(cache.resolving[:len(cache.resolving):len(cache.resolving)], key),
+	}
+
+	// The mutex isn't held while loading, as loading the Library resolves its own dependent Libraries
+	library, err := cache.shared.load(blendPath, loading)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cache.shared.mutex.Lock()
+	defer cache.shared.mutex.Unlock()
+
+	// If the Library was loaded concurrently elsewhere, the first one cached is used, so every link shares the same Library.
+	if existing, exists := cache.shared.libraries[key]; exists && existing != nil {
+		return existing, nil
+	}
+
+	cache.shared.libraries[key] = library
+
+	return library, nil
+
+}
+
+func (cache *LibraryCache) cached(key string) (*Library, bool) {
+	cache.shared.mutex.Lock()
+	defer cache.shared.mutex.Unlock()
+	library, exists := cache.shared.libraries[key]
+	return library, exists
+}
+
+// Library returns the Library cached under the given blend file path, or nil if there isn't one.
+func (cache *LibraryCache) Library(blendPath string) *Library {
+	library, _ := cache.cached(path.Clean(blendPath))
+	return library
+}
+
+// Add caches the given Library under the given blend file path, so it's used rather than loading the dependent Library at that path.
+// This is useful for adding a Library that was loaded manually (i.e. a shared assets Library that's loaded first).
+func (cache *LibraryCache) Add(blendPath string, library *Library) {
+	cache.shared.mutex.Lock()
+	defer cache.shared.mutex.Unlock()
+	cache.shared.libraries[path.Clean(blendPath)] = library
+}
+
+// Remove removes the Library cached under the given blend file path, so it's loaded again the next time it's needed.
+func (cache *LibraryCache) Remove(blendPath string) {
+	cache.shared.mutex.Lock()
+	defer cache.shared.mutex.Unlock()
+	delete(cache.shared.libraries, path.Clean(blendPath))
+}
+
+// Clear removes all Libraries from the LibraryCache.
+func (cache *LibraryCache) Clear() {
+	cache.shared.mutex.Lock()
+	defer cache.shared.mutex.Unlock()
+	cache.shared.libraries = map[string]*Library{}
+}
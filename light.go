@@ -173,7 +173,7 @@ func (p *PointLight) beginModel(model *Model) {
 	// point light's position by the inversion of the model's transform to get the same effect and save processing time.
 	// The same technique is used for Sphere - Triangle collision in bounds.go.
 
-	if model.skinned {
+	if model.deformed() {
 		p.workingPosition = p.WorldPosition()
 	} else {
 		p.workingPosition = rot.MultVec(p.WorldPosition()).Add(pos.Mult(Vector3{1 / sca.X, 1 / sca.Y, 1 / sca.Z}))
@@ -194,7 +194,7 @@ func (p *PointLight) Light(meshPart *MeshPart, model *Model, targetColors Vertex
 
 		var vertPos, vertNormal Vector3
 
-		if model.skinned {
+		if model.deformed() {
 			vertPos = model.Mesh.vertexSkinnedPositions[index]
 			vertNormal = model.Mesh.vertexSkinnedNormals[index]
 		} else {
@@ -344,7 +344,7 @@ func (sun *DirectionalLight) beginRender() {
 }
 
 func (sun *DirectionalLight) beginModel(model *Model) {
-	if !model.skinned {
+	if !model.deformed() {
 		sun.workingModelRotation = model.WorldRotation().Inverted().Transposed()
 	}
 }
@@ -355,7 +355,7 @@ func (sun *DirectionalLight) Light(meshPart *MeshPart, model *Model, targetColor
 	meshPart.ForEachVertexIndex(func(index int) {
 
 		var normal Vector3
-		if model.skinned {
+		if model.deformed() {
 			// If it's skinned, we don't have to calculate the normal, as that's been pre-calc'd for us
 			normal = model.Mesh.vertexSkinnedNormals[index]
 		} else {
@@ -547,7 +547,7 @@ func (cube *CubeLight) beginModel(model *Model) {
 
 	cube.workingDimensions = cube.TransformedDimensions()
 
-	if model.skinned {
+	if model.deformed() {
 		cube.workingPosition = lightStartPos
 	} else {
 
@@ -603,7 +603,7 @@ func (cube *CubeLight) Light(meshPart *MeshPart, model *Model, targetColors Vert
 
 		var vertPos, vertNormal Vector3
 
-		if model.skinned {
+		if model.deformed() {
 			vertPos = model.Mesh.vertexSkinnedPositions[index]
 			vertNormal = model.Mesh.vertexSkinnedNormals[index]
 		} else {
@@ -0,0 +1,350 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// MarchingCubesSettings controls how Meshes are generated from signed distance functions or scalar grids using marching cubes.
+type MarchingCubesSettings struct {
+	Min, Max Vector3 // The corners of the region of space to generate the Mesh in.
+
+	// CellSize is the size of each cube sampled when generating a Mesh from a signed distance function; smaller cells give more detail,
+	// but take longer to generate and create more triangles. Defaults to 0.25 if left at 0. This isn't used for scalar grids, as the
+	// grid's size determines its cells instead.
+	CellSize float32
+
+	// IsoLevel is the value at which the surface lies. Values less than IsoLevel are considered inside the surface (as is the case for
+	// signed distance functions). For fields that are greater on the inside (like metaballs), negate the values or the function.
+	// Defaults to 0.
+	IsoLevel float32
+
+	Material *Material // The Material to use for the generated Mesh's MeshParts.
+}
+
+// NewMeshFromSDF creates a new Mesh with the given name by sampling the signed distance function provided within the region specified in the settings,
+// and generating the surface where the function crosses the IsoLevel using marching cubes. The function should return negative values for positions
+// inside the surface and positive values for positions outside of it. The generated vertices have normals, but no UV values.
+// Note that any part of the surface crossing the boundaries of the region will be left open.
+func NewMeshFromSDF(name string, sdf func(position Vector3) float32, settings MarchingCubesSettings) *Mesh {
+
+	cellSize := settings.CellSize
+	if cellSize <= 0 {
+		cellSize = 0.25
+	}
+
+	size := settings.Max.Sub(settings.Min)
+
+	sizeX := int(math32.Ceil(math32.Abs(size.X)/cellSize)) + 1
+	sizeY := int(math32.Ceil(math32.Abs(size.Y)/cellSize)) + 1
+	sizeZ := int(math32.Ceil(math32.Abs(size.Z)/cellSize)) + 1
+
+	values := make([]float32, sizeX*sizeY*sizeZ)
+
+	for z := 0; z < sizeZ; z++ {
+		for y := 0; y < sizeY; y++ {
+			for x := 0; x < sizeX; x++ {
+				values[x+y*sizeX+z*sizeX*sizeY] = sdf(settings.Min.Add(Vector3{float32(x), float32(y), float32(z)}.Scale(cellSize)))
+			}
+		}
+	}
+
+	positions, normals, indices := marchCubes(values, sizeX, sizeY, sizeZ, settings.Min, Vector3{cellSize, cellSize, cellSize}, settings.IsoLevel)
+
+	return newMarchingCubesMesh(name, positions, normals, indices, settings.Material)
+
+}
+
+// NewMeshFromScalarGrid creates a new Mesh with the given name from a 3D grid of scalar values, generating the surface where the values cross
+// the settings' IsoLevel using marching cubes. values should contain sizeX * sizeY * sizeZ values, indexed by x + (y * sizeX) + (z * sizeX * sizeY);
+// the grid is stretched to span the region from the settings' Min to Max. If the number of values doesn't match the grid size, NewMeshFromScalarGrid will panic.
+// The generated vertices have normals, but no UV values.
+func NewMeshFromScalarGrid(name string, values []float32, sizeX, sizeY, sizeZ int, settings MarchingCubesSettings) *Mesh {
+
+	if len(values) != sizeX*sizeY*sizeZ {
+		panic("Error: NewMeshFromScalarGrid() given a number of values that doesn't match the size of the grid.")
+	}
+
+	cellSize := settings.Max.Sub(settings.Min)
+	cellSize.X /= float32(math32.Max(sizeX-1, 1))
+	cellSize.Y /= float32(math32.Max(sizeY-1, 1))
+	cellSize.Z /= float32(math32.Max(sizeZ-1, 1))
+
+	positions, normals, indices := marchCubes(values, sizeX, sizeY, sizeZ, settings.Min, cellSize, settings.IsoLevel)
+
+	return newMarchingCubesMesh(name, positions, normals, indices, settings.Material)
+
+}
+
+// newMarchingCubesMesh creates a Mesh from the results of marchCubes(), splitting the triangles up into as many MeshParts as necessary
+// to stay under the maximum renderable triangle count for each MeshPart.
+func newMarchingCubesMesh(name string, positions, normals []Vector3, indices []int, material *Material) *Mesh {
+
+	mesh := NewMesh(name)
+
+	remap := map[int]int{}
+	verts := []VertexInfo{}
+	partIndices := []int{}
+
+	flush := func() {
+		if len(partIndices) == 0 {
+			return
+		}
+		mesh.AddVertices(verts...)
+		mesh.AddMeshPart(material, partIndices...)
+		clear(remap)
+		verts = verts[:0]
+		partIndices = partIndices[:0]
+	}
+
+	for i := 0; i < len(indices); i += 3 {
+
+		if len(partIndices)/3 >= MaxTriangleCount-1 {
+			flush()
+		}
+
+		for _, index := range indices[i : i+3] {
+
+			newIndex, exists := remap[index]
+
+			if !exists {
+				p := positions[index]
+				n := normals[index]
+				v := NewVertex(p.X, p.Y, p.Z, 0, 0)
+				v.NormalX = n.X
+				v.NormalY = n.Y
+				v.NormalZ = n.Z
+				newIndex = len(verts)
+				verts = append(verts, v)
+				remap[index] = newIndex
+			}
+
+			partIndices = append(partIndices, newIndex)
+
+		}
+
+	}
+
+	flush()
+
+	mesh.UpdateBounds()
+
+	return mesh
+
+}
+
+// marchCubes generates the triangles for the surface running through the given grid of values where they cross the iso level,
+// returning the vertex positions and normals, and the indices of the triangles' vertices. Vertices are shared between neighboring triangles.
+func marchCubes(values []float32, sizeX, sizeY, sizeZ int, origin, cellSize Vector3, isoLevel float32) (positions, normals []Vector3, indices []int) {
+
+	if sizeX < 2 || sizeY < 2 || sizeZ < 2 {
+		return nil, nil, nil
+	}
+
+	value := func(x, y, z int) float32 {
+		return values[x+y*sizeX+z*sizeX*sizeY]
+	}
+
+	// The gradient of the field at a grid point, which points from the inside of the surface towards the outside.
+	gradient := func(x, y, z int) Vector3 {
+		x0, x1 := max(x-1, 0), min(x+1, sizeX-1)
+		y0, y1 := max(y-1, 0), min(y+1, sizeY-1)
+		z0, z1 := max(z-1, 0), min(z+1, sizeZ-1)
+		return Vector3{
+			(value(x1, y, z) - value(x0, y, z)) / (float32(x1-x0) * cellSize.X),
+			(value(x, y1, z) - value(x, y0, z)) / (float32(y1-y0) * cellSize.Y),
+			(value(x, y, z1) - value(x, y, z0)) / (float32(z1-z0) * cellSize.Z),
+		}
+	}
+
+	// Vertices lie on the edges of the grid, and are shared between the cells that share those edges.
+	edgeVertices := map[int]int{}
+
+	var cornerValues [8]float32
+
+	for z := 0; z < sizeZ-1; z++ {
+		for y := 0; y < sizeY-1; y++ {
+			for x := 0; x < sizeX-1; x++ {
+
+				caseIndex := 0
+
+				for c := 0; c < 8; c++ {
+					cornerValues[c] = value(x+c&1, y+(c>>1)&1, z+(c>>2)&1)
+					if cornerValues[c] < isoLevel {
+						caseIndex |= 1 << c
+					}
+				}
+
+				for _, edge := range marchingCubesTable[caseIndex] {
+
+					a, b := marchingCubesEdges[edge][0], marchingCubesEdges[edge][1]
+
+					ax, ay, az := x+a&1, y+(a>>1)&1, z+(a>>2)&1
+					bx, by, bz := x+b&1, y+(b>>1)&1, z+(b>>2)&1
+
+					// Edges always run from the lower corner to the higher one along a single axis, so the lower corner and axis identify the edge.
+					key := (ax+ay*sizeX+az*sizeX*sizeY)*3 + marchingCubesEdgeAxis(a, b)
+
+					index, exists := edgeVertices[key]
+
+					if !exists {
+
+						t := float32(0.5)
+						if diff := cornerValues[b] - cornerValues[a]; diff != 0 {
+							t = math32.Clamp((isoLevel-cornerValues[a])/diff, 0, 1)
+						}
+
+						pa := origin.Add(Vector3{float32(ax) * cellSize.X, float32(ay) * cellSize.Y, float32(az) * cellSize.Z})
+						pb := origin.Add(Vector3{float32(bx) * cellSize.X, float32(by) * cellSize.Y, float32(bz) * cellSize.Z})
+
+						normal := gradient(ax, ay, az).Lerp(gradient(bx, by, bz), t).Unit()
+
+						index = len(positions)
+						positions = append(positions, pa.Lerp(pb, t))
+						normals = append(normals, normal)
+						edgeVertices[key] = index
+
+					}
+
+					indices = append(indices, index)
+
+				}
+
+			}
+		}
+	}
+
+	return positions, normals, indices
+
+}
+
+func marchingCubesEdgeAxis(a, b int) int {
+	switch a ^ b {
+	case 1:
+		return 0
+	case 2:
+		return 1
+	}
+	return 2
+}
+
+// The corners of a cube are numbered so that corner i lies at (i & 1, (i >> 1) & 1, (i >> 2) & 1).
+// marchingCubesEdges lists the two corners of each of the cube's twelve edges.
+var marchingCubesEdges [12][2]int
+
+// marchingCubesTable lists the edges (indices into marchingCubesEdges) that form the triangles for each of the 256 possible combinations of a cube's
+// corners being inside or outside of the surface. Rather than being written out by hand, it's generated when the package is initialized.
+var marchingCubesTable [256][]int
+
+func init() {
+
+	edgeIndices := map[[2]int]int{}
+
+	for a := 0; a < 8; a++ {
+		for _, bit := range []int{1, 2, 4} {
+			if a&bit == 0 {
+				edgeIndices[[2]int{a, a | bit}] = len(edgeIndices)
+				marchingCubesEdges[len(edgeIndices)-1] = [2]int{a, a | bit}
+			}
+		}
+	}
+
+	edgeBetween := func(a, b int) int {
+		return edgeIndices[[2]int{min(a, b), max(a, b)}]
+	}
+
+	cornerPosition := func(c int) Vector3 {
+		return Vector3{float32(c & 1), float32((c >> 1) & 1), float32((c >> 2) & 1)}
+	}
+
+	// The corners of each face of the cube, in order around the face.
+	faces := [][4]int{}
+	for _, axis := range []int{1, 2, 4} {
+		u, v := 1, 2
+		if axis == 1 {
+			u, v = 2, 4
+		} else if axis == 2 {
+			v = 4
+		}
+		for _, side := range []int{0, axis} {
+			faces = append(faces, [4]int{side, side | u, side | u | v, side | v})
+		}
+	}
+
+	for caseIndex := 0; caseIndex < 256; caseIndex++ {
+
+		inside := func(c int) bool { return caseIndex&(1<<c) > 0 }
+
+		edgeMidpoint := func(edge int) Vector3 {
+			return cornerPosition(marchingCubesEdges[edge][0]).Add(cornerPosition(marchingCubesEdges[edge][1])).Scale(0.5)
+		}
+
+		// The direction from the inside corner of the edge to the outside one.
+		outwards := func(edge int) Vector3 {
+			a, b := marchingCubesEdges[edge][0], marchingCubesEdges[edge][1]
+			if inside(b) {
+				a, b = b, a
+			}
+			return cornerPosition(b).Sub(cornerPosition(a))
+		}
+
+		// First, we find where the surface cuts across each face of the cube, which gives us line segments between edges.
+		// Each edge the surface crosses is shared by two faces, so the segments link up into closed loops around the cube.
+		// Each segment is directed so that the loops wind counter-clockwise when viewed from outside of the surface; this way, the
+		// cube on the other side of the face directs the same segment the opposite way, and neighboring triangles wind consistently.
+		next := map[int]int{}
+
+		link := func(a, b int, faceNormal Vector3) {
+			if edgeMidpoint(b).Sub(edgeMidpoint(a)).Dot(outwards(a).Add(outwards(b)).Cross(faceNormal)) < 0 {
+				a, b = b, a
+			}
+			next[a] = b
+		}
+
+		for _, f := range faces {
+
+			faceNormal := cornerPosition(f[0]).Add(cornerPosition(f[2])).Scale(0.5).Sub(Vector3{0.5, 0.5, 0.5})
+
+			crossed := []int{}
+			for i := 0; i < 4; i++ {
+				if inside(f[i]) != inside(f[(i+1)%4]) {
+					crossed = append(crossed, edgeBetween(f[i], f[(i+1)%4]))
+				}
+			}
+
+			if len(crossed) == 2 {
+				link(crossed[0], crossed[1], faceNormal)
+			} else if len(crossed) == 4 {
+				// Ambiguous face where diagonal corners are inside; we always separate the inside corners from each other, which
+				// is consistent for both cubes sharing the face.
+				if inside(f[0]) {
+					link(edgeBetween(f[3], f[0]), edgeBetween(f[0], f[1]), faceNormal)
+					link(edgeBetween(f[1], f[2]), edgeBetween(f[2], f[3]), faceNormal)
+				} else {
+					link(edgeBetween(f[0], f[1]), edgeBetween(f[1], f[2]), faceNormal)
+					link(edgeBetween(f[2], f[3]), edgeBetween(f[3], f[0]), faceNormal)
+				}
+			}
+
+		}
+
+		visited := map[int]bool{}
+
+		for start := 0; start < 12; start++ {
+
+			if _, crossed := next[start]; !crossed || visited[start] {
+				continue
+			}
+
+			loop := []int{}
+			for edge := start; !visited[edge]; edge = next[edge] {
+				loop = append(loop, edge)
+				visited[edge] = true
+			}
+
+			for i := 1; i < len(loop)-1; i++ {
+				marchingCubesTable[caseIndex] = append(marchingCubesTable[caseIndex], loop[0], loop[i], loop[i+1])
+			}
+
+		}
+
+	}
+
+}
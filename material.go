@@ -26,6 +26,32 @@ const (
 	TransparencyModeTransparent
 )
 
+const (
+	// DepthWriteAuto means the triangles are written to the depth buffer unless they're transparent (see TransparencyModeTransparent).
+	DepthWriteAuto = iota
+
+	// DepthWriteOn means the triangles are always written to the depth buffer, even if they're transparent.
+	DepthWriteOn
+
+	// DepthWriteOff means the triangles are never written to the depth buffer, so they don't obscure anything rendered after them.
+	DepthWriteOff
+)
+
+const (
+	// DepthTestLess means the triangles only render where they're closer to the camera than what's already in the depth buffer.
+	DepthTestLess = iota
+
+	// DepthTestAlways means the triangles render regardless of what's already in the depth buffer (i.e. for overlays or UI rendered in 3D).
+	DepthTestAlways
+
+	// DepthTestNever means the triangles never render.
+	DepthTestNever
+
+	// DepthTestGreater means the triangles only render where they're further from the camera than what's already in the depth buffer
+	// (i.e. for "x-ray" silhouettes of objects hidden behind walls).
+	DepthTestGreater
+)
+
 const (
 	BillboardModeNone          = iota // No billboarding
 	BillboardModeFixedVertical        // Billboard to face forward relative to the camera / screen under all circumstances; up is screen up / up relative to the camera, locally (local +Y)
@@ -87,6 +113,36 @@ type Material struct {
 	// "cutting" into geometry that's further back.
 	// The default value for CustomDepthFunction is nil.
 	CustomDepthFunction func(originalDepth float32) float32
+
+	// SoftParticleDistance is the distance in world units over which the Material fades out as it nears the geometry behind it.
+	// This hides the hard edges where transparent billboards (like smoke or dust particles) intersect the world.
+	// It requires Camera.RenderDepth to be on, and only affects transparent Materials (as opaque Materials write to the depth texture
+	// themselves). The default value of 0 disables fading.
+	SoftParticleDistance float32
+
+	// PerPixelLighting indicates whether the Material is lit per-pixel in the color shader, rather than per-vertex. This is slower,
+	// but avoids the banding of vertex lighting across large polygons, which makes it a good choice for hero objects and large,
+	// low-poly surfaces. Only the nearest PointLights and DirectionalLights to the Model (up to MaxPixelLights), along with the ambient light,
+	// light per-pixel lit Materials; CubeLights are ignored. Per-pixel lighting requires Camera.RenderDepth to be on, and doesn't
+	// affect shadeless Materials or Models. The default value is false.
+	PerPixelLighting bool
+
+	// SampleOpaqueColor indicates whether the Material's custom fragment shader samples the opaque objects rendered behind it
+	// (i.e. for refraction). If so, the fourth image passed to the shader (imageSrc3) is a copy of the Camera's color texture as
+	// it was just before transparent objects were rendered (see Camera.OpaqueColorTexture()). This only works for transparent
+	// Materials with custom fragment shaders, and requires Camera.RenderDepth to be on. The default value is false.
+	SampleOpaqueColor bool
+
+	// DepthWrite controls whether the Material's triangles are written to the depth buffer. The default value of DepthWriteAuto writes
+	// them unless the Material is transparent; DepthWriteOn and DepthWriteOff override this regardless of the TransparencyMode
+	// (i.e. turning depth writing off for a skybox or an overlay so it doesn't obscure anything rendered after it). Note that DepthWrite
+	// doesn't change which render pass the Material renders in, and requires Camera.RenderDepth to be on.
+	DepthWrite int
+
+	// DepthTest controls how the Material's triangles are tested against the depth buffer. The default value of DepthTestLess renders them
+	// where they're closer than what's already been rendered; see the DepthTest constants for the others. DepthTest requires
+	// Camera.RenderDepth to be on.
+	DepthTest int
 }
 
 // NewMaterial creates a new Material with the name given.
@@ -141,6 +197,11 @@ func (m *Material) Clone() *Material {
 		newMat.FragmentShaderOptions.Uniforms[k] = v
 	}
 	newMat.TransparencyMode = m.TransparencyMode
+	newMat.SoftParticleDistance = m.SoftParticleDistance
+	newMat.PerPixelLighting = m.PerPixelLighting
+	newMat.SampleOpaqueColor = m.SampleOpaqueColor
+	newMat.DepthWrite = m.DepthWrite
+	newMat.DepthTest = m.DepthTest
 
 	return newMat
 }
@@ -194,7 +194,11 @@ type Mesh struct {
 	VertexGroupNames         []string    // The names of the vertex groups applies to the Mesh; this is only populated if the Mesh is affected by an armature
 	VertexWeights            [][]float32 // TODO: Replace this with [][8]float32 (or however many the maximum is for GLTF)
 	VertexBones              [][]uint16  // TODO: Replace this with [][8]uint16 (or however many the maximum number of bones affecting a single vertex is for GLTF)
+	VertexTangents           []Vector4   // The tangent of each vertex, with the handedness of the UV map in W; this is empty unless Mesh.GenerateTangents() is called
 	visibleVertices          []bool
+	visibleTriangles         []bool
+	visibilityFrame          uint64    // The render frame the Mesh's vertex and triangle visibility was recorded in
+	vertexClipDistances      []float32 // The distance of each vertex to the rendering Camera's clip plane, if it has one
 	maxTriangleSpan          float32
 	VertexActiveColorChannel int // VertexActiveColorChannel is the active vertex color used for coloring the mesh
 
@@ -313,6 +317,10 @@ func (mesh *Mesh) Clone() *Mesh {
 		newMesh.vertexSkinnedPositions = append(newMesh.vertexSkinnedPositions, mesh.vertexSkinnedPositions[v])
 	}
 
+	if len(mesh.VertexTangents) > 0 {
+		newMesh.VertexTangents = append(make([]Vector4, 0, len(mesh.VertexTangents)), mesh.VertexTangents...)
+	}
+
 	newMesh.Triangles = make([]*Triangle, 0, len(mesh.Triangles))
 
 	for _, part := range mesh.MeshParts {
@@ -391,6 +399,140 @@ func (mesh *Mesh) allocateVertexBuffers(vertexCount int) {
 
 }
 
+// meshPartData holds the vertices and triangle indices (relative to the vertices) of a MeshPart while its Mesh is being rebuilt
+// (i.e. by Mesh.Tessellate() or Mesh.Simplify()).
+type meshPartData struct {
+	verts   []VertexInfo
+	indices []int
+}
+
+// partData returns the vertices and triangle indices of each of the Mesh's MeshParts, to be modified and then applied using Mesh.rebuildParts().
+func (mesh *Mesh) partData() []*meshPartData {
+
+	parts := make([]*meshPartData, 0, len(mesh.MeshParts))
+
+	for _, part := range mesh.MeshParts {
+
+		data := &meshPartData{}
+
+		if part.VertexIndexCount() > 0 && part.TriangleCount() > 0 {
+
+			for i := part.VertexIndexStart; i < part.VertexIndexEnd; i++ {
+				data.verts = append(data.verts, mesh.GetVertexInfo(i))
+			}
+
+			part.ForEachTri(func(tri *Triangle) {
+				for _, index := range tri.VertexIndices {
+					data.indices = append(data.indices, index-part.VertexIndexStart)
+				}
+			})
+
+		}
+
+		parts = append(parts, data)
+
+	}
+
+	return parts
+
+}
+
+// rebuildParts rebuilds the Mesh's vertex buffers and triangles from scratch using the given data for each of the Mesh's MeshParts
+// (in order), so that each MeshPart's vertices remain contiguous. The MeshParts themselves (and so their Materials) are kept.
+func (mesh *Mesh) rebuildParts(parts []*meshPartData) {
+
+	vertexCount := 0
+	triangleCount := 0
+
+	for _, data := range parts {
+		vertexCount += len(data.verts)
+		triangleCount += len(data.indices) / 3
+	}
+
+	colorChannelCount := len(mesh.VertexColors)
+
+	mesh.Triangles = make([]*Triangle, 0, triangleCount)
+	mesh.triIndex = 0
+	mesh.maxTriangleSpan = 0
+
+	mesh.vertexTransforms = []Vector4{}
+	mesh.VertexPositions = []Vector3{}
+	mesh.visibleVertices = []bool{}
+	mesh.visibleTriangles = []bool{}
+	mesh.VertexNormals = []Vector3{}
+	mesh.vertexSkinnedNormals = []Vector3{}
+	mesh.vertexSkinnedPositions = []Vector3{}
+	mesh.vertexTransformedNormals = []Vector3{}
+	mesh.vertexLights = []Color{}
+	mesh.VertexUVs = []Vector2{}
+	mesh.VertexUVOriginalValues = []Vector2{}
+	mesh.VertexBones = [][]uint16{}
+	mesh.VertexWeights = [][]float32{}
+
+	for ci := range mesh.VertexColors {
+		mesh.VertexColors[ci] = VertexColorChannel{}
+	}
+
+	mesh.allocateVertexBuffers(vertexCount)
+
+	for i, part := range mesh.MeshParts {
+
+		data := parts[i]
+
+		part.TriangleStart = math.MaxInt
+		part.TriangleEnd = 0
+
+		if len(data.indices) == 0 {
+			part.VertexIndexStart = len(mesh.VertexPositions)
+			part.VertexIndexEnd = part.VertexIndexStart
+			part.TriangleStart = mesh.triIndex
+			part.TriangleEnd = mesh.triIndex - 1
+			continue
+		}
+
+		mesh.AddVertices(data.verts...)
+		part.VertexIndexStart = mesh.vertsAddStart
+		part.AddTriangles(data.indices...)
+
+	}
+
+	// AddVertices() ensures there's one more color channel than each vertex has, so any extra channels are removed
+	if len(mesh.VertexColors) > colorChannelCount {
+		mesh.VertexColors = mesh.VertexColors[:colorChannelCount]
+	}
+
+	mesh.UpdateBounds()
+
+	if len(mesh.VertexTangents) > 0 {
+		mesh.GenerateTangents()
+	}
+
+}
+
+// setVertexCount resizes the Mesh's vertex buffers to hold exactly the given number of vertices, reusing their existing
+// backing arrays where possible. The contents of the buffers are left as-is, so they should be filled in afterwards.
+func (mesh *Mesh) setVertexCount(vertexCount int) {
+
+	mesh.allocateVertexBuffers(vertexCount)
+
+	mesh.VertexPositions = mesh.VertexPositions[:vertexCount]
+	mesh.visibleVertices = mesh.visibleVertices[:vertexCount]
+	mesh.VertexNormals = mesh.VertexNormals[:vertexCount]
+	mesh.vertexLights = mesh.vertexLights[:vertexCount]
+	mesh.VertexUVs = mesh.VertexUVs[:vertexCount]
+	mesh.VertexUVOriginalValues = mesh.VertexUVOriginalValues[:vertexCount]
+	mesh.VertexBones = mesh.VertexBones[:vertexCount]
+	mesh.VertexWeights = mesh.VertexWeights[:vertexCount]
+	mesh.vertexTransforms = mesh.vertexTransforms[:vertexCount]
+	mesh.vertexSkinnedNormals = mesh.vertexSkinnedNormals[:vertexCount]
+	mesh.vertexTransformedNormals = mesh.vertexTransformedNormals[:vertexCount]
+	mesh.vertexSkinnedPositions = mesh.vertexSkinnedPositions[:vertexCount]
+
+	mesh.vertsAddStart = 0
+	mesh.vertsAddEnd = vertexCount
+
+}
+
 func (mesh *Mesh) ensureEnoughVertexColorChannels(channelIndex int) {
 
 	for len(mesh.VertexColors) <= channelIndex+1 {
@@ -586,6 +728,39 @@ func (mesh *Mesh) AutoNormal() {
 
 }
 
+// VertexVisible returns if the vertex of the given index was visible (part of a triangle that wasn't offscreen or backface culled)
+// when the Mesh was last rendered. This allows effects (i.e. only spawning hit particles on visible faces) to reuse the renderer's
+// work. If multiple Models share the Mesh, the results are combined across all of the Models rendered in the last frame.
+func (mesh *Mesh) VertexVisible(vertexIndex int) bool {
+	return mesh.visibilityCurrent() && vertexIndex >= 0 && vertexIndex < len(mesh.visibleVertices) && mesh.visibleVertices[vertexIndex]
+}
+
+// TriangleVisible returns if the triangle of the given index (in Mesh.Triangles) was visible (not offscreen or backface culled)
+// when the Mesh was last rendered. See Mesh.VertexVisible() for more information.
+func (mesh *Mesh) TriangleVisible(triangleIndex int) bool {
+	return mesh.visibilityCurrent() && triangleIndex >= 0 && triangleIndex < len(mesh.visibleTriangles) && mesh.visibleTriangles[triangleIndex]
+}
+
+// VisibleTriangleCount returns how many of the Mesh's triangles were visible when the Mesh was last rendered (see Mesh.TriangleVisible()).
+func (mesh *Mesh) VisibleTriangleCount() int {
+	if !mesh.visibilityCurrent() {
+		return 0
+	}
+	count := 0
+	for _, visible := range mesh.visibleTriangles {
+		if visible {
+			count++
+		}
+	}
+	return count
+}
+
+// visibilityCurrent returns if the Mesh's vertex and triangle visibility was recorded in the last rendered frame; if it wasn't, then none
+// of the Models using the Mesh were rendered (or they were all frustum culled), and so none of it is visible.
+func (mesh *Mesh) visibilityCurrent() bool {
+	return mesh.visibilityFrame == renderFrame.Load()
+}
+
 // SelectVertices generates a new vertex selection for the current Mesh.
 // This selection should generally be retained to operate on sequentially.
 // func (mesh *Mesh) SelectVertices() VertexSelection {
@@ -1304,69 +1479,285 @@ func NewPlaneMesh(vertexCountX, vertexCountZ int) *Mesh {
 
 // NewCylinderMesh creates a new cylinder Mesh and gives it a new material (suitably named "Cylinder").
 // sideCount is how many sides the cylinder should have, while radius is the radius of the cylinder in world units.
-// if createCaps is true, then the cylinder will have triangle caps.
+// if createCaps is true, then the cylinder will have flat caps. The sides of the cylinder are smooth-shaded, with the
+// texture wrapping around them, while the caps are textured with a planar projection.
 func NewCylinderMesh(sideCount int, radius, height float32, createCaps bool) *Mesh {
 
 	if sideCount < 3 {
 		sideCount = 3
 	}
 
-	mesh := NewMesh("Cylinder")
+	profile := []latheProfilePoint{}
 
-	verts := []VertexInfo{}
+	if createCaps {
+		profile = append(profile,
+			latheProfilePoint{Radius: 0, Y: height / 2, NormalY: 1, PlanarUVRadius: radius},
+			latheProfilePoint{Radius: radius, Y: height / 2, NormalY: 1, PlanarUVRadius: radius, Break: true},
+		)
+	}
+
+	profile = append(profile,
+		latheProfilePoint{Radius: radius, Y: height / 2, NormalRadial: 1, V: 1},
+		latheProfilePoint{Radius: radius, Y: -height / 2, NormalRadial: 1, V: 0, Break: true},
+	)
+
+	if createCaps {
+		profile = append(profile,
+			latheProfilePoint{Radius: radius, Y: -height / 2, NormalY: -1, PlanarUVRadius: radius},
+			latheProfilePoint{Radius: 0, Y: -height / 2, NormalY: -1, PlanarUVRadius: radius},
+		)
+	}
 
-	for i := 0; i < sideCount; i++ {
+	return newLatheMesh("Cylinder", sideCount, profile)
 
-		pos := NewVector3(radius, height/2, 0)
-		pos = pos.Rotate(0, 1, 0, float32(i)/float32(sideCount)*math.Pi*2)
-		verts = append(verts, NewVertex(pos.X, pos.Y, pos.Z, 0, 0))
+}
+
+// NewConeMesh creates a new cone Mesh and gives it a new material (suitably named "Cone"). The cone points up, with its tip
+// at height / 2 and its base at -height / 2. sideCount is how many sides the cone should have, while radius is the radius of the
+// cone's base in world units. If createCap is true, then the base of the cone will have a flat cap.
+func NewConeMesh(sideCount int, radius, height float32, createCap bool) *Mesh {
 
+	if sideCount < 3 {
+		sideCount = 3
 	}
 
-	for i := 0; i < sideCount; i++ {
+	slant := Vector2{height, radius}.Unit()
 
-		pos := NewVector3(radius, -height/2, 0)
-		pos = pos.Rotate(0, 1, 0, float32(i)/float32(sideCount)*math.Pi*2)
-		verts = append(verts, NewVertex(pos.X, pos.Y, pos.Z, 0, 0))
+	profile := []latheProfilePoint{
+		{Radius: 0, Y: height / 2, NormalRadial: slant.X, NormalY: slant.Y, V: 1},
+		{Radius: radius, Y: -height / 2, NormalRadial: slant.X, NormalY: slant.Y, V: 0, Break: true},
+	}
 
+	if createCap {
+		profile = append(profile,
+			latheProfilePoint{Radius: radius, Y: -height / 2, NormalY: -1, PlanarUVRadius: radius},
+			latheProfilePoint{Radius: 0, Y: -height / 2, NormalY: -1, PlanarUVRadius: radius},
+		)
 	}
 
-	if createCaps {
-		verts = append(verts, NewVertex(0, height/2, 0, 0, 0))
-		verts = append(verts, NewVertex(0, -height/2, 0, 0, 0))
+	return newLatheMesh("Cone", sideCount, profile)
+
+}
+
+// NewUVSphereMesh creates a new UV sphere Mesh (a sphere made of rings of quads, like a globe) and gives it a new material
+// (suitably named "UVSphere"). segmentCount is how many segments make up each ring around the sphere, while ringCount is how
+// many rings make up the sphere from top to bottom. radius is the radius of the sphere in world units.
+// Unlike an icosphere (see NewIcosphereMesh()), a UV sphere's texture coordinates wrap neatly around it.
+func NewUVSphereMesh(segmentCount, ringCount int, radius float32) *Mesh {
+
+	if segmentCount < 3 {
+		segmentCount = 3
 	}
 
-	mesh.AddVertices(verts...)
+	if ringCount < 2 {
+		ringCount = 2
+	}
 
-	indices := []int{}
-	for i := 0; i < sideCount; i++ {
-		if i < sideCount-1 {
-			indices = append(indices, i, i+sideCount, i+1)
-			indices = append(indices, i+1, i+sideCount, i+sideCount+1)
-		} else {
-			indices = append(indices, i, i+sideCount, 0)
-			indices = append(indices, 0, i+sideCount, sideCount)
+	profile := make([]latheProfilePoint, 0, ringCount+1)
+
+	for r := 0; r <= ringCount; r++ {
+		angle := float32(r) / float32(ringCount) * math32.Pi
+		sin, cos := math32.Sin(angle), math32.Cos(angle)
+		profile = append(profile, latheProfilePoint{
+			Radius:       sin * radius,
+			Y:            cos * radius,
+			NormalRadial: sin,
+			NormalY:      cos,
+			V:            1 - float32(r)/float32(ringCount),
+		})
+	}
+
+	return newLatheMesh("UVSphere", segmentCount, profile)
+
+}
+
+// NewCapsuleMesh creates a new capsule Mesh (a cylinder with hemispherical ends) and gives it a new material (suitably named
+// "Capsule"). segmentCount is how many segments make up each ring around the capsule, while ringCount is how many rings make up
+// each hemispherical end. radius is the radius of the capsule, and height is the total height of the capsule (including its ends)
+// in world units; the height is at least radius * 2.
+func NewCapsuleMesh(segmentCount, ringCount int, radius, height float32) *Mesh {
+
+	if segmentCount < 3 {
+		segmentCount = 3
+	}
+
+	if ringCount < 1 {
+		ringCount = 1
+	}
+
+	cylinderHeight := math32.Max(height-radius*2, 0)
+	totalLength := math32.Pi*radius + cylinderHeight
+
+	profile := make([]latheProfilePoint, 0, ringCount*2+2)
+
+	for end := 0; end < 2; end++ {
+
+		centerY := cylinderHeight / 2
+		if end == 1 {
+			centerY = -centerY
 		}
+
+		for r := 0; r <= ringCount; r++ {
+
+			// The end of the top hemisphere and the start of the bottom one are in the same place if there's no cylinder between them
+			if end == 1 && r == 0 && cylinderHeight == 0 {
+				continue
+			}
+
+			angle := (float32(end) + float32(r)/float32(ringCount)) * math32.Pi / 2
+			sin, cos := math32.Sin(angle), math32.Cos(angle)
+
+			// The V texture coordinate runs along the length of the capsule's surface
+			length := angle * radius
+			if end == 1 {
+				length += cylinderHeight
+			}
+
+			profile = append(profile, latheProfilePoint{
+				Radius:       sin * radius,
+				Y:            centerY + cos*radius,
+				NormalRadial: sin,
+				NormalY:      cos,
+				V:            1 - length/totalLength,
+			})
+
+		}
+
 	}
 
-	if createCaps {
-		for i := 0; i < sideCount; i++ {
-			topCenter := len(verts) - 2
-			bottomCenter := len(verts) - 1
-			if i < sideCount-1 {
-				indices = append(indices, i, i+1, topCenter)
-				indices = append(indices, i+sideCount+1, i+sideCount, bottomCenter)
-			} else {
-				indices = append(indices, i, 0, topCenter)
-				indices = append(indices, sideCount, i+sideCount, bottomCenter)
+	return newLatheMesh("Capsule", segmentCount, profile)
+
+}
+
+// NewTorusMesh creates a new torus (donut) Mesh lying flat on the XZ plane and gives it a new material (suitably named "Torus").
+// majorSegmentCount is how many segments make up the torus's ring, while minorSegmentCount is how many segments make up the tube
+// of the ring. majorRadius is the distance from the center of the torus to the center of its tube, while minorRadius is the radius
+// of the tube in world units.
+func NewTorusMesh(majorSegmentCount, minorSegmentCount int, majorRadius, minorRadius float32) *Mesh {
+
+	if majorSegmentCount < 3 {
+		majorSegmentCount = 3
+	}
+
+	if minorSegmentCount < 3 {
+		minorSegmentCount = 3
+	}
+
+	profile := make([]latheProfilePoint, 0, minorSegmentCount+1)
+
+	// The tube's profile starts at its top and runs outwards around it
+	for s := 0; s <= minorSegmentCount; s++ {
+		angle := math32.Pi/2 - float32(s)/float32(minorSegmentCount)*math32.Pi*2
+		sin, cos := math32.Sin(angle), math32.Cos(angle)
+		profile = append(profile, latheProfilePoint{
+			Radius:       majorRadius + cos*minorRadius,
+			Y:            sin * minorRadius,
+			NormalRadial: cos,
+			NormalY:      sin,
+			V:            1 - float32(s)/float32(minorSegmentCount),
+		})
+	}
+
+	return newLatheMesh("Torus", majorSegmentCount, profile)
+
+}
+
+// NewRingMesh creates a new flat ring Mesh (a disc with a hole in the middle, made of a ring of quads) facing up on the XZ
+// plane, and gives it a new material (suitably named "Ring"). segmentCount is how many segments make up the ring, while
+// innerRadius and outerRadius are the radii of the ring's inner and outer edges in world units; an innerRadius of 0 creates a disc.
+// The ring is textured with a planar projection.
+func NewRingMesh(segmentCount int, innerRadius, outerRadius float32) *Mesh {
+
+	if segmentCount < 3 {
+		segmentCount = 3
+	}
+
+	return newLatheMesh("Ring", segmentCount, []latheProfilePoint{
+		{Radius: innerRadius, NormalY: 1, PlanarUVRadius: outerRadius},
+		{Radius: outerRadius, NormalY: 1, PlanarUVRadius: outerRadius},
+	})
+
+}
+
+// latheProfilePoint is a point on the profile of a surface of revolution (see newLatheMesh()).
+type latheProfilePoint struct {
+	Radius, Y             float32 // The distance of the point from the Y axis, and its height.
+	NormalRadial, NormalY float32 // The normal of the surface at the point, pointing away from the Y axis and up, respectively.
+	V                     float32 // The V texture coordinate of the point; the U texture coordinate runs around the Y axis.
+	// If greater than 0, the point's texture coordinates are instead projected from above, with the given radius covering the texture.
+	PlanarUVRadius float32
+	Break          bool // If there's a break in the profile between this point and the next one (i.e. for hard edges).
+}
+
+// newLatheMesh creates a Mesh by revolving the given profile around the Y axis in the given number of segments. The profile should
+// run so that the outside of the surface is on the right (i.e. from top to bottom for the sides of a cylinder, or from the center
+// outwards for an upwards-facing cap).
+func newLatheMesh(name string, segmentCount int, profile []latheProfilePoint) *Mesh {
+
+	// The first vertex of each ring is duplicated at its end so the texture can wrap around the seam.
+	ringSize := segmentCount + 1
+
+	verts := make([]VertexInfo, 0, len(profile)*ringSize)
+
+	for _, point := range profile {
+
+		for s := 0; s < ringSize; s++ {
+
+			u := float32(s) / float32(segmentCount)
+			sin, cos := math32.Sin(u*math32.Pi*2), math32.Cos(u*math32.Pi*2)
+
+			x, z := sin*point.Radius, cos*point.Radius
+
+			vert := NewVertex(x, point.Y, z, u, point.V)
+
+			if point.PlanarUVRadius > 0 {
+				vert.U = 0.5 + x/point.PlanarUVRadius/2
+				vert.V = 0.5 - z/point.PlanarUVRadius/2
+			}
+
+			vert.NormalX = sin * point.NormalRadial
+			vert.NormalY = point.NormalY
+			vert.NormalZ = cos * point.NormalRadial
+
+			verts = append(verts, vert)
+
+		}
+
+	}
+
+	mesh := NewMesh(name, verts...)
+
+	indices := make([]int, 0, (len(profile)-1)*segmentCount*6)
+
+	for p := 0; p < len(profile)-1; p++ {
+
+		if profile[p].Break {
+			continue
+		}
+
+		for s := 0; s < segmentCount; s++ {
+
+			a := p*ringSize + s
+			b := a + 1
+			c := a + ringSize
+			d := c + 1
+
+			// Quads touching the Y axis collapse into triangles
+			if profile[p].Radius > 0 {
+				indices = append(indices, a, c, b)
+			}
+
+			if profile[p+1].Radius > 0 {
+				indices = append(indices, b, c, d)
 			}
+
 		}
+
 	}
 
-	mesh.AddMeshPart(NewMaterial("Cylinder"), indices...)
+	mesh.AddMeshPart(NewMaterial(name), indices...)
 
 	mesh.UpdateBounds()
-	mesh.AutoNormal()
 
 	return mesh
 
@@ -1693,13 +2084,23 @@ func (part *MeshPart) ForEachTri(triFunc func(tri *Triangle)) {
 	}
 }
 
+// ForEachVisibleTri calls the provided function for each triangle in the MeshPart that was visible when it was last rendered
+// (see Mesh.TriangleVisible()).
+func (part *MeshPart) ForEachVisibleTri(triFunc func(tri *Triangle)) {
+	for i := part.TriangleStart; i <= part.TriangleEnd; i++ {
+		if part.Mesh.TriangleVisible(i) {
+			triFunc(part.Mesh.Triangles[i])
+		}
+	}
+}
+
 // ForEachVertexIndex calls the provided function for each vertex index that the MeshPart uses.
 // If onlyVisible is true, then only the visible vertices (vertices that are rendered; that aren't
 // backface culled or offscreen) will be used with the provided function.
 func (part *MeshPart) ForEachVertexIndex(vertFunc func(vertIndex int), onlyVisible bool) {
 
 	for i := part.VertexIndexStart; i < part.VertexIndexEnd; i++ {
-		if !onlyVisible || part.Mesh.visibleVertices[i] {
+		if !onlyVisible || part.Mesh.VertexVisible(i) {
 			vertFunc(i)
 		}
 	}
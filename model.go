@@ -34,6 +34,13 @@ type Model struct {
 	skinMatrix Matrix4
 	bones      [][]*Node // The bones (nodes) of the Model, assuming it has been skinned. A Mesh's bones slice will point to indices indicating bones in the Model.
 
+	curveDeform *CurveDeform // The CurveDeform bending the Model along a Path, if set.
+	windSway    *WindSway    // The WindSway making the Model sway in the wind, if set.
+
+	particleSystem *ParticleSystem // The ParticleSystem the Model is the root of, if it was created with the Model (i.e. when loaded from a GLTF file).
+
+	flatDepth bool // If all of the Model's vertices are given the depth of its origin when rendering (i.e. for Sprite3Ds).
+
 	// A LightGroup indicates if a Model should be lit by a specific group of Lights. This allows you to control the overall lighting of scenes more accurately.
 	// If a Model has no LightGroup, the Model is lit by the lights present in the Scene.
 	LightGroup *LightGroup
@@ -52,6 +59,18 @@ type Model struct {
 	// Note that the VertexClipFunction must return the vector passed.
 	VertexClipFunction func(vertexPosition *Vector4, vertexIndex int)
 
+	// OnBecameVisible is a callback that is called when the Model is rendered by a Camera after not having been rendered by that Camera
+	// in the previous frame (i.e. it came into view, or was made visible). OnBecameInvisible is called when a Model that was rendered by a
+	// Camera in the previous frame wasn't rendered by it in the current one (i.e. it was culled, hidden, or simply not rendered). Visibility
+	// is tracked per Camera and a frame is considered to begin when the Camera is cleared, so OnBecameInvisible is called from
+	// Camera.Clear() / Camera.ClearWithColor(). Models are only tracked if at least one of these callbacks is set.
+	// These are useful for pausing expensive logic (like AI or animation) for actors that can't be seen.
+	// Note that OnBecameVisible is called once the Camera has finished rendering (outside of the render lock), but before Camera.Render()
+	// returns, so it shouldn't render using the same Camera itself.
+	OnBecameVisible   func(model *Model, camera *Camera)
+	OnBecameInvisible func(model *Model, camera *Camera)
+	renderedFrame     uint64 // The rendered frame (see renderFrame) in which the Model was last rendered by any Camera
+
 	// Automatic batching mode; when set and a Model changes parenting, it will be automatically batched as necessary according to
 	// the AutoBatchMode set.
 	AutoBatchMode int
@@ -103,8 +122,11 @@ func (model *Model) Clone() INode {
 	newModel.Shadeless = model.Shadeless
 	newModel.AutoBatchMode = model.AutoBatchMode
 
-	for k := range model.DynamicBatchModels {
-		newModel.DynamicBatchModels[k] = append([]*Model{}, model.DynamicBatchModels[k]...)
+	// The particles batched into a ParticleSystem's root belong to the original system, so they're not shared with the clone.
+	if model.particleSystem == nil {
+		for k := range model.DynamicBatchModels {
+			newModel.DynamicBatchModels[k] = append([]*Model{}, model.DynamicBatchModels[k]...)
+		}
 	}
 
 	newModel.DynamicBatchOwner = model.DynamicBatchOwner
@@ -115,15 +137,88 @@ func (model *Model) Clone() INode {
 		newModel.bones = append(newModel.bones, append([]*Node{}, model.bones[i]...))
 	}
 
+	if model.windSway != nil {
+		newModel.windSway = model.windSway.Clone()
+	}
+
+	newModel.flatDepth = model.flatDepth
+
+	if model.curveDeform != nil {
+		newModel.curveDeform = model.curveDeform.Clone()
+	}
+
+	if model.particleSystem != nil {
+		newModel.particleSystem = NewParticleSystem(newModel, model.particleSystem.ParticleFactories...)
+		newModel.particleSystem.Settings = model.particleSystem.Settings.Clone()
+		newModel.particleSystem.On = model.particleSystem.On
+		newModel.particleSystem.OnFinish = model.particleSystem.OnFinish
+	}
+
 	newModel.Node = model.Node.clone(newModel).(*Node)
 	newModel.Node.onTransformUpdate = newModel.onTransformUpdate
 
+	// The clone of a batched ParticleSystem's batch Model is dropped, as the clone's system creates its own.
+	if ps := model.particleSystem; ps != nil && ps.batchModel != nil {
+		for i, child := range model.children {
+			if child == ps.batchModel {
+				newModel.RemoveChildren(newModel.children[i])
+				break
+			}
+		}
+		newModel.particleSystem.SetBatched(true)
+	}
+
+	// Batched Models that are in the Model's own tree (i.e. GLTF mesh instances) are batched into the clone as their clones instead.
+	if model.particleSystem == nil && len(model.DynamicBatchModels) > 0 {
+
+		clones := map[*Model]*Model{}
+		originalTree := model.SearchTree().INodes()
+		clonedTree := newModel.SearchTree().INodes()
+
+		for i, node := range originalTree {
+			if original, ok := node.(*Model); ok && i < len(clonedTree) {
+				if clone, ok := clonedTree[i].(*Model); ok && original.DynamicBatchOwner == model {
+					clones[original] = clone
+				}
+			}
+		}
+
+		batched := newModel.DynamicBatchModels
+		newModel.DynamicBatchModels = map[*MeshPart][]*Model{}
+
+		for meshPart, models := range batched {
+
+			// A unique Mesh is cloned along with the Model, so its MeshParts are swapped for the clone's
+			if mesh != model.Mesh {
+				for i, mp := range model.Mesh.MeshParts {
+					if mp == meshPart {
+						meshPart = mesh.MeshParts[i]
+						break
+					}
+				}
+			}
+
+			for i, batchedModel := range models {
+				if clone, exists := clones[batchedModel]; exists {
+					models[i] = clone
+					clone.DynamicBatchOwner = newModel
+				}
+			}
+
+			newModel.DynamicBatchModels[meshPart] = models
+
+		}
+
+	}
+
 	if model.LightGroup != nil {
 		newModel.LightGroup = model.LightGroup.Clone()
 	}
 
 	newModel.VertexClipFunction = model.VertexClipFunction
 	newModel.VertexTransformFunction = model.VertexTransformFunction
+	newModel.OnBecameVisible = model.OnBecameVisible
+	newModel.OnBecameInvisible = model.OnBecameInvisible
 
 	if model.sector != nil {
 		newModel.sector = model.sector.Clone()
@@ -141,6 +236,13 @@ func (model *Model) Clone() INode {
 // When updating a Model's transform, we have to also update its bounding sphere for frustum culling.
 func (model *Model) onTransformUpdate() {
 
+	// Curve-deformed models have their bounding sphere positioned along their Path, which is sampled again (with the
+	// Model's new scale) before it's next culled or rendered.
+	if model.curveDeform != nil {
+		model.curveDeform.sampled = false
+		return
+	}
+
 	if !model.updateFrustumSphere {
 		return
 	}
@@ -428,6 +530,43 @@ func (model *Model) ReassignBones(armatureRoot INode) {
 
 }
 
+// Bone returns the bone with the given name from the armature skinning the Model, or nil if the Model isn't skinned or the armature
+// has no bone by that name.
+func (model *Model) Bone(boneName string) INode {
+
+	if model.SkinRoot == nil {
+		return nil
+	}
+
+	return model.SkinRoot.SearchTree().ByFunc(func(node INode) bool {
+		return node.IsBone() && node.Name() == boneName
+	}).First()
+
+}
+
+// AttachToBone parents the provided Node to the bone with the given name in the armature skinning the Model, so that it follows
+// the bone's animated position, rotation, and scale (i.e. for a sword in a character's hand, or a hat on its head). The Node's local
+// transform is kept and acts as an offset from the bone's head (its origin). Note that bones exported from Blender point along +Y.
+// To detach the Node afterwards, simply unparent or reparent it.
+// AttachToBone returns an error if the Model isn't skinned, or if no bone with the given name exists.
+func (model *Model) AttachToBone(boneName string, node INode) error {
+
+	if model.SkinRoot == nil {
+		return errors.New("model [" + model.Path() + "] is not skinned by an armature; cannot attach to bone [" + boneName + "]")
+	}
+
+	bone := model.Bone(boneName)
+
+	if bone == nil {
+		return errors.New("armature of model [" + model.Path() + "] has no bone named [" + boneName + "]")
+	}
+
+	bone.AddChildren(node)
+
+	return nil
+
+}
+
 func (model *Model) skinVertex(vertID int) (Vector3, Vector3) {
 
 	// Avoid reallocating a new matrix for every vertex; that's wasteful
@@ -467,17 +606,42 @@ func (model *Model) skinVertex(vertID int) (Vector3, Vector3) {
 
 }
 
+// deformed returns if the Model's vertices are transformed into world space on the CPU as it renders (by armature skinning or a CurveDeform).
+func (model *Model) deformed() bool {
+	return model.skinned || model.curveDeform != nil
+}
+
+// refreshVertexVisibility clears the visibility of the Model's Mesh's vertices and triangles before the Model's rendered. As Models can
+// share a Mesh, it's only cleared by the first of them rendered each frame, so the results are combined across all of them.
 func (model *Model) refreshVertexVisibility() {
+
+	if model.Mesh == nil {
+		return
+	}
+
+	frame := renderFrame.Load()
+	if model.Mesh.visibilityFrame == frame {
+		return
+	}
+	model.Mesh.visibilityFrame = frame
+
 	for i := range model.Mesh.visibleVertices {
 		model.Mesh.visibleVertices[i] = false
 	}
+
+	for i := range model.Mesh.visibleTriangles {
+		model.Mesh.visibleTriangles[i] = false
+	}
+
 }
 
 // ProcessVertices processes the vertices a Model has in preparation for rendering, given a view-projection
-// matrix, a camera, and the MeshPart being rendered.
+// matrix, a camera, and the MeshPart being rendered. The resulting sorted triangles are stored in the Camera's render buffers.
 func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *MeshPart, processOnlyVisible bool) {
 
-	globalSortingTriangleBucket.Clear()
+	sortingTriangles := camera.scratch().sortingTriangles
+
+	sortingTriangles.Clear()
 
 	if processOnlyVisible && ((model.Color.A == 0 && model.isTransparent(meshPart)) || !model.visible) {
 		return
@@ -497,6 +661,10 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 	mesh := meshPart.Mesh
 	base := modelTransform
 
+	if len(mesh.visibleTriangles) != len(mesh.Triangles) {
+		mesh.visibleTriangles = make([]bool, len(mesh.Triangles))
+	}
+
 	camPos := camera.WorldPosition()
 
 	// invertedCamPos := modelTransform.Inverted().MultVec(camPos)
@@ -555,10 +723,30 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 
 	camNear := camera.near
 	camFar := camera.far
-	modelSkinned := model.skinned
+	curveDeform := model.curveDeform
+	modelSkinned := model.skinned || curveDeform != nil
 	vertexSnappingOn := camera.VertexSnapping > 0
 	renderNormals := camera.RenderNormals
 
+	clipPlaneOn := camera.clipPlaneOn
+	var clipNormal Vector3
+	var clipOffset float32
+
+	if clipPlaneOn {
+
+		// Skinned vertices are already in world space, while others are in the Model's local space
+		if modelSkinned {
+			clipNormal, clipOffset = camera.clipPlane.Normal, -camera.clipPlane.Distance
+		} else {
+			clipNormal, clipOffset = camera.clipPlane.transformed(base)
+		}
+
+		if len(mesh.vertexClipDistances) < len(mesh.VertexPositions) {
+			mesh.vertexClipDistances = make([]float32, len(mesh.VertexPositions))
+		}
+
+	}
+
 	var vert Vector3
 	var normal Vector3
 
@@ -567,6 +755,21 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 		t = time.Now()
 	}
 
+	if curveDeform != nil {
+		curveDeform.update(model)
+	}
+
+	windSway := model.windSway
+
+	if windSway != nil {
+		buffers := camera.scratch()
+		if scene := model.Scene(); buffers.windScene != scene {
+			buffers.windScene = scene
+			buffers.windZones = sceneWindZones(model, buffers.windZones)
+		}
+		windSway.update(model, buffers.windZones)
+	}
+
 	vertexPositions := mesh.VertexPositions
 	vertexTransforms := mesh.vertexTransforms
 
@@ -574,7 +777,11 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 
 		if modelSkinned {
 
-			vert, normal = model.skinVertex(vertexIndex)
+			if curveDeform != nil {
+				vert, normal = curveDeform.deformVertex(model, vertexIndex)
+			} else {
+				vert, normal = model.skinVertex(vertexIndex)
+			}
 
 			if transformFuncExists {
 				transformFunc(&vert, vertexIndex)
@@ -601,6 +808,10 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 			vert.Y = vertexPositions[vertexIndex].Y
 			vert.Z = vertexPositions[vertexIndex].Z
 
+			if windSway != nil {
+				vert = vert.Add(windSway.offset(vert))
+			}
+
 			if transformFunc != nil {
 				transformFunc(&vert, vertexIndex)
 			}
@@ -612,6 +823,10 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 
 		}
 
+		if clipPlaneOn {
+			mesh.vertexClipDistances[vertexIndex] = clipNormal.X*vert.X + clipNormal.Y*vert.Y + clipNormal.Z*vert.Z + clipOffset
+		}
+
 		if vertexSnappingOn {
 			mesh.vertexTransforms[vertexIndex] = mesh.vertexTransforms[vertexIndex].Round(camera.VertexSnapping)
 		}
@@ -626,6 +841,19 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 		camera.DebugInfo.currentAnimationTime += time.Since(t)
 	}
 
+	// Models with flat depth have all of their vertices placed at the depth of their origin, so that they sort by their position and
+	// don't cut into nearby geometry.
+	if model.flatDepth {
+
+		origin := modelTransform.Row(3)
+		depth := vpMatrix[0][2]*origin.X + vpMatrix[1][2]*origin.Y + vpMatrix[2][2]*origin.Z + vpMatrix[3][2]
+
+		for vertexIndex := meshPart.VertexIndexStart; vertexIndex < meshPart.VertexIndexEnd; vertexIndex++ {
+			mesh.vertexTransforms[vertexIndex].Z = depth
+		}
+
+	}
+
 	var skinnedTriCenter Vector3
 	var transformedVertexPositions = [3]Vector3{}
 
@@ -635,6 +863,11 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 
 		vertIndices := tri.VertexIndices
 
+		// Triangles entirely behind the Camera's clip plane can be skipped; the rest are clipped per-pixel
+		if clipPlaneOn && mesh.vertexClipDistances[vertIndices[0]] < 0 && mesh.vertexClipDistances[vertIndices[1]] < 0 && mesh.vertexClipDistances[vertIndices[2]] < 0 {
+			continue
+		}
+
 		// Backface culling
 		// if meshPart.Material != nil && meshPart.Material.BackfaceCulling {
 
@@ -766,7 +999,7 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 			maxDepth = depth
 		}
 
-		globalSortingTriangleBucket.AddTriangle(ti, depth, vertIndices)
+		sortingTriangles.AddTriangle(ti, depth, vertIndices)
 
 		// I could substitute depth for W, but sorting by distance to the triangle center directly gives a better result overall, it seems.
 		// if sortMode != TriangleSortModeNone {
@@ -781,6 +1014,7 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 		mesh.visibleVertices[vertIndices[0]] = true
 		mesh.visibleVertices[vertIndices[1]] = true
 		mesh.visibleVertices[vertIndices[2]] = true
+		mesh.visibleTriangles[ti] = true
 
 		sortingTriIndex++
 
@@ -790,7 +1024,7 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 	// 	fmt.Println(minDepth, maxDepth)
 	// }
 
-	globalSortingTriangleBucket.Sort(minDepth, maxDepth)
+	sortingTriangles.Sort(minDepth, maxDepth)
 
 	// meshPart.sortingTriangles = meshPart.sortingTriangles[:sortingTriIndex]
 
@@ -806,6 +1040,14 @@ func (model *Model) ProcessVertices(vpMatrix Matrix4, camera *Camera, meshPart *
 
 }
 
+// ParticleSystem returns the ParticleSystem that the Model is the root of, if the system was created along with the Model
+// (i.e. if it was authored in Blender and loaded from a GLTF file). When the Model is cloned, its ParticleSystem is cloned as well.
+// Note that ParticleSystems need to be updated by calling ParticleSystem.Update() once per frame to spawn and move their particles.
+// If the Model isn't the root of a ParticleSystem, this returns nil.
+func (model *Model) ParticleSystem() *ParticleSystem {
+	return model.particleSystem
+}
+
 // Dimensions returns the transformed dimensions of the Model's mesh.
 func (model *Model) Dimensions() Dimensions {
 	dim := model.Mesh.Dimensions
@@ -815,6 +1057,33 @@ func (model *Model) Dimensions() Dimensions {
 	return dim
 }
 
+// ForEachWorldTriangle calls the given function for each triangle in the Model's Mesh, passing the Triangle along with its vertex
+// positions and normal in world space (i.e. transformed by the Model's current world transform). The world-space values are
+// calculated as each triangle is visited, so no memory is allocated. If the function returns false, iteration stops.
+// Note that armature deformation (skinning) is not taken into account; triangles are given in the Mesh's rest pose.
+func (model *Model) ForEachWorldTriangle(forEach func(tri *Triangle, v0, v1, v2, normal Vector3) bool) {
+
+	if model.Mesh == nil {
+		return
+	}
+
+	transform := model.Transform()
+	positions := model.Mesh.VertexPositions
+
+	for _, tri := range model.Mesh.Triangles {
+
+		v0 := transform.MultVec(positions[tri.VertexIndices[0]])
+		v1 := transform.MultVec(positions[tri.VertexIndices[1]])
+		v2 := transform.MultVec(positions[tri.VertexIndices[2]])
+
+		if !forEach(tri, v0, v1, v2, calculateNormal(v0, v1, v2)) {
+			return
+		}
+
+	}
+
+}
+
 type AOBakeOptions struct {
 	TargetMeshParts []*MeshPart // The target meshparts / materials to use for baking AO values to. If not set, then all meshparts will be used.
 	SourceVertices  VertexSelection
@@ -861,152 +1130,7 @@ func (model *Model) BakeAO(bakeOptions *AOBakeOptions) {
 
 	model.Mesh.ensureEnoughVertexColorChannels(bakeOptions.TargetChannel)
 
-	// Same model AO first
-
-	for _, tri := range model.Mesh.Triangles {
-
-		if len(bakeOptions.TargetMeshParts) > 0 {
-			include := false
-			for _, m := range bakeOptions.TargetMeshParts {
-				if m == tri.MeshPart {
-					include = true
-					break
-				}
-			}
-			if !include {
-				continue
-			}
-		}
-
-		ao := [3]float32{0, 0, 0}
-
-		verts := tri.VertexIndices
-
-		for _, other := range model.Mesh.Triangles {
-
-			if tri == other {
-				continue
-			}
-
-			span := math32.Max(tri.MaxSpan, other.MaxSpan) * 0.66
-
-			if tri.Center.DistanceSquared(other.Center) > span*span {
-				continue
-			}
-
-			angle := tri.Normal.Angle(other.Normal)
-			if angle < bakeOptions.OcclusionAngle {
-				continue
-			}
-
-			if sharedA, sharedB, sharedC, count := tri.SharesVertexPositions(other); count > 0 {
-
-				if sharedA >= 0 {
-					ao[0] = 1
-				}
-				if sharedB >= 0 {
-					ao[1] = 1
-				}
-				if sharedC >= 0 {
-					ao[2] = 1
-				}
-
-			}
-
-			if ao[0] == 1 && ao[1] == 1 && ao[2] == 1 {
-				break
-			}
-
-		}
-
-		for i := 0; i < 3; i++ {
-			p := math32.Clamp(ao[i], 0, 1)
-			model.Mesh.VertexColors[bakeOptions.TargetChannel][verts[i]].R += (bakeOptions.OcclusionColor.R - model.Mesh.VertexColors[bakeOptions.TargetChannel][verts[i]].R) * p
-			model.Mesh.VertexColors[bakeOptions.TargetChannel][verts[i]].G += (bakeOptions.OcclusionColor.G - model.Mesh.VertexColors[bakeOptions.TargetChannel][verts[i]].G) * p
-			model.Mesh.VertexColors[bakeOptions.TargetChannel][verts[i]].B += (bakeOptions.OcclusionColor.B - model.Mesh.VertexColors[bakeOptions.TargetChannel][verts[i]].B) * p
-			model.Mesh.VertexColors[bakeOptions.TargetChannel][verts[i]].A += (bakeOptions.OcclusionColor.A - model.Mesh.VertexColors[bakeOptions.TargetChannel][verts[i]].A) * p
-		}
-
-	}
-
-	// Inter-object AO next; this is kinda slow and janky, but it does work OK, I think
-
-	if !bakeOptions.OtherModels.IsZero() {
-
-		transform := model.Transform()
-
-		distanceSquared := bakeOptions.InterModelDistance * bakeOptions.InterModelDistance
-
-		bakeOptions.OtherModels.ForEach(func(node INode) bool {
-
-			other := node.(*Model)
-
-			rad := model.frustumCullingSphere.WorldRadius()
-			if or := other.frustumCullingSphere.WorldRadius(); or > rad {
-				rad = or
-			}
-			if model == other || model.WorldPosition().DistanceSquared(other.WorldPosition()) > rad*rad {
-				return true
-			}
-
-			otherTransform := other.Transform()
-
-			for _, tri := range model.Mesh.Triangles {
-
-				ao := [3]float32{0, 0, 0}
-
-				verts := tri.VertexIndices
-
-				transformedTriVerts := [3]Vector3{
-					transform.MultVec(model.Mesh.VertexPositions[verts[0]]),
-					transform.MultVec(model.Mesh.VertexPositions[verts[1]]),
-					transform.MultVec(model.Mesh.VertexPositions[verts[2]]),
-				}
-
-				for _, otherTri := range other.Mesh.Triangles {
-
-					otherVerts := otherTri.VertexIndices
-
-					span := tri.MaxSpan
-					if otherTri.MaxSpan > span {
-						span = otherTri.MaxSpan
-					}
-
-					span *= 0.66
-
-					if transform.MultVec(tri.Center).DistanceSquared(otherTransform.MultVec(otherTri.Center)) > span {
-						continue
-					}
-
-					transformedOtherVerts := [3]Vector3{
-						otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[0]]),
-						otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[1]]),
-						otherTransform.MultVec(other.Mesh.VertexPositions[otherVerts[2]]),
-					}
-
-					for i := 0; i < 3; i++ {
-						for j := 0; j < 3; j++ {
-							if transformedTriVerts[i].DistanceSquared(transformedOtherVerts[j]) <= distanceSquared {
-								ao[i] = 1
-								break
-							}
-						}
-					}
-
-				}
-
-				for i := 0; i < 3; i++ {
-					color := model.Mesh.VertexColors[verts[i]][bakeOptions.TargetChannel]
-					model.Mesh.VertexColors[verts[i]][bakeOptions.TargetChannel] = color.Mix(bakeOptions.OcclusionColor, ao[i])
-				}
-
-			}
-
-			return true
-
-		})
-
-	}
+	model.bakeAO(bakeOptions, model.Transform(), model.aoBakeOthers(bakeOptions), model.Mesh.VertexColors[bakeOptions.TargetChannel], nil)
 
 }
 
@@ -1020,52 +1144,68 @@ func (model *Model) BakeLighting(targetChannel int, lights ...ILight) {
 
 	model.Mesh.ensureEnoughVertexColorChannels(targetChannel)
 
-	allLights := append([]ILight{}, lights...)
+	model.bakeLighting(model.bakeLights(lights), model.Mesh.VertexColors[targetChannel], nil)
 
-	if model.Scene() != nil {
-		allLights = append(allLights, model.Scene().World.AmbientLight)
-	}
-
-	for _, light := range allLights {
+}
 
-		if light.IsOn() {
+// GIBakeOptions is a struct of settings for baking lighting with bounces (an approximation of global illumination) through Model.BakeLightingGI().
+type GIBakeOptions struct {
+	// The target vertex color channel to bake the lighting to.
+	// If the Model doesn't have enough vertex color channels to bake to this channel index, the BakeLightingGI() function will
+	// create vertex color channels to fill in the values up to the target channel index.
+	TargetChannel int
 
-			light.beginRender()
-			light.beginModel(model)
+	// Bounces is how many times light bounces off of surfaces after the direct lighting. 1 or 2 bounces are usually enough;
+	// each bounce adds less light than the last, but takes just as long to bake. Defaults to 1.
+	Bounces int
 
-		}
+	// Samples is how many rays are cast from each vertex to gather bounced light. More samples make for smoother results,
+	// but take longer to bake. Defaults to 32.
+	Samples int
 
-	}
+	Distance float32 // How far (in world units) light can travel between surfaces when bouncing. Defaults to 10.
+	Strength float32 // A multiplier for the bounced light. Defaults to 1.
 
-	for _, mp := range model.Mesh.MeshParts {
+	// A filter indicating other models that light can bounce off of onto the baking Model, as well as block bounced light.
+	// If this is not set, light will just bounce between the triangles within the Model.
+	// Note that the other Models' vertex colors aren't changed.
+	OtherModels NodeFilter
+}
 
-		if mp.Material != nil && mp.Material.Shadeless {
+// NewDefaultGIBakeOptions creates a new GIBakeOptions struct with default settings.
+func NewDefaultGIBakeOptions() *GIBakeOptions {
 
-			mp.ForEachVertexIndex(func(vertIndex int) {
-				model.Mesh.VertexColors[targetChannel][vertIndex].R = 1
-				model.Mesh.VertexColors[targetChannel][vertIndex].G = 1
-				model.Mesh.VertexColors[targetChannel][vertIndex].B = 1
-			}, false)
-			continue
-		} else {
+	return &GIBakeOptions{
+		TargetChannel: 0,
+		Bounces:       1,
+		Samples:       32,
+		Distance:      10,
+		Strength:      1,
+	}
 
-			mp.ForEachVertexIndex(func(vertIndex int) {
-				model.Mesh.VertexColors[targetChannel][vertIndex].R = 0
-				model.Mesh.VertexColors[targetChannel][vertIndex].G = 0
-				model.Mesh.VertexColors[targetChannel][vertIndex].B = 0
-			}, false)
+}
 
-			for _, light := range allLights {
+// BakeLightingGI bakes the colors for the provided lights into a Model's Mesh's vertex colors like Model.BakeLighting(), and then adds
+// light bouncing off of the surfaces around each vertex, using the baking options set in the provided GIBakeOptions struct.
+// Bounced light is gathered by casting rays across the hemisphere around each vertex's normal, and picking up the lighting of the surfaces
+// struck (tinted by their Material's and Model's colors). This brightens up areas facing lit surfaces (like ceilings over a sunlit floor)
+// and bleeds color between surfaces, for noticeably richer baked scenes.
+// If nil is passed instead of bake options, a default GIBakeOptions struct will be created and used.
+// Like BakeLighting(), the baked lighting overwrites whatever vertex colors previously existed in the target channel.
+// Baking bounces can take a while for larger scenes; see Model.BakeLightingGIAsync() to bake on a background goroutine.
+func (model *Model) BakeLightingGI(bakeOptions *GIBakeOptions, lights ...ILight) {
 
-				if light.IsOn() {
-					light.Light(mp, model, model.Mesh.VertexColors[targetChannel], false)
-				}
+	if bakeOptions == nil {
+		bakeOptions = NewDefaultGIBakeOptions()
+	}
 
-			}
+	if model.Mesh == nil || bakeOptions.TargetChannel < 0 {
+		return
+	}
 
-		}
+	model.Mesh.ensureEnoughVertexColorChannels(bakeOptions.TargetChannel)
 
-	}
+	model.newGIBake(bakeOptions).bake(model.bakeLights(lights), model.Mesh.VertexColors[bakeOptions.TargetChannel], nil)
 
 }
 
@@ -0,0 +1,933 @@
+package tetra3d
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// NavMeshSettings controls how a NavMesh is generated from level geometry.
+type NavMeshSettings struct {
+	// MaxSlope is the steepest slope (in radians, from flat ground) that is considered walkable. Defaults to 45 degrees.
+	MaxSlope float32
+
+	// AgentRadius is the radius of the agents navigating the NavMesh. Openings between triangles that are narrower than
+	// the agent's width are considered impassable, and paths keep this far away from the edges of the NavMesh when
+	// passing between triangles. Defaults to 0.25.
+	AgentRadius float32
+
+	// AgentHeight is the height of the agents navigating the NavMesh. Walkable triangles that have geometry above them
+	// closer than this are considered blocked (i.e. the floor underneath a low table). Defaults to 2.
+	AgentHeight float32
+
+	// WeldDistance is how close vertices of different triangles have to be to each other to be considered the same vertex
+	// when connecting triangles together. Defaults to 0.001.
+	WeldDistance float32
+}
+
+// DefaultNavMeshSettings returns the default NavMeshSettings.
+func DefaultNavMeshSettings() NavMeshSettings {
+	return NavMeshSettings{
+		MaxSlope:     math32.ToRadians(45),
+		AgentRadius:  0.25,
+		AgentHeight:  2,
+		WeldDistance: 0.001,
+	}
+}
+
+// NavMeshTriangle is a single walkable triangle in a NavMesh.
+type NavMeshTriangle struct {
+	Vertices [3]Vector3 // The world positions of the triangle's vertices.
+	Center   Vector3    // The center of the triangle.
+	Normal   Vector3    // The normal of the triangle.
+
+	// Neighbors are the triangles connected to this one; Neighbors[i] is connected through the edge going from Vertices[i]
+	// to Vertices[(i+1)%3]. If there is no (passable) neighbor along an edge, the entry will be nil.
+	Neighbors [3]*NavMeshTriangle
+
+	portals [3]navMeshPortal
+	index   int
+}
+
+// navMeshPortal is the part of an edge between two triangles that an agent can pass through, after accounting for the agent's radius.
+type navMeshPortal struct {
+	Start, End Vector3
+}
+
+func (portal navMeshPortal) midpoint() Vector3 {
+	return portal.Start.Add(portal.End).Scale(0.5)
+}
+
+// heightAt returns the height of the triangle at the given X and Z position, and whether the position lies within the
+// triangle when viewed from above.
+func (tri *NavMeshTriangle) heightAt(x, z float32) (float32, bool) {
+
+	a, b, c := tri.Vertices[0], tri.Vertices[1], tri.Vertices[2]
+
+	det := (b.Z-c.Z)*(a.X-c.X) + (c.X-b.X)*(a.Z-c.Z)
+	if math32.Abs(det) < 1e-8 {
+		return 0, false
+	}
+
+	u := ((b.Z-c.Z)*(x-c.X) + (c.X-b.X)*(z-c.Z)) / det
+	v := ((c.Z-a.Z)*(x-c.X) + (a.X-c.X)*(z-c.Z)) / det
+	w := 1 - u - v
+
+	const margin = -1e-5
+
+	if u < margin || v < margin || w < margin {
+		return 0, false
+	}
+
+	return a.Y*u + b.Y*v + c.Y*w, true
+
+}
+
+// NavMesh is a navigation mesh, composed of the walkable triangles of some level geometry. Unlike a Grid, which is a hand-authored
+// graph of points, a NavMesh covers the whole walkable surface of a level, so it works well for organic terrain. Paths can be
+// found between any two positions on it using NavMesh.PathTo().
+type NavMesh struct {
+	Settings  NavMeshSettings    // The settings the NavMesh was generated with.
+	Triangles []*NavMeshTriangle // The walkable triangles composing the NavMesh.
+
+	// Links are the off-mesh links connecting parts of the NavMesh that aren't connected by walkable triangles (i.e. gaps to jump across,
+	// ladders, or teleporters). Links should be added using NavMesh.AddLink().
+	Links []*NavMeshLink
+
+	// LinkTypeCosts holds additional costs for crossing Links by their type when pathfinding (i.e. making jumping across gaps more costly
+	// than walking around).
+	LinkTypeCosts map[GridConnectionType]float32
+
+	cellSize float32
+	cells    map[[2]int][]*NavMeshTriangle
+}
+
+// NewNavMesh generates a new NavMesh out of the walkable triangles of the given Models, using the settings provided.
+// The Models' current world transforms are used, so the NavMesh should be regenerated if the level geometry moves.
+// Note that armature deformation (skinning) is not taken into account.
+func NewNavMesh(settings NavMeshSettings, models ...*Model) *NavMesh {
+
+	navMesh := &NavMesh{
+		Settings:      settings,
+		LinkTypeCosts: map[GridConnectionType]float32{},
+		cells:         map[[2]int][]*NavMeshTriangle{},
+	}
+
+	allTris := []*NavMeshTriangle{}
+
+	for _, model := range models {
+
+		model.ForEachWorldTriangle(func(tri *Triangle, v0, v1, v2, normal Vector3) bool {
+			allTris = append(allTris, &NavMeshTriangle{
+				Vertices: [3]Vector3{v0, v1, v2},
+				Center:   v0.Add(v1).Add(v2).Divide(3),
+				Normal:   normal,
+			})
+			return true
+		})
+
+	}
+
+	if len(allTris) == 0 {
+		return navMesh
+	}
+
+	// Size the spatial lookup cells according to the average size of the triangles
+	points := make([]Vector3, 0, len(allTris)*3)
+	for _, tri := range allTris {
+		points = append(points, tri.Vertices[:]...)
+	}
+	dims := NewDimensionsFromPoints(points...)
+	navMesh.cellSize = math32.Max(math32.Sqrt(dims.Width()*dims.Depth()/float32(len(allTris)))*2, 0.5)
+
+	// Put all triangles into the lookup first, as any of them (walkable or not) can block the space above a walkable triangle.
+	for _, tri := range allTris {
+		navMesh.addToCells(tri)
+	}
+
+	minUp := math32.Cos(settings.MaxSlope)
+
+	for _, tri := range allTris {
+
+		if tri.Normal.IsZero() || tri.Normal.Dot(WorldUp) < minUp || !navMesh.hasClearance(tri) {
+			continue
+		}
+
+		tri.index = len(navMesh.Triangles)
+		navMesh.Triangles = append(navMesh.Triangles, tri)
+
+	}
+
+	// Rebuild the lookup with just the walkable triangles.
+	navMesh.cells = map[[2]int][]*NavMeshTriangle{}
+	for _, tri := range navMesh.Triangles {
+		navMesh.addToCells(tri)
+	}
+
+	navMesh.connect()
+
+	return navMesh
+
+}
+
+func (navMesh *NavMesh) cellRange(tri *NavMeshTriangle) (minX, minZ, maxX, maxZ int) {
+
+	minX, minZ = math.MaxInt, math.MaxInt
+	maxX, maxZ = math.MinInt, math.MinInt
+
+	for _, v := range tri.Vertices {
+		cx, cz := navMesh.cellAt(v)
+		minX = min(minX, cx)
+		minZ = min(minZ, cz)
+		maxX = max(maxX, cx)
+		maxZ = max(maxZ, cz)
+	}
+
+	return
+
+}
+
+func (navMesh *NavMesh) cellAt(position Vector3) (int, int) {
+	return int(math32.Floor(position.X / navMesh.cellSize)), int(math32.Floor(position.Z / navMesh.cellSize))
+}
+
+func (navMesh *NavMesh) addToCells(tri *NavMeshTriangle) {
+
+	minX, minZ, maxX, maxZ := navMesh.cellRange(tri)
+
+	for x := minX; x <= maxX; x++ {
+		for z := minZ; z <= maxZ; z++ {
+			key := [2]int{x, z}
+			navMesh.cells[key] = append(navMesh.cells[key], tri)
+		}
+	}
+
+}
+
+// hasClearance returns if there's at least AgentHeight of open space above the center of the given triangle.
+func (navMesh *NavMesh) hasClearance(tri *NavMeshTriangle) bool {
+
+	if navMesh.Settings.AgentHeight <= 0 {
+		return true
+	}
+
+	cx, cz := navMesh.cellAt(tri.Center)
+
+	for _, other := range navMesh.cells[[2]int{cx, cz}] {
+
+		if other == tri {
+			continue
+		}
+
+		if height, inside := other.heightAt(tri.Center.X, tri.Center.Z); inside {
+			diff := height - tri.Center.Y
+			if diff > 0.01 && diff < navMesh.Settings.AgentHeight {
+				return false
+			}
+		}
+
+	}
+
+	return true
+
+}
+
+// connect links up walkable triangles that share edges and calculates the portals between them.
+func (navMesh *NavMesh) connect() {
+
+	weld := navMesh.Settings.WeldDistance
+	if weld <= 0 {
+		weld = 0.001
+	}
+
+	vertexIDs := map[[3]int]int{}
+
+	vertexID := func(v Vector3) int {
+		key := [3]int{int(math32.Round(v.X / weld)), int(math32.Round(v.Y / weld)), int(math32.Round(v.Z / weld))}
+		if id, exists := vertexIDs[key]; exists {
+			return id
+		}
+		id := len(vertexIDs)
+		vertexIDs[key] = id
+		return id
+	}
+
+	type edgeRef struct {
+		tri  *NavMeshTriangle
+		edge int
+	}
+
+	edges := map[[2]int][]edgeRef{}
+	triVertexIDs := make([][3]int, len(navMesh.Triangles))
+
+	for _, tri := range navMesh.Triangles {
+
+		for i, v := range tri.Vertices {
+			triVertexIDs[tri.index][i] = vertexID(v)
+		}
+
+		for i := 0; i < 3; i++ {
+			a, b := triVertexIDs[tri.index][i], triVertexIDs[tri.index][(i+1)%3]
+			if a == b {
+				continue
+			}
+			if a > b {
+				a, b = b, a
+			}
+			edges[[2]int{a, b}] = append(edges[[2]int{a, b}], edgeRef{tri, i})
+		}
+
+	}
+
+	// Vertices touching an edge that only belongs to a single triangle lie on the border of the NavMesh.
+	borderVertices := newSet[int]()
+	for key, refs := range edges {
+		if len(refs) == 1 {
+			borderVertices.Add(key[0])
+			borderVertices.Add(key[1])
+		}
+	}
+
+	radius := math32.Max(navMesh.Settings.AgentRadius, 0)
+
+	for _, refs := range edges {
+
+		if len(refs) < 2 {
+			continue
+		}
+
+		for _, ref := range refs {
+
+			tri := ref.tri
+
+			start := tri.Vertices[ref.edge]
+			end := tri.Vertices[(ref.edge+1)%3]
+
+			startShrink, endShrink := float32(0), float32(0)
+			if borderVertices.Contains(triVertexIDs[tri.index][ref.edge]) {
+				startShrink = radius
+			}
+			if borderVertices.Contains(triVertexIDs[tri.index][(ref.edge+1)%3]) {
+				endShrink = radius
+			}
+
+			length := start.Distance(end)
+
+			// The opening's too narrow for the agent to fit through
+			if radius > 0 && length <= startShrink+endShrink {
+				continue
+			}
+
+			dir := end.Sub(start).Unit()
+
+			for _, other := range refs {
+
+				if other.tri == tri {
+					continue
+				}
+
+				tri.Neighbors[ref.edge] = other.tri
+				tri.portals[ref.edge] = navMeshPortal{
+					Start: start.Add(dir.Scale(startShrink)),
+					End:   end.Sub(dir.Scale(endShrink)),
+				}
+				break
+
+			}
+
+		}
+
+	}
+
+}
+
+// NavMeshLink is an off-mesh link between two positions on a NavMesh that aren't connected by walkable triangles (i.e. the two sides of
+// a gap to jump across, the bottom and top of a ladder, or two teleporters). Paths found using NavMesh.PathTo() can cross links, and
+// agents following those paths can tell how to cross them using the path's segments (see NavMeshPath.Segments() and PathAgent.OnTraverse).
+type NavMeshLink struct {
+	Start, End    Vector3            // The positions the link goes from and to, snapped to the NavMesh when the link was added.
+	Type          GridConnectionType // How the link is crossed (i.e. jumping, climbing a ladder, teleporting, etc).
+	Bidirectional bool               // Whether the link can be crossed from its End to its Start as well.
+	Passable      bool               // Whether the link should be considered as passable when pathfinding. Defaults to true.
+
+	// Cost is the cost of crossing the link, on top of the distance between its ends (apart from teleport links) and the cost for its
+	// type in the NavMesh's LinkTypeCosts. Defaults to 0.
+	Cost float32
+
+	startTri, endTri *NavMeshTriangle
+}
+
+// AddLink adds an off-mesh link of the given type to the NavMesh, going from the start position to the end position (and back again, if
+// bidirectional is true), and returns it. The positions are snapped to the closest points on the NavMesh. Note that a link's ends stay
+// attached to the triangles they were snapped to, so links should be added again if the NavMesh is regenerated.
+func (navMesh *NavMesh) AddLink(start, end Vector3, linkType GridConnectionType, bidirectional bool) *NavMeshLink {
+
+	link := &NavMeshLink{
+		Type:          linkType,
+		Bidirectional: bidirectional,
+		Passable:      true,
+	}
+
+	link.startTri, link.Start = navMesh.closestTriangle(start)
+	link.endTri, link.End = navMesh.closestTriangle(end)
+
+	navMesh.Links = append(navMesh.Links, link)
+
+	return link
+
+}
+
+// RemoveLink removes the given off-mesh link from the NavMesh.
+func (navMesh *NavMesh) RemoveLink(link *NavMeshLink) {
+	for i, existing := range navMesh.Links {
+		if existing == link {
+			navMesh.Links[i] = nil
+			navMesh.Links = append(navMesh.Links[:i], navMesh.Links[i+1:]...)
+			return
+		}
+	}
+}
+
+// navMeshLinkUse is a way an off-mesh link can be crossed from a triangle, either forwards or backwards.
+type navMeshLinkUse struct {
+	link     *NavMeshLink
+	from, to Vector3
+	toTri    *NavMeshTriangle
+}
+
+// linkUses returns the ways that the NavMesh's passable links can be crossed, keyed by the index of the triangle they're crossed from.
+func (navMesh *NavMesh) linkUses() map[int][]navMeshLinkUse {
+
+	uses := map[int][]navMeshLinkUse{}
+
+	for _, link := range navMesh.Links {
+
+		if !link.Passable || link.startTri == nil || link.endTri == nil {
+			continue
+		}
+
+		uses[link.startTri.index] = append(uses[link.startTri.index], navMeshLinkUse{link, link.Start, link.End, link.endTri})
+
+		if link.Bidirectional {
+			uses[link.endTri.index] = append(uses[link.endTri.index], navMeshLinkUse{link, link.End, link.Start, link.startTri})
+		}
+
+	}
+
+	return uses
+
+}
+
+// linkCost returns the cost of crossing the given off-mesh link.
+func (navMesh *NavMesh) linkCost(use navMeshLinkUse) float32 {
+	cost := use.link.Cost + navMesh.LinkTypeCosts[use.link.Type]
+	if use.link.Type != GridConnectionTypeTeleport {
+		cost += use.from.Distance(use.to)
+	}
+	return cost
+}
+
+// TriangleAt returns the walkable triangle directly underneath (or closest to, vertically) the given world position.
+// If there's no triangle above or below the position, TriangleAt returns nil.
+func (navMesh *NavMesh) TriangleAt(position Vector3) *NavMeshTriangle {
+
+	if navMesh.cells == nil {
+		return nil
+	}
+
+	cx, cz := navMesh.cellAt(position)
+
+	var closest *NavMeshTriangle
+	closestDist := float32(math.MaxFloat32)
+
+	for _, tri := range navMesh.cells[[2]int{cx, cz}] {
+
+		if height, inside := tri.heightAt(position.X, position.Z); inside {
+
+			dist := math32.Abs(height - position.Y)
+
+			// Prefer floors that are underneath the position
+			if height > position.Y {
+				dist *= 2
+			}
+
+			if dist < closestDist {
+				closest = tri
+				closestDist = dist
+			}
+
+		}
+
+	}
+
+	return closest
+
+}
+
+// closestTriangle returns the walkable triangle closest to the given world position, as well as the closest position on it.
+func (navMesh *NavMesh) closestTriangle(position Vector3) (*NavMeshTriangle, Vector3) {
+
+	if tri := navMesh.TriangleAt(position); tri != nil {
+		height, _ := tri.heightAt(position.X, position.Z)
+		return tri, Vector3{position.X, height, position.Z}
+	}
+
+	var closest *NavMeshTriangle
+	closestPos := position
+	closestDist := float32(math.MaxFloat32)
+
+	for _, tri := range navMesh.Triangles {
+
+		p := closestPointOnTri(position, tri.Vertices[0], tri.Vertices[1], tri.Vertices[2])
+
+		if dist := p.DistanceSquared(position); dist < closestDist {
+			closest = tri
+			closestPos = p
+			closestDist = dist
+		}
+
+	}
+
+	return closest, closestPos
+
+}
+
+// ClosestPoint returns the closest position on the NavMesh's walkable surface to the given world position.
+// If the NavMesh has no triangles, the position is returned as-is.
+func (navMesh *NavMesh) ClosestPoint(position Vector3) Vector3 {
+	_, pos := navMesh.closestTriangle(position)
+	return pos
+}
+
+// PathTo finds a path across the NavMesh from one world position to another. The positions are first snapped to the closest points
+// on the NavMesh. The path generated should be the shortest-possible route through the NavMesh's triangles (and its off-mesh Links).
+// The path goes through the middle of each opening between triangles; call NavMeshPath.Smooth() to pull it taut.
+// If a path is not possible from the starting point to the end point, then PathTo will return nil.
+func (navMesh *NavMesh) PathTo(from, to Vector3) *NavMeshPath {
+
+	startTri, start := navMesh.closestTriangle(from)
+	goalTri, goal := navMesh.closestTriangle(to)
+
+	if startTri == nil || goalTri == nil {
+		return nil
+	}
+
+	if startTri == goalTri {
+		return &NavMeshPath{
+			PathPoints: []Vector3{start, goal},
+			Links:      make([]*NavMeshLink, 2),
+		}
+	}
+
+	count := len(navMesh.Triangles)
+
+	costs := make([]float32, count)
+	entries := make([]Vector3, count)
+	prevLinks := make([]*NavMeshTriangle, count)
+	prevEdges := make([]int, count)
+	prevLinkUses := make([]*navMeshLinkUse, count) // The off-mesh link crossed to reach each triangle, if any
+	closed := make([]bool, count)
+
+	linkUses := navMesh.linkUses()
+
+	// Teleport links cost less than the distance they cover, so the distance to the goal alone could overestimate the cost of paths
+	// using them; the estimate is capped at the distance from the closest teleport destination to the goal, so the shortest path is still found.
+	teleportDistance := float32(math.MaxFloat32)
+	for _, uses := range linkUses {
+		for _, use := range uses {
+			if use.link.Type == GridConnectionTypeTeleport {
+				teleportDistance = math32.Min(teleportDistance, use.to.Distance(goal))
+			}
+		}
+	}
+
+	// The cost to reach the goal triangle already includes the distance to the goal itself
+	heuristic := func(point Vector3, tri *NavMeshTriangle) float32 {
+		if tri == goalTri {
+			return 0
+		}
+		return math32.Min(point.Distance(goal), teleportDistance)
+	}
+
+	for i := range costs {
+		costs[i] = math.MaxFloat32
+	}
+
+	costs[startTri.index] = 0
+	entries[startTri.index] = start
+
+	toCheck := &priorityQueue[*NavMeshTriangle]{}
+	heap.Push(toCheck, priorityQueueItem[*NavMeshTriangle]{value: startTri, priority: heuristic(start, startTri)})
+
+	found := false
+
+	for toCheck.Len() > 0 {
+
+		next := heap.Pop(toCheck).(priorityQueueItem[*NavMeshTriangle]).value
+
+		if next == goalTri {
+			found = true
+			break
+		}
+
+		if closed[next.index] {
+			continue
+		}
+
+		closed[next.index] = true
+
+		for edge, neighbor := range next.Neighbors {
+
+			if neighbor == nil || closed[neighbor.index] {
+				continue
+			}
+
+			entry := next.portals[edge].midpoint()
+			cost := costs[next.index] + entries[next.index].Distance(entry)
+
+			if neighbor == goalTri {
+				cost += entry.Distance(goal)
+			}
+
+			if cost < costs[neighbor.index] {
+				costs[neighbor.index] = cost
+				entries[neighbor.index] = entry
+				prevLinks[neighbor.index] = next
+				prevEdges[neighbor.index] = edge
+				prevLinkUses[neighbor.index] = nil
+				heap.Push(toCheck, priorityQueueItem[*NavMeshTriangle]{value: neighbor, priority: cost + heuristic(entry, neighbor)})
+			}
+
+		}
+
+		for _, use := range linkUses[next.index] {
+
+			if closed[use.toTri.index] {
+				continue
+			}
+
+			cost := costs[next.index] + entries[next.index].Distance(use.from) + navMesh.linkCost(use)
+
+			if use.toTri == goalTri {
+				cost += use.to.Distance(goal)
+			}
+
+			if cost < costs[use.toTri.index] {
+				costs[use.toTri.index] = cost
+				entries[use.toTri.index] = use.to
+				prevLinks[use.toTri.index] = next
+				prevLinkUses[use.toTri.index] = &use
+				heap.Push(toCheck, priorityQueueItem[*NavMeshTriangle]{value: use.toTri, priority: cost + heuristic(use.to, use.toTri)})
+			}
+
+		}
+
+	}
+
+	if !found {
+		return nil
+	}
+
+	path := &NavMeshPath{}
+
+	// The path is built backwards from the goal, and then reversed
+	addPoint := func(point Vector3, link *NavMeshLink, portal navMeshPortal) {
+		path.PathPoints = append(path.PathPoints, point)
+		path.Links = append(path.Links, link)
+		path.portals = append(path.portals, portal)
+	}
+
+	addPoint(goal, nil, navMeshPortal{})
+
+	for tri := goalTri; prevLinks[tri.index] != nil; tri = prevLinks[tri.index] {
+
+		if use := prevLinkUses[tri.index]; use != nil {
+			addPoint(use.to, use.link, navMeshPortal{})
+			addPoint(use.from, nil, navMeshPortal{})
+		} else {
+			portal := prevLinks[tri.index].portals[prevEdges[tri.index]]
+			addPoint(portal.midpoint(), nil, portal)
+		}
+
+	}
+
+	addPoint(start, nil, navMeshPortal{})
+
+	for i, j := 0, len(path.PathPoints)-1; i < j; i, j = i+1, j-1 {
+		path.PathPoints[i], path.PathPoints[j] = path.PathPoints[j], path.PathPoints[i]
+		path.Links[i], path.Links[j] = path.Links[j], path.Links[i]
+		path.portals[i], path.portals[j] = path.portals[j], path.portals[i]
+	}
+
+	return path
+
+}
+
+// DebugDraw draws the NavMesh's triangles and off-mesh links to the screen using the Camera and color provided. Edges that are
+// passable (i.e. connect two triangles) are drawn at half opacity.
+func (navMesh *NavMesh) DebugDraw(screen *ebiten.Image, camera *Camera, color Color) {
+
+	halfColor := color
+	halfColor.A *= 0.5
+
+	for _, tri := range navMesh.Triangles {
+
+		for i := 0; i < 3; i++ {
+
+			c := color
+			if tri.Neighbors[i] != nil {
+				c = halfColor
+			}
+
+			p1 := camera.WorldToScreenPixels(tri.Vertices[i])
+			p2 := camera.WorldToScreenPixels(tri.Vertices[(i+1)%3])
+			vector.StrokeLine(screen, p1.X, p1.Y, p2.X, p2.Y, 1, c.ToRGBA64(), false)
+
+		}
+
+	}
+
+	for _, link := range navMesh.Links {
+		p1 := camera.WorldToScreenPixels(link.Start)
+		p2 := camera.WorldToScreenPixels(link.End)
+		vector.StrokeLine(screen, p1.X, p1.Y, p2.X, p2.Y, 1, color.ToRGBA64(), false)
+		vector.StrokeCircle(screen, p1.X, p1.Y, 4, 1, color.ToRGBA64(), false)
+		vector.StrokeCircle(screen, p2.X, p2.Y, 4, 1, color.ToRGBA64(), false)
+	}
+
+}
+
+// NavMeshPath represents a path across a NavMesh, going from the starting position, through the openings between
+// the NavMesh's triangles (and across any off-mesh links), to the goal position.
+// NavMeshPath implements IPath.
+type NavMeshPath struct {
+	PathPoints []Vector3
+
+	// Links holds the off-mesh link crossed to reach each point in PathPoints from the one before it, so it's the same length as
+	// PathPoints; points that aren't reached by crossing a link have nil entries.
+	Links []*NavMeshLink
+
+	portals []navMeshPortal // The opening each point in PathPoints sits in, if it's the midpoint of an opening between triangles
+}
+
+// Length returns the length of the overall path.
+func (path *NavMeshPath) Length() float32 {
+
+	dist := float32(0)
+
+	for i := 1; i < len(path.PathPoints); i++ {
+		dist += path.PathPoints[i].Distance(path.PathPoints[i-1])
+	}
+
+	return dist
+
+}
+
+// Points returns the points of the NavMeshPath in a slice.
+func (path *NavMeshPath) Points() []Vector3 {
+	return append(make([]Vector3, 0, len(path.PathPoints)), path.PathPoints...)
+}
+
+// HopCount returns the number of hops in the path (i.e. the number of points - 1).
+func (path *NavMeshPath) HopCount() int {
+	return len(path.PathPoints) - 1
+}
+
+// Segments returns the hops of the path from each point to the next, along with how each is traversed; segments that cross an
+// off-mesh link have the link's type (and the link itself), while the others are GridConnectionTypeWalk.
+func (path *NavMeshPath) Segments() []PathSegment {
+
+	segments := make([]PathSegment, 0, len(path.PathPoints))
+
+	for i := 1; i < len(path.PathPoints); i++ {
+
+		segment := PathSegment{Start: path.PathPoints[i-1], End: path.PathPoints[i]}
+
+		if i < len(path.Links) && path.Links[i] != nil {
+			segment.Type = path.Links[i].Type
+			segment.Link = path.Links[i]
+		}
+
+		segments = append(segments, segment)
+
+	}
+
+	return segments
+
+}
+
+// Smooth smooths the NavMeshPath by pulling it taut through the openings it passes through (using the "funnel" algorithm), so that it
+// goes directly from the start to the goal, only turning around the corners of the NavMesh. As the openings are narrowed by the
+// NavMesh's AgentRadius, the smoothed path also keeps that far away from corners. Off-mesh links are kept as they are, with the path
+// only being smoothed between them.
+func (path *NavMeshPath) Smooth() {
+
+	if len(path.PathPoints) <= 2 || len(path.portals) != len(path.PathPoints) || len(path.Links) != len(path.PathPoints) {
+		return
+	}
+
+	points := make([]Vector3, 0, len(path.PathPoints))
+	links := make([]*NavMeshLink, 0, len(path.PathPoints))
+
+	// Each stretch of the path between off-mesh links is smoothed separately
+	for start := 0; start < len(path.PathPoints); {
+
+		end := start
+		for end+1 < len(path.PathPoints) && path.Links[end+1] == nil {
+			end++
+		}
+
+		section := []Vector3{path.PathPoints[start]}
+		if end > start {
+			section = navMeshFunnel(path.PathPoints[start], path.PathPoints[end], path.portals[start+1:end])
+		}
+
+		for i, point := range section {
+			points = append(points, point)
+			if i == 0 {
+				links = append(links, path.Links[start])
+			} else {
+				links = append(links, nil)
+			}
+		}
+
+		start = end + 1
+
+	}
+
+	path.PathPoints = points
+	path.Links = links
+	path.portals = nil // The path's points no longer sit in the openings, so it can't be smoothed again
+
+}
+
+// navMeshFunnel pulls a path from the start to the goal taut through the given openings using the "funnel" algorithm, returning its points.
+func navMeshFunnel(start, goal Vector3, portals []navMeshPortal) []Vector3 {
+
+	lefts := make([]Vector3, 0, len(portals)+2)
+	rights := make([]Vector3, 0, len(portals)+2)
+
+	lefts = append(lefts, start)
+	rights = append(rights, start)
+
+	// Portals run along the winding order of the triangle they're entered from, so which end is on which side of the funnel depends on how
+	// the mesh is wound; we sort them out by checking which side of the path through the previous opening each end is on.
+	prev := start
+	for _, portal := range portals {
+		if navMeshTriArea2(prev, portal.Start, portal.End) >= 0 {
+			lefts = append(lefts, portal.Start)
+			rights = append(rights, portal.End)
+		} else {
+			lefts = append(lefts, portal.End)
+			rights = append(rights, portal.Start)
+		}
+		prev = portal.midpoint()
+	}
+
+	lefts = append(lefts, goal)
+	rights = append(rights, goal)
+
+	points := []Vector3{start}
+
+	// The same corner can be reached from both sides of the funnel, so it's only added once
+	addPoint := func(point Vector3) {
+		if !points[len(points)-1].Equals(point) {
+			points = append(points, point)
+		}
+	}
+
+	apex, left, right := start, start, start
+	apexIndex, leftIndex, rightIndex := 0, 0, 0
+
+	for i := 1; i < len(lefts); i++ {
+
+		// If the apex lies on the opening (i.e. the start point sits on the edge between two triangles), the opening has no width when
+		// seen from the apex and doesn't narrow the funnel, so the funnel restarts on the other side of it rather than turning a corner.
+		if i < len(lefts)-1 && navMeshOnPortal(apex, lefts[i], rights[i]) {
+			left, leftIndex = apex, i
+			right, rightIndex = apex, i
+			continue
+		}
+
+		// Try to narrow the funnel from the right side
+		if navMeshTriArea2(apex, right, rights[i]) <= 0 {
+			if apex.Equals(right) || navMeshTriArea2(apex, left, rights[i]) > 0 {
+				right = rights[i]
+				rightIndex = i
+			} else {
+				// The right side crossed over the left, so the left point is a corner the path has to go around.
+				addPoint(left)
+				apex, apexIndex = left, leftIndex
+				right, rightIndex = apex, apexIndex
+				i = apexIndex
+				continue
+			}
+		}
+
+		// Try to narrow the funnel from the left side
+		if navMeshTriArea2(apex, left, lefts[i]) >= 0 {
+			if apex.Equals(left) || navMeshTriArea2(apex, right, lefts[i]) < 0 {
+				left = lefts[i]
+				leftIndex = i
+			} else {
+				addPoint(right)
+				apex, apexIndex = right, rightIndex
+				left, leftIndex = apex, apexIndex
+				i = apexIndex
+				continue
+			}
+		}
+
+	}
+
+	addPoint(goal)
+
+	return points
+
+}
+
+// navMeshOnPortal returns if the given point lies on the opening between the given left and right points, when viewed from above.
+func navMeshOnPortal(point, left, right Vector3) bool {
+
+	toLeft := Vector3{left.X - point.X, 0, left.Z - point.Z}
+	toRight := Vector3{right.X - point.X, 0, right.Z - point.Z}
+
+	if toLeft.IsZero() || toRight.IsZero() {
+		return false
+	}
+
+	// The point's in line with the opening, and between its ends
+	return math32.Abs(navMeshTriArea2(point, left, right)) <= 0.0001*toLeft.Magnitude()*toRight.Magnitude() && toLeft.Dot(toRight) < 0
+
+}
+
+// navMeshTriArea2 returns twice the signed area of the triangle formed by the given points, when viewed from above.
+func navMeshTriArea2(a, b, c Vector3) float32 {
+	abX, abZ := b.X-a.X, b.Z-a.Z
+	acX, acZ := c.X-a.X, c.Z-a.Z
+	return acX*abZ - abX*acZ
+}
+
+func (path *NavMeshPath) isClosed() bool {
+	return false
+}
+
+// DebugDraw draws the NavMeshPath to the screen using the Camera and color provided.
+func (path *NavMeshPath) DebugDraw(screen *ebiten.Image, camera *Camera, color Color) {
+
+	for i := 0; i < len(path.PathPoints)-1; i++ {
+		p1 := camera.WorldToScreenPixels(path.PathPoints[i])
+		p2 := camera.WorldToScreenPixels(path.PathPoints[i+1])
+		vector.StrokeLine(screen, p1.X, p1.Y, p2.X, p2.Y, 1, color.ToRGBA64(), false)
+		vector.StrokeCircle(screen, p1.X, p1.Y, 8, 1, color.ToRGBA64(), false)
+		if i == len(path.PathPoints)-2 {
+			vector.StrokeCircle(screen, p2.X, p2.Y, 8, 1, color.ToRGBA64(), false)
+		}
+	}
+
+}
+
+var _ IPath = &NavMeshPath{} // Sanity check to ensure NavMeshPaths implement paths
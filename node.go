@@ -20,18 +20,31 @@ const (
 type NodeType string
 
 const (
-	NodeTypeNode      NodeType = "NodeNode"       // NodeTypeNode represents specifically a node
-	NodeTypeModel     NodeType = "NodeModel"      // NodeTypeModel represents specifically a Model
-	NodeTypeCamera    NodeType = "NodeCamera"     // NodeTypeCamera represents specifically a Camera
-	NodeTypePath      NodeType = "NodePath"       // NodeTypePath represents specifically a Path
-	NodeTypeGrid      NodeType = "NodeGrid"       // NodeTypeGrid represents specifically a Grid
-	NodeTypeGridPoint NodeType = "Node_GridPoint" // NodeTypeGrid represents specifically a GridPoint (note the extra underscore to ensure !NodeTypeGridPoint.Is(NodeTypeGrid))
-
-	NodeTypeBoundingObject    NodeType = "NodeBounding"          // NodeTypeBoundingObject represents any generic bounding object
-	NodeTypeBoundingAABB      NodeType = "NodeBoundingAABB"      // NodeTypeBoundingAABB represents specifically a BoundingAABB
-	NodeTypeBoundingCapsule   NodeType = "NodeBoundingCapsule"   // NodeTypeBoundingCapsule represents specifically a BoundingCapsule
-	NodeTypeBoundingTriangles NodeType = "NodeBoundingTriangles" // NodeTypeBoundingTriangles represents specifically a BoundingTriangles object
-	NodeTypeBoundingSphere    NodeType = "NodeBoundingSphere"    // NodeTypeBoundingSphere represents specifically a BoundingSphere BoundingObject
+	NodeTypeNode            NodeType = "NodeNode"            // NodeTypeNode represents specifically a node
+	NodeTypeModel           NodeType = "NodeModel"           // NodeTypeModel represents specifically a Model
+	NodeTypeCamera          NodeType = "NodeCamera"          // NodeTypeCamera represents specifically a Camera
+	NodeTypePath            NodeType = "NodePath"            // NodeTypePath represents specifically a Path
+	NodeTypeGrid            NodeType = "NodeGrid"            // NodeTypeGrid represents specifically a Grid
+	NodeTypeGridPoint       NodeType = "Node_GridPoint"      // NodeTypeGrid represents specifically a GridPoint (note the extra underscore to ensure !NodeTypeGridPoint.Is(NodeTypeGrid))
+	NodeTypeGroup           NodeType = "NodeGroup"           // NodeTypeGroup represents specifically a Group
+	NodeTypeForceField      NodeType = "NodeForceField"      // NodeTypeForceField represents specifically a ForceField
+	NodeTypeRope            NodeType = "NodeRope"            // NodeTypeRope represents specifically a Rope
+	NodeTypeBuoyancyVolume  NodeType = "NodeBuoyancyVolume"  // NodeTypeBuoyancyVolume represents specifically a BuoyancyVolume
+	NodeTypeWindZone        NodeType = "NodeWindZone"        // NodeTypeWindZone represents specifically a WindZone
+	NodeTypeTrigger         NodeType = "NodeTrigger"         // NodeTypeTrigger represents specifically a Trigger
+	NodeTypeRigidBody       NodeType = "NodeRigidBody"       // NodeTypeRigidBody represents specifically a RigidBody
+	NodeTypeSpringArmCamera NodeType = "NodeSpringArmCamera" // NodeTypeSpringArmCamera represents specifically a SpringArmCamera
+	NodeTypeSprite3D        NodeType = "NodeSprite3D"        // NodeTypeSprite3D represents specifically a Sprite3D
+	NodeTypeTerrain         NodeType = "NodeTerrain"         // NodeTypeTerrain represents specifically a Terrain
+
+	NodeTypeBoundingObject      NodeType = "NodeBounding"            // NodeTypeBoundingObject represents any generic bounding object
+	NodeTypeBoundingAABB        NodeType = "NodeBoundingAABB"        // NodeTypeBoundingAABB represents specifically a BoundingAABB
+	NodeTypeBoundingOBB         NodeType = "NodeBoundingOBB"         // NodeTypeBoundingOBB represents specifically a BoundingOBB
+	NodeTypeBoundingConvexHull  NodeType = "NodeBoundingConvexHull"  // NodeTypeBoundingConvexHull represents specifically a BoundingConvexHull
+	NodeTypeBoundingCapsule     NodeType = "NodeBoundingCapsule"     // NodeTypeBoundingCapsule represents specifically a BoundingCapsule
+	NodeTypeBoundingTriangles   NodeType = "NodeBoundingTriangles"   // NodeTypeBoundingTriangles represents specifically a BoundingTriangles object
+	NodeTypeBoundingSphere      NodeType = "NodeBoundingSphere"      // NodeTypeBoundingSphere represents specifically a BoundingSphere BoundingObject
+	NodeTypeBoundingHeightfield NodeType = "NodeBoundingHeightfield" // NodeTypeBoundingHeightfield represents specifically a BoundingHeightfield
 
 	NodeTypeLight            NodeType = "NodeLight"            // NodeTypeLight represents any generic light
 	NodeTypeAmbientLight     NodeType = "NodeLightAmbient"     // NodeTypeAmbientLight represents specifically an ambient light
@@ -59,10 +72,23 @@ func (nt NodeType) Is(other NodeType) bool {
 type INode interface {
 	// Name returns the object's name.
 	Name() string
-	// ID returns the object's unique ID.
+	// ID returns the object's unique ID. See Node.ID() for more information.
 	ID() uint64
+	// SetID sets the object's ID. See Node.SetID() for more information.
+	SetID(id uint64)
 	// SetName sets the object's name.
 	SetName(name string)
+
+	// AddTag adds the given tags to the object. See Node.AddTag() for more information.
+	AddTag(tags ...string)
+	// RemoveTag removes the given tags from the object.
+	RemoveTag(tags ...string)
+	// ClearTags removes all tags from the object.
+	ClearTags()
+	// HasTag returns if the object has all of the given tags.
+	HasTag(tags ...string) bool
+	// Tags returns the object's tags.
+	Tags() []string
 	// Clone returns a clone of the specified INode implementer.
 	Clone() INode
 	// SetData sets user-customizeable data that could be usefully stored on this node.
@@ -82,6 +108,7 @@ type INode interface {
 	// Unparent unparents the Node from its parent, removing it from the scenegraph.
 	Unparent()
 	getOwner() INode
+	getNode() *Node // Returns the Node embedded in the INode, so its internal state can be edited directly.
 	// IsDescendantOf returns if a Node is a descendant child of a parent Node.
 	IsDescendantOf(parent INode) bool
 	// Scene looks for the Node's parents recursively to return what scene it exists in.
@@ -211,6 +238,20 @@ type INode interface {
 	// transform for efficiency.
 	Transform() Matrix4
 
+	// UpdateTransformDelta records the Node's current world transform, so that TransformDelta() measures how the Node has moved since this call.
+	// This should be called once per frame for Nodes whose movement is tracked (i.e. moving platforms), after everything has moved for the frame.
+	UpdateTransformDelta()
+	// TransformDelta returns the world-space change in the Node's transform since the last UpdateTransformDelta() call; transforming a point
+	// by it moves the point as though it were attached to the Node. If UpdateTransformDelta() has never been called on the Node, the delta of
+	// its nearest ancestor that it has been called on is returned instead (as children move with their parents), or an identity Matrix4 if there is none.
+	TransformDelta() Matrix4
+	// WorldPositionDelta returns how far the Node's world position has moved since the last UpdateTransformDelta() call, or a zero Vector3
+	// if UpdateTransformDelta() has never been called on the Node.
+	WorldPositionDelta() Vector3
+	// InheritTransformDelta moves and rotates the Node by the given Node's TransformDelta(), as though it were attached to it. This is used to have
+	// objects standing on moving platforms or elevators be carried along by them.
+	InheritTransformDelta(other INode)
+
 	// Visible returns whether the Object is visible.
 	Visible() bool
 	// SetVisible sets the object's visibility. If recursive is true, all recursive children of this Node will have their visibility set the same way.
@@ -220,10 +261,19 @@ type INode interface {
 	// slashes ('/'), and is relative to the node you use to call Get. As an example of Get, if you had a cup parented to a desk, which was
 	// parented to a room, that was finally parented to the root of the scene, it would be found at "Room/Desk/Cup". Note also that you can use "../" to
 	// "go up one" in the hierarchy (so cup.Get("../") would return the Desk node).
+	// Paths can also contain wildcards, indices, and type filters; see GetAll() for the format. If multiple Nodes match the path, the first is returned.
 	// Since Get uses forward slashes as path separation, it would be good to avoid using forward slashes in your Node names. Also note that Get()
 	// trims the extra spaces from the beginning and end of Node Names, so avoid using spaces at the beginning or end of your Nodes' names.
 	Get(path string) INode
 
+	// GetAll searches a node's hierarchy using a path (in the same format as Get()) and returns all Nodes that match it. Each part of the path
+	// can use wildcards ("*" matches any number of characters, and "?" matches a single character) to match multiple Nodes, and a part of just
+	// "**" matches any number of levels of the hierarchy (including none). A part can end with a type filter (i.e. ":Model" or ":BoundingObject",
+	// using the names of the NodeType constants without the "NodeType" prefix), and with an index (i.e. "[0]" for the first matching child of each
+	// Node, or "[-1]" for the last). For example, "Enemies/*/Bounds" returns the Nodes named Bounds under each child of Enemies, while
+	// "Enemies/**/*:BoundingObject" returns all BoundingObjects anywhere under Enemies, and "Enemies/*:Model[0]" returns the first Model under Enemies.
+	GetAll(path string) NodeCollection[INode]
+
 	// FindNode searches a node's hierarchy using a string to find the specified Node.
 	FindNode(nodeName string) INode
 
@@ -248,7 +298,7 @@ type INode interface {
 	// Sector returns the Sector this Node is in.
 	Sector() *Sector
 	sectorHierarchy() *Sector
-	isInVisibleSector(sectorsModels []*Model) bool
+	isInVisibleSector(buffers *renderBuffers) bool
 
 	SetSectorType(sectorType SectorType)
 	SectorType() SectorType
@@ -272,8 +322,6 @@ type INode interface {
 	setRunCallbacks(bool)
 }
 
-var nodeID uint64 = 0
-
 // Node represents a minimal struct that fully implements the Node interface. Model and Camera embed Node
 // into their structs to automatically easily implement Node.
 
@@ -291,7 +339,10 @@ type Node struct {
 	parent            INode
 	cachedTransform   Matrix4
 	isTransformDirty  bool
+	prevTransform     Matrix4 // The world transform recorded by UpdateTransformDelta()
+	hasPrevTransform  bool
 	props             Properties // Properties is an unordered set of properties, representing a means of identifying and setting game properties on Nodes.
+	tags              []uint32   // The IDs of the Node's interned tags; see Node.AddTag()
 	animationPlayer   *AnimationPlayer
 	inverseBindMatrix Matrix4 // Specifically for bones in an armature used for animating skinned meshes
 	isBone            bool
@@ -305,16 +356,19 @@ type Node struct {
 	cachedSceneRootNode *Node
 
 	cachedSector *Sector
+	sectorDirty  bool
 
 	runCallbacks bool
 	callbacks    *Callbacks
+
+	treeChanges uint64 // Counts the times Nodes have been added to or removed from anywhere under this Node
 }
 
 // NewNode returns a new Node.
 func NewNode(name string) *Node {
 
 	nb := &Node{
-		id:   nodeID,
+		id:   newNodeID(),
 		name: name,
 		// position:         NewVectorZero(),
 		scale:            Vector3{1, 1, 1},
@@ -331,8 +385,6 @@ func NewNode(name string) *Node {
 		// sectorType: NewBitMask(0 + 1 + 2 + 3 + 4 + 5 + 6 + 7),
 	}
 
-	nodeID++
-
 	nb.animationPlayer = NewAnimationPlayer(nb)
 
 	return nb
@@ -351,11 +403,36 @@ func (node *Node) setRunCallbacks(run bool) {
 	node.runCallbacks = run
 }
 
-// ID returns the object's unique ID.
+// ID returns the object's unique ID. Nodes created at runtime (including clones of Nodes) are given new IDs, while Nodes loaded from GLTF
+// files are given IDs derived from their names in the modeler, so they're the same each time the file is loaded; cloning a Scene also
+// keeps its Nodes' IDs (see Scene.Clone()). This means IDs can be used to refer to Nodes in save files or network messages
+// (see Scene.GetByID()), rather than using their names or paths. Note that IDs are only unique within a Scene.
+// CloneIDMap() returns the mapping from the IDs of the Nodes in a tree to the IDs of the matching Nodes in a clone of it.
 func (node *Node) ID() uint64 {
 	return node.id
 }
 
+// SetID sets the Node's ID. This is useful for restoring the IDs of Nodes that were created at runtime (i.e. spawned enemies)
+// when loading a save file, or for matching IDs across networked clients.
+func (node *Node) SetID(id uint64) {
+
+	// The Node's moved to its new ID in its Scene's table of Nodes by ID
+	owner := node.getOwner()
+	scene := node.Scene()
+	indexed := scene != nil && scene.indexed(owner)
+
+	if indexed {
+		scene.unindexNode(owner)
+	}
+
+	node.id = id
+
+	if indexed {
+		scene.indexNode(owner)
+	}
+
+}
+
 // Name returns the object's name.
 func (node *Node) Name() string {
 	return node.name
@@ -405,6 +482,7 @@ func (node *Node) clone(newOwner INode) INode {
 	newNode.callbacks = &newCallbacks
 
 	newNode.props = node.props.Clone()
+	newNode.tags = append([]uint32(nil), node.tags...)
 	newNode.animationPlayer = node.animationPlayer.Clone()
 
 	if node.animationPlayer.RootNode == node {
@@ -488,6 +566,51 @@ func (node *Node) Transform() Matrix4 {
 
 }
 
+// UpdateTransformDelta records the Node's current world transform, so that TransformDelta() measures how the Node has moved since this call.
+// This should be called once per frame for Nodes whose movement is tracked (i.e. moving platforms), after everything has moved for the frame.
+func (node *Node) UpdateTransformDelta() {
+	node.prevTransform = node.Transform()
+	node.hasPrevTransform = true
+}
+
+// TransformDelta returns the world-space change in the Node's transform since the last UpdateTransformDelta() call; transforming a point
+// by it moves the point as though it were attached to the Node. If UpdateTransformDelta() has never been called on the Node, the delta of
+// its nearest ancestor that it has been called on is returned instead (as children move with their parents), or an identity Matrix4 if there is none.
+func (node *Node) TransformDelta() Matrix4 {
+
+	if !node.hasPrevTransform {
+		if node.parent != nil {
+			return node.parent.TransformDelta()
+		}
+		return NewMatrix4()
+	}
+
+	return node.prevTransform.Inverted().Mult(node.Transform())
+
+}
+
+// WorldPositionDelta returns how far the Node's world position has moved since the last UpdateTransformDelta() call, or a zero Vector3
+// if UpdateTransformDelta() has never been called on the Node.
+func (node *Node) WorldPositionDelta() Vector3 {
+	if !node.hasPrevTransform {
+		return Vector3{}
+	}
+	return node.WorldPosition().Sub(node.prevTransform.Row(3).To3D())
+}
+
+// InheritTransformDelta moves and rotates the Node by the given Node's TransformDelta(), as though it were attached to it. This is used to have
+// objects standing on moving platforms or elevators be carried along by them.
+func (node *Node) InheritTransformDelta(other INode) {
+
+	delta := other.TransformDelta()
+
+	_, _, rotation := delta.Decompose()
+
+	node.SetWorldPositionVec(delta.MultVec(node.WorldPosition()))
+	node.SetWorldRotation(node.WorldRotation().Mult(rotation))
+
+}
+
 // SetWorldTransform sets the Node's global (world) transform to the full 4x4 transformation matrix provided.
 func (node *Node) SetWorldTransform(transform Matrix4) {
 	position, scale, rotationMatrix := transform.Decompose()
@@ -505,7 +628,7 @@ func (node *Node) dirtyTransform() {
 	}
 
 	node.isTransformDirty = true
-	node.cachedSector = nil
+	node.sectorDirty = true
 
 }
 
@@ -873,6 +996,8 @@ func (node *Node) AddChildren(children ...INode) {
 	for _, child := range children {
 
 		prevParent := child.Parent()
+		prevScene := child.Scene()
+
 		if prevParent != nil {
 
 			if prevParent == me {
@@ -889,11 +1014,22 @@ func (node *Node) AddChildren(children ...INode) {
 		child.setParent(me)
 		child.dirtyTransform()
 		node.children = append(node.children, child.getOwner())
+		node.treeChanged()
+
+		if scene := child.Scene(); scene != nil {
+			scene.addToIndexes(child.getOwner())
+		}
+
+		if !child.getRunCallbacks() {
+			continue
+		}
 
 		if child.Callbacks() != nil && child.Callbacks().OnReparent != nil {
 			child.Callbacks().OnReparent(child, prevParent, me)
 		}
 
+		notifyTreeChange(child, prevScene, child.Scene())
+
 	}
 
 }
@@ -908,6 +1044,21 @@ func (node *Node) getOwner() INode {
 	return node
 }
 
+func (node *Node) getNode() *Node {
+	return node
+}
+
+// treeChanged records that the Node's tree has changed on the Node and each of its ancestors.
+func (node *Node) treeChanged() {
+	for n := node; n != nil; {
+		n.treeChanges++
+		if n.parent == nil {
+			break
+		}
+		n = n.parent.getNode()
+	}
+}
+
 // RemoveChildren removes the provided children from this object.
 func (node *Node) RemoveChildren(children ...INode) {
 
@@ -922,15 +1073,29 @@ func (node *Node) RemoveChildren(children ...INode) {
 			if child2 == child1 {
 				// child.updateLocalTransform(nil)
 				prevParent := child1.Parent()
+				prevScene := child1.Scene()
 				child1.setParent(nil)
 				child1.dirtyTransform()
 
 				node.children[i] = nil
 				node.children = append(node.children[:i], node.children[i+1:]...)
+				node.treeChanged()
 
-				if child1.Callbacks() != nil && child1.Callbacks().OnReparent != nil {
-					child1.Callbacks().OnReparent(child1, prevParent, nil)
+				if prevScene != nil {
+					prevScene.removeFromIndexes(child1)
 				}
+
+				// When the child is being moved to another parent, AddChildren() calls its callbacks once it's been added
+				if child1.getRunCallbacks() {
+
+					if child1.Callbacks() != nil && child1.Callbacks().OnReparent != nil {
+						child1.Callbacks().OnReparent(child1, prevParent, nil)
+					}
+
+					notifyTreeChange(child1, prevScene, nil)
+
+				}
+
 				break
 			}
 		}
@@ -939,6 +1104,39 @@ func (node *Node) RemoveChildren(children ...INode) {
 
 }
 
+// notifyTreeChange calls the OnExitTree and OnEnterTree callbacks of the given Node and its tree when it has moved from one Scene's tree
+// to another (either of which can be nil).
+func notifyTreeChange(node INode, oldScene, newScene *Scene) {
+
+	if oldScene == newScene {
+		return
+	}
+
+	notify := func(n INode) bool {
+
+		callbacks := n.Callbacks()
+
+		if callbacks == nil {
+			return true
+		}
+
+		if oldScene != nil && callbacks.OnExitTree != nil {
+			callbacks.OnExitTree(n, oldScene)
+		}
+
+		if newScene != nil && callbacks.OnEnterTree != nil {
+			callbacks.OnEnterTree(n, newScene)
+		}
+
+		return true
+
+	}
+
+	notify(node)
+	node.SearchTree().ForEach(notify)
+
+}
+
 // Unparent unparents the Node from its parent, removing it from the scenegraph.
 func (node *Node) Unparent() {
 	if node.parent != nil {
@@ -1068,6 +1266,26 @@ func (node *Node) HierarchyAsString() string {
 				prefix = "GRID"
 			} else if nodeType.Is(NodeTypeGridPoint) {
 				prefix = "GPOINT"
+			} else if nodeType.Is(NodeTypeGroup) {
+				prefix = "GROUP"
+			} else if nodeType.Is(NodeTypeForceField) {
+				prefix = "FORCE"
+			} else if nodeType.Is(NodeTypeRope) {
+				prefix = "ROPE"
+			} else if nodeType.Is(NodeTypeBuoyancyVolume) {
+				prefix = "BUOY"
+			} else if nodeType.Is(NodeTypeWindZone) {
+				prefix = "WIND"
+			} else if nodeType.Is(NodeTypeTrigger) {
+				prefix = "TRIG"
+			} else if nodeType.Is(NodeTypeRigidBody) {
+				prefix = "RIGID"
+			} else if nodeType.Is(NodeTypeSpringArmCamera) {
+				prefix = "ARM"
+			} else if nodeType.Is(NodeTypeSprite3D) {
+				prefix = "SPRITE"
+			} else if nodeType.Is(NodeTypeTerrain) {
+				prefix = "TERRAIN"
 			} else if nodeType.Is(NodeTypeAmbientLight) {
 				prefix = "AMB"
 			} else if nodeType.Is(NodeTypeDirectionalLight) {
@@ -1080,10 +1298,16 @@ func (node *Node) HierarchyAsString() string {
 				prefix = "BS"
 			} else if nodeType.Is(NodeTypeBoundingAABB) {
 				prefix = "AABB"
+			} else if nodeType.Is(NodeTypeBoundingOBB) {
+				prefix = "OBB"
+			} else if nodeType.Is(NodeTypeBoundingConvexHull) {
+				prefix = "HULL"
 			} else if nodeType.Is(NodeTypeBoundingCapsule) {
 				prefix = "CAP"
 			} else if nodeType.Is(NodeTypeBoundingTriangles) {
 				prefix = "TRI"
+			} else if nodeType.Is(NodeTypeBoundingHeightfield) {
+				prefix = "HEIGHT"
 			} else if nodeType.Is(NodeTypeModel) {
 				prefix = "MODEL"
 			} else {
@@ -1144,53 +1368,238 @@ func (node *Node) String() string {
 // slashes ('/'), and is relative to the node you use to call Get. As an example of Get, if you had a cup parented to a desk, which was
 // parented to a room, that was finally parented to the root of the scene, it would be found at "Room/Desk/Cup". Note also that you can use "../" to
 // "go up one" in the hierarchy (so cup.Get("../") would return the Desk node).
+// Paths can also contain wildcards, indices, and type filters; see GetAll() for the format. If multiple Nodes match the path, the first is returned.
 // Since Get uses forward slashes as path separation, it would be good to avoid using forward slashes in your Node names. Also note that Get()
 // trims the extra spaces from the beginning and end of Node Names, so avoid using spaces at the beginning or end of your Nodes' names.
 func (node *Node) Get(path string) INode {
+	if found := node.GetAll(path); len(found) > 0 {
+		return found[0]
+	}
+	return nil
+}
 
-	var search func(node INode) INode
+// GetAll searches a node's hierarchy using a path (in the same format as Get()) and returns all Nodes that match it. Each part of the path
+// can use wildcards ("*" matches any number of characters, and "?" matches a single character) to match multiple Nodes, and a part of just
+// "**" matches any number of levels of the hierarchy (including none). A part can end with a type filter (i.e. ":Model" or ":BoundingObject",
+// using the names of the NodeType constants without the "NodeType" prefix), and with an index (i.e. "[0]" for the first matching child of each
+// Node, or "[-1]" for the last). For example, "Enemies/*/Bounds" returns the Nodes named Bounds under each child of Enemies, while
+// "Enemies/**/*:BoundingObject" returns all BoundingObjects anywhere under Enemies, and "Enemies/*:Model[0]" returns the first Model under Enemies.
+// A part that exactly matches the names of any of a Node's children is taken literally, so Nodes with these characters in their names
+// can still be found by name.
+func (node *Node) GetAll(path string) NodeCollection[INode] {
 
-	split := []string{}
+	current := NodeCollection[INode]{node}
 
 	for _, s := range strings.Split(path, `/`) {
-		if len(strings.TrimSpace(s)) > 0 {
-			split = append(split, s)
-		}
-	}
 
-	search = func(node INode) INode {
+		s = strings.TrimSpace(s)
 
-		if node == nil {
-			return nil
-		} else if len(split) == 0 {
-			return node
+		if len(s) == 0 {
+			continue
 		}
 
-		if split[0] == ".." {
-			split = split[1:]
-			return search(node.Parent())
+		part := parseNodePathPart(s)
+
+		next := NodeCollection[INode]{}
+		added := map[INode]bool{}
+
+		add := func(n INode) {
+			if n != nil && !added[n] {
+				added[n] = true
+				next = append(next, n)
+			}
 		}
 
-		for _, child := range node.Children() {
+		for _, n := range current {
+
+			switch part.pattern {
+
+			case "..":
+				add(n.Parent())
+
+			case ".":
+				add(n)
+
+			case "**":
+				add(n)
+				n.SearchTree().ForEach(func(child INode) bool {
+					add(child)
+					return true
+				})
+
+			default:
+
+				matches := []INode{}
+
+				// Parts using the path syntax are first matched against names exactly, so Nodes named with special characters are found
+				if part.pattern != s || strings.ContainsAny(s, "*?") {
+
+					for _, child := range n.Children() {
+						if child.Name() == s {
+							matches = append(matches, child)
+						}
+					}
+
+					if len(matches) > 0 {
+						for _, match := range matches {
+							add(match)
+						}
+						continue
+					}
+
+				}
 
-			if child.Name() == split[0] {
+				for _, child := range n.Children() {
+					if part.matches(child) {
+						matches = append(matches, child)
+					}
+				}
 
-				if len(split) <= 1 {
-					return child
-				} else {
-					split = split[1:]
-					return search(child)
+				if !part.indexed {
+					for _, match := range matches {
+						add(match)
+					}
+					continue
+				}
+
+				index := part.index
+				if index < 0 {
+					index += len(matches)
+				}
+
+				if index >= 0 && index < len(matches) {
+					add(matches[index])
 				}
 
 			}
 
 		}
 
-		return nil
+		if len(next) == 0 {
+			return next
+		}
+
+		current = next
+
+	}
+
+	return current
+
+}
+
+// nodePathTypes are the NodeTypes that can be used in type filters in paths given to Node.GetAll(), by name.
+var nodePathTypes = map[string]NodeType{
+	"Node":                NodeTypeNode,
+	"Model":               NodeTypeModel,
+	"Camera":              NodeTypeCamera,
+	"Path":                NodeTypePath,
+	"Grid":                NodeTypeGrid,
+	"GridPoint":           NodeTypeGridPoint,
+	"Group":               NodeTypeGroup,
+	"ForceField":          NodeTypeForceField,
+	"Rope":                NodeTypeRope,
+	"BuoyancyVolume":      NodeTypeBuoyancyVolume,
+	"WindZone":            NodeTypeWindZone,
+	"Trigger":             NodeTypeTrigger,
+	"RigidBody":           NodeTypeRigidBody,
+	"SpringArmCamera":     NodeTypeSpringArmCamera,
+	"Sprite3D":            NodeTypeSprite3D,
+	"Terrain":             NodeTypeTerrain,
+	"BoundingObject":      NodeTypeBoundingObject,
+	"BoundingAABB":        NodeTypeBoundingAABB,
+	"BoundingOBB":         NodeTypeBoundingOBB,
+	"BoundingConvexHull":  NodeTypeBoundingConvexHull,
+	"BoundingCapsule":     NodeTypeBoundingCapsule,
+	"BoundingTriangles":   NodeTypeBoundingTriangles,
+	"BoundingSphere":      NodeTypeBoundingSphere,
+	"BoundingHeightfield": NodeTypeBoundingHeightfield,
+	"Light":               NodeTypeLight,
+	"AmbientLight":        NodeTypeAmbientLight,
+	"PointLight":          NodeTypePointLight,
+	"DirectionalLight":    NodeTypeDirectionalLight,
+	"CubeLight":           NodeTypeCubeLight,
+}
+
+// nodePathPart is a single part of a path given to Node.GetAll(), in the format of "pattern:Type[index]".
+type nodePathPart struct {
+	pattern  string
+	nodeType NodeType
+	typed    bool
+	index    int
+	indexed  bool
+}
+
+func parseNodePathPart(s string) nodePathPart {
+
+	part := nodePathPart{pattern: s}
+
+	if open := strings.LastIndex(part.pattern, "["); open >= 0 && strings.HasSuffix(part.pattern, "]") {
+		if index, err := strconv.Atoi(part.pattern[open+1 : len(part.pattern)-1]); err == nil {
+			part.index = index
+			part.indexed = true
+			part.pattern = part.pattern[:open]
+		}
+	}
+
+	if colon := strings.LastIndex(part.pattern, ":"); colon >= 0 {
+		// Unknown types are left as an empty NodeType, which no Node matches
+		part.nodeType = nodePathTypes[part.pattern[colon+1:]]
+		part.typed = true
+		part.pattern = part.pattern[:colon]
+	}
+
+	if part.pattern == "" {
+		part.pattern = "*"
+	}
 
+	return part
+
+}
+
+// matches returns if the Node matches the path part's name pattern and type filter.
+func (part nodePathPart) matches(node INode) bool {
+
+	if part.typed && (part.nodeType == "" || !node.Type().Is(part.nodeType)) {
+		return false
+	}
+
+	if !strings.ContainsAny(part.pattern, "*?") {
+		return node.Name() == part.pattern
+	}
+
+	return matchWildcard([]rune(part.pattern), []rune(node.Name()))
+
+}
+
+// matchWildcard returns if the text matches the pattern, where "*" matches any number of characters and "?" matches a single character.
+func matchWildcard(pattern, text []rune) bool {
+
+	p, t := 0, 0
+	starP, starT := -1, 0
+
+	for t < len(text) {
+
+		if p < len(pattern) && (pattern[p] == '?' || pattern[p] == text[t]) {
+			p++
+			t++
+		} else if p < len(pattern) && pattern[p] == '*' {
+			// Remember where the star was, so it can consume more characters if the rest of the pattern doesn't match
+			starP, starT = p, t
+			p++
+		} else if starP >= 0 {
+			starT++
+			p, t = starP+1, starT
+		} else {
+			return false
+		}
+
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
 	}
 
-	return search(node)
+	return p == len(pattern)
 
 }
 
@@ -1294,12 +1703,29 @@ func (node *Node) sectorHierarchy() *Sector {
 // Sector returns the Sector this node is in hierarchically. If that fails, then
 // Sector() will search the scene tree spatially to see which of the sectors the
 // calling Node lies in.
+// The result is cached and only re-evaluated when the Node moves. A moving Node stays assigned
+// to its current Sector until it leaves that Sector's AABB by more than the Sector's ExitMargin;
+// this way, objects sitting on the border between two Sectors don't flicker back and forth between them.
+// If the Node changes Sectors, its OnSectorChange callback is called.
 func (node *Node) Sector() *Sector {
+	if onSectorChange := node.refreshSector(); onSectorChange != nil {
+		onSectorChange()
+	}
+	return node.cachedSector
+}
+
+// refreshSector re-evaluates the Sector the Node is in if it has moved, returning the call to the Node's OnSectorChange callback
+// to make if its Sector changed (or nil otherwise), so that Cameras can make it once they're done rendering.
+func (node *Node) refreshSector() func() {
 
-	if node.cachedSector != nil {
-		return node.cachedSector
+	if node.cachedSector != nil && !node.sectorDirty {
+		return nil
 	}
 
+	node.sectorDirty = false
+
+	prevSector := node.cachedSector
+
 	if sectorHierarchy := node.sectorHierarchy(); sectorHierarchy != nil {
 		node.cachedSector = sectorHierarchy
 	} else {
@@ -1312,26 +1738,46 @@ func (node *Node) Sector() *Sector {
 
 			pos := node.WorldPosition()
 
-			root.SearchTree().bySectors().ForEach(func(child INode) bool {
-				sectorModel := child.(*Model)
-				if sectorModel.sector.AABB.PointInside(pos) {
-					node.cachedSector = sectorModel.sector
-					return false
-				}
-				return true
-			})
+			// Only search for a new Sector if we've left the previous one (plus its margin).
+			if prevSector == nil || prevSector.Model.Root() != root || !prevSector.pointInside(pos, prevSector.ExitMargin) {
+
+				node.cachedSector = nil
+
+				// If Sectors overlap, we prefer the smallest one, just like the Camera does.
+				root.SearchTree().bySectors().ForEach(func(child INode) bool {
+					sector := child.(*Model).sector
+					if sector.AABB.PointInside(pos) {
+						if node.cachedSector == nil || sector.AABB.Dimensions.MaxSpan() < node.cachedSector.AABB.Dimensions.MaxSpan() {
+							node.cachedSector = sector
+						}
+					}
+					return true
+				})
+
+			}
 
 		}
 
 	}
 
-	return node.cachedSector
+	if node.cachedSector != prevSector && node.callbacks != nil && node.callbacks.OnSectorChange != nil {
+		onSectorChange, owner, newSector := node.callbacks.OnSectorChange, node.getOwner(), node.cachedSector
+		return func() { onSectorChange(owner, prevSector, newSector) }
+	}
+
+	return nil
 
 }
 
-func (node *Node) isInVisibleSector(sectorModels []*Model) bool {
-	for _, model := range sectorModels {
-		if model.sector.rendering && model.sector.AABB.PointInside(node.WorldPosition()) {
+// isInVisibleSector returns if the Node's position is within any Sector being rendered in the current Camera.RenderNodes() call.
+// The Node's Sector is also refreshed; if it changes, its OnSectorChange callback is queued in the given buffers, as the render lock is held.
+func (node *Node) isInVisibleSector(buffers *renderBuffers) bool {
+	if onSectorChange := node.refreshSector(); onSectorChange != nil {
+		buffers.callbacks = append(buffers.callbacks, onSectorChange)
+	}
+	pos := node.WorldPosition()
+	for _, sector := range buffers.renderingSectors {
+		if sector.AABB.PointInside(pos) {
 			return true
 		}
 	}
@@ -0,0 +1,108 @@
+package tetra3d
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sync/atomic"
+)
+
+// stableNodeIDBit is set on the IDs of Nodes loaded from GLTF files (which are derived from the Nodes' names), so they never collide with
+// the IDs of Nodes created at runtime (which are counted up from 0).
+const stableNodeIDBit = uint64(1) << 63
+
+var nodeID atomic.Uint64
+
+// newNodeID returns a new unique ID for a Node created at runtime.
+func newNodeID() uint64 {
+	return nodeID.Add(1) - 1
+}
+
+// assignStableIDs gives each Node in the Scene a stable ID derived from its name, so that the same Node gets the same ID each time
+// the Scene is loaded. Nodes with names that aren't unique in the Scene (i.e. objects instantiated from collections) have IDs derived
+// from their parent's ID, their name, and their index among their same-named siblings instead.
+func assignStableIDs(scene *Scene) {
+
+	nodes := append([]INode{scene.Root}, scene.Root.SearchTree().INodes()...)
+
+	nameCounts := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		nameCounts[node.Name()]++
+	}
+
+	hashID := func(parentID uint64, name string, index int) uint64 {
+		hash := fnv.New64a()
+		if parentID != 0 || index != 0 {
+			binary.Write(hash, binary.LittleEndian, parentID)
+			binary.Write(hash, binary.LittleEndian, int64(index))
+		}
+		hash.Write([]byte(name))
+		return hash.Sum64() | stableNodeIDBit
+	}
+
+	scene.Root.getNode().id = hashID(0, "scene root: "+scene.Name, 0)
+
+	// Parents are visited before their children, so their IDs are already stable when their children's IDs are derived from them
+	for _, node := range nodes[1:] {
+
+		if nameCounts[node.Name()] == 1 {
+			node.getNode().id = hashID(0, node.Name(), 0)
+			continue
+		}
+
+		index := 0
+		for _, sibling := range node.Parent().Children() {
+			if sibling == node {
+				break
+			}
+			if sibling.Name() == node.Name() {
+				index++
+			}
+		}
+
+		node.getNode().id = hashID(node.Parent().ID(), node.Name(), index)
+
+	}
+
+	scene.clearIndexes()
+
+}
+
+// CloneIDMap returns a table mapping the IDs of the Nodes in the original tree to the IDs of the matching Nodes in a clone of it (i.e.
+// one created with original.Clone()). Cloned Nodes are given new IDs, so this allows references to the original's Nodes (i.e. a prefab's)
+// to be resolved to the clone's Nodes, or allows the clone's IDs to be recorded alongside the original's in save files or network messages
+// so they can be restored with Node.SetID() later.
+func CloneIDMap(original, clone INode) map[uint64]uint64 {
+	idMap := map[uint64]uint64{}
+	matchClonedNodes(original, clone, func(originalNode, cloneNode INode) {
+		idMap[originalNode.ID()] = cloneNode.ID()
+	})
+	return idMap
+}
+
+// copyNodeIDs copies the IDs of the Nodes in the original tree to the matching Nodes in the cloned tree.
+func copyNodeIDs(original, clone INode) {
+	matchClonedNodes(original, clone, func(originalNode, cloneNode INode) {
+		cloneNode.getNode().id = originalNode.ID()
+	})
+}
+
+// matchClonedNodes calls the given function for each Node in the original tree along with the matching Node in the cloned tree.
+func matchClonedNodes(original, clone INode, match func(originalNode, cloneNode INode)) {
+
+	match(original, clone)
+
+	originalChildren := original.Children()
+	cloneChildren := clone.Children()
+
+	// Trees that don't match (i.e. a clone whose children were changed in an OnClone callback) are only matched down to where they differ
+	if len(originalChildren) != len(cloneChildren) {
+		return
+	}
+
+	for i := range originalChildren {
+		if originalChildren[i].Name() == cloneChildren[i].Name() {
+			matchClonedNodes(originalChildren[i], cloneChildren[i], match)
+		}
+	}
+
+}
@@ -0,0 +1,212 @@
+package tetra3d
+
+import "time"
+
+// NodePool holds a number of pre-made clones of a template Node, handing them out and taking them back, so that objects spawned
+// frequently (i.e. bullets or pickup effects) don't have to be cloned (and then garbage collected) each time they're spawned.
+// Nodes taken from a NodePool should be returned to it with NodePool.Release() once they're done, rather than being discarded.
+type NodePool struct {
+	Template INode // The Node that the NodePool's Nodes are cloned from.
+	// Grow controls whether the NodePool clones the Template to create a new Node when NodePool.Get() is called with no Nodes
+	// available. If false, NodePool.Get() returns nil instead. Defaults to true.
+	Grow bool
+
+	available []INode
+	pooled    map[INode]*pooledNode
+}
+
+// pooledNode is a Node created by a NodePool, along with the state that it's reset to when it's released back to the pool.
+type pooledNode struct {
+	inUse  bool
+	states []pooledNodeState
+}
+
+// pooledNodeState is the initial state of a Node in the tree of a Node created by a NodePool.
+type pooledNodeState struct {
+	node     INode
+	parent   INode // The Node's parent within the tree (nil for the pooled Node itself)
+	position Vector3
+	scale    Vector3
+	rotation Matrix4
+	visible  bool
+
+	animation *Animation
+	playing   bool
+	playhead  float32
+	layers    []*AnimationLayer
+
+	color Color // The Node's color, if it's a Model
+}
+
+// NewNodePool creates a new NodePool for the given template Node, cloning it the given number of times up-front.
+func NewNodePool(template INode, count int) *NodePool {
+
+	pool := &NodePool{
+		Template:  template,
+		Grow:      true,
+		available: make([]INode, 0, count),
+		pooled:    make(map[INode]*pooledNode, count),
+	}
+
+	pool.Fill(count)
+
+	return pool
+
+}
+
+// Fill clones the NodePool's Template until the given number of Nodes are available in the pool.
+func (pool *NodePool) Fill(count int) {
+	for len(pool.available) < count {
+		pool.available = append(pool.available, pool.create())
+	}
+}
+
+// create clones the NodePool's Template, recording the clone's initial state.
+func (pool *NodePool) create() INode {
+
+	node := pool.Template.Clone()
+
+	tree := append([]INode{node}, node.SearchTree().INodes()...)
+
+	pooled := &pooledNode{
+		states: make([]pooledNodeState, 0, len(tree)),
+	}
+
+	for _, n := range tree {
+
+		state := pooledNodeState{
+			node:     n,
+			position: n.LocalPosition(),
+			scale:    n.LocalScale(),
+			rotation: n.LocalRotation(),
+			visible:  n.Visible(),
+		}
+
+		if n != node {
+			state.parent = n.Parent()
+		}
+
+		if ap := n.AnimationPlayer(); ap != nil {
+			state.animation = ap.Animation
+			state.playing = ap.Playing
+			state.playhead = ap.Playhead
+			for _, layer := range ap.Layers {
+				state.layers = append(state.layers, layer.Clone())
+			}
+		}
+
+		if model, isModel := n.(*Model); isModel {
+			state.color = model.Color
+		}
+
+		pooled.states = append(pooled.states, state)
+
+	}
+
+	pool.pooled[node] = pooled
+
+	return node
+
+}
+
+// Get takes a Node out of the NodePool and returns it; add it to a Scene to spawn it. If the pool has no Nodes available, Get clones
+// the Template to create a new one if NodePool.Grow is true, and returns nil otherwise.
+func (pool *NodePool) Get() INode {
+
+	var node INode
+
+	if len(pool.available) > 0 {
+		node = pool.available[len(pool.available)-1]
+		pool.available[len(pool.available)-1] = nil
+		pool.available = pool.available[:len(pool.available)-1]
+	} else if pool.Grow {
+		node = pool.create()
+	} else {
+		return nil
+	}
+
+	pool.pooled[node].inUse = true
+
+	return node
+
+}
+
+// Release returns the given Node, taken from the NodePool with NodePool.Get(), to the pool. The Node is unparented, and it and its tree
+// are reset to the state they were in when the Node was created: Nodes from the tree that were reparented (or unparented) are placed
+// back under their original parents, and their local transforms and visibility, their AnimationPlayers' animations, playheads, and
+// layers, and the colors of Models are restored. Nodes that were added to the tree after the Node was taken from the pool are left
+// where they are. Releasing a Node that isn't from the NodePool, or that has already been released, does nothing.
+func (pool *NodePool) Release(node INode) {
+
+	pooled, exists := pool.pooled[node]
+
+	if !exists || !pooled.inUse {
+		return
+	}
+
+	pooled.inUse = false
+
+	node.Unparent()
+
+	// The states are in tree order, so parents are restored before their children
+	for _, state := range pooled.states {
+		if state.parent != nil && state.node.Parent() != state.parent {
+			state.parent.AddChildren(state.node)
+		}
+	}
+
+	for _, state := range pooled.states {
+
+		n := state.node
+
+		n.SetLocalPositionVec(state.position)
+		n.SetLocalScaleVec(state.scale)
+		n.SetLocalRotation(state.rotation)
+		n.SetVisible(state.visible, false)
+
+		if ap := n.AnimationPlayer(); ap != nil {
+			ap.Animation = state.animation
+			ap.Playing = state.playing
+			ap.Playhead = state.playhead
+			ap.ChannelsUpdated = false
+
+			ap.prevPlayhead = state.playhead
+			ap.justLooped = false
+			ap.finished = false
+			ap.prevFinishedAnimation = ""
+			ap.touchedMarkers = ap.touchedMarkers[:0]
+			ap.blendStart = time.Time{}
+			ap.prevAnimatedProperties = map[INode]AnimationValues{}
+
+			// The layers' offsets don't need to be undone, as the Nodes' transforms were just reset
+			ap.Layers = nil
+			for _, layer := range state.layers {
+				ap.Layers = append(ap.Layers, layer.Clone())
+			}
+			ap.layersApplied = nil
+
+			ap.rootMotionNode = nil
+			ap.rootMotionDelta = Vector3{}
+			ap.rootMotionPrevSet = false
+			ap.rootMotionLooped = false
+		}
+
+		if model, isModel := n.(*Model); isModel {
+			model.Color = state.color
+		}
+
+	}
+
+	pool.available = append(pool.available, node)
+
+}
+
+// Available returns how many Nodes are available to be taken from the NodePool.
+func (pool *NodePool) Available() int {
+	return len(pool.available)
+}
+
+// InUse returns how many of the NodePool's Nodes have been taken from it and not yet released.
+func (pool *NodePool) InUse() int {
+	return len(pool.pooled) - len(pool.available)
+}
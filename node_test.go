@@ -0,0 +1,117 @@
+package tetra3d
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatchWildcard(t *testing.T) {
+
+	tests := []struct {
+		pattern, text string
+		matches       bool
+	}{
+		{"*", "", true},
+		{"*", "Goblin", true},
+		{"Goblin", "Goblin", true},
+		{"Goblin", "Goblins", false},
+		{"Gob*", "Goblin", true},
+		{"*lin", "Goblin", true},
+		{"G*n", "Goblin", true},
+		{"G*n", "Goblins", false},
+		{"*b*", "Goblin", true},
+		{"*x*", "Goblin", false},
+		{"Gobl?n", "Goblin", true},
+		{"Gobl?n", "Gobln", false},
+		{"?", "", false},
+		{"**", "Goblin", true},
+		{"*in*in", "Spinning", false},
+		{"*in*g", "Spinning", true},
+	}
+
+	for _, test := range tests {
+		if matches := matchWildcard([]rune(test.pattern), []rune(test.text)); matches != test.matches {
+			t.Errorf("matchWildcard(%q, %q) = %t; expected %t", test.pattern, test.text, matches, test.matches)
+		}
+	}
+
+}
+
+func TestParseNodePathPart(t *testing.T) {
+
+	tests := []struct {
+		part     string
+		expected nodePathPart
+	}{
+		{"Goblin", nodePathPart{pattern: "Goblin"}},
+		{"Gob*", nodePathPart{pattern: "Gob*"}},
+		{"Goblin[2]", nodePathPart{pattern: "Goblin", index: 2, indexed: true}},
+		{"*[-1]", nodePathPart{pattern: "*", index: -1, indexed: true}},
+		{"[0]", nodePathPart{pattern: "*", index: 0, indexed: true}},
+		{"*:Model", nodePathPart{pattern: "*", nodeType: NodeTypeModel, typed: true}},
+		{":BoundingObject[1]", nodePathPart{pattern: "*", nodeType: NodeTypeBoundingObject, typed: true, index: 1, indexed: true}},
+		{"*:Unknown", nodePathPart{pattern: "*", typed: true}},
+		{"Item[x]", nodePathPart{pattern: "Item[x]"}},
+	}
+
+	for _, test := range tests {
+		if part := parseNodePathPart(test.part); part != test.expected {
+			t.Errorf("parseNodePathPart(%q) = %+v; expected %+v", test.part, part, test.expected)
+		}
+	}
+
+}
+
+func TestNodeGetAll(t *testing.T) {
+
+	root := NewScene("Scene").Root
+
+	enemies := NewNode("Enemies")
+	root.AddChildren(enemies)
+
+	for _, name := range []string{"Goblin1", "Goblin2", "Orc"} {
+		enemy := NewModel(name, nil)
+		enemy.AddChildren(NewBoundingSphere("Bounds", 1))
+		enemies.AddChildren(enemy)
+	}
+
+	enemies.AddChildren(NewNode("Spawner"), NewNode("Item[1]"))
+
+	tests := []struct {
+		path     string
+		expected []string // The paths of the expected Nodes, sorted
+	}{
+		{"Enemies", []string{"Enemies"}},
+		{"Enemies/Goblin1", []string{"Enemies/Goblin1"}},
+		{"Enemies/Goblin?", []string{"Enemies/Goblin1", "Enemies/Goblin2"}},
+		{"Enemies/*/Bounds", []string{"Enemies/Goblin1/Bounds", "Enemies/Goblin2/Bounds", "Enemies/Orc/Bounds"}},
+		{"Enemies/*:Model", []string{"Enemies/Goblin1", "Enemies/Goblin2", "Enemies/Orc"}},
+		{"Enemies/*:Model[0]", []string{"Enemies/Goblin1"}},
+		{"Enemies/*:Model[-1]", []string{"Enemies/Orc"}},
+		{"Enemies/*[1]", []string{"Enemies/Goblin2"}},
+		{"Enemies/*[10]", nil},
+		{"**/*:BoundingObject", []string{"Enemies/Goblin1/Bounds", "Enemies/Goblin2/Bounds", "Enemies/Orc/Bounds"}},
+		{"Enemies/**/Orc", []string{"Enemies/Orc"}},
+		{"Enemies/Item[1]", []string{"Enemies/Item[1]"}},
+		{"Enemies/Dragon*", nil},
+		{"*/*:Camera", nil},
+	}
+
+	for _, test := range tests {
+
+		paths := []string{}
+		for _, node := range root.GetAll(test.path) {
+			paths = append(paths, node.Path())
+		}
+
+		// Nodes found through "**" are in the order Node.SearchTree() visits them, so only which Nodes are found is compared
+		slices.Sort(paths)
+
+		if strings.Join(paths, ", ") != strings.Join(test.expected, ", ") {
+			t.Errorf("GetAll(%q) = %v; expected %v", test.path, paths, test.expected)
+		}
+
+	}
+
+}
@@ -273,16 +273,146 @@ func (nf NodeFilter) ByPropNameRegex(propNameRegexStrings ...string) NodeFilter
 }
 
 // ByProp allows you to filter a given selection of nodes by a property value check - if the nodes filtered
-// have a property with the given value, they are included.
+// have a property with the given value, they are included (i.e. ByProp("team", "enemy")). Numeric values are compared
+// by value regardless of their types, so ByProp("hp", 10) matches a property set to 10.0.
 // If no matching Nodes are found, an empty NodeFilter is returned.
 func (nf NodeFilter) ByProp(propName string, propValue any) NodeFilter {
 	nf.Filters = append(nf.Filters, func(node INode) bool {
 		value := node.Properties().Get(propName)
-		return value != nil && value.Value == propValue
+		if value == nil {
+			return false
+		}
+		if a, isNumber := propNumber(value.Value); isNumber {
+			b, isNumber := propNumber(propValue)
+			return isNumber && a == b
+		}
+		return value.Value == propValue
+	})
+	return nf
+}
+
+// ByPropLess allows you to filter a given selection of nodes by a numeric property - if the nodes filtered have a
+// numeric property with the given name that's less than the given value, they are included (i.e. ByPropLess("hp", 10)).
+// If no matching Nodes are found, an empty NodeFilter is returned.
+func (nf NodeFilter) ByPropLess(propName string, value float64) NodeFilter {
+	nf.Filters = append(nf.Filters, func(node INode) bool {
+		prop := node.Properties().Get(propName)
+		if prop == nil {
+			return false
+		}
+		number, isNumber := propNumber(prop.Value)
+		return isNumber && number < value
+	})
+	return nf
+}
+
+// ByPropGreater allows you to filter a given selection of nodes by a numeric property - if the nodes filtered have a
+// numeric property with the given name that's greater than the given value, they are included.
+// If no matching Nodes are found, an empty NodeFilter is returned.
+func (nf NodeFilter) ByPropGreater(propName string, value float64) NodeFilter {
+	nf.Filters = append(nf.Filters, func(node INode) bool {
+		prop := node.Properties().Get(propName)
+		if prop == nil {
+			return false
+		}
+		number, isNumber := propNumber(prop.Value)
+		return isNumber && number > value
+	})
+	return nf
+}
+
+// ByPropRange allows you to filter a given selection of nodes by a numeric property - if the nodes filtered have a
+// numeric property with the given name that's between the given minimum and maximum values (inclusive), they are included.
+// If no matching Nodes are found, an empty NodeFilter is returned.
+func (nf NodeFilter) ByPropRange(propName string, min, max float64) NodeFilter {
+	nf.Filters = append(nf.Filters, func(node INode) bool {
+		prop := node.Properties().Get(propName)
+		if prop == nil {
+			return false
+		}
+		number, isNumber := propNumber(prop.Value)
+		return isNumber && number >= min && number <= max
+	})
+	return nf
+}
+
+// propNumber returns the given property value as a float64, and whether it's a number at all.
+func propNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// And allows you to filter a given selection of nodes by the filters of other NodeFilters - if the nodes filtered
+// pass all of the filters of all of the given NodeFilters, they are included. The given NodeFilters are only used for their
+// filters, so they can be created from a blank NodeFilter (i.e. NodeFilter{}.ByProp("team", "enemy")).
+// This is the same as chaining the filters, and is mainly useful for grouping filters inside of Or().
+// If no matching Nodes are found, an empty NodeFilter is returned.
+func (nf NodeFilter) And(others ...NodeFilter) NodeFilter {
+	nf.Filters = append(nf.Filters, func(node INode) bool {
+		for _, other := range others {
+			if !other.passes(node) {
+				return false
+			}
+		}
+		return true
+	})
+	return nf
+}
+
+// Or allows you to filter a given selection of nodes by the filters of other NodeFilters - if the nodes filtered
+// pass all of the filters of any of the given NodeFilters, they are included. The given NodeFilters are only used for their
+// filters, so they can be created from a blank NodeFilter; for example, to find enemies that are either weak or fleeing:
+//
+//	root.SearchTree().ByProp("team", "enemy").Or(NodeFilter{}.ByPropLess("hp", 10), NodeFilter{}.ByProp("fleeing", true))
+//
+// If no matching Nodes are found, an empty NodeFilter is returned.
+func (nf NodeFilter) Or(others ...NodeFilter) NodeFilter {
+	nf.Filters = append(nf.Filters, func(node INode) bool {
+		for _, other := range others {
+			if other.passes(node) {
+				return true
+			}
+		}
+		return false
 	})
 	return nf
 }
 
+// passes returns if the given Node passes all of the NodeFilter's filters.
+func (nf NodeFilter) passes(node INode) bool {
+	for _, filter := range nf.Filters {
+		if !filter(node) {
+			return false
+		}
+	}
+	return true
+}
+
 // ByParentProps allows you to filter a given selection of nodes if the node has a parent with the provided
 // set of property names.
 // If anyProp is true, then any matching Node will be added if it has any of the properties provided;
@@ -475,6 +605,120 @@ func (nf NodeFilter) Grids() NodeCollection[*Grid] {
 	return grids
 }
 
+// Groups returns a slice of the Group nodes contained within the NodeFilter.
+func (nf NodeFilter) Groups() NodeCollection[*Group] {
+	out := nf.execute(nf.Start)
+	groups := make([]*Group, 0, len(out))
+	for _, n := range out {
+		if g, ok := n.(*Group); ok {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// Move moves all of the Nodes in the NodeFilter in local space by the given values at once, invalidating their transforms in a single pass.
+// Note that if both a Node and its parent are in the NodeFilter, the Node moves along with its parent in addition to moving itself,
+// just as if Move() were called on each Node individually.
+func (nf NodeFilter) Move(x, y, z float32) {
+
+	if x == 0 && y == 0 && z == 0 {
+		return
+	}
+
+	out := nf.execute(nf.Start)
+
+	for _, n := range out {
+		node := n.getNode()
+		node.position.X += x
+		node.position.Y += y
+		node.position.Z += z
+	}
+
+	dirtyTransforms(out)
+
+}
+
+// MoveVec moves all of the Nodes in the NodeFilter in local space using the vector provided (see NodeFilter.Move()).
+func (nf NodeFilter) MoveVec(vec Vector3) {
+	nf.Move(vec.X, vec.Y, vec.Z)
+}
+
+// SetVisible sets the visibility of all of the Nodes in the NodeFilter. If recursive is true, the visibility of their children
+// is set as well.
+func (nf NodeFilter) SetVisible(visible, recursive bool) {
+	for _, node := range nf.execute(nf.Start) {
+		node.SetVisible(visible, recursive)
+	}
+}
+
+// SetColorForModels sets the overall multiplicative Color of all of the Models in the NodeFilter (see Model.Color); other
+// types of Nodes are left as-is.
+func (nf NodeFilter) SetColorForModels(color Color) {
+	for _, node := range nf.execute(nf.Start) {
+		if model, ok := node.(*Model); ok {
+			model.Color = color
+		}
+	}
+}
+
+// ReparentTo parents all of the Nodes in the NodeFilter to the given parent Node at once (see Node.AddChildren()).
+// The parent itself, as well as any of its parents in the NodeFilter, are skipped, as a Node can't be parented to itself.
+// Note that if both a Node and its parent are in the NodeFilter, the Node is moved out from under its parent as well.
+func (nf NodeFilter) ReparentTo(parent INode) {
+
+	if parent == nil {
+		return
+	}
+
+	out := nf.execute(nf.Start)
+	children := make([]INode, 0, len(out))
+
+	for _, node := range out {
+		if node != parent.getOwner() && !parent.IsDescendantOf(node) {
+			children = append(children, node)
+		}
+	}
+
+	parent.AddChildren(children...)
+
+}
+
+// dirtyTransforms marks the transforms of the given Nodes and their children as dirty in a single pass over their trees, skipping
+// Nodes that are already covered by one of their parents being in the set.
+func dirtyTransforms(nodes []INode) {
+
+	set := make(map[INode]bool, len(nodes))
+	for _, node := range nodes {
+		set[node.getOwner()] = true
+	}
+
+	dirty := func(node INode) bool {
+		n := node.getNode()
+		n.isTransformDirty = true
+		n.sectorDirty = true
+		return true
+	}
+
+	for _, node := range nodes {
+
+		covered := false
+		for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+			if set[parent] {
+				covered = true
+				break
+			}
+		}
+
+		if !covered {
+			dirty(node)
+			node.SearchTree().ForEach(dirty)
+		}
+
+	}
+
+}
+
 // SortByX applies an X-axis sort on the results of the NodeFilter.
 // Sorts do not combine.
 func (nf NodeFilter) SortByX() NodeFilter {
@@ -0,0 +1,199 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// RecalculateNormals recalculates the physical normals of the Mesh's triangles and the visual normals of its vertices from the
+// current positions of its vertices. This is useful after moving the Mesh's vertices around at runtime (i.e. using a VertexSelection)
+// or merging Meshes together, as otherwise lighting would use the Mesh's stale normals.
+// Each vertex's normal is smoothed across the triangles sharing its position (even across MeshParts and UV seams) that face within
+// smoothingAngle (in radians) of the triangle the vertex belongs to; an angle of 0 gives flat shading, while an angle of Pi (or more)
+// smooths across all triangles. Where a vertex is shared between triangles that need differing normals (i.e. along hard edges),
+// the vertex is split; in this case, the Mesh's vertex buffers are rebuilt, and so the vertex indices of the Mesh change.
+// Note that normals are calculated from the Mesh's vertex positions, not from any deformation applied while rendering
+// (i.e. through armatures or Model.VertexTransformFunction).
+func (mesh *Mesh) RecalculateNormals(smoothingAngle float32) {
+
+	if len(mesh.Triangles) == 0 {
+		return
+	}
+
+	threshold := math32.Cos(math32.Clamp(smoothingAngle, 0, math32.Pi))
+
+	type corner struct {
+		tri    int
+		normal Vector3 // The triangle's normal, weighted by the angle of the triangle at the corner
+	}
+
+	// Normals are weighted by the angles of the triangles' corners, so that how a surface is split into triangles doesn't skew its normals
+	positionCorners := map[Vector3][]corner{}
+
+	for i, tri := range mesh.Triangles {
+
+		tri.RecalculateNormal()
+
+		for c, index := range tri.VertexIndices {
+			pos := mesh.VertexPositions[index]
+			toNext := mesh.VertexPositions[tri.VertexIndices[(c+1)%3]].Sub(pos).Unit()
+			toPrev := mesh.VertexPositions[tri.VertexIndices[(c+2)%3]].Sub(pos).Unit()
+			positionCorners[pos] = append(positionCorners[pos], corner{i, tri.Normal.Scale(toNext.Angle(toPrev))})
+		}
+
+	}
+
+	cornerNormals := make([][3]Vector3, len(mesh.Triangles))
+
+	normals := make([]Vector3, len(mesh.VertexPositions))
+	assigned := make([]bool, len(mesh.VertexPositions))
+	split := false
+
+	for i, tri := range mesh.Triangles {
+
+		for c, index := range tri.VertexIndices {
+
+			sum := Vector3{}
+
+			for _, other := range positionCorners[mesh.VertexPositions[index]] {
+				// A small epsilon keeps coplanar triangles smoothed together when the smoothing angle is 0
+				if other.tri == i || tri.Normal.Dot(mesh.Triangles[other.tri].Normal) >= threshold-0.0001 {
+					sum = sum.Add(other.normal)
+				}
+			}
+
+			normal := sum.Unit()
+			if normal.IsZero() {
+				normal = tri.Normal
+			}
+
+			cornerNormals[i][c] = normal
+
+			if !assigned[index] {
+				normals[index] = normal
+				assigned[index] = true
+			} else if !normals[index].Equals(normal) {
+				split = true
+			}
+
+		}
+
+	}
+
+	if !split {
+		for i, normal := range normals {
+			if assigned[i] {
+				mesh.VertexNormals[i] = normal
+			}
+		}
+		if len(mesh.VertexTangents) > 0 {
+			mesh.GenerateTangents()
+		}
+		return
+	}
+
+	// Otherwise, each MeshPart is rebuilt with a vertex for each combination of vertex and normal used by its triangles
+
+	type splitVertex struct {
+		index  int
+		normal Vector3
+	}
+
+	parts := make([]*meshPartData, 0, len(mesh.MeshParts))
+
+	for _, part := range mesh.MeshParts {
+
+		data := &meshPartData{}
+		indices := map[splitVertex]int{}
+
+		for t := part.TriangleStart; part.TriangleCount() > 0 && t <= part.TriangleEnd; t++ {
+
+			for c, index := range mesh.Triangles[t].VertexIndices {
+
+				key := splitVertex{index, cornerNormals[t][c]}
+
+				local, exists := indices[key]
+
+				if !exists {
+					local = len(data.verts)
+					indices[key] = local
+					vert := mesh.GetVertexInfo(index)
+					vert.NormalX, vert.NormalY, vert.NormalZ = key.normal.X, key.normal.Y, key.normal.Z
+					data.verts = append(data.verts, vert)
+				}
+
+				data.indices = append(data.indices, local)
+
+			}
+
+		}
+
+		parts = append(parts, data)
+
+	}
+
+	mesh.rebuildParts(parts)
+
+}
+
+// GenerateTangents generates the Mesh's VertexTangents from its vertex positions, normals, and UV values. Tangents point along
+// the direction the UV map's U axis increases on the Mesh's surface, perpendicular to the vertices' normals; the W component of
+// each tangent is the handedness of the UV map (either 1 or -1), so the bitangent can be calculated as normal.Cross(tangent).Scale(W).
+// Tangents can be used by custom shaders for effects that need to know how a texture is oriented on the surface (i.e. normal mapping).
+// Note that GenerateTangents() should be called again after changing the Mesh's vertices; Mesh.Tessellate(), Mesh.Simplify(),
+// Mesh.Subdivide(), and Mesh.RecalculateNormals() regenerate tangents automatically if the Mesh has them.
+func (mesh *Mesh) GenerateTangents() {
+
+	tangents := make([]Vector3, len(mesh.VertexPositions))
+	bitangents := make([]Vector3, len(mesh.VertexPositions))
+
+	for _, tri := range mesh.Triangles {
+
+		i0, i1, i2 := tri.VertexIndices[0], tri.VertexIndices[1], tri.VertexIndices[2]
+
+		e1 := mesh.VertexPositions[i1].Sub(mesh.VertexPositions[i0])
+		e2 := mesh.VertexPositions[i2].Sub(mesh.VertexPositions[i0])
+
+		du1 := mesh.VertexUVs[i1].X - mesh.VertexUVs[i0].X
+		dv1 := mesh.VertexUVs[i1].Y - mesh.VertexUVs[i0].Y
+		du2 := mesh.VertexUVs[i2].X - mesh.VertexUVs[i0].X
+		dv2 := mesh.VertexUVs[i2].Y - mesh.VertexUVs[i0].Y
+
+		// Triangles with degenerate UVs don't contribute a direction
+		r := du1*dv2 - du2*dv1
+		if math32.Abs(r) < 1e-12 {
+			continue
+		}
+
+		// The tangent and bitangent aren't divided by the triangle's UV area (only by its sign), so bigger triangles contribute more
+		tangent := e1.Scale(dv2).Sub(e2.Scale(dv1)).Scale(math32.Abs(r) / r)
+		bitangent := e2.Scale(du1).Sub(e1.Scale(du2)).Scale(math32.Abs(r) / r)
+
+		for _, index := range tri.VertexIndices {
+			tangents[index] = tangents[index].Add(tangent)
+			bitangents[index] = bitangents[index].Add(bitangent)
+		}
+
+	}
+
+	mesh.VertexTangents = make([]Vector4, len(mesh.VertexPositions))
+
+	for i, normal := range mesh.VertexNormals {
+
+		// Gram-Schmidt orthogonalization keeps the tangent perpendicular to the normal
+		tangent := tangents[i].Sub(normal.Scale(normal.Dot(tangents[i]))).Unit()
+
+		if tangent.IsZero() {
+			tangent = normal.Cross(WorldUp).Unit()
+			if tangent.IsZero() {
+				tangent = normal.Cross(WorldRight).Unit()
+			}
+		}
+
+		handedness := float32(1)
+		if normal.Cross(tangent).Dot(bitangents[i]) < 0 {
+			handedness = -1
+		}
+
+		mesh.VertexTangents[i] = Vector4{tangent.X, tangent.Y, tangent.Z, handedness}
+
+	}
+
+}
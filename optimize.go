@@ -0,0 +1,113 @@
+package tetra3d
+
+import "sort"
+
+// OptimizeVertexOrder reorders the triangles of each of the Mesh's MeshParts so that triangles that are close to each other in space are
+// close to each other in memory, and then reorders the vertices so that they're stored in the order the triangles use them. This improves
+// memory locality (and so the speed) of the per-vertex and per-triangle loops run while rendering, which particularly helps large Meshes
+// that have been merged together from many pieces (i.e. level geometry). The Mesh looks the same afterwards.
+// Note that as the Mesh's vertex buffers are rebuilt, the vertex indices of the Mesh change, and so OptimizeVertexOrder() should be called
+// before any vertex selections are made.
+func (mesh *Mesh) OptimizeVertexOrder() {
+
+	if len(mesh.Triangles) == 0 {
+		return
+	}
+
+	parts := mesh.partData()
+
+	for _, data := range parts {
+		data.optimizeOrder()
+	}
+
+	mesh.rebuildParts(parts)
+
+}
+
+// optimizeOrder sorts the triangles along a Z-order curve through their centers, and then renumbers the vertices in the order
+// that the triangles use them.
+func (t *meshPartData) optimizeOrder() {
+
+	if len(t.verts) == 0 {
+		return
+	}
+
+	positions := make([]Vector3, 0, len(t.verts))
+	for _, v := range t.verts {
+		positions = append(positions, Vector3{v.X, v.Y, v.Z})
+	}
+
+	dim := NewDimensionsFromPoints(positions...)
+
+	size := dim.Size()
+
+	// quantize maps a coordinate to 10 bits across the MeshPart's bounds
+	quantize := func(value, min, size float32) uint32 {
+		if size <= 0 {
+			return 0
+		}
+		return uint32((value - min) / size * 1023)
+	}
+
+	triCount := len(t.indices) / 3
+	order := make([]int, triCount)
+	keys := make([]uint32, triCount)
+
+	for tri := range order {
+
+		order[tri] = tri
+
+		center := Vector3{}
+		for c := 0; c < 3; c++ {
+			center = center.Add(positions[t.indices[tri*3+c]])
+		}
+		center = center.Scale(1.0 / 3.0)
+
+		keys[tri] = mortonCode(
+			quantize(center.X, dim.Min.X, size.X),
+			quantize(center.Y, dim.Min.Y, size.Y),
+			quantize(center.Z, dim.Min.Z, size.Z),
+		)
+
+	}
+
+	sort.SliceStable(order, func(i, j int) bool { return keys[order[i]] < keys[order[j]] })
+
+	remap := make([]int, len(t.verts))
+	for i := range remap {
+		remap[i] = -1
+	}
+
+	verts := make([]VertexInfo, 0, len(t.verts))
+	indices := make([]int, 0, len(t.indices))
+
+	for _, tri := range order {
+		for c := 0; c < 3; c++ {
+			index := t.indices[tri*3+c]
+			if remap[index] < 0 {
+				remap[index] = len(verts)
+				verts = append(verts, t.verts[index])
+			}
+			indices = append(indices, remap[index])
+		}
+	}
+
+	t.verts = verts
+	t.indices = indices
+
+}
+
+// mortonCode interleaves the lower 10 bits of the given values into a single 30-bit Z-order curve index.
+func mortonCode(x, y, z uint32) uint32 {
+	return spreadMortonBits(x) | spreadMortonBits(y)<<1 | spreadMortonBits(z)<<2
+}
+
+// spreadMortonBits spreads the lower 10 bits of the value out so that there are two zero bits between each of them.
+func spreadMortonBits(v uint32) uint32 {
+	v &= 0x3ff
+	v = (v | v<<16) & 0x30000ff
+	v = (v | v<<8) & 0x300f00f
+	v = (v | v<<4) & 0x30c30c3
+	v = (v | v<<2) & 0x9249249
+	return v
+}
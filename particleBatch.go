@@ -0,0 +1,282 @@
+package tetra3d
+
+// particleBatchPart is a MeshPart of a ParticleSystem's batch Mesh, holding a number of slots for particles made from one particle factory.
+type particleBatchPart struct {
+	meshPart      *MeshPart
+	vertexStart   int
+	triangleStart int
+	slots         int
+}
+
+// particleBatchGroup is the section of a ParticleSystem's batch Mesh used to render the particles made from one particle factory.
+type particleBatchGroup struct {
+	factory       *Model
+	vertexCount   int // The number of vertices in each particle
+	triangleCount int // The number of triangles in each particle
+	capacity      int
+	parts         []particleBatchPart
+	particles     []*Particle
+}
+
+// SetBatched sets whether the ParticleSystem renders its particles in batched mode. Normally, each particle is a Model that's dynamically
+// batched into the system's root Model, which adds overhead for each particle. In batched mode, the particles' Models are kept out of the
+// scene tree, and the system instead writes all of its living particles' vertices directly into a single Mesh every time it's updated.
+// This Mesh is rendered through a Model (see ParticleSystem.BatchModel()) that's added as a child of the system's root Model.
+// Batched mode is a good deal faster for systems with thousands of particles, though particles in batched mode can't use
+// armature skinning, and their Models' callbacks and children are ignored.
+func (ps *ParticleSystem) SetBatched(batched bool) {
+
+	if batched == (ps.batchModel != nil) {
+		return
+	}
+
+	particles := append(append([]*Particle{}, ps.LivingParticles...), ps.DeadParticles...)
+
+	if batched {
+
+		ps.batchSpace = NewNode("particle batch space")
+		ps.batchModel = NewModel(ps.Root.name+"_particles", NewMesh("particle batch"))
+		ps.batchModel.updateFrustumSphere = false
+		ps.batchGroups = ps.batchGroups[:0]
+		ps.Root.AddChildren(ps.batchModel)
+
+		for _, part := range particles {
+			ps.Root.DynamicBatchRemove(part.ModelBank...)
+		}
+
+		for _, part := range ps.LivingParticles {
+			transform := part.Model.Transform()
+			ps.batchSpace.AddChildren(part.Model)
+			part.Model.SetWorldTransform(transform)
+		}
+
+	} else {
+
+		ps.Root.RemoveChildren(ps.batchModel)
+		ps.batchModel = nil
+		ps.batchSpace = nil
+
+		for _, part := range particles {
+			for _, model := range part.ModelBank {
+				ps.Root.DynamicBatchAdd(model.Mesh.MeshParts[0], model)
+			}
+		}
+
+		for _, part := range ps.LivingParticles {
+			transform := part.Model.Transform()
+			if ps.Settings.LocalPosition {
+				ps.Root.AddChildren(part.Model)
+			} else {
+				ps.Root.Root().AddChildren(part.Model)
+			}
+			part.Model.SetWorldTransform(transform)
+		}
+
+	}
+
+}
+
+// Batched returns whether the ParticleSystem renders its particles in batched mode (see ParticleSystem.SetBatched()).
+func (ps *ParticleSystem) Batched() bool {
+	return ps.batchModel != nil
+}
+
+// BatchModel returns the Model that the ParticleSystem renders its particles through in batched mode, or nil if the ParticleSystem
+// isn't batched.
+func (ps *ParticleSystem) BatchModel() *Model {
+	return ps.batchModel
+}
+
+// updateBatchSpace moves the Node that batched particles are parented to so that it follows the root Model if the particles'
+// positions are local.
+func (ps *ParticleSystem) updateBatchSpace() {
+
+	if ps.Settings.LocalPosition {
+		if !ps.batchSpace.Transform().Equals(ps.Root.Transform()) {
+			ps.batchSpace.SetWorldTransform(ps.Root.Transform())
+		}
+	} else if !ps.batchSpace.Transform().IsIdentity() {
+		ps.batchSpace.ClearLocalTransform()
+	}
+
+}
+
+// updateBatch writes the vertices of the ParticleSystem's living particles into its batch Mesh, regenerating the Mesh
+// if it doesn't have enough room for them.
+func (ps *ParticleSystem) updateBatch() {
+
+	if len(ps.batchGroups) != len(ps.ParticleFactories) {
+		ps.batchGroups = ps.batchGroups[:0]
+		for _, factory := range ps.ParticleFactories {
+			ps.batchGroups = append(ps.batchGroups, &particleBatchGroup{
+				factory:       factory,
+				vertexCount:   len(factory.Mesh.VertexPositions),
+				triangleCount: len(factory.Mesh.Triangles),
+			})
+		}
+	}
+
+	for _, group := range ps.batchGroups {
+		group.particles = group.particles[:0]
+	}
+
+	for _, part := range ps.LivingParticles {
+		group := ps.batchGroups[part.bankIndex]
+		group.particles = append(group.particles, part)
+	}
+
+	regenerate := false
+
+	for _, group := range ps.batchGroups {
+		if len(group.particles) > group.capacity {
+			group.capacity = len(group.particles) * 2
+			if group.capacity < 16 {
+				group.capacity = 16
+			}
+			regenerate = true
+		}
+	}
+
+	if regenerate {
+		ps.generateBatchMesh()
+	}
+
+	mesh := ps.batchModel.Mesh
+	inverted := ps.batchModel.Transform().Inverted()
+
+	for _, group := range ps.batchGroups {
+
+		factoryMesh := group.factory.Mesh
+		channel := factoryMesh.VertexActiveColorChannel
+		slot := 0
+
+		for _, part := range group.parts {
+
+			used := len(group.particles) - slot
+			if used > part.slots {
+				used = part.slots
+			} else if used < 0 {
+				used = 0
+			}
+
+			for i := 0; i < used; i++ {
+
+				particle := group.particles[slot+i]
+				transform := particle.Model.Transform().Mult(inverted)
+				origin := transform.MultVec(Vector3{})
+				color := particle.Model.Color
+
+				start := part.vertexStart + i*group.vertexCount
+
+				for v := 0; v < group.vertexCount; v++ {
+
+					mesh.VertexPositions[start+v] = transform.MultVec(factoryMesh.VertexPositions[v])
+					mesh.VertexNormals[start+v] = transform.MultVec(factoryMesh.VertexNormals[v]).Sub(origin).Unit()
+
+					if channel >= 0 {
+						mesh.VertexColors[0][start+v] = factoryMesh.VertexColors[channel][v].Multiply(color)
+					} else {
+						mesh.VertexColors[0][start+v] = color
+					}
+
+				}
+
+				triStart := part.triangleStart + i*group.triangleCount
+				for t := 0; t < group.triangleCount; t++ {
+					mesh.Triangles[triStart+t].RecalculateCenter()
+				}
+
+			}
+
+			// Only the slots in use are rendered
+			part.meshPart.VertexIndexEnd = part.vertexStart + used*group.vertexCount
+			part.meshPart.TriangleEnd = part.triangleStart + used*group.triangleCount - 1
+
+			slot += used
+
+		}
+
+	}
+
+	ps.batchModel.frustumCullingSphere.SetLocalPositionVec(ps.Root.frustumCullingSphere.position)
+	ps.batchModel.frustumCullingSphere.Radius = ps.Root.frustumCullingSphere.Radius
+	ps.batchModel.frustumCullingSphere.SetLocalScaleVec(ps.Root.frustumCullingSphere.scale)
+
+}
+
+// generateBatchMesh creates the Mesh for the ParticleSystem's batch Model, with enough slots for each group's capacity of particles.
+func (ps *ParticleSystem) generateBatchMesh() {
+
+	mesh := NewMesh("particle batch")
+
+	for _, group := range ps.batchGroups {
+
+		group.parts = group.parts[:0]
+
+		if group.vertexCount == 0 || group.triangleCount == 0 {
+			continue
+		}
+
+		factoryMesh := group.factory.Mesh
+
+		// Each MeshPart can only hold so many triangles (and vertices, as indices are 16-bit when rendering), so larger
+		// groups are split up across multiple MeshParts.
+		slotsPerPart := (MaxTriangleCount - 1) / group.triangleCount
+		if vertexSlots := 65535 / group.vertexCount; vertexSlots < slotsPerPart {
+			slotsPerPart = vertexSlots
+		}
+
+		if slotsPerPart < 1 {
+			continue
+		}
+
+		var material *Material
+		if len(factoryMesh.MeshParts) > 0 {
+			material = factoryMesh.MeshParts[0].Material
+		}
+
+		for remaining := group.capacity; remaining > 0; remaining -= slotsPerPart {
+
+			slots := slotsPerPart
+			if remaining < slots {
+				slots = remaining
+			}
+
+			verts := make([]VertexInfo, 0, slots*group.vertexCount)
+			indices := make([]int, 0, slots*group.triangleCount*3)
+
+			for s := 0; s < slots; s++ {
+
+				for v := 0; v < group.vertexCount; v++ {
+					uv := factoryMesh.VertexUVs[v]
+					verts = append(verts, NewVertex(0, 0, 0, uv.X, uv.Y))
+				}
+
+				for _, tri := range factoryMesh.Triangles {
+					for _, index := range tri.VertexIndices {
+						indices = append(indices, s*group.vertexCount+index)
+					}
+				}
+
+			}
+
+			mesh.AddVertices(verts...)
+			meshPart := mesh.AddMeshPart(material, indices...)
+
+			group.parts = append(group.parts, particleBatchPart{
+				meshPart:      meshPart,
+				vertexStart:   meshPart.VertexIndexStart,
+				triangleStart: meshPart.TriangleStart,
+				slots:         slots,
+			})
+
+		}
+
+	}
+
+	mesh.ensureEnoughVertexColorChannels(0)
+	mesh.VertexActiveColorChannel = 0
+
+	ps.batchModel.Mesh = mesh
+
+}
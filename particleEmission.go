@@ -0,0 +1,266 @@
+package tetra3d
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+const (
+	ParticleEmissionShapeSphere      = iota // Particles spawn within (or on the surface of) a sphere around the system's root Model.
+	ParticleEmissionShapeCone               // Particles spawn heading out along a cone extending from the system's root Model.
+	ParticleEmissionShapeBox                // Particles spawn within a box (AABB) around the system's root Model.
+	ParticleEmissionShapeMeshSurface        // Particles spawn at random points on the surface of a Model's Mesh.
+	ParticleEmissionShapeMeshEdges          // Particles spawn at random points along the edges of a Model's Mesh.
+)
+
+// ParticleEmissionShape describes the shape that particles spawn within for a ParticleSystem, which is set through
+// ParticleSystemSettings.EmissionShape. Each shape also has an emission direction (i.e. outwards for spheres, along the cone for cones,
+// or the surface normal for meshes); particles are sped along that direction according to the Speed range.
+// The sphere, cone, and box shapes are relative to the ParticleSystem's root Model (following its position, rotation, and scale), while the
+// mesh shapes follow the Model they're set to.
+type ParticleEmissionShape struct {
+	Type int // The type of the shape (i.e. ParticleEmissionShapeSphere).
+
+	Radius  float32 // The radius of the sphere, or of the base of the cone. Defaults to 1.
+	Surface bool    // If particles should only spawn on the surface of the sphere, rather than within it. Defaults to false.
+
+	// Direction is the local axis that the cone extends along. For boxes, particles are emitted along this direction.
+	// Defaults to WorldUp (+Y).
+	Direction Vector3
+	Angle     float32 // The angle in radians of the cone's spread away from its Direction. Defaults to Pi / 8.
+	Length    float32 // How far along the cone particles can spawn. 0 means they spawn at the base of the cone. Defaults to 0.
+
+	Size Vector3 // The size of the box. Defaults to {1, 1, 1}.
+
+	Model *Model // The Model whose Mesh particles spawn on for the mesh shapes.
+
+	// Speed is the range of speed (per frame, like ParticleSystemSettings.Velocity) added to particles along the shape's emission direction.
+	Speed FloatRange
+
+	cacheMesh  *Mesh
+	cacheType  int
+	cacheCount int
+	cacheTotal []float32 // Cumulative area (or length) of the triangles (or edges) of the cached Mesh
+	cacheEdges [][2]int
+	cacheTris  []*Triangle
+}
+
+// NewParticleEmissionShape creates a new ParticleEmissionShape of the given type (i.e. ParticleEmissionShapeCone).
+func NewParticleEmissionShape(shapeType int) *ParticleEmissionShape {
+	return &ParticleEmissionShape{
+		Type:      shapeType,
+		Radius:    1,
+		Direction: WorldUp,
+		Angle:     math32.Pi / 8,
+		Size:      Vector3{1, 1, 1},
+		Speed:     NewFloatRange(),
+	}
+}
+
+// NewParticleEmissionShapeMesh creates a new ParticleEmissionShape that spawns particles on the surface (or the edges, if edges is true)
+// of the given Model's Mesh.
+func NewParticleEmissionShapeMesh(model *Model, edges bool) *ParticleEmissionShape {
+	shape := NewParticleEmissionShape(ParticleEmissionShapeMeshSurface)
+	if edges {
+		shape.Type = ParticleEmissionShapeMeshEdges
+	}
+	shape.Model = model
+	return shape
+}
+
+// Sample returns a random world-space position within the shape, along with the (unit) direction particles spawned there should be emitted in.
+// root is the Node that the sphere, cone, and box shapes are relative to (usually the ParticleSystem's root Model).
+func (shape *ParticleEmissionShape) Sample(root INode) (Vector3, Vector3) {
+
+	if shape.Type == ParticleEmissionShapeMeshSurface || shape.Type == ParticleEmissionShapeMeshEdges {
+		return shape.sampleMesh()
+	}
+
+	var pos, dir Vector3
+
+	switch shape.Type {
+
+	case ParticleEmissionShapeSphere:
+
+		dir = randomUnitVector()
+		dist := shape.Radius
+		if !shape.Surface {
+			// The cube root evenly distributes points throughout the volume of the sphere
+			dist *= math32.Pow(rand.Float32(), 1.0/3.0)
+		}
+		pos = dir.Scale(dist)
+
+	case ParticleEmissionShapeCone:
+
+		axis := shape.Direction.Unit()
+		if axis.IsZero() {
+			axis = WorldUp
+		}
+
+		tangent := axis.Cross(WorldUp)
+		if tangent.IsZero() {
+			tangent = axis.Cross(WorldRight)
+		}
+		tangent = tangent.Unit()
+		bitangent := axis.Cross(tangent)
+
+		// Pick a random direction within the cone's spherical cap
+		cosAngle := 1 - rand.Float32()*(1-math32.Cos(shape.Angle))
+		sinAngle := math32.Sqrt(1 - cosAngle*cosAngle)
+		spin := rand.Float32() * math32.Pi * 2
+
+		dir = axis.Scale(cosAngle).Add(tangent.Scale(sinAngle * math32.Cos(spin))).Add(bitangent.Scale(sinAngle * math32.Sin(spin)))
+
+		// Start from a random point on the cone's base
+		baseDist := shape.Radius * math32.Sqrt(rand.Float32())
+		baseSpin := rand.Float32() * math32.Pi * 2
+		pos = tangent.Scale(baseDist * math32.Cos(baseSpin)).Add(bitangent.Scale(baseDist * math32.Sin(baseSpin)))
+		pos = pos.Add(dir.Scale(shape.Length * rand.Float32()))
+
+	case ParticleEmissionShapeBox:
+
+		pos = Vector3{
+			(rand.Float32() - 0.5) * shape.Size.X,
+			(rand.Float32() - 0.5) * shape.Size.Y,
+			(rand.Float32() - 0.5) * shape.Size.Z,
+		}
+		dir = shape.Direction.Unit()
+
+	}
+
+	if root == nil {
+		return pos, dir
+	}
+
+	return root.Transform().MultVec(pos), root.WorldRotation().MultVec(dir).Unit()
+
+}
+
+// sampleMesh returns a random world-space position on the surface or edges of the shape's Model, along with the surface normal there.
+func (shape *ParticleEmissionShape) sampleMesh() (Vector3, Vector3) {
+
+	if shape.Model == nil || shape.Model.Mesh == nil || len(shape.Model.Mesh.Triangles) == 0 {
+		if shape.Model != nil {
+			return shape.Model.WorldPosition(), Vector3{}
+		}
+		return Vector3{}, Vector3{}
+	}
+
+	shape.updateCache()
+
+	mesh := shape.Model.Mesh
+
+	if len(shape.cacheTotal) == 0 {
+		return shape.Model.WorldPosition(), Vector3{}
+	}
+
+	total := shape.cacheTotal[len(shape.cacheTotal)-1]
+	target := rand.Float32() * total
+	index := sort.Search(len(shape.cacheTotal), func(i int) bool { return shape.cacheTotal[i] >= target })
+	if index >= len(shape.cacheTotal) {
+		index = len(shape.cacheTotal) - 1
+	}
+
+	var pos, normal Vector3
+
+	if shape.Type == ParticleEmissionShapeMeshEdges {
+
+		edge := shape.cacheEdges[index]
+		pos = mesh.VertexPositions[edge[0]].Lerp(mesh.VertexPositions[edge[1]], rand.Float32())
+		normal = shape.cacheTris[index].Normal
+
+	} else {
+
+		tri := shape.cacheTris[index]
+		v0 := mesh.VertexPositions[tri.VertexIndices[0]]
+		v1 := mesh.VertexPositions[tri.VertexIndices[1]]
+		v2 := mesh.VertexPositions[tri.VertexIndices[2]]
+
+		// Uniformly distributed barycentric coordinates
+		r1 := math32.Sqrt(rand.Float32())
+		r2 := rand.Float32()
+		pos = v0.Scale(1 - r1).Add(v1.Scale(r1 * (1 - r2))).Add(v2.Scale(r1 * r2))
+		normal = tri.Normal
+
+	}
+
+	return shape.Model.Transform().MultVec(pos), shape.Model.WorldRotation().MultVec(normal).Unit()
+
+}
+
+// updateCache caches the cumulative areas of the Mesh's triangles (or lengths of its edges), so that points can be picked
+// evenly across the Mesh.
+func (shape *ParticleEmissionShape) updateCache() {
+
+	mesh := shape.Model.Mesh
+
+	if shape.cacheMesh == mesh && shape.cacheType == shape.Type && shape.cacheCount == len(mesh.Triangles) {
+		return
+	}
+
+	shape.cacheMesh = mesh
+	shape.cacheType = shape.Type
+	shape.cacheCount = len(mesh.Triangles)
+	shape.cacheTotal = shape.cacheTotal[:0]
+	shape.cacheEdges = shape.cacheEdges[:0]
+	shape.cacheTris = shape.cacheTris[:0]
+
+	total := float32(0)
+
+	if shape.Type == ParticleEmissionShapeMeshEdges {
+
+		added := map[[2]int]bool{}
+
+		for _, tri := range mesh.Triangles {
+
+			for i := 0; i < 3; i++ {
+
+				a, b := tri.VertexIndices[i], tri.VertexIndices[(i+1)%3]
+				if a > b {
+					a, b = b, a
+				}
+
+				if added[[2]int{a, b}] {
+					continue
+				}
+				added[[2]int{a, b}] = true
+
+				total += mesh.VertexPositions[a].Distance(mesh.VertexPositions[b])
+				shape.cacheTotal = append(shape.cacheTotal, total)
+				shape.cacheEdges = append(shape.cacheEdges, [2]int{a, b})
+				shape.cacheTris = append(shape.cacheTris, tri)
+
+			}
+
+		}
+
+	} else {
+
+		for _, tri := range mesh.Triangles {
+
+			v0 := mesh.VertexPositions[tri.VertexIndices[0]]
+			v1 := mesh.VertexPositions[tri.VertexIndices[1]]
+			v2 := mesh.VertexPositions[tri.VertexIndices[2]]
+
+			total += v1.Sub(v0).Cross(v2.Sub(v0)).Magnitude() / 2
+			shape.cacheTotal = append(shape.cacheTotal, total)
+			shape.cacheTris = append(shape.cacheTris, tri)
+
+		}
+
+	}
+
+}
+
+// randomUnitVector returns a random, evenly distributed unit vector.
+func randomUnitVector() Vector3 {
+
+	z := rand.Float32()*2 - 1
+	angle := rand.Float32() * math32.Pi * 2
+	r := math32.Sqrt(1 - z*z)
+
+	return Vector3{r * math32.Cos(angle), r * math32.Sin(angle), z}
+
+}
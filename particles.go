@@ -3,6 +3,7 @@ package tetra3d
 import (
 	"math/rand"
 
+	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/solarlune/tetra3d/math32"
 )
 
@@ -28,6 +29,10 @@ type Particle struct {
 	Life     float32        // How long the particle has left to live
 	Lifetime float32        // How long the particle lives, maximum
 	Data     map[string]any // A custom Data map for storing and retrieving data
+
+	subEmitterTimers []float32
+	bankIndex        int
+	baseScale        Vector3 // The Particle's local scale before ParticleSystemSettings.ScaleCurve is applied
 }
 
 // NewParticle creates a new Particle for the given particle system, with the provided slice of particle factories to make particles from.
@@ -54,7 +59,8 @@ func NewParticle(partSystem *ParticleSystem, partModels []*Model) *Particle {
 
 func (part *Particle) Reinit() {
 
-	part.Model = part.ModelBank[rand.Intn(len(part.ModelBank))]
+	part.bankIndex = rand.Intn(len(part.ModelBank))
+	part.Model = part.ModelBank[part.bankIndex]
 	part.Model.ClearLocalTransform()
 	part.Life = 0
 }
@@ -66,8 +72,30 @@ func (part *Particle) Update(dt float32) {
 
 	part.Life += dt
 
+	settings := part.ParticleSystem.Settings
+
+	lifePerc := float32(1)
+	if part.Lifetime > 0 {
+		lifePerc = math32.Min(part.Life/part.Lifetime, 1)
+	}
+
 	if !part.VelocityAdd.IsZero() {
-		part.Velocity = part.Velocity.Add(part.VelocityAdd)
+		velocityAdd := part.VelocityAdd
+		if curve := settings.GravityCurve; len(curve.Points) > 0 {
+			velocityAdd = velocityAdd.Scale(curve.Value(lifePerc))
+		}
+		part.Velocity = part.Velocity.Add(velocityAdd)
+	}
+
+	if fields := part.ParticleSystem.Settings.ForceFields; len(fields) > 0 {
+		position := part.Model.WorldPosition()
+		for _, field := range fields {
+			part.Velocity = part.Velocity.Add(field.ForceAt(position, part.Life))
+		}
+	}
+
+	if zones := part.ParticleSystem.windZones; len(zones) > 0 {
+		part.Model.MoveVec(windAt(zones, part.Model.WorldPosition()).Scale(part.ParticleSystem.Settings.WindInfluence * dt))
 	}
 
 	if !part.Velocity.IsZero() {
@@ -76,7 +104,12 @@ func (part *Particle) Update(dt float32) {
 			part.Velocity = part.Velocity.SubMagnitude(friction)
 		}
 
-		part.Model.MoveVec(part.Velocity)
+		movement := part.Velocity
+		if curve := settings.VelocityCurve; len(curve.Points) > 0 {
+			movement = movement.Scale(curve.Value(lifePerc))
+		}
+
+		part.Model.MoveVec(movement)
 
 	}
 
@@ -84,14 +117,21 @@ func (part *Particle) Update(dt float32) {
 		part.ParticleSystem.Settings.MovementFunction(part)
 	}
 
-	if !part.ScaleAdd.IsZero() {
+	if curve := settings.ScaleCurve; len(curve.Points) > 0 {
+		part.baseScale = part.baseScale.Add(part.ScaleAdd)
+		part.Model.SetLocalScaleVec(part.baseScale.Scale(curve.Value(lifePerc)))
+	} else if !part.ScaleAdd.IsZero() {
 		part.Model.GrowVec(part.ScaleAdd)
 	}
 
 	if !part.RotationAdd.IsZero() {
-		part.Model.RotateVec(WorldRight, part.RotationAdd.X)
-		part.Model.RotateVec(WorldUp, part.RotationAdd.Y)
-		part.Model.RotateVec(WorldBackward, part.RotationAdd.Z)
+		rotationAdd := part.RotationAdd
+		if curve := settings.RotationCurve; len(curve.Points) > 0 {
+			rotationAdd = rotationAdd.Scale(curve.Value(lifePerc))
+		}
+		part.Model.RotateVec(WorldRight, rotationAdd.X)
+		part.Model.RotateVec(WorldUp, rotationAdd.Y)
+		part.Model.RotateVec(WorldBackward, rotationAdd.Z)
 	}
 
 	scale := part.Model.LocalScale()
@@ -100,6 +140,8 @@ func (part *Particle) Update(dt float32) {
 		part.Life = part.Lifetime // Die because the particle got too small
 	}
 
+	part.updateSubEmitters(dt)
+
 	if part.Life >= part.Lifetime {
 		part.Model.visible = false
 		part.ParticleSystem.Remove(part)
@@ -111,6 +153,104 @@ func (part *Particle) Update(dt float32) {
 
 }
 
+// updateSubEmitters triggers the sub-emitters of the Particle's ParticleSystem, if their conditions are met.
+func (part *Particle) updateSubEmitters(dt float32) {
+
+	subEmitters := part.ParticleSystem.Settings.SubEmitters
+
+	if len(subEmitters) == 0 {
+		return
+	}
+
+	if len(part.subEmitterTimers) != len(subEmitters) {
+		part.subEmitterTimers = make([]float32, len(subEmitters))
+		for i, sub := range subEmitters {
+			part.subEmitterTimers[i] = sub.Interval.Value()
+		}
+	}
+
+	dead := part.Life >= part.Lifetime
+
+	for i, sub := range subEmitters {
+
+		if sub == nil || sub.System == nil {
+			continue
+		}
+
+		switch sub.Trigger {
+
+		case SubEmitterTriggerDeath:
+			if dead {
+				sub.emit(part)
+			}
+
+		case SubEmitterTriggerInterval:
+			if dead {
+				continue
+			}
+			part.subEmitterTimers[i] -= dt
+			if part.subEmitterTimers[i] <= 0 {
+				sub.emit(part)
+				part.subEmitterTimers[i] = sub.Interval.Value()
+			}
+
+		}
+
+	}
+
+}
+
+const (
+	SubEmitterTriggerDeath    = iota // The sub-emitter spawns a burst of particles when a particle dies.
+	SubEmitterTriggerInterval        // The sub-emitter spawns bursts of particles periodically while a particle is alive.
+)
+
+// SubEmitter spawns bursts of particles from a secondary ParticleSystem at the position of the particles of another ParticleSystem,
+// either when they die or periodically while they live. This is useful for fireworks, explosions that leave smoke behind, or
+// projectiles that split apart. Note that the secondary ParticleSystem still needs to be updated by calling its Update() function;
+// its Settings.SpawnOn value is usually set to false, so it only spawns particles in bursts.
+type SubEmitter struct {
+	System   *ParticleSystem // The ParticleSystem to spawn particles from.
+	Trigger  int             // What triggers the sub-emitter; defaults to SubEmitterTriggerDeath.
+	Count    IntRange        // How many particles to spawn for each burst.
+	Interval FloatRange      // How often bursts spawn in seconds when Trigger is SubEmitterTriggerInterval.
+
+	// InheritVelocity is how much of the particle's velocity is added to the spawned particles' velocities, ranging from 0 (none) to 1 (all).
+	InheritVelocity float32
+}
+
+// NewSubEmitter creates a new SubEmitter that spawns bursts of count particles from the given ParticleSystem when particles die.
+func NewSubEmitter(system *ParticleSystem, count int) *SubEmitter {
+
+	countRange := NewIntRange()
+	countRange.Set(count, count)
+
+	interval := NewFloatRange()
+	interval.Set(1, 1)
+
+	return &SubEmitter{
+		System:   system,
+		Trigger:  SubEmitterTriggerDeath,
+		Count:    countRange,
+		Interval: interval,
+	}
+
+}
+
+func (sub *SubEmitter) emit(part *Particle) {
+
+	pos := part.Model.WorldPosition()
+	count := sub.Count.Value()
+
+	for i := 0; i < count; i++ {
+		spawned := sub.System.spawn(&pos)
+		if sub.InheritVelocity != 0 {
+			spawned.Velocity = spawned.Velocity.Add(part.Velocity.Scale(sub.InheritVelocity))
+		}
+	}
+
+}
+
 type ParticleSystemSettings struct {
 	SpawnOn     bool        // If the particle system should spawn particles at all
 	SpawnRate   FloatRange  // SpawnRate is how often a particle is spawned in seconds
@@ -118,6 +258,15 @@ type ParticleSystemSettings struct {
 	Lifetime    FloatRange  // Lifetime is how long a particle lives in seconds
 	SpawnOffset VectorRange // The range indicating how far of an offset to move
 
+	// Prewarm is how many seconds to simulate the particle system forward the first time it's updated, so it starts off looking as though
+	// it's been running for a while (i.e. a campfire that's already burning when a level loads). Defaults to 0.
+	Prewarm float32
+
+	// OneShot indicates if the particle system should spawn just a single burst of particles (SpawnCount particles) when it starts (or is
+	// restarted using ParticleSystem.Restart()), rather than spawning particles continuously. Once all of its particles have died, the
+	// particle system turns itself off and calls its OnFinish callback. Defaults to false.
+	OneShot bool
+
 	Velocity    VectorRange // The range indicating how fast a particle constantly moves per frame
 	VelocityAdd VectorRange // The range indicating how fast a particle accelerates per frame
 
@@ -132,6 +281,10 @@ type ParticleSystemSettings struct {
 	VertexSpawnMode  int // VertexSpawnMode influences where a particle spawns. By default, this is ParticleVertexSpawnModeOff.
 	VertexSpawnModel *Model
 
+	// EmissionShape, if set, is the shape that particles spawn within (i.e. a sphere, cone, box, or the surface of a Model's Mesh).
+	// It takes priority over VertexSpawnMode; SpawnOffset and SpawnOffsetFunction still apply on top of it. Defaults to nil.
+	EmissionShape *ParticleEmissionShape
+
 	// SpawnOffsetFunction is a function the user can use to customize spawning position of the particles within the system. This function
 	// is called additively to the SpawnOffset setting.
 	SpawnOffsetFunction func(particle *Particle)
@@ -140,8 +293,25 @@ type ParticleSystemSettings struct {
 	// is called additively to the other movement settings.
 	MovementFunction func(particle *Particle)
 
-	// Todo: Add curves for all features?
 	ColorCurve ColorCurve // ColorCurve is a curve indicating how the spawned particles should change color as they live.
+
+	// The following curves multiply aspects of the spawned particles over their lifetimes (from 0 when they spawn to 1 when they die),
+	// so particles can ease and taper without a MovementFunction; empty curves (the default) don't affect particles.
+
+	ScaleCurve    Curve // ScaleCurve multiplies the particles' scale (i.e. a curve going from 1 to 0 shrinks particles away as they die).
+	VelocityCurve Curve // VelocityCurve multiplies how far the particles move each frame from their velocity.
+	RotationCurve Curve // RotationCurve multiplies how fast the particles spin (see RotationAdd).
+	GravityCurve  Curve // GravityCurve multiplies the particles' acceleration (see VelocityAdd), which is usually used for gravity.
+
+	// SubEmitters spawn bursts of particles from other ParticleSystems when this system's particles die, or periodically while they live.
+	SubEmitters []*SubEmitter
+
+	// ForceFields are the ForceFields that push this system's particles around (in addition to their velocity and acceleration).
+	ForceFields []*ForceField
+
+	// WindInfluence is how strongly the wind of the WindZones in the system's Scene carries its particles along; 1 means particles move
+	// along with the wind entirely, while 0 means they ignore it. Defaults to 1.
+	WindInfluence float32
 }
 
 // NewParticleSystemSettings creates a new particle system settings.
@@ -172,7 +342,13 @@ func NewParticleSystemSettings() *ParticleSystemSettings {
 		VelocityAdd: NewVectorRange(),
 		RotationAdd: NewVectorRange(),
 
-		ColorCurve: NewColorCurve(),
+		ColorCurve:    NewColorCurve(),
+		ScaleCurve:    NewCurve(),
+		VelocityCurve: NewCurve(),
+		RotationCurve: NewCurve(),
+		GravityCurve:  NewCurve(),
+
+		WindInfluence: 1,
 	}
 }
 
@@ -185,6 +361,8 @@ func (pss *ParticleSystemSettings) Clone() *ParticleSystemSettings {
 		SpawnRate:  pss.SpawnRate,
 		SpawnCount: pss.SpawnCount,
 		Lifetime:   pss.Lifetime,
+		Prewarm:    pss.Prewarm,
+		OneShot:    pss.OneShot,
 
 		Velocity:    pss.Velocity,
 		VelocityAdd: pss.VelocityAdd,
@@ -195,6 +373,10 @@ func (pss *ParticleSystemSettings) Clone() *ParticleSystemSettings {
 		Friction:    pss.Friction,
 
 		ColorCurve:      pss.ColorCurve,
+		ScaleCurve:      pss.ScaleCurve.Clone(),
+		VelocityCurve:   pss.VelocityCurve.Clone(),
+		RotationCurve:   pss.RotationCurve.Clone(),
+		GravityCurve:    pss.GravityCurve.Clone(),
 		VertexSpawnMode: pss.VertexSpawnMode,
 
 		MovementFunction:    pss.MovementFunction,
@@ -203,6 +385,12 @@ func (pss *ParticleSystemSettings) Clone() *ParticleSystemSettings {
 		LocalPosition:      pss.LocalPosition,
 		AllowNegativeScale: pss.AllowNegativeScale,
 		VertexSpawnModel:   pss.VertexSpawnModel,
+		EmissionShape:      pss.EmissionShape,
+
+		SubEmitters: append([]*SubEmitter{}, pss.SubEmitters...),
+		ForceFields: append([]*ForceField{}, pss.ForceFields...),
+
+		WindInfluence: pss.WindInfluence,
 	}
 
 	return newPS
@@ -219,9 +407,26 @@ type ParticleSystem struct {
 	ParticleFactories []*Model
 	Root              *Model
 
+	// SpawnScale scales the number of particles spawned each time the ParticleSystem spawns particles; for example, 0.5 would
+	// spawn half as many particles as usual. This is used to throttle particles under load (see QualityGovernor). Defaults to 1.
+	SpawnScale float32
+
+	// OnFinish is a callback called when a OneShot ParticleSystem finishes (i.e. once all of the particles spawned by its burst have died).
+	OnFinish func(ps *ParticleSystem)
+
+	prewarmed    bool
+	burstSpawned bool
+	finished     bool
+
 	spawnTimer       float32
+	spawnRemainder   float32
 	Settings         *ParticleSystemSettings
 	vertexSpawnIndex int
+	windZones        []*WindZone
+
+	batchModel  *Model
+	batchSpace  *Node
+	batchGroups []*particleBatchGroup
 }
 
 // NewParticleSystem creates a new ParticleSystem, operating on the baseModel Model and
@@ -251,6 +456,8 @@ func NewParticleSystem(baseModel *Model, particles ...*Model) *ParticleSystem {
 
 		Settings: NewParticleSystemSettings(),
 
+		SpawnScale: 1,
+
 		On: true,
 	}
 
@@ -268,6 +475,8 @@ func (ps *ParticleSystem) Clone() *ParticleSystem {
 
 	newPS := NewParticleSystem(ps.Root, ps.ParticleFactories...)
 	newPS.Settings = ps.Settings
+	newPS.SpawnScale = ps.SpawnScale
+	newPS.OnFinish = ps.OnFinish
 	return newPS
 
 }
@@ -275,9 +484,25 @@ func (ps *ParticleSystem) Clone() *ParticleSystem {
 // Update should be called once per tick.
 func (ps *ParticleSystem) Update(dt float32) {
 
+	if !ps.prewarmed {
+		ps.prewarmed = true
+		if ps.Settings.Prewarm > 0 {
+			ps.Prewarm(ps.Settings.Prewarm)
+		}
+	}
+
 	furthestDist := float32(0.0)
 	largestParticle := float32(0.0)
 
+	ps.windZones = ps.windZones[:0]
+	if ps.Settings.WindInfluence != 0 && len(ps.LivingParticles) > 0 {
+		ps.windZones = sceneWindZones(ps.Root, ps.windZones)
+	}
+
+	if ps.batchModel != nil {
+		ps.updateBatchSpace()
+	}
+
 	for _, part := range ps.LivingParticles {
 		part.Update(dt)
 		furthestDist = math32.Max(furthestDist, ps.Root.DistanceSquaredTo(part.Model))
@@ -304,21 +529,42 @@ func (ps *ParticleSystem) Update(dt float32) {
 
 	ps.toRemove = ps.toRemove[:0]
 
+	if ps.batchModel != nil {
+		ps.updateBatch()
+	}
+
+	if ps.On && ps.Settings.OneShot && ps.burstSpawned && len(ps.LivingParticles) == 0 {
+		ps.On = false
+		ps.finished = true
+		if ps.OnFinish != nil {
+			ps.OnFinish(ps)
+		}
+	}
+
 	if !ps.On {
 		return
 	}
 
 	if ps.Settings.SpawnOn {
 
-		if ps.spawnTimer <= 0 {
-			spawnCount := int(ps.Settings.SpawnCount.Value())
-			for i := 0; i < spawnCount; i++ {
-				ps.Spawn()
+		if ps.Settings.OneShot {
+
+			if !ps.burstSpawned {
+				ps.spawnBurst()
+				ps.burstSpawned = true
+			}
+
+		} else {
+
+			if ps.spawnTimer <= 0 {
+				ps.spawnBurst()
+				ps.spawnTimer = ps.Settings.SpawnRate.Value()
 			}
-			ps.spawnTimer = ps.Settings.SpawnRate.Value()
+
+			ps.spawnTimer -= dt
+
 		}
 
-		ps.spawnTimer -= dt
 	}
 
 	// if len(ps.Root.DynamicBatchModels) > 0 {
@@ -327,8 +573,104 @@ func (ps *ParticleSystem) Update(dt float32) {
 
 }
 
+// spawnBurst spawns SpawnCount particles, scaled by the ParticleSystem's SpawnScale.
+func (ps *ParticleSystem) spawnBurst() {
+
+	spawnCount := int(ps.Settings.SpawnCount.Value())
+
+	if ps.SpawnScale != 1 {
+		// Fractional particles carry over to the next spawn, so that low scales still spawn particles occasionally
+		ps.spawnRemainder += float32(spawnCount) * math32.Max(ps.SpawnScale, 0)
+		spawnCount = int(ps.spawnRemainder)
+		ps.spawnRemainder -= float32(spawnCount)
+	}
+
+	for i := 0; i < spawnCount; i++ {
+		ps.Spawn()
+	}
+
+}
+
+// Prewarm simulates the ParticleSystem forward by the given duration in seconds, updating it in steps as though the game were running
+// at its current ticks per second (see ebiten.TPS()). This is done automatically the first time the ParticleSystem is updated if its
+// Settings.Prewarm value is greater than 0, but it can also be called manually (i.e. after restarting a ParticleSystem).
+func (ps *ParticleSystem) Prewarm(duration float32) {
+
+	ps.prewarmed = true
+
+	tps := ebiten.TPS()
+	if tps <= 0 {
+		tps = 60
+	}
+
+	dt := 1 / float32(tps)
+
+	for t := float32(0); t < duration; t += dt {
+		ps.Update(dt)
+	}
+
+}
+
+// Restart turns the ParticleSystem back on and resets its spawn timer, so that a OneShot ParticleSystem spawns its burst of particles again.
+func (ps *ParticleSystem) Restart() {
+	ps.On = true
+	ps.spawnTimer = 0
+	ps.burstSpawned = false
+	ps.finished = false
+}
+
+// Finished returns if the ParticleSystem is a OneShot ParticleSystem that has finished (i.e. all of the particles spawned by its burst
+// have died, and so it has turned itself off).
+func (ps *ParticleSystem) Finished() bool {
+	return ps.finished
+}
+
+// AddForceFields registers the given ForceFields with the ParticleSystem, so that they push its particles around.
+func (ps *ParticleSystem) AddForceFields(fields ...*ForceField) {
+	for _, field := range fields {
+		if !ps.HasForceField(field) {
+			ps.Settings.ForceFields = append(ps.Settings.ForceFields, field)
+		}
+	}
+}
+
+// RemoveForceFields unregisters the given ForceFields from the ParticleSystem.
+func (ps *ParticleSystem) RemoveForceFields(fields ...*ForceField) {
+	for _, field := range fields {
+		for i, existing := range ps.Settings.ForceFields {
+			if existing == field {
+				ps.Settings.ForceFields = append(ps.Settings.ForceFields[:i], ps.Settings.ForceFields[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// HasForceField returns if the given ForceField is registered with the ParticleSystem.
+func (ps *ParticleSystem) HasForceField(field *ForceField) bool {
+	for _, existing := range ps.Settings.ForceFields {
+		if existing == field {
+			return true
+		}
+	}
+	return false
+}
+
 // Spawn spawns exactly one particle when called.
 func (ps *ParticleSystem) Spawn() {
+	ps.spawn(nil)
+}
+
+// Burst spawns the given number of particles at once at the world position provided, rather than at the system's root Model
+// (or the vertices of its VertexSpawnModel). The SpawnOffset and SpawnOffsetFunction settings still apply.
+func (ps *ParticleSystem) Burst(position Vector3, count int) {
+	for i := 0; i < count; i++ {
+		ps.spawn(&position)
+	}
+}
+
+// spawn spawns a particle, returning it. If position is non-nil, the particle spawns there.
+func (ps *ParticleSystem) spawn(position *Vector3) *Particle {
 
 	var part *Particle
 	if len(ps.DeadParticles) > 0 {
@@ -337,8 +679,10 @@ func (ps *ParticleSystem) Spawn() {
 		ps.DeadParticles = ps.DeadParticles[1:]
 	} else {
 		part = NewParticle(ps, ps.ParticleFactories)
-		for _, model := range part.ModelBank {
-			ps.Root.DynamicBatchAdd(model.Mesh.MeshParts[0], model)
+		if ps.batchModel == nil {
+			for _, model := range part.ModelBank {
+				ps.Root.DynamicBatchAdd(model.Mesh.MeshParts[0], model)
+			}
 		}
 		// for _, newModel := range part.ModelBank {
 		// 	ps.Root.DynamicBatchAdd(ps.Root.Mesh.FindMeshPart(part.Model.Mesh.MeshParts[0].Material.Name), newModel)
@@ -351,22 +695,35 @@ func (ps *ParticleSystem) Spawn() {
 
 	part.Reinit()
 
-	if ps.Settings.LocalPosition {
+	if ps.batchModel != nil {
+		ps.batchSpace.AddChildren(part.Model)
+	} else if ps.Settings.LocalPosition {
 		ps.Root.AddChildren(part.Model)
 	} else {
 		ps.Root.Root().AddChildren(part.Model)
 	}
 
 	part.Model.SetWorldScaleVec(ps.Settings.Scale.Value())
+	part.baseScale = part.Model.LocalScale()
 
 	part.Velocity = ps.Settings.Velocity.Value()
 	part.VelocityAdd = ps.Settings.VelocityAdd.Value()
 	part.ScaleAdd = ps.Settings.ScaleAdd.Value()
 	part.RotationAdd = ps.Settings.RotationAdd.Value()
 
+	part.subEmitterTimers = part.subEmitterTimers[:0]
+
 	var pos Vector3
 
-	if ps.Settings.VertexSpawnMode != ParticleVertexSpawnModeOff && ps.Settings.VertexSpawnModel != nil {
+	if position != nil {
+		pos = *position
+	} else if shape := ps.Settings.EmissionShape; shape != nil {
+		var dir Vector3
+		pos, dir = shape.Sample(ps.Root)
+		if speed := shape.Speed.Value(); speed != 0 {
+			part.Velocity = part.Velocity.Add(dir.Scale(speed))
+		}
+	} else if ps.Settings.VertexSpawnMode != ParticleVertexSpawnModeOff && ps.Settings.VertexSpawnModel != nil {
 
 		model := ps.Settings.VertexSpawnModel
 
@@ -404,6 +761,8 @@ func (ps *ParticleSystem) Spawn() {
 		ps.Settings.SpawnOffsetFunction(part)
 	}
 
+	return part
+
 }
 
 // Remove removes a particle from the ParticleSystem, recycling the Particle for the next time a particle is spawned.
@@ -1,6 +1,7 @@
 package tetra3d
 
 import (
+	"sort"
 	"strconv"
 
 	"github.com/solarlune/tetra3d/math32"
@@ -135,14 +136,57 @@ type IPath interface {
 	// Points returns the points of the IPath in a slice.
 	Points() []Vector3
 	HopCount() int // HopCount returns the number of hops in the path.
+	// Segments returns the hops of the path from each point to the next, along with how each is traversed.
+	Segments() []PathSegment
 	isClosed() bool
 }
 
+// PathSegment is a single hop along an IPath from one point to the next, along with how it's traversed. Agents following a path can
+// use a segment's Type to play the right animation for it (i.e. jumping across a gap, or climbing a ladder).
+type PathSegment struct {
+	Start, End Vector3
+	Type       GridConnectionType // How the segment is traversed. Defaults to GridConnectionTypeWalk.
+	Connection *GridConnection    // The GridConnection the segment crosses, if it's part of a GridPath (and isn't a shortcut made by smoothing).
+	Link       *NavMeshLink       // The off-mesh link the segment crosses, if it's part of a NavMeshPath.
+}
+
+// PathInterpolation indicates how a Path travels from one of its points to the next.
+type PathInterpolation int
+
+const (
+	// PathInterpolationLinear makes a Path travel in straight lines from point to point. This is the default.
+	PathInterpolationLinear PathInterpolation = iota
+	// PathInterpolationCatmullRom makes a Path a Catmull-Rom spline, smoothly curving through each of its points.
+	PathInterpolationCatmullRom
+	// PathInterpolationBezier makes a Path a chain of cubic Bezier curves. The Path's points go anchor, handle, handle, anchor, handle,
+	// handle, anchor, and so on; the curve passes through the anchors, and is pulled towards the handles. For closed Paths, the last two
+	// points are the handles leading back to the first anchor. Curves exported from Blender as Bezier curves are loaded this way.
+	PathInterpolationBezier
+)
+
+// pathCurveSamples is the number of samples taken along each curved segment of a Path to measure its length.
+const pathCurveSamples = 32
+
 // A Path represents a Node that represents a sequential path. All children of the Path are considered its points, in order.
 type Path struct {
 	*Node
 	Closed    bool // Closed indicates if a Path is closed (and so going to the end will return to the start) or not.
 	PathIndex int  // Index of the path points; used to reset the path when navigating through Path.Next().
+
+	// Interpolation indicates how the Path travels between its points - in straight lines, or along a curve. Defaults to PathInterpolationLinear.
+	Interpolation PathInterpolation
+
+	curve pathCurve
+}
+
+// pathCurve caches the shape of a Path, so that distances along it can be mapped to positions on its curve.
+type pathCurve struct {
+	source        []Vector3 // The points the curve was built from
+	interpolation PathInterpolation
+	closed        bool
+	samples       []Vector3 // Positions sampled along the curve
+	params        []float32 // The curve parameter (segment index + progress through the segment) of each sample
+	lengths       []float32 // The distance along the curve to each sample
 }
 
 // NewPath returns a new Path object. A Path is a Node whose children represent points on a path. A Path can be stepped through
@@ -165,6 +209,7 @@ func (path *Path) Clone() INode {
 
 	clone := NewPath(path.name)
 	clone.Closed = path.Closed
+	clone.Interpolation = path.Interpolation
 
 	clone.Node = path.Node.clone(clone).(*Node)
 
@@ -177,7 +222,17 @@ func (path *Path) Clone() INode {
 }
 
 // Length returns the total distance that a Path covers by stepping through all of the children under the Path.
+// If the Path is curved (i.e. its Interpolation isn't PathInterpolationLinear), this is the length of the curve.
 func (path *Path) Length() float32 {
+
+	if path.Interpolation != PathInterpolationLinear {
+		lengths := path.updateCurve().lengths
+		if len(lengths) == 0 {
+			return 0
+		}
+		return lengths[len(lengths)-1]
+	}
+
 	dist := float32(0.0)
 	points := path.Children()
 
@@ -200,19 +255,310 @@ func (path *Path) Length() float32 {
 }
 
 // Points returns the Vector world positions of each point in the Path.
+// If the Path is curved (i.e. its Interpolation isn't PathInterpolationLinear), Points instead returns positions sampled along the
+// curve, so that anything following the points (like a PathStepper) follows the curve.
 func (path *Path) Points() []Vector3 {
-	points := make([]Vector3, 0, len(path.Children()))
+
+	if path.Interpolation != PathInterpolationLinear {
+		samples := path.updateCurve().samples
+		if path.Closed && len(samples) > 1 {
+			// The final sample is back at the start, which is implied by the Path being closed
+			samples = samples[:len(samples)-1]
+		}
+		return append(make([]Vector3, 0, len(samples)), samples...)
+	}
+
+	return path.controlPoints()
+
+}
+
+// controlPoints returns the world positions of the Path's children.
+func (path *Path) controlPoints() []Vector3 {
+	points := make([]Vector3, 0, len(path.children))
 	for _, c := range path.children {
 		points = append(points, c.WorldPosition())
 	}
 	return points
 }
 
+// PositionAtDistance returns the world position at the given distance along the Path, following its curve (if it's curved).
+// As the distance is measured along the Path, stepping the distance by a constant amount moves along the Path at a constant speed,
+// regardless of how far apart its points are. For closed Paths, the distance loops around the Path; otherwise, it's clamped to the
+// Path's ends. If the Path has no points, this returns an empty Vector.
+func (path *Path) PositionAtDistance(distance float32) Vector3 {
+
+	points, u, ok := path.curveParamAtDistance(distance)
+	if !ok {
+		return Vector3{}
+	}
+
+	pos, _ := evaluatePathCurve(points, path.Interpolation, path.Closed, u)
+	return pos
+
+}
+
+// TangentAtDistance returns the direction (as a unit vector) that the Path is heading at the given distance along it.
+// See Path.PositionAtDistance() for more information on how the distance is handled. If the Path has fewer than two points,
+// this returns an empty Vector.
+func (path *Path) TangentAtDistance(distance float32) Vector3 {
+
+	points, u, ok := path.curveParamAtDistance(distance)
+	if !ok || len(points) < 2 {
+		return Vector3{}
+	}
+
+	_, tangent := evaluatePathCurve(points, path.Interpolation, path.Closed, u)
+	return tangent.Unit()
+
+}
+
+// RotationAtDistance returns a rotation Matrix4 that orients an object to face along the Path at the given distance along it
+// (in the same manner as NewLookAtMatrix()), with up being the upward direction (usually +Y, or [0, 1, 0]).
+// This is useful for camera dollies or objects moving along rails.
+func (path *Path) RotationAtDistance(distance float32, up Vector3) Matrix4 {
+	pos := path.PositionAtDistance(distance)
+	return NewLookAtMatrix(pos, pos.Add(path.TangentAtDistance(distance)), up)
+}
+
+// curveParamAtDistance returns the Path's control points, along with the curve parameter at the given distance along the Path.
+func (path *Path) curveParamAtDistance(distance float32) ([]Vector3, float32, bool) {
+
+	curve := path.updateCurve()
+
+	if len(curve.source) == 0 {
+		return nil, 0, false
+	}
+
+	if len(curve.samples) < 2 {
+		return curve.source, 0, true
+	}
+
+	length := curve.lengths[len(curve.lengths)-1]
+
+	if path.Closed && length > 0 {
+		distance = math32.Mod(distance, length)
+		if distance < 0 {
+			distance += length
+		}
+	} else {
+		distance = math32.Clamp(distance, 0, length)
+	}
+
+	i := sort.Search(len(curve.lengths), func(i int) bool { return curve.lengths[i] >= distance })
+
+	if i == 0 {
+		return curve.source, curve.params[0], true
+	}
+
+	if i >= len(curve.lengths) {
+		return curve.source, curve.params[len(curve.params)-1], true
+	}
+
+	span := curve.lengths[i] - curve.lengths[i-1]
+	perc := float32(0)
+	if span > 0 {
+		perc = (distance - curve.lengths[i-1]) / span
+	}
+
+	return curve.source, curve.params[i-1] + (curve.params[i]-curve.params[i-1])*perc, true
+
+}
+
+// updateCurve rebuilds the Path's cached curve if the Path's points or settings have changed since it was last built.
+func (path *Path) updateCurve() *pathCurve {
+
+	points := path.controlPoints()
+	curve := &path.curve
+
+	if curve.samples != nil && curve.interpolation == path.Interpolation && curve.closed == path.Closed && len(curve.source) == len(points) {
+		same := true
+		for i := range points {
+			if !points[i].Equals(curve.source[i]) {
+				same = false
+				break
+			}
+		}
+		if same {
+			return curve
+		}
+	}
+
+	curve.source = points
+	curve.interpolation = path.Interpolation
+	curve.closed = path.Closed
+	curve.samples = curve.samples[:0]
+	curve.params = curve.params[:0]
+	curve.lengths = curve.lengths[:0]
+
+	if len(points) == 0 {
+		return curve
+	}
+
+	segments := pathCurveSegmentCount(len(points), path.Interpolation, path.Closed)
+
+	steps := pathCurveSamples
+	if path.Interpolation == PathInterpolationLinear {
+		steps = 1
+	}
+
+	for i := 0; i <= segments*steps; i++ {
+
+		u := float32(i) / float32(steps)
+		pos, _ := evaluatePathCurve(points, path.Interpolation, path.Closed, u)
+
+		length := float32(0)
+		if i > 0 {
+			length = curve.lengths[i-1] + pos.Distance(curve.samples[i-1])
+		}
+
+		curve.samples = append(curve.samples, pos)
+		curve.params = append(curve.params, u)
+		curve.lengths = append(curve.lengths, length)
+
+	}
+
+	return curve
+
+}
+
+// pathCurveSegmentCount returns how many segments a Path with the given number of points has.
+func pathCurveSegmentCount(pointCount int, interpolation PathInterpolation, closed bool) int {
+
+	if pointCount < 2 {
+		return 0
+	}
+
+	if interpolation == PathInterpolationBezier {
+		if closed {
+			return (pointCount + 2) / 3
+		}
+		return (pointCount + 1) / 3
+	}
+
+	if closed {
+		return pointCount
+	}
+
+	return pointCount - 1
+
+}
+
+// evaluatePathCurve returns the position and (non-normalized) tangent of a Path's curve at the given curve parameter,
+// where the integer part of the parameter is the segment, and the fractional part is the progress through the segment.
+func evaluatePathCurve(points []Vector3, interpolation PathInterpolation, closed bool, u float32) (Vector3, Vector3) {
+
+	segments := pathCurveSegmentCount(len(points), interpolation, closed)
+
+	if segments == 0 {
+		return points[0], Vector3{}
+	}
+
+	segment := int(u)
+	if segment >= segments {
+		segment = segments - 1
+	}
+	if segment < 0 {
+		segment = 0
+	}
+	t := math32.Clamp(u-float32(segment), 0, 1)
+
+	// point returns the control point at the given index, wrapping around for closed Paths and clamping to the end otherwise
+	point := func(i int) Vector3 {
+		if closed {
+			return points[((i%len(points))+len(points))%len(points)]
+		}
+		if i < 0 {
+			return points[0]
+		}
+		if i >= len(points) {
+			return points[len(points)-1]
+		}
+		return points[i]
+	}
+
+	switch interpolation {
+
+	case PathInterpolationCatmullRom:
+
+		p1 := point(segment)
+		p2 := point(segment + 1)
+
+		// Open Paths extrapolate past their ends so the curve starts and ends heading towards its neighbors
+		var p0, p3 Vector3
+		if closed || segment > 0 {
+			p0 = point(segment - 1)
+		} else {
+			p0 = p1.Scale(2).Sub(p2)
+		}
+		if closed || segment+2 < len(points) {
+			p3 = point(segment + 2)
+		} else {
+			p3 = p2.Scale(2).Sub(p1)
+		}
+
+		a := p1.Scale(2)
+		b := p2.Sub(p0)
+		c := p0.Scale(2).Sub(p1.Scale(5)).Add(p2.Scale(4)).Sub(p3)
+		d := p1.Scale(3).Sub(p0).Sub(p2.Scale(3)).Add(p3)
+
+		pos := a.Add(b.Scale(t)).Add(c.Scale(t * t)).Add(d.Scale(t * t * t)).Scale(0.5)
+		tangent := b.Add(c.Scale(2 * t)).Add(d.Scale(3 * t * t)).Scale(0.5)
+
+		return pos, tangent
+
+	case PathInterpolationBezier:
+
+		a := point(segment * 3)
+		b := point(segment*3 + 1)
+		c := point(segment*3 + 2)
+		d := point(segment*3 + 3)
+
+		it := 1 - t
+
+		pos := a.Scale(it * it * it).Add(b.Scale(3 * it * it * t)).Add(c.Scale(3 * it * t * t)).Add(d.Scale(t * t * t))
+		tangent := b.Sub(a).Scale(3 * it * it).Add(c.Sub(b).Scale(6 * it * t)).Add(d.Sub(c).Scale(3 * t * t))
+
+		// Handles sitting on their anchors zero out the tangent at the ends of the segment
+		if tangent.IsZero() {
+			tangent = d.Sub(a)
+		}
+
+		return pos, tangent
+
+	default:
+
+		start := point(segment)
+		end := point(segment + 1)
+		return start.Lerp(end, t), end.Sub(start)
+
+	}
+
+}
+
 // HopCount returns the number of hops in the path (i.e. number of nodes - 1).
 func (path *Path) HopCount() int {
 	return len(path.Children()) - 1
 }
 
+// Segments returns the hops of the path from each point to the next (including from the last point back to the first if the Path is
+// Closed); all of a Path's segments are GridConnectionTypeWalk.
+func (path *Path) Segments() []PathSegment {
+
+	points := path.Points()
+	segments := make([]PathSegment, 0, len(points))
+
+	for i := 1; i < len(points); i++ {
+		segments = append(segments, PathSegment{Start: points[i-1], End: points[i]})
+	}
+
+	if path.Closed && len(points) > 1 {
+		segments = append(segments, PathSegment{Start: points[len(points)-1], End: points[0]})
+	}
+
+	return segments
+
+}
+
 func (path *Path) isClosed() bool {
 	return path.Closed
 }
@@ -238,3 +584,108 @@ func (path *Path) Type() NodeType {
 }
 
 var _ IPath = &Path{} // Sanity check to ensure Path implements IPath.
+
+// PathSmoothOptions controls how a path is smoothed through line-of-sight pruning with SmoothPath().
+type PathSmoothOptions struct {
+	// TestAgainst is the selection of BoundingObjects that block the line of sight between points on the path - this can be
+	// either a NodeFilter or a NodeCollection (a slice of Nodes). If TestAgainst is nil, nothing blocks line of sight.
+	TestAgainst NodeIterator
+
+	// Offset is added to the path's points when testing line of sight between them. This is useful for raising the rays
+	// up off of the ground so that they don't hit the floor that the agent walks on.
+	Offset Vector3
+
+	// Radius is the radius of the agent walking the path. If above 0, line of sight is also checked along each side of the agent,
+	// so that the smoothed path doesn't clip corners.
+	Radius float32
+
+	// Doublesided indicates whether line of sight rays can strike both sides of BoundingTriangles triangles or not.
+	Doublesided bool
+}
+
+// SmoothPath smooths a path by pruning points that can be skipped, as long as there's an unobstructed line of sight (tested using RayTest())
+// between the points before and after them. This turns jagged point-to-point chains (like GridPaths) into more direct,
+// natural-looking routes. SmoothPath returns a new slice of points, leaving the original slice as-is.
+func SmoothPath(points []Vector3, options PathSmoothOptions) []Vector3 {
+
+	indices := smoothPathIndices(points, options)
+
+	smoothed := make([]Vector3, 0, len(indices))
+	for _, i := range indices {
+		smoothed = append(smoothed, points[i])
+	}
+
+	return smoothed
+
+}
+
+// smoothPathIndices returns the indices of the points that remain in the path after smoothing it with SmoothPath().
+func smoothPathIndices(points []Vector3, options PathSmoothOptions) []int {
+
+	if len(points) <= 2 {
+		indices := make([]int, 0, len(points))
+		for i := range points {
+			indices = append(indices, i)
+		}
+		return indices
+	}
+
+	smoothed := []int{0}
+
+	current := 0
+
+	for current < len(points)-1 {
+
+		// Find the furthest point that can be seen from the current one; the next point can always be "seen", as the path goes there directly.
+		next := current + 1
+
+		for i := len(points) - 1; i > current+1; i-- {
+			if pathLineOfSight(points[current], points[i], options) {
+				next = i
+				break
+			}
+		}
+
+		smoothed = append(smoothed, next)
+		current = next
+
+	}
+
+	return smoothed
+
+}
+
+func pathLineOfSight(from, to Vector3, options PathSmoothOptions) bool {
+
+	if options.TestAgainst == nil {
+		return true
+	}
+
+	from = from.Add(options.Offset)
+	to = to.Add(options.Offset)
+
+	offsets := []Vector3{{}}
+
+	if options.Radius > 0 {
+		side := to.Sub(from).Cross(WorldUp).Unit().Scale(options.Radius)
+		if !side.IsZero() {
+			offsets = append(offsets, side, side.Invert())
+		}
+	}
+
+	for _, offset := range offsets {
+
+		if RayTest(RayTestOptions{
+			From:        from.Add(offset),
+			To:          to.Add(offset),
+			TestAgainst: options.TestAgainst,
+			Doublesided: options.Doublesided,
+		}) {
+			return false
+		}
+
+	}
+
+	return true
+
+}
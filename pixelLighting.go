@@ -0,0 +1,186 @@
+package tetra3d
+
+import (
+	"sort"
+)
+
+// MaxPixelLights is the maximum number of lights (PointLights and DirectionalLights) that can light a MeshPart with a per-pixel lit
+// Material at once (see Material.PerPixelLighting); the nearest lights to the Model are used.
+const MaxPixelLights = 8
+
+// pixelLit returns if the given MeshPart of the given Model should be lit per-pixel when rendered through the Camera.
+func (camera *Camera) pixelLit(scene *Scene, model *Model, meshPart *MeshPart) bool {
+	mat := meshPart.Material
+	return camera.RenderDepth && mat != nil && mat.PerPixelLighting && !mat.Shadeless && !model.Shadeless &&
+		scene != nil && scene.World != nil && scene.World.LightingOn
+}
+
+// setPixelLightUniforms sets the uniforms the color shader uses to light the given MeshPart of the given Model per-pixel.
+// lights should be the lights that would otherwise light the Model per-vertex (including the ambient light).
+func (camera *Camera) setPixelLightUniforms(uniforms map[string]any, model *Model, meshPart *MeshPart, lights []ILight, vpMatrix Matrix4, depthOffset float32) {
+
+	buffers := camera.scratch()
+
+	ambient := []float32{0, 0, 0}
+
+	modelPos := model.WorldPosition()
+	maxSpan := float32(0)
+	if model.Mesh != nil {
+		maxSpan = model.Mesh.Dimensions.MaxSpan()
+	}
+
+	buffers.pixelLights = buffers.pixelLights[:0]
+
+	for _, light := range lights {
+
+		if !light.IsOn() {
+			continue
+		}
+
+		switch l := light.(type) {
+
+		case *AmbientLight:
+			ambient[0] += l.color.R * l.energy
+			ambient[1] += l.color.G * l.energy
+			ambient[2] += l.color.B * l.energy
+
+		case *PointLight:
+			// Skip lights that are too far away to light the Model, just like when lighting per-vertex.
+			if l.Range > 0 {
+				dist := maxSpan + l.Range
+				if modelPos.DistanceSquared(l.WorldPosition()) > dist*dist {
+					continue
+				}
+			}
+			buffers.pixelLights = append(buffers.pixelLights, l)
+
+		case *DirectionalLight:
+			buffers.pixelLights = append(buffers.pixelLights, l)
+
+		}
+
+	}
+
+	// DirectionalLights light everything equally, so they go first; after that, the closest PointLights are used.
+	sort.SliceStable(buffers.pixelLights, func(i, j int) bool {
+		_, iSun := buffers.pixelLights[i].(*DirectionalLight)
+		_, jSun := buffers.pixelLights[j].(*DirectionalLight)
+		if iSun || jSun {
+			return iSun && !jSun
+		}
+		return modelPos.DistanceSquared(buffers.pixelLights[i].WorldPosition()) < modelPos.DistanceSquared(buffers.pixelLights[j].WorldPosition())
+	})
+
+	count := len(buffers.pixelLights)
+	if count > MaxPixelLights {
+		count = MaxPixelLights
+	}
+
+	positions := make([]float32, MaxPixelLights*4)
+	colors := make([]float32, MaxPixelLights*4)
+
+	for i, light := range buffers.pixelLights[:count] {
+
+		color := light.Color()
+		energy := light.Energy()
+
+		colors[i*4] = color.R * energy
+		colors[i*4+1] = color.G * energy
+		colors[i*4+2] = color.B * energy
+
+		switch l := light.(type) {
+		case *PointLight:
+			pos := l.WorldPosition()
+			positions[i*4] = pos.X
+			positions[i*4+1] = pos.Y
+			positions[i*4+2] = pos.Z
+			colors[i*4+3] = l.Range
+		case *DirectionalLight:
+			dir := l.WorldRotation().Forward() // Already reversed
+			positions[i*4] = dir.X
+			positions[i*4+1] = dir.Y
+			positions[i*4+2] = dir.Z
+			positions[i*4+3] = 1
+		}
+
+	}
+
+	// The world position of each fragment is reconstructed from its screen position and its depth by solving the view-projection
+	// matrix's equations for the screen X, screen Y, and W (or Z for orthographic Cameras, where W is always 1).
+	third := 3
+	if !camera.perspective {
+		third = 2
+	}
+
+	columns := [3]int{0, 1, third}
+
+	solve := [3][3]float32{}
+	offset := []float32{0, 0, 0}
+	for r, c := range columns {
+		solve[r] = [3]float32{vpMatrix[0][c], vpMatrix[1][c], vpMatrix[2][c]}
+		offset[r] = vpMatrix[3][c]
+	}
+
+	inverse := invert3x3(solve)
+
+	// Kage matrices are column-major
+	inverseUniform := make([]float32, 9)
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			inverseUniform[c*3+r] = inverse[r][c]
+		}
+	}
+
+	depthMargin := (camera.far - camera.near) * camera.DepthMargin
+	depthSpread := camera.far - camera.near + (depthMargin * 2)
+
+	uniforms["PixelLighting"] = 1
+	uniforms["PixelLightingMode"] = meshPart.Material.LightingMode
+	uniforms["PixelAmbient"] = ambient
+	uniforms["PixelLightCount"] = count
+	uniforms["PixelLightPositions"] = positions
+	uniforms["PixelLightColors"] = colors
+	uniforms["PixelInverse"] = inverseUniform
+	uniforms["PixelOffset"] = offset
+	uniforms["PixelDepthRange"] = []float32{depthSpread, depthMargin, depthOffset}
+
+	perspective := 0
+	if camera.perspective {
+		perspective = 1
+	}
+	uniforms["PixelPerspective"] = perspective
+
+}
+
+// invert3x3 returns the inverse of the given 3x3 matrix, or a zero matrix if it can't be inverted.
+func invert3x3(m [3][3]float32) [3][3]float32 {
+
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+
+	if det == 0 {
+		return [3][3]float32{}
+	}
+
+	inv := 1 / det
+
+	return [3][3]float32{
+		{
+			(m[1][1]*m[2][2] - m[1][2]*m[2][1]) * inv,
+			(m[0][2]*m[2][1] - m[0][1]*m[2][2]) * inv,
+			(m[0][1]*m[1][2] - m[0][2]*m[1][1]) * inv,
+		},
+		{
+			(m[1][2]*m[2][0] - m[1][0]*m[2][2]) * inv,
+			(m[0][0]*m[2][2] - m[0][2]*m[2][0]) * inv,
+			(m[0][2]*m[1][0] - m[0][0]*m[1][2]) * inv,
+		},
+		{
+			(m[1][0]*m[2][1] - m[1][1]*m[2][0]) * inv,
+			(m[0][1]*m[2][0] - m[0][0]*m[2][1]) * inv,
+			(m[0][0]*m[1][1] - m[0][1]*m[1][0]) * inv,
+		},
+	}
+
+}
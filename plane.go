@@ -0,0 +1,53 @@
+package tetra3d
+
+// Plane represents an infinite plane in 3D space, dividing it into a front side (the side its Normal points towards) and a back side.
+type Plane struct {
+	Normal   Vector3 // The normal of the Plane; this should be normalized.
+	Distance float32 // The distance of the Plane from the origin along its Normal.
+}
+
+// NewPlane creates a new Plane that passes through the given point and faces in the direction of the given normal.
+func NewPlane(point, normal Vector3) Plane {
+	normal = normal.Unit()
+	return Plane{
+		Normal:   normal,
+		Distance: normal.Dot(point),
+	}
+}
+
+// SignedDistance returns the distance from the Plane to the given point; this is positive for points in front of the Plane and negative
+// for points behind it.
+func (plane Plane) SignedDistance(point Vector3) float32 {
+	return plane.Normal.Dot(point) - plane.Distance
+}
+
+// ClosestPoint returns the point on the Plane closest to the given point.
+func (plane Plane) ClosestPoint(point Vector3) Vector3 {
+	return point.Sub(plane.Normal.Scale(plane.SignedDistance(point)))
+}
+
+// Flipped returns a copy of the Plane that faces the opposite direction (so its front and back sides are swapped).
+func (plane Plane) Flipped() Plane {
+	return Plane{
+		Normal:   plane.Normal.Invert(),
+		Distance: -plane.Distance,
+	}
+}
+
+// transformed returns the coefficients of the Plane's signed distance function in the local space of the given transform (so for a point p
+// in that space, the signed distance is normal.Dot(p) + offset). The returned normal isn't normalized if the transform is scaled.
+func (plane Plane) transformed(transform Matrix4) (normal Vector3, offset float32) {
+
+	n := plane.Normal
+
+	normal = Vector3{
+		transform[0][0]*n.X + transform[0][1]*n.Y + transform[0][2]*n.Z,
+		transform[1][0]*n.X + transform[1][1]*n.Y + transform[1][2]*n.Z,
+		transform[2][0]*n.X + transform[2][1]*n.Y + transform[2][2]*n.Z,
+	}
+
+	offset = transform[3][0]*n.X + transform[3][1]*n.Y + transform[3][2]*n.Z - plane.Distance
+
+	return normal, offset
+
+}
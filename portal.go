@@ -0,0 +1,265 @@
+package tetra3d
+
+import (
+	_ "embed"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/solarlune/tetra3d/math32"
+)
+
+//go:embed shaders/portal.kage
+var portalShaderText []byte
+
+// portalShader is compiled the first time a Portal is created, and then shared between all Portals.
+var portalShader *ebiten.Shader
+
+// Portal is one end of a pair of linked portals; looking into a Portal shows the Scene as seen from its linked Portal, as if the two
+// were connected doorways. Each Portal is made of a rectangular Model that displays the view through it using a custom shader, so the
+// view is masked to the portal's shape, and partially hidden by anything in front of it, like any other surface.
+// The views through Portals are rendered with RenderPortals(), which should be called each frame before rendering the Scene.
+// A Portal's front is the side its Model's +Z axis faces; the view through a Portal looks out of the front of its linked Portal.
+type Portal struct {
+	Model *Model  // The Model making up the Portal's surface; add this to your Scene.
+	Link  *Portal // The Portal that this Portal looks out of; set this using Portal.LinkTo().
+
+	cameras []*Camera // The Cameras rendering the view through the Portal, one for each depth of recursion.
+}
+
+// NewPortal creates a new Portal with the given name and size in world units on the X and Y axes.
+func NewPortal(name string, width, height float32) *Portal {
+
+	w := width / 2
+	h := height / 2
+
+	mesh := NewMesh(name,
+		NewVertex(-w, h, 0, 0, 0),
+		NewVertex(w, -h, 0, 1, 1),
+		NewVertex(w, h, 0, 1, 0),
+		NewVertex(-w, -h, 0, 0, 1),
+	)
+
+	mat := NewMaterial(name)
+	mat.Shadeless = true
+	mat.Fogless = true
+
+	mesh.AddMeshPart(mat,
+		0, 1, 2,
+		3, 1, 0,
+	)
+
+	mesh.Unique = MeshUniqueMeshAndMaterials
+	mesh.UpdateBounds()
+	mesh.AutoNormal()
+
+	portal := &Portal{
+		Model: NewModel(name, mesh),
+	}
+
+	portal.init()
+
+	return portal
+
+}
+
+// init sets up the Portal's Model to render the view through the Portal.
+func (portal *Portal) init() {
+
+	if portalShader == nil {
+		shader, err := ExtendBase3DShader(string(portalShaderText))
+		if err != nil {
+			panic(err)
+		}
+		portalShader = shader
+	}
+
+	portal.Material().SetShader(portalShader)
+	portal.setView(nil)
+
+}
+
+// Material returns the Material used to render the Portal's surface. When the Portal doesn't display a view (i.e. because it
+// isn't linked, or it's seen through too many other Portals), the surface is drawn using the Material's texture and color.
+func (portal *Portal) Material() *Material {
+	return portal.Model.Mesh.MeshParts[0].Material
+}
+
+// LinkTo links the Portal and the other Portal given to each other, so each looks out of the other. Passing nil unlinks the Portal.
+func (portal *Portal) LinkTo(other *Portal) {
+
+	if portal.Link != nil && portal.Link.Link == portal {
+		portal.Link.Link = nil
+	}
+
+	portal.Link = other
+
+	if other != nil {
+		other.Link = portal
+	}
+
+}
+
+// Plane returns the world-space Plane that the Portal's surface lies on, facing out of its front.
+func (portal *Portal) Plane() Plane {
+	return NewPlane(portal.Model.WorldPosition(), portal.Model.WorldRotation().Forward())
+}
+
+// TransformThrough returns the given world transform as it would be after passing through the Portal and out of its linked Portal
+// (i.e. for teleporting a Node that walks into the Portal, or placing a Camera to see through it). If the Portal isn't linked, the
+// transform is returned as-is.
+func (portal *Portal) TransformThrough(transform Matrix4) Matrix4 {
+
+	if portal.Link == nil {
+		return transform
+	}
+
+	// The Portals' scales are ignored, so the transform doesn't change size when passing through Portals of different sizes
+	entrancePos := portal.Model.WorldPosition()
+	entrance := portal.Model.WorldRotation().Mult(NewMatrix4Translate(entrancePos.X, entrancePos.Y, entrancePos.Z))
+
+	exitPos := portal.Link.Model.WorldPosition()
+	exit := portal.Link.Model.WorldRotation().Mult(NewMatrix4Translate(exitPos.X, exitPos.Y, exitPos.Z))
+
+	// Going into the front of the entrance leads out of the front of the exit, so the transform is turned around between them
+	return transform.Mult(entrance.Inverted()).Mult(NewMatrix4Rotate(0, 1, 0, math32.Pi)).Mult(exit)
+
+}
+
+// visibleFrom returns if the Portal's view can be seen from the given Camera.
+func (portal *Portal) visibleFrom(camera *Camera) bool {
+
+	if portal.Link == nil || !portal.Model.Visible() || !portal.Link.Model.Visible() {
+		return false
+	}
+
+	plane := portal.Plane()
+
+	// The view through a Portal can only be seen from its front
+	if camera.Perspective() {
+		if plane.SignedDistance(camera.WorldPosition()) <= 0 {
+			return false
+		}
+	} else if camera.WorldRotation().Forward().Dot(plane.Normal) <= 0 {
+		return false
+	}
+
+	return camera.ModelInFrustum(portal.Model)
+
+}
+
+// camera returns the Camera used to render the view through the Portal at the given depth of recursion, set up to match the Camera
+// the Portal is seen from.
+func (portal *Portal) camera(depth int, viewer *Camera) *Camera {
+
+	for len(portal.cameras) <= depth {
+		portal.cameras = append(portal.cameras, nil)
+	}
+
+	w, h := viewer.Size()
+
+	cam := portal.cameras[depth]
+
+	if cam == nil {
+		cam = NewCamera(w, h)
+		portal.cameras[depth] = cam
+	} else {
+		cam.Resize(w, h)
+	}
+
+	// The Camera has to render depth for the clip plane to be exact
+	cam.RenderDepth = true
+	cam.SetPerspective(viewer.Perspective())
+	cam.SetFieldOfView(viewer.FieldOfView())
+	cam.SetOrthoScale(viewer.OrthoScale())
+	cam.SetNear(viewer.Near())
+	cam.SetFar(viewer.Far())
+	cam.PerspectiveCorrectedTextureMapping = viewer.PerspectiveCorrectedTextureMapping
+	cam.MaxLightCount = viewer.MaxLightCount
+
+	cam.SetWorldTransform(portal.TransformThrough(viewer.Transform()))
+
+	// Objects between the Camera and the linked Portal would otherwise block the view
+	cam.SetClipPlane(portal.Link.Plane())
+
+	return cam
+
+}
+
+// setView sets the image that the Portal displays on its surface; nil draws the surface using its Material instead.
+func (portal *Portal) setView(view *ebiten.Image) {
+
+	options := portal.Material().FragmentShaderOptions
+
+	if options.Uniforms == nil {
+		options.Uniforms = map[string]any{}
+	}
+
+	if view != nil {
+		options.Uniforms["PortalView"] = 1
+	} else {
+		options.Uniforms["PortalView"] = 0
+	}
+
+	options.Images[3] = view
+
+}
+
+// RenderPortals renders the views through the given Portals as seen from the given Camera, so that they're displayed on the Portals'
+// surfaces when the Camera renders the Scene afterwards. RenderPortals should be called once per frame, after the Camera has been
+// positioned and cleared (see Camera.Clear()), and before the Camera renders the Scene. The Camera should render depth (Camera.RenderDepth)
+// for the Portals to display their views.
+// maxDepth is how many Portals deep the views are rendered; for example, with a maxDepth of 2, a Portal seen through another Portal
+// displays its view, but a Portal seen through both doesn't. Each level of depth renders the Scene again for every visible Portal, so
+// keep this low.
+func RenderPortals(camera *Camera, scene *Scene, maxDepth int, portals ...*Portal) {
+	renderPortalViews(camera, scene, 0, maxDepth, portals)
+}
+
+// renderPortalViews renders the views through the Portals visible from the given Camera at the given depth of recursion, and then
+// sets each Portal to display its view at that depth.
+func renderPortalViews(viewer *Camera, scene *Scene, depth, maxDepth int, portals []*Portal) {
+
+	views := make([]*ebiten.Image, len(portals))
+
+	if depth < maxDepth {
+
+		for i, portal := range portals {
+
+			if !portal.visibleFrom(viewer) {
+				continue
+			}
+
+			cam := portal.camera(depth, viewer)
+
+			if scene.World != nil {
+				cam.ClearWithColor(scene.World.ClearColor)
+			} else {
+				cam.ClearWithColor(NewColor(0, 0, 0, 0))
+			}
+
+			// Clearing the Camera and updating its projection also updates its frustum, which is needed to tell which Portals
+			// can be seen through this one
+			cam.Projection()
+
+			// Portals seen through this Portal are rendered first, so their views are ready when the Scene is rendered through it
+			renderPortalViews(cam, scene, depth+1, maxDepth, portals)
+
+			// The linked Portal's surface lies on the clip plane, and would otherwise cover the view
+			exitVisible := portal.Link.Model.Visible()
+			portal.Link.Model.SetVisible(false, false)
+
+			cam.RenderScene(scene)
+
+			portal.Link.Model.SetVisible(exitVisible, false)
+
+			views[i] = cam.ColorTexture()
+
+		}
+
+	}
+
+	// Rendering the views of other Portals at this depth changes which views the Portals display, so they're only set once all are rendered
+	for i, portal := range portals {
+		portal.setView(views[i])
+	}
+
+}
@@ -0,0 +1,117 @@
+package tetra3d
+
+// Prefab is a Node tree registered as a template for instantiating copies of it into Scenes, formalizing the pattern of cloning
+// objects from a prototype Scene (i.e. a Scene in a Library that holds enemies, pickups, or bullets to be spawned into levels).
+// A Prefab's prototype isn't modified by instantiating it; each instance is a clone, with the prototype's OnClone callbacks called
+// as usual.
+type Prefab struct {
+	Name      string // The name of the Prefab.
+	Prototype INode  // The Node (and tree) that the Prefab clones to create its instances.
+	// OnInstantiate is called with each instance of the Prefab after the PrefabOverrides have been applied and the instance has been
+	// added to its Scene. This is useful for attaching your game objects to the instance (i.e. through Node.SetData()).
+	OnInstantiate func(instance INode)
+}
+
+// PrefabOverrides are the parameters that an instance of a Prefab is created with; zero values leave the instance as it is
+// when cloned from the Prefab's prototype.
+type PrefabOverrides struct {
+	Name   string // If set, the name of the instance's root Node.
+	Parent INode  // The Node to add the instance to; if nil, the instance is added to the Scene's root.
+	// Transform, if not a zero matrix, is the world transform of the instance's root Node (i.e. NewMatrix4Translate() to place the
+	// instance at a position). Otherwise, the instance keeps the prototype's local transform.
+	Transform Matrix4
+	// Properties are game properties to set on the instance's root Node, added on top of the properties cloned from the prototype.
+	Properties map[string]any
+	// Materials replaces the Materials with the given names on the instance's Models. Models whose Meshes are shared with the prototype
+	// are given their own copies of their Meshes, so the prototype and its other instances aren't affected.
+	Materials map[string]*Material
+}
+
+// NewPrefab creates a new Prefab with the given name, using the given Node (and its tree) as its prototype.
+func NewPrefab(name string, prototype INode) *Prefab {
+	return &Prefab{
+		Name:      name,
+		Prototype: prototype,
+	}
+}
+
+// Instantiate creates an instance of the Prefab by cloning its prototype, applies the given PrefabOverrides to it, and adds it to the
+// given Scene, returning the instance's root Node.
+func (prefab *Prefab) Instantiate(scene *Scene, overrides PrefabOverrides) INode {
+
+	instance := prefab.Prototype.Clone()
+
+	if overrides.Name != "" {
+		instance.SetName(overrides.Name)
+	}
+
+	if len(overrides.Properties) > 0 {
+		props := instance.Properties()
+		for name, value := range overrides.Properties {
+			props.Set(name, value)
+		}
+	}
+
+	if len(overrides.Materials) > 0 {
+		for _, node := range append([]INode{instance}, instance.SearchTree().INodes()...) {
+			if model, isModel := node.(*Model); isModel {
+				overrideMaterials(model, overrides.Materials)
+			}
+		}
+	}
+
+	parent := overrides.Parent
+	if parent == nil {
+		parent = scene.Root
+	}
+
+	parent.AddChildren(instance)
+
+	if !overrides.Transform.IsZero() {
+		instance.SetWorldTransform(overrides.Transform)
+	}
+
+	if prefab.OnInstantiate != nil {
+		prefab.OnInstantiate(instance)
+	}
+
+	return instance
+
+}
+
+// overrideMaterials replaces the Materials of the given Model's Mesh with the Materials of the same names in the given map.
+func overrideMaterials(model *Model, materials map[string]*Material) {
+
+	if model.Mesh == nil {
+		return
+	}
+
+	overridden := false
+
+	for _, part := range model.Mesh.MeshParts {
+		if part.Material != nil {
+			if _, exists := materials[part.Material.Name]; exists {
+				overridden = true
+				break
+			}
+		}
+	}
+
+	if !overridden {
+		return
+	}
+
+	// Meshes that aren't unique are shared with the Model's prototype, so the Model needs its own copy
+	if model.Mesh.Unique == MeshUniqueFalse {
+		model.Mesh = model.Mesh.Clone()
+	}
+
+	for _, part := range model.Mesh.MeshParts {
+		if part.Material != nil {
+			if mat, exists := materials[part.Material.Name]; exists {
+				part.Material = mat
+			}
+		}
+	}
+
+}
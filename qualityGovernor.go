@@ -0,0 +1,225 @@
+package tetra3d
+
+import (
+	"time"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// QualitySetting is an aspect of rendering quality that a QualityGovernor can reduce when the game runs slowly, like the maximum
+// number of lights a Camera uses or how many particles ParticleSystems spawn. A QualitySetting is reduced in steps, from full quality
+// (a level of 0) down to its lowest quality (a level of Steps).
+type QualitySetting struct {
+	Name string // The name of the QualitySetting.
+	// Priority is how important the QualitySetting is; QualitySettings with lower priorities are reduced first when the game runs
+	// slowly, and restored last once there's headroom again.
+	Priority int
+	Steps    int // How many times the QualitySetting can be reduced before it's at its lowest quality.
+	// Apply is called to apply the QualitySetting whenever its level changes, with quality ranging from 1 (full quality) down to 0
+	// (lowest quality).
+	Apply func(quality float32)
+
+	level int
+}
+
+// NewQualitySetting creates a new QualitySetting with the given name, priority, number of steps, and function to apply the setting.
+func NewQualitySetting(name string, priority int, steps int, apply func(quality float32)) *QualitySetting {
+	if steps < 1 {
+		steps = 1
+	}
+	return &QualitySetting{
+		Name:     name,
+		Priority: priority,
+		Steps:    steps,
+		Apply:    apply,
+	}
+}
+
+// NewLightCountQualitySetting creates a QualitySetting that reduces the Camera's MaxLightCount from fullLightCount (at full quality)
+// down to minLightCount (at its lowest quality), one light at a time.
+func NewLightCountQualitySetting(camera *Camera, priority int, fullLightCount, minLightCount int) *QualitySetting {
+
+	if minLightCount < 1 {
+		minLightCount = 1
+	}
+
+	if fullLightCount < minLightCount {
+		fullLightCount = minLightCount
+	}
+
+	return NewQualitySetting("light count", priority, fullLightCount-minLightCount, func(quality float32) {
+		camera.MaxLightCount = minLightCount + int(math32.Round(float32(fullLightCount-minLightCount)*quality))
+	})
+
+}
+
+// NewParticleQualitySetting creates a QualitySetting that reduces the SpawnScale of the given ParticleSystems from 1 (at full quality)
+// down to minSpawnScale (at its lowest quality) over the given number of steps.
+func NewParticleQualitySetting(priority int, minSpawnScale float32, steps int, systems ...*ParticleSystem) *QualitySetting {
+	return NewQualitySetting("particles", priority, steps, func(quality float32) {
+		for _, ps := range systems {
+			ps.SpawnScale = minSpawnScale + (1-minSpawnScale)*quality
+		}
+	})
+}
+
+// NewToggleQualitySetting creates a QualitySetting that turns something on or off, like a post-processing effect; toggle is called with
+// true at full quality, and false otherwise.
+func NewToggleQualitySetting(name string, priority int, toggle func(on bool)) *QualitySetting {
+	return NewQualitySetting(name, priority, 1, func(quality float32) {
+		toggle(quality >= 1)
+	})
+}
+
+// Level returns the current level of the QualitySetting, ranging from 0 (full quality) to its Steps (lowest quality).
+func (setting *QualitySetting) Level() int {
+	return setting.level
+}
+
+// Quality returns the current quality of the QualitySetting, ranging from 1 (full quality) to 0 (lowest quality).
+func (setting *QualitySetting) Quality() float32 {
+	if setting.Steps <= 0 {
+		return 1
+	}
+	return 1 - float32(setting.level)/float32(setting.Steps)
+}
+
+// SetLevel sets the level of the QualitySetting, ranging from 0 (full quality) to its Steps (lowest quality), and applies it.
+func (setting *QualitySetting) SetLevel(level int) {
+
+	if level < 0 {
+		level = 0
+	} else if level > setting.Steps {
+		level = setting.Steps
+	}
+
+	setting.level = level
+
+	if setting.Apply != nil {
+		setting.Apply(setting.Quality())
+	}
+
+}
+
+// QualityGovernor automatically reduces rendering quality when the game runs slowly, and restores it when there's headroom again.
+// Each frame, pass the frame time (i.e. Camera.DebugInfo.FrameTime, or the time spent on the whole frame) to QualityGovernor.Update();
+// once enough frames have passed, if the average frame time exceeds the TargetFrameTime, the QualitySetting with the lowest Priority
+// that can still be reduced is reduced by a step. If the average frame time drops below the TargetFrameTime multiplied by the
+// RestoreThreshold, the reduced QualitySetting with the highest Priority is restored by a step.
+type QualityGovernor struct {
+	On              bool              // Whether the QualityGovernor adjusts its QualitySettings or not. Defaults to true.
+	TargetFrameTime time.Duration     // The target frame time; defaults to a 60th of a second.
+	Settings        []*QualitySetting // The QualitySettings the QualityGovernor adjusts.
+
+	// RestoreThreshold is the fraction of the TargetFrameTime that the average frame time has to drop below before quality is restored;
+	// this should be below 1 so that quality isn't restored as soon as the game runs fast enough. Defaults to 0.75.
+	RestoreThreshold float32
+
+	// HistorySize is how many frames of frame time history are averaged before the QualityGovernor adjusts quality; after adjusting
+	// quality, the history is cleared, so the QualityGovernor waits this many frames before adjusting it again. Defaults to 30.
+	HistorySize int
+
+	history []time.Duration
+}
+
+// NewQualityGovernor creates a new QualityGovernor with the given target frame time and QualitySettings.
+func NewQualityGovernor(targetFrameTime time.Duration, settings ...*QualitySetting) *QualityGovernor {
+
+	if targetFrameTime <= 0 {
+		targetFrameTime = time.Second / 60
+	}
+
+	return &QualityGovernor{
+		On:               true,
+		TargetFrameTime:  targetFrameTime,
+		Settings:         settings,
+		RestoreThreshold: 0.75,
+		HistorySize:      30,
+	}
+
+}
+
+// AddSettings adds the given QualitySettings to the QualityGovernor.
+func (governor *QualityGovernor) AddSettings(settings ...*QualitySetting) {
+	governor.Settings = append(governor.Settings, settings...)
+}
+
+// Update records the frame time given (which should be the time taken for the last frame) and adjusts the QualityGovernor's
+// QualitySettings if necessary. Update returns the QualitySetting that was adjusted, or nil if none were.
+func (governor *QualityGovernor) Update(frameTime time.Duration) *QualitySetting {
+
+	if !governor.On {
+		return nil
+	}
+
+	governor.history = append(governor.history, frameTime)
+
+	historySize := governor.HistorySize
+	if historySize < 1 {
+		historySize = 1
+	}
+
+	if len(governor.history) < historySize {
+		return nil
+	}
+
+	average := governor.AverageFrameTime()
+
+	var adjusted *QualitySetting
+
+	if average > governor.TargetFrameTime {
+
+		for _, setting := range governor.Settings {
+			if setting.level < setting.Steps && (adjusted == nil || setting.Priority < adjusted.Priority) {
+				adjusted = setting
+			}
+		}
+
+		if adjusted != nil {
+			adjusted.SetLevel(adjusted.level + 1)
+		}
+
+	} else if float32(average) < float32(governor.TargetFrameTime)*governor.RestoreThreshold {
+
+		for _, setting := range governor.Settings {
+			if setting.level > 0 && (adjusted == nil || setting.Priority > adjusted.Priority) {
+				adjusted = setting
+			}
+		}
+
+		if adjusted != nil {
+			adjusted.SetLevel(adjusted.level - 1)
+		}
+
+	}
+
+	// The history is cleared either way, so the frame times measured before an adjustment don't affect the next one
+	governor.history = governor.history[:0]
+
+	return adjusted
+
+}
+
+// AverageFrameTime returns the average frame time in the QualityGovernor's current frame time history.
+func (governor *QualityGovernor) AverageFrameTime() time.Duration {
+
+	if len(governor.history) == 0 {
+		return 0
+	}
+
+	total := time.Duration(0)
+	for _, ft := range governor.history {
+		total += ft
+	}
+
+	return total / time.Duration(len(governor.history))
+
+}
+
+// Reset restores all of the QualityGovernor's QualitySettings to full quality and clears its frame time history.
+func (governor *QualityGovernor) Reset() {
+	governor.history = governor.history[:0]
+	for _, setting := range governor.Settings {
+		setting.SetLevel(0)
+	}
+}
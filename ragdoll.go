@@ -0,0 +1,415 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// RagdollBone represents a single bone simulated by a Ragdoll.
+type RagdollBone struct {
+	Bone    INode            // The bone Node driven by the Ragdoll.
+	Capsule *BoundingCapsule // The BoundingCapsule parented to the bone, if there is one.
+	Parent  *RagdollBone     // The parent RagdollBone, or nil if this bone is the root of the Ragdoll.
+
+	// Radius is the collision radius of the bone's joint. Defaults to the Capsule's world radius if the bone has a Capsule, or 0.05 otherwise.
+	Radius float32
+
+	// MaxAngle is the maximum angle in radians that the bone can bend away from its pose (relative to its parent) at the time the Ragdoll was started.
+	// A value of Pi or greater means the bone can bend freely.
+	MaxAngle float32
+
+	head, primary, secondary int // Indices of the simulated points that determine the bone's position and orientation
+
+	restRotation Matrix4
+	restFrame    Matrix4
+	restDir      Vector3
+	hasFrame     bool
+}
+
+type ragdollPoint struct {
+	position, prev Vector3
+	radius         float32
+}
+
+type ragdollDistance struct {
+	a, b   int
+	length float32
+}
+
+// Ragdoll is a simple verlet-based ragdoll simulation for an armature. Each bone's joint is simulated as a point that falls with gravity, kept at the
+// correct distances from its neighbors and within each bone's angular limit; the bones are then rotated to follow the simulated points.
+// Ragdolls are good for things like characters collapsing on death; a Ragdoll does nothing until Start() is called, after which it blends from the
+// armature's current (i.e. animated) pose to the simulated one over BlendTime seconds.
+type Ragdoll struct {
+	Root  INode          // The root Node of the armature the Ragdoll simulates.
+	Bones []*RagdollBone // The bones of the armature, ordered so that parents come before their children.
+
+	Gravity    Vector3 // The acceleration applied to the Ragdoll every second. Defaults to (0, -9.8, 0).
+	Damping    float32 // How much velocity the Ragdoll keeps from step to step, ranging from 0 to 1. Defaults to 0.99.
+	Friction   float32 // How much sliding velocity the Ragdoll loses when touching colliders, ranging from 0 to 1. Defaults to 0.5.
+	Iterations int     // The number of iterations used to resolve the Ragdoll's constraints each step. Defaults to 8.
+	BlendTime  float32 // How long in seconds the Ragdoll takes to blend from the armature's pose to the simulated pose when started. Defaults to 0.2.
+
+	// Colliders is the set of bounding objects the Ragdoll collides with (i.e. the level's BoundingTriangles). If nil, the Ragdoll doesn't collide
+	// with anything. The Ragdoll's own BoundingCapsules are ignored.
+	Colliders NodeIterator
+
+	active        bool
+	blend         float32
+	startVelocity Vector3
+	firstStep     bool
+	points        []ragdollPoint
+	distances     []ragdollDistance
+	probe         *BoundingSphere
+	ownCapsules   Set[INode]
+}
+
+// NewRagdoll creates a new Ragdoll for the armature starting at the given root Node, with each bone limited to bending up to maxAngle radians
+// away from its starting pose. The BoundingCapsules parented to the armature's bones are used to determine the bones' collision radii.
+func NewRagdoll(armatureRoot INode, maxAngle float32) *Ragdoll {
+
+	ragdoll := &Ragdoll{
+		Root:        armatureRoot,
+		Gravity:     Vector3{0, -9.8, 0},
+		Damping:     0.99,
+		Friction:    0.5,
+		Iterations:  8,
+		BlendTime:   0.2,
+		probe:       NewBoundingSphere("ragdoll probe", 0),
+		ownCapsules: newSet[INode](),
+	}
+
+	nodes := append([]INode{armatureRoot}, armatureRoot.SearchTree().INodes()...)
+
+	bonesByNode := map[INode]*RagdollBone{}
+
+	for _, node := range nodes {
+
+		if !node.IsBone() {
+			continue
+		}
+
+		bone := &RagdollBone{
+			Bone:     node,
+			Radius:   0.05,
+			MaxAngle: maxAngle,
+		}
+
+		for _, child := range node.Children() {
+			if capsule, ok := child.(*BoundingCapsule); ok {
+				bone.Capsule = capsule
+				bone.Radius = capsule.WorldRadius()
+				ragdoll.ownCapsules.Add(capsule)
+				break
+			}
+		}
+
+		for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+			if parentBone, ok := bonesByNode[parent]; ok {
+				bone.Parent = parentBone
+				break
+			}
+		}
+
+		bonesByNode[node] = bone
+		ragdoll.Bones = append(ragdoll.Bones, bone)
+
+	}
+
+	return ragdoll
+
+}
+
+// Start starts simulating the Ragdoll from the armature's current pose, with the given initial velocity (i.e. the velocity of the character
+// when it died).
+func (ragdoll *Ragdoll) Start(velocity Vector3) {
+
+	ragdoll.points = ragdoll.points[:0]
+	ragdoll.distances = ragdoll.distances[:0]
+
+	children := map[*RagdollBone][]*RagdollBone{}
+
+	for _, bone := range ragdoll.Bones {
+		bone.head = ragdoll.addPoint(bone.Bone.WorldPosition(), bone.Radius)
+		if bone.Parent != nil {
+			children[bone.Parent] = append(children[bone.Parent], bone)
+		}
+	}
+
+	for _, bone := range ragdoll.Bones {
+
+		kids := children[bone]
+
+		if len(kids) > 0 {
+			bone.primary = kids[0].head
+		} else {
+
+			// Leaf bones get a tail point of their own, extending along the bone (+Y, as bones from Blender point along +Y).
+			length := float32(0.1)
+			if bone.Capsule != nil {
+				length = bone.Capsule.Height
+			} else if bone.Parent != nil {
+				length = math32.Max(bone.Bone.WorldPosition().Distance(bone.Parent.Bone.WorldPosition()), length)
+			}
+
+			tail := bone.Bone.WorldPosition().Add(bone.Bone.WorldRotation().Up().Scale(length))
+			bone.primary = ragdoll.addPoint(tail, bone.Radius)
+			ragdoll.addDistance(bone.head, bone.primary)
+
+		}
+
+		bone.secondary = -1
+		if len(kids) > 1 {
+			bone.secondary = kids[1].head
+		} else if bone.Parent != nil {
+			bone.secondary = bone.Parent.head
+		}
+
+		// Children of the same bone are kept at fixed distances from each other as well as from their parent, so that
+		// branching parts of the armature (like the hips or chest) stay rigid.
+		for i, kid := range kids {
+			ragdoll.addDistance(bone.head, kid.head)
+			for _, other := range kids[i+1:] {
+				ragdoll.addDistance(kid.head, other.head)
+			}
+		}
+
+		bone.restRotation = bone.Bone.WorldRotation()
+		bone.restDir = ragdoll.points[bone.primary].position.Sub(ragdoll.points[bone.head].position).Unit()
+		bone.restFrame, bone.hasFrame = ragdoll.boneFrame(bone)
+
+	}
+
+	ragdoll.startVelocity = velocity
+	ragdoll.firstStep = true
+	ragdoll.blend = 1
+	if ragdoll.BlendTime > 0 {
+		ragdoll.blend = 0
+	}
+	ragdoll.active = true
+
+}
+
+// Stop stops simulating the Ragdoll; the armature's bones keep their current pose.
+func (ragdoll *Ragdoll) Stop() {
+	ragdoll.active = false
+}
+
+// Active returns if the Ragdoll is currently being simulated.
+func (ragdoll *Ragdoll) Active() bool {
+	return ragdoll.active
+}
+
+func (ragdoll *Ragdoll) addPoint(position Vector3, radius float32) int {
+	ragdoll.points = append(ragdoll.points, ragdollPoint{position: position, prev: position, radius: radius})
+	return len(ragdoll.points) - 1
+}
+
+func (ragdoll *Ragdoll) addDistance(a, b int) {
+	length := ragdoll.points[a].position.Distance(ragdoll.points[b].position)
+	ragdoll.distances = append(ragdoll.distances, ragdollDistance{a: a, b: b, length: length})
+}
+
+// boneFrame returns an orientation for the bone based on the current positions of its points, and if the orientation could be determined.
+func (ragdoll *Ragdoll) boneFrame(bone *RagdollBone) (Matrix4, bool) {
+
+	if bone.secondary < 0 {
+		return Matrix4{}, false
+	}
+
+	head := ragdoll.points[bone.head].position
+
+	x := ragdoll.points[bone.primary].position.Sub(head).Unit()
+	z := x.Cross(ragdoll.points[bone.secondary].position.Sub(head).Unit())
+
+	if z.Magnitude() < 0.01 {
+		return Matrix4{}, false
+	}
+
+	z = z.Unit()
+	y := z.Cross(x)
+
+	frame := NewMatrix4()
+	frame.SetRow(0, Vector4{x.X, x.Y, x.Z, 0})
+	frame.SetRow(1, Vector4{y.X, y.Y, y.Z, 0})
+	frame.SetRow(2, Vector4{z.X, z.Y, z.Z, 0})
+	return frame, true
+
+}
+
+// boneDelta returns the world rotation the bone has undergone since the Ragdoll was started.
+func (ragdoll *Ragdoll) boneDelta(bone *RagdollBone) Matrix4 {
+
+	if bone.hasFrame {
+		if frame, ok := ragdoll.boneFrame(bone); ok {
+			return bone.restFrame.Transposed().Mult(frame)
+		}
+	}
+
+	// Without a full frame, we can only tell how the bone has swung, not how it has twisted.
+	dir := ragdoll.points[bone.primary].position.Sub(ragdoll.points[bone.head].position).Unit()
+
+	axis := bone.restDir.Cross(dir)
+	if axis.IsZero() {
+		return NewMatrix4()
+	}
+
+	return NewMatrix4Rotate(axis.X, axis.Y, axis.Z, bone.restDir.Angle(dir))
+
+}
+
+// Update simulates the Ragdoll by the given delta time and poses the armature's bones to match. Update does nothing if the Ragdoll hasn't been started.
+func (ragdoll *Ragdoll) Update(dt float32) {
+
+	if !ragdoll.active || dt <= 0 {
+		return
+	}
+
+	// Integrate
+	for i := range ragdoll.points {
+
+		p := &ragdoll.points[i]
+
+		velocity := p.position.Sub(p.prev).Scale(ragdoll.Damping)
+		if ragdoll.firstStep {
+			velocity = ragdoll.startVelocity.Scale(dt)
+		}
+
+		p.prev = p.position
+		p.position = p.position.Add(velocity).Add(ragdoll.Gravity.Scale(dt * dt))
+
+	}
+
+	ragdoll.firstStep = false
+
+	for iter := 0; iter < ragdoll.Iterations; iter++ {
+		ragdoll.solveDistances()
+		ragdoll.solveAngleLimits()
+		ragdoll.solveCollisions()
+	}
+
+	if ragdoll.blend < 1 {
+		ragdoll.blend = math32.Min(ragdoll.blend+dt/ragdoll.BlendTime, 1)
+	}
+
+	ragdoll.applyPose()
+
+}
+
+func (ragdoll *Ragdoll) solveDistances() {
+
+	for _, d := range ragdoll.distances {
+
+		a := &ragdoll.points[d.a]
+		b := &ragdoll.points[d.b]
+
+		delta := b.position.Sub(a.position)
+		dist := delta.Magnitude()
+
+		if dist == 0 {
+			continue
+		}
+
+		correction := delta.Scale((dist - d.length) / dist * 0.5)
+		a.position = a.position.Add(correction)
+		b.position = b.position.Sub(correction)
+
+	}
+
+}
+
+func (ragdoll *Ragdoll) solveAngleLimits() {
+
+	for _, bone := range ragdoll.Bones {
+
+		if bone.Parent == nil || bone.MaxAngle >= math32.Pi {
+			continue
+		}
+
+		head := ragdoll.points[bone.head].position
+		tail := &ragdoll.points[bone.primary]
+
+		// The direction the bone would point in if it hadn't bent relative to its parent
+		restDir := ragdoll.boneDelta(bone.Parent).MultVec(bone.restDir).Unit()
+
+		dir := tail.position.Sub(head)
+		length := dir.Magnitude()
+
+		if length == 0 || dir.Unit().Angle(restDir) <= bone.MaxAngle {
+			continue
+		}
+
+		axis := restDir.Cross(dir)
+		if axis.IsZero() {
+			continue
+		}
+
+		limited := NewMatrix4Rotate(axis.X, axis.Y, axis.Z, math32.Max(bone.MaxAngle, 0)).MultVec(restDir)
+		tail.position = head.Add(limited.Scale(length))
+
+	}
+
+}
+
+func (ragdoll *Ragdoll) solveCollisions() {
+
+	if ragdoll.Colliders == nil {
+		return
+	}
+
+	var point *ragdollPoint
+
+	settings := CollisionTestSettings{
+		TestAgainst: ragdoll.Colliders,
+		OnCollision: func(col *Collision, index, count int) bool {
+
+			if ragdoll.ownCapsules.Contains(col.BoundingObject) {
+				return true
+			}
+
+			mtv := col.AverageMTV()
+			point.position = point.position.Add(mtv)
+			ragdoll.probe.SetWorldPositionVec(point.position)
+
+			// Remove some of the velocity sliding along the surface
+			if normal := mtv.Unit(); !normal.IsZero() {
+				velocity := point.position.Sub(point.prev)
+				sliding := velocity.Sub(normal.Scale(velocity.Dot(normal)))
+				point.prev = point.prev.Add(sliding.Scale(math32.Clamp(ragdoll.Friction, 0, 1)))
+			}
+
+			return true
+
+		},
+	}
+
+	for i := range ragdoll.points {
+		point = &ragdoll.points[i]
+		ragdoll.probe.Radius = point.radius
+		ragdoll.probe.SetWorldPositionVec(point.position)
+		ragdoll.probe.CollisionTest(settings)
+	}
+
+}
+
+// applyPose rotates the armature's bones (and moves the root bone) to match the simulation, blending from their current pose if the
+// Ragdoll was just started.
+func (ragdoll *Ragdoll) applyPose() {
+
+	for _, bone := range ragdoll.Bones {
+
+		rotation := bone.restRotation.Mult(ragdoll.boneDelta(bone))
+		if ragdoll.blend < 1 {
+			rotation = bone.Bone.WorldRotation().Lerp(rotation, ragdoll.blend)
+		}
+
+		if bone.Parent == nil {
+			position := ragdoll.points[bone.head].position
+			if ragdoll.blend < 1 {
+				position = bone.Bone.WorldPosition().Lerp(position, ragdoll.blend)
+			}
+			bone.Bone.SetWorldPositionVec(position)
+		}
+
+		setWorldRotation(bone.Bone, rotation)
+
+	}
+
+}
@@ -2,6 +2,7 @@ package tetra3d
 
 import (
 	"errors"
+	"math"
 	"sort"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -10,12 +11,12 @@ import (
 
 // RayHit represents the result of a raycast test.
 type RayHit struct {
-	Object   INode   // Object is a pointer to the BoundingObject that was struck by the raycast.
+	Object   INode   // Object is a pointer to the BoundingObject (or skinned Model, if RayTestOptions.TestSkinnedModels is set) that was struck by the raycast.
 	Position Vector3 // Position is the world position that the object was struct.
 	from     Vector3 // The starting position of the Ray
 	Normal   Vector3 // Normal is the normal of the surface the ray struck.
 
-	// What triangle the raycast hit - note that this is only set to a non-nil value for raycasts against BoundingTriangle objects
+	// What triangle the raycast hit - note that this is only set to a non-nil value for raycasts against BoundingTriangle objects or skinned Models
 	Triangle              *Triangle
 	untransformedPosition Vector3 // untransformed position of the ray test for BoundingTriangles tests
 }
@@ -33,7 +34,7 @@ func (r RayHit) Distance() float32 {
 const ErrorObjectHitNotBoundingTriangles = "error: object hit not a BoundingTriangles instance; no UV or vertex color data can be pulled from RayHit result"
 
 // VertexColor returns the vertex color from the given channel in the position struck on the object struck,
-// assuming it was a BoundingTriangles.
+// assuming it was a BoundingTriangles or a skinned Model.
 // The returned vertex color is linearly interpolated across the triangle just like it would be when a triangle is rendered.
 // VertexColor will return a transparent color and an error if the BoundingObject hit was not a BoundingTriangles object, or if the channel index given
 // is higher than the number of vertex color channels on the BoundingTriangles' mesh.
@@ -43,7 +44,7 @@ func (r RayHit) VertexColor(channelIndex int) (Color, error) {
 		return NewColor(0, 0, 0, 0), errors.New(ErrorObjectHitNotBoundingTriangles)
 	}
 
-	mesh := r.Object.(*BoundingTriangles).Mesh
+	mesh := r.mesh()
 
 	if len(mesh.VertexColors[0]) <= channelIndex {
 		return NewColor(0, 0, 0, 0), errors.New(ErrorVertexChannelOutsideRange)
@@ -63,7 +64,7 @@ func (r RayHit) VertexColor(channelIndex int) (Color, error) {
 }
 
 // UV returns the UV value from the position struck on the corresponding triangle for the BoundingObject struck,
-// assuming the object struck was a BoundingTriangles.
+// assuming the object struck was a BoundingTriangles or a skinned Model.
 // The returned UV value is linearly interpolated across the triangle just like it would be when a triangle is rendered.
 // UV will return a zero Vector and an error if the BoundingObject hit was not a BoundingTriangles object.
 func (r RayHit) UV() (Vector2, error) {
@@ -72,7 +73,7 @@ func (r RayHit) UV() (Vector2, error) {
 		return Vector2{}, errors.New(ErrorObjectHitNotBoundingTriangles)
 	}
 
-	mesh := r.Object.(*BoundingTriangles).Mesh
+	mesh := r.mesh()
 
 	tri := r.Triangle
 	u, v := pointInsideTriangle(r.untransformedPosition, mesh.VertexPositions[tri.VertexIndices[0]], mesh.VertexPositions[tri.VertexIndices[1]], mesh.VertexPositions[tri.VertexIndices[2]])
@@ -87,6 +88,39 @@ func (r RayHit) UV() (Vector2, error) {
 
 }
 
+// Barycentric returns the barycentric coordinates of the position struck on the corresponding triangle, assuming the object struck was
+// a BoundingTriangles or a skinned Model. The X, Y, and Z components are the weights of the triangle's first, second, and third vertices
+// (in the order of Triangle.VertexIndices), and add up to 1; they can be used to interpolate custom per-vertex data across the triangle.
+// Barycentric will return a zero Vector and an error if the BoundingObject hit was not a BoundingTriangles object.
+func (r RayHit) Barycentric() (Vector3, error) {
+
+	if r.Triangle == nil {
+		return Vector3{}, errors.New(ErrorObjectHitNotBoundingTriangles)
+	}
+
+	mesh := r.mesh()
+
+	tri := r.Triangle
+	u, v := pointInsideTriangle(r.untransformedPosition, mesh.VertexPositions[tri.VertexIndices[0]], mesh.VertexPositions[tri.VertexIndices[1]], mesh.VertexPositions[tri.VertexIndices[2]])
+
+	return Vector3{1 - u - v, v, u}, nil
+
+}
+
+// mesh returns the Mesh of the object struck, assuming it was a BoundingTriangles or a skinned Model.
+func (r RayHit) mesh() *Mesh {
+
+	switch object := r.Object.(type) {
+	case *BoundingTriangles:
+		return object.Mesh
+	case *Model:
+		return object.Mesh
+	}
+
+	return nil
+
+}
+
 func boundingSphereRayTest(center Vector3, radius float32, from, to Vector3) (RayHit, bool) {
 
 	// normal := to.Sub(from)
@@ -253,6 +287,64 @@ func boundingAABBRayTest(from, to Vector3, test *BoundingAABB) (RayHit, bool) {
 
 }
 
+func boundingOBBRayTest(from, to Vector3, test *BoundingOBB) (RayHit, bool) {
+
+	box := test.orientedBox()
+
+	rayLine := to.Sub(from)
+	delta := from.Sub(box.center)
+
+	// The ray is tested against each pair of the box's faces in terms of how far along the ray it is (from 0 at the start to 1 at the end)
+	tmin := float32(-math.MaxFloat32)
+	tmax := float32(math.MaxFloat32)
+
+	var normal Vector3
+
+	for i, axis := range box.axes {
+
+		start := delta.Dot(axis)
+		dir := rayLine.Dot(axis)
+
+		if math32.Abs(dir) < 1e-8 {
+			if math32.Abs(start) > box.half[i] {
+				return RayHit{}, false
+			}
+			continue
+		}
+
+		t1 := (-box.half[i] - start) / dir
+		t2 := (box.half[i] - start) / dir
+
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+
+		if t1 > tmin {
+			tmin = t1
+			if dir > 0 {
+				normal = axis.Invert()
+			} else {
+				normal = axis
+			}
+		}
+
+		tmax = math32.Min(tmax, t2)
+
+	}
+
+	if tmin < 0 || tmin > tmax || tmin > 1 {
+		return RayHit{}, false
+	}
+
+	return RayHit{
+		Object:   test,
+		Position: from.Add(rayLine.Scale(tmin)),
+		Normal:   normal,
+		from:     from,
+	}, true
+
+}
+
 func boundingTrianglesRayTest(from, to Vector3, test *BoundingTriangles, doublesided bool) []RayHit {
 
 	rayDistSquared := to.DistanceSquared(from)
@@ -323,27 +415,118 @@ func boundingTrianglesRayTest(from, to Vector3, test *BoundingTriangles, doubles
 
 }
 
-var internalRayTest = []RayHit{}
+// internalSkinnedPositions is used to hold the posed vertex positions of skinned Models while ray testing against them.
+var internalSkinnedPositions = []Vector3{}
+
+// skinnedModelRayTest tests the ray against the triangles of a skinned Model in its current, animated pose, rather than its rest pose.
+func skinnedModelRayTest(from, to Vector3, model *Model, doublesided bool) []RayHit {
+
+	if !model.skinned || model.Mesh == nil {
+		return nil
+	}
+
+	mesh := model.Mesh
+
+	// Skinning makes use of the Model's and bones' working state, which is also used while rendering.
+	renderLock.Lock()
+
+	positions := internalSkinnedPositions[:0]
 
-// TODO: Add SphereCast?
+	for i := range mesh.VertexPositions {
+		vert, _ := model.skinVertex(i)
+		positions = append(positions, vert)
+	}
+
+	renderLock.Unlock()
+
+	internalSkinnedPositions = positions
+
+	results := []RayHit{}
+
+	plane := newCollisionPlane()
+
+	for _, tri := range mesh.Triangles {
+
+		v0 := positions[tri.VertexIndices[0]]
+		v1 := positions[tri.VertexIndices[1]]
+		v2 := positions[tri.VertexIndices[2]]
+
+		plane.Set(v0, v1, v2)
+
+		fs := plane.Normal.Dot(from) - plane.Distance
+		ts := plane.Normal.Dot(to) - plane.Distance
+
+		// If the start and end points of the ray lie on the same side of the triangle,
+		// then we know the triangle can't be struck and we can bail early
+		if (fs > 0 && ts > 0) || (fs < 0 && ts < 0) {
+			continue
+		}
+
+		if vec, ok := plane.RayAgainstPlane(from, to, doublesided); ok && isPointInsideTriangle(vec, v0, v1, v2) {
+
+			// Map the struck position back onto the triangle in its rest pose, so UV and vertex color lookups work as usual
+			u, v := pointInsideTriangle(vec, v0, v1, v2)
+			r0 := mesh.VertexPositions[tri.VertexIndices[0]]
+			r1 := mesh.VertexPositions[tri.VertexIndices[1]]
+			r2 := mesh.VertexPositions[tri.VertexIndices[2]]
+
+			results = append(results, RayHit{
+				Object:                model,
+				Position:              vec,
+				untransformedPosition: r0.Add(r2.Sub(r0).Scale(u)).Add(r1.Sub(r0).Scale(v)),
+				from:                  from,
+				Triangle:              tri,
+				Normal:                plane.Normal,
+			})
+
+		}
+
+	}
+
+	return results
+
+}
+
+var internalRayTest = []RayHit{}
 
 // RayTestOptions is a struct designed to control what options to use when performing a ray test.
 type RayTestOptions struct {
 	From, To Vector3 // From and To are the starting and ending points of the ray test.
 
-	// If cast rays can strike both sides of BoundingTriangles triangles or not.
+	// MaxDistance is the maximum distance the ray travels from From towards To. If the ray would be longer than MaxDistance, it's shortened;
+	// this is useful when To is only used to indicate a direction. A MaxDistance of 0 or less means the ray always travels all the way to To.
+	MaxDistance float32
+
+	// If cast rays can strike both sides of BoundingTriangles triangles (and skinned Models' and BoundingHeightfields' triangles) or not; this is set per-test, so
+	// different ray tests can treat backfaces differently (i.e. shots ignoring backfaces while line-of-sight checks strike them).
 	// TODO: Implement this for all collision types, not just triangles.
 	Doublesided bool
 
+	// MaxHits is the maximum number of hits the ray test returns through OnHit. A MaxHits of 0 or less means there's no limit.
+	MaxHits int
+
+	// Unsorted indicates that hits shouldn't be sorted by distance before being passed to OnHit; instead, they're passed in the order they're
+	// found. This is faster, particularly when many triangles are struck. If MaxHits is also set, the ray test stops testing objects once
+	// MaxHits hits have been found, so the hits returned aren't necessarily the closest ones.
+	Unsorted bool
+
 	// TestAgainst is used to specify a selection of BoundingObjects to test against - this can be either a NodeFilter or a NodeCollection (a slice of Nodes).
 	TestAgainst NodeIterator
 
-	// OnHit is a callback called for each hit a cast Ray returns, sorted by distance from the starting point.
-	// OnHit is called for each object in order of distance to the starting point.
+	// OnHit is a callback called for each hit a cast Ray returns, in order of distance from the starting point (or in the order the hits
+	// were found, if Unsorted is set).
 	// OnHit is only called once for each object, apart from BoundingTriangles, as a single ray can hit multiple triangles of a BoundingTriangles mesh.
-	// index is the index of the hit out of the maximum number of hits found by the function (count).
+	// index is the index of the hit out of the hits returned (count), which are limited to MaxHits if it's set.
 	// The returned boolean indicates whether to keep iterating through all found rayhits, or to stop after the current one.
 	OnHit func(hit RayHit, index, count int) bool
+
+	// TestSkinnedModels indicates whether skinned Models found in TestAgainst should be tested against their triangles in their current,
+	// animated pose (rather than their rest pose, which is what a BoundingTriangles made from the Model's Mesh would use).
+	// This is useful for hit detection against animated characters matching what's displayed onscreen.
+	// Note that this skins every vertex of each Model tested, so it's more costly than testing against simpler BoundingObjects;
+	// it's a good idea to check against a simpler BoundingObject surrounding the Model first.
+	// Models that aren't skinned are ignored.
+	TestSkinnedModels bool
 }
 
 // RayTest casts a ray from the "from" world position to the "to" world position, testing against the provided
@@ -354,6 +537,10 @@ func RayTest(options RayTestOptions) bool {
 	// We re-use the internal raytest function to avoid reallocating a slice.
 	internalRayTest = internalRayTest[:0]
 
+	if options.MaxDistance > 0 && options.From.DistanceSquared(options.To) > options.MaxDistance*options.MaxDistance {
+		options.To = options.From.Add(options.To.Sub(options.From).Unit().Scale(options.MaxDistance))
+	}
+
 	quitEarly := false
 
 	options.TestAgainst.ForEach(func(node INode) bool {
@@ -384,11 +571,35 @@ func RayTest(options RayTestOptions) bool {
 				internalRayTest = append(internalRayTest, result)
 			}
 
+		case *BoundingOBB:
+
+			if result, ok := boundingOBBRayTest(options.From, options.To, test); ok {
+				internalRayTest = append(internalRayTest, result)
+			}
+
+		case *BoundingConvexHull:
+
+			if result, ok := boundingConvexHullRayTest(options.From, options.To, test); ok {
+				internalRayTest = append(internalRayTest, result)
+			}
+
 		case *BoundingTriangles:
 
 			// Raycasting against triangles can hit multiple triangles, so we can't bail early and have to return all potential hits
 			internalRayTest = append(internalRayTest, boundingTrianglesRayTest(options.From, options.To, test, options.Doublesided)...)
 
+		case *BoundingHeightfield:
+
+			if result, ok := boundingHeightfieldRayTest(options.From, options.To, test, options.Doublesided); ok {
+				internalRayTest = append(internalRayTest, result)
+			}
+
+		case *Model:
+
+			if options.TestSkinnedModels {
+				internalRayTest = append(internalRayTest, skinnedModelRayTest(options.From, options.To, test, options.Doublesided)...)
+			}
+
 		}
 
 		// If we're not paying attention to the ray test results specifically, then we can bail after any valid
@@ -398,6 +609,11 @@ func RayTest(options RayTestOptions) bool {
 			return false
 		}
 
+		// Similarly, if we don't care about the order, we can bail once we have enough hits.
+		if options.Unsorted && options.MaxHits > 0 && len(internalRayTest) >= options.MaxHits {
+			return false
+		}
+
 		return true
 
 	})
@@ -408,9 +624,15 @@ func RayTest(options RayTestOptions) bool {
 
 	if options.OnHit != nil {
 
-		sort.Slice(internalRayTest, func(i, j int) bool {
-			return internalRayTest[i].Position.DistanceSquared(options.From) < internalRayTest[j].Position.DistanceSquared(options.From)
-		})
+		if !options.Unsorted {
+			sort.Slice(internalRayTest, func(i, j int) bool {
+				return internalRayTest[i].Position.DistanceSquared(options.From) < internalRayTest[j].Position.DistanceSquared(options.From)
+			})
+		}
+
+		if options.MaxHits > 0 && len(internalRayTest) > options.MaxHits {
+			internalRayTest = internalRayTest[:options.MaxHits]
+		}
 
 		for i, r := range internalRayTest {
 			if !options.OnHit(r, i, len(internalRayTest)) {
@@ -439,6 +661,9 @@ type MouseRayTestOptions struct {
 	// hitIndex is the index of the hit out of the maximum number of hits found by the function (hitCount).
 	// The returned boolean indicates whether to keep iterating through all found rayhits, or to stop after the current one.
 	OnHit func(hit RayHit, hitIndex int, hitCount int) bool
+	// TestSkinnedModels indicates whether skinned Models found in TestAgainst should be tested against their triangles in their current,
+	// animated pose; see RayTestOptions.TestSkinnedModels.
+	TestSkinnedModels bool
 }
 
 // MouseRayTest casts a ray forward from the mouse's position onscreen, testing against the provided
@@ -465,11 +690,12 @@ func (camera *Camera) MouseRayTest(options MouseRayTestOptions) bool {
 	to := camera.ScreenToWorldPixels(mx, my, options.Depth)
 
 	return RayTest(RayTestOptions{
-		From:        from,
-		To:          to,
-		OnHit:       options.OnHit,
-		TestAgainst: options.TestAgainst,
-		Doublesided: options.Doublesided,
+		From:              from,
+		To:                to,
+		OnHit:             options.OnHit,
+		TestAgainst:       options.TestAgainst,
+		Doublesided:       options.Doublesided,
+		TestSkinnedModels: options.TestSkinnedModels,
 	})
 
 }
@@ -0,0 +1,966 @@
+package tetra3d
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// RayTraceOptions alters how a Scene is rendered by Scene.RenderRayTracedWithOptions().
+type RayTraceOptions struct {
+	// Width and Height are the size of the rendered image in pixels. Defaults to 0 each, which renders the image at the Camera's size.
+	Width, Height int
+
+	// Samples is how many rays are traced through each pixel. More samples make for smoother soft shadows, ambient occlusion, bounce light,
+	// and anti-aliased edges, but take longer to render. Defaults to 64.
+	Samples int
+
+	// Bounces is how many times light bounces off of surfaces after the direct lighting; 0 disables bounce light. Defaults to 2.
+	Bounces        int
+	BounceStrength float32 // A multiplier for the bounced light. Defaults to 1.
+
+	// LightRadius is the radius of PointLights in world units; larger lights cast softer shadows. Defaults to 0.25.
+	LightRadius float32
+	// SunAngle is the angular diameter of DirectionalLights in radians; larger angles cast softer shadows. Defaults to 0.05.
+	SunAngle float32
+
+	// AODistance is how far (in world units) surfaces occlude the ambient light falling on the surfaces around them; 0 disables ambient occlusion.
+	// Defaults to 1.
+	AODistance float32
+
+	// SkyStrength is how strongly the Scene's World's ClearColor lights surfaces through the light bouncing in from the sky. Defaults to 0, as the
+	// real-time renderer doesn't light surfaces from the sky; setting this above 0 can be good for outdoor scenes.
+	SkyStrength float32
+
+	// TransparentBackground controls whether the background of the image is transparent, rather than the Scene's World's ClearColor.
+	// Defaults to false.
+	TransparentBackground bool
+
+	// Workers is how many goroutines render the image in parallel. Defaults to 0, which uses one goroutine for each CPU.
+	Workers int
+}
+
+// NewDefaultRayTraceOptions creates a new RayTraceOptions struct with default settings.
+func NewDefaultRayTraceOptions() *RayTraceOptions {
+
+	return &RayTraceOptions{
+		Samples:        64,
+		Bounces:        2,
+		BounceStrength: 1,
+		LightRadius:    0.25,
+		SunAngle:       0.05,
+		AODistance:     1,
+	}
+
+}
+
+// RenderRayTraced renders the Scene from the given Camera's point of view offline (on the CPU) by tracing rays through the Scene,
+// using the default RayTraceOptions with the number of samples per pixel given. See Scene.RenderRayTracedWithOptions() for more information.
+func (scene *Scene) RenderRayTraced(camera *Camera, samples int) *image.NRGBA {
+	options := NewDefaultRayTraceOptions()
+	options.Samples = samples
+	return scene.RenderRayTracedWithOptions(camera, options)
+}
+
+// RenderRayTracedWithOptions renders the Scene from the given Camera's point of view offline (on the CPU) by tracing rays through the Scene,
+// producing a high-quality still image with soft shadows, ambient occlusion, and bounced light. The image is rendered using the same
+// Models, Materials (including their textures and vertex colors), Lights, and World settings (lighting, fog, and clear color) as the
+// real-time renderer, so it's suitable for rendering promotional stills or background plates (i.e. for a skybox, using
+// ebiten.NewImageFromImage() on the result) from the same assets as the game.
+//
+// Lighting follows the real-time renderer's falloffs, with CubeLights approximated (and not casting shadows). Custom shaders, billboarding,
+// and blend modes other than the default aren't supported.
+// If nil is passed instead of options, a default RayTraceOptions struct will be created and used.
+// Ray tracing is slow, and can take from seconds to minutes depending on the size of the Scene and image and the number of samples and bounces.
+// As the Materials' textures are read back from the GPU, RenderRayTracedWithOptions should be called from the game's goroutine while
+// the game is running (i.e. in your game's Update() function); the Scene shouldn't be modified while it's rendering.
+func (scene *Scene) RenderRayTracedWithOptions(camera *Camera, options *RayTraceOptions) *image.NRGBA {
+
+	if options == nil {
+		options = NewDefaultRayTraceOptions()
+	}
+
+	width, height := options.Width, options.Height
+	if width <= 0 || height <= 0 {
+		width, height = camera.Size()
+	}
+
+	result := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	tracer := newRayTracer(scene, camera, options, width, height)
+
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	rows := make(chan int, height)
+	for y := 0; y < height; y++ {
+		rows <- y
+	}
+	close(rows)
+
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < workers; w++ {
+
+		wg.Add(1)
+
+		go func() {
+
+			defer wg.Done()
+
+			for y := range rows {
+
+				// Each row has its own random source, so renders are deterministic regardless of the number of workers
+				rng := rand.New(rand.NewSource(int64(y) + 1))
+
+				for x := 0; x < width; x++ {
+					result.SetNRGBA(x, y, tracer.renderPixel(x, y, rng))
+				}
+
+			}
+
+		}()
+
+	}
+
+	wg.Wait()
+
+	return result
+
+}
+
+// rayTraceTexture is a copy of a Material's texture, read back from the GPU so it can be sampled while ray tracing.
+type rayTraceTexture struct {
+	width, height int
+	pixels        []byte // Premultiplied RGBA pixels
+	linear        bool
+}
+
+func newRayTraceTexture(texture *ebiten.Image, filter ebiten.Filter) *rayTraceTexture {
+
+	size := texture.Bounds().Size()
+
+	tex := &rayTraceTexture{
+		width:  size.X,
+		height: size.Y,
+		pixels: make([]byte, 4*size.X*size.Y),
+		linear: filter == ebiten.FilterLinear,
+	}
+
+	texture.ReadPixels(tex.pixels)
+
+	return tex
+
+}
+
+func (tex *rayTraceTexture) pixel(x, y int) Color {
+
+	x = ((x % tex.width) + tex.width) % tex.width
+	y = ((y % tex.height) + tex.height) % tex.height
+
+	i := (y*tex.width + x) * 4
+
+	a := float32(tex.pixels[i+3]) / 255
+	if a == 0 {
+		return Color{}
+	}
+
+	return NewColor(float32(tex.pixels[i])/255/a, float32(tex.pixels[i+1])/255/a, float32(tex.pixels[i+2])/255/a, a)
+
+}
+
+// sample samples the texture at the given UV coordinates, wrapping around its edges.
+func (tex *rayTraceTexture) sample(u, v float32) Color {
+
+	// As with rendering, V runs from the bottom of the texture to the top
+	x := u * float32(tex.width)
+	y := (1 - v) * float32(tex.height)
+
+	if !tex.linear {
+		return tex.pixel(int(math32.Floor(x)), int(math32.Floor(y)))
+	}
+
+	x -= 0.5
+	y -= 0.5
+
+	fx, fy := math32.Floor(x), math32.Floor(y)
+	tx, ty := x-fx, y-fy
+	ix, iy := int(fx), int(fy)
+
+	top := tex.pixel(ix, iy).Mix(tex.pixel(ix+1, iy), tx)
+	bottom := tex.pixel(ix, iy+1).Mix(tex.pixel(ix+1, iy+1), tx)
+
+	return top.Mix(bottom, ty)
+
+}
+
+// rayTraceSurface is a MeshPart of a Model that's being ray traced, along with its Model's vertices in world space.
+type rayTraceSurface struct {
+	model       *Model
+	material    *Material
+	color       Color // The Model's color multiplied by the Material's color
+	texture     *rayTraceTexture
+	lit         bool
+	transparent bool
+	positions   []Vector3
+	normals     []Vector3
+	uvs         []Vector2
+	colors      VertexColorChannel // The Mesh's active vertex color channel, if it has one
+}
+
+// albedo returns the color of the surface at the given barycentric coordinates of the given triangle.
+func (surface *rayTraceSurface) albedo(tri *rayTraceTriangle, u, v float32) Color {
+
+	w := 1 - u - v
+	i0, i1, i2 := tri.indices[0], tri.indices[1], tri.indices[2]
+
+	albedo := surface.color
+
+	if surface.colors != nil {
+		c0, c1, c2 := surface.colors[i0], surface.colors[i1], surface.colors[i2]
+		albedo = albedo.MultiplyRGBA(
+			c0.R*w+c1.R*u+c2.R*v,
+			c0.G*w+c1.G*u+c2.G*v,
+			c0.B*w+c1.B*u+c2.B*v,
+			c0.A*w+c1.A*u+c2.A*v,
+		)
+	}
+
+	if surface.texture != nil {
+		uv0, uv1, uv2 := surface.uvs[i0], surface.uvs[i1], surface.uvs[i2]
+		albedo = albedo.Multiply(surface.texture.sample(uv0.X*w+uv1.X*u+uv2.X*v, uv0.Y*w+uv1.Y*u+uv2.Y*v))
+	}
+
+	return albedo
+
+}
+
+// rayTraceTriangle is a triangle being ray traced, in world space.
+type rayTraceTriangle struct {
+	surface      *rayTraceSurface
+	indices      [3]int
+	v0           Vector3
+	edge1, edge2 Vector3
+	min, max     Vector3
+	center       Vector3
+}
+
+// rayTraceBVHNode is a node in a bounding volume hierarchy of triangles, used to quickly find the triangles a ray could strike.
+type rayTraceBVHNode struct {
+	min, max    Vector3
+	left, right int // The indices of the node's children, or -1 if the node is a leaf.
+	start, end  int // The range of triangles in the node, if it's a leaf.
+}
+
+// rayTraceHit is a ray striking a triangle.
+type rayTraceHit struct {
+	triangle    *rayTraceTriangle
+	distance    float32
+	u, v        float32
+	frontFacing bool
+}
+
+// rayTraceLight is a Light taking part in a ray traced render, with its settings read at the start of the render.
+type rayTraceLight struct {
+	light      ILight
+	color      Color // The light's color multiplied by its energy
+	position   Vector3
+	direction  Vector3 // For DirectionalLights and CubeLights, the direction towards the light
+	dimensions Dimensions
+}
+
+type rayTracer struct {
+	options   *RayTraceOptions
+	world     *World
+	triangles []rayTraceTriangle
+	nodes     []rayTraceBVHNode
+	lights    []rayTraceLight
+	ambient   Color
+
+	cameraPosition                   Vector3
+	cameraRotation                   Matrix4
+	perspective                      bool
+	halfWidth, halfHeight            float32 // The half-size of the view at a distance of 1 (for perspective cameras) or in world units (for orthographic ones)
+	width, height                    int
+	near, far                        float32
+	background                       Color
+	skyLight                         Color
+	lightingOn, fogOn, transparentBG bool
+}
+
+func newRayTracer(scene *Scene, camera *Camera, options *RayTraceOptions, width, height int) *rayTracer {
+
+	tracer := &rayTracer{
+		options:        options,
+		world:          scene.World,
+		cameraPosition: camera.WorldPosition(),
+		cameraRotation: camera.WorldRotation(),
+		perspective:    camera.Perspective(),
+		width:          width,
+		height:         height,
+		near:           camera.Near(),
+		far:            camera.Far(),
+		transparentBG:  options.TransparentBackground,
+		lightingOn:     true,
+	}
+
+	aspect := float32(width) / float32(height)
+
+	if tracer.perspective {
+		tracer.halfHeight = math32.Tan(math32.ToRadians(camera.FieldOfView()) / 2)
+		tracer.halfWidth = tracer.halfHeight * aspect
+	} else {
+		tracer.halfWidth = camera.OrthoScale() / 2
+		tracer.halfHeight = tracer.halfWidth / aspect
+	}
+
+	if world := scene.World; world != nil {
+		tracer.background = world.ClearColor
+		tracer.skyLight = world.ClearColor.MultiplyScalarRGB(options.SkyStrength)
+		tracer.lightingOn = world.LightingOn
+		tracer.fogOn = world.FogOn && len(world.FogRange) >= 2
+		if world.AmbientLight != nil && world.AmbientLight.IsOn() {
+			tracer.ambient = world.AmbientLight.Color().MultiplyScalarRGB(world.AmbientLight.Energy())
+		}
+	} else {
+		tracer.background = NewColor(0, 0, 0, 1)
+	}
+
+	textures := map[*ebiten.Image]*rayTraceTexture{}
+
+	scene.Root.SearchTree().ForEach(func(node INode) bool {
+
+		switch n := node.(type) {
+
+		case *Model:
+			if n.visible && n.Mesh != nil && !n.DynamicBatcher() {
+				tracer.addModel(n, textures)
+			}
+
+		case ILight:
+
+			if !n.IsOn() {
+				return true
+			}
+
+			light := rayTraceLight{
+				light:    n,
+				color:    n.Color().MultiplyScalarRGB(n.Energy()),
+				position: n.WorldPosition(),
+			}
+
+			switch l := n.(type) {
+			case *AmbientLight:
+				tracer.ambient = tracer.ambient.Add(light.color)
+				return true
+			case *DirectionalLight:
+				light.direction = l.WorldRotation().Forward()
+			case *CubeLight:
+				light.dimensions = l.TransformedDimensions()
+				light.direction = l.WorldRotation().MultVec(l.LightingAngle).Invert().Unit()
+			}
+
+			tracer.lights = append(tracer.lights, light)
+
+		}
+
+		return true
+
+	})
+
+	if len(tracer.triangles) > 0 {
+		tracer.build(0, len(tracer.triangles))
+	}
+
+	return tracer
+
+}
+
+// addModel adds the visible MeshParts of the Model to the ray tracer, transformed into world space.
+func (tracer *rayTracer) addModel(model *Model, textures map[*ebiten.Image]*rayTraceTexture) {
+
+	mesh := model.Mesh
+
+	positions := make([]Vector3, len(mesh.VertexPositions))
+	normals := make([]Vector3, len(mesh.VertexPositions))
+
+	if model.deformed() {
+		copy(positions, mesh.vertexSkinnedPositions)
+		copy(normals, mesh.vertexSkinnedNormals)
+	} else {
+
+		transform := model.Transform()
+		_, _, rotation := transform.Decompose()
+
+		for i, pos := range mesh.VertexPositions {
+			positions[i] = transform.MultVec(pos)
+			if i < len(mesh.VertexNormals) {
+				normals[i] = rotation.MultVec(mesh.VertexNormals[i]).Unit()
+			}
+		}
+
+	}
+
+	if model.VertexTransformFunction != nil {
+		for i := range positions {
+			model.VertexTransformFunction(&positions[i], i)
+		}
+	}
+
+	var colors VertexColorChannel
+	if ch := mesh.VertexActiveColorChannel; ch >= 0 && ch < len(mesh.VertexColors) {
+		colors = mesh.VertexColors[ch]
+	}
+
+	for _, part := range mesh.MeshParts {
+
+		if !part.isVisible() {
+			continue
+		}
+
+		surface := &rayTraceSurface{
+			model:       model,
+			material:    part.Material,
+			color:       model.Color,
+			lit:         tracer.lightingOn && !model.Shadeless,
+			transparent: model.isTransparent(part),
+			positions:   positions,
+			normals:     normals,
+			uvs:         mesh.VertexUVs,
+			colors:      colors,
+		}
+
+		if mat := part.Material; mat != nil {
+
+			surface.color = surface.color.Multiply(mat.Color)
+			surface.lit = surface.lit && !mat.Shadeless
+
+			if mat.Texture != nil && mat.UseTexture {
+				if _, exists := textures[mat.Texture]; !exists {
+					textures[mat.Texture] = newRayTraceTexture(mat.Texture, mat.TextureFilterMode)
+				}
+				surface.texture = textures[mat.Texture]
+				surface.transparent = surface.transparent || mat.TransparencyMode != TransparencyModeOpaque
+			}
+
+		}
+
+		part.ForEachTri(func(tri *Triangle) {
+
+			v0 := positions[tri.VertexIndices[0]]
+			v1 := positions[tri.VertexIndices[1]]
+			v2 := positions[tri.VertexIndices[2]]
+
+			min := Vector3{math32.Min(v0.X, math32.Min(v1.X, v2.X)), math32.Min(v0.Y, math32.Min(v1.Y, v2.Y)), math32.Min(v0.Z, math32.Min(v1.Z, v2.Z))}
+			max := Vector3{math32.Max(v0.X, math32.Max(v1.X, v2.X)), math32.Max(v0.Y, math32.Max(v1.Y, v2.Y)), math32.Max(v0.Z, math32.Max(v1.Z, v2.Z))}
+
+			tracer.triangles = append(tracer.triangles, rayTraceTriangle{
+				surface: surface,
+				indices: [3]int{tri.VertexIndices[0], tri.VertexIndices[1], tri.VertexIndices[2]},
+				v0:      v0,
+				edge1:   v1.Sub(v0),
+				edge2:   v2.Sub(v0),
+				min:     min,
+				max:     max,
+				center:  min.Add(max).Scale(0.5),
+			})
+
+		})
+
+	}
+
+}
+
+func vectorAxis(vec Vector3, axis int) float32 {
+	switch axis {
+	case 0:
+		return vec.X
+	case 1:
+		return vec.Y
+	default:
+		return vec.Z
+	}
+}
+
+// build builds the bounding volume hierarchy for the given range of triangles, returning the index of the created node.
+func (tracer *rayTracer) build(start, end int) int {
+
+	node := rayTraceBVHNode{
+		min:   Vector3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32},
+		max:   Vector3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32},
+		left:  -1,
+		right: -1,
+		start: start,
+		end:   end,
+	}
+
+	centerMin := node.min
+	centerMax := node.max
+
+	for _, tri := range tracer.triangles[start:end] {
+		node.min = Vector3{math32.Min(node.min.X, tri.min.X), math32.Min(node.min.Y, tri.min.Y), math32.Min(node.min.Z, tri.min.Z)}
+		node.max = Vector3{math32.Max(node.max.X, tri.max.X), math32.Max(node.max.Y, tri.max.Y), math32.Max(node.max.Z, tri.max.Z)}
+		centerMin = Vector3{math32.Min(centerMin.X, tri.center.X), math32.Min(centerMin.Y, tri.center.Y), math32.Min(centerMin.Z, tri.center.Z)}
+		centerMax = Vector3{math32.Max(centerMax.X, tri.center.X), math32.Max(centerMax.Y, tri.center.Y), math32.Max(centerMax.Z, tri.center.Z)}
+	}
+
+	index := len(tracer.nodes)
+	tracer.nodes = append(tracer.nodes, node)
+
+	if end-start <= 4 {
+		return index
+	}
+
+	// Split the triangles in half along the longest axis of their centers
+	extent := centerMax.Sub(centerMin)
+	axis := 0
+	if extent.Y > extent.X && extent.Y >= extent.Z {
+		axis = 1
+	} else if extent.Z > extent.X && extent.Z > extent.Y {
+		axis = 2
+	}
+
+	if vectorAxis(extent, axis) <= 0 {
+		return index
+	}
+
+	tris := tracer.triangles[start:end]
+	sort.Slice(tris, func(i, j int) bool {
+		return vectorAxis(tris[i].center, axis) < vectorAxis(tris[j].center, axis)
+	})
+
+	mid := (start + end) / 2
+
+	left := tracer.build(start, mid)
+	right := tracer.build(mid, end)
+
+	tracer.nodes[index].left = left
+	tracer.nodes[index].right = right
+
+	return index
+
+}
+
+// intersect returns the closest triangle struck by the ray within the given distance. If cull is true, backfaces of Materials with
+// BackfaceCulling on are skipped. Transparent surfaces let the ray through randomly according to their opacity.
+func (tracer *rayTracer) intersect(origin, dir Vector3, maxDistance float32, cull bool, rng *rand.Rand) (rayTraceHit, bool) {
+
+	hit := rayTraceHit{distance: maxDistance}
+	found := false
+
+	if len(tracer.nodes) == 0 {
+		return hit, false
+	}
+
+	invDir := Vector3{1 / dir.X, 1 / dir.Y, 1 / dir.Z}
+
+	stack := make([]int, 1, 64)
+
+	for len(stack) > 0 {
+
+		node := &tracer.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+
+		// Ray - AABB slab test
+		tx1, tx2 := (node.min.X-origin.X)*invDir.X, (node.max.X-origin.X)*invDir.X
+		ty1, ty2 := (node.min.Y-origin.Y)*invDir.Y, (node.max.Y-origin.Y)*invDir.Y
+		tz1, tz2 := (node.min.Z-origin.Z)*invDir.Z, (node.max.Z-origin.Z)*invDir.Z
+
+		tMin := math32.Max(math32.Min(tx1, tx2), math32.Max(math32.Min(ty1, ty2), math32.Min(tz1, tz2)))
+		tMax := math32.Min(math32.Max(tx1, tx2), math32.Min(math32.Max(ty1, ty2), math32.Max(tz1, tz2)))
+
+		if tMax < 0 || tMin > tMax || tMin > hit.distance {
+			continue
+		}
+
+		if node.left >= 0 {
+			stack = append(stack, node.left, node.right)
+			continue
+		}
+
+		for i := node.start; i < node.end; i++ {
+
+			tri := &tracer.triangles[i]
+
+			// Möller–Trumbore ray-triangle intersection
+			p := dir.Cross(tri.edge2)
+			det := tri.edge1.Dot(p)
+			if math32.Abs(det) < 1e-10 {
+				continue
+			}
+
+			inv := 1 / det
+			s := origin.Sub(tri.v0)
+
+			u := s.Dot(p) * inv
+			if u < 0 || u > 1 {
+				continue
+			}
+
+			q := s.Cross(tri.edge1)
+			v := dir.Dot(q) * inv
+			if v < 0 || u+v > 1 {
+				continue
+			}
+
+			dist := tri.edge2.Dot(q) * inv
+			if dist <= 1e-4 || dist >= hit.distance {
+				continue
+			}
+
+			surface := tri.surface
+
+			if cull && det < 0 && surface.material != nil && surface.material.BackfaceCulling {
+				continue
+			}
+
+			if surface.transparent {
+
+				alpha := surface.albedo(tri, u, v).A
+
+				if surface.material != nil && surface.material.TransparencyMode == TransparencyModeAlphaClip {
+					if alpha < 0.5 {
+						continue
+					}
+				} else if rng.Float32() >= alpha {
+					continue
+				}
+
+			}
+
+			hit = rayTraceHit{
+				triangle:    tri,
+				distance:    dist,
+				u:           u,
+				v:           v,
+				frontFacing: det > 0,
+			}
+			found = true
+
+		}
+
+	}
+
+	return hit, found
+
+}
+
+// renderPixel traces the samples for the given pixel, returning the resulting color.
+func (tracer *rayTracer) renderPixel(x, y int, rng *rand.Rand) color.NRGBA {
+
+	samples := tracer.options.Samples
+	if samples < 1 {
+		samples = 1
+	}
+
+	forward := tracer.cameraRotation.MultVec(Vector3{0, 0, -1})
+	right := tracer.cameraRotation.MultVec(Vector3{1, 0, 0})
+	up := tracer.cameraRotation.MultVec(Vector3{0, 1, 0})
+
+	var r, g, b, a float32
+
+	for s := 0; s < samples; s++ {
+
+		// Jittering the ray within the pixel anti-aliases the image
+		sx := ((float32(x)+rng.Float32())/float32(tracer.width))*2 - 1
+		sy := 1 - ((float32(y)+rng.Float32())/float32(tracer.height))*2
+
+		var origin, dir Vector3
+
+		if tracer.perspective {
+			origin = tracer.cameraPosition
+			dir = forward.Add(right.Scale(sx * tracer.halfWidth)).Add(up.Scale(sy * tracer.halfHeight)).Unit()
+		} else {
+			origin = tracer.cameraPosition.Add(right.Scale(sx * tracer.halfWidth)).Add(up.Scale(sy * tracer.halfHeight))
+			dir = forward
+		}
+
+		sample, hit := tracer.trace(origin, dir, 0, rng)
+
+		if !hit {
+			if tracer.transparentBG {
+				continue
+			}
+			sample = tracer.background
+			sample.A = 1
+		}
+
+		// The color's averaged premultiplied, so that edges against a transparent background blend properly
+		r += sample.R * sample.A
+		g += sample.G * sample.A
+		b += sample.B * sample.A
+		a += sample.A
+
+	}
+
+	if a <= 0 {
+		return color.NRGBA{}
+	}
+
+	return color.NRGBA{
+		R: uint8(math32.Clamp(r/a, 0, 1) * 255),
+		G: uint8(math32.Clamp(g/a, 0, 1) * 255),
+		B: uint8(math32.Clamp(b/a, 0, 1) * 255),
+		A: uint8(math32.Clamp(a/float32(samples), 0, 1) * 255),
+	}
+
+}
+
+// trace traces a ray through the Scene, returning the light reflected back along the ray from the surface struck (and if a surface was struck at all).
+// depth is how many times the ray has bounced; only camera rays (at a depth of 0) are fogged.
+func (tracer *rayTracer) trace(origin, dir Vector3, depth int, rng *rand.Rand) (Color, bool) {
+
+	maxDistance := float32(math.MaxFloat32)
+	if depth == 0 {
+		maxDistance = tracer.far
+	}
+
+	hit, ok := tracer.intersect(origin, dir, maxDistance, true, rng)
+	if !ok {
+		return Color{}, false
+	}
+
+	tri := hit.triangle
+	surface := tri.surface
+
+	result := surface.albedo(tri, hit.u, hit.v)
+	result.A = 1
+
+	position := origin.Add(dir.Scale(hit.distance))
+
+	if surface.lit {
+
+		w := 1 - hit.u - hit.v
+		normal := surface.normals[tri.indices[0]].Scale(w).Add(surface.normals[tri.indices[1]].Scale(hit.u)).Add(surface.normals[tri.indices[2]].Scale(hit.v)).Unit()
+		geometryNormal := tri.edge1.Cross(tri.edge2).Unit()
+
+		// Backfaces are lit as though they faced the other way
+		if !hit.frontFacing {
+			normal = normal.Invert()
+			geometryNormal = geometryNormal.Invert()
+		}
+
+		light := tracer.light(position, normal, geometryNormal, surface, rng)
+
+		if depth < tracer.options.Bounces && tracer.options.BounceStrength > 0 {
+
+			bounceDir := randomCosineDirection(geometryNormal, rng)
+
+			bounce, bounced := tracer.trace(position.Add(geometryNormal.Scale(1e-3)), bounceDir, depth+1, rng)
+			if !bounced {
+				bounce = tracer.skyLight
+			}
+
+			light = light.Add(bounce.MultiplyScalarRGB(tracer.options.BounceStrength))
+
+		}
+
+		result = result.MultiplyRGBA(light.R, light.G, light.B, 1)
+
+	}
+
+	if depth == 0 && tracer.fogOn && (surface.material == nil || !surface.material.Fogless) {
+		result = tracer.fog(result, position)
+	}
+
+	return result, true
+
+}
+
+// light returns the light falling on the given position with the given normal (and geometric normal, which faces the side the position is lit from).
+func (tracer *rayTracer) light(position, normal, geometryNormal Vector3, surface *rayTraceSurface, rng *rand.Rand) Color {
+
+	lightingMode := LightingModeDefault
+	if surface.material != nil {
+		lightingMode = surface.material.LightingMode
+	}
+
+	origin := position.Add(geometryNormal.Scale(1e-3))
+
+	total := Color{}
+
+	// Ambient light, occluded by the nearby surfaces
+
+	if tracer.ambient.R > 0 || tracer.ambient.G > 0 || tracer.ambient.B > 0 {
+
+		occlusion := float32(1)
+
+		if tracer.options.AODistance > 0 {
+			if _, occluded := tracer.intersect(origin, randomCosineDirection(geometryNormal, rng), tracer.options.AODistance, false, rng); occluded {
+				occlusion = 0
+			}
+		}
+
+		total = total.Add(tracer.ambient.MultiplyScalarRGB(occlusion))
+
+	}
+
+	for _, light := range tracer.lights {
+
+		var toLight Vector3
+		var factor float32
+		distance := float32(math.MaxFloat32)
+
+		switch l := light.light.(type) {
+
+		case *PointLight:
+
+			// Sampling a random point on the light's sphere softens its shadows
+			target := light.position
+			if tracer.options.LightRadius > 0 {
+				target = target.Add(randomDirection(rng).Scale(tracer.options.LightRadius))
+			}
+
+			toLight = target.Sub(position)
+			distance = toLight.Magnitude()
+			toLight = toLight.Scale(1 / distance)
+
+			distanceSquared := position.DistanceSquared(light.position)
+
+			if l.Range > 0 {
+				rangeSquared := l.Range * l.Range
+				if distanceSquared > rangeSquared {
+					continue
+				}
+				factor = math32.Clamp((rangeSquared-distanceSquared)/distanceSquared, 0, 1)
+			} else {
+				factor = 1
+			}
+
+			// The same falloff as PointLight.Light()
+			factor *= (1.0 / (1.0 + (0.1 * distanceSquared))) * 2
+
+		case *DirectionalLight:
+
+			toLight = light.direction
+
+			if tracer.options.SunAngle > 0 {
+				toLight = toLight.Add(randomDirection(rng).Scale(math32.Tan(tracer.options.SunAngle/2) * math32.Sqrt(rng.Float32()))).Unit()
+			}
+
+			factor = 1
+
+		case *CubeLight:
+
+			if !light.dimensions.Inside(position) {
+				continue
+			}
+
+			toLight = light.direction
+			factor = 1
+
+			diffuse := normalDiffuse(normal, toLight, lightingMode)
+			diffuse = diffuse*(1-l.Bleed) + l.Bleed
+
+			// CubeLights don't cast shadows, as they're an approximation of light filling a space
+			total = total.Add(light.color.MultiplyScalarRGB(diffuse * factor))
+			continue
+
+		default:
+			continue
+
+		}
+
+		diffuse := normalDiffuse(normal, toLight, lightingMode)
+		if diffuse <= 0 || factor <= 0 {
+			continue
+		}
+
+		if _, shadowed := tracer.intersect(origin, toLight, distance, false, rng); shadowed {
+			continue
+		}
+
+		total = total.Add(light.color.MultiplyScalarRGB(diffuse * factor))
+
+	}
+
+	return total
+
+}
+
+// normalDiffuse returns how strongly light from the given direction falls on a surface with the given normal and lighting mode.
+func normalDiffuse(normal, toLight Vector3, lightingMode int) float32 {
+
+	switch lightingMode {
+	case LightingModeFixedNormals:
+		return 1
+	case LightingModeDoubleSided:
+		return math32.Abs(normal.Dot(toLight))
+	}
+
+	return math32.Max(normal.Dot(toLight), 0)
+
+}
+
+// fog applies the World's fog to the given color at the given world position.
+func (tracer *rayTracer) fog(c Color, position Vector3) Color {
+
+	world := tracer.world
+
+	forward := tracer.cameraRotation.MultVec(Vector3{0, 0, -1})
+	depth := math32.Clamp((position.Sub(tracer.cameraPosition).Dot(forward)-tracer.near)/(tracer.far-tracer.near), 0, 1)
+
+	switch world.FogCurve {
+	case FogCurveOutCirc:
+		depth = math32.Sqrt(1 - (depth-1)*(depth-1))
+	case FogCurveInCirc:
+		depth = 1 - math32.Sqrt(1-depth*depth)
+	}
+
+	// Smoothstep, as in the fragment shader
+	d := math32.Clamp((depth-world.FogRange[0])/(world.FogRange[1]-world.FogRange[0]), 0, 1)
+	d = d * d * (3 - 2*d)
+
+	fog := world.FogColor
+
+	switch world.FogMode {
+	case FogAdd:
+		c = c.AddRGBA(fog.R*d, fog.G*d, fog.B*d, 0)
+	case FogSub:
+		c = c.AddRGBA(-fog.R*d, -fog.G*d, -fog.B*d, 0)
+	case FogOverwrite:
+		c = NewColor(c.R+(fog.R-c.R)*d, c.G+(fog.G-c.G)*d, c.B+(fog.B-c.B)*d, c.A)
+	case FogTransparent:
+		c.A *= math32.Abs(1 - d)
+	}
+
+	return c
+
+}
+
+// randomDirection returns a random direction using the given random source.
+func randomDirection(rng *rand.Rand) Vector3 {
+	z := rng.Float32()*2 - 1
+	angle := rng.Float32() * math32.Pi * 2
+	r := math32.Sqrt(1 - z*z)
+	return Vector3{r * math32.Cos(angle), r * math32.Sin(angle), z}
+}
+
+// randomCosineDirection returns a random direction across the hemisphere around the given normal, weighted towards the normal by the
+// cosine of the angle to it (so that light gathered along the directions doesn't have to be weighted by the angle).
+func randomCosineDirection(normal Vector3, rng *rand.Rand) Vector3 {
+
+	helper := WorldUp
+	if math32.Abs(normal.Y) > 0.99 {
+		helper = WorldRight
+	}
+
+	tangent := helper.Cross(normal).Unit()
+	bitangent := normal.Cross(tangent)
+
+	u := rng.Float32()
+	r := math32.Sqrt(u)
+	phi := rng.Float32() * math32.Pi * 2
+
+	return tangent.Scale(r * math32.Cos(phi)).Add(bitangent.Scale(r * math32.Sin(phi))).Add(normal.Scale(math32.Sqrt(1 - u)))
+
+}
@@ -0,0 +1,171 @@
+package tetra3d
+
+import "io"
+
+// MotionRecorder samples the local transforms and visibility of a Node and the Nodes in its tree over time during gameplay, and
+// records them into an Animation. The Animation can be played back using an AnimationPlayer (i.e. for replays) or exported to a
+// GLTF file (i.e. for authoring animations by physically puppeting objects in-game and then touching them up in Blender).
+// Each Node's motion is recorded into a channel named after the Node, as AnimationPlayers assign channels to Nodes by name;
+// Nodes in the tree should have unique names for the recorded motion to play back properly.
+type MotionRecorder struct {
+	Root INode // The root of the Node tree to record.
+	// SampleRate is how many times per second the Nodes' transforms are sampled while recording. Defaults to 30.
+	// If SampleRate is 0 or less, the transforms are sampled on every call to MotionRecorder.Update().
+	SampleRate float32
+
+	name        string
+	animation   *Animation
+	recording   bool
+	time        float32
+	sinceSample float32
+}
+
+// NewMotionRecorder creates a new MotionRecorder that records the given Node and its tree into Animations of the given name.
+func NewMotionRecorder(root INode, animationName string) *MotionRecorder {
+	return &MotionRecorder{
+		Root:       root,
+		SampleRate: 30,
+		name:       animationName,
+	}
+}
+
+// Start starts recording into a new Animation, sampling the Nodes' current transforms as the first keyframes.
+// Animations previously returned by MotionRecorder.Animation() are left as-is.
+func (recorder *MotionRecorder) Start() {
+
+	recorder.animation = NewAnimation(recorder.name)
+	recorder.recording = true
+	recorder.time = 0
+	recorder.sinceSample = 0
+
+	recorder.Sample()
+
+}
+
+// Stop stops recording, sampling the Nodes' transforms one last time if they haven't been sampled at the current time
+// (so the Animation's length matches the time spent recording).
+func (recorder *MotionRecorder) Stop() {
+
+	if !recorder.recording {
+		return
+	}
+
+	if recorder.sinceSample > 0 {
+		recorder.Sample()
+	}
+
+	recorder.recording = false
+
+}
+
+// Recording returns if the MotionRecorder is currently recording.
+func (recorder *MotionRecorder) Recording() bool {
+	return recorder.recording
+}
+
+// Update advances the MotionRecorder's time by dt seconds while recording, sampling the Nodes' transforms according to
+// the MotionRecorder's SampleRate. Update should be called once per game tick after the recorded Nodes have moved.
+func (recorder *MotionRecorder) Update(dt float32) {
+
+	if !recorder.recording {
+		return
+	}
+
+	recorder.time += dt
+	recorder.sinceSample += dt
+
+	if recorder.SampleRate <= 0 || recorder.sinceSample >= 1/recorder.SampleRate {
+		recorder.Sample()
+	}
+
+}
+
+// Sample samples the current transforms and visibility of the Nodes in the MotionRecorder's tree into the Animation being
+// recorded at the current recording time. Keyframes that don't change a track's value (i.e. for Nodes that are standing still)
+// are merged together to keep the Animation small. Sample does nothing if the MotionRecorder isn't recording.
+func (recorder *MotionRecorder) Sample() {
+
+	if !recorder.recording || recorder.Root == nil {
+		return
+	}
+
+	recorder.sinceSample = 0
+
+	nodes := append([]INode{recorder.Root}, recorder.Root.SearchTree().INodes()...)
+
+	// Only the first Node of each name is sampled, as the others would be played back using the same channel
+	sampled := map[string]bool{}
+
+	for _, node := range nodes {
+
+		if sampled[node.Name()] {
+			continue
+		}
+
+		sampled[node.Name()] = true
+
+		channel, exists := recorder.animation.Channels[node.Name()]
+
+		if !exists {
+			channel = recorder.animation.AddChannel(node.Name())
+			channel.AddTrack(TrackTypePosition)
+			channel.AddTrack(TrackTypeScale)
+			channel.AddTrack(TrackTypeRotation)
+			channel.AddTrack(TrackTypeVisible).Interpolation = InterpolationConstant
+		}
+
+		recorder.addKeyframe(channel.Tracks[TrackTypePosition], node.LocalPosition())
+		recorder.addKeyframe(channel.Tracks[TrackTypeScale], node.LocalScale())
+		recorder.addKeyframe(channel.Tracks[TrackTypeRotation], node.LocalRotation().ToQuaternion())
+		recorder.addKeyframe(channel.Tracks[TrackTypeVisible], node.Visible())
+
+	}
+
+	recorder.animation.Length = recorder.time
+
+}
+
+// addKeyframe adds a keyframe with the given value to the track at the current recording time. If the value is the same as
+// the last two keyframes', the last keyframe is moved to the current time instead, as it would interpolate the same way.
+func (recorder *MotionRecorder) addKeyframe(track *AnimationTrack, value any) {
+
+	keys := track.Keyframes
+
+	if count := len(keys); count >= 2 && keys[count-1].Data.contents == value && keys[count-2].Data.contents == value {
+		keys[count-1].Time = recorder.time
+		return
+	}
+
+	track.AddKeyframe(recorder.time, value)
+
+}
+
+// Time returns how long the MotionRecorder has been recording (or recorded for, if it's stopped) in seconds.
+func (recorder *MotionRecorder) Time() float32 {
+	return recorder.time
+}
+
+// Animation returns the Animation recorded (or being recorded) by the MotionRecorder. If the MotionRecorder has never started
+// recording, Animation returns nil.
+func (recorder *MotionRecorder) Animation() *Animation {
+	return recorder.animation
+}
+
+// ExportGLTF writes the MotionRecorder's Node tree along with its recorded Animation to the given writer as a GLTF file. If options
+// is nil, DefaultGLTFExportOptions() is used. See Library.ExportGLTF() for more information. If the MotionRecorder has never
+// started recording, only the Node tree is exported.
+func (recorder *MotionRecorder) ExportGLTF(writer io.Writer, options *GLTFExportOptions) error {
+
+	exporter := newGLTFExporter(options)
+
+	if _, err := exporter.addScene(recorder.name, []INode{recorder.Root}); err != nil {
+		return err
+	}
+
+	if recorder.animation != nil {
+		exporter.addAnimation(recorder.animation)
+	}
+
+	return exporter.write(writer)
+
+}
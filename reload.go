@@ -0,0 +1,331 @@
+package tetra3d
+
+import (
+	"bytes"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Reload re-parses the given GLTF file data (i.e. the file the Library was loaded from, after it's been re-exported) and patches the
+// Library's existing resources in place to match it, allowing you to tweak your scenes in Blender and see the changes without restarting
+// the game. Reload uses the GLTFLoadOptions the Library was originally loaded with, if any (though textures are always created immediately).
+//
+// Meshes, Materials, Animations, and Worlds are updated in place when one of the same name exists, so everything referencing them
+// (i.e. Models in cloned Scenes, or AnimationPlayers) sees the changes; new ones are added, while ones that are no longer in the file
+// are left as-is. Textures of updated Materials are disposed of once they're replaced (unless another of the Library's Materials
+// still uses them), as are custom shaders compiled for them through Material.SetShaderText(). For each of the Library's Scenes, Nodes are matched to the reloaded Nodes by name and type under the same parent;
+// matched Nodes keep their identity, with their transform, visibility, game properties, and type-specific settings (i.e. a Model's
+// Mesh or a Light's color) updated. Reloaded Nodes that don't match an existing Node are added, and existing Nodes that don't match a
+// reloaded Node are removed. Scenes that are no longer in the file are removed from the Library.
+// Note that Scenes cloned from the Library's Scenes (i.e. the Scenes your game is running) only see changes to the shared resources.
+func (lib *Library) Reload(data []byte) error {
+
+	options := DefaultGLTFLoadOptions()
+	if lib.loadOptions != nil {
+		copied := *lib.loadOptions
+		options = &copied
+	}
+
+	options.DeferFinalization = false
+	options.onProgress = nil
+
+	reloaded, err := LoadGLTFData(bytes.NewReader(data), options)
+	if err != nil {
+		return err
+	}
+
+	reloader := &libraryReloader{
+		library:   lib,
+		meshes:    map[*Mesh]*Mesh{},
+		materials: map[*Material]*Material{},
+		worlds:    map[*World]*World{},
+		nodes:     map[INode]INode{},
+		bones:     map[*Node]*Node{},
+	}
+
+	// Textures of reloaded Materials that are no longer used once they've been replaced are disposed to free VRAM
+	oldTextures := map[*ebiten.Image]bool{}
+
+	for name, mat := range reloaded.Materials {
+		if existing, exists := lib.Materials[name]; exists {
+			if existing.Texture != nil && existing.Texture != mat.Texture {
+				oldTextures[existing.Texture] = true
+			}
+			// Shaders set through Material.SetShader() may be shared with other Materials, so only those compiled for the Material are disposed
+			if existing.fragmentSrc != nil && existing.fragmentShader != mat.fragmentShader {
+				existing.DisposeShader()
+			}
+			*existing = *mat
+			existing.library = lib
+			reloader.materials[mat] = existing
+		} else {
+			mat.library = lib
+			lib.Materials[name] = mat
+		}
+	}
+
+	for _, mat := range lib.Materials {
+		delete(oldTextures, mat.Texture)
+	}
+
+	for texture := range oldTextures {
+		texture.Dispose()
+	}
+
+	// Meshes don't hold any GPU resources themselves (their vertices are sent to the GPU each frame as they're rendered), so
+	// they don't need to be disposed of before being replaced.
+	for name, mesh := range reloaded.Meshes {
+
+		for _, part := range mesh.MeshParts {
+			part.Material = reloader.material(part.Material)
+		}
+
+		if existing, exists := lib.Meshes[name]; exists {
+			*existing = *mesh
+			existing.library = lib
+			for _, part := range existing.MeshParts {
+				part.Mesh = existing
+			}
+			reloader.meshes[mesh] = existing
+		} else {
+			mesh.library = lib
+			lib.Meshes[name] = mesh
+		}
+
+	}
+
+	for name, anim := range reloaded.Animations {
+		if existing, exists := lib.Animations[name]; exists {
+			*existing = *anim
+			existing.library = lib
+		} else {
+			anim.library = lib
+			lib.Animations[name] = anim
+		}
+	}
+
+	for name, world := range reloaded.Worlds {
+		if existing, exists := lib.Worlds[name]; exists {
+			*existing = *world
+			reloader.worlds[world] = existing
+		} else {
+			lib.Worlds[name] = world
+		}
+	}
+
+	scenes := make([]*Scene, 0, len(reloaded.Scenes))
+
+	for _, scene := range reloaded.Scenes {
+
+		var existing *Scene
+
+		for _, s := range lib.Scenes {
+			if s.Name == scene.Name {
+				existing = s
+				break
+			}
+		}
+
+		if existing == nil {
+			existing = scene
+			existing.library = lib
+			reloader.adopt(scene.Root)
+		} else {
+			reloader.reloadTree(existing.Root, scene.Root)
+			existing.props = scene.props
+			existing.View3DCameras = scene.View3DCameras
+			existing.updateAutobatch = true
+		}
+
+		existing.World = reloader.world(scene.World)
+
+		if reloaded.ExportedScene == scene {
+			lib.ExportedScene = existing
+		}
+
+		scenes = append(scenes, existing)
+
+	}
+
+	lib.Scenes = scenes
+
+	reloader.remapBones()
+
+	return nil
+
+}
+
+// libraryReloader holds the mapping from reloaded resources to the existing resources that they were patched into while reloading
+// a Library (see Library.Reload()).
+type libraryReloader struct {
+	library   *Library
+	meshes    map[*Mesh]*Mesh
+	materials map[*Material]*Material
+	worlds    map[*World]*World
+	nodes     map[INode]INode // The existing Nodes that each reloaded Node was matched to
+	bones     map[*Node]*Node // The same as nodes, but for the base Nodes, as bones are stored
+	models    []*Model        // The existing Models whose bones need to be remapped to the existing Nodes
+}
+
+func (reloader *libraryReloader) mesh(mesh *Mesh) *Mesh {
+	if existing, exists := reloader.meshes[mesh]; exists {
+		return existing
+	}
+	return mesh
+}
+
+func (reloader *libraryReloader) material(mat *Material) *Material {
+	if existing, exists := reloader.materials[mat]; exists {
+		return existing
+	}
+	return mat
+}
+
+func (reloader *libraryReloader) world(world *World) *World {
+	if existing, exists := reloader.worlds[world]; exists {
+		return existing
+	}
+	return world
+}
+
+// reloadTree updates the existing Node and its tree to match the reloaded Node and its tree.
+func (reloader *libraryReloader) reloadTree(existing, reloaded INode) {
+
+	reloader.nodes[reloaded] = existing
+	reloader.bones[reloaded.getNode()] = existing.getNode()
+	reloader.reloadNode(existing, reloaded)
+
+	existingChildren := append([]INode{}, existing.Children()...)
+	matched := map[INode]bool{}
+
+	// Reloaded children are moved over to the existing Node if unmatched, so we iterate over a copy of the slice
+	for _, child := range append([]INode{}, reloaded.Children()...) {
+
+		var match INode
+
+		for _, c := range existingChildren {
+			if !matched[c] && c.Name() == child.Name() && c.Type() == child.Type() {
+				match = c
+				break
+			}
+		}
+
+		if match != nil {
+			matched[match] = true
+			reloader.reloadTree(match, child)
+		} else {
+			reloader.adopt(child)
+			existing.AddChildren(child)
+		}
+
+	}
+
+	for _, child := range existingChildren {
+		if !matched[child] {
+			existing.RemoveChildren(child)
+		}
+	}
+
+}
+
+// reloadNode updates the existing Node's settings to match the reloaded Node's.
+func (reloader *libraryReloader) reloadNode(existing, reloaded INode) {
+
+	node := existing.getNode()
+	node.props = reloaded.Properties().Clone()
+	node.visible = reloaded.Visible()
+
+	existing.SetLocalPositionVec(reloaded.LocalPosition())
+	existing.SetLocalScaleVec(reloaded.LocalScale())
+	existing.SetLocalRotation(reloaded.LocalRotation())
+
+	switch e := existing.(type) {
+
+	case *Model:
+		r := reloaded.(*Model)
+		e.Mesh = reloader.mesh(r.Mesh)
+		e.Color = r.Color
+		e.Shadeless = r.Shadeless
+		e.AutoBatchMode = r.AutoBatchMode
+		e.FrustumCulling = r.FrustumCulling
+		e.skinned = r.skinned
+		e.SkinRoot = r.SkinRoot
+		e.bones = r.bones
+		// The frustum culling sphere is sized from the Mesh, so it's updated for the new Mesh along with the Model's transform.
+		e.updateFrustumSphere = r.updateFrustumSphere
+		e.dirtyTransform()
+		reloader.models = append(reloader.models, e)
+
+	case *Camera:
+		r := reloaded.(*Camera)
+		e.near = r.near
+		e.far = r.far
+		e.perspective = r.perspective
+		e.fieldOfView = r.fieldOfView
+		e.orthoScale = r.orthoScale
+		e.sphereFactorCalculated = false
+		e.updateProjectionMatrix = true
+
+	case *PointLight:
+		e.Range = reloaded.(*PointLight).Range
+
+	case *CubeLight:
+		e.Dimensions = reloaded.(*CubeLight).Dimensions
+
+	}
+
+	if light, isLight := existing.(ILight); isLight {
+		r := reloaded.(ILight)
+		light.SetColor(r.Color())
+		light.SetEnergy(r.Energy())
+		light.SetOn(r.IsOn())
+	}
+
+}
+
+// adopt prepares a reloaded Node tree that doesn't match any existing Node to be added to the existing Library.
+func (reloader *libraryReloader) adopt(node INode) {
+
+	for _, n := range append([]INode{node}, node.SearchTree().INodes()...) {
+
+		n.setLibrary(reloader.library)
+
+		if model, isModel := n.(*Model); isModel {
+			model.Mesh = reloader.mesh(model.Mesh)
+			reloader.models = append(reloader.models, model)
+		}
+
+	}
+
+}
+
+// remapBones points the bones of skinned Models in the reloaded Scenes to the existing Nodes matched to the reloaded bones.
+func (reloader *libraryReloader) remapBones() {
+
+	remap := func(node *Node) *Node {
+		if existing, exists := reloader.bones[node]; exists {
+			return existing
+		}
+		return node
+	}
+
+	for _, model := range reloader.models {
+
+		if existing, exists := reloader.nodes[model.SkinRoot]; exists {
+			model.SkinRoot = existing
+		}
+
+		bones := make([][]*Node, len(model.bones))
+
+		for i := range model.bones {
+			bones[i] = make([]*Node, len(model.bones[i]))
+			for j, bone := range model.bones[i] {
+				bones[i][j] = remap(bone)
+			}
+		}
+
+		model.bones = bones
+
+	}
+
+}
@@ -0,0 +1,375 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// RigidBody is a Node that simulates simple rigid body dynamics: gravity, linear and angular velocity, bouncing (restitution), and friction.
+// This isn't a full physics engine, but it's enough for things like crates, debris, and knock-back. A RigidBody collides against the bounding
+// objects in its Colliders (i.e. the level's BoundingTriangles, or other RigidBodies), and is pushed out of them using their collision MTVs.
+//
+// A RigidBody's shape is its first BoundingObject child (see RigidBody.Bounds()); NewRigidBody() adds the given BoundingObject as a child
+// automatically. Any other children (i.e. the Model rendering a crate) move and rotate along with the RigidBody. Note that the shape is only
+// used for collision; the RigidBody's rotational inertia is approximated as that of a solid sphere enclosing the shape. As BoundingAABBs
+// can't rotate, RigidBodies using them as their shape don't rotate either. To simulate the RigidBody, call RigidBody.Update() once per frame.
+type RigidBody struct {
+	*Node
+	On bool // Whether the RigidBody is simulated or not. Defaults to true.
+
+	Velocity        Vector3 // The linear velocity of the RigidBody in world units per second.
+	AngularVelocity Vector3 // The angular velocity of the RigidBody in radians per second; the direction is the world axis of rotation, and the magnitude is the speed.
+
+	Mass    float32 // The mass of the RigidBody, used when applying impulses and when colliding with other RigidBodies. Defaults to 1.
+	Gravity Vector3 // The acceleration applied to the RigidBody in world units per second squared. Defaults to {0, -9.8, 0}.
+
+	Restitution float32 // How bouncy the RigidBody is, ranging from 0 (not at all) to 1 (bouncing without losing any speed). Defaults to 0.2.
+	Friction    float32 // How much sliding velocity the RigidBody loses when touching colliders, ranging from 0 to 1. Defaults to 0.5.
+
+	LinearDamping  float32 // How much of the RigidBody's linear velocity is lost per second, ranging from 0 to 1. Defaults to 0.05.
+	AngularDamping float32 // How much of the RigidBody's angular velocity is lost per second, ranging from 0 to 1. Defaults to 0.1.
+
+	// Colliders is the set of bounding objects the RigidBody collides with (i.e. the level's BoundingTriangles). If nil, the RigidBody doesn't
+	// collide with anything. Collisions are tested continuously (see CollisionTestSettings.Continuous), so fast RigidBodies won't pass through thin floors.
+	Colliders NodeIterator
+
+	// InheritGroundDelta is whether the RigidBody is carried along by the collider it's standing on when that collider moves or rotates (i.e.
+	// when riding a moving platform or an elevator), using the collider's TransformDelta(). For this to work, UpdateTransformDelta() should be
+	// called on the moving platform (or the collider itself) once per frame, after the RigidBody has been updated. Defaults to true.
+	InheritGroundDelta bool
+
+	// OnCollision is called when the RigidBody collides with one of its Colliders, after the RigidBody has been pushed out of it and its velocity
+	// has been updated.
+	OnCollision func(body *RigidBody, col *Collision)
+
+	ground IBoundingObject
+}
+
+// NewRigidBody creates a new RigidBody with the given name, using the given BoundingObject as its shape. The BoundingObject is added as a
+// child of the RigidBody, and so moves along with it.
+func NewRigidBody(name string, bounds IBoundingObject) *RigidBody {
+	body := &RigidBody{
+		Node:           NewNode(name),
+		On:             true,
+		Mass:           1,
+		Gravity:        Vector3{0, -9.8, 0},
+		Restitution:    0.2,
+		Friction:       0.5,
+		LinearDamping:  0.05,
+		AngularDamping: 0.1,
+
+		InheritGroundDelta: true,
+	}
+	body.owner = body
+	if bounds != nil {
+		body.AddChildren(bounds)
+	}
+	return body
+}
+
+// Clone creates a clone of the RigidBody and its children.
+func (body *RigidBody) Clone() INode {
+
+	clone := NewRigidBody(body.name, nil)
+	clone.On = body.On
+	clone.Velocity = body.Velocity
+	clone.AngularVelocity = body.AngularVelocity
+	clone.Mass = body.Mass
+	clone.Gravity = body.Gravity
+	clone.Restitution = body.Restitution
+	clone.Friction = body.Friction
+	clone.LinearDamping = body.LinearDamping
+	clone.AngularDamping = body.AngularDamping
+	clone.Colliders = body.Colliders
+	clone.InheritGroundDelta = body.InheritGroundDelta
+	clone.OnCollision = body.OnCollision
+
+	clone.Node = body.Node.clone(clone).(*Node)
+
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+
+	return clone
+
+}
+
+// Bounds returns the BoundingObject used as the RigidBody's shape, which is its first BoundingObject child. If the RigidBody has no
+// BoundingObject children, Bounds returns nil.
+func (body *RigidBody) Bounds() IBoundingObject {
+	for _, child := range body.children {
+		if bounds, ok := child.(IBoundingObject); ok {
+			return bounds
+		}
+	}
+	return nil
+}
+
+// Ground returns the collider the RigidBody was standing on as of the last Update() call (a collider it touched whose surface faces against
+// its Gravity), or nil if it wasn't standing on anything.
+func (body *RigidBody) Ground() IBoundingObject {
+	return body.ground
+}
+
+// ApplyImpulse applies the given impulse (a change in momentum, i.e. an explosion's knock-back) to the RigidBody's center, changing its velocity.
+func (body *RigidBody) ApplyImpulse(impulse Vector3) {
+	body.Velocity = body.Velocity.Add(impulse.Scale(body.inverseMass()))
+}
+
+// ApplyImpulseAt applies the given impulse to the RigidBody at the given world position, changing both its velocity and, if the position is
+// off-center, its angular velocity.
+func (body *RigidBody) ApplyImpulseAt(impulse, position Vector3) {
+	body.ApplyImpulse(impulse)
+	body.applyAngularImpulse(impulse, position.Sub(body.WorldPosition()))
+}
+
+// applyAngularImpulse changes the RigidBody's angular velocity by the given impulse applied at the given offset from its center.
+func (body *RigidBody) applyAngularImpulse(impulse, offset Vector3) {
+	if inertia := body.inertia(); inertia > 0 {
+		body.AngularVelocity = body.AngularVelocity.Add(offset.Cross(impulse).Scale(1 / inertia))
+	}
+}
+
+func (body *RigidBody) inverseMass() float32 {
+	if body.Mass <= 0 {
+		return 1
+	}
+	return 1 / body.Mass
+}
+
+// inertia returns the RigidBody's approximate moment of inertia, which is that of a solid sphere enclosing its shape. If the RigidBody
+// can't rotate, 0 is returned.
+func (body *RigidBody) inertia() float32 {
+
+	switch body.Bounds().(type) {
+	case nil, *BoundingAABB:
+		return 0
+	}
+
+	radius := body.radius()
+
+	return 0.4 * math32.Max(body.Mass, 0.0001) * radius * radius
+
+}
+
+// radius returns the radius of a sphere centered on the RigidBody that encloses its shape.
+func (body *RigidBody) radius() float32 {
+
+	radius := float32(0.5)
+
+	switch b := body.Bounds().(type) {
+	case *BoundingAABB:
+		radius = b.Dimensions.MaxSpan() / 2
+	case *BoundingSphere:
+		radius = b.WorldRadius()
+	case *BoundingCapsule:
+		radius = math32.Max(b.WorldRadius(), b.Height*b.WorldScale().Y/2)
+	case *BoundingOBB:
+		radius = b.HalfExtents().Magnitude()
+	case *BoundingConvexHull:
+		center := b.WorldPosition()
+		radius = 0
+		for _, v := range b.WorldVertices() {
+			radius = math32.Max(radius, v.Distance(center))
+		}
+	}
+
+	return radius
+
+}
+
+// Update simulates the RigidBody for the given delta time in seconds: the RigidBody is carried along by the ground it's standing on (if
+// InheritGroundDelta is true), gravity and damping are applied, the RigidBody is moved and rotated according to its velocities, and then it's
+// pushed out of any Colliders it collides with, bouncing off of them and losing sliding velocity to friction.
+func (body *RigidBody) Update(dt float32) {
+
+	if !body.On || dt <= 0 {
+		return
+	}
+
+	if body.InheritGroundDelta && body.ground != nil {
+		body.InheritTransformDelta(body.ground)
+	}
+
+	body.ground = nil
+
+	body.Velocity = body.Velocity.Add(body.Gravity.Scale(dt))
+	body.Velocity = body.Velocity.Scale(math32.Max(1-body.LinearDamping*dt, 0))
+	body.AngularVelocity = body.AngularVelocity.Scale(math32.Max(1-body.AngularDamping*dt, 0))
+
+	bounds := body.Bounds()
+
+	var from Vector3
+	if bounds != nil {
+		from = bounds.WorldPosition()
+	}
+
+	body.SetWorldPositionVec(body.WorldPosition().Add(body.Velocity.Scale(dt)))
+
+	if speed := body.AngularVelocity.Magnitude(); speed > 0 {
+		axis := body.AngularVelocity.Scale(1 / speed)
+		body.SetWorldRotation(body.WorldRotation().Mult(NewMatrix4Rotate(axis.X, axis.Y, axis.Z, speed*dt)))
+	}
+
+	if bounds == nil || body.Colliders == nil {
+		return
+	}
+
+	bounds.CollisionTest(CollisionTestSettings{
+		TestAgainst:    body.Colliders,
+		Continuous:     true,
+		ContinuousFrom: from,
+		OnCollision: func(col *Collision, index, count int) bool {
+
+			if col.BoundingObject.Parent() == body.owner {
+				return true
+			}
+
+			body.resolve(col, dt)
+
+			if body.OnCollision != nil {
+				body.OnCollision(body, col)
+			}
+
+			return true
+
+		},
+	})
+
+}
+
+// resolve pushes the RigidBody out of the given Collision and applies the collision's impulse to it (and to the other RigidBody, if it
+// collided with one).
+func (body *RigidBody) resolve(col *Collision, dt float32) {
+
+	mtv := col.AverageMTV()
+
+	if mtv.IsZero() {
+		return
+	}
+
+	body.SetWorldPositionVec(body.WorldPosition().Add(mtv))
+
+	normal := mtv.Unit()
+
+	// Surfaces facing against gravity at an angle of 45 degrees or less can be stood on.
+	if !body.Gravity.IsZero() && normal.Dot(body.Gravity.Unit().Invert()) >= 0.7 {
+		body.ground = col.BoundingObject
+	}
+	offset := body.contactOffset(normal)
+	contact := body.WorldPosition().Add(offset)
+
+	other, _ := col.BoundingObject.Parent().(*RigidBody)
+	if other != nil && !other.On {
+		other = nil
+	}
+
+	var otherOffset Vector3
+
+	velocity := body.Velocity.Add(body.AngularVelocity.Cross(offset))
+
+	if other != nil {
+		otherOffset = contact.Sub(other.WorldPosition())
+		velocity = velocity.Sub(other.Velocity.Add(other.AngularVelocity.Cross(otherOffset)))
+	}
+
+	// response returns how much the relative velocity at the contact point changes along the given direction for a unit impulse along it.
+	response := func(dir Vector3) float32 {
+		r := body.impulseResponse(offset, dir)
+		if other != nil {
+			r += other.impulseResponse(otherOffset, dir)
+		}
+		return r
+	}
+
+	approach := velocity.Dot(normal)
+
+	// The RigidBody is already moving away from the surface
+	if approach >= 0 {
+		return
+	}
+
+	// Slow contacts (i.e. resting on the ground, where gravity pulls the RigidBody in a bit every frame) don't bounce, to avoid jittering.
+	restitution := math32.Clamp(body.Restitution, 0, 1)
+	if -approach < body.Gravity.Magnitude()*dt*2 {
+		restitution = 0
+	}
+
+	impulse := normal.Scale(-(1 + restitution) * approach / response(normal))
+
+	// Friction removes some of the sliding velocity, but never more than would stop the RigidBody sliding entirely.
+	sliding := velocity.Sub(normal.Scale(approach))
+	if speed := sliding.Magnitude(); speed > 0 {
+		dir := sliding.Scale(1 / speed)
+		impulse = impulse.Sub(dir.Scale(math32.Clamp(body.Friction, 0, 1) * speed / response(dir)))
+	}
+
+	body.ApplyImpulse(impulse)
+	body.applyAngularImpulse(impulse, offset)
+
+	if other != nil {
+		other.ApplyImpulse(impulse.Invert())
+		other.applyAngularImpulse(impulse.Invert(), otherOffset)
+	}
+
+}
+
+// contactOffset returns the offset from the RigidBody's center to the point where it touches a surface with the given normal; this is the
+// center of the part of the RigidBody's shape that lies farthest against the normal (i.e. the middle of a box's bottom face when it's
+// resting flat on the ground, or the middle of its bottom edge when it's tipping over).
+func (body *RigidBody) contactOffset(normal Vector3) Vector3 {
+
+	center := body.WorldPosition()
+	down := normal.Invert()
+
+	var points []Vector3
+
+	switch b := body.Bounds().(type) {
+	case *BoundingSphere:
+		return b.WorldPosition().Add(down.Scale(b.WorldRadius())).Sub(center)
+	case *BoundingCapsule:
+		radius := down.Scale(b.WorldRadius())
+		points = []Vector3{b.lineBottom().Add(radius), b.lineTop().Add(radius)}
+	case *BoundingOBB:
+		points = b.Corners()
+	case *BoundingConvexHull:
+		points = b.WorldVertices()
+	}
+
+	if len(points) == 0 {
+		return Vector3{}
+	}
+
+	deepest := down.Dot(points[0])
+	size := float32(0)
+	for _, p := range points {
+		deepest = math32.Max(deepest, down.Dot(p))
+		size = math32.Max(size, p.Distance(center))
+	}
+
+	// Points within a small tolerance of the deepest one are considered to be touching the surface, so that slightly tilted shapes still rest evenly.
+	tolerance := size * 0.05
+	sum := Vector3{}
+	count := float32(0)
+	for _, p := range points {
+		if down.Dot(p) >= deepest-tolerance {
+			sum = sum.Add(p)
+			count++
+		}
+	}
+
+	return sum.Divide(count).Sub(center)
+
+}
+
+// impulseResponse returns how much the velocity of the point of the RigidBody at the given offset from its center changes along the given
+// direction for a unit impulse applied at that point along that direction.
+func (body *RigidBody) impulseResponse(offset, dir Vector3) float32 {
+	response := body.inverseMass()
+	if inertia := body.inertia(); inertia > 0 {
+		response += offset.Cross(dir).MagnitudeSquared() / inertia
+	}
+	return response
+}
+
+// Type returns the NodeType for this object.
+func (body *RigidBody) Type() NodeType {
+	return NodeTypeRigidBody
+}
@@ -0,0 +1,409 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// RopeMeshMode indicates the shape of the Mesh generated to render a Rope.
+type RopeMeshMode int
+
+const (
+	RopeMeshTube   RopeMeshMode = iota // The Rope is rendered as a round tube, suitable for cables and chains.
+	RopeMeshRibbon                     // The Rope is rendered as a flat, double-sided ribbon, suitable for banners and streamers.
+)
+
+// Rope is a Node that simulates a rope (or cable, chain, or similar) using verlet integration. The Rope is a chain of points spaced
+// apart by its SegmentLength; each end of the Rope can be attached to a Node (i.e. a grappling hook and the player) so that it follows
+// that Node, and the Rope's points collide against the bounding objects in its Colliders slice.
+// The Rope is rendered through a generated Model (a tube or ribbon) that is a child of the Rope; to simulate the Rope and update
+// its Model, call Rope.Update() once per frame.
+type Rope struct {
+	*Node
+	Model *Model // The generated Model rendering the Rope. It's a child of the Rope.
+
+	// Points are the world positions of the Rope's points, from its start to its end.
+	Points     []Vector3
+	prevPoints []Vector3
+
+	SegmentLength float32 // The resting distance between each of the Rope's points.
+	Radius        float32 // The radius of the Rope; this is how thick the generated Model is, and how close points can get to Colliders. Defaults to 0.05.
+
+	Gravity    Vector3 // The acceleration applied to the Rope's points in world units per second squared. Defaults to {0, -9.8, 0}.
+	Damping    float32 // How much of the Rope's points' velocity is lost each update, ranging from 0 to 1. Defaults to 0.01.
+	Iterations int     // How many times the Rope's segments are solved each update; higher values make the Rope stretch less. Defaults to 8.
+
+	// WindInfluence is how strongly the wind of the WindZones in the Rope's Scene drags the Rope's points along; 0 means the Rope
+	// ignores the wind. Defaults to 1.
+	WindInfluence float32
+
+	StartAttachment INode // If set, the start of the Rope is attached to this Node, following its world position.
+	EndAttachment   INode // If set, the end of the Rope is attached to this Node, following its world position.
+
+	Colliders []IBoundingObject // Bounding objects the Rope's points collide against.
+
+	meshMode        RopeMeshMode
+	sides           int
+	collisionSphere *BoundingSphere
+	windZones       []*WindZone
+}
+
+// NewRope creates a new Rope with the given name, stretching from the start to the end world positions provided, and split into
+// the given number of segments. The rest length of the Rope is the distance between start and end. The Rope's Model is generated
+// according to the mesh mode given; for tubes, sides is how many sides the tube has (minimum 3).
+func NewRope(name string, start, end Vector3, segmentCount int, meshMode RopeMeshMode, sides int) *Rope {
+
+	if segmentCount < 1 {
+		segmentCount = 1
+	}
+
+	if sides < 3 {
+		sides = 3
+	}
+
+	rope := &Rope{
+		Node:            NewNode(name),
+		Radius:          0.05,
+		Gravity:         Vector3{0, -9.8, 0},
+		Damping:         0.01,
+		Iterations:      8,
+		WindInfluence:   1,
+		meshMode:        meshMode,
+		sides:           sides,
+		collisionSphere: NewBoundingSphere("rope collision sphere", 0),
+	}
+	rope.owner = rope
+
+	rope.Reset(start, end, segmentCount)
+
+	rope.Model = NewModel(name+"_mesh", rope.generateMesh())
+	rope.AddChildren(rope.Model)
+	rope.updateMesh()
+
+	return rope
+
+}
+
+// Clone creates a clone of the Rope, including its points and generated Model.
+func (rope *Rope) Clone() INode {
+
+	clone := &Rope{
+		Points:          append([]Vector3{}, rope.Points...),
+		prevPoints:      append([]Vector3{}, rope.prevPoints...),
+		SegmentLength:   rope.SegmentLength,
+		Radius:          rope.Radius,
+		Gravity:         rope.Gravity,
+		Damping:         rope.Damping,
+		Iterations:      rope.Iterations,
+		WindInfluence:   rope.WindInfluence,
+		StartAttachment: rope.StartAttachment,
+		EndAttachment:   rope.EndAttachment,
+		Colliders:       append([]IBoundingObject{}, rope.Colliders...),
+		meshMode:        rope.meshMode,
+		sides:           rope.sides,
+		collisionSphere: NewBoundingSphere("rope collision sphere", 0),
+	}
+
+	clone.Node = rope.Node.clone(clone).(*Node)
+
+	// The generated Model is cloned along with the rest of the Rope's children, so we find it again by its index.
+	for i, child := range rope.children {
+		if child == rope.Model {
+			clone.Model = clone.children[i].(*Model)
+			clone.Model.Mesh = rope.Model.Mesh.Clone()
+			break
+		}
+	}
+
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+
+	return clone
+
+}
+
+// Reset places the Rope's points in a straight line from the start to the end world positions provided, split into the given
+// number of segments, stopping any movement. The SegmentLength of the Rope is set so that its rest length is the distance between
+// start and end. If the number of segments changes, the Rope's Model is regenerated.
+func (rope *Rope) Reset(start, end Vector3, segmentCount int) {
+
+	if segmentCount < 1 {
+		segmentCount = 1
+	}
+
+	rope.Points = rope.Points[:0]
+
+	for i := 0; i <= segmentCount; i++ {
+		rope.Points = append(rope.Points, start.Lerp(end, float32(i)/float32(segmentCount)))
+	}
+
+	rope.prevPoints = append(rope.prevPoints[:0], rope.Points...)
+	rope.SegmentLength = start.Distance(end) / float32(segmentCount)
+
+	if rope.Model != nil {
+		if len(rope.Model.Mesh.VertexPositions) != len(rope.Points)*rope.ringSize() {
+			rope.Model.Mesh = rope.generateMesh()
+		}
+		rope.updateMesh()
+	}
+
+}
+
+// Length returns the resting length of the Rope (the total length of its segments).
+func (rope *Rope) Length() float32 {
+	return rope.SegmentLength * float32(len(rope.Points)-1)
+}
+
+// Update simulates the Rope for the given delta time in seconds, and then updates its Model to match.
+func (rope *Rope) Update(dt float32) {
+
+	if len(rope.Points) == 0 {
+		return
+	}
+
+	last := len(rope.Points) - 1
+
+	gravity := rope.Gravity.Scale(dt * dt)
+	damping := 1 - math32.Clamp(rope.Damping, 0, 1)
+
+	rope.windZones = rope.windZones[:0]
+	if rope.WindInfluence != 0 && dt > 0 {
+		rope.windZones = sceneWindZones(rope, rope.windZones)
+	}
+
+	for i := range rope.Points {
+
+		if rope.pinned(i) {
+			continue
+		}
+
+		point := rope.Points[i]
+		velocity := point.Sub(rope.prevPoints[i]).Scale(damping)
+		rope.prevPoints[i] = point
+		rope.Points[i] = point.Add(velocity).Add(gravity)
+
+		// The wind drags the point's velocity towards its own
+		if len(rope.windZones) > 0 {
+			if wind := windAt(rope.windZones, point); !wind.IsZero() {
+				drag := wind.Sub(velocity.Divide(dt)).Scale(rope.WindInfluence * dt * dt)
+				rope.Points[i] = rope.Points[i].Add(drag)
+			}
+		}
+
+	}
+
+	if rope.StartAttachment != nil {
+		rope.Points[0] = rope.StartAttachment.WorldPosition()
+		rope.prevPoints[0] = rope.Points[0]
+	}
+
+	if rope.EndAttachment != nil {
+		rope.Points[last] = rope.EndAttachment.WorldPosition()
+		rope.prevPoints[last] = rope.Points[last]
+	}
+
+	iterations := rope.Iterations
+	if iterations < 1 {
+		iterations = 1
+	}
+
+	for iter := 0; iter < iterations; iter++ {
+
+		for i := 0; i < last; i++ {
+
+			a := rope.Points[i]
+			b := rope.Points[i+1]
+
+			diff := b.Sub(a)
+			dist := diff.Magnitude()
+
+			if dist == 0 {
+				continue
+			}
+
+			correction := diff.Scale((dist - rope.SegmentLength) / dist)
+
+			pinnedA := rope.pinned(i)
+			pinnedB := rope.pinned(i + 1)
+
+			if pinnedA && pinnedB {
+				continue
+			} else if pinnedA {
+				rope.Points[i+1] = b.Sub(correction)
+			} else if pinnedB {
+				rope.Points[i] = a.Add(correction)
+			} else {
+				rope.Points[i] = a.Add(correction.Scale(0.5))
+				rope.Points[i+1] = b.Sub(correction.Scale(0.5))
+			}
+
+		}
+
+		rope.collide()
+
+	}
+
+	rope.updateMesh()
+
+}
+
+// pinned returns if the point at the given index is attached to a Node, and so shouldn't be moved by the simulation.
+func (rope *Rope) pinned(index int) bool {
+	return (index == 0 && rope.StartAttachment != nil) || (index == len(rope.Points)-1 && rope.EndAttachment != nil)
+}
+
+// collide pushes the Rope's points out of its Colliders.
+func (rope *Rope) collide() {
+
+	if len(rope.Colliders) == 0 {
+		return
+	}
+
+	rope.collisionSphere.Radius = rope.Radius
+
+	for i := range rope.Points {
+
+		if rope.pinned(i) {
+			continue
+		}
+
+		rope.collisionSphere.SetLocalPositionVec(rope.Points[i])
+
+		for _, collider := range rope.Colliders {
+			if col := rope.collisionSphere.Collision(collider); col != nil {
+				rope.Points[i] = rope.Points[i].Add(col.AverageMTV())
+				rope.collisionSphere.SetLocalPositionVec(rope.Points[i])
+			}
+		}
+
+	}
+
+}
+
+// ringSize returns how many vertices make up each ring of the Rope's generated Mesh.
+func (rope *Rope) ringSize() int {
+	if rope.meshMode == RopeMeshRibbon {
+		return 2
+	}
+	// The first vertex in each ring is duplicated at the end so the UVs can wrap around the tube.
+	return rope.sides + 1
+}
+
+// generateMesh creates the Mesh for the Rope's Model, with a ring of vertices for each of the Rope's points.
+func (rope *Rope) generateMesh() *Mesh {
+
+	mesh := NewMesh("Rope")
+
+	ringSize := rope.ringSize()
+
+	verts := make([]VertexInfo, 0, len(rope.Points)*ringSize)
+
+	for i := range rope.Points {
+		v := float32(i) / float32(len(rope.Points)-1)
+		for c := 0; c < ringSize; c++ {
+			verts = append(verts, NewVertex(0, 0, 0, float32(c)/float32(ringSize-1), v))
+		}
+	}
+
+	mesh.AddVertices(verts...)
+
+	indices := make([]int, 0, (len(rope.Points)-1)*(ringSize-1)*6)
+
+	for i := 0; i < len(rope.Points)-1; i++ {
+		for c := 0; c < ringSize-1; c++ {
+			a := i*ringSize + c
+			b := a + 1
+			nextA := a + ringSize
+			nextB := nextA + 1
+			indices = append(indices, a, b, nextA, b, nextB, nextA)
+		}
+	}
+
+	mat := NewMaterial("Rope")
+	if rope.meshMode == RopeMeshRibbon {
+		mat.BackfaceCulling = false
+	}
+
+	mesh.AddMeshPart(mat, indices...)
+
+	return mesh
+
+}
+
+// updateMesh moves the vertices of the Rope's Model to follow the Rope's points.
+func (rope *Rope) updateMesh() {
+
+	mesh := rope.Model.Mesh
+	ringSize := rope.ringSize()
+	last := len(rope.Points) - 1
+
+	inverted := rope.Model.Transform().Inverted()
+	_, _, invertedRotation := inverted.Decompose()
+
+	var normal Vector3
+
+	for i, point := range rope.Points {
+
+		var tangent Vector3
+
+		if last > 0 {
+			prev, next := i-1, i+1
+			if prev < 0 {
+				prev = 0
+			}
+			if next > last {
+				next = last
+			}
+			tangent = rope.Points[next].Sub(rope.Points[prev]).Unit()
+		}
+
+		if tangent.IsZero() {
+			tangent = WorldUp
+		}
+
+		// The normal is carried along from point to point (parallel transport), so that the Rope doesn't twist as it bends.
+		if i == 0 || normal.IsZero() {
+			normal = WorldUp
+			if math32.Abs(tangent.Dot(normal)) > 0.99 {
+				normal = WorldRight
+			}
+		}
+
+		normal = normal.Sub(tangent.Scale(normal.Dot(tangent))).Unit()
+		binormal := tangent.Cross(normal)
+
+		start := i * ringSize
+
+		if rope.meshMode == RopeMeshRibbon {
+
+			side := binormal.Scale(rope.Radius)
+			mesh.VertexPositions[start] = inverted.MultVec(point.Sub(side))
+			mesh.VertexPositions[start+1] = inverted.MultVec(point.Add(side))
+			mesh.VertexNormals[start] = invertedRotation.MultVec(normal)
+			mesh.VertexNormals[start+1] = mesh.VertexNormals[start]
+
+		} else {
+
+			for c := 0; c < ringSize; c++ {
+				angle := float32(c) / float32(rope.sides) * math32.Pi * 2
+				dir := normal.Scale(math32.Cos(angle)).Add(binormal.Scale(math32.Sin(angle)))
+				mesh.VertexPositions[start+c] = inverted.MultVec(point.Add(dir.Scale(rope.Radius)))
+				mesh.VertexNormals[start+c] = invertedRotation.MultVec(dir)
+			}
+
+		}
+
+	}
+
+	for _, tri := range mesh.Triangles {
+		tri.RecalculateCenter()
+		tri.RecalculateNormal()
+	}
+
+	mesh.UpdateBounds()
+	rope.Model.onTransformUpdate()
+
+}
+
+// Type returns the NodeType for this object.
+func (rope *Rope) Type() NodeType {
+	return NodeTypeRope
+}
@@ -14,10 +14,18 @@ type Scene struct {
 	props         Properties
 	data          any
 	View3DCameras []*Camera // Any 3D view cameras that were exported from Blender
+	// Clock can be used to update the Scene's animated systems (i.e. AnimationPlayers, ParticleSystems, and TweenManagers) together,
+	// allowing you to pause or single-step them while debugging. See Clock for more information.
+	Clock *Clock
 
 	updateAutobatch     bool
 	autobatchDynamicMap map[*Material]*Model
 	autobatchStaticMap  map[*Material]*Model
+
+	idIndex  map[uint64][]INode // The Nodes in the Scene by their IDs (usually one each); see Scene.GetByID()
+	tagIndex map[uint32][]INode // The Nodes in the Scene by their tags; see Scene.NodesWithTag()
+
+	spatialHash *SpatialHash // The collision broadphase for the Scene's BoundingObjects; see Scene.SpatialHash()
 }
 
 // NewScene creates a new Scene by the name given.
@@ -27,6 +35,7 @@ func NewScene(name string) *Scene {
 		Name:                name,
 		Root:                NewNode("Root"),
 		World:               NewWorld("World"),
+		Clock:               NewClock(),
 		props:               NewProperties(),
 		autobatchDynamicMap: map[*Material]*Model{},
 		autobatchStaticMap:  map[*Material]*Model{},
@@ -46,12 +55,24 @@ func (scene *Scene) Clone() *Scene {
 
 	newScene.Root = scene.Root.Clone().(*Node)
 
+	// The clone is a copy of the same Scene, so its Nodes keep their IDs
+	copyNodeIDs(scene.Root, newScene.Root)
+
 	newScene.Root.scene = newScene
 	newScene.Root.cachedSceneRootNode = newScene.Root
 
 	newScene.World = scene.World // Here, we simply reference the same world; we don't clone it, since a single world can be shared across multiple Scenes
 	newScene.props = scene.props.Clone()
 
+	// The Updaters registered to the Scene's Clock belong to the original Scene, so only the Clock's settings are copied.
+	newScene.Clock.TimeScale = scene.Clock.TimeScale
+	newScene.Clock.StepDelta = scene.Clock.StepDelta
+	newScene.Clock.paused = scene.Clock.paused
+
+	if scene.spatialHash != nil {
+		newScene.SpatialHash().CellSize = scene.spatialHash.CellSize
+	}
+
 	newScene.updateAutobatch = true
 
 	// Update sectors after cloning the scene
@@ -71,10 +92,219 @@ func (scene *Scene) Clone() *Scene {
 
 	newScene.data = scene.data
 
+	// The cloned Nodes are put together before they become part of the new Scene, so they only enter its tree once it's complete
+	notifyTreeChange(newScene.Root, nil, newScene)
+
 	return newScene
 
 }
 
+// GetByID returns the Node in the Scene with the given ID (see Node.ID()), or nil if there's no such Node. The Scene builds a table of
+// its Nodes by ID the first time GetByID is called, and then keeps it up to date as Nodes enter and leave its tree, so looking up Nodes
+// is fast. If multiple Nodes in the Scene share an ID, the one that was indexed first is returned.
+func (scene *Scene) GetByID(id uint64) INode {
+
+	scene.buildIndexes()
+
+	if nodes := scene.idIndex[id]; len(nodes) > 0 {
+		return nodes[0]
+	}
+
+	return nil
+
+}
+
+// NodesWithTag returns the Nodes in the Scene that have the given tag (see Node.AddTag()). Like with Scene.GetByID(), the Scene keeps
+// a table of its Nodes by tag that's kept up to date as Nodes enter and leave its tree (or have their tags changed), so finding tagged
+// Nodes doesn't search the Scene. The returned slice is shared with the Scene, and so shouldn't be modified; it isn't changed by
+// Nodes leaving the Scene afterwards, though, so it's safe to remove the returned Nodes from the Scene while iterating through it.
+func (scene *Scene) NodesWithTag(tag string) []INode {
+
+	id, exists := lookupTag(tag)
+	if !exists {
+		return nil
+	}
+
+	scene.buildIndexes()
+
+	return scene.tagIndex[id]
+
+}
+
+// buildIndexes builds the Scene's tables of Nodes by ID and by tag if they haven't been built yet; afterwards, they're kept up to date
+// as Nodes enter and leave the Scene's tree, or have their IDs or tags changed.
+func (scene *Scene) buildIndexes() {
+
+	if scene.idIndex != nil {
+		return
+	}
+
+	scene.idIndex = map[uint64][]INode{}
+	scene.tagIndex = map[uint32][]INode{}
+
+	scene.indexNode(scene.Root)
+
+	scene.Root.SearchTree().ForEach(func(node INode) bool {
+		scene.indexNode(node)
+		return true
+	})
+
+}
+
+// clearIndexes clears the Scene's tables of Nodes, so that they're built again the next time they're needed.
+func (scene *Scene) clearIndexes() {
+	scene.idIndex = nil
+	scene.tagIndex = nil
+}
+
+// indexed returns if the given Node is in the Scene's tables of Nodes.
+func (scene *Scene) indexed(node INode) bool {
+
+	if scene.idIndex == nil {
+		return false
+	}
+
+	for _, n := range scene.idIndex[node.ID()] {
+		if n == node {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// indexNode adds the given Node to the Scene's tables of Nodes.
+func (scene *Scene) indexNode(node INode) {
+
+	id := node.ID()
+	scene.idIndex[id] = append(scene.idIndex[id], node)
+
+	for _, tag := range node.getNode().tags {
+		scene.indexTag(node, tag)
+	}
+
+}
+
+// unindexNode removes the given Node from the Scene's tables of Nodes.
+func (scene *Scene) unindexNode(node INode) {
+
+	id := node.ID()
+
+	if nodes := removeIndexedNode(scene.idIndex[id], node); len(nodes) > 0 {
+		scene.idIndex[id] = nodes
+	} else {
+		delete(scene.idIndex, id)
+	}
+
+	for _, tag := range node.getNode().tags {
+		scene.unindexTag(node, tag)
+	}
+
+}
+
+// indexTag adds the given Node to the Scene's table of Nodes with the given tag.
+func (scene *Scene) indexTag(node INode, tag uint32) {
+	scene.tagIndex[tag] = append(scene.tagIndex[tag], node)
+}
+
+// unindexTag removes the given Node from the Scene's table of Nodes with the given tag.
+func (scene *Scene) unindexTag(node INode, tag uint32) {
+	if nodes := removeIndexedNode(scene.tagIndex[tag], node); len(nodes) > 0 {
+		scene.tagIndex[tag] = nodes
+	} else {
+		delete(scene.tagIndex, tag)
+	}
+}
+
+// removeIndexedNode returns a copy of the given slice of Nodes without the Node provided. A copy is made (rather than removing the Node
+// in place) as the slices returned from Scene.NodesWithTag() are shared.
+func removeIndexedNode(nodes []INode, node INode) []INode {
+	if len(nodes) == 1 && nodes[0] == node {
+		return nil
+	}
+	out := make([]INode, 0, len(nodes))
+	for _, n := range nodes {
+		if n != node {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// addToIndexes adds the given Node and its tree to the Scene's tables of Nodes (and its BoundingObjects to the Scene's SpatialHash) as they
+// enter the Scene's tree.
+func (scene *Scene) addToIndexes(node INode) {
+
+	hash := scene.spatialHash
+	if hash != nil && !hash.synced {
+		hash = nil
+	}
+
+	if scene.idIndex == nil && hash == nil {
+		return
+	}
+
+	add := func(n INode) bool {
+		if scene.idIndex != nil && !scene.indexed(n) {
+			scene.indexNode(n)
+		}
+		if obj, ok := n.(IBoundingObject); ok && hash != nil {
+			hash.Add(obj)
+		}
+		return true
+	}
+
+	add(node)
+	node.SearchTree().ForEach(add)
+
+}
+
+// removeFromIndexes removes the given Node and its tree from the Scene's tables of Nodes (and its BoundingObjects from the Scene's
+// SpatialHash) as they leave the Scene's tree.
+func (scene *Scene) removeFromIndexes(node INode) {
+
+	hash := scene.spatialHash
+	if hash != nil && !hash.synced {
+		hash = nil
+	}
+
+	if scene.idIndex == nil && hash == nil {
+		return
+	}
+
+	var removed []IBoundingObject
+
+	remove := func(n INode) bool {
+		if scene.idIndex != nil && scene.indexed(n) {
+			scene.unindexNode(n)
+		}
+		if obj, ok := n.(IBoundingObject); ok && hash != nil {
+			removed = append(removed, obj)
+		}
+		return true
+	}
+
+	remove(node)
+	node.SearchTree().ForEach(remove)
+
+	if len(removed) > 0 {
+		hash.Remove(removed...)
+	}
+
+}
+
+// SpatialHash returns the Scene's collision broadphase, a SpatialHash containing all of the BoundingObjects in the Scene; BoundingObjects
+// are added to and removed from it automatically as they enter and leave the Scene's tree. Use SpatialHash.Near() to only collision test
+// against the BoundingObjects near a moving object, and call SpatialHash.Update() once per frame after moving objects.
+// The SpatialHash is created the first time this function is called.
+func (scene *Scene) SpatialHash() *SpatialHash {
+	if scene.spatialHash == nil {
+		scene.spatialHash = newSceneSpatialHash(scene)
+	}
+	return scene.spatialHash
+}
+
 // Data returns the Scene's user-customizeable data.
 func (scene *Scene) Data() any {
 	return scene.data
@@ -183,6 +413,33 @@ func (scene *Scene) Get(nodePath string) INode {
 	return scene.Root.Get(nodePath)
 }
 
+// ParticleSystems returns the ParticleSystems of the Models in the Scene (see Model.ParticleSystem()), which is useful for updating
+// ParticleSystems loaded from GLTF files once per frame.
+func (scene *Scene) ParticleSystems() []*ParticleSystem {
+
+	systems := []*ParticleSystem{}
+
+	for _, model := range scene.Root.SearchTree().Models() {
+		if model.particleSystem != nil {
+			systems = append(systems, model.particleSystem)
+		}
+	}
+
+	return systems
+
+}
+
+// WindZones returns the WindZones in the Scene.
+func (scene *Scene) WindZones() []*WindZone {
+	return sceneWindZones(scene.Root, nil)
+}
+
+// WindAt returns the combined velocity of the wind blowing from all of the WindZones in the Scene at the given world position,
+// in world units per second.
+func (scene *Scene) WindAt(position Vector3) Vector3 {
+	return windAt(scene.WindZones(), position)
+}
+
 // FindNode searches through a Node's tree for the node by name exactly. This is mostly syntactic sugar for
 // Node.SearchTree().ByName(nodeName).First().
 func (scene *Scene) FindNode(nodeName string) INode {
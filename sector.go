@@ -19,7 +19,10 @@ type Sector struct {
 	AABB                *BoundingAABB       // The AABB used to search for neighbors if the SectorDetectionType is set to SectorDetectionTypeAABB
 	Neighbors           Set[*Sector]        // The Sector's neighbors
 	SectorDetectionType SectorDetectionType // How the Sector is detected
-	rendering           bool                // If the Sector was rendering in the last Camera.Render____() call.
+	// ExitMargin is how far outside of the Sector's AABB an object with SectorTypeObject has to move before it's no longer considered
+	// to be in the Sector. This prevents objects from rapidly switching between Sectors when they're on the border. Defaults to 0.1.
+	ExitMargin float32
+	rendering  bool // If the Sector was rendering in the last Camera.Render____() call.
 }
 
 // NewSector creates a new Sector for the provided Model.
@@ -31,9 +34,10 @@ func NewSector(model *Model) *Sector {
 	sectorAABB.SetLocalPositionVec(model.WorldPosition().Add(mesh.Dimensions.Center()))
 
 	return &Sector{
-		Model:     model,
-		AABB:      sectorAABB,
-		Neighbors: newSet[*Sector](),
+		Model:      model,
+		AABB:       sectorAABB,
+		Neighbors:  newSet[*Sector](),
+		ExitMargin: 0.1,
 	}
 
 }
@@ -42,9 +46,10 @@ func NewSector(model *Model) *Sector {
 func (sector *Sector) Clone() *Sector {
 
 	newSector := &Sector{
-		Model:     sector.Model,
-		AABB:      sector.AABB.Clone().(*BoundingAABB),
-		Neighbors: make(Set[*Sector], len(sector.Neighbors)),
+		Model:      sector.Model,
+		AABB:       sector.AABB.Clone().(*BoundingAABB),
+		Neighbors:  make(Set[*Sector], len(sector.Neighbors)),
+		ExitMargin: sector.ExitMargin,
 	}
 	for n := range sector.Neighbors {
 		newSector.Neighbors[n] = struct{}{}
@@ -142,3 +147,21 @@ func (sector *Sector) NeighborsWithinRange(searchRange int) Set[*Sector] {
 	return out
 
 }
+
+// Contains returns if the provided Node is currently assigned to the Sector (i.e. if node.Sector() returns this Sector).
+func (sector *Sector) Contains(node INode) bool {
+	return node.Sector() == sector
+}
+
+// pointInside returns if the given point is inside the Sector's AABB, expanded by the given margin on all sides.
+func (sector *Sector) pointInside(point Vector3, margin float32) bool {
+
+	position := sector.AABB.WorldPosition()
+	min := sector.AABB.Dimensions.Min.Add(position)
+	max := sector.AABB.Dimensions.Max.Add(position)
+
+	return point.X >= min.X-margin && point.X <= max.X+margin &&
+		point.Y >= min.Y-margin && point.Y <= max.Y+margin &&
+		point.Z >= min.Z-margin && point.Z <= max.Z+margin
+
+}
@@ -0,0 +1,334 @@
+package tetra3d
+
+import (
+	"sort"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// ShapeCastHit represents the result of a shape cast (see SphereCast() and CapsuleCast()), where a shape moving along a line struck
+// a BoundingObject.
+type ShapeCastHit struct {
+	Object       IBoundingObject // Object is the BoundingObject struck by the shape.
+	Position     Vector3         // Position is the world position of the center of the shape when it first touches the struck object.
+	ContactPoint Vector3         // ContactPoint is the world position of the point where the shape touches the struck object.
+	Normal       Vector3         // Normal is the normal of the struck object's surface at the contact point.
+	// Fraction is how far the shape traveled along the line before striking the object, ranging from 0 (at the start) to 1 (at the end).
+	Fraction float32
+	// What triangle the shape struck - note that this is only set to a non-nil value for shape casts against BoundingTriangles objects.
+	Triangle *Triangle
+}
+
+// Slope returns the slope of the ShapeCastHit's normal, in radians. This ranges from 0 (straight up) to pi (straight down).
+func (hit ShapeCastHit) Slope() float32 {
+	return WorldUp.Angle(hit.Normal)
+}
+
+// ShapeCastOptions controls how a shape cast (SphereCast() or CapsuleCast()) evaluates.
+type ShapeCastOptions struct {
+	From, To Vector3 // From and To are the starting and ending world positions of the center of the cast shape.
+
+	Radius float32 // Radius is the radius of the cast sphere or capsule.
+	// Height is the total height of the cast capsule; capsules are cast upright along the Y axis, like with CollisionTestCapsule().
+	// Height is ignored for sphere casts.
+	Height float32
+
+	// TestAgainst is used to specify a selection of BoundingObjects to test against - this can be either a NodeFilter or a NodeCollection (a slice of Nodes).
+	TestAgainst NodeIterator
+
+	// OnHit is a callback called for each hit the cast shape returns, sorted by how far the shape traveled before striking each object.
+	// OnHit is only called once for each object, apart from BoundingTriangles, as a single shape cast can hit multiple triangles of a BoundingTriangles mesh.
+	// (A BoundingHeightfield is only hit once, at the first point the shape touches its surface.)
+	// Objects that the shape already overlaps at its starting position aren't hit; use a collision test to check for those.
+	// index is the index of the hit out of the maximum number of hits found by the function (count).
+	// The returned boolean indicates whether to keep iterating through all found hits, or to stop after the current one.
+	OnHit func(hit ShapeCastHit, index, count int) bool
+}
+
+// internalShapeCast is used to hold the hits of a shape cast, to avoid reallocating a slice for each cast.
+var internalShapeCast = []ShapeCastHit{}
+
+// shapeCastAABB is used to check which triangles of a BoundingTriangles object a cast shape could strike along its way.
+var shapeCastAABB = NewBoundingAABB("shape cast aabb", 1, 1, 1)
+
+// SphereCast moves a sphere with the given radius from the From position to the To position in the given ShapeCastOptions, testing for
+// the BoundingObjects it strikes along the way. Unlike a collision test at the end position, a sphere cast can't pass through thin objects
+// (like walls) that lie between the start and end positions, which makes this useful for fast-moving objects or character movement.
+// SphereCast returns a boolean indicating if any objects were struck with the given ShapeCastOptions options set.
+func SphereCast(options ShapeCastOptions) bool {
+	return shapeCast(sphereShape{options.From, options.Radius}, options)
+}
+
+// CapsuleCast moves an upright capsule with the given radius and height from the From position to the To position in the given
+// ShapeCastOptions, testing for the BoundingObjects it strikes along the way. Unlike a collision test at the end position, a capsule cast
+// can't pass through thin objects (like walls) that lie between the start and end positions, which makes this useful for character movement.
+// CapsuleCast returns a boolean indicating if any objects were struck with the given ShapeCastOptions options set.
+func CapsuleCast(options ShapeCastOptions) bool {
+	height := math32.Max(options.Height, options.Radius*2)
+	line := WorldUp.Scale(height/2 - options.Radius)
+	return shapeCast(capsuleShape{options.From.Sub(line), options.From.Add(line), options.Radius}, options)
+}
+
+// shapeCast casts the given convex shape (positioned at options.From) from options.From to options.To.
+func shapeCast(shape convexShape, options ShapeCastOptions) bool {
+
+	internalShapeCast = internalShapeCast[:0]
+
+	motion := options.To.Sub(options.From)
+
+	setShapeCastAABB(shape, motion)
+
+	quitEarly := false
+
+	options.TestAgainst.ForEach(func(node INode) bool {
+
+		node.Transform() // Make sure the transform is updated before the test
+
+		if triangles, ok := node.(*BoundingTriangles); ok {
+			internalShapeCast = append(internalShapeCast, shapeCastTriangles(shape, motion, triangles)...)
+		} else if hf, ok := node.(*BoundingHeightfield); ok {
+			if hit, ok := shapeCastHeightfield(shape, motion, hf); ok {
+				internalShapeCast = append(internalShapeCast, hit)
+			}
+		} else if bounds, ok := node.(IBoundingObject); ok {
+
+			if other, ok := convexShapeOf(bounds); ok {
+
+				if fraction, normal, ok := gjkCast(shape, other, motion); ok {
+					internalShapeCast = append(internalShapeCast, newShapeCastHit(shape, motion, bounds, fraction, normal))
+				}
+
+			}
+
+		}
+
+		// If we're not paying attention to the hits specifically, then we can bail after any valid hit.
+		if options.OnHit == nil && len(internalShapeCast) > 0 {
+			quitEarly = true
+			return false
+		}
+
+		return true
+
+	})
+
+	if quitEarly {
+		return true
+	}
+
+	if options.OnHit != nil {
+
+		sort.SliceStable(internalShapeCast, func(i, j int) bool {
+			return internalShapeCast[i].Fraction < internalShapeCast[j].Fraction
+		})
+
+		for i, hit := range internalShapeCast {
+			if !options.OnHit(hit, i, len(internalShapeCast)) {
+				break
+			}
+		}
+
+	}
+
+	return len(internalShapeCast) > 0
+
+}
+
+// convexShapeOf returns the given BoundingObject as a convexShape in world space, if it's convex.
+func convexShapeOf(bounds IBoundingObject) (convexShape, bool) {
+
+	switch b := bounds.(type) {
+
+	case *BoundingSphere:
+		return b.shape(), true
+
+	case *BoundingCapsule:
+		return b.shape(), true
+
+	case *BoundingAABB:
+		return aabbOrientedBox(b), true
+
+	case *BoundingOBB:
+		return b.orientedBox(), true
+
+	case *BoundingConvexHull:
+		if len(b.Vertices) > 0 {
+			return b.shape(), true
+		}
+
+	}
+
+	return nil, false
+
+}
+
+// setShapeCastAABB sets up shapeCastAABB to enclose the whole path of the given shape moving along the given motion vector.
+func setShapeCastAABB(shape convexShape, motion Vector3) {
+
+	size := Vector3{
+		shape.support(WorldRight).X - shape.support(WorldLeft).X,
+		shape.support(WorldUp).Y - shape.support(WorldDown).Y,
+		shape.support(WorldBackward).Z - shape.support(WorldForward).Z,
+	}
+
+	shapeCastAABB.SetLocalPositionVec(shape.centroid().Add(motion.Scale(0.5)))
+	shapeCastAABB.SetDimensions(
+		math32.Abs(motion.X)+size.X,
+		math32.Abs(motion.Y)+size.Y,
+		math32.Abs(motion.Z)+size.Z,
+	)
+
+}
+
+// newShapeCastHit returns a ShapeCastHit for the given shape striking the given object after moving by the given fraction of its motion.
+func newShapeCastHit(shape convexShape, motion Vector3, object IBoundingObject, fraction float32, normal Vector3) ShapeCastHit {
+	offset := motion.Scale(fraction)
+	return ShapeCastHit{
+		Object:       object,
+		Position:     shape.centroid().Add(offset),
+		ContactPoint: shape.support(normal.Invert()).Add(offset),
+		Normal:       normal,
+		Fraction:     fraction,
+	}
+}
+
+func shapeCastTriangles(shape convexShape, motion Vector3, triangles *BoundingTriangles) []ShapeCastHit {
+
+	// If the shape's path doesn't intersect the triangle's bounding AABB, it couldn't possibly strike any of the triangles, so we're good
+	if !shapeCastAABB.Colliding(triangles.BoundingAABB) {
+		return nil
+	}
+
+	transform := triangles.Transform()
+
+	hits := []ShapeCastHit{}
+
+	for triID := range triangles.Broadphase.TrianglesFromBoundingObject(shapeCastAABB) {
+
+		tri := triangles.Mesh.Triangles[triID]
+
+		triShape := pointsShape{
+			transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[0]]),
+			transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[1]]),
+			transform.MultVec(triangles.Mesh.VertexPositions[tri.VertexIndices[2]]),
+		}
+
+		if fraction, normal, ok := gjkCast(shape, triShape, motion); ok {
+			hit := newShapeCastHit(shape, motion, triangles, fraction, normal)
+			hit.Triangle = tri
+			hits = append(hits, hit)
+		}
+
+	}
+
+	return hits
+
+}
+
+// shapeCastHeightfield casts the given shape along the given motion vector against the given BoundingHeightfield, returning the first hit.
+func shapeCastHeightfield(shape convexShape, motion Vector3, hf *BoundingHeightfield) (ShapeCastHit, bool) {
+
+	var hit ShapeCastHit
+	found := false
+
+	hf.forEachTriangle(shapeDimensions(aabbOrientedBox(shapeCastAABB)), false, func(tri pointsShape, normal Vector3) bool {
+		if fraction, normal, ok := gjkCast(shape, tri, motion); ok && (!found || fraction < hit.Fraction) {
+			hit = newShapeCastHit(shape, motion, hf, fraction, normal)
+			found = true
+		}
+		return true
+	})
+
+	return hit, found
+
+}
+
+// collisionSweep is used for continuous collision detection in collision tests (see CollisionTestSettings.Continuous); it represents
+// a convex BoundingObject swept from its previous position to its current one.
+type collisionSweep struct {
+	shape  convexShape // shape is the BoundingObject's shape at its previous position.
+	motion Vector3
+	end    Vector3 // end is the BoundingObject's current position.
+}
+
+// newCollisionSweep returns a collisionSweep for the given BoundingObject moving from the given world position to its current one, or nil if
+// the BoundingObject isn't convex or hasn't moved.
+func newCollisionSweep(bounds IBoundingObject, from Vector3) *collisionSweep {
+
+	bounds.Transform() // Make sure the transform is updated before the test
+
+	shape, ok := convexShapeOf(bounds)
+	if !ok {
+		return nil
+	}
+
+	end := bounds.WorldPosition()
+	motion := end.Sub(from)
+
+	if motion.IsZero() {
+		return nil
+	}
+
+	sweep := &collisionSweep{
+		shape:  offsetShape{shape, motion.Invert()},
+		motion: motion,
+		end:    end,
+	}
+
+	setShapeCastAABB(sweep.shape, motion)
+
+	return sweep
+
+}
+
+// collision returns a Collision if the swept shape struck the other BoundingObject along its way, or nil otherwise.
+func (sweep *collisionSweep) collision(other IBoundingObject) *Collision {
+
+	other.Transform() // Make sure the transform is updated before the test
+
+	var hit ShapeCastHit
+	found := false
+
+	if triangles, ok := other.(*BoundingTriangles); ok {
+
+		for _, h := range shapeCastTriangles(sweep.shape, sweep.motion, triangles) {
+			if !found || h.Fraction < hit.Fraction {
+				hit = h
+				found = true
+			}
+		}
+
+	} else if hf, ok := other.(*BoundingHeightfield); ok {
+
+		hit, found = shapeCastHeightfield(sweep.shape, sweep.motion, hf)
+
+	} else if otherShape, ok := convexShapeOf(other); ok {
+
+		if fraction, normal, ok := gjkCast(sweep.shape, otherShape, sweep.motion); ok {
+			hit = newShapeCastHit(sweep.shape, sweep.motion, other, fraction, normal)
+			found = true
+		}
+
+	}
+
+	if !found {
+		return nil
+	}
+
+	// Push the object back out along the struck surface's normal to where it first made contact; if it didn't pass the surface (i.e. it
+	// grazed an edge), we move it back along its path instead.
+	offset := hit.Position.Sub(sweep.end)
+	mtv := hit.Normal.Scale(offset.Dot(hit.Normal))
+	if offset.Dot(hit.Normal) <= 0 {
+		mtv = offset
+	}
+
+	return newCollision(other).add(&Intersection{
+		StartingPoint: sweep.end,
+		ContactPoint:  hit.ContactPoint,
+		MTV:           mtv,
+		Normal:        hit.Normal,
+		Triangle:      hit.Triangle,
+	})
+
+}
@@ -0,0 +1,486 @@
+package tetra3d
+
+import (
+	"container/heap"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// simplifyConstraintWeight is how strongly Mesh.Simplify() keeps the edges of a Mesh's surface and the seams between its vertices in place.
+const simplifyConstraintWeight = 1000
+
+// Simplify reduces the number of triangles in the Mesh to roughly the given ratio of its current triangle count (i.e. 0.5 to halve it) by
+// collapsing its edges, using quadric error metrics to pick the edges whose removal changes the Mesh's shape the least. This is useful for
+// generating lower-detail versions of high-poly Meshes at load time, like LODs or collision proxies (i.e. for BoundingTriangles); to keep the
+// original Mesh, simplify a clone of it.
+// Each MeshPart is simplified separately. The open edges of the Mesh (including the borders between MeshParts) and the seams between vertices
+// with differing UVs, normals, or colors are kept in place as much as possible. Edges are only collapsed onto one of their vertices or their midpoint,
+// with the remaining vertices keeping their UVs, normals, colors, and bone weights as-is. Collapses that would fold triangles over or pinch the
+// surface are skipped, so a Mesh may keep more triangles than the ratio given.
+// Note that as the Mesh's vertex buffers are rebuilt, the vertex indices of the Mesh change, and so Simplify() should be called before any
+// vertex selections are made.
+func (mesh *Mesh) Simplify(targetTriangleRatio float32) {
+
+	if targetTriangleRatio >= 1 || len(mesh.Triangles) == 0 {
+		return
+	}
+
+	if targetTriangleRatio < 0 {
+		targetTriangleRatio = 0
+	}
+
+	parts := mesh.partData()
+
+	for _, data := range parts {
+		if len(data.indices) > 0 {
+			target := int(math32.Ceil(float32(len(data.indices)/3) * targetTriangleRatio))
+			newMeshSimplifier(data).simplify(math32.Max(target, 1))
+		}
+	}
+
+	mesh.rebuildParts(parts)
+
+}
+
+// simplifyQuadric is a symmetric 4x4 matrix (stored as its upper triangle) that measures the squared distance of a point from a set of planes.
+type simplifyQuadric [10]float64
+
+// newSimplifyQuadric creates a quadric for the plane with the given normal and distance (so that normal.Dot(p) + d = 0 for points on the plane).
+func newSimplifyQuadric(normal Vector3, d, weight float64) simplifyQuadric {
+	a, b, c := float64(normal.X), float64(normal.Y), float64(normal.Z)
+	return simplifyQuadric{
+		a * a * weight, a * b * weight, a * c * weight, a * d * weight,
+		b * b * weight, b * c * weight, b * d * weight,
+		c * c * weight, c * d * weight,
+		d * d * weight,
+	}
+}
+
+func (q *simplifyQuadric) add(other simplifyQuadric) {
+	for i := range q {
+		q[i] += other[i]
+	}
+}
+
+// error returns the weighted sum of the squared distances of the given point from the quadric's planes.
+func (q *simplifyQuadric) error(p Vector3) float64 {
+	x, y, z := float64(p.X), float64(p.Y), float64(p.Z)
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z +
+		q[9]
+}
+
+// simplifyCollapse is a candidate collapse of the edge between two positions, moving position a to the given position and merging
+// position b into it.
+type simplifyCollapse struct {
+	cost               float64
+	a, b               int
+	versionA, versionB int
+	position           Vector3
+}
+
+type simplifyHeap []simplifyCollapse
+
+func (h simplifyHeap) Len() int           { return len(h) }
+func (h simplifyHeap) Less(i, j int) bool { return h[i].cost < h[j].cost }
+func (h simplifyHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *simplifyHeap) Push(x any)        { *h = append(*h, x.(simplifyCollapse)) }
+func (h *simplifyHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// meshSimplifier simplifies a MeshPart's triangles. As a Mesh's vertices are split wherever their UVs, normals, or colors differ, edges are
+// collapsed between positions (each made up of all vertices sharing that position), rather than between vertices.
+type meshSimplifier struct {
+	data *meshPartData
+
+	positionOf []int // The index of the position of each vertex
+	positions  []Vector3
+	quadrics   []simplifyQuadric
+	versions   []int  // Incremented whenever a position changes, to recognize outdated collapses in the queue
+	removed    []bool // Whether each position has been merged into another one
+
+	triangles    [][3]int // Vertex indices of each triangle
+	alive        []bool
+	aliveCount   int
+	positionTris [][]int // The triangles around each position; this can include dead triangles
+
+	queue simplifyHeap
+}
+
+func newMeshSimplifier(data *meshPartData) *meshSimplifier {
+
+	s := &meshSimplifier{
+		data:       data,
+		positionOf: make([]int, len(data.verts)),
+	}
+
+	// Weld the vertices by position
+
+	positionIndices := map[Vector3]int{}
+
+	for i, v := range data.verts {
+		pos := Vector3{v.X, v.Y, v.Z}
+		index, exists := positionIndices[pos]
+		if !exists {
+			index = len(s.positions)
+			positionIndices[pos] = index
+			s.positions = append(s.positions, pos)
+		}
+		s.positionOf[i] = index
+	}
+
+	s.quadrics = make([]simplifyQuadric, len(s.positions))
+	s.versions = make([]int, len(s.positions))
+	s.removed = make([]bool, len(s.positions))
+	s.positionTris = make([][]int, len(s.positions))
+
+	type edgeInfo struct {
+		count    int
+		vertices [2]int // The vertices of the first triangle found using the edge, ordered by their positions
+		tri      int
+		seam     bool
+	}
+
+	edges := map[[2]int]*edgeInfo{}
+	edgeOrder := [][2]int{}
+
+	for i := 0; i < len(data.indices); i += 3 {
+
+		tri := [3]int{data.indices[i], data.indices[i+1], data.indices[i+2]}
+		triIndex := len(s.triangles)
+
+		s.triangles = append(s.triangles, tri)
+
+		p0, p1, p2 := s.positionOf[tri[0]], s.positionOf[tri[1]], s.positionOf[tri[2]]
+
+		// Triangles that are already degenerate are dropped
+		if p0 == p1 || p1 == p2 || p0 == p2 {
+			s.alive = append(s.alive, false)
+			continue
+		}
+
+		s.alive = append(s.alive, true)
+		s.aliveCount++
+
+		normal := s.triangleNormal(triIndex, -1, Vector3{})
+		area := normal.Magnitude()
+
+		if area > 0 {
+			normal = normal.Scale(1 / area)
+			q := newSimplifyQuadric(normal, -float64(normal.Dot(s.positions[p0])), float64(area)/2)
+			for _, p := range [3]int{p0, p1, p2} {
+				s.quadrics[p].add(q)
+			}
+		}
+
+		for c := 0; c < 3; c++ {
+
+			s.positionTris[s.positionOf[tri[c]]] = append(s.positionTris[s.positionOf[tri[c]]], triIndex)
+
+			va, vb := tri[c], tri[(c+1)%3]
+			if s.positionOf[va] > s.positionOf[vb] {
+				va, vb = vb, va
+			}
+
+			key := [2]int{s.positionOf[va], s.positionOf[vb]}
+
+			if edge, exists := edges[key]; exists {
+				edge.count++
+				if edge.vertices != [2]int{va, vb} {
+					edge.seam = true
+				}
+			} else {
+				edges[key] = &edgeInfo{count: 1, vertices: [2]int{va, vb}, tri: triIndex}
+				edgeOrder = append(edgeOrder, key)
+			}
+
+		}
+
+	}
+
+	// Open edges and seams are kept in place by planes running along them, perpendicular to their triangles
+
+	for _, key := range edgeOrder {
+
+		edge := edges[key]
+
+		if edge.count == 2 && !edge.seam {
+			continue
+		}
+
+		pa, pb := s.positions[key[0]], s.positions[key[1]]
+		dir := pb.Sub(pa)
+
+		normal := dir.Cross(s.triangleNormal(edge.tri, -1, Vector3{})).Unit()
+		if normal.IsZero() {
+			continue
+		}
+
+		q := newSimplifyQuadric(normal, -float64(normal.Dot(pa)), float64(dir.MagnitudeSquared())*simplifyConstraintWeight)
+		s.quadrics[key[0]].add(q)
+		s.quadrics[key[1]].add(q)
+
+	}
+
+	for _, key := range edgeOrder {
+		s.queue = append(s.queue, s.collapseFor(key[0], key[1]))
+	}
+
+	heap.Init(&s.queue)
+
+	return s
+
+}
+
+// triangleNormal returns the (unnormalized) normal of the triangle, as though the vertices at the given position were moved to the given
+// new position (or as-is, if movedPosition is -1).
+func (s *meshSimplifier) triangleNormal(tri int, movedPosition int, newPosition Vector3) Vector3 {
+
+	var corners [3]Vector3
+
+	for c, v := range s.triangles[tri] {
+		if p := s.positionOf[v]; p == movedPosition {
+			corners[c] = newPosition
+		} else {
+			corners[c] = s.positions[p]
+		}
+	}
+
+	return corners[1].Sub(corners[0]).Cross(corners[2].Sub(corners[0]))
+
+}
+
+// collapseFor returns the cheapest collapse of the edge between the given positions.
+func (s *meshSimplifier) collapseFor(a, b int) simplifyCollapse {
+
+	q := s.quadrics[a]
+	q.add(s.quadrics[b])
+
+	pa, pb := s.positions[a], s.positions[b]
+
+	collapse := simplifyCollapse{cost: q.error(pa), a: a, b: b, position: pa}
+
+	if cost := q.error(pb); cost < collapse.cost {
+		collapse = simplifyCollapse{cost: cost, a: b, b: a, position: pb}
+	}
+
+	mid := pa.Add(pb).Scale(0.5)
+	if cost := q.error(mid); cost < collapse.cost {
+		collapse = simplifyCollapse{cost: cost, a: a, b: b, position: mid}
+	}
+
+	collapse.versionA = s.versions[collapse.a]
+	collapse.versionB = s.versions[collapse.b]
+
+	return collapse
+
+}
+
+// neighbors returns the positions connected to the given position by the edges of living triangles.
+func (s *meshSimplifier) neighbors(p int) Set[int] {
+
+	neighbors := Set[int]{}
+
+	for _, t := range s.positionTris[p] {
+		if s.alive[t] {
+			for _, v := range s.triangles[t] {
+				if n := s.positionOf[v]; n != p {
+					neighbors.Add(n)
+				}
+			}
+		}
+	}
+
+	return neighbors
+
+}
+
+// sharesTriangle returns if the living triangle contains both of the given positions.
+func (s *meshSimplifier) sharesTriangle(t, a, b int) bool {
+	hasA, hasB := false, false
+	for _, v := range s.triangles[t] {
+		hasA = hasA || s.positionOf[v] == a
+		hasB = hasB || s.positionOf[v] == b
+	}
+	return hasA && hasB
+}
+
+// canCollapse returns if collapsing the edge wouldn't fold any triangles over or pinch the surface together.
+func (s *meshSimplifier) canCollapse(collapse simplifyCollapse) bool {
+
+	// The positions connected to both ends of the edge should only be the ones opposite the edge in the triangles being removed;
+	// otherwise, the collapse would pinch the surface.
+	shared := 0
+	na := s.neighbors(collapse.a)
+	for n := range s.neighbors(collapse.b) {
+		if na.Contains(n) {
+			shared++
+		}
+	}
+
+	edgeTris := 0
+
+	for _, p := range [2]int{collapse.a, collapse.b} {
+
+		for _, t := range s.positionTris[p] {
+
+			if !s.alive[t] {
+				continue
+			}
+
+			if s.sharesTriangle(t, collapse.a, collapse.b) {
+				if p == collapse.a {
+					edgeTris++
+				}
+				continue
+			}
+
+			before := s.triangleNormal(t, -1, Vector3{})
+			after := s.triangleNormal(t, p, collapse.position)
+
+			if after.IsZero() || before.Unit().Dot(after.Unit()) < 0.2 {
+				return false
+			}
+
+		}
+
+	}
+
+	return shared <= edgeTris
+
+}
+
+// collapse merges position b of the collapse into position a, moving a to the collapse's position.
+func (s *meshSimplifier) collapse(collapse simplifyCollapse) {
+
+	a, b := collapse.a, collapse.b
+
+	// Vertices at position b are replaced by the vertices at position a they shared a removed triangle with, so that the triangles
+	// around them keep their UVs and other attributes. Vertices that can't be replaced are moved to position a instead.
+	replacements := map[int]int{}
+
+	for _, t := range s.positionTris[b] {
+
+		if !s.alive[t] || !s.sharesTriangle(t, a, b) {
+			continue
+		}
+
+		var va, vb int
+		for _, v := range s.triangles[t] {
+			if s.positionOf[v] == a {
+				va = v
+			} else if s.positionOf[v] == b {
+				vb = v
+			}
+		}
+
+		if _, exists := replacements[vb]; !exists {
+			replacements[vb] = va
+		}
+
+		s.alive[t] = false
+		s.aliveCount--
+
+	}
+
+	trisA := make([]int, 0, len(s.positionTris[a])+len(s.positionTris[b]))
+
+	for _, t := range s.positionTris[a] {
+		if s.alive[t] {
+			trisA = append(trisA, t)
+		}
+	}
+
+	for _, t := range s.positionTris[b] {
+
+		if !s.alive[t] {
+			continue
+		}
+
+		for c, v := range s.triangles[t] {
+			if s.positionOf[v] == b {
+				if replacement, exists := replacements[v]; exists {
+					s.triangles[t][c] = replacement
+				} else {
+					s.positionOf[v] = a
+				}
+			}
+		}
+
+		trisA = append(trisA, t)
+
+	}
+
+	s.positionTris[a] = trisA
+	s.positionTris[b] = nil
+
+	s.positions[a] = collapse.position
+	s.quadrics[a].add(s.quadrics[b])
+	s.removed[b] = true
+	s.versions[a]++
+
+	for n := range s.neighbors(a) {
+		heap.Push(&s.queue, s.collapseFor(a, n))
+	}
+
+}
+
+// simplify collapses edges until the number of triangles drops to the target count (or no more edges can be collapsed), and then writes
+// the results back to the meshPartData.
+func (s *meshSimplifier) simplify(targetTriangleCount int) {
+
+	for s.aliveCount > targetTriangleCount && s.queue.Len() > 0 {
+
+		collapse := heap.Pop(&s.queue).(simplifyCollapse)
+
+		if s.removed[collapse.a] || s.removed[collapse.b] || s.versions[collapse.a] != collapse.versionA || s.versions[collapse.b] != collapse.versionB {
+			continue
+		}
+
+		if s.canCollapse(collapse) {
+			s.collapse(collapse)
+		}
+
+	}
+
+	// Only the vertices still in use are kept, moved to their positions' final locations
+
+	newIndices := make([]int, len(s.data.verts))
+	for i := range newIndices {
+		newIndices[i] = -1
+	}
+
+	verts := make([]VertexInfo, 0, len(s.data.verts))
+	indices := make([]int, 0, s.aliveCount*3)
+
+	for t, tri := range s.triangles {
+
+		if !s.alive[t] {
+			continue
+		}
+
+		for _, v := range tri {
+
+			if newIndices[v] < 0 {
+				newIndices[v] = len(verts)
+				vert := s.data.verts[v]
+				pos := s.positions[s.positionOf[v]]
+				vert.X, vert.Y, vert.Z = pos.X, pos.Y, pos.Z
+				verts = append(verts, vert)
+			}
+
+			indices = append(indices, newIndices[v])
+
+		}
+
+	}
+
+	s.data.verts = verts
+	s.data.indices = indices
+
+}
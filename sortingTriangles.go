@@ -150,9 +150,3 @@ func (s *sortingTriangleBucket) ForEach(forEach func(triIndex, triID int, vertex
 func (s *sortingTriangleBucket) IsEmpty() bool {
 	return s.unsetTriIndex == 0
 }
-
-var globalSortingTriangleBucket = newSortingTriangleBucket()
-
-func init() {
-	globalSortingTriangleBucket.Resize(512)
-}
@@ -0,0 +1,336 @@
+package tetra3d
+
+import (
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// SpatialHash is a collision broadphase that sorts BoundingObjects into a uniform grid of cells by their world bounds. Rather than testing
+// a moving object against every BoundingObject in a level, a collision test can use SpatialHash.Near() to only test against the objects
+// in the cells the moving object overlaps, which stops collision tests against "everything solid" from scaling with the size of the level:
+//
+//	player.CollisionTest(tetra3d.CollisionTestSettings{TestAgainst: scene.SpatialHash().Near(player)})
+//
+// A SpatialHash doesn't notice when the objects in it move; call SpatialHash.Update() once per frame after moving objects (and before testing
+// for collisions) to sort them into their new cells. Objects that don't move (i.e. the level's BoundingTriangles) are cheap to keep in the hash,
+// as only objects whose cells changed are re-sorted.
+type SpatialHash struct {
+	CellSize float32 // The size of each cell of the grid in world units. Objects should generally be about as big as a cell or smaller. Defaults to 4.
+
+	objects map[IBoundingObject]*spatialHashEntry
+	order   []IBoundingObject // The objects in the order they were added, so that updates are deterministic
+	cells   map[spatialHashCell][]IBoundingObject
+	large   []IBoundingObject // Objects that span too many cells to be sorted into them individually
+	queryID uint64
+	scene   *Scene // The Scene whose tree the SpatialHash automatically mirrors, if any; see Scene.SpatialHash()
+	synced  bool   // Whether the SpatialHash has been filled with the BoundingObjects in its Scene's tree
+}
+
+type spatialHashCell struct {
+	X, Y, Z int32
+}
+
+type spatialHashEntry struct {
+	min, max spatialHashCell
+	queryID  uint64
+}
+
+// spatialHashMaxCells is the maximum number of cells an object can span before it's considered large; large objects (i.e. a whole level's
+// BoundingTriangles) are kept in a separate list that's checked by every query instead.
+const spatialHashMaxCells = 512
+
+func (entry *spatialHashEntry) cellCount() int64 {
+	return (int64(entry.max.X-entry.min.X) + 1) * (int64(entry.max.Y-entry.min.Y) + 1) * (int64(entry.max.Z-entry.min.Z) + 1)
+}
+
+func (entry *spatialHashEntry) overlaps(min, max spatialHashCell) bool {
+	return entry.max.X >= min.X && entry.min.X <= max.X && entry.max.Y >= min.Y && entry.min.Y <= max.Y && entry.max.Z >= min.Z && entry.min.Z <= max.Z
+}
+
+// NewSpatialHash creates a new, empty SpatialHash with the given cell size in world units. If the cell size is 0 or less, it defaults to 4.
+func NewSpatialHash(cellSize float32) *SpatialHash {
+	if cellSize <= 0 {
+		cellSize = 4
+	}
+	return &SpatialHash{
+		CellSize: cellSize,
+		objects:  map[IBoundingObject]*spatialHashEntry{},
+		cells:    map[spatialHashCell][]IBoundingObject{},
+	}
+}
+
+// newSceneSpatialHash creates a new SpatialHash that mirrors the BoundingObjects in the given Scene's tree.
+func newSceneSpatialHash(scene *Scene) *SpatialHash {
+	hash := NewSpatialHash(0)
+	hash.scene = scene
+	return hash
+}
+
+// Add adds the given BoundingObjects to the SpatialHash. Objects already in the SpatialHash are ignored.
+func (hash *SpatialHash) Add(objects ...IBoundingObject) {
+	for _, obj := range objects {
+		if _, exists := hash.objects[obj]; exists {
+			continue
+		}
+		entry := &spatialHashEntry{}
+		entry.min, entry.max = hash.cellRange(spatialHashBounds(obj))
+		hash.objects[obj] = entry
+		hash.order = append(hash.order, obj)
+		hash.insert(obj, entry)
+	}
+}
+
+// Remove removes the given BoundingObjects from the SpatialHash.
+func (hash *SpatialHash) Remove(objects ...IBoundingObject) {
+
+	removed := false
+
+	for _, obj := range objects {
+		if entry, exists := hash.objects[obj]; exists {
+			hash.remove(obj, entry)
+			delete(hash.objects, obj)
+			removed = true
+		}
+	}
+
+	if removed {
+		order := hash.order[:0]
+		for _, obj := range hash.order {
+			if _, exists := hash.objects[obj]; exists {
+				order = append(order, obj)
+			}
+		}
+		hash.order = order
+	}
+
+}
+
+// Clear removes all objects from the SpatialHash. If the SpatialHash belongs to a Scene (see Scene.SpatialHash()), it's filled with the
+// BoundingObjects in the Scene's tree again the next time it's used.
+func (hash *SpatialHash) Clear() {
+	clear(hash.objects)
+	clear(hash.cells)
+	hash.order = hash.order[:0]
+	hash.large = hash.large[:0]
+	hash.synced = false
+}
+
+// Contains returns if the given BoundingObject is in the SpatialHash.
+func (hash *SpatialHash) Contains(obj IBoundingObject) bool {
+	_, exists := hash.objects[obj]
+	return exists
+}
+
+// Objects returns the BoundingObjects in the SpatialHash, in the order they were added. The returned slice shouldn't be modified.
+func (hash *SpatialHash) Objects() []IBoundingObject {
+	hash.sync()
+	return hash.order
+}
+
+// Update sorts the objects in the SpatialHash that have moved since the last Update() call into their new cells. This should be called once
+// per frame, after objects have moved.
+func (hash *SpatialHash) Update() {
+
+	hash.sync()
+
+	for _, obj := range hash.order {
+
+		entry := hash.objects[obj]
+
+		min, max := hash.cellRange(spatialHashBounds(obj))
+
+		if min != entry.min || max != entry.max {
+			hash.remove(obj, entry)
+			entry.min, entry.max = min, max
+			hash.insert(obj, entry)
+		}
+
+	}
+
+}
+
+// Near returns a NodeIterator that iterates through the BoundingObjects in the SpatialHash that are in the cells overlapped by the given
+// BoundingObject (other than the BoundingObject itself), which makes it suitable for use as the TestAgainst field of CollisionTestSettings.
+// Note that the objects returned are only potentially colliding with the given BoundingObject; they should still be tested for collision.
+func (hash *SpatialHash) Near(bounds IBoundingObject) NodeIterator {
+	return spatialHashQuery{hash: hash, dimensions: spatialHashBounds(bounds), exclude: bounds}
+}
+
+// Within returns a NodeIterator that iterates through the BoundingObjects in the SpatialHash that are in the cells overlapped by the given
+// world-space Dimensions; this is useful to narrow down the objects to ray test against (i.e. with Dimensions enclosing the ray).
+func (hash *SpatialHash) Within(dimensions Dimensions) NodeIterator {
+	return spatialHashQuery{hash: hash, dimensions: dimensions}
+}
+
+// sync fills the SpatialHash with the BoundingObjects in its Scene's tree the first time it's used, if it belongs to a Scene; afterwards,
+// BoundingObjects are added and removed as they enter and leave the Scene's tree.
+func (hash *SpatialHash) sync() {
+
+	if hash.scene == nil || hash.synced {
+		return
+	}
+
+	hash.synced = true
+
+	hash.scene.Root.SearchTree().ForEach(func(node INode) bool {
+		if obj, ok := node.(IBoundingObject); ok {
+			hash.Add(obj)
+		}
+		return true
+	})
+
+}
+
+func (hash *SpatialHash) cellRange(dimensions Dimensions) (spatialHashCell, spatialHashCell) {
+	return hash.cellAt(dimensions.Min), hash.cellAt(dimensions.Max)
+}
+
+func (hash *SpatialHash) cellAt(position Vector3) spatialHashCell {
+	return spatialHashCell{
+		int32(math32.Floor(position.X / hash.CellSize)),
+		int32(math32.Floor(position.Y / hash.CellSize)),
+		int32(math32.Floor(position.Z / hash.CellSize)),
+	}
+}
+
+func (hash *SpatialHash) insert(obj IBoundingObject, entry *spatialHashEntry) {
+	if entry.cellCount() > spatialHashMaxCells {
+		hash.large = append(hash.large, obj)
+		return
+	}
+	for x := entry.min.X; x <= entry.max.X; x++ {
+		for y := entry.min.Y; y <= entry.max.Y; y++ {
+			for z := entry.min.Z; z <= entry.max.Z; z++ {
+				cell := spatialHashCell{x, y, z}
+				hash.cells[cell] = append(hash.cells[cell], obj)
+			}
+		}
+	}
+}
+
+func (hash *SpatialHash) remove(obj IBoundingObject, entry *spatialHashEntry) {
+	if entry.cellCount() > spatialHashMaxCells {
+		for i, o := range hash.large {
+			if o == obj {
+				hash.large = append(hash.large[:i], hash.large[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+	for x := entry.min.X; x <= entry.max.X; x++ {
+		for y := entry.min.Y; y <= entry.max.Y; y++ {
+			for z := entry.min.Z; z <= entry.max.Z; z++ {
+				cell := spatialHashCell{x, y, z}
+				objects := hash.cells[cell]
+				for i, o := range objects {
+					if o == obj {
+						objects = append(objects[:i], objects[i+1:]...)
+						break
+					}
+				}
+				if len(objects) == 0 {
+					delete(hash.cells, cell)
+				} else {
+					hash.cells[cell] = objects
+				}
+			}
+		}
+	}
+}
+
+// spatialHashQuery is a NodeIterator that iterates through the objects in the cells of a SpatialHash overlapped by a set of Dimensions.
+type spatialHashQuery struct {
+	hash       *SpatialHash
+	dimensions Dimensions
+	exclude    IBoundingObject
+}
+
+func (query spatialHashQuery) ForEach(forEachFunc func(node INode) bool) {
+
+	hash := query.hash
+
+	hash.sync()
+
+	// Each query gets a new ID, so that objects spanning multiple cells are only iterated through once.
+	hash.queryID++
+	queryID := hash.queryID
+
+	visit := func(obj IBoundingObject) bool {
+
+		entry := hash.objects[obj]
+
+		if obj == query.exclude || entry.queryID == queryID {
+			return true
+		}
+
+		entry.queryID = queryID
+
+		return forEachFunc(obj)
+
+	}
+
+	queryEntry := spatialHashEntry{}
+	queryEntry.min, queryEntry.max = hash.cellRange(query.dimensions)
+	min, max := queryEntry.min, queryEntry.max
+
+	// If the query covers more cells than there are objects, it's faster to just check each object's cells directly.
+	if queryEntry.cellCount() > int64(len(hash.order)) {
+
+		for _, obj := range hash.order {
+			if hash.objects[obj].overlaps(min, max) && !visit(obj) {
+				return
+			}
+		}
+
+		return
+
+	}
+
+	for _, obj := range hash.large {
+		if hash.objects[obj].overlaps(min, max) && !visit(obj) {
+			return
+		}
+	}
+
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			for z := min.Z; z <= max.Z; z++ {
+				for _, obj := range hash.cells[spatialHashCell{x, y, z}] {
+					if !visit(obj) {
+						return
+					}
+				}
+			}
+		}
+	}
+
+}
+
+// spatialHashBounds returns the world-space bounds of the given BoundingObject.
+func spatialHashBounds(bounds IBoundingObject) Dimensions {
+
+	bounds.Transform() // Make sure the transform is updated before getting the bounds
+
+	switch b := bounds.(type) {
+
+	case *BoundingAABB:
+		pos := b.WorldPosition()
+		return Dimensions{pos.Add(b.Dimensions.Min), pos.Add(b.Dimensions.Max)}
+
+	case *BoundingTriangles:
+		pos := b.BoundingAABB.WorldPosition()
+		return Dimensions{pos.Add(b.BoundingAABB.Dimensions.Min), pos.Add(b.BoundingAABB.Dimensions.Max)}
+
+	case *BoundingHeightfield:
+		return b.worldDimensions()
+
+	}
+
+	if shape, ok := convexShapeOf(bounds); ok {
+		return shapeDimensions(shape)
+	}
+
+	pos := bounds.WorldPosition()
+	return Dimensions{pos, pos}
+
+}
@@ -0,0 +1,71 @@
+package tetra3d
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSpatialHash(t *testing.T) {
+
+	hash := NewSpatialHash(2)
+
+	a := NewBoundingSphere("a", 0.5)
+	b := NewBoundingSphere("b", 0.5)
+	b.SetLocalPosition(1, 0, 0)
+	c := NewBoundingSphere("c", 0.5)
+	c.SetLocalPosition(20, 0, 0)
+	floor := NewBoundingAABB("floor", 10000, 1, 10000) // Large enough to span too many cells to be sorted into them
+
+	hash.Add(a, b, c, floor)
+	hash.Add(a) // Adding an object again does nothing
+
+	near := func(obj IBoundingObject) []string {
+		names := []string{}
+		hash.Near(obj).ForEach(func(node INode) bool {
+			names = append(names, node.Name())
+			return true
+		})
+		slices.Sort(names)
+		return names
+	}
+
+	tests := []struct {
+		name   string
+		update func()
+		query  IBoundingObject
+		near   []string
+	}{
+		{"near a", nil, a, []string{"b", "floor"}},
+		{"near c", nil, c, []string{"floor"}},
+		{"c moved next to a", func() { c.SetLocalPosition(-1, 0, 0) }, a, []string{"b", "c", "floor"}},
+		{"b moved away", func() { b.SetLocalPosition(0, 0, 30) }, a, []string{"c", "floor"}},
+		{"near b after moving", nil, b, []string{"floor"}},
+		{"c removed", func() { hash.Remove(c) }, a, []string{"floor"}},
+		{"floor removed", func() { hash.Remove(floor) }, a, []string{}},
+		{"b moved back", func() { b.SetLocalPosition(0.5, 0.5, 0) }, a, []string{"b"}},
+	}
+
+	for _, test := range tests {
+
+		if test.update != nil {
+			test.update()
+			hash.Update()
+		}
+
+		if names := near(test.query); !slices.Equal(names, test.near) {
+			t.Errorf("%s: Near() returned %v; expected %v", test.name, names, test.near)
+		}
+
+	}
+
+	if hash.Contains(c) || !hash.Contains(a) || len(hash.Objects()) != 2 {
+		t.Errorf("SpatialHash contains %d objects after removing two of four", len(hash.Objects()))
+	}
+
+	hash.Clear()
+
+	if len(hash.Objects()) != 0 || len(near(a)) != 0 {
+		t.Errorf("SpatialHash isn't empty after being cleared")
+	}
+
+}
@@ -0,0 +1,215 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// SpringArmCamera is a Node that holds a Camera at the end of an arm pivoting around a target (i.e. the player's character), like a
+// third-person camera. The SpringArmCamera smoothly follows its Target, rotates around it according to its Yaw and Pitch (within limits),
+// and shortens its arm when something solid is between the target and the Camera, so the Camera never clips into walls. The Camera can be
+// offset to the side (a shoulder offset) for an over-the-shoulder view.
+//
+// The SpringArmCamera should generally not be parented to its Target (i.e. it should be added to the Scene's root), as it follows the Target
+// by itself. Its Camera is a child of the SpringArmCamera; call SpringArmCamera.Update() once per frame, after the Target has moved, to update it.
+type SpringArmCamera struct {
+	*Node
+	Camera *Camera // The Camera at the end of the arm. It's a child of the SpringArmCamera.
+
+	Target       INode   // The Node the SpringArmCamera follows and pivots around. If nil, the SpringArmCamera stays where it is.
+	TargetOffset Vector3 // The world offset from the Target's position to the pivot point of the arm (i.e. up to a character's head height).
+
+	// FollowSpeed is how quickly the pivot catches up to the Target; higher values follow more tightly. Each second, the pivot covers all but
+	// 1/e^FollowSpeed of the remaining distance. A FollowSpeed of 0 or less follows the Target exactly. Defaults to 10.
+	FollowSpeed float32
+
+	Distance float32 // The length of the arm in world units when nothing's in the way. Defaults to 5.
+	// ShoulderOffset is the offset of the Camera from the end of the arm, relative to the Camera's orientation (so an X of 1 places the
+	// Camera 1 unit to the right of where it would be otherwise, for an over-the-shoulder view).
+	ShoulderOffset Vector3
+
+	Yaw   float32 // The rotation of the arm around the world Y axis in radians.
+	Pitch float32 // The tilt of the arm in radians; positive values look up at the Target from below, while negative values look down on it.
+
+	MinPitch float32 // The lowest Pitch the arm can have. Defaults to -1.4 radians (just above looking straight down).
+	MaxPitch float32 // The highest Pitch the arm can have. Defaults to 1.4 radians (just below looking straight up).
+	LimitYaw bool    // Whether the arm's Yaw is limited to the range between MinYaw and MaxYaw. Defaults to false.
+	MinYaw   float32 // The lowest Yaw the arm can have if LimitYaw is true.
+	MaxYaw   float32 // The highest Yaw the arm can have if LimitYaw is true.
+
+	// TestAgainst is used to specify a selection of solid BoundingObjects that the arm shortens to avoid - this can be either a NodeFilter or a
+	// NodeCollection (a slice of Nodes). Objects that the pivot point is inside of (i.e. the Target's own BoundingObjects) are ignored.
+	// If nil, the arm is always at its full length.
+	TestAgainst NodeIterator
+	// CollisionRadius is the radius of the sphere swept along the arm when testing against the objects in TestAgainst; it's how far the
+	// Camera stays from solid surfaces, and should be a bit larger than the Camera's near plane. Defaults to 0.25.
+	CollisionRadius float32
+	// ZoomSpeed is how quickly the arm extends back out to its full length after being shortened by an obstacle, in world units per second.
+	// (The arm always shortens immediately, so that the Camera doesn't clip into walls.) A ZoomSpeed of 0 or less extends it immediately.
+	// Defaults to 5.
+	ZoomSpeed float32
+
+	armLength float32
+	snap      bool
+}
+
+// NewSpringArmCamera creates a new SpringArmCamera with the given name, holding the given Camera at the end of its arm. The Camera is added
+// as a child of the SpringArmCamera.
+func NewSpringArmCamera(name string, camera *Camera) *SpringArmCamera {
+	arm := &SpringArmCamera{
+		Node:            NewNode(name),
+		Camera:          camera,
+		FollowSpeed:     10,
+		Distance:        5,
+		MinPitch:        -1.4,
+		MaxPitch:        1.4,
+		CollisionRadius: 0.25,
+		ZoomSpeed:       5,
+		snap:            true,
+	}
+	arm.owner = arm
+	if camera != nil {
+		arm.AddChildren(camera)
+	}
+	return arm
+}
+
+// Clone creates a clone of the SpringArmCamera and its children, including its Camera.
+func (arm *SpringArmCamera) Clone() INode {
+
+	clone := NewSpringArmCamera(arm.name, nil)
+	clone.Target = arm.Target
+	clone.TargetOffset = arm.TargetOffset
+	clone.FollowSpeed = arm.FollowSpeed
+	clone.Distance = arm.Distance
+	clone.ShoulderOffset = arm.ShoulderOffset
+	clone.Yaw = arm.Yaw
+	clone.Pitch = arm.Pitch
+	clone.MinPitch = arm.MinPitch
+	clone.MaxPitch = arm.MaxPitch
+	clone.LimitYaw = arm.LimitYaw
+	clone.MinYaw = arm.MinYaw
+	clone.MaxYaw = arm.MaxYaw
+	clone.TestAgainst = arm.TestAgainst
+	clone.CollisionRadius = arm.CollisionRadius
+	clone.ZoomSpeed = arm.ZoomSpeed
+	clone.armLength = arm.armLength
+	clone.snap = arm.snap
+
+	clone.Node = arm.Node.clone(clone).(*Node)
+
+	// The Camera is cloned along with the rest of the SpringArmCamera's children, so we find it again by its index.
+	for i, child := range arm.children {
+		if child == arm.Camera {
+			clone.Camera = clone.children[i].(*Camera)
+			break
+		}
+	}
+
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+
+	return clone
+
+}
+
+// Orbit rotates the arm around its pivot by the given yaw and pitch in radians (i.e. according to mouse or right stick movement), limiting
+// the resulting Yaw and Pitch to the SpringArmCamera's limits.
+func (arm *SpringArmCamera) Orbit(yaw, pitch float32) {
+	arm.Yaw += yaw
+	arm.Pitch += pitch
+	arm.clampRotation()
+}
+
+// clampRotation limits the SpringArmCamera's Yaw and Pitch to its limits.
+func (arm *SpringArmCamera) clampRotation() {
+
+	if arm.MinPitch <= arm.MaxPitch {
+		arm.Pitch = math32.Clamp(arm.Pitch, arm.MinPitch, arm.MaxPitch)
+	}
+
+	if arm.LimitYaw && arm.MinYaw <= arm.MaxYaw {
+		arm.Yaw = math32.Clamp(arm.Yaw, arm.MinYaw, arm.MaxYaw)
+	}
+
+}
+
+// ArmLength returns the current length of the arm in world units, which is shorter than Distance when an obstacle is in the way.
+func (arm *SpringArmCamera) ArmLength() float32 {
+	return arm.armLength
+}
+
+// Snap makes the SpringArmCamera's next Update() call jump directly to its Target and extend its arm fully (apart from any obstacles),
+// rather than smoothly moving there; this is useful when the Target teleports, or when switching to the SpringArmCamera.
+func (arm *SpringArmCamera) Snap() {
+	arm.snap = true
+}
+
+// Update updates the SpringArmCamera over the given delta time in seconds, moving its pivot towards its Target, rotating the arm according
+// to its Yaw and Pitch, and positioning its Camera at the end of the arm, shortening the arm if something in TestAgainst is in the way.
+func (arm *SpringArmCamera) Update(dt float32) {
+
+	if arm.Target != nil {
+
+		target := arm.Target.WorldPosition().Add(arm.TargetOffset)
+
+		if arm.snap || arm.FollowSpeed <= 0 {
+			arm.SetWorldPositionVec(target)
+		} else {
+			arm.SetWorldPositionVec(arm.WorldPosition().Lerp(target, 1-math32.Exp(-arm.FollowSpeed*dt)))
+		}
+
+	}
+
+	arm.clampRotation()
+
+	// The arm points backwards from the Camera's view (as Cameras look down -Z), so positive pitches swing the Camera under the pivot.
+	arm.SetWorldRotation(NewMatrix4Rotate(0, 1, 0, arm.Yaw).Rotated(1, 0, 0, arm.Pitch))
+
+	offset := arm.ShoulderOffset.Add(Vector3{0, 0, math32.Max(arm.Distance, 0)})
+	length := offset.Magnitude()
+
+	// The arm extends back out smoothly, but is shortened immediately if something's in the way.
+	if arm.snap || arm.ZoomSpeed <= 0 {
+		arm.armLength = length
+	} else {
+		arm.armLength = math32.Min(arm.armLength+arm.ZoomSpeed*dt, length)
+	}
+
+	arm.snap = false
+
+	if arm.TestAgainst != nil && length > 0 {
+
+		pivot := arm.WorldPosition()
+		end := arm.Transform().MultVec(offset.Scale(arm.armLength / length))
+
+		SphereCast(ShapeCastOptions{
+			From:        pivot,
+			To:          end,
+			Radius:      arm.CollisionRadius,
+			TestAgainst: arm.TestAgainst,
+			OnHit: func(hit ShapeCastHit, index, count int) bool {
+				// Hits on the SpringArmCamera's own children (or the Camera's) are ignored.
+				if hit.Object.IsDescendantOf(arm.owner) {
+					return true
+				}
+				arm.armLength *= hit.Fraction
+				return false
+			},
+		})
+
+	}
+
+	if arm.Camera != nil {
+		if length > 0 {
+			arm.Camera.SetLocalPositionVec(offset.Scale(arm.armLength / length))
+		} else {
+			arm.Camera.SetLocalPositionVec(Vector3{})
+		}
+		arm.Camera.SetLocalRotation(NewMatrix4())
+	}
+
+}
+
+// Type returns the NodeType for this object.
+func (arm *SpringArmCamera) Type() NodeType {
+	return NodeTypeSpringArmCamera
+}
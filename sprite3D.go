@@ -0,0 +1,433 @@
+package tetra3d
+
+import (
+	"errors"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// SpriteFrame is a rectangle of a Sprite3D's image, in pixels, that is displayed as a single frame of the sprite.
+type SpriteFrame struct {
+	X, Y          int // The top-left corner of the frame in the image.
+	Width, Height int // The size of the frame.
+}
+
+// SpriteAnimation is a named sequence of a Sprite3D's frames.
+type SpriteAnimation struct {
+	Name   string
+	Frames []int   // The indices of the frames (in the Sprite3D's Frames slice) that make up the animation, in order.
+	FPS    float32 // The playback speed of the animation in frames per second.
+	Loop   bool    // Whether the animation loops back to the start once it finishes.
+}
+
+// Sprite3D is a Node that displays an image (or a frame of a spritesheet) as a flat sprite in 3D space, like the enemies and items in
+// classic first-person shooters. The sprite is rendered through a generated Model (a quad sized to the current frame) that is a child of
+// the Sprite3D, and that billboards towards the Camera according to the Sprite3D's billboard mode.
+//
+// Spritesheets are split into frames through Sprite3D.SetSpritesheet() (or by setting the Frames slice directly), and the frames can be
+// played back through named animations (see Sprite3D.AddAnimation() and Sprite3D.Play()); call Sprite3D.Update() once per frame to advance
+// the current animation.
+//
+// By default, a Sprite3D has flat depth - all of its pixels are given the depth of its origin, rather than the depth of the quad, so
+// that billboarded sprites don't cut into each other or into nearby walls and floors as they turn to face the Camera.
+type Sprite3D struct {
+	*Node
+	Model *Model // The generated Model rendering the Sprite3D. It's a child of the Sprite3D.
+
+	Frames     []SpriteFrame               // The frames of the Sprite3D's image.
+	Animations map[string]*SpriteAnimation // The Sprite3D's animations, by name.
+
+	// PixelsPerUnit is how many pixels of the image make up one world unit of the sprite's size. Defaults to 16.
+	PixelsPerUnit float32
+
+	// Origin is the point of the frame that the Sprite3D's position lines up with, with 0, 0 being the bottom-left corner
+	// of the frame and 1, 1 being the top-right. Defaults to 0.5, 0.5 (the center of the frame).
+	Origin Vector2
+
+	FlipH bool // Whether the sprite is flipped horizontally.
+	FlipV bool // Whether the sprite is flipped vertically.
+
+	Speed float32 // The playback speed of animations, with 1 being 100%. Negative values play animations backwards.
+
+	// OnAnimationFinish is called when a non-looping animation reaches its end, or when a looping animation loops.
+	OnAnimationFinish func(animation *SpriteAnimation)
+
+	image     *ebiten.Image
+	frame     int
+	animation *SpriteAnimation
+	playhead  float32
+	playing   bool
+}
+
+// NewSprite3D creates a new Sprite3D with the given name, displaying the given image. Initially, the entire image is a single frame;
+// use Sprite3D.SetSpritesheet() to split it up into multiple frames.
+func NewSprite3D(name string, image *ebiten.Image) *Sprite3D {
+
+	sprite := &Sprite3D{
+		Node:          NewNode(name),
+		Animations:    map[string]*SpriteAnimation{},
+		PixelsPerUnit: 16,
+		Origin:        Vector2{0.5, 0.5},
+		Speed:         1,
+	}
+	sprite.owner = sprite
+
+	mat := NewMaterial(name)
+	mat.BillboardMode = BillboardModeFixedVertical
+	mat.TransparencyMode = TransparencyModeAlphaClip
+	mat.BackfaceCulling = false
+
+	mesh := NewMesh(name,
+		NewVertex(0, 0, 0, 0, 0),
+		NewVertex(0, 0, 0, 1, 0),
+		NewVertex(0, 0, 0, 1, 1),
+		NewVertex(0, 0, 0, 0, 1),
+	)
+	mesh.AddMeshPart(mat, 0, 1, 2, 0, 2, 3)
+	mesh.Unique = MeshUniqueMeshAndMaterials
+
+	for i := range mesh.VertexNormals {
+		mesh.VertexNormals[i] = Vector3{0, 0, 1}
+	}
+
+	sprite.Model = NewModel(name+"_sprite", mesh)
+	sprite.Model.flatDepth = true
+	sprite.AddChildren(sprite.Model)
+
+	sprite.SetImage(image)
+
+	return sprite
+
+}
+
+// Clone creates a clone of the Sprite3D, including its generated Model.
+func (sprite *Sprite3D) Clone() INode {
+
+	clone := &Sprite3D{
+		Frames:            append([]SpriteFrame{}, sprite.Frames...),
+		Animations:        make(map[string]*SpriteAnimation, len(sprite.Animations)),
+		PixelsPerUnit:     sprite.PixelsPerUnit,
+		Origin:            sprite.Origin,
+		FlipH:             sprite.FlipH,
+		FlipV:             sprite.FlipV,
+		Speed:             sprite.Speed,
+		OnAnimationFinish: sprite.OnAnimationFinish,
+		image:             sprite.image,
+		frame:             sprite.frame,
+		animation:         sprite.animation,
+		playhead:          sprite.playhead,
+		playing:           sprite.playing,
+	}
+
+	for name, anim := range sprite.Animations {
+		clone.Animations[name] = anim
+	}
+
+	clone.Node = sprite.Node.clone(clone).(*Node)
+
+	// The generated Model is cloned along with the rest of the Sprite3D's children (with its own Mesh and Material, as the Mesh is unique),
+	// so we find it again by its index.
+	for i, child := range sprite.children {
+		if child == sprite.Model {
+			clone.Model = clone.children[i].(*Model)
+			break
+		}
+	}
+
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+
+	return clone
+
+}
+
+// Image returns the image the Sprite3D displays.
+func (sprite *Sprite3D) Image() *ebiten.Image {
+	return sprite.image
+}
+
+// SetImage sets the image the Sprite3D displays, resetting its frames to a single frame covering the entire image.
+func (sprite *Sprite3D) SetImage(image *ebiten.Image) {
+
+	sprite.image = image
+	sprite.Material().Texture = image
+
+	sprite.Frames = sprite.Frames[:0]
+	if image != nil {
+		sprite.Frames = append(sprite.Frames, SpriteFrame{0, 0, image.Bounds().Dx(), image.Bounds().Dy()})
+	}
+
+	sprite.frame = 0
+	sprite.updateMesh()
+
+}
+
+// SetSpritesheet splits the Sprite3D's image up into a grid of frames of the given size in pixels, ordered from left to right and then top
+// to bottom. SetSpritesheet will panic if the frame size is 0 or less.
+func (sprite *Sprite3D) SetSpritesheet(frameWidth, frameHeight int) {
+
+	if frameWidth <= 0 || frameHeight <= 0 {
+		panic("Error: Sprite3D.SetSpritesheet() must be given a frame width and height above 0.")
+	}
+
+	sprite.Frames = sprite.Frames[:0]
+
+	if sprite.image != nil {
+
+		w, h := sprite.image.Bounds().Dx(), sprite.image.Bounds().Dy()
+
+		for y := 0; y+frameHeight <= h; y += frameHeight {
+			for x := 0; x+frameWidth <= w; x += frameWidth {
+				sprite.Frames = append(sprite.Frames, SpriteFrame{x, y, frameWidth, frameHeight})
+			}
+		}
+
+	}
+
+	sprite.SetFrame(0)
+
+}
+
+// Material returns the Material of the Sprite3D's Model, which can be used to customize how the Sprite3D is rendered (i.e. its color or
+// transparency mode).
+func (sprite *Sprite3D) Material() *Material {
+	return sprite.Model.Mesh.MeshParts[0].Material
+}
+
+// BillboardMode returns the billboard mode of the Sprite3D (i.e. BillboardModeFixedVertical, the default).
+func (sprite *Sprite3D) BillboardMode() int {
+	return sprite.Material().BillboardMode
+}
+
+// SetBillboardMode sets the billboard mode of the Sprite3D. BillboardModeNone makes the Sprite3D a flat quad facing its local +Z axis.
+func (sprite *Sprite3D) SetBillboardMode(mode int) {
+	sprite.Material().BillboardMode = mode
+}
+
+// FlatDepth returns whether the Sprite3D has flat depth (see Sprite3D.SetFlatDepth()).
+func (sprite *Sprite3D) FlatDepth() bool {
+	return sprite.Model.flatDepth
+}
+
+// SetFlatDepth sets whether the Sprite3D has flat depth, which is on by default. With flat depth, all of the sprite's pixels are given the depth of
+// its origin, so sprites sort against each other by their positions and don't cut into nearby geometry as they turn to face the Camera.
+// Without flat depth, the depth of the sprite's quad is used, just like any other Model.
+func (sprite *Sprite3D) SetFlatDepth(flat bool) {
+	sprite.Model.flatDepth = flat
+}
+
+// AddAnimation adds a named animation consisting of the given frame indices to the Sprite3D, returning it. If an animation with the same
+// name already exists, it's replaced.
+func (sprite *Sprite3D) AddAnimation(name string, fps float32, loop bool, frames ...int) *SpriteAnimation {
+	anim := &SpriteAnimation{
+		Name:   name,
+		Frames: frames,
+		FPS:    fps,
+		Loop:   loop,
+	}
+	sprite.Animations[name] = anim
+	return anim
+}
+
+// Play plays the animation with the given name from the start, unless it's already playing. Play returns an error if no animation with
+// the given name exists.
+func (sprite *Sprite3D) Play(animationName string) error {
+
+	anim, ok := sprite.Animations[animationName]
+	if !ok {
+		return errors.New("Animation named {" + animationName + "} not found in Sprite3D " + sprite.name)
+	}
+
+	if sprite.animation == anim && sprite.playing {
+		return nil
+	}
+
+	sprite.animation = anim
+	sprite.playing = true
+
+	if sprite.Speed < 0 {
+		sprite.playhead = float32(len(anim.Frames)) - 0.0001
+	} else {
+		sprite.playhead = 0
+	}
+
+	sprite.applyAnimationFrame()
+
+	return nil
+
+}
+
+// Stop stops the Sprite3D's current animation, leaving it on its current frame.
+func (sprite *Sprite3D) Stop() {
+	sprite.playing = false
+}
+
+// Playing returns whether the Sprite3D is currently playing an animation.
+func (sprite *Sprite3D) Playing() bool {
+	return sprite.playing
+}
+
+// Animation returns the Sprite3D's current (or last played) animation, or nil if it hasn't played one.
+func (sprite *Sprite3D) Animation() *SpriteAnimation {
+	return sprite.animation
+}
+
+// Frame returns the index of the frame the Sprite3D is currently displaying.
+func (sprite *Sprite3D) Frame() int {
+	return sprite.frame
+}
+
+// SetFrame sets the Sprite3D to display the frame at the given index, clamped to the range of its Frames.
+func (sprite *Sprite3D) SetFrame(frameIndex int) {
+
+	if frameIndex >= len(sprite.Frames) {
+		frameIndex = len(sprite.Frames) - 1
+	}
+
+	if frameIndex < 0 {
+		frameIndex = 0
+	}
+
+	sprite.frame = frameIndex
+	sprite.updateMesh()
+
+}
+
+// Update advances the Sprite3D's current animation by the given delta time in seconds, and updates its Model to reflect any
+// changes to the Sprite3D's settings (like its Origin or PixelsPerUnit).
+func (sprite *Sprite3D) Update(dt float32) {
+
+	if anim := sprite.animation; sprite.playing && anim != nil && len(anim.Frames) > 0 {
+
+		sprite.playhead += dt * anim.FPS * sprite.Speed
+
+		length := float32(len(anim.Frames))
+
+		if sprite.playhead >= length || sprite.playhead < 0 {
+
+			if anim.Loop {
+				for sprite.playhead >= length {
+					sprite.playhead -= length
+				}
+				for sprite.playhead < 0 {
+					sprite.playhead += length
+				}
+			} else {
+				if sprite.playhead < 0 {
+					sprite.playhead = 0
+				} else {
+					sprite.playhead = length - 0.0001
+				}
+				sprite.playing = false
+			}
+
+			if sprite.OnAnimationFinish != nil {
+				sprite.OnAnimationFinish(anim)
+			}
+
+		}
+
+		sprite.applyAnimationFrame()
+		return
+
+	}
+
+	sprite.updateMesh()
+
+}
+
+// applyAnimationFrame displays the frame of the current animation under the playhead.
+func (sprite *Sprite3D) applyAnimationFrame() {
+
+	anim := sprite.animation
+
+	if anim == nil || len(anim.Frames) == 0 {
+		return
+	}
+
+	index := int(sprite.playhead)
+	if index >= len(anim.Frames) {
+		index = len(anim.Frames) - 1
+	} else if index < 0 {
+		index = 0
+	}
+
+	sprite.SetFrame(anim.Frames[index])
+
+}
+
+// updateMesh sizes the Sprite3D's quad to its current frame and sets its UV values to display it.
+func (sprite *Sprite3D) updateMesh() {
+
+	mesh := sprite.Model.Mesh
+
+	if sprite.image == nil || len(sprite.Frames) == 0 {
+		return
+	}
+
+	frame := sprite.Frames[sprite.frame]
+
+	ppu := sprite.PixelsPerUnit
+	if ppu <= 0 {
+		ppu = 1
+	}
+
+	w := float32(frame.Width) / ppu
+	h := float32(frame.Height) / ppu
+
+	left := -sprite.Origin.X * w
+	bottom := -sprite.Origin.Y * h
+
+	mesh.VertexPositions[0] = Vector3{left, bottom, 0}
+	mesh.VertexPositions[1] = Vector3{left + w, bottom, 0}
+	mesh.VertexPositions[2] = Vector3{left + w, bottom + h, 0}
+	mesh.VertexPositions[3] = Vector3{left, bottom + h, 0}
+
+	imageW := float32(sprite.image.Bounds().Dx())
+	imageH := float32(sprite.image.Bounds().Dy())
+
+	u0 := float32(frame.X) / imageW
+	u1 := float32(frame.X+frame.Width) / imageW
+
+	// UV values start at the bottom of the image, while pixels start at the top
+	v0 := 1 - float32(frame.Y+frame.Height)/imageH
+	v1 := 1 - float32(frame.Y)/imageH
+
+	if sprite.FlipH {
+		u0, u1 = u1, u0
+	}
+
+	if sprite.FlipV {
+		v0, v1 = v1, v0
+	}
+
+	mesh.VertexUVs[0] = Vector2{u0, v0}
+	mesh.VertexUVs[1] = Vector2{u1, v0}
+	mesh.VertexUVs[2] = Vector2{u1, v1}
+	mesh.VertexUVs[3] = Vector2{u0, v1}
+
+	for _, tri := range mesh.Triangles {
+		tri.RecalculateCenter()
+	}
+
+	// As the quad billboards around the Sprite3D's origin, the bounds are made to cover the quad facing any direction.
+	radius := float32(0)
+	for _, pos := range mesh.VertexPositions {
+		radius = math32.Max(radius, pos.Magnitude())
+	}
+
+	mesh.Dimensions = Dimensions{
+		Min: Vector3{-radius, -radius, -radius},
+		Max: Vector3{radius, radius, radius},
+	}
+
+	sprite.Model.onTransformUpdate()
+
+}
+
+// Type returns the NodeType for this object.
+func (sprite *Sprite3D) Type() NodeType {
+	return NodeTypeSprite3D
+}
@@ -0,0 +1,254 @@
+package tetra3d
+
+import "io/fs"
+
+// StreamChunkState indicates the streaming state of a StreamChunk.
+type StreamChunkState int
+
+const (
+	StreamChunkUnloaded StreamChunkState = iota // The StreamChunk isn't loaded.
+	StreamChunkLoading                          // The StreamChunk's GLTF file is being loaded in the background.
+	StreamChunkLoaded                           // The StreamChunk is loaded and attached to the SceneStreamer's Scene.
+	StreamChunkFailed                           // The StreamChunk failed to load; see StreamChunk.Error().
+)
+
+// StreamChunk is a piece of a streamed world (i.e. a section of an open-world map), loaded from its own GLTF file by a SceneStreamer
+// when the SceneStreamer's focus Node comes near it, and unloaded when it moves away. The chunk's objects are loaded from the
+// exported Scene of its GLTF file (or its first Scene), and should be positioned in world space in the modeler.
+type StreamChunk struct {
+	Name        string           // The name of the StreamChunk; this is also the name of the Node its objects are attached under.
+	FileSystem  fs.FS            // The file system to load the StreamChunk's GLTF file from.
+	Filename    string           // The filename of the StreamChunk's GLTF file in the FileSystem.
+	LoadOptions *GLTFLoadOptions // The options used to load the StreamChunk's GLTF file; if nil, the default options are used.
+
+	// Position is the world position of the center of the StreamChunk, used to stream it by distance to the SceneStreamer's focus Node.
+	Position Vector3
+	// Sector, if set, streams the StreamChunk by Sector rather than distance; the StreamChunk is loaded while the focus Node is in the Sector,
+	// or within SceneStreamer.SectorDepth neighbors of it. The Sector's Model should be part of the SceneStreamer's Scene (i.e. a low-detail
+	// proxy of the chunk's area) rather than of the StreamChunk itself, as it's needed to tell when to load the StreamChunk.
+	Sector *Sector
+
+	state   StreamChunkState
+	handle  *GLTFLoadHandle
+	library *Library
+	root    INode
+	err     error
+}
+
+// NewStreamChunk creates a new StreamChunk that loads the given GLTF file from the given file system, streamed by distance from the given
+// world position.
+func NewStreamChunk(name string, fileSystem fs.FS, filename string, position Vector3) *StreamChunk {
+	return &StreamChunk{
+		Name:       name,
+		FileSystem: fileSystem,
+		Filename:   filename,
+		Position:   position,
+	}
+}
+
+// State returns the streaming state of the StreamChunk.
+func (chunk *StreamChunk) State() StreamChunkState {
+	return chunk.state
+}
+
+// Root returns the Node that the StreamChunk's objects are attached under while it's loaded, or nil if it isn't loaded.
+func (chunk *StreamChunk) Root() INode {
+	return chunk.root
+}
+
+// Library returns the Library loaded from the StreamChunk's GLTF file while it's loaded, or nil if it isn't loaded.
+func (chunk *StreamChunk) Library() *Library {
+	return chunk.library
+}
+
+// Error returns the error that occurred the last time the StreamChunk failed to load, if any.
+func (chunk *StreamChunk) Error() error {
+	return chunk.err
+}
+
+// SceneStreamer streams StreamChunks into and out of a Scene as a focus Node (i.e. the player or the Camera) moves around, so that large
+// worlds don't need to be in memory all at once. StreamChunks are loaded in the background (see LoadGLTFFileSystemAsync()) and attached to
+// the Scene once they're ready; StreamChunks that are no longer needed are detached, and their Libraries are disposed (see Library.Dispose()).
+// Call SceneStreamer.Update() once per frame from the game's goroutine.
+type SceneStreamer struct {
+	Scene  *Scene         // The Scene that StreamChunks are attached to.
+	Focus  INode          // The Node that StreamChunks are streamed in around.
+	Chunks []*StreamChunk // The StreamChunks being streamed; see SceneStreamer.AddChunks().
+
+	// LoadDistance is how close the focus Node has to be to a StreamChunk's Position for it to be loaded. Defaults to 50.
+	LoadDistance float32
+	// UnloadDistance is how far the focus Node has to be from a loaded StreamChunk's Position for it to be unloaded; this should be larger
+	// than LoadDistance, so StreamChunks don't load and unload repeatedly as the focus Node moves along the edge. Defaults to 60.
+	UnloadDistance float32
+	// SectorDepth is how many Sectors away from the focus Node's Sector StreamChunks streamed by Sector are loaded; 0 only loads the
+	// StreamChunk of the focus Node's Sector. Defaults to 1.
+	SectorDepth int
+
+	OnChunkLoad   func(chunk *StreamChunk) // Called when a StreamChunk has been loaded and attached to the Scene.
+	OnChunkUnload func(chunk *StreamChunk) // Called when a StreamChunk is about to be detached from the Scene and unloaded.
+}
+
+// NewSceneStreamer creates a new SceneStreamer that streams StreamChunks into the given Scene around the given focus Node.
+func NewSceneStreamer(scene *Scene, focus INode) *SceneStreamer {
+	return &SceneStreamer{
+		Scene:          scene,
+		Focus:          focus,
+		Chunks:         []*StreamChunk{},
+		LoadDistance:   50,
+		UnloadDistance: 60,
+		SectorDepth:    1,
+	}
+}
+
+// AddChunks adds the given StreamChunks to the SceneStreamer.
+func (streamer *SceneStreamer) AddChunks(chunks ...*StreamChunk) {
+	streamer.Chunks = append(streamer.Chunks, chunks...)
+}
+
+// RemoveChunks removes the given StreamChunks from the SceneStreamer, unloading them if they're loaded.
+func (streamer *SceneStreamer) RemoveChunks(chunks ...*StreamChunk) {
+
+	for _, chunk := range chunks {
+
+		for i, c := range streamer.Chunks {
+
+			if c == chunk {
+				streamer.unload(chunk)
+				streamer.Chunks[i] = nil
+				streamer.Chunks = append(streamer.Chunks[:i], streamer.Chunks[i+1:]...)
+				break
+			}
+
+		}
+
+	}
+
+}
+
+// Update streams the SceneStreamer's StreamChunks in and out of its Scene depending on where the focus Node is, starting to load
+// StreamChunks that are needed, attaching those that have finished loading, and unloading those that are no longer needed.
+func (streamer *SceneStreamer) Update() {
+
+	if streamer.Focus == nil {
+		return
+	}
+
+	focusPos := streamer.Focus.WorldPosition()
+
+	var nearbySectors Set[*Sector]
+
+	for _, chunk := range streamer.Chunks {
+
+		var wanted, unwanted bool
+
+		if chunk.Sector != nil {
+
+			// The focus Node's Sector is only evaluated if there's a StreamChunk streamed by Sector
+			if nearbySectors == nil {
+				nearbySectors = newSet[*Sector]()
+				if sector := streamer.Focus.Sector(); sector != nil {
+					nearbySectors = sector.NeighborsWithinRange(streamer.SectorDepth)
+					nearbySectors.Add(sector)
+				}
+			}
+
+			wanted = nearbySectors.Contains(chunk.Sector)
+			unwanted = !wanted
+
+		} else {
+			distance := focusPos.DistanceSquared(chunk.Position)
+			wanted = distance <= streamer.LoadDistance*streamer.LoadDistance
+			unwanted = distance > streamer.UnloadDistance*streamer.UnloadDistance
+		}
+
+		switch chunk.state {
+
+		case StreamChunkUnloaded:
+			if wanted {
+				chunk.handle = LoadGLTFFileSystemAsync(chunk.FileSystem, chunk.Filename, chunk.LoadOptions)
+				chunk.state = StreamChunkLoading
+			}
+
+		case StreamChunkLoading:
+
+			// Loading can't be cancelled, so StreamChunks that become unneeded while loading are unloaded once they're done
+			if !chunk.handle.Done() {
+				continue
+			}
+
+			if err := chunk.handle.Error(); err != nil {
+
+				chunk.err = err
+				chunk.state = StreamChunkFailed
+				if library := chunk.handle.Library(); library != nil {
+					library.Dispose()
+				}
+
+			} else {
+				streamer.attach(chunk, chunk.handle.Library())
+			}
+
+			chunk.handle = nil
+
+		case StreamChunkLoaded:
+			if unwanted {
+				streamer.unload(chunk)
+			}
+
+		case StreamChunkFailed:
+			// Failed StreamChunks aren't retried until the focus Node leaves and comes back
+			if unwanted {
+				chunk.state = StreamChunkUnloaded
+			}
+
+		}
+
+	}
+
+}
+
+// attach attaches the objects of the Library loaded for the given StreamChunk to the SceneStreamer's Scene.
+func (streamer *SceneStreamer) attach(chunk *StreamChunk, library *Library) {
+
+	chunk.library = library
+	chunk.root = NewNode(chunk.Name)
+	chunk.err = nil
+	chunk.state = StreamChunkLoaded
+
+	scene := library.ExportedScene
+	if scene == nil && len(library.Scenes) > 0 {
+		scene = library.Scenes[0]
+	}
+
+	// The Library belongs to the StreamChunk alone, so its objects are moved over rather than cloned
+	if scene != nil {
+		chunk.root.AddChildren(scene.Root.Children()...)
+	}
+
+	streamer.Scene.Root.AddChildren(chunk.root)
+
+	if streamer.OnChunkLoad != nil {
+		streamer.OnChunkLoad(chunk)
+	}
+
+}
+
+// unload detaches the given StreamChunk from the SceneStreamer's Scene and disposes of its Library, if it's loaded.
+func (streamer *SceneStreamer) unload(chunk *StreamChunk) {
+
+	if chunk.state != StreamChunkLoaded {
+		return
+	}
+
+	if streamer.OnChunkUnload != nil {
+		streamer.OnChunkUnload(chunk)
+	}
+
+	chunk.root.Unparent()
+	chunk.library.Dispose()
+
+	chunk.root = nil
+	chunk.library = nil
+	chunk.state = StreamChunkUnloaded
+
+}
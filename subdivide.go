@@ -0,0 +1,196 @@
+package tetra3d
+
+import "log"
+
+// Subdivide splits each of the Mesh's triangles into four triangles (by splitting each of their edges in half) the number of times given
+// by levels. This is useful to give a Mesh enough vertices for per-vertex effects (like vertex lighting, vertex colors, or displacing
+// vertices using Model.VertexTransformFunction) without having to author a dense Mesh in your modeler.
+// If smooth is false, the new vertices lie on the original triangles, so the shape of the Mesh doesn't change. If smooth is true, the
+// Mesh is smoothed using Loop subdivision, rounding it off more with each level. The open edges of the Mesh (including the borders
+// between MeshParts) are smoothed only along themselves, so they stay connected. Vertices split by UV, normal, or color seams are
+// smoothed by their positions, so seams don't open up.
+// New vertices are interpolated from the vertices of the edges they split (with bone weights being copied from the first vertex).
+// Note that as the Mesh's vertex buffers are rebuilt, the vertex indices of the Mesh change, and so Subdivide() should be called before
+// any vertex selections are made; also note that the number of triangles in the Mesh quadruples with each level, and a single MeshPart
+// can't render more than 65535 vertices or MaxTriangleCount triangles, so subdivision stops for a MeshPart if another level would exceed that.
+func (mesh *Mesh) Subdivide(levels int, smooth bool) {
+
+	if levels <= 0 || len(mesh.Triangles) == 0 {
+		return
+	}
+
+	parts := mesh.partData()
+
+	for _, t := range parts {
+		if len(t.indices) > 0 && !t.subdivide(levels, smooth) {
+			log.Println("warning: mesh [" + mesh.Name + "] could not be fully subdivided, as a MeshPart would exceed the maximum number of renderable vertices or triangles.")
+		}
+	}
+
+	mesh.rebuildParts(parts)
+
+}
+
+// subdivide splits each triangle into four the given number of times, returning false if this couldn't be done without exceeding the
+// limits of a MeshPart.
+func (t *meshPartData) subdivide(levels int, smooth bool) bool {
+
+	for level := 0; level < levels; level++ {
+
+		midpoints := map[[2]int]int{}
+
+		for i := 0; i < len(t.indices); i += 3 {
+			for e := 0; e < 3; e++ {
+				midpoints[tessellationEdge(t.indices[i+e], t.indices[i+(e+1)%3])] = -1
+			}
+		}
+
+		if len(t.verts)+len(midpoints) > 65535 || len(t.indices)/3*4 >= MaxTriangleCount {
+			return false
+		}
+
+		var smoothed *loopSmoothing
+		if smooth {
+			smoothed = t.loopSmoothing()
+		}
+
+		next := make([]int, 0, len(t.indices)*4)
+
+		for i := 0; i < len(t.indices); i += 3 {
+			a, b, c := t.indices[i], t.indices[i+1], t.indices[i+2]
+			ab, bc, ca := t.midpoint(midpoints, a, b), t.midpoint(midpoints, b, c), t.midpoint(midpoints, c, a)
+			next = append(next, a, ab, ca, ab, b, bc, ca, bc, c, ab, bc, ca)
+		}
+
+		t.indices = next
+
+		if smoothed != nil {
+
+			for edge, index := range midpoints {
+				t.setVertexPosition(index, smoothed.edgePoints[smoothed.edge(edge[0], edge[1])])
+			}
+
+			for i, p := range smoothed.positionOf {
+				t.setVertexPosition(i, smoothed.vertexPoints[p])
+			}
+
+		}
+
+	}
+
+	return true
+
+}
+
+// setVertexPosition sets the position of the vertex at the given index.
+func (t *meshPartData) setVertexPosition(index int, position Vector3) {
+	t.verts[index].X, t.verts[index].Y, t.verts[index].Z = position.X, position.Y, position.Z
+}
+
+// loopSmoothing holds the smoothed positions of the vertices of a meshPartData, and of the points splitting its edges, for a level of
+// Loop subdivision. As vertices are split by their UVs, normals, and colors, these are calculated using the positions of the vertices.
+type loopSmoothing struct {
+	positionOf   []int // The index of the position of each vertex
+	vertexPoints []Vector3
+	edgePoints   map[[2]int]Vector3 // The smoothed midpoint of each edge, keyed by the indices of its positions
+}
+
+// edge returns the key for the edge between the vertices at the given indices.
+func (l *loopSmoothing) edge(a, b int) [2]int {
+	return tessellationEdge(l.positionOf[a], l.positionOf[b])
+}
+
+// loopSmoothing calculates where the vertices and edge midpoints of the meshPartData should be moved to for a level of Loop subdivision.
+func (t *meshPartData) loopSmoothing() *loopSmoothing {
+
+	l := &loopSmoothing{
+		positionOf: make([]int, len(t.verts)),
+		edgePoints: map[[2]int]Vector3{},
+	}
+
+	positions := []Vector3{}
+	positionIndices := map[Vector3]int{}
+
+	for i, v := range t.verts {
+		pos := Vector3{v.X, v.Y, v.Z}
+		index, exists := positionIndices[pos]
+		if !exists {
+			index = len(positions)
+			positionIndices[pos] = index
+			positions = append(positions, pos)
+		}
+		l.positionOf[i] = index
+	}
+
+	// The positions opposite each edge in the triangles using it
+	opposites := map[[2]int][]int{}
+	edgeOrder := [][2]int{}
+
+	for i := 0; i < len(t.indices); i += 3 {
+		for e := 0; e < 3; e++ {
+			edge := l.edge(t.indices[i+e], t.indices[i+(e+1)%3])
+			if _, exists := opposites[edge]; !exists {
+				edgeOrder = append(edgeOrder, edge)
+			}
+			opposites[edge] = append(opposites[edge], l.positionOf[t.indices[i+(e+2)%3]])
+		}
+	}
+
+	neighbors := make([][]int, len(positions))
+	boundaryNeighbors := make([][]int, len(positions))
+
+	for _, edge := range edgeOrder {
+
+		pa, pb := positions[edge[0]], positions[edge[1]]
+		opposite := opposites[edge]
+
+		neighbors[edge[0]] = append(neighbors[edge[0]], edge[1])
+		neighbors[edge[1]] = append(neighbors[edge[1]], edge[0])
+
+		// Edges that aren't shared by exactly two triangles are open edges (or creases), and so are only smoothed along themselves
+		if len(opposite) == 2 {
+			l.edgePoints[edge] = pa.Add(pb).Scale(3.0 / 8.0).Add(positions[opposite[0]].Add(positions[opposite[1]]).Scale(1.0 / 8.0))
+		} else {
+			l.edgePoints[edge] = pa.Add(pb).Scale(0.5)
+			boundaryNeighbors[edge[0]] = append(boundaryNeighbors[edge[0]], edge[1])
+			boundaryNeighbors[edge[1]] = append(boundaryNeighbors[edge[1]], edge[0])
+		}
+
+	}
+
+	l.vertexPoints = make([]Vector3, len(positions))
+
+	for p, pos := range positions {
+
+		switch boundary := boundaryNeighbors[p]; {
+
+		case len(boundary) == 2:
+			l.vertexPoints[p] = pos.Scale(3.0 / 4.0).Add(positions[boundary[0]].Add(positions[boundary[1]]).Scale(1.0 / 8.0))
+
+		case len(boundary) > 0 || len(neighbors[p]) < 3:
+			// Corners (where more than two open edges meet) stay in place
+			l.vertexPoints[p] = pos
+
+		default:
+
+			n := float32(len(neighbors[p]))
+
+			beta := float32(3.0 / 16.0)
+			if n > 3 {
+				beta = 3 / (8 * n)
+			}
+
+			sum := Vector3{}
+			for _, neighbor := range neighbors[p] {
+				sum = sum.Add(positions[neighbor])
+			}
+
+			l.vertexPoints[p] = pos.Scale(1 - n*beta).Add(sum.Scale(beta))
+
+		}
+
+	}
+
+	return l
+
+}
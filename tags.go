@@ -0,0 +1,158 @@
+package tetra3d
+
+import "sync"
+
+// Tags are interned, so each Node only stores small IDs for its tags, and checking for a tag doesn't compare strings.
+var (
+	tagMutex sync.RWMutex
+	tagIDs   = map[string]uint32{}
+	tagNames = []string{}
+)
+
+// internTag returns the ID of the given tag, creating one if the tag hasn't been used before.
+func internTag(tag string) uint32 {
+
+	tagMutex.RLock()
+	id, exists := tagIDs[tag]
+	tagMutex.RUnlock()
+
+	if exists {
+		return id
+	}
+
+	tagMutex.Lock()
+	defer tagMutex.Unlock()
+
+	// The tag could have been interned by another goroutine in the meantime
+	if id, exists := tagIDs[tag]; exists {
+		return id
+	}
+
+	id = uint32(len(tagNames))
+	tagIDs[tag] = id
+	tagNames = append(tagNames, tag)
+
+	return id
+
+}
+
+// lookupTag returns the ID of the given tag, and if the tag has been used at all.
+func lookupTag(tag string) (uint32, bool) {
+	tagMutex.RLock()
+	defer tagMutex.RUnlock()
+	id, exists := tagIDs[tag]
+	return id, exists
+}
+
+// tagName returns the tag with the given ID.
+func tagName(id uint32) string {
+	tagMutex.RLock()
+	defer tagMutex.RUnlock()
+	return tagNames[id]
+}
+
+// AddTag adds the given tags to the Node. Tags are a lightweight way to categorize Nodes (i.e. "enemy" or "pickup") that's separate
+// from their game properties; Nodes with a tag can be found quickly using Scene.NodesWithTag() or NodeFilter.ByTag().
+// Tags that the Node already has aren't added again.
+func (node *Node) AddTag(tags ...string) {
+
+	for _, tag := range tags {
+
+		id := internTag(tag)
+
+		if !node.hasTagID(id) {
+			node.tags = append(node.tags, id)
+			if scene := node.indexingScene(); scene != nil {
+				scene.indexTag(node.getOwner(), id)
+			}
+		}
+
+	}
+
+}
+
+// RemoveTag removes the given tags from the Node.
+func (node *Node) RemoveTag(tags ...string) {
+
+	for _, tag := range tags {
+
+		id, exists := lookupTag(tag)
+		if !exists {
+			continue
+		}
+
+		for i, t := range node.tags {
+			if t == id {
+				node.tags = append(node.tags[:i], node.tags[i+1:]...)
+				if scene := node.indexingScene(); scene != nil {
+					scene.unindexTag(node.getOwner(), id)
+				}
+				break
+			}
+		}
+
+	}
+
+}
+
+// ClearTags removes all tags from the Node.
+func (node *Node) ClearTags() {
+	if len(node.tags) > 0 {
+		if scene := node.indexingScene(); scene != nil {
+			for _, id := range node.tags {
+				scene.unindexTag(node.getOwner(), id)
+			}
+		}
+		node.tags = nil
+	}
+}
+
+// indexingScene returns the Scene whose table of Nodes by tag the Node is in, or nil if it isn't in one.
+func (node *Node) indexingScene() *Scene {
+	if scene := node.Scene(); scene != nil && scene.indexed(node.getOwner()) {
+		return scene
+	}
+	return nil
+}
+
+// HasTag returns if the Node has all of the given tags.
+func (node *Node) HasTag(tags ...string) bool {
+
+	for _, tag := range tags {
+		id, exists := lookupTag(tag)
+		if !exists || !node.hasTagID(id) {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+func (node *Node) hasTagID(id uint32) bool {
+	for _, t := range node.tags {
+		if t == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Tags returns the Node's tags.
+func (node *Node) Tags() []string {
+	tags := make([]string, 0, len(node.tags))
+	for _, id := range node.tags {
+		tags = append(tags, tagName(id))
+	}
+	return tags
+}
+
+// ByTag allows you to filter a given selection of nodes by tags - if the nodes filtered have all of the given tags
+// (see Node.AddTag()), they are included.
+// If no matching Nodes are found, an empty NodeFilter is returned.
+func (nf NodeFilter) ByTag(tags ...string) NodeFilter {
+	nf.Filters = append(nf.Filters, func(node INode) bool {
+		return node.HasTag(tags...)
+	})
+	return nf
+}
@@ -0,0 +1,433 @@
+package tetra3d
+
+import (
+	"image"
+
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// maxTerrainChunkSize is the largest number of quads along each side of a TerrainChunk; any larger, and a chunk's MeshPart would
+// exceed MaxTriangleCount.
+const maxTerrainChunkSize = 100
+
+// TerrainChunk is a square section of a Terrain, rendered through its own Model and collided against through its own BoundingTriangles.
+type TerrainChunk struct {
+	Model  *Model             // The Model rendering the TerrainChunk. It's a child of the Terrain.
+	Bounds *BoundingTriangles // The BoundingTriangles used to collide against the TerrainChunk. It's a child of the TerrainChunk's Model, or nil if the Terrain uses heightfield collision (see Terrain.SetHeightfieldCollision()).
+
+	// The index of the height samples at the corner of the TerrainChunk with the lowest X and Z values.
+	SampleX, SampleZ int
+}
+
+// Terrain is a Node representing a landscape generated from a grid of height samples (a heightmap). The Terrain is split up into
+// square chunks (see TerrainChunk), each of which is a Model with a BoundingTriangles object for collision, so that chunks that are
+// offscreen can be frustum culled and collision checks only need to test against nearby chunks. The Terrain is centered on its origin,
+// with each height sample spaced apart on the X and Z axes according to the Terrain's scale.
+//
+// Alternatively, a Terrain can collide through a single BoundingHeightfield (Terrain.Heightfield) matching its surface instead, which is
+// much faster to test against and uses far less memory than the chunks' BoundingTriangles; see Terrain.SetHeightfieldCollision().
+type Terrain struct {
+	*Node
+	Chunks []*TerrainChunk // The chunks making up the Terrain.
+	// The BoundingHeightfield matching the Terrain's surface. It's only a child of the Terrain (and so only collides) if the Terrain uses
+	// heightfield collision (see Terrain.SetHeightfieldCollision()).
+	Heightfield *BoundingHeightfield
+
+	heights              [][]float32
+	scale                Vector3
+	chunkSize            int
+	material             *Material
+	heightfieldCollision bool
+}
+
+// NewTerrain creates a new Terrain with the given name from the given height samples, organized by rows along the Z axis, and then
+// columns along the X axis (so heights[z][x]). Each row should be the same length; there should be at least two rows and columns.
+// scale controls the spacing between the height samples on the X and Z axes in world units, while scale.Y is how high a height of 1
+// is. chunkSize is how many quads make up each side of a chunk of the Terrain, ranging from 1 to 100.
+func NewTerrain(name string, heights [][]float32, scale Vector3, chunkSize int) *Terrain {
+
+	if len(heights) < 2 || len(heights[0]) < 2 {
+		panic("Error: NewTerrain() requires at least 2x2 height samples.")
+	}
+
+	for _, row := range heights {
+		if len(row) != len(heights[0]) {
+			panic("Error: NewTerrain() requires each row of height samples to be the same length.")
+		}
+	}
+
+	if chunkSize < 1 {
+		chunkSize = 1
+	} else if chunkSize > maxTerrainChunkSize {
+		chunkSize = maxTerrainChunkSize
+	}
+
+	terrain := &Terrain{
+		Node:      NewNode(name),
+		heights:   heights,
+		scale:     scale,
+		chunkSize: chunkSize,
+		material:  NewMaterial(name),
+	}
+	terrain.owner = terrain
+
+	terrain.Heightfield = NewBoundingHeightfield(name+"_heightfield", heights, scale)
+
+	terrain.generateChunks()
+
+	return terrain
+
+}
+
+// NewTerrainFromImage creates a new Terrain with the given name from a heightmap image, where brighter pixels are higher. Each pixel is a
+// height sample ranging from 0 (black) to 1 (white), with the image's Y axis running along the Terrain's Z axis. See NewTerrain() for
+// more information on the other arguments.
+func NewTerrainFromImage(name string, heightmap image.Image, scale Vector3, chunkSize int) *Terrain {
+
+	bounds := heightmap.Bounds()
+
+	heights := make([][]float32, bounds.Dy())
+
+	for z := range heights {
+		heights[z] = make([]float32, bounds.Dx())
+		for x := range heights[z] {
+			r, g, b, _ := heightmap.At(bounds.Min.X+x, bounds.Min.Y+z).RGBA()
+			heights[z][x] = (0.299*float32(r) + 0.587*float32(g) + 0.114*float32(b)) / 0xffff
+		}
+	}
+
+	return NewTerrain(name, heights, scale, chunkSize)
+
+}
+
+// Clone creates a clone of the Terrain, including its chunks. The clone shares its height samples, Meshes, and Material with the original.
+func (terrain *Terrain) Clone() INode {
+
+	clone := &Terrain{
+		heights:              terrain.heights,
+		scale:                terrain.scale,
+		chunkSize:            terrain.chunkSize,
+		material:             terrain.material,
+		heightfieldCollision: terrain.heightfieldCollision,
+	}
+
+	clone.Node = terrain.Node.clone(clone).(*Node)
+
+	for i, child := range terrain.children {
+		if child == terrain.Heightfield {
+			clone.Heightfield = clone.children[i].(*BoundingHeightfield)
+			break
+		}
+	}
+
+	// The Heightfield is also used for height queries, so the clone needs one even if it isn't one of the original's children.
+	if clone.Heightfield == nil {
+		clone.Heightfield = NewBoundingHeightfield(terrain.name+"_heightfield", terrain.heights, terrain.scale)
+	}
+
+	// The chunks' Models are cloned along with the rest of the Terrain's children, so we find them again by their indices.
+	for _, chunk := range terrain.Chunks {
+
+		newChunk := &TerrainChunk{
+			SampleX: chunk.SampleX,
+			SampleZ: chunk.SampleZ,
+		}
+
+		for i, child := range terrain.children {
+			if child == chunk.Model {
+				newChunk.Model = clone.children[i].(*Model)
+				break
+			}
+		}
+
+		if newChunk.Model != nil {
+			for i, child := range chunk.Model.children {
+				if child == chunk.Bounds {
+					newChunk.Bounds = newChunk.Model.children[i].(*BoundingTriangles)
+					break
+				}
+			}
+		}
+
+		clone.Chunks = append(clone.Chunks, newChunk)
+
+	}
+
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+
+	return clone
+
+}
+
+// sampleCounts returns the number of height samples along the X and Z axes.
+func (terrain *Terrain) sampleCounts() (int, int) {
+	return len(terrain.heights[0]), len(terrain.heights)
+}
+
+// sample returns the height sample at the given indices, clamped to the edges of the Terrain.
+func (terrain *Terrain) sample(x, z int) float32 {
+
+	countX, countZ := terrain.sampleCounts()
+
+	if x < 0 {
+		x = 0
+	} else if x >= countX {
+		x = countX - 1
+	}
+
+	if z < 0 {
+		z = 0
+	} else if z >= countZ {
+		z = countZ - 1
+	}
+
+	return terrain.heights[z][x]
+
+}
+
+// samplePosition returns the local position of the height sample at the given indices.
+func (terrain *Terrain) samplePosition(x, z int) Vector3 {
+	return Vector3{
+		float32(x)*terrain.scale.X - terrain.Width()/2,
+		terrain.sample(x, z) * terrain.scale.Y,
+		float32(z)*terrain.scale.Z - terrain.Depth()/2,
+	}
+}
+
+// sampleNormal returns the local normal of the height sample at the given indices, calculated from the samples surrounding it.
+func (terrain *Terrain) sampleNormal(x, z int) Vector3 {
+
+	countX, countZ := terrain.sampleCounts()
+
+	left, right := math32.Max(x-1, 0), math32.Min(x+1, countX-1)
+	back, forward := math32.Max(z-1, 0), math32.Min(z+1, countZ-1)
+
+	slopeX := (terrain.sample(right, z) - terrain.sample(left, z)) * terrain.scale.Y / (float32(right-left) * terrain.scale.X)
+	slopeZ := (terrain.sample(x, forward) - terrain.sample(x, back)) * terrain.scale.Y / (float32(forward-back) * terrain.scale.Z)
+
+	return Vector3{-slopeX, 1, -slopeZ}.Unit()
+
+}
+
+// generateChunks (re)creates the chunks making up the Terrain.
+func (terrain *Terrain) generateChunks() {
+
+	for _, chunk := range terrain.Chunks {
+		terrain.RemoveChildren(chunk.Model)
+	}
+
+	terrain.Chunks = terrain.Chunks[:0]
+
+	countX, countZ := terrain.sampleCounts()
+
+	for startZ := 0; startZ < countZ-1; startZ += terrain.chunkSize {
+
+		for startX := 0; startX < countX-1; startX += terrain.chunkSize {
+
+			endX := math32.Min(startX+terrain.chunkSize, countX-1)
+			endZ := math32.Min(startZ+terrain.chunkSize, countZ-1)
+
+			chunk := &TerrainChunk{
+				SampleX: startX,
+				SampleZ: startZ,
+			}
+
+			// Each chunk is positioned at its center so that it can be frustum culled properly.
+			center := terrain.samplePosition(startX, startZ).Add(terrain.samplePosition(endX, endZ)).Scale(0.5)
+			center.Y = 0
+
+			mesh := terrain.generateChunkMesh(startX, startZ, endX, endZ, center)
+
+			chunk.Model = NewModel(terrain.name+"_chunk", mesh)
+			chunk.Model.SetLocalPositionVec(center)
+			terrain.AddChildren(chunk.Model)
+
+			if !terrain.heightfieldCollision {
+				terrain.addChunkBounds(chunk)
+			}
+
+			terrain.Chunks = append(terrain.Chunks, chunk)
+
+		}
+
+	}
+
+}
+
+// generateChunkMesh creates the Mesh for the chunk covering the height samples between the given indices (inclusive), with its
+// vertices relative to the given center.
+func (terrain *Terrain) generateChunkMesh(startX, startZ, endX, endZ int, center Vector3) *Mesh {
+
+	countX, countZ := terrain.sampleCounts()
+
+	width := endX - startX + 1
+
+	verts := make([]VertexInfo, 0, width*(endZ-startZ+1))
+
+	for z := startZ; z <= endZ; z++ {
+
+		for x := startX; x <= endX; x++ {
+
+			pos := terrain.samplePosition(x, z).Sub(center)
+			normal := terrain.sampleNormal(x, z)
+
+			v := NewVertex(pos.X, pos.Y, pos.Z, float32(x)/float32(countX-1), float32(z)/float32(countZ-1))
+			v.NormalX = normal.X
+			v.NormalY = normal.Y
+			v.NormalZ = normal.Z
+			verts = append(verts, v)
+
+		}
+
+	}
+
+	mesh := NewMesh(terrain.name+"_chunk", verts...)
+
+	indices := make([]int, 0, (width-1)*(endZ-startZ)*6)
+
+	for z := 0; z < endZ-startZ; z++ {
+
+		for x := 0; x < width-1; x++ {
+
+			quad := z*width + x
+
+			indices = append(indices,
+				quad+1, quad+width, quad+width+1,
+				quad, quad+width, quad+1,
+			)
+
+		}
+
+	}
+
+	mesh.AddMeshPart(terrain.material, indices...)
+	mesh.UpdateBounds()
+
+	return mesh
+
+}
+
+// Material returns the Material shared by all of the Terrain's chunks.
+func (terrain *Terrain) Material() *Material {
+	return terrain.material
+}
+
+// Scale returns the scale of the Terrain's height samples; X and Z are the spacing between the samples, and Y is how high a height of 1 is.
+func (terrain *Terrain) Scale() Vector3 {
+	return terrain.scale
+}
+
+// Width returns the width of the Terrain on the X axis in local units.
+func (terrain *Terrain) Width() float32 {
+	countX, _ := terrain.sampleCounts()
+	return float32(countX-1) * terrain.scale.X
+}
+
+// Depth returns the depth of the Terrain on the Z axis in local units.
+func (terrain *Terrain) Depth() float32 {
+	_, countZ := terrain.sampleCounts()
+	return float32(countZ-1) * terrain.scale.Z
+}
+
+// addChunkBounds creates the BoundingTriangles used to collide against the given chunk.
+func (terrain *Terrain) addChunkBounds(chunk *TerrainChunk) {
+	chunk.Bounds = NewBoundingTriangles(terrain.name+"_chunk_bounds", chunk.Model.Mesh, math32.Max(terrain.scale.X, terrain.scale.Z)*8)
+	chunk.Model.AddChildren(chunk.Bounds)
+}
+
+// SetHeightfieldCollision sets whether the Terrain collides through its Heightfield rather than through its chunks' BoundingTriangles
+// (the default). When on, the Heightfield is added as a child of the Terrain and the chunks' BoundingTriangles are removed (freeing the
+// memory used by the triangles and their broadphases), so that collision tests against the Terrain only ever hit one or the other.
+// Turning it back off creates the chunks' BoundingTriangles again.
+func (terrain *Terrain) SetHeightfieldCollision(on bool) {
+
+	if terrain.heightfieldCollision == on {
+		return
+	}
+
+	terrain.heightfieldCollision = on
+
+	if on {
+		terrain.AddChildren(terrain.Heightfield)
+		for _, chunk := range terrain.Chunks {
+			if chunk.Bounds != nil {
+				chunk.Model.RemoveChildren(chunk.Bounds)
+				chunk.Bounds = nil
+			}
+		}
+	} else {
+		terrain.RemoveChildren(terrain.Heightfield)
+		for _, chunk := range terrain.Chunks {
+			if chunk.Bounds == nil {
+				terrain.addChunkBounds(chunk)
+			}
+		}
+	}
+
+}
+
+// HeightfieldCollision returns whether the Terrain collides through its Heightfield rather than through its chunks' BoundingTriangles.
+func (terrain *Terrain) HeightfieldCollision() bool {
+	return terrain.heightfieldCollision
+}
+
+// localHeight returns the local height of the Terrain's surface at the given local X and Z position, along with a boolean indicating if the
+// position is within the Terrain's bounds. The height is interpolated across the triangles the Terrain's chunks are made of.
+func (terrain *Terrain) localHeight(x, z float32) (float32, bool) {
+	return terrain.Heightfield.localHeight(x, z)
+}
+
+// HeightAt returns the world height (Y position) of the Terrain's surface directly above or below the given world position, along with
+// a boolean indicating if the position is within the Terrain's bounds. This is faster than casting a ray against the Terrain's chunks.
+// Note that this is only exact if the Terrain isn't rotated on its X or Z axes.
+func (terrain *Terrain) HeightAt(position Vector3) (float32, bool) {
+
+	transform := terrain.Transform()
+	local := transform.Inverted().MultVec(position)
+
+	height, ok := terrain.localHeight(local.X, local.Z)
+	if !ok {
+		return 0, false
+	}
+
+	return transform.MultVec(Vector3{local.X, height, local.Z}).Y, true
+
+}
+
+// NormalAt returns the world normal of the Terrain's surface directly above or below the given world position, interpolated between the
+// height samples surrounding it. If the position is outside of the Terrain's bounds, the world up direction of the Terrain is returned.
+func (terrain *Terrain) NormalAt(position Vector3) Vector3 {
+
+	local := terrain.Transform().Inverted().MultVec(position)
+
+	countX, countZ := terrain.sampleCounts()
+
+	fx := (local.X + terrain.Width()/2) / terrain.scale.X
+	fz := (local.Z + terrain.Depth()/2) / terrain.scale.Z
+
+	normal := Vector3{0, 1, 0}
+
+	if fx >= 0 && fz >= 0 && fx <= float32(countX-1) && fz <= float32(countZ-1) {
+
+		cellX := math32.Min(int(fx), countX-2)
+		cellZ := math32.Min(int(fz), countZ-2)
+
+		fx -= float32(cellX)
+		fz -= float32(cellZ)
+
+		top := terrain.sampleNormal(cellX, cellZ).Lerp(terrain.sampleNormal(cellX+1, cellZ), fx)
+		bottom := terrain.sampleNormal(cellX, cellZ+1).Lerp(terrain.sampleNormal(cellX+1, cellZ+1), fx)
+		normal = top.Lerp(bottom, fz).Unit()
+
+	}
+
+	return terrain.WorldRotation().MultVec(normal).Unit()
+
+}
+
+// Type returns the NodeType for this object.
+func (terrain *Terrain) Type() NodeType {
+	return NodeTypeTerrain
+}
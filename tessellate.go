@@ -0,0 +1,183 @@
+package tetra3d
+
+import "log"
+
+// maxTessellationPasses is the maximum number of times Mesh.Tessellate() splits the edges of a Mesh's triangles in half.
+const maxTessellationPasses = 8
+
+// Tessellate subdivides the triangles of the Mesh until none of their edges are longer than maxEdgeLength (in the Mesh's local space).
+// This is useful for large, flat surfaces like floors and walls; because lighting is calculated per-vertex, big triangles light poorly
+// (i.e. a PointLight in the middle of a floor made of two triangles doesn't light it at all), and because texturing is affine (unless
+// Camera.PerspectiveCorrectedTextureMapping is on), textures on big triangles warp noticeably as the Camera moves, PS1-style.
+// Each pass splits every edge that's too long in half, so each edge is split at most 8 times; new vertices are interpolated from the
+// vertices of the edges they split (with bone weights being copied from the first vertex). As edges are split by their positions alone,
+// neighboring triangles remain connected (no cracks appear between them).
+// Note that as the Mesh's vertex buffers are rebuilt, the vertex indices of the Mesh change, and so Tessellate() should be called before
+// any vertex selections are made; also note that a single MeshPart can't render more than 65535 vertices or MaxTriangleCount triangles,
+// so tessellation stops for a MeshPart if splitting its edges further would exceed that.
+func (mesh *Mesh) Tessellate(maxEdgeLength float32) {
+
+	if maxEdgeLength <= 0 || len(mesh.Triangles) == 0 {
+		return
+	}
+
+	parts := mesh.partData()
+
+	for _, t := range parts {
+		if len(t.indices) > 0 && !t.tessellate(maxEdgeLength*maxEdgeLength) {
+			log.Println("warning: mesh [" + mesh.Name + "] could not be fully tessellated, as a MeshPart would exceed the maximum number of renderable vertices or triangles.")
+		}
+	}
+
+	mesh.rebuildParts(parts)
+
+}
+
+// tessellate splits the edges of the triangles until none of them are longer than the square root of maxEdgeLengthSquared,
+// returning false if this couldn't be done without exceeding the limits of a MeshPart.
+func (t *meshPartData) tessellate(maxEdgeLengthSquared float32) bool {
+
+	for pass := 0; pass < maxTessellationPasses; pass++ {
+
+		// First, every edge that's too long is found, so that we know if there's room to split them all. Edges are keyed by their
+		// sorted vertex indices so that triangles sharing an edge share the vertex that splits it.
+		midpoints := map[[2]int]int{}
+
+		for i := 0; i < len(t.indices); i += 3 {
+			for e := 0; e < 3; e++ {
+				a, b := t.indices[i+e], t.indices[i+(e+1)%3]
+				if t.edgeLengthSquared(a, b) > maxEdgeLengthSquared {
+					midpoints[tessellationEdge(a, b)] = -1
+				}
+			}
+		}
+
+		if len(midpoints) == 0 {
+			return true
+		}
+
+		// Each split edge adds a vertex, along with a triangle for each triangle sharing it
+		if len(t.verts)+len(midpoints) > 65535 || len(t.indices)/3+len(midpoints)*2 >= MaxTriangleCount {
+			return false
+		}
+
+		next := make([]int, 0, len(t.indices)*2)
+
+		for i := 0; i < len(t.indices); i += 3 {
+
+			a, b, c := t.indices[i], t.indices[i+1], t.indices[i+2]
+			ab, bc, ca := t.midpoint(midpoints, a, b), t.midpoint(midpoints, b, c), t.midpoint(midpoints, c, a)
+
+			splits := 0
+			for _, m := range []int{ab, bc, ca} {
+				if m >= 0 {
+					splits++
+				}
+			}
+
+			// The triangle is rotated (keeping its winding order) so that the first edge is split, and, if two edges are split, the
+			// last edge isn't.
+			if splits == 1 || splits == 2 {
+				for ab < 0 || (splits == 2 && ca >= 0) {
+					a, b, c = b, c, a
+					ab, bc, ca = bc, ca, ab
+				}
+			}
+
+			switch splits {
+
+			case 0:
+				next = append(next, a, b, c)
+
+			case 1:
+				next = append(next, a, ab, c, ab, b, c)
+
+			case 2:
+				next = append(next, ab, b, bc)
+				// The remaining quad is split along its shorter diagonal
+				if t.edgeLengthSquared(a, bc) <= t.edgeLengthSquared(ab, c) {
+					next = append(next, a, ab, bc, a, bc, c)
+				} else {
+					next = append(next, a, ab, c, ab, bc, c)
+				}
+
+			case 3:
+				next = append(next, a, ab, ca, ab, b, bc, ca, bc, c, ab, bc, ca)
+
+			}
+
+		}
+
+		t.indices = next
+
+	}
+
+	return true
+
+}
+
+// midpoint returns the index of the vertex splitting the edge between the vertices at the given indices, creating it if necessary,
+// or -1 if the edge isn't split.
+func (t *meshPartData) midpoint(midpoints map[[2]int]int, a, b int) int {
+
+	edge := tessellationEdge(a, b)
+
+	index, exists := midpoints[edge]
+	if !exists {
+		return -1
+	}
+
+	if index < 0 {
+		index = len(t.verts)
+		t.verts = append(t.verts, lerpVertexInfo(t.verts[edge[0]], t.verts[edge[1]]))
+		midpoints[edge] = index
+	}
+
+	return index
+
+}
+
+// edgeLengthSquared returns the squared distance between the vertices at the given indices.
+func (t *meshPartData) edgeLengthSquared(a, b int) float32 {
+	va, vb := t.verts[a], t.verts[b]
+	return Vector3{va.X, va.Y, va.Z}.DistanceSquared(Vector3{vb.X, vb.Y, vb.Z})
+}
+
+// tessellationEdge returns the key for the edge between the vertices at the given indices, regardless of their order.
+func tessellationEdge(a, b int) [2]int {
+	if a > b {
+		return [2]int{b, a}
+	}
+	return [2]int{a, b}
+}
+
+// lerpVertexInfo returns a vertex halfway between the two given vertices. The new vertex's bones and weights are copied from the first vertex.
+func lerpVertexInfo(a, b VertexInfo) VertexInfo {
+
+	normal := Vector3{a.NormalX + b.NormalX, a.NormalY + b.NormalY, a.NormalZ + b.NormalZ}.Unit()
+
+	v := VertexInfo{
+		X:       (a.X + b.X) / 2,
+		Y:       (a.Y + b.Y) / 2,
+		Z:       (a.Z + b.Z) / 2,
+		U:       (a.U + b.U) / 2,
+		V:       (a.V + b.V) / 2,
+		NormalX: normal.X,
+		NormalY: normal.Y,
+		NormalZ: normal.Z,
+		Bones:   a.Bones,
+		Weights: a.Weights,
+		Colors:  make([]Color, 0, len(a.Colors)),
+	}
+
+	for i := range a.Colors {
+		if i < len(b.Colors) {
+			v.Colors = append(v.Colors, a.Colors[i].Lerp(b.Colors[i], 0.5))
+		} else {
+			v.Colors = append(v.Colors, a.Colors[i])
+		}
+	}
+
+	return v
+
+}
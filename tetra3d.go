@@ -4,6 +4,7 @@ package tetra3d
 
 import (
 	"image/color"
+	"sync"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
@@ -14,13 +15,69 @@ var defaultImg = ebiten.NewImage(1, 1)
 
 const MaxTriangleCount = 21845
 
-var colorVertexList = make([]ebiten.Vertex, MaxTriangleCount*3)
-var normalVertexList = make([]ebiten.Vertex, MaxTriangleCount*3)
-var depthVertexList = make([]ebiten.Vertex, MaxTriangleCount*3)
-var indexList = make([]uint16, MaxTriangleCount*3)
-var vertexListIndex = 0
-var indexListIndex = 0
-var indexListStart = 0
+// renderBuffers holds the scratch buffers a Camera uses while rendering: the vertex and index lists sent to the GPU, the
+// triangle sorting bucket, and the lists of Models and Lights being rendered. Each Camera grabs a set from renderBufferPool
+// when it begins rendering and returns it once it's done, so Cameras don't share buffers (and so can render at the same
+// time), but also don't each hold onto several megabytes of vertices when they aren't rendering.
+type renderBuffers struct {
+	colorVertexList  []ebiten.Vertex
+	normalVertexList []ebiten.Vertex
+	depthVertexList  []ebiten.Vertex
+	indexList        []uint16
+	vertexListIndex  int
+	indexListIndex   int
+	indexListStart   int
+
+	sortingTriangles *sortingTriangleBucket
+
+	models      []*Model
+	lights      []ILight
+	sceneLights []ILight
+
+	renderingSectors []*Sector // The Sectors being rendered when rendering with sectors
+
+	windScene *Scene // The Scene that windZones were gathered from for swaying Models
+	windZones []*WindZone
+
+	pixelLights []ILight // The lights sorted for lighting a MeshPart per-pixel
+
+	callbacks []func() // User callbacks queued while the render lock was held, to be called once it's released
+}
+
+// runCallbacks calls the user callbacks queued while rendering. The render lock must not be held, so that the callbacks can render,
+// bake, or ray test against skinned Models themselves.
+func (buffers *renderBuffers) runCallbacks() {
+	for len(buffers.callbacks) > 0 {
+		callback := buffers.callbacks[0]
+		buffers.callbacks[0] = nil
+		buffers.callbacks = buffers.callbacks[1:]
+		callback()
+	}
+	buffers.callbacks = buffers.callbacks[:0]
+}
+
+func newRenderBuffers() *renderBuffers {
+	buffers := &renderBuffers{
+		colorVertexList:  make([]ebiten.Vertex, MaxTriangleCount*3),
+		normalVertexList: make([]ebiten.Vertex, MaxTriangleCount*3),
+		depthVertexList:  make([]ebiten.Vertex, MaxTriangleCount*3),
+		indexList:        make([]uint16, MaxTriangleCount*3),
+		sortingTriangles: newSortingTriangleBucket(),
+	}
+	buffers.sortingTriangles.Resize(512)
+	return buffers
+}
+
+var renderBufferPool = sync.Pool{
+	New: func() any { return newRenderBuffers() },
+}
+
+// renderLock guards the per-render state stored on shared objects while a Camera processes them - the transformed vertices
+// and lighting of Meshes, the working state of Lights, and the cached transforms of Nodes. Cameras hold it while preparing each
+// MeshPart's vertices, but not while submitting them for drawing, which happens out of each Camera's own buffers. This means
+// Cameras can render Scenes that share Meshes and Materials (i.e. from the same Library) from multiple goroutines or game contexts
+// without corrupting each other's output.
+var renderLock sync.Mutex
 
 func init() {
 	defaultImg.Fill(color.White)
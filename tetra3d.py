@@ -68,6 +68,19 @@ materialTransparencyModes = [
     ("TRANSPARENT", "Transparent", "Partial transparency. Renders after all opaque objects and is sorted from back-to-front", 0, 3),
 ]
 
+materialDepthWriteModes = [
+    ("AUTO", "Auto", "The material writes to the depth buffer unless it's transparent", 0, 0),
+    ("ON", "On", "The material always writes to the depth buffer, even if it's transparent", 0, 1),
+    ("OFF", "Off", "The material never writes to the depth buffer, so it doesn't obscure anything rendered after it; useful for skyboxes and overlays", 0, 2),
+]
+
+materialDepthTestModes = [
+    ("LESS", "Less", "The material renders where it's closer to the camera than what's already been rendered", 0, 0),
+    ("ALWAYS", "Always", "The material renders regardless of what's already been rendered; useful for overlays", 0, 1),
+    ("NEVER", "Never", "The material never renders", 0, 2),
+    ("GREATER", "Greater", "The material renders only where it's further from the camera than what's already been rendered; useful for x-ray silhouettes", 0, 3),
+]
+
 materialBillboardModes = [
     ("NONE", "None", "No billboarding - the (unskinned) object with this material does not rotate to face the camera.", 0, 0),
     ("FIXEDVERTICAL", "Fixed Vertical", "Fixed Vertical billboarding - the (unskinned) object with this material faces the camera, with up always pointing towards the camera's local up vector (+Y). Good for top-down games.", 0, 1),
@@ -107,6 +120,13 @@ gamePropTypes = [
     ("directory", "Directory Path", "Directory Path as a string", 0, 8),
 ]
 
+particleEmissionShapes = [
+    ("NONE", "None", "Particles spawn at the center of the object", 0, 0),
+    ("SPHERE", "Sphere", "Particles spawn within (or on the surface of) a sphere around the object, heading outwards", 0, 1),
+    ("CONE", "Cone", "Particles spawn heading upwards (+Z) out along a cone extending from the object", 0, 2),
+    ("BOX", "Box", "Particles spawn within a box around the object", 0, 3),
+]
+
 batchModes = [ 
     ("OFF", "Off", "No automatic batching.", 0, 0), 
     ("DYNAMIC", "Dynamic Batching", "Dynamic batching based off of one material (the first one).", 0, 1), 
@@ -829,6 +849,88 @@ class OBJECT_PT_tetra3d(bpy.types.Panel):
             clear.mode = "object"
 
 
+class OBJECT_PT_tetra3d_particles(bpy.types.Panel):
+    bl_idname = "OBJECT_PT_tetra3d_particles"
+    bl_label = "Tetra3d Particle System"
+    bl_space_type = 'PROPERTIES'
+    bl_region_type = 'WINDOW'
+    bl_context = "object"
+    bl_options = {'DEFAULT_CLOSED'}
+
+    @classmethod
+    def poll(self,context):
+        return context.object is not None and context.object.type in ("MESH", "EMPTY")
+
+    def draw_header(self, context):
+        self.layout.prop(context.object, "t3dParticleSystem__", text="")
+
+    def draw(self, context):
+
+        obj = context.object
+
+        layout = self.layout
+        layout.enabled = obj.t3dParticleSystem__
+
+        layout.prop(obj, "t3dParticleCollection__")
+
+        box = layout.box()
+        box.label(text="Spawning")
+        box.prop(obj, "t3dParticleSpawnRate__")
+        box.prop(obj, "t3dParticleSpawnCount__")
+        box.prop(obj, "t3dParticleLifetime__")
+        box.prop(obj, "t3dParticleLocalPosition__")
+        box.prop(obj, "t3dParticlePrewarm__")
+        box.prop(obj, "t3dParticleOneShot__")
+
+        row = box.row()
+        row.prop(obj, "t3dParticleSpawnOffsetMin__")
+        row.prop(obj, "t3dParticleSpawnOffsetMax__")
+
+        box.prop(obj, "t3dParticleEmissionShape__")
+
+        shape = obj.t3dParticleEmissionShape__
+        if shape == "SPHERE":
+            box.prop(obj, "t3dParticleEmissionRadius__")
+            box.prop(obj, "t3dParticleEmissionSurface__")
+        elif shape == "CONE":
+            box.prop(obj, "t3dParticleEmissionRadius__")
+            box.prop(obj, "t3dParticleEmissionAngle__")
+        elif shape == "BOX":
+            box.prop(obj, "t3dParticleEmissionSize__")
+
+        if shape != "NONE":
+            box.prop(obj, "t3dParticleEmissionSpeed__")
+
+        box = layout.box()
+        box.label(text="Movement (per frame)")
+
+        for label, propName in (("Velocity", "t3dParticleVelocity"), ("Acceleration", "t3dParticleVelocityAdd"), ("Rotation", "t3dParticleRotationAdd")):
+            box.label(text=label + ":")
+            row = box.row()
+            row.prop(obj, propName + "Min__")
+            row.prop(obj, propName + "Max__")
+
+        box.prop(obj, "t3dParticleFriction__")
+
+        box = layout.box()
+        box.label(text="Scale")
+        row = box.row()
+        row.prop(obj, "t3dParticleScaleMin__")
+        row.prop(obj, "t3dParticleScaleMax__")
+        box.prop(obj, "t3dParticleScaleUniform__")
+        box.label(text="Growth (per frame):")
+        row = box.row()
+        row.prop(obj, "t3dParticleScaleAddMin__")
+        row.prop(obj, "t3dParticleScaleAddMax__")
+
+        box = layout.box()
+        box.prop(obj, "t3dParticleColorCurve__")
+        if obj.t3dParticleColorCurve__:
+            row = box.row()
+            row.prop(obj, "t3dParticleColorStart__")
+            row.prop(obj, "t3dParticleColorEnd__")
+
+
 class SCENE_PT_tetra3d(bpy.types.Panel):
     bl_idname = "SCENE_PT_tetra3d"
     bl_label = "Tetra3d Scene Properties"
@@ -1008,6 +1110,12 @@ class MATERIAL_PT_tetra3d(bpy.types.Panel):
         row.label(text="Transparency Mode:")
         row.prop(context.material, "t3dTransparencyMode__", text="")
         row = self.layout.row()
+        row.label(text="Depth Write:")
+        row.prop(context.material, "t3dDepthWrite__", text="")
+        row = self.layout.row()
+        row.label(text="Depth Test:")
+        row.prop(context.material, "t3dDepthTest__", text="")
+        row = self.layout.row()
         row.label(text="Blend Mode:")
         row.prop(context.material, "t3dBlendMode__", text="")
         row = self.layout.row()
@@ -1032,8 +1140,12 @@ class MATERIAL_PT_tetra3d(bpy.types.Panel):
         row.enabled = context.material.t3dCustomDepthOn__
         row.prop(context.material, "t3dCustomDepthValue__")
         row = box.row()
+        row.prop(context.material, "t3dSoftParticleDistance__")
+        row = box.row()
         row.label(text="Lighting Mode:")
         row.prop(context.material, "t3dMaterialLightingMode__", text="")
+        row = box.row()
+        row.prop(context.material, "t3dPerPixelLighting__")
 
         if context.object.active_material != None:
 
@@ -1097,6 +1209,132 @@ def globalSet(propName, value):
 def globalDel(propName):
     del bpy.data.scenes[0][propName]
 
+# getVisibilityKeys returns the times (in seconds) and values of the hide / show keyframes in the given action, or None if it has none.
+def getVisibilityKeys(action, fps):
+
+    curves = [fcurve for fcurve in action.fcurves if fcurve.data_path in ("hide_viewport", "hide_render", "t3dVisible__")]
+
+    if len(curves) == 0:
+        return None
+
+    frames = sorted(set(point.co[0] for fcurve in curves for point in fcurve.keyframe_points))
+
+    keys = []
+
+    for frame in frames:
+        visible = True
+        for fcurve in curves:
+            value = fcurve.evaluate(frame) > 0.5
+            if fcurve.data_path == "t3dVisible__":
+                visible = visible and value
+            else:
+                visible = visible and not value
+        keys.append([frame / fps, 1 if visible else 0])
+
+    return keys
+
+# getParticleSettings returns the particle system settings set on the given object, converted to Tetra3D's Y-up coordinate system.
+def getParticleSettings(obj):
+
+    def toYUp(vec):
+        return [vec[0], vec[2], -vec[1]]
+
+    # Negating the Y axis flips which end of the range is the minimum
+    def rangeToYUp(minVec, maxVec):
+        return [[minVec[0], minVec[2], -maxVec[1]], [maxVec[0], maxVec[2], -minVec[1]]]
+
+    def scaleRange(minVec, maxVec):
+        return [[minVec[0], minVec[2], minVec[1]], [maxVec[0], maxVec[2], maxVec[1]]]
+
+    factories = []
+    if obj.t3dParticleCollection__:
+        factories = [o.name for o in obj.t3dParticleCollection__.all_objects if o.type == "MESH"]
+
+    settings = {
+        "factories" : factories,
+        "spawnRate" : list(obj.t3dParticleSpawnRate__),
+        "spawnCount" : list(obj.t3dParticleSpawnCount__),
+        "lifetime" : list(obj.t3dParticleLifetime__),
+        "velocity" : rangeToYUp(obj.t3dParticleVelocityMin__, obj.t3dParticleVelocityMax__),
+        "velocityAdd" : rangeToYUp(obj.t3dParticleVelocityAddMin__, obj.t3dParticleVelocityAddMax__),
+        "rotationAdd" : rangeToYUp(obj.t3dParticleRotationAddMin__, obj.t3dParticleRotationAddMax__),
+        "spawnOffset" : rangeToYUp(obj.t3dParticleSpawnOffsetMin__, obj.t3dParticleSpawnOffsetMax__),
+        "scale" : scaleRange(obj.t3dParticleScaleMin__, obj.t3dParticleScaleMax__),
+        "scaleUniform" : obj.t3dParticleScaleUniform__,
+        "scaleAdd" : scaleRange(obj.t3dParticleScaleAddMin__, obj.t3dParticleScaleAddMax__),
+        "friction" : obj.t3dParticleFriction__,
+        "localPosition" : obj.t3dParticleLocalPosition__,
+        "prewarm" : obj.t3dParticlePrewarm__,
+        "oneShot" : obj.t3dParticleOneShot__,
+    }
+
+    shape = obj.t3dParticleEmissionShape__
+    if shape != "NONE":
+        size = obj.t3dParticleEmissionSize__
+        settings["emission"] = {
+            "shape" : {"SPHERE" : 1, "CONE" : 2, "BOX" : 3}[shape],
+            "radius" : obj.t3dParticleEmissionRadius__,
+            "surface" : obj.t3dParticleEmissionSurface__,
+            "angle" : obj.t3dParticleEmissionAngle__,
+            "size" : [size[0], size[2], size[1]],
+            "speed" : list(obj.t3dParticleEmissionSpeed__),
+        }
+
+    if obj.t3dParticleColorCurve__:
+        settings["colors"] = [list(obj.t3dParticleColorStart__), list(obj.t3dParticleColorEnd__)]
+
+    return settings
+
+# sampleMaterialAction samples the material values keyed in the given action (the Tetra3D material color, the alpha and emission strength
+# of shader nodes, and custom properties) on each frame, so the animation can be played back on the Material in Tetra3D.
+def sampleMaterialAction(material, action, fps):
+
+    colorCurves = {}
+    propertyCurves = {}
+
+    for fcurve in action.fcurves:
+
+        path = fcurve.data_path
+
+        if path == "t3dMaterialColor__":
+            colorCurves[fcurve.array_index] = fcurve
+        elif path.startswith('["') and path.endswith('"]'):
+            propertyCurves[path[2:-2]] = fcurve
+        elif path.startswith("nodes[") and path.endswith(".default_value") and material.node_tree:
+            try:
+                socket = material.node_tree.path_resolve(path[:-len(".default_value")])
+            except ValueError:
+                continue
+            if socket.name == "Alpha":
+                colorCurves[3] = fcurve
+            elif socket.name == "Emission Strength":
+                propertyCurves["emission"] = fcurve
+
+    if len(colorCurves) == 0 and len(propertyCurves) == 0:
+        return None
+
+    start, end = int(action.frame_range[0]), int(action.frame_range[1])
+
+    animation = {
+        "name" : action.name,
+    }
+
+    if len(colorCurves) > 0:
+        keys = []
+        for frame in range(start, end+1):
+            color = list(material.t3dMaterialColor__)
+            for index, fcurve in colorCurves.items():
+                color[index] = fcurve.evaluate(frame)
+            keys.append([frame / fps] + color)
+        animation["color"] = keys
+
+    if len(propertyCurves) > 0:
+        animation["properties"] = {}
+        for name, fcurve in propertyCurves.items():
+            animation["properties"][name] = [[frame / fps, fcurve.evaluate(frame)] for frame in range(start, end+1)]
+
+    return animation
+
 class RENDER_PT_tetra3d(bpy.types.Panel):
     bl_idname = "RENDER_PT_tetra3d"
     bl_label = "Tetra3D Render Properties"
@@ -1298,6 +1536,23 @@ def export():
             if scene.world:
                 scene["t3dCurrentWorld__"] = scene.world.name
 
+            # Export the scene's collection hierarchy, so collections can be loaded as Groups
+            collectionTree = {}
+
+            def addCollections(parent, parentName):
+                for index, child in enumerate(parent.children):
+                    collectionTree[child.name] = {
+                        "objects" : [o.name for o in child.objects if o.parent is None],
+                        "parent" : parentName,
+                        "index" : index,
+                        "enabled" : not child.hide_viewport,
+                    }
+                    addCollections(child, child.name)
+
+            addCollections(scene.collection, "")
+
+            scene["t3dCollectionTree__"] = collectionTree
+
             for layer in scene.view_layers:
                 for obj in layer.objects:
                     if obj.animation_data:
@@ -1309,6 +1564,112 @@ def export():
 
                     obj["t3dOriginalLocalPosition__"] = obj.location
 
+                    if obj.t3dParticleSystem__ and obj.type in ("MESH", "EMPTY"):
+                        obj["t3dParticleSettings__"] = getParticleSettings(obj)
+
+                    if obj.animation_data:
+
+                        actions = [strip.action for track in obj.animation_data.nla_tracks.values() for strip in track.strips.values() if strip.action]
+
+                        if obj.animation_data.action:
+                            obj["t3dCurrentAction__"] = obj.animation_data.action.name
+                            actions.append(obj.animation_data.action)
+
+                        visibilityKeys = {}
+                        for action in actions:
+                            keys = getVisibilityKeys(action, globalGet("t3dPlaybackFPS__", 60))
+                            if keys:
+                                visibilityKeys[action.name] = keys
+
+                        if len(visibilityKeys) > 0:
+                            obj["t3dVisibilityKeys__"] = visibilityKeys
+
+                        # Export the NLA tracks so they can be composed into a single animation
+                        fps = globalGet("t3dPlaybackFPS__", 60)
+                        nlaTracks = []
+                        for track in obj.animation_data.nla_tracks.values():
+                            strips = []
+                            for strip in track.strips.values():
+                                if strip.action is None:
+                                    continue
+                                strips.append({
+                                    "action" : strip.action.name,
+                                    "start" : strip.frame_start / fps,
+                                    "actionStart" : strip.action_frame_start / fps,
+                                    "actionEnd" : strip.action_frame_end / fps,
+                                    "scale" : strip.scale,
+                                    "repeat" : strip.repeat,
+                                    "blendMode" : {"REPLACE" : 0, "ADD" : 1, "COMBINE" : 2}.get(strip.blend_type, 0),
+                                    "influence" : strip.influence if strip.use_animated_influence else 1,
+                                    "blendIn" : strip.blend_in / fps,
+                                    "blendOut" : strip.blend_out / fps,
+                                    "hold" : strip.extrapolation in ("HOLD", "HOLD_FORWARD"),
+                                })
+                            if len(strips) > 0:
+                                nlaTracks.append({"name" : track.name, "muted" : track.mute, "strips" : strips})
+
+                        if len(nlaTracks) > 0:
+                            obj["t3dNLATracks__"] = nlaTracks
+
+                    # Export the supported bone constraints, so they can be applied after animating in Tetra3D
+                    if obj.type == "ARMATURE" and obj.pose:
+
+                        trackAxes = {
+                            "TRACK_X" : [1, 0, 0], "TRACK_Y" : [0, 1, 0], "TRACK_Z" : [0, 0, 1],
+                            "TRACK_NEGATIVE_X" : [-1, 0, 0], "TRACK_NEGATIVE_Y" : [0, -1, 0], "TRACK_NEGATIVE_Z" : [0, 0, -1],
+                        }
+
+                        boneConstraints = []
+
+                        for bone in obj.pose.bones:
+
+                            for constraint in bone.constraints:
+
+                                if constraint.mute or constraint.type not in ("COPY_ROTATION", "COPY_LOCATION", "DAMPED_TRACK", "LIMIT_ROTATION", "IK"):
+                                    continue
+
+                                data = {
+                                    "bone" : bone.name,
+                                    "type" : constraint.type,
+                                    "influence" : constraint.influence,
+                                }
+
+                                target = getattr(constraint, "target", None)
+                                if target:
+                                    subtarget = getattr(constraint, "subtarget", "")
+                                    data["target"] = subtarget if subtarget != "" else target.name
+
+                                if constraint.type in ("COPY_ROTATION", "COPY_LOCATION"):
+                                    data["local"] = int(constraint.owner_space == "LOCAL" and constraint.target_space == "LOCAL")
+                                elif constraint.type == "DAMPED_TRACK":
+                                    data["axis"] = trackAxes[constraint.track_axis]
+                                elif constraint.type == "LIMIT_ROTATION":
+                                    data["limits"] = [
+                                        [int(constraint.use_limit_x), constraint.min_x, constraint.max_x],
+                                        [int(constraint.use_limit_y), constraint.min_y, constraint.max_y],
+                                        [int(constraint.use_limit_z), constraint.min_z, constraint.max_z],
+                                    ]
+                                elif constraint.type == "IK":
+                                    data["chainCount"] = constraint.chain_count
+
+                                boneConstraints.append(data)
+
+                        if len(boneConstraints) > 0:
+                            obj["t3dBoneConstraints__"] = boneConstraints
+
+                    # Delta transforms are exported converted to Y-up, so animations can be offset by them in Tetra3D
+                    if obj.rotation_mode == "QUATERNION":
+                        deltaRot = obj.delta_rotation_quaternion
+                    else:
+                        deltaRot = obj.delta_rotation_euler.to_quaternion()
+
+                    if obj.delta_location.length > 0 or deltaRot != mathutils.Quaternion() or obj.delta_scale != mathutils.Vector((1, 1, 1)):
+                        obj["t3dDeltaTransform__"] = {
+                            "location" : [obj.delta_location.x, obj.delta_location.z, -obj.delta_location.y],
+                            "rotation" : [deltaRot.x, deltaRot.z, -deltaRot.y, deltaRot.w],
+                            "scale" : [obj.delta_scale.x, obj.delta_scale.z, obj.delta_scale.y],
+                        }
+
                     if obj.type == "MESH":
 
                         if obj.data:
@@ -1361,15 +1722,27 @@ def export():
                     # Record relevant information for curves
                     if obj.type == "CURVE":
                         points = []
+                        bezier = False
 
                         for spline in obj.data.splines:
+                            cyclic = spline.use_cyclic_u or spline.use_cyclic_v
                             for point in spline.points:
                                 points.append(point.co)
-                            for point in spline.bezier_points:
+                            # Bezier points are exported as anchor, handle, handle, anchor, etc.
+                            bezierPoints = spline.bezier_points
+                            for i, point in enumerate(bezierPoints):
+                                bezier = True
+                                if i > 0:
+                                    points.append(point.handle_left)
                                 points.append(point.co)
+                                if i < len(bezierPoints) - 1 or cyclic:
+                                    points.append(point.handle_right)
+                            if cyclic and len(bezierPoints) > 0:
+                                points.append(bezierPoints[0].handle_left)
 
                         obj["t3dPathPoints__"] = points
                         obj["t3dPathCyclic__"] = spline.use_cyclic_u or spline.use_cyclic_v
+                        obj["t3dPathBezier__"] = bezier
 
                     if obj.instance_type == "COLLECTION" and obj.instance_collection is not None:
                         obj["t3dInstanceCollection__"] = obj.instance_collection.name
@@ -1436,6 +1809,21 @@ def export():
         if len(markers) > 0:
             action["t3dMarkers__"] = markers
 
+    # Sample material animations and put them into the materials.
+    for material in bpy.data.materials:
+        actions = []
+        if material.animation_data and material.animation_data.action:
+            actions.append(material.animation_data.action)
+        if material.node_tree and material.node_tree.animation_data and material.node_tree.animation_data.action:
+            actions.append(material.node_tree.animation_data.action)
+        animations = []
+        for action in actions:
+            animation = sampleMaterialAction(material, action, globalGet("t3dPlaybackFPS__", 60))
+            if animation:
+                animations.append(animation)
+        if len(animations) > 0:
+            material["t3dMaterialAnimations__"] = animations
+
     view3DCameraData = []
 
     renderResolutionH = getRenderResolutionH(None)
@@ -1530,6 +1918,9 @@ def export():
         if scene.world and "t3dCurrentWorld__" in scene:
             del(scene["t3dCurrentWorld__"])
 
+        if "t3dCollectionTree__" in scene:
+            del(scene["t3dCollectionTree__"])
+
         if scene.users > 0:
 
             for layer in scene.view_layers:
@@ -1544,6 +1935,10 @@ def export():
 
                     if "t3dOriginalLocalPosition__" in obj:
                         del(obj["t3dOriginalLocalPosition__"])
+
+                    for propName in ("t3dCurrentAction__", "t3dVisibilityKeys__", "t3dDeltaTransform__", "t3dNLATracks__", "t3dBoneConstraints__", "t3dParticleSettings__"):
+                        if propName in obj:
+                            del(obj[propName])
                         
                     if "t3dInstanceCollection__" in obj:
                         del(obj["t3dInstanceCollection__"])
@@ -1553,6 +1948,8 @@ def export():
                         del(obj["t3dPathPoints__"])
                     if "t3dPathCyclic__" in obj:
                         del(obj["t3dPathCyclic__"])
+                    if "t3dPathBezier__" in obj:
+                        del(obj["t3dPathBezier__"])
                     if obj.type == "MESH":
                         if "t3dVertexColorNames__" in obj.data:
                             del(obj.data["t3dVertexColorNames__"])
@@ -1569,6 +1966,10 @@ def export():
         if "t3dMarkers__" in action:
             del(action["t3dMarkers__"])
 
+    for material in bpy.data.materials:
+        if "t3dMaterialAnimations__" in material:
+            del(material["t3dMaterialAnimations__"])
+
     globalDel("t3dView3DCameraData__")
     globalDel("t3dCollections__")
     globalDel("t3dWorlds__")
@@ -1914,6 +2315,37 @@ objectProps = {
     "t3dAutoBatch__" : bpy.props.EnumProperty(items=batchModes, name="Auto Batch", description="Whether objects should be automatically batched together; for dynamically batched objects, they can only have one, common Material. For statically merged objects, they can have however many materials"),    
     "t3dSectorType__" : bpy.props.EnumProperty(items=listSectorTypes,name="Sector Type", description="The type of sector capability this object has; only used if rendered with a camera with Sector Rendering on"),
     "t3dSectorTypeOverride__" : bpy.props.BoolProperty(name="Override Sector Type", description="If the collection object should override the sector type for ALL its objects"),
+    "t3dParticleSystem__" : bpy.props.BoolProperty(name="Particle System", description="Whether a ParticleSystem should be created with this object as its root when loaded in Tetra3D", default=False),
+    "t3dParticleCollection__" : bpy.props.PointerProperty(type=bpy.types.Collection, name="Particles", description="The collection of mesh objects to use as particles; each particle uses a random object from the collection. The collection should usually be excluded from the scene (or be in another scene) so the objects aren't visible themselves"),
+    "t3dParticleSpawnRate__" : bpy.props.FloatVectorProperty(name="Spawn Rate", description="The minimum and maximum time in seconds between spawning particles", size=2, min=0.0, default=[1,1]),
+    "t3dParticleSpawnCount__" : bpy.props.IntVectorProperty(name="Spawn Count", description="The minimum and maximum number of particles spawned at a time", size=2, min=0, default=[1,1]),
+    "t3dParticleLifetime__" : bpy.props.FloatVectorProperty(name="Lifetime", description="The minimum and maximum time in seconds that particles live for", size=2, min=0.0, default=[1,1]),
+    "t3dParticleLocalPosition__" : bpy.props.BoolProperty(name="Local Position", description="Whether particles move along with the object once they've spawned", default=False),
+    "t3dParticlePrewarm__" : bpy.props.FloatProperty(name="Prewarm", description="How many seconds to simulate the particle system forward when it's created, so it starts off looking as though it's been running for a while", min=0.0, default=0),
+    "t3dParticleOneShot__" : bpy.props.BoolProperty(name="One Shot", description="Whether the particle system should spawn a single burst of particles and then turn itself off once they've died, rather than spawning particles continuously", default=False),
+    "t3dParticleSpawnOffsetMin__" : bpy.props.FloatVectorProperty(name="Offset Min", description="The minimum offset from the spawn position that particles spawn at", subtype="XYZ"),
+    "t3dParticleSpawnOffsetMax__" : bpy.props.FloatVectorProperty(name="Offset Max", description="The maximum offset from the spawn position that particles spawn at", subtype="XYZ"),
+    "t3dParticleEmissionShape__" : bpy.props.EnumProperty(items=particleEmissionShapes, name="Emission Shape", description="The shape that particles spawn within"),
+    "t3dParticleEmissionRadius__" : bpy.props.FloatProperty(name="Radius", description="The radius of the emission sphere, or of the base of the emission cone", min=0.0, default=1),
+    "t3dParticleEmissionSurface__" : bpy.props.BoolProperty(name="Surface Only", description="Whether particles only spawn on the surface of the emission sphere", default=False),
+    "t3dParticleEmissionAngle__" : bpy.props.FloatProperty(name="Angle", description="The spread of the emission cone", subtype="ANGLE", min=0.0, max=math.pi, default=math.pi / 8),
+    "t3dParticleEmissionSize__" : bpy.props.FloatVectorProperty(name="Size", description="The size of the emission box", subtype="XYZ", min=0.0, default=[1,1,1]),
+    "t3dParticleEmissionSpeed__" : bpy.props.FloatVectorProperty(name="Emission Speed", description="The minimum and maximum speed (per frame) particles are given heading out from the emission shape", size=2, default=[0,0]),
+    "t3dParticleVelocityMin__" : bpy.props.FloatVectorProperty(name="Min", description="The minimum velocity of particles per frame", subtype="XYZ"),
+    "t3dParticleVelocityMax__" : bpy.props.FloatVectorProperty(name="Max", description="The maximum velocity of particles per frame", subtype="XYZ"),
+    "t3dParticleVelocityAddMin__" : bpy.props.FloatVectorProperty(name="Min", description="The minimum acceleration of particles per frame", subtype="XYZ"),
+    "t3dParticleVelocityAddMax__" : bpy.props.FloatVectorProperty(name="Max", description="The maximum acceleration of particles per frame", subtype="XYZ"),
+    "t3dParticleRotationAddMin__" : bpy.props.FloatVectorProperty(name="Min", description="The minimum spin of particles per frame", subtype="EULER"),
+    "t3dParticleRotationAddMax__" : bpy.props.FloatVectorProperty(name="Max", description="The maximum spin of particles per frame", subtype="EULER"),
+    "t3dParticleFriction__" : bpy.props.FloatProperty(name="Friction", description="How much particles' velocity is reduced per frame", min=0.0, default=0),
+    "t3dParticleScaleMin__" : bpy.props.FloatVectorProperty(name="Min", description="The minimum scale particles spawn with", subtype="XYZ", default=[1,1,1]),
+    "t3dParticleScaleMax__" : bpy.props.FloatVectorProperty(name="Max", description="The maximum scale particles spawn with", subtype="XYZ", default=[1,1,1]),
+    "t3dParticleScaleUniform__" : bpy.props.BoolProperty(name="Uniform Scale", description="Whether particles are scaled uniformly between the minimum and maximum scale, rather than on each axis separately", default=True),
+    "t3dParticleScaleAddMin__" : bpy.props.FloatVectorProperty(name="Min", description="The minimum growth of particles per frame", subtype="XYZ"),
+    "t3dParticleScaleAddMax__" : bpy.props.FloatVectorProperty(name="Max", description="The maximum growth of particles per frame", subtype="XYZ"),
+    "t3dParticleColorCurve__" : bpy.props.BoolProperty(name="Color Over Lifetime", description="Whether particles fade from a starting color to an ending color over their lifetime", default=False),
+    "t3dParticleColorStart__" : bpy.props.FloatVectorProperty(name="Start", description="The color of particles when they spawn", subtype="COLOR", size=4, min=0.0, max=1.0, default=[1,1,1,1]),
+    "t3dParticleColorEnd__" : bpy.props.FloatVectorProperty(name="End", description="The color of particles when they die", subtype="COLOR", size=4, min=0.0, max=1.0, default=[1,1,1,0]),
 }
 
 ####
@@ -1923,6 +2355,7 @@ keymaps = []
 def register():
     
     bpy.utils.register_class(OBJECT_PT_tetra3d)
+    bpy.utils.register_class(OBJECT_PT_tetra3d_particles)
     bpy.utils.register_class(ACTION_PT_tetra3d)
     bpy.utils.register_class(MESH_PT_tetra3d)
     bpy.utils.register_class(RENDER_PT_tetra3d)
@@ -2048,10 +2481,14 @@ def register():
     bpy.types.Material.t3dMaterialFogless__ = bpy.props.BoolProperty(name="Fogless", description="Whether fog affects this material", default=False)
     bpy.types.Material.t3dBlendMode__ = bpy.props.EnumProperty(items=materialBlendModes, name="Blend Mode", description="Composite mode (i.e. additive, multiplicative, etc) for this material", default="DEFAULT")
     bpy.types.Material.t3dTransparencyMode__ = bpy.props.EnumProperty(items=materialTransparencyModes, name="Transparency Mode", description="Transparency mode for this material", default="AUTO")
+    bpy.types.Material.t3dDepthWrite__ = bpy.props.EnumProperty(items=materialDepthWriteModes, name="Depth Write", description="Whether this material writes to the depth buffer", default="AUTO")
+    bpy.types.Material.t3dDepthTest__ = bpy.props.EnumProperty(items=materialDepthTestModes, name="Depth Test", description="How this material is tested against the depth buffer", default="LESS")
     bpy.types.Material.t3dBillboardMode__ = bpy.props.EnumProperty(items=materialBillboardModes, name="Billboarding Mode", description="Billboard mode (i.e. if the object with this material should rotate to face the camera) for this material; doesn't take effect on armature skinned meshes", default="NONE")
     bpy.types.Material.t3dCustomDepthOn__ = bpy.props.BoolProperty(name="Custom Depth", description="Whether custom depth offsetting should be enabled", default=False)
     bpy.types.Material.t3dCustomDepthValue__ = bpy.props.FloatProperty(name="Depth Offset Value", description="How far in world units the material should offset when rendering (negative values are closer to the camera, positive values are further)")
+    bpy.types.Material.t3dSoftParticleDistance__ = bpy.props.FloatProperty(name="Soft Particle Distance", description="How far in world units a transparent material fades out as it nears the geometry behind it, hiding hard edges where it intersects the world. 0 disables fading", default=0, min=0)
     bpy.types.Material.t3dMaterialLightingMode__ = bpy.props.EnumProperty(items=materialLightingModes, name="Lighting mode", description="How materials should be lit", default="DEFAULT")
+    bpy.types.Material.t3dPerPixelLighting__ = bpy.props.BoolProperty(name="Per-Pixel Lighting", description="Whether the material should be lit per-pixel rather than per-vertex. This is slower, but avoids lighting bands across large polygons. Only the nearest point and sun lights light per-pixel lit materials", default=False)
     bpy.types.Material.t3dVisible__ = bpy.props.BoolProperty(name="Visible", description="Whether this material is visible", default=True)
 
     bpy.types.Material.t3dAutoUV__ = bpy.props.BoolProperty(name="Auto UV-Map", description="If the UV map of the faces that use this material should automatically be Cube Projection UV mapped when exiting edit mode")
@@ -2094,6 +2531,7 @@ def register():
 
 def unregister():
     bpy.utils.unregister_class(OBJECT_PT_tetra3d)
+    bpy.utils.unregister_class(OBJECT_PT_tetra3d_particles)
     bpy.utils.unregister_class(ACTION_PT_tetra3d)
     bpy.utils.unregister_class(MESH_PT_tetra3d)
     bpy.utils.unregister_class(RENDER_PT_tetra3d)
@@ -2165,10 +2603,14 @@ def unregister():
     del bpy.types.Material.t3dBlendMode__
     del bpy.types.Material.t3dBillboardMode__
     del bpy.types.Material.t3dTransparencyMode__
+    del bpy.types.Material.t3dDepthWrite__
+    del bpy.types.Material.t3dDepthTest__
     del bpy.types.Material.t3dMaterialLightingMode__
 
     del bpy.types.Material.t3dCustomDepthOn__
     del bpy.types.Material.t3dCustomDepthValue__
+    del bpy.types.Material.t3dSoftParticleDistance__
+    del bpy.types.Material.t3dPerPixelLighting__
     del bpy.types.Material.t3dGameProperties__
     del bpy.types.Material.t3dAutoUV__
     del bpy.types.Material.t3dAutoUVUnitSize__
@@ -52,6 +52,15 @@ type TextStyle struct {
 
 	// Manual offsets to positioning
 	OffsetX, OffsetY int
+
+	// DistanceField renders the text using a signed distance field rather than directly rasterizing it, which keeps the letters crisp and
+	// smooth when the Text is magnified (i.e. when scaled up in 3D) without needing enormous textures. Outlines and shadows are supported
+	// with distance fields as well, though shadows are drop shadows, rather than extruded. Defaults to false.
+	DistanceField bool
+
+	// DistanceFieldSpread is how far (in pixels of the Text's texture) the distance field extends from the letters, ranging from 1 to 16.
+	// Outlines and shadows can't extend further than this. Defaults to 8.
+	DistanceFieldSpread int
 }
 
 func NewDefaultTextStyle() TextStyle {
@@ -66,6 +75,8 @@ func NewDefaultTextStyle() TextStyle {
 		ShadowDirection: Vector3{1, 1, 0}.Unit(),
 		ShadowColorNear: NewColor(0, 0, 0, 1),
 		ShadowColorFar:  NewColor(0, 0, 0, 1),
+
+		DistanceFieldSpread: 8,
 	}
 }
 
@@ -82,6 +93,8 @@ type Text struct {
 	typewriterIndex int
 	typewriterOn    bool
 	textureSize     int
+
+	distanceFieldMask *ebiten.Image // The texture the text is rasterized to before generating a distance field from it
 }
 
 //go:embed shaders/text.kage
@@ -341,15 +354,28 @@ func (textObj *Text) UpdateTexture() {
 
 	typing := true
 
+	textureWidth := textObj.Texture.Bounds().Dx()
+	textureHeight := textObj.Texture.Bounds().Dy()
+
+	// When rendering with a distance field, the text is drawn to a mask first, and the distance field is generated from that.
+	target := textObj.Texture
+
+	if textObj.style.DistanceField {
+		if textObj.distanceFieldMask == nil || !textObj.distanceFieldMask.Bounds().Eq(textObj.Texture.Bounds()) {
+			if textObj.distanceFieldMask != nil {
+				textObj.distanceFieldMask.Dispose()
+			}
+			textObj.distanceFieldMask = ebiten.NewImage(textureWidth, textureHeight)
+		}
+		target = textObj.distanceFieldMask
+	}
+
 	// if textObj.style.BGColor != nil {
 	// 	textObj.Texture.Fill(textObj.style.BGColor.ToRGBA64())
 	// } else {
-	textObj.Texture.Clear()
+	target.Clear()
 	// }
 
-	textureWidth := textObj.Texture.Bounds().Dx()
-	textureHeight := textObj.Texture.Bounds().Dy()
-
 	blockHeight := math32.Max(len(textObj.parsedText)*multipliedLineHeight, lineHeight)
 
 	for lineIndex, line := range textObj.parsedText {
@@ -402,11 +428,15 @@ func (textObj *Text) UpdateTexture() {
 			line += textObj.style.Cursor
 		}
 
-		text.Draw(textObj.Texture, line, textObj.style.Font, x, y, color.RGBA{255, 255, 255, 255})
+		text.Draw(target, line, textObj.style.Font, x, y, color.RGBA{255, 255, 255, 255})
 		// text.Draw(textObj.Texture, line, textObj.style.Font, x, y, textObj.style.FGColor.ToRGBA64())
 
 	}
 
+	if textObj.style.DistanceField {
+		drawDistanceField(textObj.Texture, target, textObj.style.DistanceFieldSpread)
+	}
+
 }
 
 func (text *Text) Style() TextStyle {
@@ -442,6 +472,11 @@ func (text *Text) SetStyle(style TextStyle) {
 			uniformMap["ShadowColorFarSet"] = 1.0
 		}
 
+		if style.DistanceField {
+			uniformMap["DistanceField"] = 1.0
+			uniformMap["DistanceFieldSpread"] = clampDistanceFieldSpread(style.DistanceFieldSpread)
+		}
+
 		text.meshPart.Material.FragmentShaderOptions = &ebiten.DrawTrianglesShaderOptions{
 			Images: [4]*ebiten.Image{
 				text.Texture,
@@ -536,6 +571,10 @@ func (text *Text) Dispose() {
 		text.Texture = nil
 		text.meshPart.Material.Texture = nil
 	}
+	if text.distanceFieldMask != nil {
+		text.distanceFieldMask.Dispose()
+		text.distanceFieldMask = nil
+	}
 }
 
 // type Text struct {
@@ -0,0 +1,301 @@
+package tetra3d
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/solarlune/tetra3d/math32"
+	"github.com/tanema/gween/ease"
+)
+
+// Timeline is a lightweight sequence of keyframes and callbacks authored in code, useful for cutscenes and scripted events that
+// don't warrant exporting an animation from a 3D modeler. Nodes' transforms and colors, as well as Properties, are keyed at specific
+// times on the Timeline (i.e. with Timeline.KeyPosition()), and functions can be called when the playhead reaches a given time
+// (with Timeline.Call()). Play it back by calling Timeline.Play() and then Timeline.Update() once per frame, or scrub through it with
+// Timeline.Seek().
+type Timeline struct {
+	Playhead  float32                  // The current time of the Timeline in seconds.
+	Playing   bool                     // Whether the Timeline is playing or not.
+	PlaySpeed float32                  // The playback speed of the Timeline in percentage; defaults to 1 (100%). Should be above 0.
+	Loop      bool                     // If the Timeline should start over once it reaches the end. Defaults to false.
+	OnFinish  func(timeline *Timeline) // Called when the Timeline reaches its end (each time it loops, if Loop is true).
+	tracks    map[timelineTarget]*timelineTrack
+	order     []*timelineTrack
+	events    []timelineEvent
+	nextEvent int
+	length    float32
+	finished  bool
+}
+
+// timelineTarget identifies the value a timelineTrack animates (i.e. the position of a specific Node).
+type timelineTarget struct {
+	target   any
+	property string
+}
+
+type timelineKey struct {
+	time   float32
+	value  any
+	easing ease.TweenFunc
+}
+
+type timelineTrack struct {
+	keys  []timelineKey
+	apply func(from, to any, perc float32)
+}
+
+type timelineEvent struct {
+	time     float32
+	callback func()
+}
+
+// NewTimeline creates a new, empty Timeline.
+func NewTimeline() *Timeline {
+	return &Timeline{
+		PlaySpeed: 1,
+		tracks:    map[timelineTarget]*timelineTrack{},
+	}
+}
+
+// key adds a keyframe with the given value for the target provided, creating a track for it using the apply function if necessary.
+func (timeline *Timeline) key(target timelineTarget, time float32, value any, easing ease.TweenFunc, apply func(from, to any, perc float32)) *Timeline {
+
+	if easing == nil {
+		easing = ease.Linear
+	}
+
+	track, exists := timeline.tracks[target]
+	if !exists {
+		track = &timelineTrack{apply: apply}
+		timeline.tracks[target] = track
+		timeline.order = append(timeline.order, track)
+	}
+
+	key := timelineKey{time: time, value: value, easing: easing}
+
+	// Keep keys sorted by time, with later keys at the same time replacing earlier ones.
+	i := sort.Search(len(track.keys), func(i int) bool { return track.keys[i].time >= time })
+	if i < len(track.keys) && track.keys[i].time == time {
+		track.keys[i] = key
+	} else {
+		track.keys = append(track.keys, timelineKey{})
+		copy(track.keys[i+1:], track.keys[i:])
+		track.keys[i] = key
+	}
+
+	if time > timeline.length {
+		timeline.length = time
+	}
+
+	return timeline
+
+}
+
+// KeyPosition keys the local position of the given Node at the time provided in seconds. The easing function (from the
+// github.com/tanema/gween/ease package) is used to move from the previous key to this one; passing nil uses linear easing.
+func (timeline *Timeline) KeyPosition(node INode, time float32, position Vector3, easing ease.TweenFunc) *Timeline {
+	return timeline.key(timelineTarget{node, "position"}, time, position, easing, func(from, to any, perc float32) {
+		node.SetLocalPositionVec(from.(Vector3).Lerp(to.(Vector3), perc))
+	})
+}
+
+// KeyRotation keys the local rotation of the given Node at the time provided in seconds. The easing function is used to rotate from
+// the previous key to this one; passing nil uses linear easing.
+func (timeline *Timeline) KeyRotation(node INode, time float32, rotation Matrix4, easing ease.TweenFunc) *Timeline {
+	return timeline.key(timelineTarget{node, "rotation"}, time, rotation, easing, func(from, to any, perc float32) {
+		node.SetLocalRotation(from.(Matrix4).Lerp(to.(Matrix4), perc))
+	})
+}
+
+// KeyScale keys the local scale of the given Node at the time provided in seconds. The easing function is used to scale from
+// the previous key to this one; passing nil uses linear easing.
+func (timeline *Timeline) KeyScale(node INode, time float32, scale Vector3, easing ease.TweenFunc) *Timeline {
+	return timeline.key(timelineTarget{node, "scale"}, time, scale, easing, func(from, to any, perc float32) {
+		node.SetLocalScaleVec(from.(Vector3).Lerp(to.(Vector3), perc))
+	})
+}
+
+// KeyColor keys the Color of the given Model at the time provided in seconds. The easing function is used to change color from
+// the previous key to this one; passing nil uses linear easing.
+func (timeline *Timeline) KeyColor(model *Model, time float32, color Color, easing ease.TweenFunc) *Timeline {
+	return timeline.key(timelineTarget{model, "color"}, time, color, easing, func(from, to any, perc float32) {
+		model.Color = from.(Color).Mix(to.(Color), perc)
+	})
+}
+
+// KeyProperty keys the value of the property of the given name in the Properties provided (i.e. from Node.Properties()) at the time
+// provided in seconds. float32 values are interpolated from the previous key using the easing function (with nil meaning linear
+// easing); other values (like strings or booleans) are set once the playhead reaches their key.
+func (timeline *Timeline) KeyProperty(properties Properties, name string, time float32, value any, easing ease.TweenFunc) *Timeline {
+
+	// Properties is a map, so it's identified by its pointer.
+	target := timelineTarget{reflect.ValueOf(properties).Pointer(), "property:" + name}
+
+	return timeline.key(target, time, value, easing, func(from, to any, perc float32) {
+
+		fromFloat, fromOK := from.(float32)
+		toFloat, toOK := to.(float32)
+
+		if fromOK && toOK {
+			properties.Add(name).Set(fromFloat + (toFloat-fromFloat)*perc)
+		} else if perc >= 1 {
+			properties.Add(name).Set(to)
+		} else {
+			properties.Add(name).Set(from)
+		}
+
+	})
+
+}
+
+// Call adds a callback to the Timeline that is called when the playhead reaches the time provided in seconds while playing. Callbacks
+// aren't called when seeking with Timeline.Seek().
+func (timeline *Timeline) Call(time float32, callback func()) *Timeline {
+
+	i := sort.Search(len(timeline.events), func(i int) bool { return timeline.events[i].time > time })
+	timeline.events = append(timeline.events, timelineEvent{})
+	copy(timeline.events[i+1:], timeline.events[i:])
+	timeline.events[i] = timelineEvent{time: time, callback: callback}
+
+	if i < timeline.nextEvent {
+		timeline.nextEvent++
+	}
+
+	if time > timeline.length {
+		timeline.length = time
+	}
+
+	return timeline
+
+}
+
+// Length returns the length of the Timeline in seconds (the time of its last key or callback).
+func (timeline *Timeline) Length() float32 {
+	return timeline.length
+}
+
+// Play starts playing the Timeline from its current playhead. If the Timeline has finished, it starts over from the beginning.
+func (timeline *Timeline) Play() {
+	if timeline.finished {
+		timeline.Seek(0)
+	}
+	timeline.Playing = true
+}
+
+// Pause pauses the Timeline, leaving the playhead where it is.
+func (timeline *Timeline) Pause() {
+	timeline.Playing = false
+}
+
+// Stop stops the Timeline and rewinds it to the beginning, applying the keys at the start.
+func (timeline *Timeline) Stop() {
+	timeline.Playing = false
+	timeline.Seek(0)
+}
+
+// Finished returns if the Timeline has reached its end (and isn't looping).
+func (timeline *Timeline) Finished() bool {
+	return timeline.finished
+}
+
+// Seek moves the playhead to the given time in seconds, applying the Timeline's keys at that time to their Nodes and Properties.
+// Callbacks aren't called when seeking; playing from the new time calls any callbacks that come after it (including ones at that time).
+func (timeline *Timeline) Seek(time float32) {
+
+	if time < 0 {
+		time = 0
+	} else if time > timeline.length {
+		time = timeline.length
+	}
+
+	timeline.Playhead = time
+	timeline.finished = false
+	timeline.nextEvent = sort.Search(len(timeline.events), func(i int) bool { return timeline.events[i].time >= time })
+	timeline.apply()
+
+}
+
+// Update advances the Timeline by the delta time given in seconds if it's playing, applying its keys and calling any callbacks the
+// playhead passes.
+func (timeline *Timeline) Update(dt float32) {
+
+	if !timeline.Playing {
+		return
+	}
+
+	timeline.Playhead += dt * timeline.PlaySpeed
+
+	if timeline.Playhead < timeline.length {
+		timeline.apply()
+		timeline.callEvents()
+		return
+	}
+
+	overflow := timeline.Playhead - timeline.length
+
+	timeline.Playhead = timeline.length
+	timeline.apply()
+	timeline.callEvents()
+
+	// Callbacks could have paused or moved the Timeline.
+	if !timeline.Playing || timeline.Playhead != timeline.length {
+		return
+	}
+
+	if timeline.OnFinish != nil {
+		timeline.OnFinish(timeline)
+	}
+
+	if !timeline.Loop || timeline.length <= 0 {
+		timeline.Playing = false
+		timeline.finished = true
+		return
+	}
+
+	timeline.Seek(math32.Mod(overflow, timeline.length))
+	timeline.callEvents()
+
+}
+
+// callEvents calls the callbacks between the last called one and the playhead.
+func (timeline *Timeline) callEvents() {
+
+	for timeline.nextEvent < len(timeline.events) && timeline.events[timeline.nextEvent].time <= timeline.Playhead {
+		event := timeline.events[timeline.nextEvent]
+		timeline.nextEvent++
+		event.callback()
+	}
+
+}
+
+// apply applies the values of the Timeline's tracks at the current playhead.
+func (timeline *Timeline) apply() {
+
+	for _, track := range timeline.order {
+
+		keys := track.keys
+		time := timeline.Playhead
+
+		// Before the first key, the first key's value is held; after the last one, the last key's value is.
+		if time <= keys[0].time {
+			track.apply(keys[0].value, keys[0].value, 1)
+			continue
+		}
+
+		i := sort.Search(len(keys), func(i int) bool { return keys[i].time >= time })
+
+		if i >= len(keys) {
+			last := keys[len(keys)-1]
+			track.apply(last.value, last.value, 1)
+			continue
+		}
+
+		from := keys[i-1]
+		to := keys[i]
+		duration := to.time - from.time
+
+		track.apply(from.value, to.value, to.easing(time-from.time, 0, 1, duration))
+
+	}
+
+}
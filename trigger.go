@@ -0,0 +1,162 @@
+package tetra3d
+
+// Trigger is a Node representing a volume that tracks which BoundingObjects overlap it from frame to frame, calling its OnEnter, OnStay,
+// and OnExit callbacks as objects enter it, remain inside of it, and leave it. This is useful for areas that should react to objects
+// (i.e. doors that open when the player approaches, kill zones, checkpoints, or pickups) without having to diff collision test results manually.
+//
+// A Trigger's shape is its first BoundingObject child (see Trigger.Bounds()); NewTrigger() adds the given BoundingObject as a child
+// automatically. A Trigger doesn't test for overlaps by itself; call Trigger.Update() once per frame to have it test against the objects
+// specified in TestAgainst and fire its callbacks.
+type Trigger struct {
+	*Node
+	On bool // Whether the Trigger tests for overlapping objects or not. When turned off, the Trigger's next Update() call exits all overlapping objects. Defaults to true.
+
+	// TestAgainst is used to specify a selection of BoundingObjects to test against - this can be either a NodeFilter or a NodeCollection (a slice of Nodes).
+	// If TestAgainst is nil, nothing is tested, and so nothing can overlap the Trigger.
+	TestAgainst NodeIterator
+
+	OnEnter func(trigger *Trigger, other IBoundingObject) // OnEnter is called when a BoundingObject starts overlapping the Trigger.
+	OnStay  func(trigger *Trigger, other IBoundingObject) // OnStay is called on each Update() for each BoundingObject that was already overlapping the Trigger and still is.
+	OnExit  func(trigger *Trigger, other IBoundingObject) // OnExit is called when a BoundingObject stops overlapping the Trigger.
+
+	overlapping []IBoundingObject
+	inside      map[IBoundingObject]bool
+	current     map[IBoundingObject]bool
+	entered     []IBoundingObject
+	exited      []IBoundingObject
+}
+
+// NewTrigger creates a new Trigger with the given name, using the given BoundingObject as its shape. The BoundingObject is added as a
+// child of the Trigger, and so moves along with it.
+func NewTrigger(name string, bounds IBoundingObject) *Trigger {
+	trigger := &Trigger{
+		Node:    NewNode(name),
+		On:      true,
+		inside:  map[IBoundingObject]bool{},
+		current: map[IBoundingObject]bool{},
+	}
+	trigger.owner = trigger
+	if bounds != nil {
+		trigger.AddChildren(bounds)
+	}
+	return trigger
+}
+
+// Clone creates a clone of the Trigger and its children. Note that the clone doesn't start out overlapping any objects.
+func (trigger *Trigger) Clone() INode {
+
+	clone := NewTrigger(trigger.name, nil)
+	clone.On = trigger.On
+	clone.TestAgainst = trigger.TestAgainst
+	clone.OnEnter = trigger.OnEnter
+	clone.OnStay = trigger.OnStay
+	clone.OnExit = trigger.OnExit
+
+	clone.Node = trigger.Node.clone(clone).(*Node)
+
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+
+	return clone
+
+}
+
+// Bounds returns the BoundingObject used as the Trigger's shape, which is its first BoundingObject child. If the Trigger has no
+// BoundingObject children, Bounds returns nil.
+func (trigger *Trigger) Bounds() IBoundingObject {
+	for _, child := range trigger.children {
+		if bounds, ok := child.(IBoundingObject); ok {
+			return bounds
+		}
+	}
+	return nil
+}
+
+// Overlapping returns the BoundingObjects overlapping the Trigger as of the last Update() call, in the order they entered it.
+// The returned slice shouldn't be modified or held onto, as it's reused by the Trigger.
+func (trigger *Trigger) Overlapping() []IBoundingObject {
+	return trigger.overlapping
+}
+
+// IsOverlapping returns if the given BoundingObject was overlapping the Trigger as of the last Update() call.
+func (trigger *Trigger) IsOverlapping(other IBoundingObject) bool {
+	return trigger.inside[other]
+}
+
+// Update tests the Trigger's shape against the objects in TestAgainst, calling OnExit for each object that stopped overlapping the
+// Trigger since the last Update() call, OnStay for each object that was already overlapping it and still is, and OnEnter for each
+// object that started overlapping it, in that order.
+func (trigger *Trigger) Update() {
+
+	bounds := trigger.Bounds()
+
+	clear(trigger.current)
+	trigger.entered = trigger.entered[:0]
+	trigger.exited = trigger.exited[:0]
+
+	if trigger.On && bounds != nil && trigger.TestAgainst != nil {
+
+		trigger.TestAgainst.ForEach(func(node INode) bool {
+
+			other, ok := node.(IBoundingObject)
+
+			if !ok || other == bounds || trigger.current[other] {
+				return true
+			}
+
+			if bounds.Colliding(other) {
+				trigger.current[other] = true
+				if !trigger.inside[other] {
+					trigger.entered = append(trigger.entered, other)
+				}
+			}
+
+			return true
+
+		})
+
+	}
+
+	stayed := trigger.overlapping[:0]
+
+	for _, other := range trigger.overlapping {
+		if trigger.current[other] {
+			stayed = append(stayed, other)
+		} else {
+			trigger.exited = append(trigger.exited, other)
+			delete(trigger.inside, other)
+		}
+	}
+
+	for _, other := range trigger.entered {
+		trigger.inside[other] = true
+	}
+
+	stayCount := len(stayed)
+	trigger.overlapping = append(stayed, trigger.entered...)
+
+	if trigger.OnExit != nil {
+		for _, other := range trigger.exited {
+			trigger.OnExit(trigger, other)
+		}
+	}
+
+	if trigger.OnStay != nil {
+		for _, other := range trigger.overlapping[:stayCount] {
+			trigger.OnStay(trigger, other)
+		}
+	}
+
+	if trigger.OnEnter != nil {
+		for _, other := range trigger.entered {
+			trigger.OnEnter(trigger, other)
+		}
+	}
+
+}
+
+// Type returns the NodeType for this object.
+func (trigger *Trigger) Type() NodeType {
+	return NodeTypeTrigger
+}
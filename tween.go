@@ -0,0 +1,242 @@
+package tetra3d
+
+import "github.com/tanema/gween/ease"
+
+// TweenManager updates a collection of Tweens, which animate the properties of Nodes (their position, rotation, scale, and color)
+// over time. Create a Tween for a Node with TweenManager.Tween(), and call TweenManager.Update() once per frame to play them.
+type TweenManager struct {
+	tweens []*Tween
+}
+
+// NewTweenManager creates a new TweenManager.
+func NewTweenManager() *TweenManager {
+	return &TweenManager{
+		tweens: []*Tween{},
+	}
+}
+
+// Tween creates a new Tween to animate the given Node and adds it to the TweenManager. Steps added to the Tween
+// (i.e. through Tween.MoveTo()) start playing on the next TweenManager.Update() call.
+func (manager *TweenManager) Tween(node INode) *Tween {
+	tween := &Tween{
+		Node:  node,
+		steps: []*tweenStep{},
+	}
+	manager.tweens = append(manager.tweens, tween)
+	return tween
+}
+
+// Update advances all of the TweenManager's Tweens by the given delta time (in seconds). Tweens that finish
+// (or are stopped) are removed from the TweenManager.
+func (manager *TweenManager) Update(dt float32) {
+
+	// Tweens can be added from callbacks while updating, so the length is checked on each iteration
+	for i := 0; i < len(manager.tweens); i++ {
+		manager.tweens[i].update(dt)
+	}
+
+	active := manager.tweens[:0]
+	for _, tween := range manager.tweens {
+		if !tween.finished {
+			active = append(active, tween)
+		}
+	}
+
+	for i := len(active); i < len(manager.tweens); i++ {
+		manager.tweens[i] = nil
+	}
+
+	manager.tweens = active
+
+}
+
+// Stop stops all Tweens in the TweenManager that animate the given Node, leaving the Node as it currently is.
+func (manager *TweenManager) Stop(node INode) {
+	for _, tween := range manager.tweens {
+		if tween.Node == node {
+			tween.Stop()
+		}
+	}
+}
+
+// StopAll stops all Tweens in the TweenManager.
+func (manager *TweenManager) StopAll() {
+	for _, tween := range manager.tweens {
+		tween.Stop()
+	}
+}
+
+// Count returns the number of Tweens that are currently playing in the TweenManager.
+func (manager *TweenManager) Count() int {
+	return len(manager.tweens)
+}
+
+// Tween animates a Node through a sequence of steps, each of which plays after the previous one finishes; for example,
+// manager.Tween(node).MoveTo(pos, 0.5, ease.OutQuad).ScaleTo(Vector3{2, 2, 2}, 0.25, nil) moves the Node, and then scales it.
+// Each step starts from whatever values the Node has once the step begins. The easing functions are from the
+// github.com/tanema/gween/ease package; passing nil uses linear easing.
+// To animate properties at the same time, create multiple Tweens for the same Node.
+type Tween struct {
+	Node INode // The Node being animated by the Tween
+
+	steps    []*tweenStep
+	index    int
+	time     float32
+	onFinish func()
+	finished bool
+}
+
+type tweenStep struct {
+	duration float32
+	easing   ease.TweenFunc
+	begin    func()
+	apply    func(perc float32)
+	started  bool
+}
+
+func (tween *Tween) add(duration float32, easing ease.TweenFunc, begin func(), apply func(perc float32)) *Tween {
+
+	if easing == nil {
+		easing = ease.Linear
+	}
+
+	tween.steps = append(tween.steps, &tweenStep{
+		duration: duration,
+		easing:   easing,
+		begin:    begin,
+		apply:    apply,
+	})
+
+	return tween
+
+}
+
+// MoveTo adds a step to the Tween that moves the Node to the given local position over the duration given in seconds, using the
+// easing function provided.
+func (tween *Tween) MoveTo(position Vector3, duration float32, easing ease.TweenFunc) *Tween {
+
+	var start Vector3
+
+	return tween.add(duration, easing,
+		func() { start = tween.Node.LocalPosition() },
+		func(perc float32) { tween.Node.SetLocalPositionVec(start.Lerp(position, perc)) },
+	)
+
+}
+
+// RotateTo adds a step to the Tween that rotates the Node to the given local rotation over the duration given in seconds,
+// using the easing function provided.
+func (tween *Tween) RotateTo(rotation Matrix4, duration float32, easing ease.TweenFunc) *Tween {
+
+	var start Matrix4
+
+	return tween.add(duration, easing,
+		func() { start = tween.Node.LocalRotation() },
+		func(perc float32) { tween.Node.SetLocalRotation(start.Lerp(rotation, perc)) },
+	)
+
+}
+
+// ScaleTo adds a step to the Tween that scales the Node to the given local scale over the duration given in seconds,
+// using the easing function provided.
+func (tween *Tween) ScaleTo(scale Vector3, duration float32, easing ease.TweenFunc) *Tween {
+
+	var start Vector3
+
+	return tween.add(duration, easing,
+		func() { start = tween.Node.LocalScale() },
+		func(perc float32) { tween.Node.SetLocalScaleVec(start.Lerp(scale, perc)) },
+	)
+
+}
+
+// ColorTo adds a step to the Tween that changes the color of the Node to the given color over the duration given in seconds,
+// using the easing function provided. This only has an effect if the Node is a Model (in which case, the Model's Color is changed);
+// for other Nodes, the step just waits for the duration.
+func (tween *Tween) ColorTo(color Color, duration float32, easing ease.TweenFunc) *Tween {
+
+	var start Color
+
+	return tween.add(duration, easing,
+		func() {
+			if model, ok := tween.Node.(*Model); ok {
+				start = model.Color
+			}
+		},
+		func(perc float32) {
+			if model, ok := tween.Node.(*Model); ok {
+				model.Color = start.Mix(color, perc)
+			}
+		},
+	)
+
+}
+
+// Wait adds a step to the Tween that waits for the duration given in seconds before continuing on to the next step.
+func (tween *Tween) Wait(duration float32) *Tween {
+	return tween.add(duration, nil, func() {}, func(perc float32) {})
+}
+
+// Call adds a step to the Tween that calls the given function once the previous steps have finished.
+func (tween *Tween) Call(callback func()) *Tween {
+	return tween.add(0, nil, callback, func(perc float32) {})
+}
+
+// OnFinish sets a function to call once all of the Tween's steps have finished playing. It isn't called if the Tween is stopped.
+func (tween *Tween) OnFinish(callback func()) *Tween {
+	tween.onFinish = callback
+	return tween
+}
+
+// Stop stops the Tween, leaving the Node as it currently is. The Tween will be removed from its TweenManager on the next update.
+func (tween *Tween) Stop() {
+	tween.finished = true
+}
+
+// Finished returns if the Tween has finished playing all of its steps, or has been stopped.
+func (tween *Tween) Finished() bool {
+	return tween.finished
+}
+
+func (tween *Tween) update(dt float32) {
+
+	if tween.finished {
+		return
+	}
+
+	// Time left over from finishing one step carries over to the next, so chains of steps don't drift over time
+	for tween.index < len(tween.steps) {
+
+		step := tween.steps[tween.index]
+
+		if !step.started {
+			step.started = true
+			step.begin()
+			// The step's begin function could have stopped the Tween
+			if tween.finished {
+				return
+			}
+		}
+
+		remaining := step.duration - tween.time
+
+		if dt < remaining {
+			tween.time += dt
+			step.apply(step.easing(tween.time, 0, 1, step.duration))
+			return
+		}
+
+		step.apply(1)
+		dt -= remaining
+		tween.time = 0
+		tween.index++
+
+	}
+
+	tween.finished = true
+
+	if tween.onFinish != nil {
+		tween.onFinish()
+	}
+
+}
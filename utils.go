@@ -195,3 +195,22 @@ func (s Set[E]) ForEach(f func(element E)) {
 // 	}
 
 // }
+
+type priorityQueueItem[T any] struct {
+	value    T
+	priority float32
+}
+
+// priorityQueue is a min-heap of values ordered by priority, used for pathfinding. It should be used through the container/heap package.
+type priorityQueue[T any] []priorityQueueItem[T]
+
+func (q priorityQueue[T]) Len() int           { return len(q) }
+func (q priorityQueue[T]) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q priorityQueue[T]) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *priorityQueue[T]) Push(x any)        { *q = append(*q, x.(priorityQueueItem[T])) }
+func (q *priorityQueue[T]) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,396 @@
+package tetra3d
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/qmuntal/gltf"
+	"github.com/solarlune/tetra3d/math32"
+)
+
+// ValidationSeverity indicates how serious a ValidationIssue is.
+type ValidationSeverity int
+
+const (
+	// ValidationSeverityWarning indicates that something in the file won't look or behave as it does in the modeler.
+	ValidationSeverityWarning ValidationSeverity = iota
+	// ValidationSeverityError indicates that something in the file will likely cause problems at runtime (i.e. a crash when rendering).
+	ValidationSeverityError
+)
+
+// String returns the severity as a string.
+func (severity ValidationSeverity) String() string {
+	if severity == ValidationSeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// ValidationIssue is a tetra3d-specific problem found in a GLTF file by ValidateGLTFData() or ValidateGLTFFileSystem().
+type ValidationIssue struct {
+	Severity ValidationSeverity
+	Subject  string // What the issue concerns, like "mesh [Cube]" or "object [Player] in scene [Level]".
+	Message  string // A description of the issue.
+}
+
+// String returns the ValidationIssue as a human-readable string.
+func (issue ValidationIssue) String() string {
+	return issue.Severity.String() + ": " + issue.Subject + ": " + issue.Message
+}
+
+// GLTFValidationOptions alters how a GLTF file is validated.
+type GLTFValidationOptions struct {
+	// LoadOptions are the GLTFLoadOptions used to load the file for validation. Defaults to nil, which loads the file using the
+	// default GLTFLoadOptions. The file is always loaded with DeferFinalization on, so textures aren't created while validating.
+	LoadOptions *GLTFLoadOptions
+
+	// SolidProperties are the names of game properties that mark an object as solid (i.e. an object that should be collided with).
+	// Objects with any of these properties and no BoundingObject (either as a child or as the object itself) are reported.
+	// Defaults to []string{"solid"}.
+	SolidProperties []string
+
+	// ScaleTolerance is how far apart a bone's scale can be on each axis before it's considered non-uniform. Defaults to 0.001.
+	ScaleTolerance float32
+}
+
+// DefaultGLTFValidationOptions creates an instance of GLTFValidationOptions with some sensible defaults.
+func DefaultGLTFValidationOptions() *GLTFValidationOptions {
+	return &GLTFValidationOptions{
+		SolidProperties: []string{"solid"},
+		ScaleTolerance:  0.001,
+	}
+}
+
+// ValidateGLTFFileSystem loads the .gltf or .glb file from the file system given using the filename provided, and reports any
+// tetra3d-specific issues found in it (see ValidateGLTFData()). Passing nil for validationOptions validates the file using the
+// default validation options.
+func ValidateGLTFFileSystem(fileSystem fs.FS, filename string, validationOptions *GLTFValidationOptions) ([]ValidationIssue, error) {
+
+	file, err := fileSystem.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if validationOptions == nil {
+		validationOptions = DefaultGLTFValidationOptions()
+	}
+
+	options := *validationOptions
+	options.LoadOptions = validationLoadOptions(validationOptions.LoadOptions)
+	options.LoadOptions.externalBufferFileSystem = fileSystem
+	options.LoadOptions.rootFilename = filename
+
+	return validateGLTF(data, &options)
+
+}
+
+// ValidateGLTFData loads the .gltf or .glb file from the byte data given and reports any tetra3d-specific issues found in it, so
+// problems can be caught before runtime. These include:
+//
+// - MeshParts with more triangles than can be rendered at once (see MaxTriangleCount), or more vertices than can be indexed.
+//
+// - Objects marked as solid (see GLTFValidationOptions.SolidProperties) without any BoundingObjects for collision.
+//
+// - Material features that tetra3d doesn't support (like normal maps or metallic / roughness textures) and required extensions
+// that tetra3d can't load.
+//
+// - Bones that are scaled (or animated to scale) non-uniformly, which doesn't skin correctly.
+//
+// Passing nil for validationOptions validates the file using the default validation options. ValidateGLTFData returns an error
+// if the file can't be loaded at all; as with LoadGLTFData(), external buffers can only be loaded using ValidateGLTFFileSystem().
+func ValidateGLTFData(data []byte, validationOptions *GLTFValidationOptions) ([]ValidationIssue, error) {
+
+	if validationOptions == nil {
+		validationOptions = DefaultGLTFValidationOptions()
+	}
+
+	options := *validationOptions
+	options.LoadOptions = validationLoadOptions(validationOptions.LoadOptions)
+
+	return validateGLTF(data, &options)
+
+}
+
+// validationLoadOptions returns a copy of the given GLTFLoadOptions (or the default GLTFLoadOptions if nil) suitable for validation.
+func validationLoadOptions(loadOptions *GLTFLoadOptions) *GLTFLoadOptions {
+
+	if loadOptions == nil {
+		loadOptions = DefaultGLTFLoadOptions()
+	}
+
+	copied := *loadOptions
+	copied.DeferFinalization = true
+	return &copied
+
+}
+
+func validateGLTF(data []byte, options *GLTFValidationOptions) ([]ValidationIssue, error) {
+
+	doc := gltf.NewDocument()
+
+	if err := gltf.NewDecoder(bytes.NewReader(data)).Decode(doc); err != nil {
+		return nil, err
+	}
+
+	issues := validateGLTFDocument(doc, options.LoadOptions)
+
+	library, err := LoadGLTFData(bytes.NewReader(data), options.LoadOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	library.buildMeshes()
+
+	issues = append(issues, validateLibrary(library, options)...)
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Severity > issues[j].Severity
+	})
+
+	return issues, nil
+
+}
+
+// supportedGLTFExtensions are the GLTF extensions tetra3d can load.
+var supportedGLTFExtensions = map[string]bool{
+	"EXT_mesh_gpu_instancing": true,
+	"KHR_lights_punctual":     true,
+	"KHR_texture_transform":   true,
+}
+
+// gltfExtensionSupported returns if the GLTF extension of the given name can be loaded using the given load options.
+func gltfExtensionSupported(ext string, loadOptions *GLTFLoadOptions) bool {
+	switch ext {
+	case gltfDracoExtension:
+		return loadOptions.DracoDecoder != nil
+	case gltfBasisuExtension:
+		return loadOptions.KTX2Decoder != nil
+	}
+	return supportedGLTFExtensions[ext]
+}
+
+// validateGLTFDocument reports issues with the GLTF document that aren't visible in the loaded Library, like unsupported
+// material features.
+func validateGLTFDocument(doc *gltf.Document, loadOptions *GLTFLoadOptions) []ValidationIssue {
+
+	issues := []ValidationIssue{}
+
+	warn := func(subject, message string) {
+		issues = append(issues, ValidationIssue{ValidationSeverityWarning, subject, message})
+	}
+
+	for _, ext := range doc.ExtensionsRequired {
+		if !gltfExtensionSupported(ext, loadOptions) {
+			issues = append(issues, ValidationIssue{ValidationSeverityError, "file", "requires the unsupported extension " + ext + "; data using it won't load"})
+		}
+	}
+
+	for _, mat := range doc.Materials {
+
+		subject := "material [" + mat.Name + "]"
+
+		if mat.NormalTexture != nil {
+			warn(subject, "has a normal map, which is unsupported and will be ignored")
+		}
+
+		if mat.OcclusionTexture != nil {
+			warn(subject, "has an occlusion texture, which is unsupported and will be ignored")
+		}
+
+		if mat.EmissiveTexture != nil {
+			warn(subject, "has an emissive texture, which is unsupported and will be ignored")
+		}
+
+		if pbr := mat.PBRMetallicRoughness; pbr != nil {
+
+			if pbr.MetallicRoughnessTexture != nil {
+				warn(subject, "has a metallic / roughness texture, which is unsupported and will be ignored")
+			}
+
+			if pbr.BaseColorTexture != nil && pbr.BaseColorTexture.TexCoord != 0 {
+				warn(subject, fmt.Sprintf("samples its base color texture using UV map #%d; only the first UV map is used for texturing", pbr.BaseColorTexture.TexCoord))
+			}
+
+		}
+
+		for ext := range mat.Extensions {
+			if !gltfExtensionSupported(ext, loadOptions) {
+				warn(subject, "uses the unsupported extension "+ext+", which will be ignored")
+			}
+		}
+
+	}
+
+	for _, img := range doc.Images {
+
+		mimeType := img.MimeType
+		if mimeType == "" {
+			mimeType = strings.ToLower(path.Ext(img.URI))
+		}
+
+		if mimeType == "image/jpeg" || mimeType == ".jpg" || mimeType == ".jpeg" {
+			warn("image ["+img.Name+"]", "is a JPEG image; import the image/jpeg package in your game so it can be decoded")
+		}
+
+		if isKTX2Image(img, nil) && loadOptions.KTX2Decoder == nil {
+			warn("image ["+img.Name+"]", "is a KTX2 texture; set GLTFLoadOptions.KTX2Decoder so it can be decoded")
+		}
+
+	}
+
+	for _, mesh := range doc.Meshes {
+
+		for _, prim := range mesh.Primitives {
+
+			if _, exists := prim.Attributes["JOINTS_1"]; exists {
+				warn("mesh ["+mesh.Name+"]", "has vertices influenced by more than 4 bones; only the first 4 influences of each vertex are used")
+				break
+			}
+
+			if prim.Mode != gltf.PrimitiveTriangles {
+				warn("mesh ["+mesh.Name+"]", "has primitives that aren't made of triangles, which are unsupported")
+				break
+			}
+
+		}
+
+	}
+
+	return issues
+
+}
+
+// validateLibrary reports issues with the loaded Library, like MeshParts that are too large to render.
+func validateLibrary(library *Library, options *GLTFValidationOptions) []ValidationIssue {
+
+	issues := []ValidationIssue{}
+
+	meshNames := make([]string, 0, len(library.Meshes))
+	for name := range library.Meshes {
+		meshNames = append(meshNames, name)
+	}
+	sort.Strings(meshNames)
+
+	for _, name := range meshNames {
+
+		for _, part := range library.Meshes[name].MeshParts {
+
+			matName := "nil"
+			if part.Material != nil {
+				matName = part.Material.Name
+			}
+
+			subject := "mesh [" + name + "], material [" + matName + "]"
+
+			if tc := part.TriangleCount(); tc >= MaxTriangleCount {
+				issues = append(issues, ValidationIssue{ValidationSeverityError, subject, fmt.Sprintf("has %d triangles, which exceeds the renderable maximum of %d triangles for one MeshPart; split up the mesh using materials or into multiple objects", tc, MaxTriangleCount-1)})
+			}
+
+			if vc := part.VertexIndexEnd - part.VertexIndexStart; vc > 65535 {
+				issues = append(issues, ValidationIssue{ValidationSeverityError, subject, fmt.Sprintf("has %d vertices, which exceeds the maximum of 65535 vertices for one MeshPart; split up the mesh using materials or into multiple objects", vc)})
+			}
+
+		}
+
+	}
+
+	tolerance := options.ScaleTolerance
+
+	nonUniform := func(scale Vector3) bool {
+		return math32.Abs(scale.X-scale.Y) > tolerance || math32.Abs(scale.Y-scale.Z) > tolerance || math32.Abs(scale.X-scale.Z) > tolerance
+	}
+
+	bones := map[string]bool{}
+
+	for _, scene := range library.Scenes {
+
+		for _, node := range scene.Root.SearchTree().INodes() {
+
+			subject := "object [" + node.Name() + "] in scene [" + scene.Name + "]"
+
+			if node.IsBone() {
+
+				bones[node.Name()] = true
+
+				if nonUniform(node.LocalScale()) {
+					issues = append(issues, ValidationIssue{ValidationSeverityWarning, subject, "is a bone with a non-uniform scale, which won't skin correctly"})
+				}
+
+			}
+
+			// Having any one of the SolidProperties marks the node as solid
+			solid := false
+			for _, prop := range options.SolidProperties {
+				if node.Properties().Has(prop) {
+					solid = true
+					break
+				}
+			}
+
+			if solid && !hasBounds(node) {
+				issues = append(issues, ValidationIssue{ValidationSeverityWarning, subject, "is marked as solid, but has no BoundingObject to collide with"})
+			}
+
+		}
+
+	}
+
+	animNames := make([]string, 0, len(library.Animations))
+	for name := range library.Animations {
+		animNames = append(animNames, name)
+	}
+	sort.Strings(animNames)
+
+	for _, name := range animNames {
+
+		for channelName, channel := range library.Animations[name].Channels {
+
+			if !bones[channelName] {
+				continue
+			}
+
+			if track, exists := channel.Tracks[TrackTypeScale]; exists {
+				for _, key := range track.Keyframes {
+					if nonUniform(key.Data.AsVector()) {
+						issues = append(issues, ValidationIssue{ValidationSeverityWarning, "animation [" + name + "]", "scales the bone [" + channelName + "] non-uniformly, which won't skin correctly"})
+						break
+					}
+				}
+			}
+
+		}
+
+	}
+
+	return issues
+
+}
+
+// hasBounds returns if the given Node is a BoundingObject, or has one as a direct child.
+func hasBounds(node INode) bool {
+
+	if _, ok := node.(IBoundingObject); ok {
+		return true
+	}
+
+	for _, child := range node.Children() {
+		if _, ok := child.(IBoundingObject); ok {
+			return true
+		}
+	}
+
+	return false
+
+}
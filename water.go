@@ -0,0 +1,200 @@
+package tetra3d
+
+import (
+	_ "embed"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/solarlune/tetra3d/math32"
+)
+
+//go:embed shaders/water.kage
+var waterShaderText []byte
+
+// waterShader is compiled the first time a WaterModel is created, and then shared between all WaterModels.
+var waterShader *ebiten.Shader
+
+// WaterModel is a helper for rendering a water surface. It's made of a subdivided plane Model that ripples with waves (through
+// the Model's VertexTransformFunction), rendered using a custom shader that refracts the opaque objects behind the water (see
+// Material.SampleOpaqueColor), and colors the water with foam where it meets the geometry behind it. Call WaterModel.Update() once
+// per frame to animate the water. The WaterModel's shader requires Camera.RenderDepth to be on.
+type WaterModel struct {
+	Model *Model // The Model rendering the water; add this to your Scene.
+
+	WaveHeight    float32 // The height of the water's waves in world units. Defaults to 0.1.
+	WaveLength    float32 // The distance between the crests of the water's waves in world units. Defaults to 4.
+	WaveSpeed     float32 // How fast the water's waves move in world units per second. Defaults to 1.
+	WaveDirection Vector3 // The direction the water's waves travel in (on the X and Z axes). Defaults to {1, 0, 0.6}.
+
+	WaterColor Color // The color of the water's surface; its alpha is how opaque the water is. Defaults to a translucent blue.
+	// RefractionStrength is how far the objects behind the water are offset by its ripples, in pixels. Defaults to 4.
+	RefractionStrength float32
+	// RippleScale is the size of the ripples used to refract the objects behind the water; higher values make for smaller ripples.
+	// Defaults to 0.05.
+	RippleScale float32
+	// FoamColor is the color of the foam where the water meets the geometry behind it; its alpha is how opaque the foam is.
+	// Defaults to white.
+	FoamColor Color
+	// FoamDistance is how far from the geometry behind the water the foam extends, in world units; 0 disables the foam. Defaults to 0.25.
+	FoamDistance float32
+
+	Time float32 // Time is used to animate the water; this is advanced by Update().
+}
+
+// NewWaterModel creates a new WaterModel with the given name and size in world units on the X and Z axes. subdivisions is how
+// many quads make up each side of the water's plane; more subdivisions make for smoother waves.
+func NewWaterModel(name string, width, depth float32, subdivisions int) *WaterModel {
+
+	if subdivisions < 1 {
+		subdivisions = 1
+	}
+
+	mesh := NewPlaneMesh(subdivisions+1, subdivisions+1)
+	mesh.Name = name
+	mesh.Unique = MeshUniqueMeshAndMaterials
+
+	// NewPlaneMesh() creates a plane that's 2x2 units in size
+	mesh.Select().ApplyMatrix(NewMatrix4Scale(width/2, 1, depth/2))
+	mesh.UpdateBounds()
+
+	mat := mesh.MeshParts[0].Material
+	mat.Name = name
+	mat.TransparencyMode = TransparencyModeTransparent
+	mat.SampleOpaqueColor = true
+
+	water := &WaterModel{
+		Model:              NewModel(name, mesh),
+		WaveHeight:         0.1,
+		WaveLength:         4,
+		WaveSpeed:          1,
+		WaveDirection:      Vector3{1, 0, 0.6},
+		WaterColor:         NewColor(0.2, 0.5, 0.8, 0.5),
+		RefractionStrength: 4,
+		RippleScale:        0.05,
+		FoamColor:          NewColor(1, 1, 1, 1),
+		FoamDistance:       0.25,
+	}
+
+	water.init()
+
+	return water
+
+}
+
+// init sets up the WaterModel's Model to render as water.
+func (water *WaterModel) init() {
+
+	if waterShader == nil {
+		shader, err := ExtendBase3DShader(string(waterShaderText))
+		if err != nil {
+			panic(err)
+		}
+		waterShader = shader
+	}
+
+	water.Material().SetShader(waterShader)
+
+	water.Model.VertexTransformFunction = func(vertexPosition *Vector3, vertexIndex int) {
+		vertexPosition.Y += water.WaveOffset(*vertexPosition)
+	}
+
+	water.updateUniforms()
+
+}
+
+// Clone creates a clone of the WaterModel, including its Model.
+func (water *WaterModel) Clone() *WaterModel {
+
+	clone := *water
+	clone.Model = water.Model.Clone().(*Model)
+	clone.init()
+	return &clone
+
+}
+
+// Material returns the Material used to render the WaterModel.
+func (water *WaterModel) Material() *Material {
+	return water.Model.Mesh.MeshParts[0].Material
+}
+
+// Update advances the WaterModel's Time by the given delta time in seconds, animating its waves and ripples.
+func (water *WaterModel) Update(dt float32) {
+	water.Time += dt
+	water.updateUniforms()
+}
+
+// WaveOffset returns how far the water's waves raise (or lower) the water's surface at the given world position at the WaterModel's
+// current Time.
+func (water *WaterModel) WaveOffset(position Vector3) float32 {
+	return water.waveOffset(position, water.Time)
+}
+
+// waveOffset returns how far the water's waves raise (or lower) the water's surface at the given world position at the given time.
+func (water *WaterModel) waveOffset(position Vector3, time float32) float32 {
+
+	if water.WaveHeight == 0 || water.WaveLength <= 0 {
+		return 0
+	}
+
+	dir := Vector3{water.WaveDirection.X, 0, water.WaveDirection.Z}.Unit()
+	if dir.IsZero() {
+		dir = WorldRight
+	}
+
+	frequency := math32.Pi * 2 / water.WaveLength
+	phase := (position.Dot(dir) - time*water.WaveSpeed) * frequency
+
+	// A second, smaller wave crossing the first keeps the waves from looking too regular
+	cross := (position.X*dir.Z - position.Z*dir.X + time*water.WaveSpeed*0.5) * frequency * 1.7
+
+	return (math32.Sin(phase) + math32.Sin(cross)*0.35) * water.WaveHeight / 1.35
+
+}
+
+// HeightAt returns the world height of the water's surface at the given world position, including its waves.
+func (water *WaterModel) HeightAt(position Vector3) float32 {
+	return water.Model.WorldPosition().Y + water.WaveOffset(position)
+}
+
+// NormalAt returns the normal of the water's surface at the given world position, including its waves; this can be used to tilt floating
+// objects (like boats) to follow the waves.
+func (water *WaterModel) NormalAt(position Vector3) Vector3 {
+
+	const step = 0.05
+
+	dx := water.WaveOffset(position.Add(Vector3{step, 0, 0})) - water.WaveOffset(position.Sub(Vector3{step, 0, 0}))
+	dz := water.WaveOffset(position.Add(Vector3{0, 0, step})) - water.WaveOffset(position.Sub(Vector3{0, 0, step}))
+
+	return Vector3{-dx, step * 2, -dz}.Unit()
+
+}
+
+// WaveFunction returns a function that gives the height of the water's waves at the given world X and Z coordinates at the given time,
+// suitable for BuoyancyVolume.WaveFunction; this allows a BuoyancyVolume's surface to match the WaterModel's waves, so that floating
+// objects bob along with the rendered water. For the waves to line up, the BuoyancyVolume's Time should be kept in sync with the
+// WaterModel's (i.e. by updating both with the same delta time), and the top of its box should be at the WaterModel's height.
+func (water *WaterModel) WaveFunction() func(x, z, time float32) float32 {
+	return func(x, z, time float32) float32 {
+		return water.waveOffset(Vector3{x, 0, z}, time)
+	}
+}
+
+// updateUniforms sets the uniforms of the WaterModel's shader.
+func (water *WaterModel) updateUniforms() {
+
+	options := water.Material().FragmentShaderOptions
+
+	if options.Uniforms == nil {
+		options.Uniforms = map[string]any{}
+	}
+
+	waterColor := water.WaterColor.ToFloat32Array()
+	foamColor := water.FoamColor.ToFloat32Array()
+
+	options.Uniforms["Time"] = water.Time
+	options.Uniforms["WaterColor"] = waterColor[:]
+	options.Uniforms["FoamColor"] = foamColor[:]
+	options.Uniforms["FoamDistance"] = water.FoamDistance
+	options.Uniforms["RefractionStrength"] = water.RefractionStrength
+	options.Uniforms["RippleScale"] = water.RippleScale
+
+}
@@ -0,0 +1,134 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// WeldVertices merges the vertices of the Mesh that lie within threshold distance of each other (in the Mesh's local space) into single
+// vertices, and returns how many vertices were removed. This is useful for Meshes imported from exporters that don't share vertices
+// between triangles at all, which wastes memory and makes each triangle shade flat. A threshold of 0 only merges vertices with exactly
+// the same position.
+// If respectSeams is true, vertices are only merged if their UV values and vertex colors also match, so textures and colors don't smear
+// across seams. Merged vertices keep the UV values, colors, and bone weights of the first vertex, and their normals are averaged together;
+// call Mesh.RecalculateNormals() afterwards to recalculate normals entirely (i.e. to keep hard edges sharp). Triangles that collapse as a
+// result of welding are removed.
+// Vertices are only merged with other vertices in the same MeshPart. Note that as the Mesh's vertex buffers are rebuilt, the vertex
+// indices of the Mesh change, and so WeldVertices() should be called before any vertex selections are made.
+func (mesh *Mesh) WeldVertices(threshold float32, respectSeams bool) int {
+
+	if len(mesh.Triangles) == 0 {
+		return 0
+	}
+
+	vertexCount := len(mesh.VertexPositions)
+
+	parts := mesh.partData()
+
+	for _, data := range parts {
+		data.weld(threshold, respectSeams)
+	}
+
+	mesh.rebuildParts(parts)
+
+	return vertexCount - len(mesh.VertexPositions)
+
+}
+
+// weld merges the vertices within threshold distance of each other, and removes any triangles that collapse as a result.
+func (t *meshPartData) weld(threshold float32, respectSeams bool) {
+
+	if threshold < 0 {
+		threshold = 0
+	}
+
+	thresholdSquared := threshold * threshold
+
+	// Vertices are sorted into a grid of cells at least as large as the threshold, so only neighboring cells need to be checked
+	cellSize := math32.Max(threshold, 0.001)
+
+	cellOf := func(v VertexInfo) [3]int {
+		return [3]int{
+			int(math32.Floor(v.X / cellSize)),
+			int(math32.Floor(v.Y / cellSize)),
+			int(math32.Floor(v.Z / cellSize)),
+		}
+	}
+
+	grid := map[[3]int][]int{}
+	remap := make([]int, len(t.verts))
+	verts := make([]VertexInfo, 0, len(t.verts))
+	normals := make([]Vector3, 0, len(t.verts))
+
+	for i, v := range t.verts {
+
+		cell := cellOf(v)
+		pos := Vector3{v.X, v.Y, v.Z}
+		merged := -1
+
+	search:
+		for x := -1; x <= 1; x++ {
+			for y := -1; y <= 1; y++ {
+				for z := -1; z <= 1; z++ {
+					for _, other := range grid[[3]int{cell[0] + x, cell[1] + y, cell[2] + z}] {
+						o := verts[other]
+						if pos.DistanceSquared(Vector3{o.X, o.Y, o.Z}) <= thresholdSquared && (!respectSeams || weldCompatible(o, v)) {
+							merged = other
+							break search
+						}
+					}
+				}
+			}
+		}
+
+		normal := Vector3{v.NormalX, v.NormalY, v.NormalZ}
+
+		if merged < 0 {
+			merged = len(verts)
+			verts = append(verts, v)
+			normals = append(normals, normal)
+			grid[cell] = append(grid[cell], merged)
+		} else {
+			normals[merged] = normals[merged].Add(normal)
+		}
+
+		remap[i] = merged
+
+	}
+
+	for i, normal := range normals {
+		if normal = normal.Unit(); !normal.IsZero() {
+			verts[i].NormalX, verts[i].NormalY, verts[i].NormalZ = normal.X, normal.Y, normal.Z
+		}
+	}
+
+	indices := make([]int, 0, len(t.indices))
+
+	for i := 0; i < len(t.indices); i += 3 {
+		a, b, c := remap[t.indices[i]], remap[t.indices[i+1]], remap[t.indices[i+2]]
+		if a != b && b != c && a != c {
+			indices = append(indices, a, b, c)
+		}
+	}
+
+	t.verts = verts
+	t.indices = indices
+
+}
+
+// weldCompatible returns if the two vertices have matching UV values and vertex colors, and so can be welded without creating a seam.
+func weldCompatible(a, b VertexInfo) bool {
+
+	const epsilon = 0.0001
+
+	if math32.Abs(a.U-b.U) > epsilon || math32.Abs(a.V-b.V) > epsilon || len(a.Colors) != len(b.Colors) {
+		return false
+	}
+
+	for i := range a.Colors {
+		ca, cb := a.Colors[i], b.Colors[i]
+		if math32.Abs(ca.R-cb.R) > epsilon || math32.Abs(ca.G-cb.G) > epsilon || math32.Abs(ca.B-cb.B) > epsilon || math32.Abs(ca.A-cb.A) > epsilon {
+			return false
+		}
+	}
+
+	return true
+
+}
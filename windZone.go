@@ -0,0 +1,224 @@
+package tetra3d
+
+import "github.com/solarlune/tetra3d/math32"
+
+// WindZoneMode indicates the shape of the wind a WindZone blows.
+type WindZoneMode int
+
+const (
+	WindZoneDirectional WindZoneMode = iota // The wind blows along the WindZone's local -Z axis (the direction it faces, like a Camera).
+	WindZoneSpherical                       // The wind blows outwards from the WindZone's center (or inwards, if its Strength is negative).
+)
+
+// WindZone is a Node that blows wind through the area around it. The wind of all of the WindZones in a Scene can be queried
+// at any world position through Scene.WindAt(), and is automatically applied to the particles of ParticleSystems (see
+// ParticleSystemSettings.WindInfluence), to Ropes (see Rope.WindInfluence), and to Models swaying in the wind (see Model.SetWindSway()),
+// so that environmental motion is consistent across the Scene.
+type WindZone struct {
+	*Node
+	On       bool         // Whether the WindZone blows wind or not. Defaults to true.
+	Mode     WindZoneMode // The shape of the wind. Defaults to WindZoneDirectional.
+	Strength float32      // The speed of the wind in world units per second. Defaults to 1.
+
+	// Radius is how far from the WindZone the wind reaches. For spherical WindZones, 0 or less means the wind has no effect; for directional
+	// WindZones, 0 or less means the wind blows everywhere. Defaults to 0.
+	Radius float32
+
+	// Falloff is how quickly the wind weakens towards the edge of the Radius; 0 means there's no falloff, 1 means the wind weakens linearly,
+	// 2 means it weakens quadratically, and so on. Defaults to 1.
+	Falloff float32
+
+	// Gustiness is how much the wind's strength varies over time and space, ranging from 0 (a steady breeze) to 1 (gusts that
+	// range from stillness to double the Strength). Defaults to 0.
+	Gustiness float32
+	// GustFrequency is how often gusts blow by, in gusts per second. Defaults to 0.5.
+	GustFrequency float32
+	// Time is used to animate the wind's gusts; this is advanced by Update().
+	Time float32
+}
+
+// NewWindZone creates a new WindZone with the given name and mode.
+func NewWindZone(name string, mode WindZoneMode) *WindZone {
+	zone := &WindZone{
+		Node:          NewNode(name),
+		On:            true,
+		Mode:          mode,
+		Strength:      1,
+		Falloff:       1,
+		GustFrequency: 0.5,
+	}
+	zone.owner = zone
+	return zone
+}
+
+// Clone creates a clone of the WindZone and its children.
+func (zone *WindZone) Clone() INode {
+
+	clone := NewWindZone(zone.name, zone.Mode)
+	clone.On = zone.On
+	clone.Strength = zone.Strength
+	clone.Radius = zone.Radius
+	clone.Falloff = zone.Falloff
+	clone.Gustiness = zone.Gustiness
+	clone.GustFrequency = zone.GustFrequency
+	clone.Time = zone.Time
+
+	clone.Node = zone.Node.clone(clone).(*Node)
+
+	if clone.Callbacks() != nil && clone.Callbacks().OnClone != nil {
+		clone.Callbacks().OnClone(clone)
+	}
+
+	return clone
+
+}
+
+// Update advances the WindZone's Time by the given delta time in seconds, animating its gusts.
+func (zone *WindZone) Update(dt float32) {
+	zone.Time += dt
+}
+
+// WindAt returns the velocity of the WindZone's wind at the given world position, in world units per second.
+func (zone *WindZone) WindAt(position Vector3) Vector3 {
+
+	if !zone.On {
+		return Vector3{}
+	}
+
+	diff := position.Sub(zone.WorldPosition())
+	dist := diff.Magnitude()
+
+	strength := zone.Strength
+
+	if zone.Radius > 0 {
+
+		if dist > zone.Radius {
+			return Vector3{}
+		}
+
+		if zone.Falloff > 0 {
+			strength *= math32.Pow(1-dist/zone.Radius, zone.Falloff)
+		}
+
+	} else if zone.Mode == WindZoneSpherical {
+		return Vector3{}
+	}
+
+	if zone.Gustiness > 0 {
+		// Gusts travel along with the wind, so they're offset by the distance along it
+		phase := (zone.Time*zone.GustFrequency - dist*0.1) * math32.Pi * 2
+		gust := (math32.Sin(phase) + math32.Sin(phase*0.37+1.3)) / 2
+		strength *= 1 + gust*math32.Clamp(zone.Gustiness, 0, 1)
+	}
+
+	var dir Vector3
+
+	switch zone.Mode {
+	case WindZoneDirectional:
+		dir = zone.WorldRotation().Forward().Invert()
+	case WindZoneSpherical:
+		if dist > 0 {
+			dir = diff.Divide(dist)
+		}
+	}
+
+	return dir.Scale(strength)
+
+}
+
+// Type returns the NodeType for this object.
+func (zone *WindZone) Type() NodeType {
+	return NodeTypeWindZone
+}
+
+// windAt returns the combined wind velocity of the given WindZones at the given world position.
+func windAt(zones []*WindZone, position Vector3) Vector3 {
+	wind := Vector3{}
+	for _, zone := range zones {
+		wind = wind.Add(zone.WindAt(position))
+	}
+	return wind
+}
+
+// sceneWindZones returns the WindZones in the given Node's Scene, reusing the slice given.
+func sceneWindZones(node INode, zones []*WindZone) []*WindZone {
+
+	zones = zones[:0]
+
+	if scene := node.Scene(); scene != nil {
+		scene.Root.SearchTree().ForEach(func(node INode) bool {
+			if zone, ok := node.(*WindZone); ok {
+				zones = append(zones, zone)
+			}
+			return true
+		})
+	}
+
+	return zones
+
+}
+
+// WindSway makes a Model's vertices sway in the wind blowing through its Scene (see WindZone), which is useful for foliage like grass,
+// bushes, and trees. Vertices are pushed along with the wind further the higher they are above the Model's origin, so the base of the
+// Model stays in place. A WindSway is set on a Model through Model.SetWindSway(). Note that WindSways don't affect armature-skinned or
+// curve-deformed Models.
+type WindSway struct {
+	// Amount is how far the Model's vertices move in world units per unit of wind speed, for each world unit they are above the
+	// Model's origin.
+	Amount float32
+
+	localSway  Vector3
+	localScale float32
+}
+
+// SetWindSway sets the Model to sway in the wind by the given amount (see WindSway.Amount), returning the WindSway so that it can
+// be customized further. An amount of 0 clears the Model's WindSway.
+func (model *Model) SetWindSway(amount float32) *WindSway {
+
+	if amount == 0 {
+		model.windSway = nil
+		return nil
+	}
+
+	model.windSway = &WindSway{
+		Amount: amount,
+	}
+
+	return model.windSway
+
+}
+
+// WindSway returns the WindSway making the Model sway in the wind, or nil if the Model doesn't sway.
+func (model *Model) WindSway() *WindSway {
+	return model.windSway
+}
+
+// Clone returns a clone of the WindSway.
+func (sway *WindSway) Clone() *WindSway {
+	return &WindSway{
+		Amount: sway.Amount,
+	}
+}
+
+// update converts the wind blowing at the Model's position into the sway of the Model's vertices in its local space.
+func (sway *WindSway) update(model *Model, zones []*WindZone) {
+
+	wind := windAt(zones, model.WorldPosition())
+
+	transform := model.Transform()
+	_, scale, rotation := transform.Decompose()
+
+	// Sway is measured in world units, so the offset and height are both scaled back to the Model's local space
+	local := rotation.Transposed().MultVec(wind.Scale(sway.Amount))
+	sway.localSway = Vector3{local.X / scale.X, local.Y / scale.Y, local.Z / scale.Z}
+	sway.localScale = scale.Y
+
+}
+
+// offset returns the local offset of a vertex at the given local position.
+func (sway *WindSway) offset(vert Vector3) Vector3 {
+	if vert.Y <= 0 {
+		return Vector3{}
+	}
+	return sway.localSway.Scale(vert.Y * sway.localScale)
+}